@@ -5,14 +5,32 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"distributed-llm/internal/agent"
+	"distributed-llm/internal/alert"
+	"distributed-llm/internal/compress"
+	"distributed-llm/internal/events"
+	"distributed-llm/internal/k8s"
 	"distributed-llm/internal/network"
+	"distributed-llm/internal/planner"
+	"distributed-llm/internal/quota"
+	"distributed-llm/internal/router"
+	"distributed-llm/internal/state"
+	"distributed-llm/internal/tensor"
+	"distributed-llm/internal/usage"
+	"distributed-llm/pkg/config"
+	"distributed-llm/pkg/health"
 	"distributed-llm/pkg/metrics"
+	"distributed-llm/pkg/models"
+	"distributed-llm/pkg/tracing"
 )
 
 func main() {
@@ -22,9 +40,117 @@ func main() {
 		gossipPort  = flag.Int("gossip-port", 7946, "Port for memberlist gossip")
 		metricsPort = flag.Int("metrics-port", 9090, "Port for Prometheus metrics")
 		seedNodes   = flag.String("seed-nodes", "", "Comma-separated list of seed nodes (host:port)")
+		seedDNS     = flag.String("seed-dns", "", "Comma-separated DNS names whose records are seed nodes (name[:port] for A/AAAA, _service._proto.name for SRV)")
+		seedFile    = flag.String("seed-file", "", "File listing seed nodes, one host:port per line, re-read when it changes")
+		seedRefresh = flag.Duration("seed-refresh", network.DefaultSeedRefreshInterval, "How often seed providers are re-resolved")
+		probeEvery  = flag.Duration("probe-interval", network.DefaultProbeInterval, "How often to measure RTT and bandwidth to peers over gRPC")
+		configPath  = flag.String("config", "", "Path to JSON configuration file")
+		dataPath    = flag.String("data-path", "", "Directory for persistent cluster state (overrides config)")
+		joinAddrs   = flag.String("join-addrs", "", "Comma-separated gRPC addresses of agents to register with when gossip is unreachable")
+		clusterID   = flag.String("cluster-id", "", "Cluster ID that joining nodes must match (overrides config)")
+		joinToken   = flag.String("join-token", "", "Shared secret for joining the cluster over gRPC (overrides config)")
+		backend     = flag.String("backend", agent.DefaultBackend, "Inference backend executable")
+		reflection  = flag.Bool("grpc-reflection", false, "Enable gRPC server reflection (for grpcurl)")
+		callTimeout = flag.Duration("grpc-default-timeout", network.DefaultCallTimeout, "Deadline for unary gRPC calls that arrive without one (0 disables)")
+		lanDiscover = flag.Bool("lan-discovery", false, "Announce this agent and find peers on the local network via UDP multicast")
+		lanGroup    = flag.String("discovery-group", network.DefaultDiscoveryGroup, "UDP multicast group for LAN discovery (host:port)")
+		k8sDiscover = flag.Bool("k8s-discovery", false, "Find peers by watching agent pods through the Kubernetes API")
+		k8sNS       = flag.String("k8s-namespace", "", "Namespace to watch for agent pods (overrides config)")
+		k8sSelector = flag.String("k8s-selector", k8s.DefaultAgentSelector, "Label selector for agent pods, or EndpointSlices with --k8s-endpointslices")
+		k8sSlices   = flag.Bool("k8s-endpointslices", false, "Watch EndpointSlices instead of pods for Kubernetes discovery")
+		k8sNode     = flag.String("k8s-node", os.Getenv("NODE_NAME"), "Kubernetes node to read zone, host and distributed-llm.io/ labels from")
+		zone        = flag.String("zone", "", "Zone this node runs in (overrides labels)")
+		rack        = flag.String("rack", "", "Rack this node runs in (overrides labels)")
+		labels      = flag.String("labels", "", "Comma-separated key=value labels advertised to peers (overrides config)")
+		routePolicy = flag.String("routing-policy", "", "How requests are spread across model replicas: least-outstanding or p2c (overrides config)")
+		autoscale   = flag.Bool("autoscale", false, "Scale model replicas by queue depth (or routing.autoscale.enabled in config)")
+		actCompress = flag.String("activation-compression", "", "Compression for activations sent to pipeline stages: none, lz4, zstd or gzip (overrides config)")
+		actQuantize = flag.String("activation-quantization", "", "Quantization for activations sent to pipeline stages: none, fp16 or int8 (overrides config)")
+		maxInfer    = flag.Int("max-concurrent-inference", 0, "Requests run at once by this node; others wait for a slot (0 for no limit)")
+		otlpAddr    = flag.String("otlp-endpoint", "", "OTLP/gRPC collector (host:port) to export traces to (overrides config)")
+		otlpPlain   = flag.Bool("otlp-insecure", false, "Export traces without TLS (or tracing.insecure in config)")
+		sampleRatio = flag.Float64("trace-sample-ratio", -1, "Fraction of new traces recorded (overrides config)")
+		alertHook   = flag.String("alert-webhook", "", "URL to POST alerts to when they start firing or resolve (overrides config)")
 	)
 	flag.Parse()
 
+	cfg := config.Default()
+	if *configPath != "" {
+		loaded, err := config.LoadConfig(*configPath)
+		if err != nil {
+			slog.Error("Failed to load config", "path", *configPath, "error", err)
+			os.Exit(1)
+		}
+		cfg = loaded
+	}
+	if *dataPath != "" {
+		cfg.DataPath = *dataPath
+	}
+	if *clusterID != "" {
+		cfg.ClusterID = *clusterID
+	}
+	if *joinToken != "" {
+		cfg.JoinToken = *joinToken
+	}
+	if *k8sNS != "" {
+		cfg.KubernetesNamespace = *k8sNS
+	}
+	flagLabels, err := config.ParseLabels(*labels)
+	if err != nil {
+		slog.Error("Invalid --labels", "error", err)
+		os.Exit(1)
+	}
+	if *zone != "" {
+		flagLabels[models.LabelZone] = *zone
+	}
+	if *rack != "" {
+		flagLabels[models.LabelRack] = *rack
+	}
+	if *routePolicy != "" {
+		cfg.Routing.Policy = *routePolicy
+	}
+	if *autoscale {
+		cfg.Routing.Autoscale.Enabled = true
+	}
+	policy, err := router.ParsePolicy(cfg.Routing.Policy)
+	if err != nil {
+		slog.Error("Invalid routing policy", "error", err)
+		os.Exit(1)
+	}
+	if *actCompress != "" {
+		cfg.Activations.Compression = *actCompress
+	}
+	if *actQuantize != "" {
+		cfg.Activations.Quantization = *actQuantize
+	}
+	activationCompression, err := compress.Parse(cfg.Activations.Compression)
+	if err != nil {
+		slog.Error("Invalid activation compression", "error", err)
+		os.Exit(1)
+	}
+	activationDType, err := tensor.ParseDType(cfg.Activations.Quantization)
+	if err != nil {
+		slog.Error("Invalid activation quantization", "error", err)
+		os.Exit(1)
+	}
+	apiKeys, err := tenantKeys(cfg.Tenants)
+	if err != nil {
+		slog.Error("Invalid tenants", "error", err)
+		os.Exit(1)
+	}
+	if *otlpAddr != "" {
+		cfg.Tracing.Endpoint = *otlpAddr
+	}
+	if *otlpPlain {
+		cfg.Tracing.Insecure = true
+	}
+	if *sampleRatio >= 0 {
+		cfg.Tracing.SampleRatio = *sampleRatio
+	}
+	if *alertHook != "" {
+		cfg.Alerts.WebhookURL = *alertHook
+	}
+
 	if *nodeID == "" {
 		hostname, err := os.Hostname()
 		if err != nil {
@@ -35,7 +161,8 @@ func main() {
 	}
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	logger.Info("Starting distributed LLM agent", "nodeID", *nodeID)
+	version := strings.TrimSpace(Version)
+	logger.Info("Starting distributed LLM agent", "nodeID", *nodeID, "version", version)
 
 	// Initialize metrics collector
 	metricsCollector := metrics.NewMetricsCollector(*nodeID, *metricsPort)
@@ -43,6 +170,12 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Subsystems register liveness and readiness checks served on the metrics
+	// port. Those registered while starting fail until then, so that the node
+	// isn't ready before it can serve.
+	healthChecks := metricsCollector.HealthRegistry()
+	healthChecks.Pending("models", "memberlist", "grpc")
+
 	// Start metrics collection
 	if err := metricsCollector.Start(ctx); err != nil {
 		logger.Error("Failed to start metrics collector", "error", err)
@@ -50,14 +183,20 @@ func main() {
 	}
 	logger.Info("Metrics server started", "port", *metricsPort)
 
-	// Parse seed nodes
-	var seeds []string
-	if *seedNodes != "" {
-		seeds = strings.Split(*seedNodes, ",")
-		for i, seed := range seeds {
-			seeds[i] = strings.TrimSpace(seed)
+	// Seed providers are resolved before joining and re-resolved while running
+	seedProviders := network.SeedProviders{network.StaticSeeds(splitList(*seedNodes))}
+	for _, name := range splitList(*seedDNS) {
+		provider, err := network.ParseDNSSeeds(name, *gossipPort)
+		if err != nil {
+			logger.Error("Invalid --seed-dns", "error", err)
+			os.Exit(1)
 		}
+		seedProviders = append(seedProviders, provider)
+	}
+	if *seedFile != "" {
+		seedProviders = append(seedProviders, network.NewFileSeeds(*seedFile))
 	}
+	joinAddresses := splitList(*joinAddrs)
 
 	// Create P2P network with metrics
 	p2pNetwork, err := network.NewP2PNetwork(*nodeID, *bindPort, *gossipPort)
@@ -66,12 +205,77 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Record what happens in the cluster for StreamEvents
+	eventBus := events.NewBus(events.DefaultRetention)
+
 	// Set metrics collector in network (we'll add this method)
 	p2pNetwork.SetMetricsCollector(metricsCollector)
+	p2pNetwork.SetEventPublisher(eventBus)
+	p2pNetwork.SetClusterID(cfg.ClusterID)
+	p2pNetwork.SetJoinToken(cfg.JoinToken)
+	p2pNetwork.SetVersion(version)
+	p2pNetwork.SetLabels(nodeLabels(ctx, logger, *k8sNode, cfg.Labels, flagLabels))
+	p2pNetwork.SetActivationEncoding(activationCompression, activationDType)
+
+	// Export spans of the requests passing through this node
+	if cfg.Tracing.Endpoint != "" {
+		tracerProvider, err := tracing.NewProvider(ctx, tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+			NodeID:      *nodeID,
+			Version:     version,
+		})
+		if err != nil {
+			logger.Error("Failed to set up tracing", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			tracerProvider.Shutdown(shutdownCtx)
+		}()
+		p2pNetwork.SetTracerProvider(tracerProvider)
+		logger.Info("Exporting traces", "endpoint", cfg.Tracing.Endpoint, "sampleRatio", cfg.Tracing.SampleRatio)
+	}
+
+	// Restore replicated cluster state before joining so peers see our latest index
+	stateStore, err := state.NewStore(state.Config{
+		NodeID:  *nodeID,
+		DataDir: filepath.Join(cfg.DataPath, "state"),
+	}, p2pNetwork.Transport(network.ChannelState))
+	if err != nil {
+		logger.Warn("Failed to open persistent cluster state, keeping it in memory", "dataPath", cfg.DataPath, "error", err)
+		stateStore, _ = state.NewStore(state.Config{NodeID: *nodeID}, p2pNetwork.Transport(network.ChannelState))
+	}
+	stateStore.SetEventPublisher(eventBus)
+	p2pNetwork.RegisterChannel(network.ChannelState, stateStore)
+	if err := stateStore.Start(ctx); err != nil {
+		logger.Error("Failed to start cluster state", "error", err)
+		os.Exit(1)
+	}
+
+	// Spread requests across model replicas, sharing queue depths for autoscaling
+	requestRouter := router.New(*nodeID, policy)
+	requestRouter.SetHealthSource(p2pNetwork)
+	requestRouter.SetMetrics(metricsCollector)
+	p2pNetwork.RegisterChannel(network.ChannelLoad, requestRouter)
+	var autoscaler *router.Autoscaler
+	if as := cfg.Routing.Autoscale; as.Enabled {
+		autoscaler = router.NewAutoscaler(router.AutoscaleConfig{
+			MinReplicas:      as.MinReplicas,
+			MaxReplicas:      as.MaxReplicas,
+			TargetQueueDepth: as.TargetQueueDepth,
+			ScaleDownDelay:   time.Duration(as.ScaleDownDelaySeconds) * time.Second,
+		})
+	}
 
 	// Create and configure broadcaster
 	broadcaster := agent.NewBroadcaster()
 	broadcaster.SetMetricsCollector(metricsCollector)
+	broadcaster.SetHeartbeat(healthChecks.Heartbeat("resource-broadcast", 3*agent.BroadcastInterval))
+	broadcaster.UpdateResources(agent.GetResourceInfo())
+	p2pNetwork.SetLocalResources(broadcaster.GetResources())
 
 	// Start broadcaster
 	if err := broadcaster.Start(ctx); err != nil {
@@ -80,11 +284,88 @@ func main() {
 	}
 	logger.Info("Broadcaster started")
 
+	// Track backend and model readiness for health checks
+	modelManager := agent.NewModelManager(*backend, cfg.ModelPath)
+	modelManager.SetEventPublisher(eventBus, *nodeID)
+	if err := modelManager.CheckBackend(); err != nil {
+		logger.Warn("Inference backend not found, node will report not ready", "backend", *backend, "error", err)
+	}
+	healthChecks.Register("models", health.Readiness, health.CheckerFunc(modelManager.CheckLoaded))
+	go reconcileModels(ctx, modelManager, stateStore, *nodeID, healthChecks.Heartbeat("model-reconcile", 3*modelReconcileInterval))
+
+	// Find peers on the LAN and use them as extra seeds
+	var seeds []string
+	var lanDiscovery *network.Discovery
+	if *lanDiscover {
+		lanDiscovery = network.NewDiscovery(network.DefaultDiscoveryInterval)
+		lanDiscovery.SetGroup(*lanGroup)
+		lanDiscovery.SetAnnouncement(network.Announcement{
+			NodeID:     *nodeID,
+			ClusterID:  cfg.ClusterID,
+			Version:    version,
+			GRPCPort:   *bindPort,
+			GossipPort: *gossipPort,
+		})
+		if err := lanDiscovery.Start(); err != nil {
+			logger.Warn("LAN discovery unavailable", "group", *lanGroup, "error", err)
+			lanDiscovery = nil
+		} else {
+			waitCtx, waitCancel := context.WithTimeout(ctx, lanDiscoveryWait)
+			seeds = append(seeds, lanDiscovery.WaitForPeers(waitCtx)...)
+			waitCancel()
+		}
+	}
+
+	// Watch the Kubernetes API for agent pods and use the ready ones as seeds
+	var k8sDiscovery *k8s.PeerDiscovery
+	if *k8sDiscover {
+		k8sDiscovery, err = startK8sDiscovery(ctx, cfg.KubernetesNamespace, *k8sSelector, *k8sSlices, *gossipPort)
+		if err != nil {
+			logger.Warn("Kubernetes discovery unavailable", "namespace", cfg.KubernetesNamespace, "error", err)
+		} else {
+			seedProviders = append(seedProviders, k8s.PodSeeds{Discovery: k8sDiscovery})
+		}
+	}
+
+	resolveCtx, resolveCancel := context.WithTimeout(ctx, seedResolveTimeout)
+	resolved, err := seedProviders.Seeds(resolveCtx)
+	resolveCancel()
+	if err != nil {
+		logger.Warn("Failed to resolve some seed nodes", "error", err)
+	}
+	seeds = append(seeds, resolved...)
+
 	// Start the network
 	if err := p2pNetwork.Start(seeds); err != nil {
 		logger.Error("Failed to start P2P network", "error", err)
 		os.Exit(1)
 	}
+	if k8sDiscovery != nil {
+		k8sDiscovery.SetPeerHandler(&k8sPeerHandler{network: p2pNetwork, logger: logger})
+	}
+	if lanDiscovery != nil {
+		lanDiscovery.SetPeerHandler(func(peer network.Peer) {
+			go func() {
+				if err := p2pNetwork.Join([]string{peer.GossipAddr()}); err != nil {
+					logger.Warn("Failed to join LAN peer", "nodeID", peer.NodeID, "address", peer.GossipAddr(), "error", err)
+				}
+			}()
+		})
+	}
+	healthChecks.Register("memberlist", health.Readiness, health.CheckerFunc(p2pNetwork.CheckJoined))
+	go p2pNetwork.ProbeLatency(ctx, *probeEvery)
+	go network.WatchSeeds(ctx, seedProviders, *seedRefresh, func(current, added []string) {
+		// Join seeds as they appear, and all of them again if we are alone
+		if len(p2pNetwork.GetMembers()) < 2 {
+			added = current
+		}
+		if len(added) == 0 {
+			return
+		}
+		if err := p2pNetwork.Join(added); err != nil {
+			logger.Debug("Failed to join seed nodes", "seeds", added, "error", err)
+		}
+	})
 
 	// Start gRPC server with compression support
 	grpcServer, err := network.NewGRPCServer(p2pNetwork, *bindPort)
@@ -92,6 +373,59 @@ func main() {
 		logger.Error("Failed to create gRPC server", "error", err)
 		os.Exit(1)
 	}
+	grpcServer.SetDefaultTimeout(*callTimeout)
+	grpcServer.SetMaxConcurrentInference(*maxInfer)
+
+	// Record the usage of requests entering the cluster through this node
+	usageLedger, err := usage.Open(usage.Config{
+		Dir:          filepath.Join(cfg.DataPath, "usage"),
+		MaxFileBytes: int64(cfg.Usage.MaxFileMB) << 20,
+		MaxFiles:     cfg.Usage.MaxFiles,
+	})
+	if err != nil {
+		logger.Warn("Failed to open usage ledger, usage will not be recorded", "dataPath", cfg.DataPath, "error", err)
+	} else {
+		defer usageLedger.Close()
+		grpcServer.SetUsageLedger(usageLedger)
+	}
+	grpcServer.SetStateStore(stateStore)
+	grpcServer.SetMetricsHistory(metricsCollector.History())
+	grpcServer.SetEventBus(eventBus)
+
+	// Limit tenants by their quotas, counting their requests on every node
+	quotaLimiter := quota.New(*nodeID)
+	p2pNetwork.RegisterChannel(network.ChannelQuota, quotaLimiter)
+	grpcServer.SetTenantKeys(apiKeys)
+	grpcServer.SetQuotaLimiter(quotaLimiter)
+	go shareQuotas(ctx, stateStore, p2pNetwork, quotaLimiter, tenantQuotas(cfg.Tenants))
+
+	// Evaluate the alert rules against this node's metrics, sharing the
+	// alerts with every node
+	alerts, err := alert.NewEvaluator(*nodeID, alertRules(cfg.Alerts.Rules))
+	if err != nil {
+		logger.Error("Invalid alert rules", "error", err)
+		os.Exit(1)
+	}
+	p2pNetwork.RegisterChannel(network.ChannelAlert, alerts)
+	grpcServer.SetAlertSource(alerts)
+	var alertWebhook *alert.Webhook
+	if cfg.Alerts.WebhookURL != "" {
+		alertWebhook = alert.NewWebhook(cfg.Alerts.WebhookURL)
+	}
+	go evaluateAlerts(ctx, logger, metricsCollector.History(), p2pNetwork, alerts, alertWebhook, eventBus)
+
+	grpcServer.SetPlacementConstraints(planner.Constraints{
+		SameZone:    cfg.Placement.SameZone,
+		SpreadZones: cfg.Placement.SpreadZones,
+		SpreadHosts: cfg.Placement.SpreadHosts,
+	})
+	healthChecks.Register("grpc", health.Readiness, health.CheckerFunc(grpcServer.CheckServing))
+	grpcServer.SetReadinessSource(modelManager)
+	grpcServer.SetRouter(requestRouter)
+	go reconcileReplicas(ctx, logger, stateStore, grpcServer, p2pNetwork, requestRouter, autoscaler)
+	if *reflection {
+		grpcServer.EnableReflection()
+	}
 
 	// Start gRPC server in background
 	go func() {
@@ -100,14 +434,24 @@ func main() {
 		}
 	}()
 
+	// Register over gRPC as well, so we stay in the cluster view even if gossip is blocked
+	if len(joinAddresses) > 0 {
+		if err := p2pNetwork.JoinViaGRPC(ctx, joinAddresses); err != nil {
+			logger.Warn("Failed to join cluster over gRPC", "addresses", joinAddresses, "error", err)
+		}
+		go p2pNetwork.KeepJoined(ctx, joinAddresses, network.JoinHeartbeatInterval)
+	}
+
 	logger.Info("Agent started successfully")
 	logger.Info("Node ID", "nodeID", *nodeID)
 	logger.Info("gRPC server with compression listening", "port", *bindPort)
 	logger.Info("Memberlist gossip", "port", *gossipPort)
 	logger.Info("Metrics endpoint", "url", fmt.Sprintf("http://localhost:%d/metrics", *metricsPort))
+	logger.Info("Health endpoints", "url", fmt.Sprintf("http://localhost:%d/healthz/verbose", *metricsPort))
 	if len(seeds) > 0 {
 		logger.Info("Seed nodes", "seeds", seeds)
 	}
+	logger.Info("Cluster", "clusterID", p2pNetwork.ClusterID())
 
 	// Wait for interrupt signal
 	c := make(chan os.Signal, 1)
@@ -116,6 +460,16 @@ func main() {
 	<-c
 	logger.Info("Shutting down agent...")
 
+	// Fail readiness first so traffic moves to other nodes
+	healthChecks.Drain()
+
+	if lanDiscovery != nil {
+		lanDiscovery.Stop()
+	}
+
+	// End event streams so the gRPC server can stop gracefully
+	eventBus.Close()
+
 	// Stop gRPC server
 	grpcServer.Stop()
 	logger.Info("gRPC server stopped")
@@ -124,8 +478,14 @@ func main() {
 	p2pNetwork.Stop()
 	logger.Info("P2P network stopped")
 
-	// Stop broadcaster
-	// broadcaster doesn't have a Stop method in our implementation
+	// Snapshot cluster state so a restart resumes from here
+	if err := stateStore.Close(); err != nil {
+		logger.Error("Error saving cluster state", "error", err)
+	}
+
+	// Stop broadcaster, closing its subscriptions
+	broadcaster.Stop()
+	logger.Info("Broadcaster stopped", "droppedUpdates", broadcaster.Dropped())
 
 	// Stop metrics collector
 	if err := metricsCollector.Stop(); err != nil {
@@ -134,3 +494,281 @@ func main() {
 
 	logger.Info("Agent shutdown complete")
 }
+
+// splitList parses a comma-separated flag value, ignoring empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// nodeLabels merges the labels of the Kubernetes node we run on, if any, with
+// those from the config file and then the flags
+func nodeLabels(ctx context.Context, logger *slog.Logger, k8sNode string, configured, flags map[string]string) map[string]string {
+	labels := make(map[string]string)
+	if k8sNode != "" {
+		client, err := k8s.NewKubernetesClient()
+		if err == nil {
+			var nodeLabels map[string]string
+			nodeLabels, err = k8s.NodeLabels(ctx, client.GetClientset(), k8sNode)
+			maps.Copy(labels, nodeLabels)
+		}
+		if err != nil {
+			logger.Warn("Failed to read Kubernetes node labels", "node", k8sNode, "error", err)
+		}
+	}
+	maps.Copy(labels, configured)
+	maps.Copy(labels, flags)
+	return labels
+}
+
+// startK8sDiscovery watches agent pods in namespace until ctx is done
+func startK8sDiscovery(ctx context.Context, namespace, selector string, endpointSlices bool, gossipPort int) (*k8s.PeerDiscovery, error) {
+	client, err := k8s.NewKubernetesClient()
+	if err != nil {
+		return nil, err
+	}
+
+	discovery, err := k8s.NewPeerDiscovery(client.GetClientset(), k8s.DiscoveryConfig{
+		Namespace:         namespace,
+		LabelSelector:     selector,
+		GossipPort:        gossipPort,
+		UseEndpointSlices: endpointSlices,
+		SelfAddress:       os.Getenv("POD_IP"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := discovery.Start(ctx); err != nil {
+		return nil, err
+	}
+	return discovery, nil
+}
+
+// k8sPeerHandler joins agent pods into memberlist as they become ready and
+// evicts them as soon as Kubernetes reports them gone
+type k8sPeerHandler struct {
+	network *network.P2PNetwork
+	logger  *slog.Logger
+}
+
+func (h *k8sPeerHandler) PeerReady(peer k8s.Peer) {
+	go func() {
+		if err := h.network.Join([]string{peer.GossipAddr()}); err != nil {
+			h.logger.Warn("Failed to join agent pod", "pod", peer.Name, "address", peer.GossipAddr(), "error", err)
+		}
+	}()
+}
+
+func (h *k8sPeerHandler) PeerGone(peer k8s.Peer) {
+	h.network.Evict(peer.Address)
+}
+
+// seedResolveTimeout bounds resolving seed providers before joining the cluster
+const seedResolveTimeout = 10 * time.Second
+
+// lanDiscoveryWait is how long to listen for LAN peers before joining the cluster
+const lanDiscoveryWait = 2 * time.Second
+
+// modelReconcileInterval is how often assigned models are checked
+const modelReconcileInterval = 10 * time.Second
+
+// replicaReconcileInterval is how often the router learns the replicas from the
+// cluster state and the leader reconciles replica counts
+const replicaReconcileInterval = 5 * time.Second
+
+// reconcileReplicas keeps the router in line with the placement plans and
+// shares this node's queue depths. On the state leader it also adds or
+// removes replicas to match each placed model's replica count, or the
+// autoscaler's when autoscaling.
+func reconcileReplicas(ctx context.Context, logger *slog.Logger, store *state.Store, server *network.GRPCServer, p2p *network.P2PNetwork, requestRouter *router.Router, autoscaler *router.Autoscaler) {
+	ticker := time.NewTicker(replicaReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		st := store.Snapshot()
+		plans := make([]models.PlacementPlan, 0, len(st.Plans))
+		for _, plan := range st.Plans {
+			plans = append(plans, plan)
+		}
+		requestRouter.Update(plans)
+		p2p.Broadcast(network.ChannelLoad, requestRouter.LocalReport())
+
+		if store.IsLeader() {
+			for modelID, model := range st.Models {
+				current := len(st.Replicas(modelID))
+				if current == 0 {
+					continue // Not placed yet
+				}
+				desired := model.DesiredReplicas()
+				if autoscaler != nil {
+					desired = autoscaler.Desired(modelID, current, requestRouter.QueueDepth(modelID))
+				}
+				if desired == current {
+					continue
+				}
+				logger.Info("Scaling model replicas", "model", modelID, "from", current, "to", desired)
+				if err := server.ScaleModel(ctx, modelID, desired); err != nil {
+					logger.Warn("Failed to scale model replicas", "model", modelID, "replicas", desired, "error", err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// quotaReportInterval is how often tenant quotas are refreshed from the
+// cluster state and this node's usage of them is gossiped
+const quotaReportInterval = time.Second
+
+// tenantKeys maps the API keys of the tenants in the config to their tenant
+func tenantKeys(tenants map[string]config.Tenant) (map[string]string, error) {
+	keys := make(map[string]string)
+	for name, tenant := range tenants {
+		for _, key := range tenant.APIKeys {
+			if name == quota.AnyTenant {
+				return nil, fmt.Errorf("tenant %q cannot have API keys", name)
+			}
+			if key == "" {
+				return nil, fmt.Errorf("tenant %s has an empty API key", name)
+			}
+			if other, ok := keys[key]; ok && other != name {
+				return nil, fmt.Errorf("tenants %s and %s share an API key", other, name)
+			}
+			keys[key] = name
+		}
+	}
+	return keys, nil
+}
+
+// tenantQuotas converts the tenants from the config
+func tenantQuotas(tenants map[string]config.Tenant) map[string]models.Quota {
+	quotas := make(map[string]models.Quota, len(tenants))
+	for name, tenant := range tenants {
+		quotas[name] = models.Quota{
+			RequestsPerSecond: tenant.RequestsPerSecond,
+			TokensPerMinute:   tenant.TokensPerMinute,
+			MaxConcurrent:     tenant.MaxConcurrent,
+			Models:            tenant.Models,
+		}
+	}
+	return quotas
+}
+
+// shareQuotas applies the configured quotas, overridden by those in the
+// cluster state, and gossips this node's usage so that every node enforces
+// them cluster-wide
+func shareQuotas(ctx context.Context, store *state.Store, p2p *network.P2PNetwork, limiter *quota.Limiter, configured map[string]models.Quota) {
+	ticker := time.NewTicker(quotaReportInterval)
+	defer ticker.Stop()
+
+	for {
+		quotas := maps.Clone(configured)
+		maps.Copy(quotas, store.Snapshot().Quotas)
+		limiter.SetQuotas(quotas)
+		p2p.Broadcast(network.ChannelQuota, limiter.LocalReport())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// alertRules converts the alert rules from the config
+func alertRules(configured []config.AlertRule) []alert.Rule {
+	rules := make([]alert.Rule, len(configured))
+	for i, rule := range configured {
+		rules[i] = alert.Rule{
+			Name:     rule.Name,
+			Expr:     rule.Expr,
+			For:      time.Duration(rule.ForSeconds) * time.Second,
+			Severity: rule.Severity,
+			Summary:  rule.Summary,
+		}
+	}
+	return rules
+}
+
+// evaluateAlerts checks each new snapshot of this node's metrics against
+// the alert rules, publishing the alerts that fire or resolve as events and
+// posting them to the webhook, if any, and gossips this node's active alerts
+func evaluateAlerts(ctx context.Context, logger *slog.Logger, history *metrics.History, p2p *network.P2PNetwork, evaluator *alert.Evaluator, webhook *alert.Webhook, bus *events.Bus) {
+	ticker := time.NewTicker(history.Resolution())
+	defer ticker.Stop()
+
+	var evaluated time.Time
+	for {
+		if snapshot, ok := history.Latest(); ok && snapshot.Time.After(evaluated) {
+			evaluated = snapshot.Time
+			changed := evaluator.Evaluate(snapshot)
+			for _, a := range changed {
+				logger.Warn("Alert "+string(a.State), "alert", a.Name, "severity", a.Severity, "expr", a.Expr, "value", a.Value)
+				bus.Publish(alertEvent(a))
+			}
+			if webhook != nil && len(changed) > 0 {
+				go func() {
+					if err := webhook.Notify(ctx, changed); err != nil {
+						logger.Warn("Failed to post alerts to the webhook", "error", err)
+					}
+				}()
+			}
+		}
+		p2p.Broadcast(network.ChannelAlert, evaluator.LocalReport())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// alertEvent describes an alert that fired or resolved
+func alertEvent(a models.Alert) models.Event {
+	event := models.Event{
+		Type:    models.EventAlertFired,
+		Time:    a.UpdatedAt,
+		NodeID:  a.NodeID,
+		Message: a.Summary,
+		Attributes: map[string]string{
+			"alert":    a.Name,
+			"severity": a.Severity,
+			"expr":     a.Expr,
+			"value":    strconv.FormatFloat(a.Value, 'g', -1, 64),
+		},
+	}
+	if a.State == models.AlertResolved {
+		event.Type = models.EventAlertResolved
+	}
+	return event
+}
+
+// reconcileModels keeps the models loaded on this node in line with the placement plans
+func reconcileModels(ctx context.Context, manager *agent.ModelManager, store *state.Store, nodeID string, heartbeat *health.Heartbeat) {
+	ticker := time.NewTicker(modelReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		manager.CheckBackend()
+		manager.Reconcile(store.Snapshot().ModelsOn(nodeID))
+		heartbeat.Beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"distributed-llm/internal/network"
 	"distributed-llm/internal/tui"
 	"distributed-llm/pkg/models"
 )
@@ -16,9 +17,15 @@ import (
 func main() {
 	var (
 		seedNodes    = flag.String("seed-nodes", "", "Comma-separated list of seed nodes (host:port)")
-		dockerMode   = flag.Bool("docker", false, "Use Docker service discovery")
-		k8sNamespace = flag.String("k8s-namespace", "default", "Kubernetes namespace for service discovery")
+		seedDNS      = flag.String("seed-dns", "", "Comma-separated DNS names whose records are agents (name[:port] for A/AAAA, _service._proto.name for SRV)")
+		seedFile     = flag.String("seed-file", "", "File listing agent gRPC addresses, one host:port per line")
+		seedRefresh  = flag.Duration("seed-refresh", network.DefaultSeedRefreshInterval, "How often seed providers are re-resolved")
+		dockerMode   = flag.Bool("docker", false, "Discover agent containers through the Docker Engine API")
+		dockerHost   = flag.String("docker-host", os.Getenv("DOCKER_HOST"), "Docker Engine API address (unix:///path or tcp://host:port)")
+		k8sNamespace = flag.String("k8s-namespace", "", "Kubernetes namespace to discover agents in (disabled when empty)")
 		logLevel     = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+		lanDiscovery = flag.Bool("lan-discovery", true, "Find agents announcing themselves on the local network")
+		lanGroup     = flag.String("discovery-group", network.DefaultDiscoveryGroup, "UDP multicast group for LAN discovery (host:port)")
 	)
 	flag.Parse()
 
@@ -46,9 +53,15 @@ func main() {
 	// Create channels for agent communication
 	nodeUpdateChan := make(chan []models.Node, 10)
 	modelUpdateChan := make(chan []models.Model, 10)
+	metricsChan := make(chan tui.MetricsSample, 64)
+	alertsChan := make(chan []models.Alert, 10)
+	eventsChan := make(chan models.Event, 64)
 
 	// Create the Bubble Tea model
 	model := tui.NewModelWithChannels(nodeUpdateChan, modelUpdateChan)
+	model.SetMetricsChan(metricsChan)
+	model.SetAlertsChan(alertsChan)
+	model.SetEventsChan(eventsChan)
 
 	// Parse seed nodes
 	var seedNodesList []string
@@ -59,38 +72,43 @@ func main() {
 		}
 	}
 
-	// Set up default seed nodes if none provided
-	if len(seedNodesList) == 0 {
-		if *dockerMode {
-			// Default Docker service names
-			seedNodesList = []string{
-				"distributed-llm-agent:8080",
-				"agent:8080",
-				"localhost:8080",
-				"localhost:8081",
-				"localhost:8082",
-			}
-		} else if *k8sNamespace != "" {
-			// Default Kubernetes service names
-			seedNodesList = []string{
-				"distributed-llm-agent." + *k8sNamespace + ".svc.cluster.local:8080",
-				"agent." + *k8sNamespace + ".svc.cluster.local:8080",
-			}
-		} else {
-			// Default local development
-			seedNodesList = []string{
-				"localhost:8080",
-				"127.0.0.1:8080",
-			}
+	var providers []network.SeedProvider
+	for _, name := range strings.Split(*seedDNS, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
 		}
+		provider, err := network.ParseDNSSeeds(name, 8080)
+		if err != nil {
+			logger.Error("Invalid --seed-dns", "error", err)
+			os.Exit(1)
+		}
+		providers = append(providers, provider)
+	}
+	if *seedFile != "" {
+		providers = append(providers, network.NewFileSeeds(*seedFile))
+	}
+
+	// Default to a local agent when nothing else is configured. Docker and
+	// Kubernetes agents are found through their APIs.
+	if len(seedNodesList) == 0 && len(providers) == 0 && !*dockerMode {
+		seedNodesList = []string{"localhost:8080"}
 	}
 
 	// Create and start agent discovery
 	discovery := tui.NewAgentDiscovery(tui.DiscoveryConfig{
 		SeedNodes:    seedNodesList,
 		DockerMode:   *dockerMode,
+		DockerHost:   *dockerHost,
 		K8sNamespace: *k8sNamespace,
 		UpdateChan:   nodeUpdateChan,
+		MetricsChan:  metricsChan,
+		AlertsChan:   alertsChan,
+		EventsChan:   eventsChan,
+		LANDiscovery: *lanDiscovery,
+		LANGroup:     *lanGroup,
+
+		SeedProviders:   providers,
+		RefreshInterval: *seedRefresh,
 	})
 
 	if err := discovery.Start(); err != nil {
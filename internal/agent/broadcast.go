@@ -4,6 +4,7 @@ import (
 	"context"
 	"distributed-llm/pkg/models"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,21 +16,89 @@ type MetricsCollector interface {
 	RecordNetworkMessage(direction, messageType string)
 }
 
+// BroadcastInterval is how often resources are pushed to listeners
+const BroadcastInterval = 30 * time.Second
+
+// NodeTTL is how long a node added to the broadcaster is kept without
+// being added again
+const NodeTTL = 3 * BroadcastInterval
+
+// DefaultSubscriberBuffer is how many updates a subscriber may fall behind
+// by when SubscribeOptions.Buffer isn't positive
+const DefaultSubscriberBuffer = 16
+
+// Heartbeat is told each time the broadcast loop runs, for liveness checks
+type Heartbeat interface {
+	Beat()
+}
+
+// DropPolicy decides what happens to an update for a subscriber whose
+// buffer is full
+type DropPolicy int
+
+const (
+	// DropOldest discards the oldest buffered update to make room
+	DropOldest DropPolicy = iota
+	// LatestWins buffers a single update, replaced by each newer one
+	LatestWins
+	// BlockWithTimeout waits up to SubscribeOptions.Timeout for room, then
+	// discards the update. Other subscribers wait meanwhile.
+	BlockWithTimeout
+)
+
+// SubscribeOptions configures a subscription to resource updates
+type SubscribeOptions struct {
+	// Buffer is how many updates may wait to be received,
+	// DefaultSubscriberBuffer if not positive. LatestWins ignores it.
+	Buffer  int
+	Policy  DropPolicy
+	Timeout time.Duration
+}
+
+// subscription is a listener for resource updates
+type subscription struct {
+	opts SubscribeOptions
+	// mu serializes sends with closing ch
+	mu   sync.Mutex
+	ch   chan models.ResourceInfo
+	done chan struct{}
+	once sync.Once
+	// stop unregisters the subscription from its context
+	stop func() bool
+}
+
+// trackedNode is a node along with when it was last added
+type trackedNode struct {
+	node models.Node
+	seen time.Time
+}
+
 // Broadcaster handles resource broadcasting and node management
 type Broadcaster struct {
 	mu               sync.RWMutex
 	resources        models.ResourceInfo
-	nodes            []models.Node
-	listeners        []chan models.ResourceInfo
+	nodes            []trackedNode
+	nodeTTL          time.Duration
+	subs             map[*subscription]struct{}
 	metricsCollector MetricsCollector
+	heartbeat        Heartbeat
+	now              func() time.Time
+	// dropped counts the updates discarded for every subscriber so far
+	dropped atomic.Uint64
+	stopped bool
+	stopCh  chan struct{}
+	loop    sync.WaitGroup
 }
 
 // NewBroadcaster creates a new broadcaster instance
 func NewBroadcaster() *Broadcaster {
 	return &Broadcaster{
 		resources: GetResourceInfo(),
-		nodes:     []models.Node{},
-		listeners: []chan models.ResourceInfo{},
+		nodes:     []trackedNode{},
+		nodeTTL:   NodeTTL,
+		subs:      make(map[*subscription]struct{}),
+		now:       time.Now,
+		stopCh:    make(chan struct{}),
 	}
 }
 
@@ -40,32 +109,73 @@ func (b *Broadcaster) SetMetricsCollector(collector MetricsCollector) {
 	b.metricsCollector = collector
 }
 
-// Start begins the broadcasting service
+// SetHeartbeat sets the heartbeat beaten by the broadcast loop
+func (b *Broadcaster) SetHeartbeat(heartbeat Heartbeat) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.heartbeat = heartbeat
+}
+
+// SetNodeTTL sets how long nodes are kept without being added again;
+// 0 keeps them until removed
+func (b *Broadcaster) SetNodeTTL(ttl time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nodeTTL = ttl
+}
+
+// Start begins the broadcasting service, which runs until ctx is done or
+// Stop is called
 func (b *Broadcaster) Start(ctx context.Context) error {
+	b.loop.Add(1)
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		defer b.loop.Done()
+		ticker := time.NewTicker(BroadcastInterval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case <-b.stopCh:
+				return
 			case <-ticker.C:
+				b.expireNodes()
 				b.broadcast()
+				b.beat()
 			}
 		}
 	}()
 	return nil
 }
 
+// Stop ends the broadcast loop and every subscription, whose channels are
+// closed. Later subscriptions are closed right away.
+func (b *Broadcaster) Stop() {
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	b.stopped = true
+	close(b.stopCh)
+	subs := make([]*subscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.subs = make(map[*subscription]struct{})
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+	b.loop.Wait()
+}
+
 // UpdateResources updates the current resource information
 func (b *Broadcaster) UpdateResources(resources models.ResourceInfo) {
 	b.mu.Lock()
 	b.resources = resources
-	// Create a copy of listeners while holding the lock
-	listeners := make([]chan models.ResourceInfo, len(b.listeners))
-	copy(listeners, b.listeners)
-	resourcesCopy := b.resources
 	collector := b.metricsCollector
 	b.mu.Unlock()
 
@@ -74,14 +184,7 @@ func (b *Broadcaster) UpdateResources(resources models.ResourceInfo) {
 		collector.UpdateNodeResources(resources)
 	}
 
-	// Send updates without holding the lock
-	for _, listener := range listeners {
-		select {
-		case listener <- resourcesCopy:
-		default:
-			// Don't block if channel is full
-		}
-	}
+	b.publish(resources)
 }
 
 // GetResources returns the current resource information
@@ -91,17 +194,70 @@ func (b *Broadcaster) GetResources() models.ResourceInfo {
 	return b.resources
 }
 
-// Subscribe adds a listener for resource updates
-func (b *Broadcaster) Subscribe(ch chan models.ResourceInfo) {
+// Subscribe returns a channel receiving resource updates until ctx is done,
+// the returned cancel func is called or the broadcaster is stopped, when it
+// is closed
+func (b *Broadcaster) Subscribe(ctx context.Context, opts SubscribeOptions) (<-chan models.ResourceInfo, context.CancelFunc) {
+	size := opts.Buffer
+	if size <= 0 {
+		size = DefaultSubscriberBuffer
+	}
+	if opts.Policy == LatestWins {
+		size = 1
+	}
+	sub := &subscription{
+		opts: opts,
+		ch:   make(chan models.ResourceInfo, size),
+		done: make(chan struct{}),
+	}
+
 	b.mu.Lock()
-	defer b.mu.Unlock()
-	b.listeners = append(b.listeners, ch)
+	if b.stopped {
+		b.mu.Unlock()
+		sub.close()
+		return sub.ch, func() {}
+	}
+	cancel := func() {
+		b.mu.Lock()
+		delete(b.subs, sub)
+		b.mu.Unlock()
+		sub.close()
+	}
+	b.subs[sub] = struct{}{}
+	sub.stop = context.AfterFunc(ctx, cancel)
+	b.mu.Unlock()
+	return sub.ch, cancel
+}
+
+// Subscribers returns the number of open subscriptions
+func (b *Broadcaster) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
 }
 
-// AddNode adds a node to the cluster
+// Dropped returns the number of updates discarded for subscribers that
+// fell behind
+func (b *Broadcaster) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// AddNode adds a node to the cluster, replacing any node with the same ID
+// and renewing its TTL
 func (b *Broadcaster) AddNode(node models.Node) {
 	b.mu.Lock()
-	b.nodes = append(b.nodes, node)
+	tracked := trackedNode{node: node, seen: b.now()}
+	replaced := false
+	for i := range b.nodes {
+		if b.nodes[i].node.ID == node.ID {
+			b.nodes[i] = tracked
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		b.nodes = append(b.nodes, tracked)
+	}
 	nodeCount := len(b.nodes)
 	collector := b.metricsCollector
 	b.mu.Unlock()
@@ -113,19 +269,69 @@ func (b *Broadcaster) AddNode(node models.Node) {
 	}
 }
 
-// GetNodes returns all known nodes
+// RemoveNode removes a node from the cluster, reporting whether it was known
+func (b *Broadcaster) RemoveNode(nodeID string) bool {
+	b.mu.Lock()
+	removed := false
+	for i := range b.nodes {
+		if b.nodes[i].node.ID == nodeID {
+			b.nodes = append(b.nodes[:i], b.nodes[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	nodeCount := len(b.nodes)
+	collector := b.metricsCollector
+	b.mu.Unlock()
+
+	if removed && collector != nil {
+		collector.UpdateNetworkConnections(nodeCount)
+		collector.RecordNetworkMessage("incoming", "node_removed")
+	}
+	return removed
+}
+
+// GetNodes returns all known nodes, in the order they were first added
 func (b *Broadcaster) GetNodes() []models.Node {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return append([]models.Node{}, b.nodes...)
+	nodes := make([]models.Node, 0, len(b.nodes))
+	for _, tracked := range b.nodes {
+		if !b.expiredLocked(tracked) {
+			nodes = append(nodes, tracked.node)
+		}
+	}
+	return nodes
+}
+
+// expireNodes removes the nodes not added again within the TTL
+func (b *Broadcaster) expireNodes() {
+	b.mu.Lock()
+	kept := b.nodes[:0]
+	for _, tracked := range b.nodes {
+		if !b.expiredLocked(tracked) {
+			kept = append(kept, tracked)
+		}
+	}
+	expired := len(b.nodes) - len(kept)
+	b.nodes = kept
+	nodeCount := len(b.nodes)
+	collector := b.metricsCollector
+	b.mu.Unlock()
+
+	if expired > 0 && collector != nil {
+		collector.UpdateNetworkConnections(nodeCount)
+		collector.RecordNetworkMessage("incoming", "node_expired")
+	}
+}
+
+func (b *Broadcaster) expiredLocked(tracked trackedNode) bool {
+	return b.nodeTTL > 0 && b.now().Sub(tracked.seen) > b.nodeTTL
 }
 
 // broadcast sends resource updates to all listeners
 func (b *Broadcaster) broadcast() {
 	b.mu.RLock()
-	// Create copies while holding the lock
-	listeners := make([]chan models.ResourceInfo, len(b.listeners))
-	copy(listeners, b.listeners)
 	resourcesCopy := b.resources
 	collector := b.metricsCollector
 	b.mu.RUnlock()
@@ -135,12 +341,96 @@ func (b *Broadcaster) broadcast() {
 		collector.RecordNetworkMessage("outgoing", "resource_broadcast")
 	}
 
-	// Send updates without holding the lock
-	for _, listener := range listeners {
+	b.publish(resourcesCopy)
+}
+
+// publish delivers an update to every subscriber without holding the lock
+func (b *Broadcaster) publish(resources models.ResourceInfo) {
+	b.mu.RLock()
+	subs := make([]*subscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	collector := b.metricsCollector
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if !sub.send(resources) {
+			b.dropped.Add(1)
+			if collector != nil {
+				collector.RecordNetworkMessage("outgoing", "resource_update_dropped")
+			}
+		}
+	}
+}
+
+// beat tells the heartbeat, if any, that the broadcast loop is alive
+func (b *Broadcaster) beat() {
+	b.mu.RLock()
+	heartbeat := b.heartbeat
+	b.mu.RUnlock()
+
+	if heartbeat != nil {
+		heartbeat.Beat()
+	}
+}
+
+// send delivers an update according to the drop policy, reporting false if
+// an update was discarded
+func (s *subscription) send(resources models.ResourceInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return true
+	default:
+	}
+
+	select {
+	case s.ch <- resources:
+		return true
+	default:
+	}
+
+	switch s.opts.Policy {
+	case DropOldest, LatestWins:
+		// Make room by discarding the oldest update, unless the subscriber
+		// received it meanwhile. Only senders holding mu fill ch, so there
+		// is room afterwards.
+		dropped := false
 		select {
-		case listener <- resourcesCopy:
+		case <-s.ch:
+			dropped = true
 		default:
-			// Don't block if channel is full
+		}
+		s.ch <- resources
+		if !dropped {
+			return true
+		}
+	case BlockWithTimeout:
+		timer := time.NewTimer(s.opts.Timeout)
+		defer timer.Stop()
+		select {
+		case s.ch <- resources:
+			return true
+		case <-timer.C:
+		case <-s.done:
+			return true
 		}
 	}
+	return false
+}
+
+// close ends the subscription, closing its channel once no send is in
+// progress
+func (s *subscription) close() {
+	s.once.Do(func() {
+		if s.stop != nil {
+			s.stop()
+		}
+		close(s.done)
+		s.mu.Lock()
+		close(s.ch)
+		s.mu.Unlock()
+	})
 }
@@ -386,8 +386,8 @@ func TestRealBroadcasterNewBroadcaster(t *testing.T) {
 		t.Fatal("NewBroadcaster returned nil")
 	}
 
-	if len(broadcaster.listeners) != 0 {
-		t.Errorf("Expected empty listeners, got %d", len(broadcaster.listeners))
+	if broadcaster.Subscribers() != 0 {
+		t.Errorf("Expected no subscribers, got %d", broadcaster.Subscribers())
 	}
 
 	if len(broadcaster.nodes) != 0 {
@@ -454,8 +454,8 @@ func TestRealBroadcasterStartWithBroadcast(t *testing.T) {
 	broadcaster.SetMetricsCollector(mockCollector)
 
 	// Create a subscription channel
-	updateCh := make(chan models.ResourceInfo, 10)
-	broadcaster.Subscribe(updateCh)
+	updateCh, cancel := broadcaster.Subscribe(context.Background(), SubscribeOptions{Buffer: 10})
+	defer cancel()
 
 	// Manually trigger broadcast to test it works
 	broadcaster.broadcast()
@@ -489,10 +489,10 @@ func TestRealBroadcasterUpdateResources(t *testing.T) {
 	broadcaster.SetMetricsCollector(mockCollector)
 
 	// Create subscribers
-	ch1 := make(chan models.ResourceInfo, 10)
-	ch2 := make(chan models.ResourceInfo, 10)
-	broadcaster.Subscribe(ch1)
-	broadcaster.Subscribe(ch2)
+	ch1, cancel1 := broadcaster.Subscribe(context.Background(), SubscribeOptions{Buffer: 10})
+	defer cancel1()
+	ch2, cancel2 := broadcaster.Subscribe(context.Background(), SubscribeOptions{Buffer: 10})
+	defer cancel2()
 
 	newResources := models.ResourceInfo{
 		CPUCores:   12,
@@ -517,7 +517,7 @@ func TestRealBroadcasterUpdateResources(t *testing.T) {
 	}
 
 	// Verify both subscribers received the update
-	for i, ch := range []chan models.ResourceInfo{ch1, ch2} {
+	for i, ch := range []<-chan models.ResourceInfo{ch1, ch2} {
 		select {
 		case received := <-ch:
 			if received.CPUCores != newResources.CPUCores {
@@ -566,25 +566,35 @@ func TestRealBroadcasterGetResources(t *testing.T) {
 func TestRealBroadcasterSubscribe(t *testing.T) {
 	broadcaster := NewBroadcaster()
 
-	// Initial state should have no listeners
-	if len(broadcaster.listeners) != 0 {
-		t.Errorf("Expected 0 initial listeners, got %d", len(broadcaster.listeners))
+	// Initial state should have no subscribers
+	if broadcaster.Subscribers() != 0 {
+		t.Errorf("Expected 0 initial subscribers, got %d", broadcaster.Subscribers())
 	}
 
-	// Add first subscriber
-	ch1 := make(chan models.ResourceInfo, 10)
-	broadcaster.Subscribe(ch1)
+	// The first subscription ends with its cancel func, the second with its context
+	ch1, cancel1 := broadcaster.Subscribe(context.Background(), SubscribeOptions{})
+	ctx, cancel2 := context.WithCancel(context.Background())
+	ch2, _ := broadcaster.Subscribe(ctx, SubscribeOptions{})
 
-	if len(broadcaster.listeners) != 1 {
-		t.Errorf("Expected 1 listener after first subscribe, got %d", len(broadcaster.listeners))
+	if broadcaster.Subscribers() != 2 {
+		t.Errorf("Expected 2 subscribers, got %d", broadcaster.Subscribers())
 	}
 
-	// Add second subscriber
-	ch2 := make(chan models.ResourceInfo, 10)
-	broadcaster.Subscribe(ch2)
-
-	if len(broadcaster.listeners) != 2 {
-		t.Errorf("Expected 2 listeners after second subscribe, got %d", len(broadcaster.listeners))
+	cancel1()
+	cancel1() // Cancelling twice is harmless
+	cancel2()
+	for i, ch := range []<-chan models.ResourceInfo{ch1, ch2} {
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Errorf("Subscriber %d: Expected no updates", i)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Subscriber %d: Expected the channel to be closed", i)
+		}
+	}
+	if broadcaster.Subscribers() != 0 {
+		t.Errorf("Expected 0 subscribers after cancelling, got %d", broadcaster.Subscribers())
 	}
 }
 
@@ -734,13 +744,15 @@ func TestRealBroadcasterConcurrentAccess(t *testing.T) {
 		}(i)
 	}
 
-	// Concurrent subscriptions
+	// Concurrent subscriptions, half of them cancelled right away
 	for i := 0; i < numGoroutines; i++ {
 		go func() {
 			defer wg.Done()
 			for j := 0; j < numOperations; j++ {
-				ch := make(chan models.ResourceInfo, 100)
-				broadcaster.Subscribe(ch)
+				_, cancel := broadcaster.Subscribe(ctx, SubscribeOptions{Buffer: 100})
+				if j%2 == 0 {
+					cancel()
+				}
 			}
 		}()
 	}
@@ -762,14 +774,60 @@ func TestRealBroadcasterConcurrentAccess(t *testing.T) {
 	}
 }
 
+func TestRealBroadcasterDropPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        SubscribeOptions
+		wantCores   []int64
+		wantDropped uint64
+	}{
+		{"drop oldest", SubscribeOptions{Buffer: 2, Policy: DropOldest}, []int64{3, 4}, 2},
+		{"latest wins", SubscribeOptions{Buffer: 2, Policy: LatestWins}, []int64{4}, 3},
+		{"block with timeout", SubscribeOptions{Buffer: 2, Policy: BlockWithTimeout, Timeout: 10 * time.Millisecond}, []int64{1, 2}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broadcaster := NewBroadcaster()
+			mockCollector := NewMockMetricsCollector()
+			broadcaster.SetMetricsCollector(mockCollector)
+			ch, cancel := broadcaster.Subscribe(context.Background(), tt.opts)
+			defer cancel()
+
+			// Nothing is received until every update was published
+			for cores := int64(1); cores <= 4; cores++ {
+				broadcaster.UpdateResources(models.ResourceInfo{CPUCores: cores})
+			}
+
+			var got []int64
+			for len(ch) > 0 {
+				got = append(got, (<-ch).CPUCores)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantCores) {
+				t.Errorf("Expected updates %v, got %v", tt.wantCores, got)
+			}
+			if broadcaster.Dropped() != tt.wantDropped {
+				t.Errorf("Expected %d dropped updates, got %d", tt.wantDropped, broadcaster.Dropped())
+			}
+			dropped := 0
+			for _, msg := range mockCollector.GetNetworkMessages() {
+				if msg.MessageType == "resource_update_dropped" {
+					dropped++
+				}
+			}
+			if uint64(dropped) != tt.wantDropped {
+				t.Errorf("Expected %d dropped updates recorded, got %d", tt.wantDropped, dropped)
+			}
+		})
+	}
+}
+
 func TestRealBroadcasterSubscribeWithFullChannel(t *testing.T) {
 	broadcaster := NewBroadcaster()
 
-	// Create a channel with capacity 1 and fill it
-	ch := make(chan models.ResourceInfo, 1)
-	ch <- models.ResourceInfo{CPUCores: 1} // Fill the channel
-
-	broadcaster.Subscribe(ch)
+	// A subscriber that never reads, with a full buffer
+	_, cancel := broadcaster.Subscribe(context.Background(), SubscribeOptions{Buffer: 1})
+	defer cancel()
+	broadcaster.UpdateResources(models.ResourceInfo{CPUCores: 1})
 
 	// Update resources - should not block even though channel is full
 	newResources := models.ResourceInfo{CPUCores: 8, MemoryMB: 16384}
@@ -789,6 +847,96 @@ func TestRealBroadcasterSubscribeWithFullChannel(t *testing.T) {
 	}
 }
 
+func TestRealBroadcasterStop(t *testing.T) {
+	broadcaster := NewBroadcaster()
+	if err := broadcaster.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	// Stopping ends subscribers, including one blocked on a full buffer
+	blocked, cancel := broadcaster.Subscribe(context.Background(), SubscribeOptions{Buffer: 1, Policy: BlockWithTimeout, Timeout: time.Minute})
+	defer cancel()
+	broadcaster.UpdateResources(models.ResourceInfo{CPUCores: 1})
+	go broadcaster.UpdateResources(models.ResourceInfo{CPUCores: 2})
+
+	stopped := make(chan struct{})
+	go func() {
+		broadcaster.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+	for range blocked {
+	}
+	broadcaster.Stop() // Stopping twice is harmless
+
+	// Later subscriptions are closed right away
+	late, _ := broadcaster.Subscribe(context.Background(), SubscribeOptions{})
+	if _, ok := <-late; ok {
+		t.Error("Expected a subscription after Stop to be closed")
+	}
+	broadcaster.UpdateResources(models.ResourceInfo{CPUCores: 3})
+}
+
+func TestRealBroadcasterRemoveNode(t *testing.T) {
+	broadcaster := NewBroadcaster()
+	mockCollector := NewMockMetricsCollector()
+	broadcaster.SetMetricsCollector(mockCollector)
+
+	broadcaster.AddNode(models.Node{ID: "node1"})
+	broadcaster.AddNode(models.Node{ID: "node2"})
+	broadcaster.AddNode(models.Node{ID: "node1", Address: "addr1"}) // Replaces node1
+
+	if !broadcaster.RemoveNode("node2") {
+		t.Error("Expected node2 to be removed")
+	}
+	if broadcaster.RemoveNode("node3") {
+		t.Error("Expected unknown node3 not to be removed")
+	}
+
+	nodes := broadcaster.GetNodes()
+	if len(nodes) != 1 || nodes[0].ID != "node1" || nodes[0].Address != "addr1" {
+		t.Errorf("Expected the replaced node1 only, got %+v", nodes)
+	}
+	connections := mockCollector.GetNetworkConnections()
+	if fmt.Sprint(connections) != "[1 2 2 1]" {
+		t.Errorf("Expected connection counts [1 2 2 1], got %v", connections)
+	}
+}
+
+func TestRealBroadcasterNodeExpiry(t *testing.T) {
+	broadcaster := NewBroadcaster()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	broadcaster.now = func() time.Time { return now }
+	broadcaster.SetNodeTTL(time.Minute)
+
+	broadcaster.AddNode(models.Node{ID: "node1"})
+	broadcaster.AddNode(models.Node{ID: "node2"})
+	now = now.Add(45 * time.Second)
+	broadcaster.AddNode(models.Node{ID: "node1"}) // Renews node1
+	now = now.Add(30 * time.Second)
+
+	// Expired nodes are hidden right away and removed by the broadcast loop
+	nodes := broadcaster.GetNodes()
+	if len(nodes) != 1 || nodes[0].ID != "node1" {
+		t.Errorf("Expected only node1 to be left, got %+v", nodes)
+	}
+	broadcaster.expireNodes()
+	if len(broadcaster.nodes) != 1 {
+		t.Errorf("Expected node2 to be removed, got %d nodes", len(broadcaster.nodes))
+	}
+
+	// A TTL of 0 keeps nodes until removed
+	broadcaster.SetNodeTTL(0)
+	now = now.Add(time.Hour)
+	if len(broadcaster.GetNodes()) != 1 {
+		t.Error("Expected node1 to be kept without a TTL")
+	}
+}
+
 // Benchmark tests for the real Broadcaster
 func BenchmarkRealBroadcasterUpdateResources(b *testing.B) {
 	broadcaster := NewBroadcaster()
@@ -810,8 +958,8 @@ func BenchmarkRealBroadcasterSubscribe(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		ch := make(chan models.ResourceInfo, 10)
-		broadcaster.Subscribe(ch)
+		_, cancel := broadcaster.Subscribe(context.Background(), SubscribeOptions{Buffer: 10})
+		cancel()
 	}
 }
 
@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"distributed-llm/pkg/models"
+)
+
+// DefaultBackend is the inference executable invoked by LLM
+const DefaultBackend = "llama.cpp"
+
+// EventPublisher records what happens in the cluster (*events.Bus)
+type EventPublisher interface {
+	Publish(event models.Event) models.Event
+}
+
+// ModelManager tracks whether the inference backend and the models assigned
+// to this node are ready to serve
+type ModelManager struct {
+	mu         sync.RWMutex
+	backend    string
+	modelDir   string
+	backendErr error
+	models     map[string]models.ModelReadiness
+	nodeID     string
+	events     EventPublisher
+}
+
+// NewModelManager creates a manager for the given backend executable. Relative
+// model file paths are resolved against modelDir.
+func NewModelManager(backend, modelDir string) *ModelManager {
+	return &ModelManager{
+		backend:    backend,
+		modelDir:   modelDir,
+		backendErr: fmt.Errorf("backend %s not checked yet", backend),
+		models:     make(map[string]models.ModelReadiness),
+	}
+}
+
+// SetEventPublisher publishes the models this node loads and unloads as
+// events about nodeID
+func (m *ModelManager) SetEventPublisher(publisher EventPublisher, nodeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = publisher
+	m.nodeID = nodeID
+}
+
+// CheckBackend looks up the backend executable and records the result
+func (m *ModelManager) CheckBackend() error {
+	_, err := exec.LookPath(m.backend)
+	if err != nil {
+		err = fmt.Errorf("inference backend unavailable: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.backendErr = err
+	return err
+}
+
+// BackendError returns nil if the backend was found by the last check
+func (m *ModelManager) BackendError() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.backendErr
+}
+
+// Reconcile loads newly assigned models, retries failed ones and forgets
+// models no longer assigned to this node
+func (m *ModelManager) Reconcile(assigned []models.Model) {
+	wanted := make(map[string]bool, len(assigned))
+	for _, model := range assigned {
+		wanted[model.ID] = true
+
+		m.mu.RLock()
+		current, tracked := m.models[model.ID]
+		m.mu.RUnlock()
+		if tracked && current.State == models.ModelStateReady {
+			continue
+		}
+		m.load(model)
+	}
+
+	m.mu.Lock()
+	var unloaded []string
+	for id, readiness := range m.models {
+		if !wanted[id] {
+			delete(m.models, id)
+			if readiness.State == models.ModelStateReady {
+				unloaded = append(unloaded, id)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Strings(unloaded)
+	for _, id := range unloaded {
+		m.publish(models.Event{Type: models.EventModelUnloaded, ModelID: id})
+	}
+}
+
+// load marks the model as loading and checks that its weights are present
+func (m *ModelManager) load(model models.Model) {
+	m.setState(models.ModelReadiness{ModelID: model.ID, State: models.ModelStateLoading})
+
+	path := model.FilePath
+	if path == "" {
+		m.setState(models.ModelReadiness{ModelID: model.ID, State: models.ModelStateFailed, Error: "model has no file path"})
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.modelDir, path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		m.setState(models.ModelReadiness{ModelID: model.ID, State: models.ModelStateFailed, Error: err.Error()})
+		return
+	}
+	m.setState(models.ModelReadiness{ModelID: model.ID, State: models.ModelStateReady})
+}
+
+func (m *ModelManager) setState(readiness models.ModelReadiness) {
+	m.mu.Lock()
+	previous := m.models[readiness.ModelID]
+	m.models[readiness.ModelID] = readiness
+	m.mu.Unlock()
+
+	if readiness.State == models.ModelStateReady && previous.State != models.ModelStateReady {
+		m.publish(models.Event{Type: models.EventModelLoaded, ModelID: readiness.ModelID})
+	}
+}
+
+// publish sends an event about this node to the publisher, if any
+func (m *ModelManager) publish(event models.Event) {
+	m.mu.RLock()
+	publisher := m.events
+	event.NodeID = m.nodeID
+	m.mu.RUnlock()
+
+	if publisher != nil {
+		publisher.Publish(event)
+	}
+}
+
+// ModelReadiness returns the state of every assigned model, sorted by ID
+func (m *ModelManager) ModelReadiness() []models.ModelReadiness {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	readiness := make([]models.ModelReadiness, 0, len(m.models))
+	for _, r := range m.models {
+		readiness = append(readiness, r)
+	}
+	sort.Slice(readiness, func(i, j int) bool { return readiness[i].ModelID < readiness[j].ModelID })
+	return readiness
+}
+
+// CheckLoaded fails if models are assigned to this node but none of them can
+// be served yet. A node without assigned models only forwards requests.
+func (m *ModelManager) CheckLoaded(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.models) == 0 {
+		return nil
+	}
+	if m.backendErr != nil {
+		return m.backendErr
+	}
+	for _, r := range m.models {
+		if r.State == models.ModelStateReady {
+			return nil
+		}
+	}
+	return errors.New("no assigned model is loaded")
+}
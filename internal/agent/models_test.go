@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"distributed-llm/pkg/models"
+)
+
+func TestModelManagerCheckBackend(t *testing.T) {
+	dir := t.TempDir()
+	backend := filepath.Join(dir, "llama.cpp")
+	if err := os.WriteFile(backend, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake backend: %v", err)
+	}
+
+	manager := NewModelManager(backend, dir)
+	if manager.BackendError() == nil {
+		t.Error("Expected backend to be unready before the first check")
+	}
+	if err := manager.CheckBackend(); err != nil {
+		t.Errorf("Expected backend to be found, got %v", err)
+	}
+	if manager.BackendError() != nil {
+		t.Errorf("Expected backend to be ready, got %v", manager.BackendError())
+	}
+
+	missing := NewModelManager(filepath.Join(dir, "missing"), dir)
+	if err := missing.CheckBackend(); err == nil {
+		t.Error("Expected error for missing backend")
+	}
+}
+
+func TestModelManagerReconcile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "llama.gguf"), []byte("weights"), 0o644); err != nil {
+		t.Fatalf("Failed to write model file: %v", err)
+	}
+	manager := NewModelManager(DefaultBackend, dir)
+
+	manager.Reconcile([]models.Model{
+		{ID: "llama", FilePath: "llama.gguf"},
+		{ID: "missing", FilePath: "missing.gguf"},
+		{ID: "no-path"},
+	})
+
+	readiness := manager.ModelReadiness()
+	expected := map[string]models.ModelState{
+		"llama":   models.ModelStateReady,
+		"missing": models.ModelStateFailed,
+		"no-path": models.ModelStateFailed,
+	}
+	if len(readiness) != len(expected) {
+		t.Fatalf("Expected %d models, got %+v", len(expected), readiness)
+	}
+	for _, r := range readiness {
+		if r.State != expected[r.ModelID] {
+			t.Errorf("Model %s state = %s, want %s", r.ModelID, r.State, expected[r.ModelID])
+		}
+		if r.State == models.ModelStateFailed && r.Error == "" {
+			t.Errorf("Expected error message for failed model %s", r.ModelID)
+		}
+	}
+
+	// Failed models are retried once their weights appear, unassigned models are dropped
+	if err := os.WriteFile(filepath.Join(dir, "missing.gguf"), []byte("weights"), 0o644); err != nil {
+		t.Fatalf("Failed to write model file: %v", err)
+	}
+	manager.Reconcile([]models.Model{{ID: "missing", FilePath: "missing.gguf"}})
+
+	readiness = manager.ModelReadiness()
+	if len(readiness) != 1 || readiness[0].State != models.ModelStateReady {
+		t.Errorf("Expected only a ready missing model, got %+v", readiness)
+	}
+}
+
+func TestModelManagerCheckLoaded(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "llama.gguf"), []byte("weights"), 0o644); err != nil {
+		t.Fatalf("Failed to write model file: %v", err)
+	}
+	backend := filepath.Join(dir, "llama.cpp")
+	if err := os.WriteFile(backend, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake backend: %v", err)
+	}
+	manager := NewModelManager(backend, dir)
+	ctx := context.Background()
+
+	if err := manager.CheckLoaded(ctx); err != nil {
+		t.Errorf("Expected a node without models to be ready, got %v", err)
+	}
+
+	manager.Reconcile([]models.Model{{ID: "missing", FilePath: "missing.gguf"}})
+	if err := manager.CheckLoaded(ctx); err == nil {
+		t.Error("Expected error when the backend has not been found")
+	}
+	manager.CheckBackend()
+	if err := manager.CheckLoaded(ctx); err == nil {
+		t.Error("Expected error when no assigned model is loaded")
+	}
+
+	manager.Reconcile([]models.Model{
+		{ID: "missing", FilePath: "missing.gguf"},
+		{ID: "llama", FilePath: "llama.gguf"},
+	})
+	if err := manager.CheckLoaded(ctx); err != nil {
+		t.Errorf("Expected ready once one model is loaded, got %v", err)
+	}
+}
+
+// eventRecorder records the events published to it
+type eventRecorder struct {
+	events []models.Event
+}
+
+func (r *eventRecorder) Publish(event models.Event) models.Event {
+	r.events = append(r.events, event)
+	return event
+}
+
+func TestModelManagerEvents(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "llama.gguf"), []byte("weights"), 0o644); err != nil {
+		t.Fatalf("Failed to write model file: %v", err)
+	}
+	manager := NewModelManager(DefaultBackend, dir)
+	recorder := &eventRecorder{}
+	manager.SetEventPublisher(recorder, "node-a")
+
+	assigned := []models.Model{{ID: "llama", FilePath: "llama.gguf"}, {ID: "missing", FilePath: "missing.gguf"}}
+	manager.Reconcile(assigned)
+	manager.Reconcile(assigned) // Nothing changes
+	manager.Reconcile(nil)
+
+	want := []models.Event{
+		{Type: models.EventModelLoaded, NodeID: "node-a", ModelID: "llama"},
+		{Type: models.EventModelUnloaded, NodeID: "node-a", ModelID: "llama"},
+	}
+	if len(recorder.events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), recorder.events)
+	}
+	for i, event := range recorder.events {
+		if event.Type != want[i].Type || event.NodeID != want[i].NodeID || event.ModelID != want[i].ModelID {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want[i], event)
+		}
+	}
+}
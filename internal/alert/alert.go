@@ -0,0 +1,248 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"distributed-llm/internal/gossip"
+	"distributed-llm/pkg/metrics"
+	"distributed-llm/pkg/models"
+)
+
+// Rule raises an alert while a node metric crosses a threshold
+type Rule struct {
+	Name string
+	// Expr compares a metric to a number, e.g. "cpu_usage_percent > 90".
+	// The metrics are listed under Alerts in docs/overview.md.
+	Expr string
+	// For is how long the condition must hold before the alert fires
+	For      time.Duration
+	Severity string
+	Summary  string
+}
+
+// metric reads a value from the latest snapshot and the one before it;
+// ok is false when the snapshots don't define it
+type metric func(cur, prev *metrics.Snapshot) (value float64, ok bool)
+
+// gauge reads a metric from the latest snapshot alone
+func gauge(read func(s *metrics.Snapshot) float64) metric {
+	return func(cur, _ *metrics.Snapshot) (float64, bool) {
+		return read(cur), true
+	}
+}
+
+// rate reads a counter's increase per second since the previous snapshot
+func rate(read func(s *metrics.Snapshot) uint64) metric {
+	return func(cur, prev *metrics.Snapshot) (float64, bool) {
+		if prev == nil || !cur.Time.After(prev.Time) || read(cur) < read(prev) {
+			return 0, false
+		}
+		return float64(read(cur)-read(prev)) / cur.Time.Sub(prev.Time).Seconds(), true
+	}
+}
+
+// ruleMetrics are the values rule expressions can compare
+var ruleMetrics = map[string]metric{
+	"cpu_usage_percent": gauge(func(s *metrics.Snapshot) float64 { return s.CPUUsagePercent }),
+	"memory_used_bytes": gauge(func(s *metrics.Snapshot) float64 { return float64(s.MemoryUsedBytes) }),
+	"memory_used_percent": func(cur, _ *metrics.Snapshot) (float64, bool) {
+		if cur.MemoryTotalBytes == 0 {
+			return 0, false
+		}
+		return float64(cur.MemoryUsedBytes) / float64(cur.MemoryTotalBytes) * 100, true
+	},
+	"layers_allocated": gauge(func(s *metrics.Snapshot) float64 { return float64(s.LayersAllocated) }),
+	"layers_capacity":  gauge(func(s *metrics.Snapshot) float64 { return float64(s.LayersCapacity) }),
+	"layers_available": func(cur, _ *metrics.Snapshot) (float64, bool) {
+		if cur.LayersCapacity == 0 {
+			return 0, false
+		}
+		return float64(cur.LayersCapacity - cur.LayersAllocated), true
+	},
+	"connections":         gauge(func(s *metrics.Snapshot) float64 { return float64(s.Connections) }),
+	"peer_rtt_seconds":    gauge(func(s *metrics.Snapshot) float64 { return s.PeerRTT.Seconds() }),
+	"requests_active":     gauge(func(s *metrics.Snapshot) float64 { return float64(s.RequestsActive) }),
+	"tokens_per_second":   gauge(func(s *metrics.Snapshot) float64 { return s.TokensPerSecond }),
+	"avg_latency_seconds": gauge(func(s *metrics.Snapshot) float64 { return s.AvgLatency.Seconds() }),
+	"goroutines":          gauge(func(s *metrics.Snapshot) float64 { return float64(s.Goroutines) }),
+	"heap_alloc_bytes":    gauge(func(s *metrics.Snapshot) float64 { return float64(s.HeapAllocBytes) }),
+	"requests_per_second": rate(func(s *metrics.Snapshot) uint64 { return s.RequestsTotal }),
+	"errors_per_second":   rate(func(s *metrics.Snapshot) uint64 { return s.ErrorsTotal }),
+	"error_ratio": func(cur, prev *metrics.Snapshot) (float64, bool) {
+		if prev == nil || cur.RequestsTotal <= prev.RequestsTotal || cur.ErrorsTotal < prev.ErrorsTotal {
+			return 0, false
+		}
+		return float64(cur.ErrorsTotal-prev.ErrorsTotal) / float64(cur.RequestsTotal-prev.RequestsTotal), true
+	},
+}
+
+// comparisons are the operators of rule expressions, longest first so that
+// ">=" is not read as ">"
+var comparisons = []struct {
+	op      string
+	compare func(value, threshold float64) bool
+}{
+	{">=", func(v, t float64) bool { return v >= t }},
+	{"<=", func(v, t float64) bool { return v <= t }},
+	{"==", func(v, t float64) bool { return v == t }},
+	{"!=", func(v, t float64) bool { return v != t }},
+	{">", func(v, t float64) bool { return v > t }},
+	{"<", func(v, t float64) bool { return v < t }},
+}
+
+// condition is a parsed rule expression
+type condition struct {
+	metric    metric
+	compare   func(value, threshold float64) bool
+	threshold float64
+}
+
+// parseExpr parses "<metric> <op> <number>"
+func parseExpr(expr string) (condition, error) {
+	for _, c := range comparisons {
+		name, number, ok := strings.Cut(expr, c.op)
+		if !ok {
+			continue
+		}
+		m, ok := ruleMetrics[strings.TrimSpace(name)]
+		if !ok {
+			return condition{}, fmt.Errorf("unknown metric %q", strings.TrimSpace(name))
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid threshold %q", strings.TrimSpace(number))
+		}
+		return condition{metric: m, compare: c.compare, threshold: threshold}, nil
+	}
+	return condition{}, fmt.Errorf("expected <metric> <op> <number>, got %q", expr)
+}
+
+// rule is a rule with its parsed condition and the alert it raised, if any
+type rule struct {
+	Rule
+	condition condition
+	active    *models.Alert
+}
+
+// Evaluator checks a node's metrics against alert rules. It tracks the
+// node's alerts from pending to firing to resolved, and gossips them so
+// every node knows the cluster's active alerts.
+type Evaluator struct {
+	nodeID string
+	now    func() time.Time
+
+	mu    sync.Mutex
+	rules []*rule
+	prev  *metrics.Snapshot
+	// reports holds the latest alerts reported by every node, including ours
+	reports *gossip.Table[report, *report]
+}
+
+// NewEvaluator creates an evaluator for the node, or returns an error for
+// the first rule with an invalid name or expression
+func NewEvaluator(nodeID string, rules []Rule) (*Evaluator, error) {
+	e := &Evaluator{
+		nodeID:  nodeID,
+		now:     time.Now,
+		reports: gossip.NewTable(nodeID, reportTTL, &report{Report: gossip.Report{Sent: time.Now()}}),
+	}
+	names := make(map[string]bool)
+	for _, r := range rules {
+		if r.Name == "" || names[r.Name] {
+			return nil, fmt.Errorf("alert rules need unique names, got %q", r.Name)
+		}
+		names[r.Name] = true
+		cond, err := parseExpr(r.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("alert rule %s: %w", r.Name, err)
+		}
+		e.rules = append(e.rules, &rule{Rule: r, condition: cond})
+	}
+	return e, nil
+}
+
+// Evaluate checks a snapshot of the node's metrics against the rules and
+// returns the alerts that started firing or resolved. Snapshots must be
+// passed in order; rates are measured against the previous one.
+func (e *Evaluator) Evaluate(s metrics.Snapshot) []models.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var changed []models.Alert
+	for _, r := range e.rules {
+		value, ok := r.condition.metric(&s, e.prev)
+		holds := ok && r.condition.compare(value, r.condition.threshold)
+
+		switch {
+		case holds && r.active == nil:
+			r.active = &models.Alert{
+				Name:        r.Name,
+				NodeID:      e.nodeID,
+				Severity:    r.Severity,
+				State:       models.AlertPending,
+				Expr:        r.Expr,
+				Summary:     r.Summary,
+				ActiveSince: s.Time,
+			}
+			fallthrough
+		case holds:
+			r.active.Value = value
+			r.active.UpdatedAt = s.Time
+			if r.active.State == models.AlertPending && s.Time.Sub(r.active.ActiveSince) >= r.For {
+				r.active.State = models.AlertFiring
+				changed = append(changed, *r.active)
+			}
+		case r.active != nil:
+			if r.active.State == models.AlertFiring {
+				resolved := *r.active
+				resolved.State = models.AlertResolved
+				resolved.UpdatedAt = s.Time
+				if ok {
+					resolved.Value = value
+				}
+				changed = append(changed, resolved)
+			}
+			r.active = nil
+		}
+	}
+	e.prev = &s
+	return changed
+}
+
+// Alerts returns the active alerts of every node in the cluster, ordered
+// by node and name
+func (e *Evaluator) Alerts() []models.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+
+	alerts := e.localLocked()
+	e.reports.Each(now, func(r *report) {
+		if r.NodeID != e.nodeID {
+			alerts = append(alerts, r.Alerts...)
+		}
+	})
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].NodeID != alerts[j].NodeID {
+			return alerts[i].NodeID < alerts[j].NodeID
+		}
+		return alerts[i].Name < alerts[j].Name
+	})
+	return alerts
+}
+
+// localLocked returns this node's active alerts
+func (e *Evaluator) localLocked() []models.Alert {
+	var alerts []models.Alert
+	for _, r := range e.rules {
+		if r.active != nil {
+			alerts = append(alerts, *r.active)
+		}
+	}
+	return alerts
+}
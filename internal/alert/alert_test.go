@@ -0,0 +1,162 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/metrics"
+	"distributed-llm/pkg/models"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// at returns a snapshot taken some 5s steps after start
+func at(step int, s metrics.Snapshot) metrics.Snapshot {
+	s.Time = start.Add(time.Duration(step) * 5 * time.Second)
+	return s
+}
+
+// states summarizes alerts as name=state
+func states(alerts []models.Alert) string {
+	var parts []string
+	for _, a := range alerts {
+		parts = append(parts, a.Name+"="+string(a.State))
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestParseExpr(t *testing.T) {
+	s := &metrics.Snapshot{CPUUsagePercent: 90, MemoryUsedBytes: 3, MemoryTotalBytes: 4}
+	tests := []struct {
+		expr    string
+		holds   bool
+		wantErr string
+	}{
+		{"cpu_usage_percent > 90", false, ""},
+		{"cpu_usage_percent >= 90", true, ""},
+		{"cpu_usage_percent<=90", true, ""},
+		{"cpu_usage_percent != 90", false, ""},
+		{"memory_used_percent == 75", true, ""},
+		{"memory_used_percent < 1e2", true, ""},
+		{"gpu_usage_percent > 90", false, "unknown metric"},
+		{"cpu_usage_percent > ninety", false, "invalid threshold"},
+		{"cpu_usage_percent", false, "expected <metric> <op> <number>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cond, err := parseExpr(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseExpr failed: %v", err)
+			}
+			value, ok := cond.metric(s, nil)
+			if holds := ok && cond.compare(value, cond.threshold); holds != tt.holds {
+				t.Errorf("Expected the condition to hold: %v, got %v (value %v)", tt.holds, holds, value)
+			}
+		})
+	}
+}
+
+func TestNewEvaluatorRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"unnamed", []Rule{{Expr: "connections < 1"}}},
+		{"duplicate", []Rule{{Name: "A", Expr: "connections < 1"}, {Name: "A", Expr: "goroutines > 1000"}}},
+		{"invalid expression", []Rule{{Name: "A", Expr: "connections"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEvaluator("node-a", tt.rules); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestEvaluateForDuration(t *testing.T) {
+	e, err := NewEvaluator("node-a", []Rule{
+		{Name: "HighCPU", Expr: "cpu_usage_percent > 90", For: 10 * time.Second, Severity: "warning"},
+		{Name: "Partition", Expr: "connections < 1", Severity: "critical"},
+	})
+	if err != nil {
+		t.Fatalf("NewEvaluator failed: %v", err)
+	}
+
+	steps := []struct {
+		snapshot metrics.Snapshot
+		changed  string
+		active   string
+	}{
+		{metrics.Snapshot{CPUUsagePercent: 50, Connections: 2}, "", ""},
+		{metrics.Snapshot{CPUUsagePercent: 95, Connections: 2}, "", "HighCPU=pending"},
+		{metrics.Snapshot{CPUUsagePercent: 97, Connections: 0}, "Partition=firing", "HighCPU=pending,Partition=firing"},
+		// Fires once the condition has held for 10s
+		{metrics.Snapshot{CPUUsagePercent: 99, Connections: 0}, "HighCPU=firing", "HighCPU=firing,Partition=firing"},
+		{metrics.Snapshot{CPUUsagePercent: 98, Connections: 0}, "", "HighCPU=firing,Partition=firing"},
+		{metrics.Snapshot{CPUUsagePercent: 40, Connections: 3}, "HighCPU=resolved,Partition=resolved", ""},
+		// A pending alert that stops holding never fires or resolves
+		{metrics.Snapshot{CPUUsagePercent: 95, Connections: 3}, "", "HighCPU=pending"},
+		{metrics.Snapshot{CPUUsagePercent: 60, Connections: 3}, "", ""},
+	}
+	for i, step := range steps {
+		changed := e.Evaluate(at(i, step.snapshot))
+		if got := states(changed); got != step.changed {
+			t.Errorf("Step %d: expected changes %q, got %q", i, step.changed, got)
+		}
+		if got := states(e.Alerts()); got != step.active {
+			t.Errorf("Step %d: expected active alerts %q, got %q", i, step.active, got)
+		}
+	}
+}
+
+func TestEvaluateAlertDetails(t *testing.T) {
+	e, _ := NewEvaluator("node-a", []Rule{
+		{Name: "HighCPU", Expr: "cpu_usage_percent > 90", For: 5 * time.Second, Severity: "warning", Summary: "CPU is busy"},
+	})
+	e.Evaluate(at(0, metrics.Snapshot{CPUUsagePercent: 95}))
+	changed := e.Evaluate(at(1, metrics.Snapshot{CPUUsagePercent: 96}))
+	if len(changed) != 1 {
+		t.Fatalf("Expected the alert to fire, got %v", changed)
+	}
+	want := models.Alert{
+		Name: "HighCPU", NodeID: "node-a", Severity: "warning", State: models.AlertFiring,
+		Expr: "cpu_usage_percent > 90", Value: 96, Summary: "CPU is busy",
+		ActiveSince: at(0, metrics.Snapshot{}).Time, UpdatedAt: at(1, metrics.Snapshot{}).Time,
+	}
+	if changed[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, changed[0])
+	}
+
+	resolved := e.Evaluate(at(2, metrics.Snapshot{CPUUsagePercent: 20}))
+	if len(resolved) != 1 || resolved[0].Value != 20 || resolved[0].ActiveSince != want.ActiveSince {
+		t.Errorf("Expected the resolution with the last value, got %+v", resolved)
+	}
+}
+
+func TestEvaluateRates(t *testing.T) {
+	e, _ := NewEvaluator("node-a", []Rule{
+		{Name: "Errors", Expr: "error_ratio > 0.05"},
+		{Name: "Busy", Expr: "requests_per_second >= 2"},
+	})
+
+	// Rates need a previous snapshot
+	if changed := e.Evaluate(at(0, metrics.Snapshot{RequestsTotal: 100, ErrorsTotal: 50})); len(changed) != 0 {
+		t.Errorf("Expected no rate on the first snapshot, got %v", states(changed))
+	}
+	// 10 requests in 5s, 1 failed
+	if got := states(e.Evaluate(at(1, metrics.Snapshot{RequestsTotal: 110, ErrorsTotal: 51}))); got != "Errors=firing,Busy=firing" {
+		t.Errorf("Expected both rates over their thresholds, got %q", got)
+	}
+	// No requests: the error ratio is undefined and resolves
+	if got := states(e.Evaluate(at(2, metrics.Snapshot{RequestsTotal: 110, ErrorsTotal: 51}))); got != "Errors=resolved,Busy=resolved" {
+		t.Errorf("Expected both alerts to resolve without requests, got %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"time"
+
+	"distributed-llm/internal/gossip"
+	"distributed-llm/pkg/models"
+)
+
+// reportTTL is how long another node's alerts are shown after it last
+// reported them, so that departed nodes' alerts clear
+const reportTTL = 30 * time.Second
+
+// report is a node's active alerts
+type report struct {
+	gossip.Report
+	Alerts []models.Alert `json:"alerts,omitempty"`
+}
+
+// LocalReport encodes this node's active alerts for gossip. Call it
+// periodically; a node's alerts clear on the others once it stops
+// reporting them.
+func (e *Evaluator) LocalReport() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+
+	e.reports.SetLocal(&report{Alerts: e.localLocked()})
+	e.reports.Expire(now)
+	return e.reports.EncodeLocal(now)
+}
+
+// HandleMessage merges alerts gossiped by another node (network.ChannelHandler)
+func (e *Evaluator) HandleMessage(msg []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reports.Merge(msg)
+}
+
+// LocalState shares every node's alerts during push/pull (network.ChannelHandler)
+func (e *Evaluator) LocalState() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.reports.Encode(e.now())
+}
+
+// MergeRemoteState merges the alerts of a push/pull peer (network.ChannelHandler)
+func (e *Evaluator) MergeRemoteState(buf []byte) {
+	e.HandleMessage(buf)
+}
@@ -0,0 +1,83 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/metrics"
+)
+
+// newTestEvaluator creates an evaluator with a CPU rule and a clock that
+// advance moves forward
+func newTestEvaluator(t *testing.T, nodeID string) (*Evaluator, func(time.Duration)) {
+	t.Helper()
+	e, err := NewEvaluator(nodeID, []Rule{{Name: "HighCPU", Expr: "cpu_usage_percent > 90"}})
+	if err != nil {
+		t.Fatalf("NewEvaluator failed: %v", err)
+	}
+	now := start
+	e.now = func() time.Time { return now }
+	return e, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestAlertsSharedAcrossNodes(t *testing.T) {
+	a, advanceA := newTestEvaluator(t, "node-a")
+	b, advanceB := newTestEvaluator(t, "node-b")
+	c, _ := newTestEvaluator(t, "node-c")
+
+	b.Evaluate(at(0, metrics.Snapshot{CPUUsagePercent: 99}))
+	a.HandleMessage(b.LocalReport())
+	if got := states(a.Alerts()); got != "HighCPU=firing" {
+		t.Fatalf("Expected node-b's alert on node-a, got %q", got)
+	}
+	if alerts := a.Alerts(); alerts[0].NodeID != "node-b" {
+		t.Errorf("Expected the alert to name node-b, got %+v", alerts[0])
+	}
+
+	// A node joining later learns the alerts through push/pull
+	c.MergeRemoteState(a.LocalState())
+	if got := states(c.Alerts()); got != "HighCPU=firing" {
+		t.Errorf("Expected node-b's alert on node-c, got %q", got)
+	}
+
+	// Resolved alerts clear with node-b's next report
+	b.Evaluate(at(1, metrics.Snapshot{CPUUsagePercent: 10}))
+	advanceB(time.Second)
+	a.HandleMessage(b.LocalReport())
+	if got := states(a.Alerts()); got != "" {
+		t.Errorf("Expected node-b's alert to clear, got %q", got)
+	}
+
+	// Alerts of nodes that stop reporting expire
+	b.Evaluate(at(2, metrics.Snapshot{CPUUsagePercent: 99}))
+	advanceB(time.Second)
+	a.HandleMessage(b.LocalReport())
+	advanceA(reportTTL + 5*time.Second)
+	if got := states(a.Alerts()); got != "" {
+		t.Errorf("Expected node-b's stale alert to expire, got %q", got)
+	}
+}
+
+func TestAlertReportsKeepNewest(t *testing.T) {
+	a, _ := newTestEvaluator(t, "node-a")
+	b, advanceB := newTestEvaluator(t, "node-b")
+
+	b.Evaluate(at(0, metrics.Snapshot{CPUUsagePercent: 99}))
+	older := b.LocalReport()
+	b.Evaluate(at(1, metrics.Snapshot{CPUUsagePercent: 10}))
+	advanceB(time.Second)
+	newer := b.LocalReport()
+
+	a.HandleMessage(newer)
+	a.HandleMessage(older)
+	if got := states(a.Alerts()); got != "" {
+		t.Errorf("Expected the older report to be ignored, got %q", got)
+	}
+
+	// Reports about ourselves are ignored
+	a.HandleMessage([]byte(`[{"node_id":"node-a","alerts":[{"name":"Fake","node_id":"node-a","state":"firing"}],"sent":"2030-01-01T00:00:00Z"}]`))
+	a.HandleMessage([]byte(`not json`))
+	if got := states(a.Alerts()); got != "" {
+		t.Errorf("Expected no alerts, got %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+// webhookTimeout bounds each POST to a webhook
+const webhookTimeout = 10 * time.Second
+
+// Webhook POSTs alerts that start firing or resolve to a URL as JSON:
+//
+//	{"alerts": [{"name": "...", "node_id": "...", "state": "firing", ...}]}
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// WebhookPayload is the body POSTed to a webhook
+type WebhookPayload struct {
+	Alerts []models.Alert `json:"alerts"`
+}
+
+// NewWebhook creates a webhook posting to url
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Notify POSTs the alerts, returning an error unless the webhook answers
+// with a 2xx status
+func (w *Webhook) Notify(ctx context.Context, alerts []models.Alert) error {
+	body, err := json.Marshal(WebhookPayload{Alerts: alerts})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alerts: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert webhook returned %s", resp.Status)
+	}
+	return nil
+}
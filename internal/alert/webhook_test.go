@@ -0,0 +1,46 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"distributed-llm/pkg/models"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	var received WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode the payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	alerts := []models.Alert{{Name: "HighCPU", NodeID: "node-a", State: models.AlertFiring, Value: 95}}
+	if err := NewWebhook(server.URL).Notify(context.Background(), alerts); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(received.Alerts) != 1 || received.Alerts[0].Name != "HighCPU" || received.Alerts[0].State != models.AlertFiring {
+		t.Errorf("Expected the firing alert, got %+v", received)
+	}
+}
+
+func TestWebhookNotifyFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if err := NewWebhook(server.URL).Notify(context.Background(), nil); err == nil {
+		t.Error("Expected an error for a 503")
+	}
+	if err := NewWebhook("http://127.0.0.1:1").Notify(context.Background(), nil); err == nil {
+		t.Error("Expected an error for an unreachable webhook")
+	}
+}
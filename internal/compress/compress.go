@@ -0,0 +1,184 @@
+// Package compress registers the gRPC compressors agents can negotiate for
+// activation streams: zstd and lz4 alongside gRPC's own gzip. Importing it
+// is enough for a server to accept all of them.
+package compress
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Compressor names, as used in configuration and on the wire
+const (
+	None = "none"
+	Gzip = gzip.Name
+	Zstd = "zstd"
+	LZ4  = "lz4"
+)
+
+// Names returns every supported compressor
+func Names() []string {
+	return []string{None, LZ4, Zstd, Gzip}
+}
+
+// Parse validates a compressor name; the empty name means None
+func Parse(name string) (string, error) {
+	if name == "" {
+		return None, nil
+	}
+	if !slices.Contains(Names(), name) {
+		return "", fmt.Errorf("unknown compression %q (want one of %v)", name, Names())
+	}
+	return name, nil
+}
+
+// Feature is the capability an agent advertises when it can decompress name
+func Feature(name string) string {
+	return "compress-" + name
+}
+
+// Features returns the capabilities of every compressor this build supports
+func Features() []string {
+	var features []string
+	for _, name := range Names() {
+		if name != None {
+			features = append(features, Feature(name))
+		}
+	}
+	return features
+}
+
+// Negotiate returns the compressor to use towards a peer: the preferred one
+// when the peer advertises it, otherwise None, which every peer accepts
+func Negotiate(preferred string, peerFeatures []string) string {
+	if preferred == None || !slices.Contains(peerFeatures, Feature(preferred)) {
+		return None
+	}
+	return preferred
+}
+
+// CallOption returns the gRPC call option that compresses requests with name
+func CallOption(name string) grpc.CallOption {
+	if name == None || name == "" {
+		return grpc.EmptyCallOption{}
+	}
+	return grpc.UseCompressor(name)
+}
+
+func init() {
+	encoding.RegisterCompressor(newCompressor(Zstd,
+		func() (resetWriter, error) {
+			return zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		},
+		func() (resetReader, error) {
+			return zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		},
+	))
+	encoding.RegisterCompressor(newCompressor(LZ4,
+		func() (resetWriter, error) { return lz4.NewWriter(nil), nil },
+		func() (resetReader, error) { return lz4Reader{lz4.NewReader(nil)}, nil },
+	))
+}
+
+// resetWriter is a compressing writer that can be reused for another stream
+type resetWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// resetReader is a decompressing reader that can be reused for another stream
+type resetReader interface {
+	io.Reader
+	Reset(r io.Reader) error
+}
+
+// compressor implements encoding.Compressor with pools of writers and
+// readers, which are expensive to create
+type compressor struct {
+	name    string
+	writers sync.Pool
+	readers sync.Pool
+	newW    func() (resetWriter, error)
+	newR    func() (resetReader, error)
+}
+
+func newCompressor(name string, newW func() (resetWriter, error), newR func() (resetReader, error)) *compressor {
+	return &compressor{name: name, newW: newW, newR: newR}
+}
+
+func (c *compressor) Name() string {
+	return c.name
+}
+
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	zw, ok := c.writers.Get().(resetWriter)
+	if !ok {
+		var err error
+		if zw, err = c.newW(); err != nil {
+			return nil, err
+		}
+	}
+	zw.Reset(w)
+	return &pooledWriter{resetWriter: zw, pool: &c.writers}, nil
+}
+
+func (c *compressor) Decompress(r io.Reader) (io.Reader, error) {
+	zr, ok := c.readers.Get().(resetReader)
+	if !ok {
+		var err error
+		if zr, err = c.newR(); err != nil {
+			return nil, err
+		}
+	}
+	if err := zr.Reset(r); err != nil {
+		c.readers.Put(zr)
+		return nil, err
+	}
+	return &pooledReader{resetReader: zr, pool: &c.readers}, nil
+}
+
+// pooledWriter returns its writer to the pool once the message is flushed
+type pooledWriter struct {
+	resetWriter
+	pool *sync.Pool
+}
+
+func (w *pooledWriter) Close() error {
+	defer w.pool.Put(w.resetWriter)
+	return w.resetWriter.Close()
+}
+
+// pooledReader returns its reader to the pool once the message is read
+type pooledReader struct {
+	resetReader
+	pool *sync.Pool
+	done bool
+}
+
+func (r *pooledReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	n, err := r.resetReader.Read(p)
+	if err == io.EOF {
+		r.done = true
+		r.pool.Put(r.resetReader)
+	}
+	return n, err
+}
+
+// lz4Reader adapts the lz4 reader, whose Reset cannot fail
+type lz4Reader struct{ *lz4.Reader }
+
+func (l lz4Reader) Reset(r io.Reader) error {
+	l.Reader.Reset(r)
+	return nil
+}
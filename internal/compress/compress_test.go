@@ -0,0 +1,149 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+
+	"distributed-llm/internal/tensor"
+)
+
+// activationFrame returns a framed tensor of hidden states shaped like a
+// transformer's: mostly small normal values with a few outlier channels
+func activationFrame(tb testing.TB, dtype tensor.DType, tokens, hidden int) []byte {
+	tb.Helper()
+	rng := rand.New(rand.NewPCG(1, 2))
+	values := make([]float32, tokens*hidden)
+	for i := range values {
+		values[i] = float32(rng.NormFloat64())
+		if i%hidden%997 == 0 {
+			values[i] *= 40
+		}
+	}
+	t, err := tensor.Encode(dtype, []int64{int64(tokens), int64(hidden)}, values)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	frame, err := tensor.AppendFrame(nil, t)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return frame
+}
+
+func roundTrip(tb testing.TB, c encoding.Compressor, data []byte) (compressed int, out []byte) {
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	if err != nil {
+		tb.Fatalf("Compress failed: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		tb.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		tb.Fatalf("Close failed: %v", err)
+	}
+	compressed = buf.Len()
+
+	r, err := c.Decompress(&buf)
+	if err != nil {
+		tb.Fatalf("Decompress failed: %v", err)
+	}
+	out, err = io.ReadAll(r)
+	if err != nil {
+		tb.Fatalf("Read failed: %v", err)
+	}
+	return compressed, out
+}
+
+func TestCompressorsRoundTrip(t *testing.T) {
+	frame := activationFrame(t, tensor.Float16, 16, 4096)
+
+	for _, name := range Names() {
+		if name == None {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			c := encoding.GetCompressor(name)
+			if c == nil {
+				t.Fatalf("Compressor %s is not registered", name)
+			}
+			// Reuse pooled writers and readers
+			for i := 0; i < 3; i++ {
+				if _, out := roundTrip(t, c, frame); !bytes.Equal(out, frame) {
+					t.Fatalf("Round trip %d changed the data", i)
+				}
+			}
+			if _, out := roundTrip(t, c, nil); len(out) != 0 {
+				t.Errorf("Expected an empty message to stay empty, got %d bytes", len(out))
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	peer := []string{"grpc-join", Feature(LZ4), Feature(Gzip)}
+
+	tests := []struct {
+		preferred string
+		want      string
+	}{
+		{LZ4, LZ4},
+		{Gzip, Gzip},
+		{Zstd, None},
+		{None, None},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.preferred, peer); got != tt.want {
+			t.Errorf("Negotiate(%s) = %s, want %s", tt.preferred, got, tt.want)
+		}
+	}
+	if got := Negotiate(LZ4, nil); got != None {
+		t.Errorf("Expected peers without features to get no compression, got %s", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, name := range append(Names(), "") {
+		if _, err := Parse(name); err != nil {
+			t.Errorf("Parse(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := Parse("snappy"); err == nil {
+		t.Error("Expected an error for an unknown compressor")
+	}
+}
+
+// BenchmarkCompressors measures each compressor on the activations of a
+// 4096-wide model: one decode step and a 512-token prefill, at each
+// quantization. "ratio" is the compressed size over the original.
+func BenchmarkCompressors(b *testing.B) {
+	sizes := []struct {
+		name   string
+		tokens int
+	}{
+		{"decode-1x4096", 1},
+		{"prefill-512x4096", 512},
+	}
+
+	for _, size := range sizes {
+		for _, dtype := range []tensor.DType{tensor.Float32, tensor.Float16, tensor.Int8} {
+			frame := activationFrame(b, dtype, size.tokens, 4096)
+			for _, name := range []string{LZ4, Zstd, Gzip} {
+				c := encoding.GetCompressor(name)
+				b.Run(size.name+"/"+dtype.String()+"/"+name, func(b *testing.B) {
+					b.SetBytes(int64(len(frame)))
+					var compressed int
+					for i := 0; i < b.N; i++ {
+						compressed, _ = roundTrip(b, c, frame)
+					}
+					b.ReportMetric(float64(compressed)/float64(len(frame)), "ratio")
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultHost is where the Docker Engine API listens
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// AgentLabel marks agent containers, e.g. in docker-compose.yml
+const AgentLabel = "distributed-llm.role=agent"
+
+// requestTimeout bounds API calls other than the event stream
+const requestTimeout = 10 * time.Second
+
+// Port is a container port and where it is published on the host
+type Port struct {
+	IP          string `json:"IP"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort"`
+	Type        string `json:"Type"`
+}
+
+// Network is a container's address on one Docker network
+type Network struct {
+	IPAddress string `json:"IPAddress"`
+}
+
+// Container is the part of the Engine API container summary we use
+type Container struct {
+	ID              string            `json:"Id"`
+	Names           []string          `json:"Names"`
+	Labels          map[string]string `json:"Labels"`
+	State           string            `json:"State"`
+	Ports           []Port            `json:"Ports"`
+	NetworkSettings struct {
+		Networks map[string]Network `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// Name returns the container name without Docker's leading slash
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// Event is a container event from the Engine API event stream
+type Event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+}
+
+// Client talks to the Docker Engine API
+type Client struct {
+	http *http.Client
+	base string
+}
+
+// NewClient creates a client for the Engine API at host, which takes the
+// DOCKER_HOST forms unix:///path/to/socket and tcp://host:port, or an
+// http:// URL. An empty host means DefaultHost.
+func NewClient(host string) (*Client, error) {
+	if host == "" {
+		host = DefaultHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &Client{http: &http.Client{Transport: transport}, base: "http://docker"}, nil
+	case "tcp", "http":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid docker host %q: missing address", host)
+		}
+		return &Client{http: &http.Client{}, base: "http://" + u.Host}, nil
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q", host)
+	}
+}
+
+// ListContainers returns the running containers carrying label, given as key=value
+func (c *Client) ListContainers(ctx context.Context, label string) ([]Container, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	resp, err := c.get(ctx, "/containers/json", map[string][]string{"label": {label}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var containers []Container
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("failed to decode containers: %w", err)
+	}
+	return containers, nil
+}
+
+// Events streams the events of containers carrying label to handler until
+// ctx is done or the stream breaks. It returns nil only when ctx is done.
+func (c *Client) Events(ctx context.Context, label string, handler func(Event)) error {
+	resp, err := c.get(ctx, "/events", map[string][]string{
+		"type":  {"container"},
+		"label": {label},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event Event
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				return errors.New("docker event stream closed")
+			}
+			return fmt.Errorf("docker event stream failed: %w", err)
+		}
+		handler(event)
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, filters map[string][]string) (*http.Response, error) {
+	target := c.base + path
+	if filters != nil {
+		encoded, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		target += "?filters=" + url.QueryEscape(string(encoded))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker API unavailable: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("docker API %s returned %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
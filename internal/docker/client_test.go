@@ -0,0 +1,144 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDocker emulates the container list and event stream of the Engine API
+type fakeDocker struct {
+	mu         sync.Mutex
+	containers []Container
+	filters    []string
+	events     chan Event
+}
+
+func newFakeDocker(t *testing.T, containers ...Container) (*fakeDocker, *Client) {
+	t.Helper()
+	fake := &fakeDocker{containers: containers, events: make(chan Event, 10)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return fake, client
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.filters = append(f.filters, r.URL.Query().Get("filters"))
+	f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/containers/json":
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.containers)
+	case "/events":
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		encoder := json.NewEncoder(w)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-f.events:
+				encoder.Encode(event)
+				w.(http.Flusher).Flush()
+			}
+		}
+	default:
+		http.Error(w, `{"message":"page not found"}`, http.StatusNotFound)
+	}
+}
+
+// setContainers replaces the running containers and emits an event
+func (f *fakeDocker) setContainers(action string, containers ...Container) {
+	f.mu.Lock()
+	f.containers = containers
+	f.mu.Unlock()
+
+	event := Event{Type: "container", Action: action}
+	f.events <- event
+}
+
+func agentContainer(id, name, ip string, ports ...Port) Container {
+	container := Container{
+		ID:     id,
+		Names:  []string{"/" + name},
+		Labels: map[string]string{"distributed-llm.role": "agent"},
+		State:  "running",
+		Ports:  ports,
+	}
+	container.NetworkSettings.Networks = map[string]Network{"llm-network": {IPAddress: ip}}
+	return container
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		host    string
+		base    string
+		wantErr bool
+	}{
+		{"", "http://docker", false},
+		{"unix:///run/user/1000/docker.sock", "http://docker", false},
+		{"tcp://10.0.0.5:2375", "http://10.0.0.5:2375", false},
+		{"http://127.0.0.1:2375", "http://127.0.0.1:2375", false},
+		{"tcp://", "", true},
+		{"ssh://user@host", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			client, err := NewClient(tt.host)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && client.base != tt.base {
+				t.Errorf("Expected base %s, got %s", tt.base, client.base)
+			}
+		})
+	}
+}
+
+func TestListContainers(t *testing.T) {
+	fake, client := newFakeDocker(t, agentContainer("abc", "agent-1", "172.18.0.2"))
+
+	containers, err := client.ListContainers(context.Background(), AgentLabel)
+	if err != nil {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+	if len(containers) != 1 || containers[0].Name() != "agent-1" {
+		t.Errorf("Expected agent-1, got %+v", containers)
+	}
+	if want := `{"label":["distributed-llm.role=agent"]}`; fake.filters[0] != want {
+		t.Errorf("Expected filters %s, got %s", want, fake.filters[0])
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"client version 1.99 is too new"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(server.URL)
+	_, err := client.ListContainers(context.Background(), AgentLabel)
+	if err == nil || !strings.Contains(err.Error(), "too new") {
+		t.Errorf("Expected the API error message, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Events(ctx, AgentLabel, func(Event) {}); err == nil {
+		t.Error("Expected error from event stream")
+	}
+}
@@ -0,0 +1,258 @@
+package docker
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GRPCPortLabel overrides the gRPC port of an agent container
+const GRPCPortLabel = "distributed-llm.grpc-port"
+
+// Agent is a running agent container
+type Agent struct {
+	ID   string
+	Name string
+	// IP is the container's address on its Docker network
+	IP       string
+	GRPCPort int
+	// PublishedAddr is where the gRPC port is published on the host, if it is
+	PublishedAddr string
+}
+
+// NetworkAddr returns the gRPC address on the container's Docker network
+func (a Agent) NetworkAddr() string {
+	if a.IP == "" {
+		return ""
+	}
+	return net.JoinHostPort(a.IP, strconv.Itoa(a.GRPCPort))
+}
+
+// AgentHandler is told when agent containers start or stop
+type AgentHandler interface {
+	AgentStarted(agent Agent)
+	AgentStopped(agent Agent)
+}
+
+// DiscoveryConfig selects the agent containers to watch
+type DiscoveryConfig struct {
+	// Label selects agent containers, as key=value
+	Label string
+	// GRPCPort is the container port agents serve gRPC on, unless the
+	// container sets GRPCPortLabel
+	GRPCPort int
+	// Network picks the Docker network whose address is used; by default
+	// the first network of each container, by name
+	Network string
+	// PreferPublished uses the published host port when there is one, for
+	// clients running outside Docker where container IPs may be unreachable
+	PreferPublished bool
+}
+
+// ContainerDiscovery finds agent containers through the Docker Engine API. It
+// lists the labelled containers and relists whenever the event stream reports
+// a container starting, stopping or changing.
+type ContainerDiscovery struct {
+	mu      sync.Mutex
+	client  *Client
+	config  DiscoveryConfig
+	handler AgentHandler
+	agents  map[string]Agent
+	logger  *slog.Logger
+}
+
+// NewContainerDiscovery creates a discovery for the containers matching config
+func NewContainerDiscovery(client *Client, config DiscoveryConfig) *ContainerDiscovery {
+	if config.Label == "" {
+		config.Label = AgentLabel
+	}
+	if config.GRPCPort == 0 {
+		config.GRPCPort = 8080
+	}
+	return &ContainerDiscovery{
+		client: client,
+		config: config,
+		agents: make(map[string]Agent),
+		logger: slog.With("component", "docker-discovery"),
+	}
+}
+
+// SetAgentHandler sets the handler told about containers found after it is set
+func (d *ContainerDiscovery) SetAgentHandler(handler AgentHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handler = handler
+}
+
+// Start lists the agent containers and follows container events until ctx is
+// done. It fails if the Docker API is unreachable.
+func (d *ContainerDiscovery) Start(ctx context.Context) error {
+	if err := d.sync(ctx); err != nil {
+		return err
+	}
+	go d.watch(ctx)
+
+	d.logger.Info("Docker discovery started", "label", d.config.Label, "agents", len(d.Agents()))
+	return nil
+}
+
+// watch follows the event stream, reconnecting with backoff and relisting
+// after every reconnect so no start or stop is missed
+func (d *ContainerDiscovery) watch(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := d.client.Events(ctx, d.config.Label, func(event Event) {
+			// Health checks run as exec_* events every few seconds
+			if strings.HasPrefix(event.Action, "exec_") {
+				return
+			}
+			if err := d.sync(ctx); err != nil {
+				d.logger.Debug("Failed to list containers", "error", err)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		d.logger.Debug("Docker event stream interrupted, reconnecting", "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+		if err := d.sync(ctx); err == nil {
+			backoff = time.Second
+		}
+	}
+}
+
+// sync lists the running agent containers and reports what changed
+func (d *ContainerDiscovery) sync(ctx context.Context) error {
+	containers, err := d.client.ListContainers(ctx, d.config.Label)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]Agent)
+	for _, container := range containers {
+		if container.State != "" && container.State != "running" {
+			continue
+		}
+		if agent := d.agent(container); agent.NetworkAddr() != "" || agent.PublishedAddr != "" {
+			current[agent.ID] = agent
+		}
+	}
+
+	d.mu.Lock()
+	previous := d.agents
+	d.agents = current
+	handler := d.handler
+	d.mu.Unlock()
+
+	for id, agent := range previous {
+		if now, ok := current[id]; !ok || now != agent {
+			d.logger.Info("Agent container stopped", "container", agent.Name)
+			if handler != nil {
+				handler.AgentStopped(agent)
+			}
+		}
+	}
+	for id, agent := range current {
+		if before, ok := previous[id]; !ok || before != agent {
+			d.logger.Info("Agent container started", "container", agent.Name, "address", d.GRPCAddr(agent))
+			if handler != nil {
+				handler.AgentStarted(agent)
+			}
+		}
+	}
+	return nil
+}
+
+// agent reads the addresses of a container
+func (d *ContainerDiscovery) agent(container Container) Agent {
+	port := d.config.GRPCPort
+	if value, ok := container.Labels[GRPCPortLabel]; ok {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			port = n
+		}
+	}
+
+	agent := Agent{ID: container.ID, Name: container.Name(), IP: d.containerIP(container), GRPCPort: port}
+	for _, p := range container.Ports {
+		if p.PrivatePort != port || p.PublicPort == 0 || (p.Type != "" && p.Type != "tcp") {
+			continue
+		}
+		host := p.IP
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "localhost"
+		}
+		agent.PublishedAddr = net.JoinHostPort(host, strconv.Itoa(p.PublicPort))
+		break
+	}
+	return agent
+}
+
+// containerIP returns the container's address on the configured network, or
+// on the first of its networks by name
+func (d *ContainerDiscovery) containerIP(container Container) string {
+	networks := container.NetworkSettings.Networks
+	if d.config.Network != "" {
+		return networks[d.config.Network].IPAddress
+	}
+
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ip := networks[name].IPAddress; ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+// GRPCAddr returns the address to reach agent on, following PreferPublished
+func (d *ContainerDiscovery) GRPCAddr(agent Agent) string {
+	if agent.PublishedAddr != "" && (d.config.PreferPublished || agent.IP == "") {
+		return agent.PublishedAddr
+	}
+	return agent.NetworkAddr()
+}
+
+// Agents returns the running agent containers sorted by name
+func (d *ContainerDiscovery) Agents() []Agent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	agents := make([]Agent, 0, len(d.agents))
+	for _, agent := range d.agents {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	return agents
+}
+
+// Seeds returns the gRPC addresses of the running agents. It satisfies
+// network.SeedProvider.
+func (d *ContainerDiscovery) Seeds(ctx context.Context) ([]string, error) {
+	agents := d.Agents()
+	seeds := make([]string, len(agents))
+	for i, agent := range agents {
+		seeds[i] = d.GRPCAddr(agent)
+	}
+	return seeds, nil
+}
+
+func (d *ContainerDiscovery) String() string {
+	return "docker:" + d.config.Label
+}
@@ -0,0 +1,131 @@
+package docker
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler collects agent events
+type recordingHandler struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHandler) AgentStarted(agent Agent) { h.record("started " + agent.Name) }
+func (h *recordingHandler) AgentStopped(agent Agent) { h.record("stopped " + agent.Name) }
+
+func (h *recordingHandler) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+}
+
+// waitFor polls until the handler has seen event
+func (h *recordingHandler) waitFor(t *testing.T, event string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		for _, e := range h.events {
+			if e == event {
+				h.mu.Unlock()
+				return
+			}
+		}
+		h.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t.Fatalf("Expected event %q, got %v", event, h.events)
+}
+
+func TestContainerDiscoveryAddresses(t *testing.T) {
+	published := agentContainer("a", "agent-1", "172.18.0.2",
+		Port{IP: "0.0.0.0", PrivatePort: 8080, PublicPort: 18080, Type: "tcp"},
+		Port{IP: "0.0.0.0", PrivatePort: 9090, PublicPort: 19090, Type: "tcp"})
+	custom := agentContainer("b", "agent-2", "172.18.0.3")
+	custom.Labels[GRPCPortLabel] = "8081"
+	stopped := agentContainer("c", "agent-3", "172.18.0.4")
+	stopped.State = "exited"
+	noNetwork := agentContainer("d", "agent-4", "")
+
+	_, client := newFakeDocker(t, published, custom, stopped, noNetwork)
+
+	tests := []struct {
+		name   string
+		config DiscoveryConfig
+		want   []string
+	}{
+		{"network addresses", DiscoveryConfig{}, []string{"172.18.0.2:8080", "172.18.0.3:8081"}},
+		{"published ports", DiscoveryConfig{PreferPublished: true}, []string{"localhost:18080", "172.18.0.3:8081"}},
+		{"other network", DiscoveryConfig{Network: "bridge", PreferPublished: true}, []string{"localhost:18080"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			discovery := NewContainerDiscovery(client, tt.config)
+			if err := discovery.Start(ctx); err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+			seeds, _ := discovery.Seeds(ctx)
+			if !reflect.DeepEqual(seeds, tt.want) {
+				t.Errorf("Expected seeds %v, got %v", tt.want, seeds)
+			}
+		})
+	}
+}
+
+func TestContainerDiscoveryEvents(t *testing.T) {
+	agent1 := agentContainer("a", "agent-1", "172.18.0.2")
+	agent2 := agentContainer("b", "agent-2", "172.18.0.3")
+	fake, client := newFakeDocker(t, agent1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	discovery := NewContainerDiscovery(client, DiscoveryConfig{})
+	if err := discovery.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	handler := &recordingHandler{}
+	discovery.SetAgentHandler(handler)
+
+	fake.setContainers("start", agent1, agent2)
+	handler.waitFor(t, "started agent-2")
+
+	fake.setContainers("die", agent2)
+	handler.waitFor(t, "stopped agent-1")
+
+	if agents := discovery.Agents(); len(agents) != 1 || agents[0].Name != "agent-2" {
+		t.Errorf("Expected only agent-2 running, got %+v", agents)
+	}
+
+	// exec events from health checks do not trigger a relist
+	fake.mu.Lock()
+	requests := len(fake.filters)
+	fake.mu.Unlock()
+	fake.events <- Event{Type: "container", Action: "exec_start: wget"}
+	time.Sleep(50 * time.Millisecond)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.filters) != requests {
+		t.Errorf("Expected no relist for exec events, got %d new requests", len(fake.filters)-requests)
+	}
+}
+
+func TestContainerDiscoveryUnavailable(t *testing.T) {
+	client, err := NewClient("unix:///nonexistent/docker.sock")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := NewContainerDiscovery(client, DiscoveryConfig{}).Start(context.Background()); err == nil {
+		t.Error("Expected Start to fail without a Docker daemon")
+	}
+}
@@ -0,0 +1,178 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+// DefaultRetention is how many of the latest events a bus keeps for
+// subscribers resuming from a sequence number
+const DefaultRetention = 1024
+
+// subscriberBuffer is how many new events a subscriber may fall behind by
+// before it is dropped
+const subscriberBuffer = 256
+
+var (
+	// ErrExpired means events after the requested sequence number are no
+	// longer retained
+	ErrExpired = errors.New("events after the sequence number are no longer retained")
+	// ErrAhead means the requested sequence number was not published yet,
+	// usually because the agent restarted
+	ErrAhead = errors.New("sequence number not published yet")
+	// ErrSlow ends subscriptions that fell too far behind. They may resume
+	// after the last event they received.
+	ErrSlow = errors.New("subscriber fell behind")
+	// ErrClosed ends subscriptions when the bus is closed
+	ErrClosed = errors.New("event bus closed")
+)
+
+// Bus numbers the events published on an agent and delivers them to
+// subscribers, keeping the latest ones so that subscribers can resume
+// where they left off
+type Bus struct {
+	mu  sync.Mutex
+	now func() time.Time
+	// ring holds the retained events, the oldest at head
+	ring  []models.Event
+	head  int
+	count int
+	// last is the sequence number of the latest event
+	last   uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBus creates a bus retaining the given number of events, or
+// DefaultRetention if it isn't positive
+func NewBus(retention int) *Bus {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Bus{
+		now:  time.Now,
+		ring: make([]models.Event, retention),
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish numbers the event, stamps it with the current time unless it has
+// one, and delivers it to every subscriber. Subscribers too slow to take it
+// are dropped with ErrSlow.
+func (b *Bus) Publish(event models.Event) models.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return event
+	}
+
+	b.last++
+	event.Sequence = b.last
+	if event.Time.IsZero() {
+		event.Time = b.now()
+	}
+	if b.count < len(b.ring) {
+		b.ring[(b.head+b.count)%len(b.ring)] = event
+		b.count++
+	} else {
+		b.ring[b.head] = event
+		b.head = (b.head + 1) % len(b.ring)
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			b.endLocked(sub, ErrSlow)
+		}
+	}
+	return event
+}
+
+// Latest returns the sequence number of the latest event, 0 before any
+func (b *Bus) Latest() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
+
+// Subscribe delivers the retained events after the given sequence number,
+// then every new event. An after of 0 replays every retained event.
+func (b *Bus) Subscribe(after uint64) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if after > b.last {
+		return nil, ErrAhead
+	}
+	oldest := b.last - uint64(b.count) + 1
+	if after != 0 && after+1 < oldest {
+		return nil, ErrExpired
+	}
+
+	replay := b.count
+	if after >= oldest {
+		replay = int(b.last - after)
+	}
+	sub := &Subscription{
+		bus: b,
+		ch:  make(chan models.Event, replay+subscriberBuffer),
+	}
+	for i := b.count - replay; i < b.count; i++ {
+		sub.ch <- b.ring[(b.head+i)%len(b.ring)]
+	}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Close ends every subscription with ErrClosed; later events are discarded
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.endLocked(sub, ErrClosed)
+	}
+}
+
+func (b *Bus) endLocked(sub *Subscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.ch)
+}
+
+// Subscription receives the events published on a bus
+type Subscription struct {
+	bus *Bus
+	ch  chan models.Event
+	err error
+}
+
+// Events returns the channel the events arrive on. It is closed when the
+// subscription ends, after which Err tells why.
+func (s *Subscription) Events() <-chan models.Event {
+	return s.ch
+}
+
+// Err returns ErrSlow or ErrClosed once the bus ended the subscription,
+// nil otherwise
+func (s *Subscription) Err() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.endLocked(s, nil)
+}
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+// publish publishes n events
+func publish(b *Bus, n int) {
+	for i := 0; i < n; i++ {
+		b.Publish(models.Event{Type: models.EventModelLoaded, ModelID: "llama-7b"})
+	}
+}
+
+// sequences drains the events already delivered to a subscription
+func sequences(sub *Subscription) []uint64 {
+	var seqs []uint64
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return seqs
+			}
+			seqs = append(seqs, event.Sequence)
+		default:
+			return seqs
+		}
+	}
+}
+
+func TestPublishNumbersEvents(t *testing.T) {
+	b := NewBus(0)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	first := b.Publish(models.Event{Type: models.EventNodeJoined, NodeID: "node-b"})
+	stamped := now.Add(-time.Minute)
+	second := b.Publish(models.Event{Type: models.EventNodeLeft, NodeID: "node-b", Time: stamped})
+
+	if first.Sequence != 1 || second.Sequence != 2 || b.Latest() != 2 {
+		t.Errorf("Expected sequences 1 and 2, got %d and %d (latest %d)", first.Sequence, second.Sequence, b.Latest())
+	}
+	if !first.Time.Equal(now) || !second.Time.Equal(stamped) {
+		t.Errorf("Expected the bus to stamp only unstamped events, got %v and %v", first.Time, second.Time)
+	}
+}
+
+func TestSubscribeResume(t *testing.T) {
+	b := NewBus(4)
+	publish(b, 6) // Retains 3..6
+
+	tests := []struct {
+		name    string
+		after   uint64
+		want    []uint64
+		wantErr error
+	}{
+		{"everything retained", 0, []uint64{3, 4, 5, 6}, nil},
+		{"oldest retained", 2, []uint64{3, 4, 5, 6}, nil},
+		{"resume", 4, []uint64{5, 6}, nil},
+		{"up to date", 6, nil, nil},
+		{"expired", 1, nil, ErrExpired},
+		{"ahead", 7, nil, ErrAhead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := b.Subscribe(tt.after)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer sub.Close()
+			if got := sequences(sub); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected sequences %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSubscribeDeliversNewEvents(t *testing.T) {
+	b := NewBus(0)
+	publish(b, 2)
+	sub, err := b.Subscribe(b.Latest())
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	publish(b, 2)
+	if got := sequences(sub); !slices.Equal(got, []uint64{3, 4}) {
+		t.Errorf("Expected the new events, got %v", got)
+	}
+
+	sub.Close()
+	publish(b, 1)
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected no events after Close")
+	}
+	if sub.Err() != nil {
+		t.Errorf("Expected no error after Close, got %v", sub.Err())
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	b := NewBus(0)
+	slow, _ := b.Subscribe(0)
+	fast, _ := b.Subscribe(0)
+
+	// The fast subscriber keeps up while the slow one reads nothing
+	var last uint64
+	for i := 0; i < subscriberBuffer+1; i++ {
+		publish(b, 1)
+		last = (<-fast.Events()).Sequence
+	}
+
+	received := sequences(slow)
+	if len(received) != subscriberBuffer || !errors.Is(slow.Err(), ErrSlow) {
+		t.Fatalf("Expected the slow subscriber dropped after %d events, got %d (err %v)", subscriberBuffer, len(received), slow.Err())
+	}
+	if fast.Err() != nil || last != uint64(subscriberBuffer+1) {
+		t.Errorf("Expected the fast subscriber to keep up, got %d (err %v)", last, fast.Err())
+	}
+
+	// It resumes after the last event it received
+	resumed, err := b.Subscribe(received[len(received)-1])
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if got := sequences(resumed); !slices.Equal(got, []uint64{uint64(subscriberBuffer + 1)}) {
+		t.Errorf("Expected the missed event, got %v", got)
+	}
+}
+
+func TestBusClose(t *testing.T) {
+	b := NewBus(0)
+	sub, _ := b.Subscribe(0)
+	b.Close()
+
+	if _, ok := <-sub.Events(); ok || !errors.Is(sub.Err(), ErrClosed) {
+		t.Errorf("Expected the subscription to end with ErrClosed, got %v", sub.Err())
+	}
+	if event := b.Publish(models.Event{Type: models.EventNodeJoined}); event.Sequence != 0 {
+		t.Errorf("Expected events published after Close to be discarded, got %+v", event)
+	}
+	if _, err := b.Subscribe(0); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
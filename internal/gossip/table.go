@@ -0,0 +1,141 @@
+// Package gossip keeps the reports nodes gossip about themselves, such as
+// their queue depths, quota usage, alerts and latency measurements.
+package gossip
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Report is embedded in every gossiped report, naming the node it is about
+// and when that node sent it
+type Report struct {
+	NodeID string    `json:"node_id"`
+	Sent   time.Time `json:"sent"`
+}
+
+func (r *Report) header() *Report { return r }
+
+// report is a pointer to a type embedding Report
+type report[T any] interface {
+	*T
+	header() *Report
+}
+
+// Table holds the latest report of every node, including this one. Reports
+// of other nodes go stale ttl after they were sent, if ttl is positive.
+// A table is not safe for concurrent use; its owner guards it.
+type Table[T any, P report[T]] struct {
+	nodeID  string
+	ttl     time.Duration
+	reports map[string]P
+}
+
+// NewTable creates a table holding local as this node's report
+func NewTable[T any, P report[T]](nodeID string, ttl time.Duration, local P) *Table[T, P] {
+	t := &Table[T, P]{nodeID: nodeID, ttl: ttl, reports: make(map[string]P)}
+	t.SetLocal(local)
+	return t
+}
+
+// Local returns this node's report
+func (t *Table[T, P]) Local() P {
+	return t.reports[t.nodeID]
+}
+
+// SetLocal replaces this node's report
+func (t *Table[T, P]) SetLocal(local P) {
+	local.header().NodeID = t.nodeID
+	t.reports[t.nodeID] = local
+}
+
+// Get returns the report of a node, stale or not
+func (t *Table[T, P]) Get(nodeID string) (P, bool) {
+	r, ok := t.reports[nodeID]
+	return r, ok
+}
+
+// Put stores the report of another node, until a newer one is merged
+func (t *Table[T, P]) Put(r P) {
+	if id := r.header().NodeID; id != "" && id != t.nodeID {
+		t.reports[id] = r
+	}
+}
+
+// Delete forgets another node's report
+func (t *Table[T, P]) Delete(nodeID string) {
+	if nodeID != t.nodeID {
+		delete(t.reports, nodeID)
+	}
+}
+
+// Each calls fn with this node's report and every fresh one of other nodes
+func (t *Table[T, P]) Each(now time.Time, fn func(P)) {
+	for id, r := range t.reports {
+		if id == t.nodeID || !t.stale(r, now) {
+			fn(r)
+		}
+	}
+}
+
+// Expire forgets the stale reports of other nodes
+func (t *Table[T, P]) Expire(now time.Time) {
+	for id, r := range t.reports {
+		if id != t.nodeID && t.stale(r, now) {
+			delete(t.reports, id)
+		}
+	}
+}
+
+func (t *Table[T, P]) stale(r P, now time.Time) bool {
+	return t.ttl > 0 && now.Sub(r.header().Sent) > t.ttl
+}
+
+// EncodeLocal encodes this node's report, sent now, for gossip
+func (t *Table[T, P]) EncodeLocal(now time.Time) []byte {
+	return t.encode(now, false)
+}
+
+// Encode encodes every report, this node's sent now, for push/pull
+func (t *Table[T, P]) Encode(now time.Time) []byte {
+	return t.encode(now, true)
+}
+
+func (t *Table[T, P]) encode(now time.Time, all bool) []byte {
+	reports := make([]T, 0, len(t.reports))
+	for id, r := range t.reports {
+		if !all && id != t.nodeID {
+			continue
+		}
+		copied := *r
+		if id == t.nodeID {
+			P(&copied).header().Sent = now
+		}
+		reports = append(reports, copied)
+	}
+	data, err := json.Marshal(reports)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Merge keeps the newest report of each other node from encoded reports
+func (t *Table[T, P]) Merge(data []byte) error {
+	var reports []T
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return err
+	}
+	for i := range reports {
+		r := P(&reports[i])
+		id := r.header().NodeID
+		if id == "" || id == t.nodeID {
+			continue
+		}
+		if existing, ok := t.reports[id]; ok && !r.header().Sent.After(existing.header().Sent) {
+			continue
+		}
+		t.reports[id] = r
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package gossip
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+type countReport struct {
+	Report
+	Count int `json:"count"`
+}
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func encode(t *testing.T, reports ...countReport) []byte {
+	t.Helper()
+	data, err := json.Marshal(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func counts(table *Table[countReport, *countReport], now time.Time) map[string]int {
+	got := make(map[string]int)
+	table.Each(now, func(r *countReport) { got[r.NodeID] = r.Count })
+	return got
+}
+
+func TestTableMerge(t *testing.T) {
+	report := func(node string, count int, sent time.Time) countReport {
+		return countReport{Report: Report{NodeID: node, Sent: sent}, Count: count}
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"newer report", encode(t, report("node-b", 2, start.Add(time.Second))), 2},
+		{"older report", encode(t, report("node-b", 3, start.Add(-time.Second))), 1},
+		{"report as old", encode(t, report("node-b", 4, start)), 1},
+		{"report about this node", encode(t, report("node-a", 5, start.Add(time.Second))), 1},
+		{"report without a node", encode(t, report("", 6, start.Add(time.Second))), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := NewTable("node-a", 0, &countReport{})
+			if err := table.Merge(encode(t, report("node-b", 1, start))); err != nil {
+				t.Fatalf("Merge failed: %v", err)
+			}
+			if err := table.Merge(tt.data); err != nil {
+				t.Fatalf("Merge failed: %v", err)
+			}
+			got := counts(table, start)
+			if got["node-b"] != tt.want || got["node-a"] != 0 || len(got) != 2 {
+				t.Errorf("Expected node-b at %d, got %v", tt.want, got)
+			}
+		})
+	}
+
+	table := NewTable("node-a", 0, &countReport{})
+	if err := table.Merge([]byte("not json")); err == nil {
+		t.Error("Expected malformed reports to be rejected")
+	}
+}
+
+func TestTableStaleReports(t *testing.T) {
+	table := NewTable("node-a", 10*time.Second, &countReport{Count: 1})
+	table.Merge(encode(t,
+		countReport{Report: Report{NodeID: "node-b", Sent: start}, Count: 2},
+		countReport{Report: Report{NodeID: "node-c", Sent: start.Add(5 * time.Second)}, Count: 3},
+	))
+
+	now := start.Add(12 * time.Second)
+	if got := counts(table, now); len(got) != 2 || got["node-a"] != 1 || got["node-c"] != 3 {
+		t.Errorf("Expected node-b's report to be stale, got %v", got)
+	}
+	if _, ok := table.Get("node-b"); !ok {
+		t.Error("Expected stale reports to be kept until expired")
+	}
+	table.Expire(now)
+	if _, ok := table.Get("node-b"); ok {
+		t.Error("Expected node-b's stale report to expire")
+	}
+	if _, ok := table.Get("node-c"); !ok {
+		t.Error("Expected node-c's fresh report to be kept")
+	}
+}
+
+func TestTableEncode(t *testing.T) {
+	table := NewTable("node-a", 0, &countReport{Count: 1})
+	table.Put(&countReport{Report: Report{NodeID: "node-b", Sent: start}, Count: 2})
+	now := start.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		nodes []string
+	}{
+		{"local report", table.EncodeLocal(now), []string{"node-a"}},
+		{"every report", table.Encode(now), []string{"node-a", "node-b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []countReport
+			if err := json.Unmarshal(tt.data, &reports); err != nil {
+				t.Fatalf("Failed to decode reports: %v", err)
+			}
+			var nodes []string
+			for _, r := range reports {
+				nodes = append(nodes, r.NodeID)
+				if r.NodeID == "node-a" && !r.Sent.Equal(now) {
+					t.Errorf("Expected this node's report sent now, got %v", r.Sent)
+				}
+				if r.NodeID == "node-b" && !r.Sent.Equal(start) {
+					t.Errorf("Expected node-b's report as received, got %v", r.Sent)
+				}
+			}
+			slices.Sort(nodes)
+			if !slices.Equal(nodes, tt.nodes) {
+				t.Errorf("Expected reports of %v, got %v", tt.nodes, nodes)
+			}
+		})
+	}
+	if !table.Local().Sent.IsZero() {
+		t.Error("Expected encoding to leave this node's report untouched")
+	}
+}
@@ -0,0 +1,390 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultAgentSelector matches the agent pods created by the manifests in deployments/
+const DefaultAgentSelector = "app=distributed-llm-agent"
+
+// Container or EndpointSlice port names for memberlist and the gRPC server
+const (
+	gossipPortName = "gossip"
+	grpcPortName   = "grpc"
+)
+
+// Peer is an agent pod that is ready to gossip
+type Peer struct {
+	Name       string
+	Address    string
+	GossipPort int
+	GRPCPort   int
+}
+
+// GossipAddr returns the peer's memberlist address
+func (p Peer) GossipAddr() string {
+	return net.JoinHostPort(p.Address, strconv.Itoa(p.GossipPort))
+}
+
+// GRPCAddr returns the peer's gRPC address
+func (p Peer) GRPCAddr() string {
+	return net.JoinHostPort(p.Address, strconv.Itoa(p.GRPCPort))
+}
+
+// PeerHandler is told when agent pods become ready or go away
+type PeerHandler interface {
+	PeerReady(peer Peer)
+	PeerGone(peer Peer)
+}
+
+// DiscoveryConfig selects the agent pods to watch
+type DiscoveryConfig struct {
+	Namespace     string
+	LabelSelector string
+	// GossipPort and GRPCPort are used when a pod does not name a "gossip"
+	// or "grpc" port
+	GossipPort int
+	GRPCPort   int
+	// UseEndpointSlices watches EndpointSlices instead of pods; the selector
+	// then applies to slices, e.g. "kubernetes.io/service-name=distributed-llm-agent"
+	UseEndpointSlices bool
+	// SelfAddress is this agent's pod IP, which is never reported as a peer
+	SelfAddress  string
+	ResyncPeriod time.Duration
+	// SyncTimeout bounds the initial listing in Start
+	SyncTimeout time.Duration
+}
+
+// PeerDiscovery watches the Kubernetes API for agent pods using informers
+type PeerDiscovery struct {
+	mu        sync.Mutex
+	clientset kubernetes.Interface
+	config    DiscoveryConfig
+	selector  labels.Selector
+	handler   PeerHandler
+	peers     map[string]Peer
+	// slices tracks the peers contributed by each EndpointSlice
+	slices map[string]map[string]Peer
+	logger *slog.Logger
+}
+
+// NewPeerDiscovery creates a discovery for the agent pods matching config
+func NewPeerDiscovery(clientset kubernetes.Interface, config DiscoveryConfig) (*PeerDiscovery, error) {
+	if config.LabelSelector == "" {
+		config.LabelSelector = DefaultAgentSelector
+	}
+	if config.GossipPort == 0 {
+		config.GossipPort = 7946
+	}
+	if config.GRPCPort == 0 {
+		config.GRPCPort = 8080
+	}
+	if config.Namespace == "" {
+		config.Namespace = metav1.NamespaceDefault
+	}
+	if config.SyncTimeout == 0 {
+		config.SyncTimeout = 30 * time.Second
+	}
+
+	selector, err := labels.Parse(config.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %w", config.LabelSelector, err)
+	}
+
+	return &PeerDiscovery{
+		clientset: clientset,
+		config:    config,
+		selector:  selector,
+		peers:     make(map[string]Peer),
+		slices:    make(map[string]map[string]Peer),
+		logger:    slog.With("component", "k8s-discovery"),
+	}, nil
+}
+
+// SetPeerHandler sets the handler told about peers found after it is set
+func (d *PeerDiscovery) SetPeerHandler(handler PeerHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handler = handler
+}
+
+// Start runs the informers until ctx is done and waits up to SyncTimeout for
+// their initial sync
+func (d *PeerDiscovery) Start(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(d.clientset, d.config.ResyncPeriod,
+		informers.WithNamespace(d.config.Namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = d.config.LabelSelector
+		}),
+	)
+
+	var informer cache.SharedIndexInformer
+	var handler cache.ResourceEventHandlerFuncs
+	if d.config.UseEndpointSlices {
+		informer = factory.Discovery().V1().EndpointSlices().Informer()
+		handler = cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { d.syncSlice(obj) },
+			UpdateFunc: func(_, obj interface{}) { d.syncSlice(obj) },
+			DeleteFunc: func(obj interface{}) { d.deleteSlice(obj) },
+		}
+	} else {
+		informer = factory.Core().V1().Pods().Informer()
+		handler = cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { d.syncPod(obj) },
+			UpdateFunc: func(_, obj interface{}) { d.syncPod(obj) },
+			DeleteFunc: func(obj interface{}) { d.deletePod(obj) },
+		}
+	}
+	if _, err := informer.AddEventHandler(handler); err != nil {
+		return fmt.Errorf("failed to watch agent pods: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, d.config.SyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) {
+		return fmt.Errorf("timed out syncing agent pods in namespace %s", d.config.Namespace)
+	}
+
+	d.logger.Info("Kubernetes peer discovery started",
+		"namespace", d.config.Namespace,
+		"selector", d.config.LabelSelector,
+		"endpointSlices", d.config.UseEndpointSlices,
+		"peers", len(d.Peers()))
+	return nil
+}
+
+// Peers returns the ready peers sorted by name
+func (d *PeerDiscovery) Peers() []Peer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	peers := make([]Peer, 0, len(d.peers))
+	for _, peer := range d.peers {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+	return peers
+}
+
+// GossipSeeds returns the memberlist addresses of the ready peers
+func (d *PeerDiscovery) GossipSeeds() []string {
+	peers := d.Peers()
+	seeds := make([]string, len(peers))
+	for i, peer := range peers {
+		seeds[i] = peer.GossipAddr()
+	}
+	return seeds
+}
+
+// PodSeeds serves the ready peers of a PeerDiscovery as seeds, with their
+// gossip addresses or, when GRPC is set, their gRPC addresses. It satisfies
+// network.SeedProvider.
+type PodSeeds struct {
+	Discovery *PeerDiscovery
+	GRPC      bool
+}
+
+// Seeds returns the addresses of the ready peers
+func (s PodSeeds) Seeds(ctx context.Context) ([]string, error) {
+	peers := s.Discovery.Peers()
+	seeds := make([]string, len(peers))
+	for i, peer := range peers {
+		if s.GRPC {
+			seeds[i] = peer.GRPCAddr()
+		} else {
+			seeds[i] = peer.GossipAddr()
+		}
+	}
+	return seeds, nil
+}
+
+func (s PodSeeds) String() string {
+	return "k8s:" + s.Discovery.config.Namespace + "/" + s.Discovery.config.LabelSelector
+}
+
+func (d *PeerDiscovery) syncPod(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if !d.selector.Matches(labels.Set(pod.Labels)) {
+		d.remove(pod.Name)
+		return
+	}
+	if !podReady(pod) {
+		d.remove(pod.Name)
+		return
+	}
+	d.add(Peer{
+		Name:       pod.Name,
+		Address:    pod.Status.PodIP,
+		GossipPort: podPort(pod, gossipPortName, d.config.GossipPort),
+		GRPCPort:   podPort(pod, grpcPortName, d.config.GRPCPort),
+	})
+}
+
+func (d *PeerDiscovery) deletePod(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*corev1.Pod); ok {
+		d.remove(pod.Name)
+	}
+}
+
+// podReady returns true for running, ready pods that are not shutting down
+func podReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podPort returns the container port called name, or fallback
+func podPort(pod *corev1.Pod, name string, fallback int) int {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == name {
+				return int(port.ContainerPort)
+			}
+		}
+	}
+	return fallback
+}
+
+func (d *PeerDiscovery) syncSlice(obj interface{}) {
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return
+	}
+
+	current := make(map[string]Peer)
+	if d.selector.Matches(labels.Set(slice.Labels)) {
+		gossipPort, grpcPort := d.config.GossipPort, d.config.GRPCPort
+		for _, p := range slice.Ports {
+			if p.Name == nil || p.Port == nil {
+				continue
+			}
+			switch *p.Name {
+			case gossipPortName:
+				gossipPort = int(*p.Port)
+			case grpcPortName:
+				grpcPort = int(*p.Port)
+			}
+		}
+		for _, endpoint := range slice.Endpoints {
+			if !endpointReady(endpoint) || len(endpoint.Addresses) == 0 {
+				continue
+			}
+			name := endpoint.Addresses[0]
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Name != "" {
+				name = endpoint.TargetRef.Name
+			}
+			current[name] = Peer{Name: name, Address: endpoint.Addresses[0], GossipPort: gossipPort, GRPCPort: grpcPort}
+		}
+	}
+
+	d.mu.Lock()
+	previous := d.slices[slice.Name]
+	d.slices[slice.Name] = current
+	d.mu.Unlock()
+
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			d.remove(name)
+		}
+	}
+	for _, peer := range current {
+		d.add(peer)
+	}
+}
+
+func (d *PeerDiscovery) deleteSlice(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	previous := d.slices[slice.Name]
+	delete(d.slices, slice.Name)
+	d.mu.Unlock()
+
+	for name := range previous {
+		d.remove(name)
+	}
+}
+
+// endpointReady treats a missing ready condition as ready, as the API specifies
+func endpointReady(endpoint discoveryv1.Endpoint) bool {
+	if endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating {
+		return false
+	}
+	return endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+}
+
+// add records a ready peer and tells the handler if it is new or moved
+func (d *PeerDiscovery) add(peer Peer) {
+	if peer.Address == d.config.SelfAddress {
+		return
+	}
+
+	d.mu.Lock()
+	existing, known := d.peers[peer.Name]
+	d.peers[peer.Name] = peer
+	handler := d.handler
+	d.mu.Unlock()
+
+	if known && existing == peer {
+		return
+	}
+	d.logger.Info("Agent pod ready", "pod", peer.Name, "address", peer.GossipAddr())
+	if handler != nil {
+		if known {
+			handler.PeerGone(existing)
+		}
+		handler.PeerReady(peer)
+	}
+}
+
+// remove forgets a peer and tells the handler if it was known
+func (d *PeerDiscovery) remove(name string) {
+	d.mu.Lock()
+	existing, known := d.peers[name]
+	delete(d.peers, name)
+	handler := d.handler
+	d.mu.Unlock()
+
+	if !known {
+		return
+	}
+	d.logger.Info("Agent pod gone", "pod", name, "address", existing.GossipAddr())
+	if handler != nil {
+		handler.PeerGone(existing)
+	}
+}
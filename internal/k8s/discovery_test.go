@@ -0,0 +1,227 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// recordingHandler collects peer events
+type recordingHandler struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHandler) PeerReady(peer Peer) {
+	h.record("ready " + peer.Name + " " + peer.GossipAddr())
+}
+func (h *recordingHandler) PeerGone(peer Peer) { h.record("gone " + peer.Name) }
+
+func (h *recordingHandler) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+}
+
+// waitFor polls until the handler has seen event
+func (h *recordingHandler) waitFor(t *testing.T, event string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		for _, e := range h.events {
+			if e == event {
+				h.mu.Unlock()
+				return
+			}
+		}
+		h.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t.Fatalf("Expected event %q, got %v", event, h.events)
+}
+
+func agentPod(name, ip string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "llm",
+			Labels:    map[string]string{"app": "distributed-llm-agent"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "agent",
+				Ports: []corev1.ContainerPort{{Name: "gossip", ContainerPort: 7950}},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIP:      ip,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestPodReady(t *testing.T) {
+	deleting := agentPod("a", "10.0.0.1", true)
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	pending := agentPod("a", "10.0.0.1", true)
+	pending.Status.Phase = corev1.PodPending
+	noIP := agentPod("a", "", true)
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want bool
+	}{
+		{"ready", agentPod("a", "10.0.0.1", true), true},
+		{"not ready", agentPod("a", "10.0.0.1", false), false},
+		{"terminating", deleting, false},
+		{"pending", pending, false},
+		{"no IP", noIP, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podReady(tt.pod); got != tt.want {
+				t.Errorf("podReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeerDiscoveryPods(t *testing.T) {
+	other := agentPod("web-0", "10.0.0.9", true)
+	other.Labels = map[string]string{"app": "web"}
+	clientset := fake.NewSimpleClientset(
+		agentPod("agent-0", "10.0.0.1", true),
+		agentPod("agent-1", "10.0.0.2", false),
+		agentPod("self", "10.0.0.3", true),
+		other,
+	)
+
+	discovery, err := NewPeerDiscovery(clientset, DiscoveryConfig{Namespace: "llm", SelfAddress: "10.0.0.3"})
+	if err != nil {
+		t.Fatalf("NewPeerDiscovery failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := discovery.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	seeds := discovery.GossipSeeds()
+	if len(seeds) != 1 || seeds[0] != "10.0.0.1:7950" {
+		t.Fatalf("Expected only the ready agent pod as seed, got %v", seeds)
+	}
+
+	grpcSeeds, _ := PodSeeds{Discovery: discovery, GRPC: true}.Seeds(ctx)
+	if len(grpcSeeds) != 1 || grpcSeeds[0] != "10.0.0.1:8080" {
+		t.Errorf("Expected the default gRPC port for pods without a grpc port, got %v", grpcSeeds)
+	}
+
+	handler := &recordingHandler{}
+	discovery.SetPeerHandler(handler)
+	pods := clientset.CoreV1().Pods("llm")
+
+	// A pod becoming ready is joined
+	if _, err := pods.UpdateStatus(ctx, agentPod("agent-1", "10.0.0.2", true), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+	handler.waitFor(t, "ready agent-1 10.0.0.2:7950")
+
+	// A pod that starts terminating is removed before it is deleted
+	terminating := agentPod("agent-0", "10.0.0.1", true)
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	if _, err := pods.Update(ctx, terminating, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	handler.waitFor(t, "gone agent-0")
+
+	if err := pods.Delete(ctx, "agent-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	handler.waitFor(t, "gone agent-1")
+
+	if peers := discovery.Peers(); len(peers) != 0 {
+		t.Errorf("Expected no peers left, got %+v", peers)
+	}
+}
+
+func TestPeerDiscoveryEndpointSlices(t *testing.T) {
+	ready, notReady := true, false
+	gossip := "gossip"
+	port := int32(7960)
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "distributed-llm-agent-abc",
+			Namespace: "llm",
+			Labels:    map[string]string{"kubernetes.io/service-name": "distributed-llm-agent"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports:       []discoveryv1.EndpointPort{{Name: &gossip, Port: &port}},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.1.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "agent-0"}},
+			{Addresses: []string{"10.0.1.2"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady},
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "agent-1"}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(slice)
+
+	discovery, err := NewPeerDiscovery(clientset, DiscoveryConfig{
+		Namespace:         "llm",
+		LabelSelector:     "kubernetes.io/service-name=distributed-llm-agent",
+		UseEndpointSlices: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPeerDiscovery failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := discovery.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if seeds := discovery.GossipSeeds(); len(seeds) != 1 || seeds[0] != "10.0.1.1:7960" {
+		t.Fatalf("Expected the ready endpoint as seed, got %v", seeds)
+	}
+
+	handler := &recordingHandler{}
+	discovery.SetPeerHandler(handler)
+	slices := clientset.DiscoveryV1().EndpointSlices("llm")
+
+	// agent-1 becomes ready while agent-0 starts terminating
+	terminating := true
+	updated := slice.DeepCopy()
+	updated.Endpoints[0].Conditions = discoveryv1.EndpointConditions{Ready: &notReady, Terminating: &terminating}
+	updated.Endpoints[1].Conditions = discoveryv1.EndpointConditions{Ready: &ready}
+	if _, err := slices.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	handler.waitFor(t, "gone agent-0")
+	handler.waitFor(t, "ready agent-1 10.0.1.2:7960")
+
+	if err := slices.Delete(ctx, slice.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	handler.waitFor(t, "gone agent-1")
+}
+
+func TestNewPeerDiscoveryInvalidSelector(t *testing.T) {
+	if _, err := NewPeerDiscovery(fake.NewSimpleClientset(), DiscoveryConfig{LabelSelector: "app in (("}); err == nil {
+		t.Error("Expected error for invalid label selector")
+	}
+}
@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"distributed-llm/pkg/models"
+)
+
+// NodeLabelPrefix marks Kubernetes node labels copied to the agents on the
+// node, without the prefix, e.g. distributed-llm.io/rack=r1 gives rack=r1
+const NodeLabelPrefix = "distributed-llm.io/"
+
+// Well-known Kubernetes node labels
+const (
+	zoneNodeLabel       = "topology.kubernetes.io/zone"
+	legacyZoneNodeLabel = "failure-domain.beta.kubernetes.io/zone"
+	hostNodeLabel       = "kubernetes.io/hostname"
+)
+
+// NodeLabels returns the topology labels of the named Kubernetes node: its
+// zone, its hostname (or name) as the host, and any labels under
+// NodeLabelPrefix
+func NodeLabels(ctx context.Context, clientset kubernetes.Interface, nodeName string) (map[string]string, error) {
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+
+	labels := map[string]string{models.LabelHost: nodeName}
+	if host := node.Labels[hostNodeLabel]; host != "" {
+		labels[models.LabelHost] = host
+	}
+	if zone := node.Labels[legacyZoneNodeLabel]; zone != "" {
+		labels[models.LabelZone] = zone
+	}
+	if zone := node.Labels[zoneNodeLabel]; zone != "" {
+		labels[models.LabelZone] = zone
+	}
+	for key, value := range node.Labels {
+		if name, ok := strings.CutPrefix(key, NodeLabelPrefix); ok && name != "" {
+			labels[name] = value
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodeLabels(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name: "worker-1",
+			Labels: map[string]string{
+				"topology.kubernetes.io/zone":            "eu-west-1a",
+				"failure-domain.beta.kubernetes.io/zone": "old-zone",
+				"kubernetes.io/hostname":                 "ip-10-0-0-1",
+				"distributed-llm.io/rack":                "r7",
+				"node.kubernetes.io/instance-type":       "g5.xlarge",
+			},
+		}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}},
+	)
+
+	tests := []struct {
+		node string
+		want map[string]string
+	}{
+		{"worker-1", map[string]string{"zone": "eu-west-1a", "host": "ip-10-0-0-1", "rack": "r7"}},
+		{"worker-2", map[string]string{"host": "worker-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.node, func(t *testing.T) {
+			labels, err := NodeLabels(context.Background(), clientset, tt.node)
+			if err != nil {
+				t.Fatalf("NodeLabels failed: %v", err)
+			}
+			if !reflect.DeepEqual(labels, tt.want) {
+				t.Errorf("Expected labels %v, got %v", tt.want, labels)
+			}
+		})
+	}
+
+	if _, err := NodeLabels(context.Background(), clientset, "missing"); err == nil {
+		t.Error("Expected error for unknown node")
+	}
+}
@@ -0,0 +1,191 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/compress"
+	"distributed-llm/internal/tensor"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// ErrActivationStreamUnsupported is returned for stages on nodes that
+// don't advertise activation streams
+var ErrActivationStreamUnsupported = errors.New("activation streams not supported")
+
+// SetActivationEncoding sets how activations sent to other stages are
+// quantized and which compressor is offered to them. Stages that don't
+// advertise the compressor receive the activations uncompressed.
+func (n *P2PNetwork) SetActivationEncoding(compression string, dtype tensor.DType) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.activationCompression = compression
+	n.activationDType = dtype
+}
+
+func (n *P2PNetwork) activationEncoding() (string, tensor.DType) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	compression, dtype := n.activationCompression, n.activationDType
+	if compression == "" {
+		compression = compress.None
+	}
+	if dtype == 0 {
+		dtype = tensor.Float32
+	}
+	return compression, dtype
+}
+
+// ActivationStream carries one request's activations to a pipeline stage
+// and brings back the stage's outputs
+type ActivationStream struct {
+	stream    pb.NodeService_StreamActivationsClient
+	requestID string
+	modelID   string
+	dtype     tensor.DType
+	sequence  uint32
+
+	// Compression is the compressor negotiated with the stage
+	Compression string
+	// BytesSent and BytesReceived count the frames exchanged, before
+	// compression
+	BytesSent     int64
+	BytesReceived int64
+	// ComputeTime is the time the stage reported computing its replies
+	ComputeTime time.Duration
+}
+
+// OpenActivationStream opens an activation stream for a request to the
+// stage running on nodeID
+func (n *P2PNetwork) OpenActivationStream(ctx context.Context, nodeID, requestID, modelID string) (*ActivationStream, error) {
+	var stage models.Node
+	for _, node := range n.GetNodes() {
+		if node.ID == nodeID {
+			stage = node
+			break
+		}
+	}
+	if !stage.HasFeature(FeatureActivationStream) {
+		return nil, fmt.Errorf("node %s: %w", nodeID, ErrActivationStreamUnsupported)
+	}
+
+	client, err := n.peerClient(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	preferred, dtype := n.activationEncoding()
+	compression := compress.Negotiate(preferred, stage.Features)
+	stream, err := client.StreamActivations(ctx, compress.CallOption(compression))
+	if err != nil {
+		return nil, err
+	}
+	return &ActivationStream{
+		stream:      stream,
+		requestID:   requestID,
+		modelID:     modelID,
+		dtype:       dtype,
+		Compression: compression,
+	}, nil
+}
+
+// Send quantizes the activations for the stage starting at layer and sends
+// them as one frame
+func (s *ActivationStream) Send(layer int32, shape []int64, values []float32) error {
+	t, err := tensor.Encode(s.dtype, shape, values)
+	if err != nil {
+		return err
+	}
+	// gRPC may hold on to the message after Send returns, so every frame
+	// gets its own buffer
+	frame, err := tensor.AppendFrame(make([]byte, 0, tensor.FrameSize(t)), t)
+	if err != nil {
+		return err
+	}
+	s.sequence++
+	s.BytesSent += int64(len(frame))
+	return s.stream.Send(&pb.ActivationFrame{
+		RequestId: s.requestID,
+		ModelId:   s.modelID,
+		Layer:     layer,
+		Sequence:  s.sequence,
+		Tensor:    frame,
+	})
+}
+
+// Recv returns the stage's output for the next frame sent
+func (s *ActivationStream) Recv() (*tensor.Tensor, error) {
+	frame, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	s.BytesReceived += int64(len(frame.Tensor))
+	s.ComputeTime += time.Duration(frame.ComputeMicros) * time.Microsecond
+	t, _, err := tensor.ParseFrame(frame.Tensor)
+	return t, err
+}
+
+// CloseSend tells the stage that no more activations follow
+func (s *ActivationStream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// StreamActivations applies this node's stage to each frame of activations
+// it receives and answers with the output, in the same encoding. Frames are
+// checked against their checksum before use.
+func (s *NodeServer) StreamActivations(stream pb.NodeService_StreamActivationsServer) error {
+	for {
+		frame, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		t, _, err := tensor.ParseFrame(frame.Tensor)
+		switch {
+		case errors.Is(err, tensor.ErrChecksum):
+			return status.Errorf(codes.DataLoss, "request %s frame %d: %v", frame.RequestId, frame.Sequence, err)
+		case err != nil:
+			return status.Errorf(codes.InvalidArgument, "request %s frame %d: %v", frame.RequestId, frame.Sequence, err)
+		}
+		if s.network.metricsCollector != nil {
+			s.network.metricsCollector.RecordNetworkMessage("incoming", "activation")
+		}
+
+		start := time.Now()
+		_, span := s.network.tracer().Start(stream.Context(), spanForward, trace.WithAttributes(
+			attribute.String("inference.model_id", frame.ModelId),
+			attribute.String("request.id", frame.RequestId),
+			attribute.Int("inference.stage", int(frame.Layer)),
+			attribute.String("inference.node_id", s.network.nodeID),
+			attribute.Int("inference.sequence", int(frame.Sequence)),
+		))
+		// Mock stage - a real backend would run the node's layers over the
+		// hidden states; until then they pass through unchanged
+		output, err := tensor.AppendFrame(make([]byte, 0, tensor.FrameSize(t)), t)
+		span.End()
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode output: %v", err)
+		}
+		if err := stream.Send(&pb.ActivationFrame{
+			RequestId:     frame.RequestId,
+			ModelId:       frame.ModelId,
+			Layer:         frame.Layer,
+			Sequence:      frame.Sequence,
+			Tensor:        output,
+			ComputeMicros: time.Since(start).Microseconds(),
+		}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package network
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/compress"
+	"distributed-llm/internal/tensor"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+func TestActivationStream(t *testing.T) {
+	server, err := NewGRPCServer(newTestNetwork(t, "node-b"), findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	go server.Start()
+	t.Cleanup(server.Stop)
+	port := server.listener.Addr().(*net.TCPAddr).Port
+
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	registerNode(network, models.Node{ID: "node-b", Address: "127.0.0.1", Port: port, Features: SupportedFeatures()})
+	registerNode(network, models.Node{ID: "node-c", Address: "127.0.0.1", Port: port, Features: []string{FeatureActivationStream, compress.Feature(compress.Gzip)}})
+	registerNode(network, models.Node{ID: "node-d", Address: "127.0.0.1", Port: port})
+
+	values := make([]float32, 2*4096)
+	for i := range values {
+		values[i] = float32(math.Sin(float64(i)))
+	}
+
+	tests := []struct {
+		name        string
+		node        string
+		compression string
+		dtype       tensor.DType
+		want        string
+		tolerance   float64
+	}{
+		{"uncompressed fp32", "node-b", compress.None, tensor.Float32, compress.None, 0},
+		{"zstd fp16", "node-b", compress.Zstd, tensor.Float16, compress.Zstd, 1e-3},
+		{"lz4 int8", "node-b", compress.LZ4, tensor.Int8, compress.LZ4, 1.0 / 127},
+		{"gzip fp32", "node-b", compress.Gzip, tensor.Float32, compress.Gzip, 0},
+		{"falls back when the stage lacks zstd", "node-c", compress.Zstd, tensor.Float16, compress.None, 1e-3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network.SetActivationEncoding(tt.compression, tt.dtype)
+			stream, err := network.OpenActivationStream(context.Background(), tt.node, "req-1", "llama-7b")
+			if err != nil {
+				t.Fatalf("OpenActivationStream failed: %v", err)
+			}
+			defer stream.CloseSend()
+			if stream.Compression != tt.want {
+				t.Errorf("Expected compression %s, got %s", tt.want, stream.Compression)
+			}
+
+			for i := 0; i < 2; i++ {
+				if err := stream.Send(16, []int64{2, 4096}, values); err != nil {
+					t.Fatalf("Send failed: %v", err)
+				}
+				out, err := stream.Recv()
+				if err != nil {
+					t.Fatalf("Recv failed: %v", err)
+				}
+				if out.DType != tt.dtype || len(out.Shape) != 2 || out.Shape[1] != 4096 {
+					t.Fatalf("Unexpected output tensor %v %v", out.DType, out.Shape)
+				}
+				decoded, err := out.Float32s()
+				if err != nil {
+					t.Fatalf("Float32s failed: %v", err)
+				}
+				for j, v := range values {
+					if diff := math.Abs(float64(decoded[j] - v)); diff > tt.tolerance {
+						t.Fatalf("Element %d: got %v, want %v", j, decoded[j], v)
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := network.OpenActivationStream(context.Background(), "node-d", "req-1", "llama-7b"); err == nil {
+		t.Error("Expected an error for a stage without activation streams")
+	}
+}
+
+func TestStreamActivationsRejectsCorruptFrames(t *testing.T) {
+	server, err := NewGRPCServer(newTestNetwork(t, "node-b"), findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	go server.Start()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(server.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewNodeServiceClient(conn)
+
+	valid, _ := tensor.Encode(tensor.Float16, []int64{4}, []float32{1, 2, 3, 4})
+	frame, _ := tensor.AppendFrame(nil, valid)
+	corrupt := append([]byte(nil), frame...)
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	tests := []struct {
+		name  string
+		frame []byte
+		want  codes.Code
+	}{
+		{"bad checksum", corrupt, codes.DataLoss},
+		{"not a frame", []byte("hello"), codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.StreamActivations(context.Background())
+			if err != nil {
+				t.Fatalf("StreamActivations failed: %v", err)
+			}
+			if err := stream.Send(&pb.ActivationFrame{RequestId: "req-1", Sequence: 1, Tensor: tt.frame}); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if _, err := stream.Recv(); status.Code(err) != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package network
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// AlertSource knows the active alerts of every node in the cluster
+// (*alert.Evaluator)
+type AlertSource interface {
+	Alerts() []models.Alert
+}
+
+// SetAlertSource serves the cluster's active alerts through GetAlerts
+func (g *GRPCServer) SetAlertSource(alerts AlertSource) {
+	g.nodeServer.alerts = alerts
+}
+
+// GetAlerts returns the active alerts of every node, optionally only those
+// in one state
+func (s *NodeServer) GetAlerts(ctx context.Context, req *pb.AlertsRequest) (*pb.AlertsResponse, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.FailedPrecondition, "alerts are not evaluated on this node")
+	}
+	switch models.AlertState(req.State) {
+	case "", models.AlertPending, models.AlertFiring:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "state must be pending or firing, got %q", req.State)
+	}
+
+	resp := &pb.AlertsResponse{}
+	for _, alert := range s.alerts.Alerts() {
+		if req.State == "" || string(alert.State) == req.State {
+			resp.Alerts = append(resp.Alerts, alert.ToProto())
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+package network
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// staticAlerts is an AlertSource with fixed alerts
+type staticAlerts []models.Alert
+
+func (a staticAlerts) Alerts() []models.Alert { return a }
+
+func TestGetAlerts(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	nodeServer := &NodeServer{network: network, alerts: staticAlerts{
+		{Name: "HighCPU", NodeID: "node-a", State: models.AlertFiring, Value: 95},
+		{Name: "Backlog", NodeID: "node-b", State: models.AlertPending},
+	}}
+
+	tests := []struct {
+		state    string
+		want     []string
+		wantCode codes.Code
+	}{
+		{"", []string{"HighCPU", "Backlog"}, codes.OK},
+		{"firing", []string{"HighCPU"}, codes.OK},
+		{"pending", []string{"Backlog"}, codes.OK},
+		{"resolved", nil, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			resp, err := nodeServer.GetAlerts(context.Background(), &pb.AlertsRequest{State: tt.state})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Expected %v, got %v", tt.wantCode, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(resp.Alerts) != len(tt.want) {
+				t.Fatalf("Expected %v, got %+v", tt.want, resp.Alerts)
+			}
+			for i, name := range tt.want {
+				if resp.Alerts[i].Name != name {
+					t.Errorf("Expected %v, got %+v", tt.want, resp.Alerts)
+				}
+			}
+		})
+	}
+
+	if resp, _ := nodeServer.GetAlerts(context.Background(), &pb.AlertsRequest{State: "firing"}); resp.Alerts[0].NodeId != "node-a" || resp.Alerts[0].Value != 95 {
+		t.Errorf("Expected the alert's node and value, got %+v", resp.Alerts[0])
+	}
+}
+
+func TestGetAlertsWithoutEvaluator(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	nodeServer := &NodeServer{network: network}
+
+	if _, err := nodeServer.GetAlerts(context.Background(), &pb.AlertsRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+}
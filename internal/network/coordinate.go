@@ -0,0 +1,93 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// coordinateDimensions is the size of the Euclidean part of a coordinate
+const coordinateDimensions = 4
+
+// Vivaldi tuning, as in the original paper
+const (
+	vivaldiErrorWeight  = 0.25
+	vivaldiStepWeight   = 0.25
+	minCoordinateHeight = 10e-6
+	maxCoordinateError  = 1.5
+)
+
+// Coordinate is a Vivaldi network coordinate: a position in a small Euclidean
+// space plus a height modelling the access link. The distance between two
+// coordinates estimates the round trip time between their nodes in seconds,
+// so the latency of any pair can be estimated without measuring it.
+type Coordinate struct {
+	Vec    [coordinateDimensions]float64 `json:"vec"`
+	Height float64                       `json:"height"`
+	Error  float64                       `json:"error"`
+}
+
+// NewCoordinate returns a coordinate at the origin with maximum error
+func NewCoordinate() Coordinate {
+	return Coordinate{Height: minCoordinateHeight, Error: maxCoordinateError}
+}
+
+// DistanceTo estimates the round trip time to the node at other
+func (c Coordinate) DistanceTo(other Coordinate) time.Duration {
+	seconds := c.rawDistance(other) + c.Height + other.Height
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (c Coordinate) rawDistance(other Coordinate) float64 {
+	sum := 0.0
+	for i := range c.Vec {
+		d := c.Vec[i] - other.Vec[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// Update moves the coordinate after measuring rtt to the node at other
+func (c *Coordinate) Update(other Coordinate, rtt time.Duration) {
+	measured := rtt.Seconds()
+	if measured <= 0 {
+		return
+	}
+
+	dist := c.DistanceTo(other).Seconds()
+	weight := c.Error / (c.Error + other.Error)
+	if math.IsNaN(weight) {
+		weight = 0.5
+	}
+	sampleError := math.Abs(dist-measured) / measured
+	c.Error = math.Min(sampleError*vivaldiErrorWeight*weight+c.Error*(1-vivaldiErrorWeight*weight), maxCoordinateError)
+
+	force := vivaldiStepWeight * weight * (measured - dist)
+
+	// Push away from (or pull towards) the other node, in a random direction
+	// when both sit on the same spot
+	raw := c.rawDistance(other)
+	var unit [coordinateDimensions]float64
+	if raw > 1e-9 {
+		for i := range unit {
+			unit[i] = (c.Vec[i] - other.Vec[i]) / raw
+		}
+	} else {
+		norm := 0.0
+		for i := range unit {
+			unit[i] = rand.Float64() - 0.5
+			norm += unit[i] * unit[i]
+		}
+		norm = math.Sqrt(norm)
+		for i := range unit {
+			unit[i] /= norm
+		}
+	}
+	for i := range c.Vec {
+		c.Vec[i] += unit[i] * force
+	}
+
+	if dist > 0 {
+		c.Height = math.Max((c.Height+other.Height)*force/dist+c.Height, minCoordinateHeight)
+	}
+}
@@ -0,0 +1,54 @@
+package network
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCoordinateConverges(t *testing.T) {
+	// Two racks 50ms apart, with 2ms between the nodes of a rack
+	rtt := func(a, b int) time.Duration {
+		if a/2 == b/2 {
+			return 2 * time.Millisecond
+		}
+		return 50 * time.Millisecond
+	}
+
+	coords := make([]Coordinate, 4)
+	for i := range coords {
+		coords[i] = NewCoordinate()
+	}
+	for round := 0; round < 1000; round++ {
+		for a := range coords {
+			for b := range coords {
+				if a != b {
+					coords[a].Update(coords[b], rtt(a, b))
+				}
+			}
+		}
+	}
+
+	for a := range coords {
+		for b := range coords {
+			if a == b {
+				continue
+			}
+			want, got := rtt(a, b).Seconds(), coords[a].DistanceTo(coords[b]).Seconds()
+			if math.Abs(got-want) > 0.5*want {
+				t.Errorf("Estimate %d->%d = %v, want about %v", a, b, coords[a].DistanceTo(coords[b]), rtt(a, b))
+			}
+		}
+	}
+	if coords[0].Error >= maxCoordinateError {
+		t.Errorf("Expected error estimate to shrink, got %v", coords[0].Error)
+	}
+}
+
+func TestCoordinateIgnoresInvalidRTT(t *testing.T) {
+	c := NewCoordinate()
+	c.Update(NewCoordinate(), 0)
+	if c != NewCoordinate() {
+		t.Errorf("Expected zero RTT to be ignored, got %+v", c)
+	}
+}
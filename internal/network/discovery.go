@@ -1,61 +1,358 @@
 package network
 
 import (
-    "net"
-    "sync"
-    "time"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 )
 
+// DiscoveryService identifies announcements from distributed-llm agents, using
+// the DNS-SD service name so other tooling can recognise it
+const DiscoveryService = "_distributed-llm._tcp"
+
+// DefaultDiscoveryGroup is the administratively scoped multicast group and
+// port agents announce themselves on
+const DefaultDiscoveryGroup = "239.255.76.76:7980"
+
+// DefaultDiscoveryInterval is how often agents announce themselves. Peers
+// that miss three announcements are forgotten.
+const DefaultDiscoveryInterval = 5 * time.Second
+
+// maxAnnouncementSize bounds the datagrams read from the group
+const maxAnnouncementSize = 1024
+
+// Announcement is the datagram an agent multicasts to advertise itself
+type Announcement struct {
+	Service    string `json:"service"`
+	NodeID     string `json:"node_id"`
+	ClusterID  string `json:"cluster_id,omitempty"`
+	Version    string `json:"version,omitempty"`
+	GRPCPort   int    `json:"grpc_port"`
+	GossipPort int    `json:"gossip_port"`
+	// Query asks every listener to announce itself right away
+	Query bool `json:"query,omitempty"`
+}
+
+// Peer is an agent found through LAN discovery
+type Peer struct {
+	NodeID     string
+	Address    string
+	ClusterID  string
+	Version    string
+	GRPCPort   int
+	GossipPort int
+	LastSeen   time.Time
+}
+
+// GossipAddr returns the peer's memberlist address
+func (p Peer) GossipAddr() string {
+	return net.JoinHostPort(p.Address, strconv.Itoa(p.GossipPort))
+}
+
+// GRPCAddr returns the peer's gRPC address
+func (p Peer) GRPCAddr() string {
+	return net.JoinHostPort(p.Address, strconv.Itoa(p.GRPCPort))
+}
+
+// Discovery finds agents on the local network. Agents announce their node ID
+// and ports to a UDP multicast group every interval and listen for the
+// announcements of others; peers that stop announcing age out. A Discovery
+// without an announcement only listens, which is how the TUI uses it.
 type Discovery struct {
-    mu       sync.Mutex
-    peers    map[string]time.Time
-    interval time.Duration
+	mu       sync.Mutex
+	peers    map[string]Peer
+	interval time.Duration
+	group    string
+	self     *Announcement
+	onPeer   func(Peer)
+	conn     *net.UDPConn
+	done     chan struct{}
+	logger   *slog.Logger
 }
 
+// NewDiscovery creates a discovery that announces every interval on the default group
 func NewDiscovery(interval time.Duration) *Discovery {
-    return &Discovery{
-        peers:    make(map[string]time.Time),
-        interval: interval,
-    }
-}
-
-func (d *Discovery) Start() {
-    go func() {
-        for {
-            d.mu.Lock()
-            for peer := range d.peers {
-                if time.Since(d.peers[peer]) > d.interval {
-                    delete(d.peers, peer)
-                }
-            }
-            d.mu.Unlock()
-            time.Sleep(d.interval)
-        }
-    }()
-}
-
-func (d *Discovery) AddPeer(address string) {
-    d.mu.Lock()
-    defer d.mu.Unlock()
-    d.peers[address] = time.Now()
-}
-
-func (d *Discovery) GetPeers() []string {
-    d.mu.Lock()
-    defer d.mu.Unlock()
-    addresses := make([]string, 0, len(d.peers))
-    for peer := range d.peers {
-        addresses = append(addresses, peer)
-    }
-    return addresses
+	if interval <= 0 {
+		interval = DefaultDiscoveryInterval
+	}
+	return &Discovery{
+		peers:    make(map[string]Peer),
+		interval: interval,
+		group:    DefaultDiscoveryGroup,
+		logger:   slog.With("component", "discovery"),
+	}
+}
+
+// SetGroup changes the multicast group, as host:port. It must be called before Start.
+func (d *Discovery) SetGroup(group string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.group = group
+}
+
+// SetAnnouncement makes the discovery advertise this node. Announcements from
+// a different cluster ID are ignored once one is set.
+func (d *Discovery) SetAnnouncement(announcement Announcement) {
+	announcement.Service = DiscoveryService
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.self = &announcement
 }
 
+// SetPeerHandler sets a function called whenever a new peer is found
+func (d *Discovery) SetPeerHandler(handler func(Peer)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onPeer = handler
+}
+
+// Start joins the multicast group, asks existing agents to announce
+// themselves and starts announcing and expiring peers
+func (d *Discovery) Start() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn != nil {
+		return errors.New("discovery already started")
+	}
+
+	groupAddr, err := net.ResolveUDPAddr("udp4", d.group)
+	if err != nil {
+		return fmt.Errorf("invalid discovery group %q: %w", d.group, err)
+	}
+	if !groupAddr.IP.IsMulticast() {
+		return fmt.Errorf("discovery group %s is not a multicast address", groupAddr.IP)
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return fmt.Errorf("failed to join discovery group %s: %w", d.group, err)
+	}
+	d.conn = conn
+	d.done = make(chan struct{})
+
+	go d.listen(conn, groupAddr)
+	go d.run(groupAddr)
+
+	d.logger.Info("LAN discovery started", "group", d.group, "announcing", d.self != nil)
+	return nil
+}
+
+// Stop leaves the multicast group
+func (d *Discovery) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn == nil {
+		return
+	}
+	close(d.done)
+	d.conn.Close()
+	d.conn = nil
+}
+
+// run sends a query, then announces this node and expires stale peers every interval
+func (d *Discovery) run(group *net.UDPAddr) {
+	d.send(group, true)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+			d.send(group, false)
+			d.expire()
+		}
+	}
+}
+
+// listen reads announcements until the connection is closed, answering
+// queries on the group
+func (d *Discovery) listen(conn *net.UDPConn, group *net.UDPAddr) {
+	buf := make([]byte, maxAnnouncementSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			d.logger.Debug("Failed to read announcement", "error", err)
+			continue
+		}
+		if d.handle(buf[:n], from) {
+			d.send(group, false)
+		}
+	}
+}
+
+// handle records the announcement in data and returns true if the sender
+// asked for an immediate answer
+func (d *Discovery) handle(data []byte, from *net.UDPAddr) bool {
+	var announcement Announcement
+	if err := json.Unmarshal(data, &announcement); err != nil || announcement.Service != DiscoveryService {
+		return false
+	}
+
+	d.mu.Lock()
+	self := d.self
+	d.mu.Unlock()
+
+	// Queries from listen-only clients such as the TUI carry no node to record
+	if announcement.NodeID == "" {
+		return announcement.Query && self != nil
+	}
+	if self != nil {
+		if announcement.NodeID == self.NodeID {
+			return false
+		}
+		if self.ClusterID != "" && announcement.ClusterID != self.ClusterID {
+			return false
+		}
+	}
+
+	if announcement.GossipPort > 0 {
+		d.AddPeer(Peer{
+			NodeID:     announcement.NodeID,
+			Address:    from.IP.String(),
+			ClusterID:  announcement.ClusterID,
+			Version:    announcement.Version,
+			GRPCPort:   announcement.GRPCPort,
+			GossipPort: announcement.GossipPort,
+		})
+	}
+	return announcement.Query && self != nil
+}
+
+// send writes this node's announcement, or a bare query when only listening
+func (d *Discovery) send(to *net.UDPAddr, query bool) {
+	d.mu.Lock()
+	conn := d.conn
+	var announcement Announcement
+	if d.self != nil {
+		announcement = *d.self
+	} else if !query {
+		d.mu.Unlock()
+		return
+	}
+	d.mu.Unlock()
+
+	if conn == nil {
+		return
+	}
+
+	announcement.Service = DiscoveryService
+	announcement.Query = query
+	data, err := json.Marshal(announcement)
+	if err != nil {
+		return
+	}
+	if _, err := conn.WriteToUDP(data, to); err != nil {
+		d.logger.Debug("Failed to send announcement", "to", to, "error", err)
+	}
+}
+
+// expire forgets peers that missed three announcements
+func (d *Discovery) expire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for id, peer := range d.peers {
+		if time.Since(peer.LastSeen) > 3*d.interval {
+			delete(d.peers, id)
+			d.logger.Debug("LAN peer expired", "nodeID", id)
+		}
+	}
+}
+
+// AddPeer records a peer, calling the peer handler if it is new
+func (d *Discovery) AddPeer(peer Peer) {
+	if peer.LastSeen.IsZero() {
+		peer.LastSeen = time.Now()
+	}
+
+	d.mu.Lock()
+	existing, known := d.peers[peer.NodeID]
+	d.peers[peer.NodeID] = peer
+	handler := d.onPeer
+	d.mu.Unlock()
+
+	isNew := !known || existing.GossipAddr() != peer.GossipAddr() || existing.GRPCAddr() != peer.GRPCAddr()
+	if isNew {
+		d.logger.Info("Found LAN peer", "nodeID", peer.NodeID, "address", peer.Address)
+		if handler != nil {
+			handler(peer)
+		}
+	}
+}
+
+// GetPeers returns the live peers sorted by node ID
+func (d *Discovery) GetPeers() []Peer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	peers := make([]Peer, 0, len(d.peers))
+	for _, peer := range d.peers {
+		if time.Since(peer.LastSeen) <= 3*d.interval {
+			peers = append(peers, peer)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].NodeID < peers[j].NodeID })
+	return peers
+}
+
+// GossipSeeds returns the memberlist addresses of the live peers
+func (d *Discovery) GossipSeeds() []string {
+	peers := d.GetPeers()
+	seeds := make([]string, len(peers))
+	for i, peer := range peers {
+		seeds[i] = peer.GossipAddr()
+	}
+	return seeds
+}
+
+// WaitForPeers blocks until at least one peer is known or ctx is done, and
+// returns the gossip seeds found so far
+func (d *Discovery) WaitForPeers(ctx context.Context) []string {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if seeds := d.GossipSeeds(); len(seeds) > 0 {
+			return seeds
+		}
+		select {
+		case <-ctx.Done():
+			return d.GossipSeeds()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Broadcast sends this node's announcement to address directly, for
+// answering queries or reaching agents on networks without multicast
 func (d *Discovery) Broadcast(address string) error {
-    conn, err := net.Dial("udp", address)
-    if err != nil {
-        return err
-    }
-    defer conn.Close()
-    // Implement broadcasting logic here
-    return nil
-}
\ No newline at end of file
+	to, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	started := d.conn != nil
+	d.mu.Unlock()
+
+	if !started {
+		return errors.New("discovery not started")
+	}
+	d.send(to, false)
+	return nil
+}
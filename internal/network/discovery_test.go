@@ -0,0 +1,144 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testGroup returns a multicast group on a free UDP port
+func testGroup(t *testing.T) (group string, port int) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		t.Fatalf("Failed to find free UDP port: %v", err)
+	}
+	defer conn.Close()
+	port = conn.LocalAddr().(*net.UDPAddr).Port
+	return fmt.Sprintf("239.255.76.76:%d", port), port
+}
+
+func encodeAnnouncement(t *testing.T, a Announcement) []byte {
+	t.Helper()
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatalf("Failed to encode announcement: %v", err)
+	}
+	return data
+}
+
+func TestDiscoveryHandle(t *testing.T) {
+	from := &net.UDPAddr{IP: net.ParseIP("192.168.1.20"), Port: 7947}
+	peer := Announcement{Service: DiscoveryService, NodeID: "node-b", ClusterID: "prod", GRPCPort: 8080, GossipPort: 7946}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantPeer  bool
+		wantReply bool
+	}{
+		{"valid announcement", encodeAnnouncement(t, peer), true, false},
+		{"query from agent", encodeAnnouncement(t, Announcement{Service: DiscoveryService, NodeID: "node-b", ClusterID: "prod", GRPCPort: 8080, GossipPort: 7946, Query: true}), true, true},
+		{"query from listener", encodeAnnouncement(t, Announcement{Service: DiscoveryService, Query: true}), false, true},
+		{"own announcement", encodeAnnouncement(t, Announcement{Service: DiscoveryService, NodeID: "node-a", ClusterID: "prod", GossipPort: 7946}), false, false},
+		{"other cluster", encodeAnnouncement(t, Announcement{Service: DiscoveryService, NodeID: "node-c", ClusterID: "staging", GossipPort: 7946}), false, false},
+		{"other service", encodeAnnouncement(t, Announcement{Service: "_http._tcp", NodeID: "node-d", ClusterID: "prod", GossipPort: 7946}), false, false},
+		{"garbage", []byte("hello"), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDiscovery(time.Second)
+			d.SetAnnouncement(Announcement{NodeID: "node-a", ClusterID: "prod", GRPCPort: 8080, GossipPort: 7946})
+
+			if reply := d.handle(tt.data, from); reply != tt.wantReply {
+				t.Errorf("handle() reply = %v, want %v", reply, tt.wantReply)
+			}
+			peers := d.GetPeers()
+			if got := len(peers) == 1; got != tt.wantPeer {
+				t.Fatalf("Expected peer recorded = %v, got %+v", tt.wantPeer, peers)
+			}
+			if tt.wantPeer && (peers[0].GossipAddr() != "192.168.1.20:7946" || peers[0].GRPCAddr() != "192.168.1.20:8080") {
+				t.Errorf("Expected addresses from the sender IP, got %+v", peers[0])
+			}
+		})
+	}
+}
+
+func TestDiscoveryExpiresPeers(t *testing.T) {
+	d := NewDiscovery(time.Second)
+	d.AddPeer(Peer{NodeID: "fresh", Address: "10.0.0.1", GossipPort: 7946})
+	d.AddPeer(Peer{NodeID: "stale", Address: "10.0.0.2", GossipPort: 7946, LastSeen: time.Now().Add(-time.Minute)})
+
+	peers := d.GetPeers()
+	if len(peers) != 1 || peers[0].NodeID != "fresh" {
+		t.Errorf("Expected only the fresh peer, got %+v", peers)
+	}
+
+	d.expire()
+	d.mu.Lock()
+	_, kept := d.peers["stale"]
+	d.mu.Unlock()
+	if kept {
+		t.Error("Expected stale peer to be removed")
+	}
+}
+
+func TestDiscoveryFindsAnnouncedPeer(t *testing.T) {
+	listenerGroup, listenerPort := testGroup(t)
+	announcerGroup, _ := testGroup(t)
+
+	listener := NewDiscovery(time.Second)
+	listener.SetGroup(listenerGroup)
+	var found atomic.Int32
+	listener.SetPeerHandler(func(Peer) { found.Add(1) })
+	if err := listener.Start(); err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	t.Cleanup(listener.Stop)
+
+	announcer := NewDiscovery(time.Second)
+	announcer.SetGroup(announcerGroup)
+	announcer.SetAnnouncement(Announcement{NodeID: "node-b", GRPCPort: 8081, GossipPort: 7947})
+	if err := announcer.Broadcast("127.0.0.1:1"); err == nil {
+		t.Error("Expected Broadcast to fail before Start")
+	}
+	if err := announcer.Start(); err != nil {
+		t.Fatalf("Failed to start announcer: %v", err)
+	}
+	t.Cleanup(announcer.Stop)
+
+	// Multicast may not be routed in test environments, so announce directly
+	target := fmt.Sprintf("127.0.0.1:%d", listenerPort)
+	for i := 0; i < 2; i++ {
+		if err := announcer.Broadcast(target); err != nil {
+			t.Fatalf("Broadcast failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	seeds := listener.WaitForPeers(ctx)
+	if len(seeds) != 1 || seeds[0] != "127.0.0.1:7947" {
+		t.Fatalf("Expected announced gossip address as seed, got %v", seeds)
+	}
+	eventually(t, func() bool { return found.Load() == 1 })
+	time.Sleep(50 * time.Millisecond)
+	if found.Load() != 1 {
+		t.Errorf("Expected peer handler to run once for a repeated announcement, got %d", found.Load())
+	}
+}
+
+func TestDiscoveryWaitForPeersTimeout(t *testing.T) {
+	d := NewDiscovery(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if seeds := d.WaitForPeers(ctx); len(seeds) != 0 {
+		t.Errorf("Expected no seeds, got %v", seeds)
+	}
+}
@@ -0,0 +1,141 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/events"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// EventPublisher records what happens in the cluster (*events.Bus)
+type EventPublisher interface {
+	Publish(event models.Event) models.Event
+}
+
+// SetEventPublisher publishes membership changes and suspect members
+func (n *P2PNetwork) SetEventPublisher(publisher EventPublisher) {
+	n.eventMu.Lock()
+	defer n.eventMu.Unlock()
+	n.events = publisher
+}
+
+// publish sends an event to the publisher, if any
+func (n *P2PNetwork) publish(event models.Event) {
+	n.eventMu.Lock()
+	publisher := n.events
+	n.eventMu.Unlock()
+
+	if publisher != nil {
+		publisher.Publish(event)
+	}
+}
+
+// markSuspect records whether a member answers probes, publishing an event
+// when that changes
+func (n *P2PNetwork) markSuspect(nodeID string, err error) {
+	n.eventMu.Lock()
+	was := n.suspects[nodeID]
+	if err != nil {
+		n.suspects[nodeID] = true
+	} else {
+		delete(n.suspects, nodeID)
+	}
+	n.eventMu.Unlock()
+
+	switch {
+	case err != nil && !was:
+		n.publish(models.Event{Type: models.EventNodeSuspect, NodeID: nodeID, Message: err.Error()})
+	case err == nil && was:
+		n.publish(models.Event{Type: models.EventNodeRecovered, NodeID: nodeID})
+	}
+}
+
+// forgetSuspect drops a member that left from the suspects
+func (n *P2PNetwork) forgetSuspect(nodeID string) {
+	n.eventMu.Lock()
+	defer n.eventMu.Unlock()
+	delete(n.suspects, nodeID)
+}
+
+// SetEventBus serves the events published on bus through StreamEvents,
+// publishes failed inference requests on it, and sends StreamUpdates
+// deltas as soon as an event is published
+func (g *GRPCServer) SetEventBus(bus *events.Bus) {
+	g.nodeServer.events = bus
+	g.tuiServer.events = bus
+}
+
+// publish sends an event to the bus, if any
+func (s *NodeServer) publish(event models.Event) {
+	if s.events != nil {
+		s.events.Publish(event)
+	}
+}
+
+// publishFailure publishes a request entering through this node that failed
+func (s *NodeServer) publishFailure(ctx context.Context, req *pb.InferenceRequest, resp *pb.InferenceResponse, err error) {
+	event := models.Event{
+		Type:       models.EventRequestFailed,
+		NodeID:     s.network.nodeID,
+		ModelID:    req.ModelId,
+		Attributes: map[string]string{"request_id": RequestIDFromContext(ctx)},
+	}
+	if err != nil {
+		st := status.Convert(err)
+		event.Message = st.Message()
+		event.Attributes["code"] = st.Code().String()
+	} else {
+		event.Message = resp.ErrorMessage
+	}
+	s.publish(event)
+}
+
+// StreamEvents sends the retained events after the requested sequence
+// number, then each new event as it is published, optionally only those
+// of some types. Clients falling behind are disconnected with
+// ResourceExhausted and resume after the last event they received.
+func (s *NodeServer) StreamEvents(req *pb.EventsRequest, stream pb.NodeService_StreamEventsServer) error {
+	if s.events == nil {
+		return status.Error(codes.FailedPrecondition, "events are not recorded on this node")
+	}
+	for _, t := range req.Types {
+		if !slices.Contains(models.EventTypes, models.EventType(t)) {
+			return status.Errorf(codes.InvalidArgument, "unknown event type %q", t)
+		}
+	}
+
+	sub, err := s.events.Subscribe(req.AfterSequence)
+	switch {
+	case errors.Is(err, events.ErrExpired), errors.Is(err, events.ErrAhead):
+		return status.Errorf(codes.OutOfRange, "cannot resume after sequence %d (latest %d): %v", req.AfterSequence, s.events.Latest(), err)
+	case err != nil:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case event, ok := <-sub.Events():
+			if !ok {
+				if errors.Is(sub.Err(), events.ErrSlow) {
+					return status.Error(codes.ResourceExhausted, sub.Err().Error())
+				}
+				return status.Error(codes.Unavailable, sub.Err().Error())
+			}
+			if len(req.Types) > 0 && !slices.Contains(req.Types, string(event.Type)) {
+				continue
+			}
+			if err := stream.Send(event.ToProto()); err != nil {
+				return err
+			}
+		}
+	}
+}
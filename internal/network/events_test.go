@@ -0,0 +1,152 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/events"
+	"distributed-llm/internal/router"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// eventTypes lists the types of the events published on a bus so far
+func eventTypes(bus *events.Bus) []models.EventType {
+	sub, err := bus.Subscribe(0)
+	if err != nil {
+		return nil
+	}
+	defer sub.Close()
+	var types []models.EventType
+	for range bus.Latest() {
+		types = append(types, (<-sub.Events()).Type)
+	}
+	return types
+}
+
+func TestMembershipEvents(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	bus := events.NewBus(0)
+	network.SetEventPublisher(bus)
+
+	node := &memberlist.Node{Name: "node-b", Addr: net.ParseIP("10.0.0.2")}
+	network.eventDelegate.NotifyJoin(node)
+	network.markSuspect("node-b", errors.New("probe timed out"))
+	network.markSuspect("node-b", errors.New("probe timed out"))
+	network.markSuspect("node-b", nil)
+	network.markSuspect("node-b", nil)
+	network.markSuspect("node-b", errors.New("probe timed out"))
+	network.eventDelegate.NotifyLeave(node)
+
+	want := []models.EventType{
+		models.EventNodeJoined,
+		models.EventNodeSuspect,
+		models.EventNodeRecovered,
+		models.EventNodeSuspect,
+		models.EventNodeLeft,
+	}
+	if got := eventTypes(bus); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+	if network.suspects["node-b"] {
+		t.Error("Expected node-b to be forgotten once it left")
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	network, server, port := newTracedNode(t, "node-a", nil)
+	bus := events.NewBus(0)
+	network.SetEventPublisher(bus)
+	server.SetEventBus(bus)
+
+	// Requests for a replica on an unreachable node fail
+	rt := router.New("node-a", router.LeastOutstanding)
+	rt.Update([]models.PlacementPlan{{ModelID: "llama-7b", Stages: []models.PipelineStage{{NodeID: "node-z", LayerStart: 0, LayerEnd: 31}}}})
+	server.SetRouter(rt)
+
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewNodeServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	network.eventDelegate.NotifyJoin(&memberlist.Node{Name: "node-b", Addr: net.ParseIP("10.0.0.2")})
+	stream, err := client.StreamEvents(ctx, &pb.EventsRequest{Types: []string{"node_joined", "request_failed"}})
+	if err != nil {
+		t.Fatalf("StreamEvents failed: %v", err)
+	}
+	joined, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if joined.Sequence != 1 || joined.Type != "node_joined" || joined.NodeId != "node-b" {
+		t.Errorf("Expected the retained join, got %+v", joined)
+	}
+
+	// New events arrive as they happen, filtered by type
+	network.markSuspect("node-b", errors.New("probe timed out"))
+	requestCtx := metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, "req-1")
+	if _, err := client.ProcessInference(requestCtx, &pb.InferenceRequest{ModelId: "llama-7b", Prompt: "hi"}); err == nil {
+		t.Fatal("Expected the request to fail")
+	}
+	failed, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if failed.Sequence != 3 || failed.Type != "request_failed" || failed.ModelId != "llama-7b" ||
+		failed.Attributes["request_id"] != "req-1" || failed.Attributes["code"] != codes.Unavailable.String() {
+		t.Errorf("Expected the failed request, got %+v", failed)
+	}
+
+	// Resuming replays what was missed
+	resumed, err := client.StreamEvents(ctx, &pb.EventsRequest{AfterSequence: 1})
+	if err != nil {
+		t.Fatalf("StreamEvents failed: %v", err)
+	}
+	suspect, err := resumed.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if suspect.Sequence != 2 || suspect.Type != "node_suspect" || suspect.Message != "probe timed out" {
+		t.Errorf("Expected the suspect event, got %+v", suspect)
+	}
+}
+
+func TestStreamEventsErrors(t *testing.T) {
+	bus := events.NewBus(0)
+	bus.Publish(models.Event{Type: models.EventNodeJoined})
+
+	tests := []struct {
+		name string
+		bus  *events.Bus
+		req  *pb.EventsRequest
+		want codes.Code
+	}{
+		{"no bus", nil, &pb.EventsRequest{}, codes.FailedPrecondition},
+		{"unknown type", bus, &pb.EventsRequest{Types: []string{"node_exploded"}}, codes.InvalidArgument},
+		{"ahead", bus, &pb.EventsRequest{AfterSequence: 5}, codes.OutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &NodeServer{network: newTestNetwork(t, "node-a"), events: tt.bus}
+			err := server.StreamEvents(tt.req, nil)
+			if status.Code(err) != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/memberlist"
+)
+
+// Channel identifies a stream of application messages multiplexed over memberlist
+type Channel byte
+
+const (
+	// ChannelState carries replicated cluster state
+	ChannelState Channel = iota + 1
+	// ChannelLatency carries each node's latency measurements
+	ChannelLatency
+	// ChannelLoad carries each node's inference queue depth per model
+	ChannelLoad
+	// ChannelQuota carries each node's consumption of the tenant quotas
+	ChannelQuota
+	// ChannelAlert carries each node's active alerts
+	ChannelAlert
+)
+
+// maxGossipPayload is the largest message sent through the UDP gossip queue.
+// Anything bigger is delivered to each member over TCP instead.
+const maxGossipPayload = 1024
+
+// ChannelHandler consumes messages and anti-entropy state for one channel
+type ChannelHandler interface {
+	HandleMessage(msg []byte)
+	LocalState() []byte
+	MergeRemoteState(buf []byte)
+}
+
+// gossipBroadcast is a fire-and-forget memberlist broadcast
+type gossipBroadcast []byte
+
+func (b gossipBroadcast) Invalidates(memberlist.Broadcast) bool { return false }
+func (b gossipBroadcast) Message() []byte                       { return b }
+func (b gossipBroadcast) Finished()                             {}
+
+// gossipDelegate implements memberlist.Delegate and routes messages to channel handlers
+type gossipDelegate struct {
+	network    *P2PNetwork
+	mu         sync.RWMutex
+	handlers   map[Channel]ChannelHandler
+	broadcasts *memberlist.TransmitLimitedQueue
+}
+
+func newGossipDelegate(network *P2PNetwork) *gossipDelegate {
+	d := &gossipDelegate{
+		network:  network,
+		handlers: make(map[Channel]ChannelHandler),
+	}
+	d.broadcasts = &memberlist.TransmitLimitedQueue{
+		NumNodes: func() int {
+			return len(network.GetMembers())
+		},
+		RetransmitMult: 3,
+	}
+	return d
+}
+
+func (d *gossipDelegate) handler(ch Channel) ChannelHandler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.handlers[ch]
+}
+
+// NodeMeta advertises the build version and protocol range of this node
+func (d *gossipDelegate) NodeMeta(limit int) []byte {
+	return encodeMeta(d.network.localMeta(), limit)
+}
+
+func (d *gossipDelegate) NotifyMsg(msg []byte) {
+	if len(msg) < 2 {
+		return
+	}
+
+	handler := d.handler(Channel(msg[0]))
+	if handler == nil {
+		d.network.logger.Debug("Dropping message for unknown channel", "channel", msg[0])
+		return
+	}
+
+	// memberlist reuses the buffer after we return
+	payload := append([]byte(nil), msg[1:]...)
+	handler.HandleMessage(payload)
+
+	if d.network.metricsCollector != nil {
+		d.network.metricsCollector.RecordNetworkMessage("incoming", "gossip")
+	}
+}
+
+func (d *gossipDelegate) GetBroadcasts(overhead, limit int) [][]byte {
+	return d.broadcasts.GetBroadcasts(overhead, limit)
+}
+
+func (d *gossipDelegate) LocalState(join bool) []byte {
+	d.mu.RLock()
+	states := make(map[Channel][]byte, len(d.handlers))
+	for ch, handler := range d.handlers {
+		states[ch] = handler.LocalState()
+	}
+	d.mu.RUnlock()
+
+	data, err := json.Marshal(states)
+	if err != nil {
+		d.network.logger.Error("Failed to encode local state", "error", err)
+		return nil
+	}
+	return data
+}
+
+func (d *gossipDelegate) MergeRemoteState(buf []byte, join bool) {
+	if len(buf) == 0 {
+		return
+	}
+
+	var states map[Channel][]byte
+	if err := json.Unmarshal(buf, &states); err != nil {
+		d.network.logger.Warn("Dropping malformed remote state", "error", err)
+		return
+	}
+
+	for ch, state := range states {
+		if handler := d.handler(ch); handler != nil {
+			handler.MergeRemoteState(state)
+		}
+	}
+}
+
+// RegisterChannel routes messages and push/pull state for a channel to a handler.
+// Handlers should be registered before Start so the initial state exchange reaches them.
+func (n *P2PNetwork) RegisterChannel(ch Channel, handler ChannelHandler) {
+	n.delegate.mu.Lock()
+	defer n.delegate.mu.Unlock()
+	n.delegate.handlers[ch] = handler
+}
+
+// Broadcast sends a message on a channel to every other member
+func (n *P2PNetwork) Broadcast(ch Channel, msg []byte) {
+	payload := append([]byte{byte(ch)}, msg...)
+
+	if len(payload) <= maxGossipPayload {
+		n.delegate.broadcasts.QueueBroadcast(gossipBroadcast(payload))
+	} else if n.memberlist != nil {
+		for _, member := range n.memberlist.Members() {
+			if member.Name == n.nodeID {
+				continue
+			}
+			if err := n.memberlist.SendReliable(member, payload); err != nil {
+				n.logger.Warn("Failed to send broadcast", "node", member.Name, "error", err)
+			}
+		}
+	}
+
+	if n.metricsCollector != nil {
+		n.metricsCollector.RecordNetworkMessage("outgoing", "gossip")
+	}
+}
+
+// SendTo reliably sends a message on a channel to a single member
+func (n *P2PNetwork) SendTo(nodeID string, ch Channel, msg []byte) error {
+	if n.memberlist == nil {
+		return fmt.Errorf("network not started")
+	}
+
+	for _, member := range n.memberlist.Members() {
+		if member.Name == nodeID {
+			return n.memberlist.SendReliable(member, append([]byte{byte(ch)}, msg...))
+		}
+	}
+	return fmt.Errorf("unknown member %s", nodeID)
+}
+
+// ChannelTransport adapts a single channel of the network to the transport
+// interface used by replicated components such as the state store
+type ChannelTransport struct {
+	network *P2PNetwork
+	channel Channel
+}
+
+// Transport returns a transport bound to one channel
+func (n *P2PNetwork) Transport(ch Channel) *ChannelTransport {
+	return &ChannelTransport{network: n, channel: ch}
+}
+
+func (t *ChannelTransport) LocalID() string   { return t.network.nodeID }
+func (t *ChannelTransport) Members() []string { return t.network.GetMembers() }
+func (t *ChannelTransport) Broadcast(msg []byte) {
+	t.network.Broadcast(t.channel, msg)
+}
+func (t *ChannelTransport) SendTo(nodeID string, msg []byte) error {
+	return t.network.SendTo(nodeID, t.channel, msg)
+}
@@ -0,0 +1,191 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler captures messages delivered on a channel
+type recordingHandler struct {
+	mu       sync.Mutex
+	messages [][]byte
+	state    []byte
+	merged   [][]byte
+}
+
+func (h *recordingHandler) HandleMessage(msg []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, msg)
+}
+
+func (h *recordingHandler) LocalState() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state
+}
+
+func (h *recordingHandler) MergeRemoteState(buf []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.merged = append(h.merged, buf)
+}
+
+func (h *recordingHandler) received(msg []byte) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, m := range h.messages {
+		if bytes.Equal(m, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *recordingHandler) mergedState(state []byte) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, m := range h.merged {
+		if bytes.Equal(m, state) {
+			return true
+		}
+	}
+	return false
+}
+
+// startGossipPair starts two networks joined to each other with handlers on ChannelState
+func startGossipPair(t *testing.T) (*P2PNetwork, *P2PNetwork, *recordingHandler, *recordingHandler) {
+	t.Helper()
+
+	handlers := []*recordingHandler{
+		{state: []byte("state-from-node-a")},
+		{state: []byte("state-from-node-b")},
+	}
+	networks := make([]*P2PNetwork, 2)
+	gossipPorts := make([]int, 2)
+
+	for i, name := range []string{"node-a", "node-b"} {
+		gossipPorts[i] = findAvailablePort(t)
+		network, err := NewP2PNetwork(name, findAvailablePort(t), gossipPorts[i])
+		if err != nil {
+			t.Fatalf("Failed to create network %s: %v", name, err)
+		}
+		network.RegisterChannel(ChannelState, handlers[i])
+		networks[i] = network
+	}
+
+	if err := networks[0].Start(nil); err != nil {
+		t.Fatalf("Failed to start node-a: %v", err)
+	}
+	t.Cleanup(networks[0].Stop)
+
+	if err := networks[1].Start([]string{fmt.Sprintf("127.0.0.1:%d", gossipPorts[0])}); err != nil {
+		t.Fatalf("Failed to start node-b: %v", err)
+	}
+	t.Cleanup(networks[1].Stop)
+
+	eventually(t, func() bool { return len(networks[0].GetMembers()) == 2 })
+	return networks[0], networks[1], handlers[0], handlers[1]
+}
+
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("Condition not met before timeout")
+}
+
+func TestGossipExchangesStateOnJoin(t *testing.T) {
+	_, _, handlerA, handlerB := startGossipPair(t)
+
+	eventually(t, func() bool { return handlerA.mergedState([]byte("state-from-node-b")) })
+	eventually(t, func() bool { return handlerB.mergedState([]byte("state-from-node-a")) })
+}
+
+func TestGossipBroadcast(t *testing.T) {
+	nodeA, _, _, handlerB := startGossipPair(t)
+
+	small := []byte("small update")
+	nodeA.Broadcast(ChannelState, small)
+	eventually(t, func() bool { return handlerB.received(small) })
+
+	// Oversized payloads bypass the UDP queue and go over TCP
+	large := bytes.Repeat([]byte("x"), maxGossipPayload*4)
+	nodeA.Broadcast(ChannelState, large)
+	eventually(t, func() bool { return handlerB.received(large) })
+}
+
+func TestGossipSendTo(t *testing.T) {
+	nodeA, nodeB, handlerA, _ := startGossipPair(t)
+
+	msg := []byte("direct message")
+	if err := nodeB.SendTo("node-a", ChannelState, msg); err != nil {
+		t.Fatalf("SendTo failed: %v", err)
+	}
+	eventually(t, func() bool { return handlerA.received(msg) })
+
+	if err := nodeA.SendTo("missing-node", ChannelState, msg); err == nil {
+		t.Error("Expected error sending to unknown member")
+	}
+}
+
+func TestGossipSendToBeforeStart(t *testing.T) {
+	network, err := NewP2PNetwork("test-node", 8080, 7946)
+	if err != nil {
+		t.Fatalf("Failed to create P2P network: %v", err)
+	}
+
+	if err := network.SendTo("node-a", ChannelState, []byte("msg")); err == nil {
+		t.Error("Expected error sending before start")
+	}
+
+	// Broadcasting before start queues without panicking
+	network.Broadcast(ChannelState, []byte("msg"))
+}
+
+func TestGossipDelegateIgnoresUnknownChannels(t *testing.T) {
+	network, err := NewP2PNetwork("test-node", 8080, 7946)
+	if err != nil {
+		t.Fatalf("Failed to create P2P network: %v", err)
+	}
+	handler := &recordingHandler{}
+	network.RegisterChannel(ChannelState, handler)
+
+	network.delegate.NotifyMsg([]byte{0xff, 'x'})
+	network.delegate.NotifyMsg(nil)
+	network.delegate.MergeRemoteState([]byte("not json"), false)
+
+	network.delegate.NotifyMsg(append([]byte{byte(ChannelState)}, "hello"...))
+	if !handler.received([]byte("hello")) {
+		t.Error("Expected message on registered channel to be delivered")
+	}
+	if len(handler.messages) != 1 {
+		t.Errorf("Expected exactly one delivered message, got %d", len(handler.messages))
+	}
+}
+
+func TestChannelTransport(t *testing.T) {
+	nodeA, _, _, handlerB := startGossipPair(t)
+	transport := nodeA.Transport(ChannelState)
+
+	if transport.LocalID() != "node-a" {
+		t.Errorf("Expected local ID node-a, got %s", transport.LocalID())
+	}
+	if len(transport.Members()) != 2 {
+		t.Errorf("Expected 2 members, got %d", len(transport.Members()))
+	}
+
+	msg := []byte("via transport")
+	if err := transport.SendTo("node-b", msg); err != nil {
+		t.Fatalf("SendTo failed: %v", err)
+	}
+	eventually(t, func() bool { return handlerB.received(msg) })
+}
@@ -5,18 +5,39 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
+	"distributed-llm/internal/events"
+	"distributed-llm/internal/planner"
+	"distributed-llm/internal/state"
 	"distributed-llm/pkg/models"
 	pb "distributed-llm/proto"
 )
 
+// StateStore is the replicated cluster state consulted by the gRPC services
+type StateStore interface {
+	Snapshot() *state.State
+	Dump() ([]byte, error)
+	Cordon(ctx context.Context, nodeID string) error
+	Uncordon(ctx context.Context, nodeID string) error
+	PutPlan(ctx context.Context, plan models.PlacementPlan) error
+	DeletePlan(ctx context.Context, key string) error
+	PutModel(ctx context.Context, model models.Model) error
+}
+
 // DiscoveryServer implements the gRPC DiscoveryService
 type DiscoveryServer struct {
 	pb.UnimplementedDiscoveryServiceServer
 	network *P2PNetwork
+	router  InferenceRouter
 }
 
 func NewDiscoveryServer(network *P2PNetwork) *DiscoveryServer {
@@ -40,29 +61,7 @@ func (d *DiscoveryServer) DiscoverNodes(ctx context.Context, req *pb.DiscoveryRe
 			continue
 		}
 
-		gpus := make([]*pb.GPUInfo, len(node.Resources.GPUs))
-		for j, gpu := range node.Resources.GPUs {
-			gpus[j] = &pb.GPUInfo{
-				Name:     gpu.Name,
-				MemoryMb: gpu.MemoryMB,
-				Uuid:     gpu.UUID,
-			}
-		}
-
-		discovered = append(discovered, &pb.NodeInfo{
-			NodeId:  node.ID,
-			Address: node.Address,
-			Port:    int32(node.Port),
-			Resources: &pb.ResourceInfo{
-				CpuCores:   node.Resources.CPUCores,
-				MemoryMb:   node.Resources.MemoryMB,
-				Gpus:       gpus,
-				MaxLayers:  node.Resources.MaxLayers,
-				UsedLayers: node.Resources.UsedLayers,
-			},
-			Status:   string(node.Status),
-			LastSeen: node.LastSeen.Unix(),
-		})
+		discovered = append(discovered, nodeToProto(node))
 	}
 
 	return &pb.DiscoveryResponse{
@@ -79,48 +78,35 @@ func (d *DiscoveryServer) RegisterWithCluster(ctx context.Context, req *pb.Clust
 		"port", req.Port,
 		"seedNodes", req.SeedNodes)
 
-	// In a real implementation, this would handle cluster join logic
-	existingNodes := d.network.GetNodes()
-	existingNodeInfos := make([]*pb.NodeInfo, len(existingNodes))
-
-	for i, node := range existingNodes {
-		gpus := make([]*pb.GPUInfo, len(node.Resources.GPUs))
-		for j, gpu := range node.Resources.GPUs {
-			gpus[j] = &pb.GPUInfo{
-				Name:     gpu.Name,
-				MemoryMb: gpu.MemoryMB,
-				Uuid:     gpu.UUID,
-			}
-		}
-
-		existingNodeInfos[i] = &pb.NodeInfo{
-			NodeId:  node.ID,
-			Address: node.Address,
-			Port:    int32(node.Port),
-			Resources: &pb.ResourceInfo{
-				CpuCores:   node.Resources.CPUCores,
-				MemoryMb:   node.Resources.MemoryMB,
-				Gpus:       gpus,
-				MaxLayers:  node.Resources.MaxLayers,
-				UsedLayers: node.Resources.UsedLayers,
-			},
-			Status:   string(node.Status),
-			LastSeen: node.LastSeen.Unix(),
-		}
+	existingNodes, err := d.network.admit(ctx, admission{
+		NodeID:          req.NodeId,
+		Address:         req.Address,
+		Port:            req.Port,
+		GossipPort:      req.GossipPort,
+		Resources:       req.Resources,
+		SeedNodes:       req.SeedNodes,
+		Version:         req.Version,
+		ProtocolVersion: req.ProtocolVersion,
+		MinProtocol:     req.MinProtocolVersion,
+		ClusterID:       req.ClusterId,
+		AuthToken:       req.AuthToken,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &pb.ClusterJoinResponse{
 		Success:       true,
-		Message:       "Successfully joined cluster",
-		ExistingNodes: existingNodeInfos,
-		ClusterId:     "distributed-llm-cluster",
+		Message:       fmt.Sprintf("Successfully joined cluster with %d existing nodes", len(existingNodes)),
+		ExistingNodes: nodesToProto(existingNodes),
+		ClusterId:     d.network.ClusterID(),
 	}, nil
 }
 
 func (d *DiscoveryServer) LeaveCluster(ctx context.Context, req *pb.ClusterLeaveRequest) (*pb.ClusterLeaveResponse, error) {
 	d.network.logger.Info("Cluster leave request", "nodeID", req.NodeId, "reason", req.Reason)
 
-	// In a real implementation, this would handle cleanup
+	d.network.forget(req.NodeId)
 	return &pb.ClusterLeaveResponse{
 		Success: true,
 		Message: "Successfully left cluster",
@@ -138,29 +124,7 @@ func (d *DiscoveryServer) GetClusterInfo(ctx context.Context, req *pb.ClusterInf
 	allocatedLayers := int32(0)
 
 	for i, node := range nodes {
-		gpus := make([]*pb.GPUInfo, len(node.Resources.GPUs))
-		for j, gpu := range node.Resources.GPUs {
-			gpus[j] = &pb.GPUInfo{
-				Name:     gpu.Name,
-				MemoryMb: gpu.MemoryMB,
-				Uuid:     gpu.UUID,
-			}
-		}
-
-		nodeInfos[i] = &pb.NodeInfo{
-			NodeId:  node.ID,
-			Address: node.Address,
-			Port:    int32(node.Port),
-			Resources: &pb.ResourceInfo{
-				CpuCores:   node.Resources.CPUCores,
-				MemoryMb:   node.Resources.MemoryMB,
-				Gpus:       gpus,
-				MaxLayers:  node.Resources.MaxLayers,
-				UsedLayers: node.Resources.UsedLayers,
-			},
-			Status:   string(node.Status),
-			LastSeen: node.LastSeen.Unix(),
-		}
+		nodeInfos[i] = nodeToProto(node)
 
 		// Aggregate metrics
 		totalCPU += node.Resources.CPUCores
@@ -183,10 +147,16 @@ func (d *DiscoveryServer) GetClusterInfo(ctx context.Context, req *pb.ClusterInf
 		utilization = float32(allocatedLayers) / float32(totalLayers) * 100.0
 	}
 
+	var replicas []*pb.ReplicaStats
+	if d.router != nil {
+		replicas = replicaStatsToProto(d.router.Stats())
+	}
+
 	return &pb.ClusterInfoResponse{
-		ClusterId: "distributed-llm-cluster",
+		ClusterId: d.network.ClusterID(),
 		Nodes:     nodeInfos,
 		Models:    []*pb.ModelInfo{}, // Empty for now - would be populated from model registry
+		Replicas:  replicas,
 		Metrics: &pb.ClusterMetrics{
 			TotalNodes:         int32(len(nodes)),
 			HealthyNodes:       healthyNodes,
@@ -205,12 +175,21 @@ type TUIServer struct {
 	pb.UnimplementedTUIServiceServer
 	network         *P2PNetwork
 	discoveryServer *DiscoveryServer
+	stateStore      StateStore
+	planner         *planner.Planner
+	// events wakes StreamUpdates when the cluster changes, when set
+	events *events.Bus
 }
 
 func NewTUIServer(network *P2PNetwork, discoveryServer *DiscoveryServer) *TUIServer {
+	p := planner.New()
+	if network != nil {
+		p.SetLatencySource(network.Latency())
+	}
 	return &TUIServer{
 		network:         network,
 		discoveryServer: discoveryServer,
+		planner:         p,
 	}
 }
 
@@ -219,29 +198,7 @@ func (t *TUIServer) GetNodeList(ctx context.Context, req *pb.NodeListRequest) (*
 	nodeInfos := make([]*pb.NodeInfo, len(nodes))
 
 	for i, node := range nodes {
-		gpus := make([]*pb.GPUInfo, len(node.Resources.GPUs))
-		for j, gpu := range node.Resources.GPUs {
-			gpus[j] = &pb.GPUInfo{
-				Name:     gpu.Name,
-				MemoryMb: gpu.MemoryMB,
-				Uuid:     gpu.UUID,
-			}
-		}
-
-		nodeInfos[i] = &pb.NodeInfo{
-			NodeId:  node.ID,
-			Address: node.Address,
-			Port:    int32(node.Port),
-			Resources: &pb.ResourceInfo{
-				CpuCores:   node.Resources.CPUCores,
-				MemoryMb:   node.Resources.MemoryMB,
-				Gpus:       gpus,
-				MaxLayers:  node.Resources.MaxLayers,
-				UsedLayers: node.Resources.UsedLayers,
-			},
-			Status:   string(node.Status),
-			LastSeen: node.LastSeen.Unix(),
-		}
+		nodeInfos[i] = nodeToProto(node)
 	}
 
 	// Get cluster metrics if requested
@@ -261,8 +218,19 @@ func (t *TUIServer) GetNodeList(ctx context.Context, req *pb.NodeListRequest) (*
 	}, nil
 }
 
+// SetStateStore sets the replicated state backing the model registry and cluster commands
+func (t *TUIServer) SetStateStore(store StateStore) {
+	t.stateStore = store
+}
+
 func (t *TUIServer) GetModelList(ctx context.Context, req *pb.ModelListRequest) (*pb.ModelListResponse, error) {
-	// Mock models for now - in a real implementation this would come from a model registry
+	if t.stateStore != nil {
+		if registered := registeredModels(t.stateStore.Snapshot()); len(registered) > 0 {
+			return &pb.ModelListResponse{Models: registered}, nil
+		}
+	}
+
+	// Mock models until something has been registered
 	models := []*pb.ModelInfo{
 		{
 			Id:              "llama-7b",
@@ -289,64 +257,6 @@ func (t *TUIServer) GetModelList(ctx context.Context, req *pb.ModelListRequest)
 	}, nil
 }
 
-func (t *TUIServer) StreamUpdates(req *pb.UpdateStreamRequest, stream pb.TUIService_StreamUpdatesServer) error {
-	interval := time.Duration(req.IntervalSeconds) * time.Second
-	if interval < time.Second {
-		interval = 5 * time.Second // Default 5 seconds
-	}
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-stream.Context().Done():
-			return stream.Context().Err()
-		case <-ticker.C:
-			update := &pb.ClusterUpdate{
-				Timestamp: time.Now().Unix(),
-			}
-
-			// Include requested update types
-			for _, updateType := range req.UpdateTypes {
-				switch updateType {
-				case "nodes":
-					nodeResp, err := t.GetNodeList(stream.Context(), &pb.NodeListRequest{
-						RequesterId:    req.RequesterId,
-						IncludeMetrics: false,
-					})
-					if err == nil {
-						update.UpdateType = "nodes"
-						update.Nodes = nodeResp.Nodes
-					}
-
-				case "models":
-					modelResp, err := t.GetModelList(stream.Context(), &pb.ModelListRequest{
-						RequesterId: req.RequesterId,
-					})
-					if err == nil {
-						update.UpdateType = "models"
-						update.Models = modelResp.Models
-					}
-
-				case "metrics":
-					clusterInfo, err := t.discoveryServer.GetClusterInfo(stream.Context(), &pb.ClusterInfoRequest{
-						RequesterId: req.RequesterId,
-					})
-					if err == nil {
-						update.UpdateType = "metrics"
-						update.Metrics = clusterInfo.Metrics
-					}
-				}
-
-				if err := stream.Send(update); err != nil {
-					return err
-				}
-			}
-		}
-	}
-}
-
 func (t *TUIServer) ExecuteCommand(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
 	t.network.logger.Info("Command execution request",
 		"requester", req.RequesterId,
@@ -369,6 +279,84 @@ func (t *TUIServer) ExecuteCommand(ctx context.Context, req *pb.CommandRequest)
 			ExitCode: 0,
 		}, nil
 
+	case "state":
+		if len(req.Args) != 1 || req.Args[0] != "dump" {
+			return failedCommand("usage: state dump"), nil
+		}
+		if t.stateStore == nil {
+			return failedCommand("cluster state store not available"), nil
+		}
+		dump, err := t.stateStore.Dump()
+		if err != nil {
+			return failedCommand(fmt.Sprintf("failed to dump state: %v", err)), nil
+		}
+		return &pb.CommandResponse{
+			Success:  true,
+			Output:   string(dump),
+			ExitCode: 0,
+		}, nil
+
+	case "cordon", "uncordon":
+		if len(req.Args) != 1 {
+			return failedCommand(fmt.Sprintf("usage: %s <node-id>", req.Command)), nil
+		}
+		if t.stateStore == nil {
+			return failedCommand("cluster state store not available"), nil
+		}
+		apply := t.stateStore.Cordon
+		if req.Command == "uncordon" {
+			apply = t.stateStore.Uncordon
+		}
+		if err := apply(ctx, req.Args[0]); err != nil {
+			return failedCommand(fmt.Sprintf("failed to %s %s: %v", req.Command, req.Args[0], err)), nil
+		}
+		return &pb.CommandResponse{
+			Success:  true,
+			Output:   fmt.Sprintf("node %s %sed", req.Args[0], req.Command),
+			ExitCode: 0,
+		}, nil
+
+	case "plan":
+		if len(req.Args) < 1 || len(req.Args) > 2 {
+			return failedCommand("usage: plan <model-id> [node-id,...]"), nil
+		}
+		if t.stateStore == nil {
+			return failedCommand("cluster state store not available"), nil
+		}
+		var pinned []string
+		if len(req.Args) == 2 {
+			pinned = strings.Split(req.Args[1], ",")
+		}
+		plan, err := t.placeModel(ctx, req.Args[0], pinned)
+		if err != nil {
+			return failedCommand(fmt.Sprintf("failed to plan %s: %v", req.Args[0], err)), nil
+		}
+		return &pb.CommandResponse{
+			Success:  true,
+			Output:   fmt.Sprintf("model %s placed on %s", plan.ModelID, strings.Join(plan.NodeIDs(), " -> ")),
+			ExitCode: 0,
+		}, nil
+
+	case "scale":
+		if len(req.Args) != 2 {
+			return failedCommand("usage: scale <model-id> <replicas>"), nil
+		}
+		replicas, err := strconv.Atoi(req.Args[1])
+		if err != nil || replicas < 1 {
+			return failedCommand(fmt.Sprintf("invalid replica count %q", req.Args[1])), nil
+		}
+		if t.stateStore == nil {
+			return failedCommand("cluster state store not available"), nil
+		}
+		if err := t.ScaleModel(ctx, req.Args[0], replicas); err != nil {
+			return failedCommand(fmt.Sprintf("failed to scale %s: %v", req.Args[0], err)), nil
+		}
+		return &pb.CommandResponse{
+			Success:  true,
+			Output:   fmt.Sprintf("model %s scaled to %d replicas", req.Args[0], replicas),
+			ExitCode: 0,
+		}, nil
+
 	default:
 		return &pb.CommandResponse{
 			Success:  false,
@@ -378,31 +366,153 @@ func (t *TUIServer) ExecuteCommand(ctx context.Context, req *pb.CommandRequest)
 	}
 }
 
-// GRPCServer wraps the gRPC server with compression support
+// placeModel plans a registered model onto uncordoned nodes, optionally
+// restricted to the pinned nodes, and records the plan in the cluster state.
+// Plans mixing nodes without a common protocol version are refused.
+func (t *TUIServer) placeModel(ctx context.Context, modelID string, pinned []string) (models.PlacementPlan, error) {
+	st := t.stateStore.Snapshot()
+	model, ok := st.Models[modelID]
+	if !ok {
+		return models.PlacementPlan{}, fmt.Errorf("model %s is not registered", modelID)
+	}
+
+	candidates := t.schedulableNodes(st, pinned)
+	plan, err := t.planner.Plan(model, candidates)
+	if err != nil {
+		return models.PlacementPlan{}, err
+	}
+	if err := t.planner.Validate(plan, candidates); err != nil {
+		return models.PlacementPlan{}, err
+	}
+	if err := t.stateStore.PutPlan(ctx, plan); err != nil {
+		return models.PlacementPlan{}, err
+	}
+	return plan, nil
+}
+
+// ScaleModel sets the number of replica pipelines of a registered model,
+// placing new replicas away from the existing ones or removing the
+// highest-numbered replicas. The count is recorded even if not every new
+// replica fits, so that a later call can place the rest.
+func (t *TUIServer) ScaleModel(ctx context.Context, modelID string, replicas int) error {
+	st := t.stateStore.Snapshot()
+	model, ok := st.Models[modelID]
+	if !ok {
+		return fmt.Errorf("model %s is not registered", modelID)
+	}
+
+	if int(model.Replicas) != replicas {
+		model.Replicas = int32(replicas)
+		if err := t.stateStore.PutModel(ctx, model); err != nil {
+			return err
+		}
+	}
+
+	current := st.Replicas(modelID)
+	if replicas < len(current) {
+		for _, plan := range current[replicas:] {
+			if err := t.stateStore.DeletePlan(ctx, plan.Key()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	candidates := t.schedulableNodes(st, nil)
+	plans, planErr := t.planner.PlanReplicas(model, candidates, current, replicas-len(current))
+	for _, plan := range plans {
+		if err := t.planner.Validate(plan, candidates); err != nil {
+			return err
+		}
+		if err := t.stateStore.PutPlan(ctx, plan); err != nil {
+			return err
+		}
+	}
+	return planErr
+}
+
+// schedulableNodes returns the uncordoned nodes, restricted to the pinned
+// ones if any are given
+func (t *TUIServer) schedulableNodes(st *state.State, pinned []string) []models.Node {
+	allowed := make(map[string]bool, len(pinned))
+	for _, id := range pinned {
+		allowed[strings.TrimSpace(id)] = true
+	}
+	nodes := t.network.GetNodes()
+	candidates := make([]models.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if st.Cordoned[node.ID] || (len(allowed) > 0 && !allowed[node.ID]) {
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+	return candidates
+}
+
+// failedCommand builds the response for a command that could not be executed
+func failedCommand(message string) *pb.CommandResponse {
+	return &pb.CommandResponse{
+		Success:  false,
+		Error:    message,
+		ExitCode: 1,
+	}
+}
+
+// registeredModels converts the model registry into protobuf form, listing the
+// nodes each model is placed on in pipeline order
+func registeredModels(st *state.State) []*pb.ModelInfo {
+	infos := make([]*pb.ModelInfo, 0, len(st.Models))
+	for _, model := range st.Models {
+		assignments := []string{}
+		if plan, ok := st.Plans[model.ID]; ok {
+			assignments = plan.NodeIDs()
+		}
+
+		infos = append(infos, &pb.ModelInfo{
+			Id:              model.ID,
+			Name:            model.Name,
+			Version:         model.Version,
+			LayerCount:      model.LayerCount,
+			FilePath:        model.FilePath,
+			SizeBytes:       model.Size,
+			NodeAssignments: assignments,
+			Replicas:        int32(len(st.Replicas(model.ID))),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Id < infos[j].Id })
+	return infos
+}
+
+// GRPCServer wraps the gRPC server. It accepts every compressor registered by
+// internal/compress and answers in the compressor each call was made with.
 type GRPCServer struct {
 	server          *grpc.Server
 	nodeServer      *NodeServer
 	discoveryServer *DiscoveryServer
 	tuiServer       *TUIServer
+	health          *healthReporter
+	interceptors    *serverInterceptors
 	listener        net.Listener
+	serving         atomic.Bool
 }
 
 func NewGRPCServer(network *P2PNetwork, port int) (*GRPCServer, error) {
-	// Create gRPC server with compression enabled
-	server := grpc.NewServer(
-		grpc.RPCCompressor(grpc.NewGZIPCompressor()),
-		grpc.RPCDecompressor(grpc.NewGZIPDecompressor()),
-	)
+	interceptors := newServerInterceptors(network.logger, network.metricsCollector, network.tracer)
+	interceptors.stages = network.forwardedByMember
+	server := grpc.NewServer(interceptors.serverOptions()...)
 
 	// Create service implementations
 	nodeServer := &NodeServer{network: network}
 	discoveryServer := NewDiscoveryServer(network)
 	tuiServer := NewTUIServer(network, discoveryServer)
+	healthReporter := newHealthReporter(network)
 
 	// Register services
 	pb.RegisterNodeServiceServer(server, nodeServer)
 	pb.RegisterDiscoveryServiceServer(server, discoveryServer)
 	pb.RegisterTUIServiceServer(server, tuiServer)
+	healthpb.RegisterHealthServer(server, healthReporter.server)
 
 	// Create listener
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
@@ -415,17 +525,76 @@ func NewGRPCServer(network *P2PNetwork, port int) (*GRPCServer, error) {
 		nodeServer:      nodeServer,
 		discoveryServer: discoveryServer,
 		tuiServer:       tuiServer,
+		health:          healthReporter,
+		interceptors:    interceptors,
 		listener:        listener,
 	}, nil
 }
 
+// SetDefaultTimeout sets the deadline given to unary calls that arrive
+// without one (DefaultCallTimeout unless set); 0 disables it
+func (g *GRPCServer) SetDefaultTimeout(timeout time.Duration) {
+	g.interceptors.setDefaultTimeout(timeout)
+}
+
+// SetStateStore wires the replicated cluster state into the services
+func (g *GRPCServer) SetStateStore(store StateStore) {
+	g.tuiServer.SetStateStore(store)
+}
+
+// SetPlacementConstraints sets the topology constraints used when planning models
+func (g *GRPCServer) SetPlacementConstraints(constraints planner.Constraints) {
+	g.tuiServer.planner.SetConstraints(constraints)
+}
+
+// SetRouter routes inference requests across model replicas and reports
+// replica stats in GetClusterInfo
+func (g *GRPCServer) SetRouter(r InferenceRouter) {
+	g.nodeServer.router = r
+	g.discoveryServer.router = r
+}
+
+// ScaleModel sets the number of replica pipelines of a model
+func (g *GRPCServer) ScaleModel(ctx context.Context, modelID string, replicas int) error {
+	if g.tuiServer.stateStore == nil {
+		return fmt.Errorf("cluster state store not available")
+	}
+	return g.tuiServer.ScaleModel(ctx, modelID, replicas)
+}
+
+// SetReadinessSource makes health checks report backend and model readiness
+func (g *GRPCServer) SetReadinessSource(readiness ReadinessSource) {
+	g.nodeServer.readiness = readiness
+	g.health.setReadiness(readiness)
+}
+
+// EnableReflection registers the gRPC reflection service so tools like grpcurl
+// can discover the API. It must be called before Start.
+func (g *GRPCServer) EnableReflection() {
+	reflection.Register(g.server)
+}
+
 func (g *GRPCServer) Start() error {
 	slog.Info("Starting gRPC server with compression", "address", g.listener.Addr().String())
+	g.health.update()
+	go g.health.run()
+	g.serving.Store(true)
+	defer g.serving.Store(false)
 	return g.server.Serve(g.listener)
 }
 
+// CheckServing fails unless the server is accepting connections
+func (g *GRPCServer) CheckServing(ctx context.Context) error {
+	if !g.serving.Load() {
+		return fmt.Errorf("gRPC server not serving on %s", g.listener.Addr())
+	}
+	return nil
+}
+
 func (g *GRPCServer) Stop() {
 	slog.Info("Stopping gRPC server")
+	g.serving.Store(false)
+	g.health.stop()
 	g.server.GracefulStop()
 }
 
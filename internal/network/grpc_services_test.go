@@ -2,9 +2,11 @@ package network
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"distributed-llm/internal/state"
 	"distributed-llm/pkg/models"
 	pb "distributed-llm/proto"
 )
@@ -15,8 +17,9 @@ type MockMetricsCollector struct{}
 func (m *MockMetricsCollector) RecordNetworkMessage(direction, messageType string) {}
 func (m *MockMetricsCollector) RecordNetworkLatency(targetNode, operation string, duration time.Duration) {
 }
-func (m *MockMetricsCollector) UpdateNodeStatus(status models.NodeStatus) {}
-func (m *MockMetricsCollector) UpdateNetworkConnections(count int)        {}
+func (m *MockMetricsCollector) UpdateNodeStatus(status models.NodeStatus)   {}
+func (m *MockMetricsCollector) UpdateNetworkConnections(count int)          {}
+func (m *MockMetricsCollector) AddActiveRequests(modelID string, delta int) {}
 func (m *MockMetricsCollector) RecordInferenceRequest(modelID, status string, duration time.Duration, tokensGenerated int) {
 }
 
@@ -395,3 +398,160 @@ func TestNodeServer_Extended(t *testing.T) {
 		t.Error("Expected non-empty status")
 	}
 }
+
+func TestTUIServer_StateCommands(t *testing.T) {
+	network, err := NewP2PNetwork("test-node", 8080, 7946)
+	if err != nil {
+		t.Fatalf("Failed to create P2P network: %v", err)
+	}
+	defer network.Stop()
+
+	tuiServer := NewTUIServer(network, NewDiscoveryServer(network))
+	ctx := context.Background()
+
+	// Without a store the commands fail cleanly
+	resp, err := tuiServer.ExecuteCommand(ctx, &pb.CommandRequest{Command: "state", Args: []string{"dump"}})
+	if err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if resp.Success {
+		t.Error("Expected state dump to fail without a store")
+	}
+
+	store, err := state.NewStore(state.Config{NodeID: "test-node"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+	defer store.Close()
+	tuiServer.SetStateStore(store)
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		success bool
+	}{
+		{"cordon", "cordon", []string{"node-2"}, true},
+		{"cordon without node", "cordon", nil, false},
+		{"state dump", "state", []string{"dump"}, true},
+		{"state without subcommand", "state", nil, false},
+		{"uncordon", "uncordon", []string{"node-2"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tuiServer.ExecuteCommand(ctx, &pb.CommandRequest{Command: tt.command, Args: tt.args})
+			if err != nil {
+				t.Fatalf("ExecuteCommand failed: %v", err)
+			}
+			if resp.Success != tt.success {
+				t.Errorf("Expected success=%v, got %v (error %q)", tt.success, resp.Success, resp.Error)
+			}
+		})
+	}
+
+	dump, _ := tuiServer.ExecuteCommand(ctx, &pb.CommandRequest{Command: "state", Args: []string{"dump"}})
+	if !strings.Contains(dump.Output, `"index": 2`) {
+		t.Errorf("Expected dump to show both cordon entries, got %s", dump.Output)
+	}
+	if store.IsCordoned("node-2") {
+		t.Error("Expected node-2 to be uncordoned")
+	}
+}
+
+func TestTUIServer_GetModelListFromRegistry(t *testing.T) {
+	network, err := NewP2PNetwork("test-node", 8080, 7946)
+	if err != nil {
+		t.Fatalf("Failed to create P2P network: %v", err)
+	}
+	defer network.Stop()
+
+	store, err := state.NewStore(state.Config{NodeID: "test-node"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+	defer store.Close()
+
+	tuiServer := NewTUIServer(network, NewDiscoveryServer(network))
+	tuiServer.SetStateStore(store)
+
+	ctx := context.Background()
+	store.PutModel(ctx, models.Model{ID: "phi-2", Name: "Phi 2", LayerCount: 32, Size: 1 << 30})
+	store.PutPlan(ctx, models.PlacementPlan{
+		ModelID: "phi-2",
+		Stages: []models.PipelineStage{
+			{NodeID: "node-1", LayerStart: 0, LayerEnd: 15},
+			{NodeID: "node-2", LayerStart: 16, LayerEnd: 31},
+		},
+	})
+
+	resp, err := tuiServer.GetModelList(ctx, &pb.ModelListRequest{RequesterId: "tui-client"})
+	if err != nil {
+		t.Fatalf("GetModelList failed: %v", err)
+	}
+	if len(resp.Models) != 1 || resp.Models[0].Id != "phi-2" {
+		t.Fatalf("Expected registered model only, got %+v", resp.Models)
+	}
+
+	assignments := resp.Models[0].NodeAssignments
+	if len(assignments) != 2 || assignments[0] != "node-1" || assignments[1] != "node-2" {
+		t.Errorf("Expected assignments in pipeline order, got %v", assignments)
+	}
+}
+
+func TestTUIServer_PlanCommand(t *testing.T) {
+	network, err := NewP2PNetwork("test-node", 8080, 7946)
+	if err != nil {
+		t.Fatalf("Failed to create P2P network: %v", err)
+	}
+
+	now := time.Now()
+	for _, node := range []models.Node{
+		{ID: "old-1", Version: "v1", ProtocolVersion: 1, Resources: models.ResourceInfo{MaxLayers: 16}},
+		{ID: "new-1", Version: "v2", ProtocolVersion: 2, MinProtocolVersion: 2, Resources: models.ResourceInfo{MaxLayers: 16}},
+		{ID: "new-2", Version: "v2", ProtocolVersion: 2, MinProtocolVersion: 2, Resources: models.ResourceInfo{MaxLayers: 16}},
+	} {
+		node.Status = models.NodeStatusOnline
+		node.LastSeen = now
+		network.registered[node.ID] = node
+	}
+
+	store, err := state.NewStore(state.Config{NodeID: "test-node"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	store.PutModel(ctx, models.Model{ID: "llama-7b", LayerCount: 32})
+
+	tuiServer := NewTUIServer(network, NewDiscoveryServer(network))
+	tuiServer.SetStateStore(store)
+
+	tests := []struct {
+		name    string
+		args    []string
+		success bool
+	}{
+		{"unknown model", []string{"missing"}, false},
+		{"pinned to incompatible nodes", []string{"llama-7b", "old-1,new-1"}, false},
+		{"pinned to one version", []string{"llama-7b", "new-1,new-2"}, true},
+		{"no arguments", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tuiServer.ExecuteCommand(ctx, &pb.CommandRequest{Command: "plan", Args: tt.args})
+			if err != nil {
+				t.Fatalf("ExecuteCommand failed: %v", err)
+			}
+			if resp.Success != tt.success {
+				t.Errorf("Expected success=%v, got %v (error %q)", tt.success, resp.Success, resp.Error)
+			}
+		})
+	}
+
+	plan, ok := store.Plan("llama-7b", 0)
+	if !ok || len(plan.Stages) != 2 || plan.Stages[0].NodeID != "new-1" {
+		t.Errorf("Expected plan on new-1 and new-2, got %+v", plan)
+	}
+}
@@ -0,0 +1,193 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// Health check service names. The empty name reports the agent as a whole,
+// as expected by Kubernetes gRPC probes.
+const (
+	HealthServiceOverall   = ""
+	HealthServiceInference = "inference"
+)
+
+// healthRefreshInterval is how often the gRPC health statuses are recomputed
+const healthRefreshInterval = 5 * time.Second
+
+// Node status values reported by HealthCheck
+const (
+	statusRunning            = "running"
+	statusLoading            = "loading"
+	statusBackendUnavailable = "backend_unavailable"
+	statusModelFailed        = "model_failed"
+)
+
+// ReadinessSource reports whether the inference backend and the models
+// assigned to this node are ready to serve
+type ReadinessSource interface {
+	BackendError() error
+	ModelReadiness() []models.ModelReadiness
+}
+
+// inferenceHealthService names the per-model health entry
+func inferenceHealthService(modelID string) string {
+	return HealthServiceInference + "/" + modelID
+}
+
+// nodeHealth summarizes readiness into a status string. Without a readiness
+// source the node only forwards requests and is always ready.
+func nodeHealth(readiness ReadinessSource) (status string, backendReady bool, modelStates []models.ModelReadiness) {
+	if readiness == nil {
+		return statusRunning, true, nil
+	}
+
+	modelStates = readiness.ModelReadiness()
+	backendReady = readiness.BackendError() == nil
+	if !backendReady {
+		return statusBackendUnavailable, false, modelStates
+	}
+
+	status = statusRunning
+	for _, m := range modelStates {
+		switch m.State {
+		case models.ModelStateFailed:
+			return statusModelFailed, true, modelStates
+		case models.ModelStateLoading:
+			status = statusLoading
+		}
+	}
+	return status, true, modelStates
+}
+
+// CheckJoined fails until the gossip layer is running and, when seed nodes
+// were given, at least one other member has been reached
+func (n *P2PNetwork) CheckJoined(ctx context.Context) error {
+	if !n.Started() {
+		return errors.New("gossip layer not started")
+	}
+
+	n.mu.RLock()
+	seeded, joined := n.seeded, n.joined
+	n.mu.RUnlock()
+	if seeded && !joined && n.memberlist.NumMembers() < 2 {
+		return errors.New("no seed node reachable and no peers joined")
+	}
+	return nil
+}
+
+func (s *NodeServer) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
+	status, backendReady, modelStates := nodeHealth(s.readiness)
+
+	readiness := make([]*pb.ModelReadiness, len(modelStates))
+	for i, m := range modelStates {
+		readiness[i] = &pb.ModelReadiness{
+			ModelId: m.ModelID,
+			State:   string(m.State),
+			Error:   m.Error,
+		}
+	}
+
+	return &pb.HealthCheckResponse{
+		Healthy:       status == statusRunning,
+		Status:        status,
+		UptimeSeconds: int64(s.network.Uptime().Seconds()),
+		BackendReady:  backendReady,
+		Models:        readiness,
+	}, nil
+}
+
+// healthReporter keeps the grpc.health.v1 statuses of the agent's services current
+type healthReporter struct {
+	mu         sync.Mutex
+	server     *health.Server
+	network    *P2PNetwork
+	readiness  ReadinessSource
+	modelNames map[string]bool
+	done       chan struct{}
+}
+
+func newHealthReporter(network *P2PNetwork) *healthReporter {
+	return &healthReporter{
+		server:     health.NewServer(),
+		network:    network,
+		modelNames: make(map[string]bool),
+		done:       make(chan struct{}),
+	}
+}
+
+// update recomputes the serving status of every service
+func (h *healthReporter) update() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	set := func(service string, serving bool) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if serving {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		h.server.SetServingStatus(service, status)
+	}
+
+	// Node and discovery services need the gossip layer; the TUI service
+	// answers from whatever the node knows
+	started := h.network.Started()
+	set(pb.NodeService_ServiceDesc.ServiceName, started)
+	set(pb.DiscoveryService_ServiceDesc.ServiceName, started)
+	set(pb.TUIService_ServiceDesc.ServiceName, true)
+	set(HealthServiceOverall, started)
+
+	status, backendReady, modelStates := nodeHealth(h.readiness)
+	set(HealthServiceInference, status == statusRunning)
+
+	current := make(map[string]bool, len(modelStates))
+	for _, m := range modelStates {
+		current[m.ModelID] = true
+		set(inferenceHealthService(m.ModelID), backendReady && m.State == models.ModelStateReady)
+	}
+	for modelID := range h.modelNames {
+		if !current[modelID] {
+			h.server.SetServingStatus(inferenceHealthService(modelID), healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+		}
+	}
+	h.modelNames = current
+}
+
+func (h *healthReporter) setReadiness(readiness ReadinessSource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = readiness
+}
+
+// run refreshes the statuses until stop is called
+func (h *healthReporter) run() {
+	ticker := time.NewTicker(healthRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			h.update()
+		}
+	}
+}
+
+// stop marks every service as not serving so clients drain before shutdown
+func (h *healthReporter) stop() {
+	select {
+	case <-h.done:
+	default:
+		close(h.done)
+	}
+	h.server.Shutdown()
+}
@@ -0,0 +1,215 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// fakeReadiness is a static ReadinessSource
+type fakeReadiness struct {
+	backendErr error
+	models     []models.ModelReadiness
+}
+
+func (f *fakeReadiness) BackendError() error                     { return f.backendErr }
+func (f *fakeReadiness) ModelReadiness() []models.ModelReadiness { return f.models }
+
+func TestNodeHealth(t *testing.T) {
+	ready := models.ModelReadiness{ModelID: "llama", State: models.ModelStateReady}
+	loading := models.ModelReadiness{ModelID: "mistral", State: models.ModelStateLoading}
+	failed := models.ModelReadiness{ModelID: "phi", State: models.ModelStateFailed, Error: "missing weights"}
+
+	tests := []struct {
+		name      string
+		readiness ReadinessSource
+		status    string
+	}{
+		{"no readiness source", nil, statusRunning},
+		{"all models ready", &fakeReadiness{models: []models.ModelReadiness{ready}}, statusRunning},
+		{"backend missing", &fakeReadiness{backendErr: errors.New("not found"), models: []models.ModelReadiness{ready}}, statusBackendUnavailable},
+		{"model loading", &fakeReadiness{models: []models.ModelReadiness{ready, loading}}, statusLoading},
+		{"model failed", &fakeReadiness{models: []models.ModelReadiness{loading, failed}}, statusModelFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _, _ := nodeHealth(tt.readiness); status != tt.status {
+				t.Errorf("nodeHealth() = %s, want %s", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestHealthCheckReportsReadiness(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	network.startedAt = time.Now().Add(-90 * time.Second)
+	server := &NodeServer{
+		network: network,
+		readiness: &fakeReadiness{models: []models.ModelReadiness{
+			{ModelID: "phi", State: models.ModelStateFailed, Error: "missing weights"},
+		}},
+	}
+
+	resp, err := server.HealthCheck(context.Background(), &pb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if resp.Healthy || resp.Status != statusModelFailed || !resp.BackendReady {
+		t.Errorf("Expected unhealthy model_failed with backend ready, got %+v", resp)
+	}
+	if resp.UptimeSeconds < 90 || resp.UptimeSeconds > 100 {
+		t.Errorf("Expected uptime around 90s, got %d", resp.UptimeSeconds)
+	}
+	if len(resp.Models) != 1 || resp.Models[0].Error != "missing weights" {
+		t.Errorf("Expected per-model readiness, got %+v", resp.Models)
+	}
+}
+
+func TestGRPCHealthService(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	server, err := NewGRPCServer(network, findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	readiness := &fakeReadiness{models: []models.ModelReadiness{
+		{ModelID: "llama", State: models.ModelStateReady},
+		{ModelID: "phi", State: models.ModelStateLoading},
+	}}
+	server.SetReadinessSource(readiness)
+	server.EnableReflection()
+	go server.Start()
+	t.Cleanup(server.Stop)
+
+	addr := fmt.Sprintf("127.0.0.1:%d", server.listener.Addr().(*net.TCPAddr).Port)
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q) failed: %v", service, err)
+		}
+		return resp.Status
+	}
+
+	// The gossip layer isn't running, so only the TUI service is up
+	expected := map[string]healthpb.HealthCheckResponse_ServingStatus{
+		HealthServiceOverall:                        healthpb.HealthCheckResponse_NOT_SERVING,
+		pb.NodeService_ServiceDesc.ServiceName:      healthpb.HealthCheckResponse_NOT_SERVING,
+		pb.DiscoveryService_ServiceDesc.ServiceName: healthpb.HealthCheckResponse_NOT_SERVING,
+		pb.TUIService_ServiceDesc.ServiceName:       healthpb.HealthCheckResponse_SERVING,
+		HealthServiceInference:                      healthpb.HealthCheckResponse_NOT_SERVING,
+		inferenceHealthService("llama"):             healthpb.HealthCheckResponse_SERVING,
+		inferenceHealthService("phi"):               healthpb.HealthCheckResponse_NOT_SERVING,
+	}
+	eventually(t, func() bool { return check(HealthServiceOverall) == expected[HealthServiceOverall] })
+	for service, status := range expected {
+		if got := check(service); got != status {
+			t.Errorf("Service %q status = %v, want %v", service, got, status)
+		}
+	}
+
+	if err := network.Start(nil); err != nil {
+		t.Fatalf("Failed to start network: %v", err)
+	}
+	t.Cleanup(network.Stop)
+	readiness.models = readiness.models[:1]
+	server.health.update()
+
+	if got := check(HealthServiceOverall); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected agent to serve once gossip is running, got %v", got)
+	}
+	if got := check(HealthServiceInference); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected inference to serve once all models are ready, got %v", got)
+	}
+	if got := check(inferenceHealthService("phi")); got != healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("Expected unassigned model to be unknown, got %v", got)
+	}
+
+	// Reflection lists the agent's services for grpcurl
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatalf("Reflection stream failed: %v", err)
+	}
+	if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatalf("Reflection request failed: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Reflection response failed: %v", err)
+	}
+	found := false
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		if service.Name == pb.NodeService_ServiceDesc.ServiceName {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected reflection to list %s", pb.NodeService_ServiceDesc.ServiceName)
+	}
+}
+
+func TestCheckJoined(t *testing.T) {
+	ctx := context.Background()
+
+	solo := newTestNetwork(t, "solo")
+	if err := solo.CheckJoined(ctx); err == nil {
+		t.Error("Expected error before the gossip layer starts")
+	}
+	if err := solo.Start(nil); err != nil {
+		t.Fatalf("Failed to start network: %v", err)
+	}
+	t.Cleanup(solo.Stop)
+	if err := solo.CheckJoined(ctx); err != nil {
+		t.Errorf("Expected a node without seeds to be joined, got %v", err)
+	}
+
+	stranded := newTestNetwork(t, "stranded")
+	if err := stranded.Start([]string{fmt.Sprintf("127.0.0.1:%d", findAvailablePort(t))}); err != nil {
+		t.Fatalf("Failed to start network: %v", err)
+	}
+	t.Cleanup(stranded.Stop)
+	if err := stranded.CheckJoined(ctx); err == nil {
+		t.Error("Expected error when no seed node was reachable")
+	}
+}
+
+func TestCheckServing(t *testing.T) {
+	server, err := NewGRPCServer(newTestNetwork(t, "test-node"), findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	if err := server.CheckServing(context.Background()); err == nil {
+		t.Error("Expected error before Start")
+	}
+
+	go server.Start()
+	eventually(t, func() bool { return server.CheckServing(context.Background()) == nil })
+
+	server.Stop()
+	if err := server.CheckServing(context.Background()); err == nil {
+		t.Error("Expected error after Stop")
+	}
+}
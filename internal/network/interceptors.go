@@ -2,11 +2,245 @@ package network
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"runtime/debug"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "distributed-llm/proto"
 )
 
+// RequestIDMetadataKey is the metadata key carrying a call's request ID.
+// Callers may set it; otherwise the server generates one. Either way it is
+// returned in the response header and passed on to calls made to peers.
+const RequestIDMetadataKey = "x-request-id"
+
+// DefaultCallTimeout bounds unary calls that arrive without a deadline
+const DefaultCallTimeout = 60 * time.Second
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID of the call being served, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random 128-bit request ID
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// incomingRequestID returns the caller's request ID, or a new one
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDMetadataKey); len(ids) > 0 && ids[0] != "" {
+			return ids[0]
+		}
+	}
+	return newRequestID()
+}
+
+// serverInterceptors is the chain installed on every agent gRPC server:
+// request IDs, forwarded stages, tracing, logging, tenants, tenant quotas,
+// metrics, panic recovery and default deadlines, in that order from the
+// outside in, so that panics are traced, logged and counted as failed calls,
+// and rejected requests are logged but not counted
+type serverInterceptors struct {
+	logger         *slog.Logger
+	metrics        *MetricsInterceptor
+	tracer         func() trace.Tracer
+	defaultTimeout atomic.Int64
+	// tenantKeys maps API keys to the tenants they authenticate
+	tenantKeys map[string]string
+	// quotas admits inference requests entering the cluster when set
+	quotas QuotaLimiter
+	// stages reports whether a call was forwarded by another member
+	stages func(context.Context) bool
+}
+
+func newServerInterceptors(logger *slog.Logger, collector MetricsCollector, tracer func() trace.Tracer) *serverInterceptors {
+	i := &serverInterceptors{
+		logger:  logger,
+		metrics: NewMetricsInterceptor(collector),
+		tracer:  tracer,
+	}
+	i.defaultTimeout.Store(int64(DefaultCallTimeout))
+	return i
+}
+
+// serverOptions installs the chain on a server
+func (i *serverInterceptors) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			i.unaryRequestID,
+			i.unaryStage,
+			i.unaryTracing,
+			i.unaryLogging,
+			i.unaryTenant,
+			i.unaryQuota,
+			i.metrics.UnaryServerInterceptor(),
+			i.unaryRecovery,
+			i.unaryDeadline,
+		),
+		grpc.ChainStreamInterceptor(
+			i.streamRequestID,
+			i.streamTracing,
+			i.streamLogging,
+			i.metrics.StreamServerInterceptor(),
+			i.streamRecovery,
+		),
+	}
+}
+
+// setDefaultTimeout changes the deadline given to unary calls without one;
+// 0 leaves them without a deadline
+func (i *serverInterceptors) setDefaultTimeout(timeout time.Duration) {
+	i.defaultTimeout.Store(int64(timeout))
+}
+
+func (i *serverInterceptors) unaryRequestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := incomingRequestID(ctx)
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, id))
+	return handler(context.WithValue(ctx, requestIDKey{}, id), req)
+}
+
+func (i *serverInterceptors) streamRequestID(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	id := incomingRequestID(ss.Context())
+	ss.SetHeader(metadata.Pairs(RequestIDMetadataKey, id))
+	return handler(srv, &contextStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), requestIDKey{}, id)})
+}
+
+func (i *serverInterceptors) unaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	i.logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (i *serverInterceptors) streamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	i.logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+// logCall logs a finished call. Successful calls are logged at debug level
+// so that health probes don't flood the log; server faults at error level.
+func (i *serverInterceptors) logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+		level = slog.LevelDebug
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		level = slog.LevelError
+	}
+	if !i.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("request_id", RequestIDFromContext(ctx)),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("code", code.String()),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		attrs = append(attrs, slog.String("trace_id", span.TraceID().String()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	i.logger.LogAttrs(ctx, level, "gRPC call", attrs...)
+}
+
+func (i *serverInterceptors) unaryRecovery(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = i.recovered(ctx, info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (i *serverInterceptors) streamRecovery(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = i.recovered(ss.Context(), info.FullMethod, r)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// recovered logs a panic in a handler and turns it into an Internal error,
+// keeping the panic's details out of the response
+func (i *serverInterceptors) recovered(ctx context.Context, method string, r any) error {
+	i.logger.Error("Panic in gRPC handler",
+		"method", method,
+		"request_id", RequestIDFromContext(ctx),
+		"panic", r,
+		"stack", string(debug.Stack()))
+	return status.Errorf(codes.Internal, "internal error serving %s", method)
+}
+
+// unaryDeadline gives calls without a deadline the default one. Streams are
+// left alone since they are meant to stay open.
+func (i *serverInterceptors) unaryDeadline(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	timeout := time.Duration(i.defaultTimeout.Load())
+	if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return handler(ctx, req)
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// propagateRequestID passes the request ID of the call being served on to
+// calls made to peers while serving it
+func propagateRequestID(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+}
+
+func propagateRequestIDStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+}
+
+func outgoingRequestID(ctx context.Context) context.Context {
+	id := RequestIDFromContext(ctx)
+	if id == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDMetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, id)
+}
+
 // MetricsInterceptor provides gRPC interceptors for metrics collection
 type MetricsInterceptor struct {
 	metricsCollector MetricsCollector
@@ -28,32 +262,40 @@ func (mi *MetricsInterceptor) UnaryServerInterceptor() grpc.UnaryServerIntercept
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
 		start := time.Now()
+		r, ok := req.(*pb.InferenceRequest)
+		inference := ok && info.FullMethod == pb.NodeService_ProcessInference_FullMethodName
+
+		// Count the requests this node is serving, whether it is their
+		// gateway or a stage of their pipeline
+		if inference && mi.metricsCollector != nil {
+			mi.metricsCollector.AddActiveRequests(r.ModelId, 1)
+			defer mi.metricsCollector.AddActiveRequests(r.ModelId, -1)
+		}
 
 		// Call the handler
-		resp, err := handler(ctx, req) // Record metrics
+		resp, err := handler(ctx, req)
 		duration := time.Since(start)
-
-		// Determine status string for metrics
-		statusStr := "success"
-		if err != nil {
-			statusStr = "error"
+		if mi.metricsCollector == nil {
+			return resp, err
 		}
 
-		// Extract method name from full method
-		method := info.FullMethod
-
-		// Record inference request if it's an inference method
-		if method == "/node.NodeService/ProcessInference" {
-			tokensGenerated := 10 // Default mock value - in real implementation extract from response
-			if mi.metricsCollector != nil {
-				mi.metricsCollector.RecordInferenceRequest("default_model", statusStr, duration, tokensGenerated)
+		// Record inference requests with their model and generated tokens,
+		// once by the node they entered the cluster through rather than
+		// again by each stage of their pipeline
+		if inference && !forwardedStage(ctx) {
+			statusStr := "success"
+			tokensGenerated := 0
+			inference, _ := resp.(*pb.InferenceResponse)
+			if err != nil || inference == nil || !inference.Success {
+				statusStr = "error"
+			} else {
+				tokensGenerated = int(inference.TokensGenerated)
 			}
+			mi.metricsCollector.RecordInferenceRequest(r.ModelId, statusStr, duration, tokensGenerated)
 		}
 
 		// Record general network latency
-		if mi.metricsCollector != nil {
-			mi.metricsCollector.RecordNetworkLatency("grpc_client", method, duration)
-		}
+		mi.metricsCollector.RecordNetworkLatency("grpc_client", info.FullMethod, duration)
 
 		return resp, err
 	}
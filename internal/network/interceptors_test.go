@@ -0,0 +1,377 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// interceptedService answers ProcessInference according to the prompt:
+// "panic" panics, "deadline" returns the time left before the call's
+// deadline, anything else returns the call's request ID
+type interceptedService struct {
+	pb.UnimplementedNodeServiceServer
+}
+
+func (s *interceptedService) ProcessInference(ctx context.Context, req *pb.InferenceRequest) (*pb.InferenceResponse, error) {
+	switch req.Prompt {
+	case "panic":
+		panic("boom")
+	case "deadline":
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return &pb.InferenceResponse{Success: true, GeneratedText: "none"}, nil
+		}
+		return &pb.InferenceResponse{Success: true, GeneratedText: time.Until(deadline).String()}, nil
+	}
+	return &pb.InferenceResponse{Success: true, GeneratedText: RequestIDFromContext(ctx), TokensGenerated: 7}, nil
+}
+
+func (s *interceptedService) StreamMetrics(req *pb.StreamMetricsRequest, stream pb.NodeService_StreamMetricsServer) error {
+	if req.IntervalSeconds < 0 {
+		panic("boom")
+	}
+	return stream.Send(&pb.MetricsUpdate{NodeId: RequestIDFromContext(stream.Context())})
+}
+
+func noopTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(tracerName)
+}
+
+// recordingCollector records inference requests
+type recordingCollector struct {
+	MockMetricsCollector
+	mu        sync.Mutex
+	inference []string
+}
+
+func (c *recordingCollector) RecordInferenceRequest(modelID, status string, duration time.Duration, tokensGenerated int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inference = append(c.inference, modelID+" "+status+" "+strings.Repeat("t", tokensGenerated))
+}
+
+// syncBuffer is a bytes.Buffer safe for the server's goroutines to log to
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the JSON log records written so far
+func (b *syncBuffer) records(t *testing.T) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+type interceptorHarness struct {
+	client       pb.NodeServiceClient
+	interceptors *serverInterceptors
+	collector    *recordingCollector
+	logs         *syncBuffer
+}
+
+// newInterceptorHarness serves interceptedService behind the interceptor
+// chain over an in-memory connection
+func newInterceptorHarness(t *testing.T) *interceptorHarness {
+	t.Helper()
+	h := &interceptorHarness{collector: &recordingCollector{}, logs: &syncBuffer{}}
+	logger := slog.New(slog.NewJSONHandler(h.logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h.interceptors = newServerInterceptors(logger, h.collector, noopTracer)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(h.interceptors.serverOptions()...)
+	pb.RegisterNodeServiceServer(server, &interceptedService{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	h.client = pb.NewNodeServiceClient(conn)
+	return h
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	h := newInterceptorHarness(t)
+
+	_, err := h.client.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "llama-7b", Prompt: "panic"})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Expected Internal after a panic, got %v", err)
+	}
+	if strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected the panic value to stay out of the response, got %v", err)
+	}
+
+	stream, err := h.client.StreamMetrics(context.Background(), &pb.StreamMetricsRequest{IntervalSeconds: -1})
+	if err != nil {
+		t.Fatalf("StreamMetrics failed: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Errorf("Expected Internal after a panic in a stream, got %v", err)
+	}
+
+	// The server keeps serving
+	if _, err := h.client.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "llama-7b"}); err != nil {
+		t.Fatalf("Expected the server to survive the panic, got %v", err)
+	}
+
+	var panics int
+	for _, record := range h.logs.records(t) {
+		if record["msg"] == "Panic in gRPC handler" {
+			panics++
+			if stack, _ := record["stack"].(string); !strings.Contains(stack, "ProcessInference") && !strings.Contains(stack, "StreamMetrics") {
+				t.Errorf("Expected the panic's stack to be logged, got %q", stack)
+			}
+		}
+	}
+	if panics != 2 {
+		t.Errorf("Expected 2 panics logged, got %d", panics)
+	}
+}
+
+func TestRequestIDInterceptor(t *testing.T) {
+	h := newInterceptorHarness(t)
+
+	tests := []struct {
+		name     string
+		incoming string
+	}{
+		{"propagates the caller's ID", "req-42"},
+		{"generates an ID", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.incoming != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, tt.incoming)
+			}
+			var header metadata.MD
+			resp, err := h.client.ProcessInference(ctx, &pb.InferenceRequest{ModelId: "llama-7b"}, grpc.Header(&header))
+			if err != nil {
+				t.Fatalf("ProcessInference failed: %v", err)
+			}
+			id := resp.GeneratedText
+			if tt.incoming != "" && id != tt.incoming {
+				t.Errorf("Expected request ID %s in the handler, got %q", tt.incoming, id)
+			}
+			if tt.incoming == "" && len(id) != 32 {
+				t.Errorf("Expected a request ID in the handler, got %q", id)
+			}
+			if got := header.Get(RequestIDMetadataKey); len(got) != 1 || got[0] != id {
+				t.Errorf("Expected request ID %s in the response header, got %v", id, got)
+			}
+
+			stream, err := h.client.StreamMetrics(ctx, &pb.StreamMetricsRequest{})
+			if err != nil {
+				t.Fatalf("StreamMetrics failed: %v", err)
+			}
+			streamHeader, err := stream.Header()
+			if err != nil {
+				t.Fatalf("Header failed: %v", err)
+			}
+			update, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("Recv failed: %v", err)
+			}
+			if tt.incoming != "" && update.NodeId != tt.incoming {
+				t.Errorf("Expected request ID %s in the stream handler, got %q", tt.incoming, update.NodeId)
+			}
+			if got := streamHeader.Get(RequestIDMetadataKey); len(got) != 1 || got[0] != update.NodeId {
+				t.Errorf("Expected request ID %s in the stream header, got %v", update.NodeId, got)
+			}
+		})
+	}
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	h := newInterceptorHarness(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDMetadataKey, "req-log")
+	h.client.ProcessInference(ctx, &pb.InferenceRequest{ModelId: "llama-7b"})
+	h.client.GetVersion(ctx, &pb.GetVersionRequest{})
+
+	want := map[string]struct {
+		level string
+		code  string
+	}{
+		pb.NodeService_ProcessInference_FullMethodName: {"DEBUG", "OK"},
+		pb.NodeService_GetVersion_FullMethodName:       {"INFO", "Unimplemented"},
+	}
+	logged := 0
+	for _, record := range h.logs.records(t) {
+		if record["msg"] != "gRPC call" {
+			continue
+		}
+		method, _ := record["method"].(string)
+		expected, ok := want[method]
+		if !ok {
+			t.Errorf("Unexpected call logged: %v", record)
+			continue
+		}
+		logged++
+		if record["level"] != expected.level || record["code"] != expected.code {
+			t.Errorf("%s: expected %s/%s, got %v/%v", method, expected.level, expected.code, record["level"], record["code"])
+		}
+		if record["request_id"] != "req-log" {
+			t.Errorf("%s: expected request_id req-log, got %v", method, record["request_id"])
+		}
+		if peer, _ := record["peer"].(string); peer == "" {
+			t.Errorf("%s: expected the peer to be logged", method)
+		}
+		if _, ok := record["duration"].(float64); !ok {
+			t.Errorf("%s: expected a duration, got %v", method, record["duration"])
+		}
+	}
+	if logged != len(want) {
+		t.Errorf("Expected %d calls logged, got %d", len(want), logged)
+	}
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	h := newInterceptorHarness(t)
+	h.interceptors.setDefaultTimeout(5 * time.Second)
+
+	remaining := func(ctx context.Context) string {
+		t.Helper()
+		resp, err := h.client.ProcessInference(ctx, &pb.InferenceRequest{Prompt: "deadline"})
+		if err != nil {
+			t.Fatalf("ProcessInference failed: %v", err)
+		}
+		return resp.GeneratedText
+	}
+	within := func(got string, min, max time.Duration) bool {
+		d, err := time.ParseDuration(got)
+		return err == nil && d > min && d <= max
+	}
+
+	if got := remaining(context.Background()); !within(got, 4*time.Second, 5*time.Second) {
+		t.Errorf("Expected the default deadline of 5s, got %s", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if got := remaining(ctx); !within(got, 0, time.Second) {
+		t.Errorf("Expected the caller's shorter deadline to be kept, got %s", got)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if got := remaining(ctx); !within(got, 50*time.Second, time.Minute) {
+		t.Errorf("Expected the caller's longer deadline to be kept, got %s", got)
+	}
+
+	h.interceptors.setDefaultTimeout(0)
+	if got := remaining(context.Background()); got != "none" {
+		t.Errorf("Expected no deadline once disabled, got %s", got)
+	}
+}
+
+func TestMetricsInterceptorRecordsInference(t *testing.T) {
+	h := newInterceptorHarness(t)
+
+	h.client.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "llama-7b"})
+	h.client.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "mistral-7b", Prompt: "panic"})
+
+	h.collector.mu.Lock()
+	defer h.collector.mu.Unlock()
+	want := []string{"llama-7b success ttttttt", "mistral-7b error "}
+	if strings.Join(h.collector.inference, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected inference metrics %q, got %q", want, h.collector.inference)
+	}
+}
+
+func TestGRPCServerInstallsInterceptors(t *testing.T) {
+	server, err := NewGRPCServer(newTestNetwork(t, "node-b"), findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	go server.Start()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(server.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDMetadataKey, "req-server")
+	if _, err := pb.NewNodeServiceClient(conn).GetVersion(ctx, &pb.GetVersionRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetVersion failed: %v", err)
+	}
+	if got := header.Get(RequestIDMetadataKey); len(got) != 1 || got[0] != "req-server" {
+		t.Errorf("Expected the request ID echoed by the agent, got %v", got)
+	}
+}
+
+func TestPeerCallsPropagateRequestID(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer(newServerInterceptors(slog.Default(), nil, noopTracer).serverOptions()...)
+	pb.RegisterNodeServiceServer(server, &interceptedService{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	registerNode(network, models.Node{ID: "node-b", Address: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port})
+	client, err := network.peerClient("node-b")
+	if err != nil {
+		t.Fatalf("peerClient failed: %v", err)
+	}
+
+	// A call made while serving request req-7 carries its ID
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-7")
+	resp, err := client.ProcessInference(ctx, &pb.InferenceRequest{ModelId: "llama-7b"})
+	if err != nil {
+		t.Fatalf("ProcessInference failed: %v", err)
+	}
+	if resp.GeneratedText != "req-7" {
+		t.Errorf("Expected the peer to see request ID req-7, got %q", resp.GeneratedText)
+	}
+}
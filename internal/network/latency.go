@@ -0,0 +1,451 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+
+	"distributed-llm/internal/gossip"
+	pb "distributed-llm/proto"
+)
+
+// DefaultProbeInterval is how often each agent probes its peers over gRPC
+const DefaultProbeInterval = 30 * time.Second
+
+// probePayloadSize is the payload sent to estimate bandwidth
+const probePayloadSize = 256 << 10
+
+// probeTimeout bounds the probes of a single peer
+const probeTimeout = 5 * time.Second
+
+// probeFanout is how many peers, picked at random, are probed each interval
+const probeFanout = 3
+
+// bandwidthProbeRounds is how many intervals pass between bandwidth probes
+// of a peer; the rounds in between only measure RTT
+const bandwidthProbeRounds = 10
+
+// latencySmoothing is the weight of a new sample in the moving averages
+const latencySmoothing = 0.2
+
+// PeerLatency is one node's measurement of the path to another
+type PeerLatency struct {
+	RTT time.Duration `json:"rtt"`
+	// Bandwidth is in bytes per second, zero until a probe measured it
+	Bandwidth float64   `json:"bw,omitempty"`
+	Updated   time.Time `json:"updated"`
+}
+
+// LatencyEntry is the path between two nodes, measured by one of them or
+// estimated from their network coordinates
+type LatencyEntry struct {
+	From      string
+	To        string
+	RTT       time.Duration
+	Bandwidth float64
+	Estimated bool
+}
+
+// LatencyMetrics exports this node's measurements
+type LatencyMetrics interface {
+	UpdatePeerLatency(peer string, rtt time.Duration, bandwidth float64)
+	RemovePeerLatency(peer string)
+}
+
+// latencyReport is what a node gossips about its view of the network
+type latencyReport struct {
+	gossip.Report
+	Coordinate Coordinate             `json:"coordinate"`
+	Peers      map[string]PeerLatency `json:"peers"`
+}
+
+// LatencyTracker builds the cluster's RTT matrix. This node's row comes from
+// memberlist pings and gRPC probes; the rows of other nodes arrive by gossip.
+// Pairs nobody measured are estimated from Vivaldi coordinates, which are
+// exchanged in memberlist ping acks.
+type LatencyTracker struct {
+	nodeID  string
+	mu      sync.RWMutex
+	reports *gossip.Table[latencyReport, *latencyReport]
+	metrics LatencyMetrics
+	logger  *slog.Logger
+}
+
+// NewLatencyTracker creates a tracker for nodeID
+func NewLatencyTracker(nodeID string) *LatencyTracker {
+	return &LatencyTracker{
+		nodeID:  nodeID,
+		reports: gossip.NewTable(nodeID, 0, &latencyReport{Coordinate: NewCoordinate(), Peers: make(map[string]PeerLatency)}),
+		logger:  slog.With("component", "latency"),
+	}
+}
+
+// SetMetrics exports this node's measurements
+func (t *LatencyTracker) SetMetrics(metrics LatencyMetrics) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = metrics
+}
+
+// Coordinate returns this node's network coordinate
+func (t *LatencyTracker) Coordinate() Coordinate {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.reports.Local().Coordinate
+}
+
+// AckPayload sends our coordinate with every ping ack (memberlist.PingDelegate)
+func (t *LatencyTracker) AckPayload() []byte {
+	data, _ := json.Marshal(t.Coordinate())
+	return data
+}
+
+// NotifyPingComplete records a memberlist ping and moves our coordinate
+// (memberlist.PingDelegate)
+func (t *LatencyTracker) NotifyPingComplete(other *memberlist.Node, rtt time.Duration, payload []byte) {
+	var coordinate Coordinate
+	if err := json.Unmarshal(payload, &coordinate); err == nil {
+		t.mu.Lock()
+		t.reports.Local().Coordinate.Update(coordinate, rtt)
+		t.peerReport(other.Name).Coordinate = coordinate
+		t.mu.Unlock()
+	}
+	t.ObserveRTT(other.Name, rtt)
+}
+
+// peerReport returns the report for nodeID, creating it. t.mu must be held.
+func (t *LatencyTracker) peerReport(nodeID string) *latencyReport {
+	report, ok := t.reports.Get(nodeID)
+	if !ok {
+		report = &latencyReport{Report: gossip.Report{NodeID: nodeID}, Coordinate: NewCoordinate()}
+		t.reports.Put(report)
+	}
+	return report
+}
+
+// ObserveRTT records a round trip time measured to peer
+func (t *LatencyTracker) ObserveRTT(peer string, rtt time.Duration) {
+	t.observe(peer, func(latency *PeerLatency) {
+		if latency.RTT == 0 {
+			latency.RTT = rtt
+		} else {
+			latency.RTT = time.Duration(latencySmoothing*float64(rtt) + (1-latencySmoothing)*float64(latency.RTT))
+		}
+	})
+}
+
+// ObserveBandwidth records a bandwidth in bytes per second measured to peer
+func (t *LatencyTracker) ObserveBandwidth(peer string, bandwidth float64) {
+	t.observe(peer, func(latency *PeerLatency) {
+		if latency.Bandwidth == 0 {
+			latency.Bandwidth = bandwidth
+		} else {
+			latency.Bandwidth = latencySmoothing*bandwidth + (1-latencySmoothing)*latency.Bandwidth
+		}
+	})
+}
+
+func (t *LatencyTracker) observe(peer string, update func(*PeerLatency)) {
+	if peer == t.nodeID {
+		return
+	}
+
+	t.mu.Lock()
+	self := t.reports.Local()
+	latency := self.Peers[peer]
+	update(&latency)
+	latency.Updated = time.Now()
+	self.Peers[peer] = latency
+	metrics := t.metrics
+	t.mu.Unlock()
+
+	if metrics != nil {
+		metrics.UpdatePeerLatency(peer, latency.RTT, latency.Bandwidth)
+	}
+}
+
+// Forget drops a node that left the cluster
+func (t *LatencyTracker) Forget(nodeID string) {
+	if nodeID == t.nodeID {
+		return
+	}
+
+	t.mu.Lock()
+	t.reports.Delete(nodeID)
+	self := t.reports.Local()
+	_, measured := self.Peers[nodeID]
+	delete(self.Peers, nodeID)
+	metrics := t.metrics
+	t.mu.Unlock()
+
+	if measured && metrics != nil {
+		metrics.RemovePeerLatency(nodeID)
+	}
+}
+
+// RTT returns the round trip time between two nodes, preferring a
+// measurement by either of them over a coordinate estimate
+func (t *LatencyTracker) RTT(from, to string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.entry(from, to)
+	return entry.RTT, ok
+}
+
+// entry describes the path from one node to another. t.mu must be held.
+func (t *LatencyTracker) entry(from, to string) (LatencyEntry, bool) {
+	entry := LatencyEntry{From: from, To: to}
+	if from == to {
+		return entry, true
+	}
+
+	a, _ := t.reports.Get(from)
+	b, _ := t.reports.Get(to)
+	var measured []PeerLatency
+	if a != nil {
+		if latency, ok := a.Peers[to]; ok {
+			measured = append(measured, latency)
+		}
+	}
+	if b != nil {
+		if latency, ok := b.Peers[from]; ok {
+			measured = append(measured, latency)
+		}
+	}
+	if len(measured) > 0 {
+		// The freshest RTT, and the bandwidth of whoever measured one
+		sort.Slice(measured, func(i, j int) bool { return measured[i].Updated.After(measured[j].Updated) })
+		entry.RTT = measured[0].RTT
+		for _, latency := range measured {
+			if latency.Bandwidth > 0 {
+				entry.Bandwidth = latency.Bandwidth
+				break
+			}
+		}
+		return entry, true
+	}
+
+	if a == nil || b == nil {
+		return entry, false
+	}
+	entry.RTT = a.Coordinate.DistanceTo(b.Coordinate)
+	entry.Estimated = true
+	return entry, true
+}
+
+// Matrix returns every known path between the nodes, sorted by source and
+// target
+func (t *LatencyTracker) Matrix() []LatencyEntry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	// Peers measured before their own report arrives are nodes too
+	known := make(map[string]bool)
+	t.reports.Each(time.Now(), func(report *latencyReport) {
+		known[report.NodeID] = true
+		for peer := range report.Peers {
+			known[peer] = true
+		}
+	})
+	nodes := make([]string, 0, len(known))
+	for id := range known {
+		nodes = append(nodes, id)
+	}
+	sort.Strings(nodes)
+
+	entries := make([]LatencyEntry, 0)
+	for _, from := range nodes {
+		for _, to := range nodes {
+			if from == to {
+				continue
+			}
+			if entry, ok := t.entry(from, to); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// localReport encodes this node's row for gossip
+func (t *LatencyTracker) localReport() []byte {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.reports.EncodeLocal(time.Now())
+}
+
+// merge keeps the newest report of each other node
+func (t *LatencyTracker) merge(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.reports.Merge(data); err != nil {
+		t.logger.Debug("Dropping malformed latency report", "error", err)
+	}
+}
+
+// HandleMessage merges a report gossiped by another node (ChannelHandler)
+func (t *LatencyTracker) HandleMessage(msg []byte) {
+	t.merge(msg)
+}
+
+// LocalState shares every report we hold during push/pull, so joining nodes
+// learn the whole matrix at once (ChannelHandler)
+func (t *LatencyTracker) LocalState() []byte {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.reports.Encode(time.Now())
+}
+
+// MergeRemoteState merges the reports of a push/pull peer (ChannelHandler)
+func (t *LatencyTracker) MergeRemoteState(buf []byte) {
+	t.merge(buf)
+}
+
+// Latency returns the tracker behind this node's RTT matrix
+func (n *P2PNetwork) Latency() *LatencyTracker {
+	return n.latency
+}
+
+// ProbeLatency measures the RTT to a few peers picked at random over gRPC
+// each interval, and their bandwidth every bandwidthProbeRounds intervals,
+// then gossips the results, until ctx is done. Peers failing their probe are
+// published as suspect.
+func (n *P2PNetwork) ProbeLatency(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// bandwidthProbed is when each peer's bandwidth was last probed
+	bandwidthProbed := make(map[string]time.Time)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		peers := n.probePeers()
+		for id := range bandwidthProbed {
+			if !slices.Contains(peers, id) {
+				delete(bandwidthProbed, id)
+			}
+		}
+
+		now := time.Now()
+		var wg sync.WaitGroup
+		for _, nodeID := range peers[:min(probeFanout, len(peers))] {
+			bandwidth := now.Sub(bandwidthProbed[nodeID]) >= bandwidthProbeRounds*interval
+			if bandwidth {
+				bandwidthProbed[nodeID] = now
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := n.probePeer(ctx, nodeID, bandwidth)
+				if err != nil {
+					n.logger.Debug("Latency probe failed", "node", nodeID, "error", err)
+				}
+				if ctx.Err() == nil {
+					n.markSuspect(nodeID, err)
+				}
+			}()
+		}
+		wg.Wait()
+		n.Broadcast(ChannelLatency, n.latency.localReport())
+	}
+}
+
+// probePeers returns the peers that can be probed, shuffled
+func (n *P2PNetwork) probePeers() []string {
+	var peers []string
+	for _, node := range n.GetNodes() {
+		if node.ID != n.nodeID && node.Address != "" && node.Port != 0 {
+			peers = append(peers, node.ID)
+		}
+	}
+	mathrand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+	return peers
+}
+
+// probePeer times an empty probe for RTT and, if bandwidth is set, a larger
+// one for bandwidth, over the connection shared with other calls to the peer
+func (n *P2PNetwork) probePeer(ctx context.Context, nodeID string, bandwidth bool) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	// Peer connections don't compress, so the payload measures the link
+	// rather than gzip
+	client, err := n.peerClient(nodeID)
+	if err != nil {
+		return err
+	}
+
+	// The first call may pay for connection setup, so keep the fastest of three
+	var rtt time.Duration
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if _, err := client.ProbeLatency(ctx, &pb.ProbeRequest{NodeId: n.nodeID}); err != nil {
+			return err
+		}
+		if elapsed := time.Since(start); rtt == 0 || elapsed < rtt {
+			rtt = elapsed
+		}
+	}
+	n.latency.ObserveRTT(nodeID, rtt)
+	if n.metricsCollector != nil {
+		n.metricsCollector.RecordNetworkLatency(nodeID, "probe", rtt)
+	}
+	if !bandwidth {
+		return nil
+	}
+
+	payload := make([]byte, probePayloadSize)
+	rand.Read(payload)
+	start := time.Now()
+	resp, err := client.ProbeLatency(ctx, &pb.ProbeRequest{NodeId: n.nodeID, Payload: payload})
+	if err != nil {
+		return err
+	}
+	if resp.ReceivedBytes != probePayloadSize {
+		return fmt.Errorf("peer received %d of %d probe bytes", resp.ReceivedBytes, probePayloadSize)
+	}
+	if transfer := time.Since(start) - rtt; transfer > 0 {
+		n.latency.ObserveBandwidth(nodeID, probePayloadSize/transfer.Seconds())
+	}
+	return nil
+}
+
+// GetLatencyMatrix returns the RTT and bandwidth between every pair of known nodes
+func (s *NodeServer) GetLatencyMatrix(ctx context.Context, req *pb.LatencyMatrixRequest) (*pb.LatencyMatrixResponse, error) {
+	matrix := s.network.latency.Matrix()
+	entries := make([]*pb.LatencyEntry, len(matrix))
+	for i, entry := range matrix {
+		entries[i] = &pb.LatencyEntry{
+			FromNode:                entry.From,
+			ToNode:                  entry.To,
+			RttMs:                   float64(entry.RTT) / float64(time.Millisecond),
+			BandwidthBytesPerSecond: entry.Bandwidth,
+			Estimated:               entry.Estimated,
+		}
+	}
+	return &pb.LatencyMatrixResponse{Entries: entries}, nil
+}
+
+// ProbeLatency answers latency and bandwidth probes from peers
+func (s *NodeServer) ProbeLatency(ctx context.Context, req *pb.ProbeRequest) (*pb.ProbeResponse, error) {
+	return &pb.ProbeResponse{
+		NodeId:        s.network.nodeID,
+		ReceivedBytes: int64(len(req.Payload)),
+	}, nil
+}
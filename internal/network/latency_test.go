@@ -0,0 +1,182 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+
+	"distributed-llm/internal/gossip"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// recordingLatencyMetrics keeps the latest exported values
+type recordingLatencyMetrics struct {
+	mu        sync.Mutex
+	rtt       map[string]time.Duration
+	bandwidth map[string]float64
+}
+
+func (m *recordingLatencyMetrics) UpdatePeerLatency(peer string, rtt time.Duration, bandwidth float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rtt[peer] = rtt
+	m.bandwidth[peer] = bandwidth
+}
+
+func (m *recordingLatencyMetrics) RemovePeerLatency(peer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rtt, peer)
+	delete(m.bandwidth, peer)
+}
+
+func TestLatencyTrackerObserve(t *testing.T) {
+	tracker := NewLatencyTracker("node-a")
+	metrics := &recordingLatencyMetrics{rtt: map[string]time.Duration{}, bandwidth: map[string]float64{}}
+	tracker.SetMetrics(metrics)
+
+	tracker.ObserveRTT("node-b", 10*time.Millisecond)
+	tracker.ObserveRTT("node-b", 20*time.Millisecond)
+	tracker.ObserveBandwidth("node-b", 1e6)
+	tracker.ObserveRTT("node-a", time.Second) // ignored
+
+	rtt, ok := tracker.RTT("node-a", "node-b")
+	if !ok || rtt != 12*time.Millisecond {
+		t.Errorf("Expected smoothed RTT of 12ms, got %v (%v)", rtt, ok)
+	}
+	if back, _ := tracker.RTT("node-b", "node-a"); back != rtt {
+		t.Errorf("Expected the measurement to apply both ways, got %v", back)
+	}
+	if metrics.rtt["node-b"] != rtt || metrics.bandwidth["node-b"] != 1e6 {
+		t.Errorf("Expected metrics to follow measurements, got %v %v", metrics.rtt, metrics.bandwidth)
+	}
+
+	tracker.Forget("node-b")
+	if _, ok := tracker.RTT("node-a", "node-b"); ok {
+		t.Error("Expected forgotten node to have no RTT")
+	}
+	if _, ok := metrics.rtt["node-b"]; ok {
+		t.Error("Expected forgotten node's metrics to be removed")
+	}
+}
+
+func TestLatencyMatrixMergesReports(t *testing.T) {
+	a, b := NewLatencyTracker("node-a"), NewLatencyTracker("node-b")
+	a.ObserveRTT("node-b", 5*time.Millisecond)
+	b.ObserveRTT("node-c", 40*time.Millisecond)
+	b.ObserveBandwidth("node-c", 2e6)
+
+	// node-a learns node-b's row by gossip and node-c's coordinate by ping
+	a.HandleMessage(b.localReport())
+	payload, _ := json.Marshal(Coordinate{Vec: [coordinateDimensions]float64{0.03}, Height: minCoordinateHeight})
+	a.NotifyPingComplete(&memberlist.Node{Name: "node-c"}, 30*time.Millisecond, payload)
+
+	entries := make(map[string]LatencyEntry)
+	for _, entry := range a.Matrix() {
+		entries[entry.From+"->"+entry.To] = entry
+	}
+
+	if entry := entries["node-b->node-c"]; entry.RTT != 40*time.Millisecond || entry.Bandwidth != 2e6 || entry.Estimated {
+		t.Errorf("Expected node-b's measurement of node-c, got %+v", entry)
+	}
+	if entry := entries["node-a->node-c"]; entry.RTT != 30*time.Millisecond || entry.Estimated {
+		t.Errorf("Expected node-a's ping of node-c, got %+v", entry)
+	}
+	if len(entries) != 6 {
+		t.Errorf("Expected every ordered pair of 3 nodes, got %d entries", len(entries))
+	}
+
+	// Older reports do not replace newer ones
+	stale := b.localReport()
+	b.ObserveRTT("node-c", 400*time.Millisecond)
+	a.MergeRemoteState(b.LocalState())
+	a.HandleMessage(stale)
+	if rtt, _ := a.RTT("node-b", "node-c"); rtt == 40*time.Millisecond {
+		t.Errorf("Expected the newer report to win, got %v", rtt)
+	}
+}
+
+func TestLatencyEstimatedFromCoordinates(t *testing.T) {
+	tracker := NewLatencyTracker("node-a")
+	tracker.MergeRemoteState(mustJSON(t, []latencyReport{
+		{Report: gossip.Report{NodeID: "node-b", Sent: time.Now()}, Coordinate: Coordinate{Height: 0.01}},
+		{Report: gossip.Report{NodeID: "node-c", Sent: time.Now()}, Coordinate: Coordinate{Height: 0.02}},
+	}))
+
+	rtt, ok := tracker.RTT("node-b", "node-c")
+	if !ok || rtt != 30*time.Millisecond {
+		t.Errorf("Expected coordinate estimate of 30ms, got %v (%v)", rtt, ok)
+	}
+	if _, ok := tracker.RTT("node-b", "node-x"); ok {
+		t.Error("Expected no RTT for an unknown node")
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestProbePeer(t *testing.T) {
+	server, err := NewGRPCServer(newTestNetwork(t, "node-b"), findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	go server.Start()
+	t.Cleanup(server.Stop)
+
+	prober := newTestNetwork(t, "node-a")
+	t.Cleanup(prober.Stop)
+	registerNode(prober, models.Node{ID: "node-b", Address: "127.0.0.1", Port: server.listener.Addr().(*net.TCPAddr).Port})
+	if err := prober.probePeer(context.Background(), "node-b", true); err != nil {
+		t.Fatalf("probePeer failed: %v", err)
+	}
+	// Later probes measure only RTT, over the same connection
+	if err := prober.probePeer(context.Background(), "node-b", false); err != nil {
+		t.Fatalf("probePeer failed: %v", err)
+	}
+	prober.connMu.Lock()
+	conns := len(prober.conns)
+	prober.connMu.Unlock()
+	if conns != 1 {
+		t.Errorf("Expected the probes to share one connection, got %d", conns)
+	}
+
+	resp, err := (&NodeServer{network: prober}).GetLatencyMatrix(context.Background(), &pb.LatencyMatrixRequest{})
+	if err != nil {
+		t.Fatalf("GetLatencyMatrix failed: %v", err)
+	}
+	if len(resp.Entries) != 2 {
+		t.Fatalf("Expected both directions between node-a and node-b, got %+v", resp.Entries)
+	}
+	entry := resp.Entries[0]
+	if entry.FromNode != "node-a" || entry.ToNode != "node-b" || entry.RttMs <= 0 || entry.BandwidthBytesPerSecond <= 0 || entry.Estimated {
+		t.Errorf("Expected a measured RTT and bandwidth, got %+v", entry)
+	}
+}
+
+func TestProbePeers(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	want := []string{"node-b", "node-c", "node-d", "node-e"}
+	for i, id := range want {
+		registerNode(network, models.Node{ID: id, Address: "127.0.0.1", Port: 9000 + i})
+	}
+	registerNode(network, models.Node{ID: "node-f", Address: "127.0.0.1"})
+
+	peers := network.probePeers()
+	slices.Sort(peers)
+	if !slices.Equal(peers, want) {
+		t.Errorf("Expected the peers with an address, got %v", peers)
+	}
+}
@@ -0,0 +1,449 @@
+package network
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// DefaultClusterID is the cluster ID used when none is configured
+const DefaultClusterID = "distributed-llm-cluster"
+
+const (
+	// registeredNodeTTL is how long a node admitted over gRPC stays in the
+	// cluster view without re-registering
+	registeredNodeTTL = 2 * time.Minute
+
+	// JoinHeartbeatInterval is how often KeepJoined re-registers with the cluster
+	JoinHeartbeatInterval = 30 * time.Second
+
+	joinTimeout = 5 * time.Second
+)
+
+// admission is a registration request from either RegisterNode or RegisterWithCluster
+type admission struct {
+	NodeID          string
+	Address         string
+	Port            int32
+	GossipPort      int32
+	Resources       *pb.ResourceInfo
+	SeedNodes       []string
+	Version         string
+	ProtocolVersion int32
+	MinProtocol     int32
+	ClusterID       string
+	AuthToken       string
+}
+
+// SetClusterID sets the cluster ID that joining nodes must present
+func (n *P2PNetwork) SetClusterID(clusterID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.clusterID = clusterID
+}
+
+// ClusterID returns the cluster this node belongs to
+func (n *P2PNetwork) ClusterID() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.clusterID == "" {
+		return DefaultClusterID
+	}
+	return n.clusterID
+}
+
+// SetJoinToken sets the shared secret required to join the cluster over gRPC.
+// The same token is presented when this node joins others.
+func (n *P2PNetwork) SetJoinToken(token string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.joinToken = token
+}
+
+// SetLocalResources records the resources this node advertises to the cluster
+func (n *P2PNetwork) SetLocalResources(resources models.ResourceInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.localResources = &resources
+}
+
+// LocalResources returns the advertised resources of this node, if known
+func (n *P2PNetwork) LocalResources() (models.ResourceInfo, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.localResources == nil {
+		return models.ResourceInfo{}, false
+	}
+	return *n.localResources, true
+}
+
+// admit validates a joining node, records it in the cluster view and asks
+// memberlist to gossip with it. It returns the other nodes already known.
+func (n *P2PNetwork) admit(ctx context.Context, req admission) ([]models.Node, error) {
+	if err := n.validateAdmission(ctx, req); err != nil {
+		if n.metricsCollector != nil {
+			n.metricsCollector.RecordNetworkMessage("incoming", "register_rejected")
+		}
+		return nil, err
+	}
+
+	address := req.Address
+	if address == "" {
+		if remote := peerIP(ctx); remote != nil {
+			address = remote.String()
+		}
+	}
+
+	node := models.Node{
+		ID:                 req.NodeID,
+		Address:            address,
+		Port:               int(req.Port),
+		Status:             models.NodeStatusOnline,
+		LastSeen:           time.Now(),
+		Version:            req.Version,
+		ProtocolVersion:    req.ProtocolVersion,
+		MinProtocolVersion: req.MinProtocol,
+	}
+	if req.Resources != nil {
+		node.Resources = *models.ResourceInfoFromProto(req.Resources)
+		node.Resources.UsedLayers = req.Resources.UsedLayers
+	}
+
+	n.mu.Lock()
+	n.registered[node.ID] = node
+	n.pruneRegisteredLocked()
+	n.mu.Unlock()
+
+	n.logger.Info("Admitted node over gRPC",
+		"nodeID", req.NodeID,
+		"address", address,
+		"port", req.Port,
+		"version", req.Version)
+	if n.metricsCollector != nil {
+		n.metricsCollector.RecordNetworkMessage("incoming", "register_accepted")
+	}
+
+	seeds := append([]string{}, req.SeedNodes...)
+	if address != "" && req.GossipPort > 0 {
+		seeds = append(seeds, net.JoinHostPort(address, strconv.Itoa(int(req.GossipPort))))
+	}
+	// Nodes re-register periodically; only join those not gossiping yet
+	if seeds = n.unjoinedSeeds(seeds); len(seeds) > 0 {
+		// Joining may block on unreachable UDP/TCP gossip ports; the node is
+		// already in the cluster view so don't hold up the RPC
+		go n.joinSeeds(seeds)
+	}
+
+	existing := make([]models.Node, 0)
+	for _, known := range n.GetNodes() {
+		if known.ID != req.NodeID {
+			existing = append(existing, known)
+		}
+	}
+	return existing, nil
+}
+
+// peerIP returns the address the call being served comes from, or nil
+func peerIP(ctx context.Context) net.IP {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+func (n *P2PNetwork) validateAdmission(ctx context.Context, req admission) error {
+	if req.NodeID == "" {
+		return status.Error(codes.InvalidArgument, "node ID cannot be empty")
+	}
+	if strings.ContainsAny(req.NodeID, "\n\r\t") {
+		return status.Error(codes.InvalidArgument, "node ID cannot contain newlines or tabs")
+	}
+	if req.NodeID == n.nodeID {
+		return status.Errorf(codes.AlreadyExists, "node ID %s is already in use", req.NodeID)
+	}
+
+	n.mu.RLock()
+	token := n.joinToken
+	n.mu.RUnlock()
+	if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(req.AuthToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid join token")
+	}
+
+	clusterID := req.ClusterID
+	if clusterID == "" {
+		clusterID = DefaultClusterID
+	}
+	if clusterID != n.ClusterID() {
+		return status.Errorf(codes.FailedPrecondition, "cluster ID mismatch: node belongs to %q, this cluster is %q", clusterID, n.ClusterID())
+	}
+	if err := n.checkMemberID(ctx, req.NodeID); err != nil {
+		return err
+	}
+
+	local := n.localNode()
+	remote := models.Node{ProtocolVersion: req.ProtocolVersion, MinProtocolVersion: req.MinProtocol}
+	if !local.CompatibleWith(&remote) {
+		return status.Errorf(codes.FailedPrecondition, "incompatible protocol version %d (version %s), this node speaks %d-%d",
+			req.ProtocolVersion, req.Version, MinProtocolVersion, ProtocolVersion)
+	}
+
+	return nil
+}
+
+// checkMemberID rejects a node ID held by a live member unless the request
+// comes from that member's address, so that registering can't take over
+// another node's place in the cluster view. The address the node claims is
+// not trusted; the connection's is.
+func (n *P2PNetwork) checkMemberID(ctx context.Context, nodeID string) error {
+	if n.memberlist == nil {
+		return nil
+	}
+	remote := peerIP(ctx)
+	for _, member := range n.memberlist.Members() {
+		if member.Name == nodeID && (remote == nil || !remote.Equal(member.Addr)) {
+			return status.Errorf(codes.AlreadyExists, "node ID %s is already in use by a member at %s", nodeID, member.Addr)
+		}
+	}
+	return nil
+}
+
+// unjoinedSeeds leaves out the seeds that are the gossip address of a member
+func (n *P2PNetwork) unjoinedSeeds(seeds []string) []string {
+	if n.memberlist == nil {
+		return seeds
+	}
+	members := make(map[string]bool)
+	for _, member := range n.memberlist.Members() {
+		members[net.JoinHostPort(member.Addr.String(), strconv.Itoa(int(member.Port)))] = true
+	}
+
+	var unjoined []string
+	for _, seed := range seeds {
+		if !members[seed] {
+			unjoined = append(unjoined, seed)
+		}
+	}
+	return unjoined
+}
+
+func (n *P2PNetwork) joinSeeds(seeds []string) {
+	if n.memberlist == nil {
+		return
+	}
+
+	joined, err := n.memberlist.Join(seeds)
+	if err != nil {
+		n.logger.Warn("Could not gossip with registered node, keeping it via gRPC only", "seeds", seeds, "error", err)
+		return
+	}
+	n.logger.Info("Joined registered node into memberlist", "seeds", seeds, "contacted", joined)
+}
+
+// Evict drops the nodes at host from the cluster view and the member set
+// until they are joined again, for when an orchestrator reports a node gone
+// before gossip notices. memberlist itself keeps gossiping with them until
+// its failure detector declares them dead.
+func (n *P2PNetwork) Evict(host string) {
+	n.evictMu.Lock()
+	if n.evicted == nil {
+		n.evicted = make(map[string]bool)
+	}
+	n.evicted[host] = true
+	n.evictMu.Unlock()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for id, node := range n.registered {
+		if node.Address == host {
+			delete(n.registered, id)
+		}
+	}
+}
+
+// clearEviction returns the nodes at host to the cluster view
+func (n *P2PNetwork) clearEviction(host string) {
+	n.evictMu.Lock()
+	defer n.evictMu.Unlock()
+	delete(n.evicted, host)
+}
+
+// evictedHosts returns a copy of the evicted hosts
+func (n *P2PNetwork) evictedHosts() map[string]bool {
+	n.evictMu.Lock()
+	defer n.evictMu.Unlock()
+
+	hosts := make(map[string]bool, len(n.evicted))
+	for host := range n.evicted {
+		hosts[host] = true
+	}
+	return hosts
+}
+
+// forget drops a node admitted over gRPC from the cluster view
+func (n *P2PNetwork) forget(nodeID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.registered, nodeID)
+}
+
+func (n *P2PNetwork) pruneRegisteredLocked() {
+	for id, node := range n.registered {
+		if time.Since(node.LastSeen) > registeredNodeTTL {
+			delete(n.registered, id)
+		}
+	}
+}
+
+// JoinViaGRPC registers this node with the agents at the given gRPC addresses.
+// It is a fallback for nodes whose gossip traffic is blocked: the remote agent
+// lists us in its cluster view even if memberlist cannot reach us, and the
+// nodes it returns are added to our own view. It succeeds if any agent admits us.
+func (n *P2PNetwork) JoinViaGRPC(ctx context.Context, addrs []string) error {
+	req := n.joinRequest()
+
+	var lastErr error
+	admitted := 0
+	for _, addr := range addrs {
+		existing, err := n.registerWith(ctx, addr, req)
+		if err != nil {
+			n.logger.Warn("gRPC cluster join failed", "address", addr, "error", err)
+			lastErr = err
+			continue
+		}
+
+		n.mu.Lock()
+		for _, info := range existing {
+			if info.NodeId == n.nodeID {
+				continue
+			}
+			// Keep when the agent last saw the node, so that nodes gone
+			// from the cluster still expire while agents report them
+			node := models.NodeFromProto(info)
+			if info.LastSeen == 0 {
+				node.LastSeen = time.Now()
+			}
+			if known, ok := n.registered[node.ID]; ok && known.LastSeen.After(node.LastSeen) {
+				continue
+			}
+			n.registered[node.ID] = node
+		}
+		n.mu.Unlock()
+		admitted++
+	}
+
+	if admitted == 0 && lastErr != nil {
+		return fmt.Errorf("no agent admitted this node: %w", lastErr)
+	}
+	return nil
+}
+
+// KeepJoined re-registers with the given agents until the context is cancelled,
+// keeping this node in their cluster view
+func (n *P2PNetwork) KeepJoined(ctx context.Context, addrs []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.JoinViaGRPC(ctx, addrs); err != nil {
+				n.logger.Warn("Cluster re-registration failed", "error", err)
+			}
+		}
+	}
+}
+
+func (n *P2PNetwork) joinRequest() *pb.ClusterJoinRequest {
+	n.mu.RLock()
+	token := n.joinToken
+	resources := n.localResources
+	n.mu.RUnlock()
+
+	req := &pb.ClusterJoinRequest{
+		NodeId:             n.nodeID,
+		Port:               int32(n.bindPort),
+		GossipPort:         int32(n.gossipPort),
+		Version:            n.Version(),
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+		ClusterId:          n.ClusterID(),
+		AuthToken:          token,
+	}
+	if n.memberlist != nil {
+		req.Address = n.memberlist.LocalNode().Addr.String()
+	}
+	if resources != nil {
+		req.Resources = resources.ToProto()
+		req.Resources.UsedLayers = resources.UsedLayers
+	}
+	return req
+}
+
+func (n *P2PNetwork) registerWith(ctx context.Context, addr string, req *pb.ClusterJoinRequest) ([]*pb.NodeInfo, error) {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, joinTimeout)
+	defer cancel()
+
+	resp, err := pb.NewDiscoveryServiceClient(conn).RegisterWithCluster(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.ExistingNodes, nil
+}
+
+// nodeToProto converts a node to its protobuf form
+func nodeToProto(node models.Node) *pb.NodeInfo {
+	resources := node.Resources.ToProto()
+	resources.UsedLayers = node.Resources.UsedLayers
+
+	return &pb.NodeInfo{
+		NodeId:             node.ID,
+		Address:            node.Address,
+		Port:               int32(node.Port),
+		Resources:          resources,
+		Status:             string(node.Status),
+		LastSeen:           node.LastSeen.Unix(),
+		Version:            node.Version,
+		ProtocolVersion:    node.ProtocolVersion,
+		MinProtocolVersion: node.MinProtocolVersion,
+		Features:           node.Features,
+		Labels:             node.Labels,
+	}
+}
+
+func nodesToProto(nodes []models.Node) []*pb.NodeInfo {
+	infos := make([]*pb.NodeInfo, len(nodes))
+	for i, node := range nodes {
+		infos[i] = nodeToProto(node)
+	}
+	return infos
+}
@@ -0,0 +1,271 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+func newTestNetwork(t *testing.T, nodeID string) *P2PNetwork {
+	t.Helper()
+	network, err := NewP2PNetwork(nodeID, findAvailablePort(t), findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create P2P network: %v", err)
+	}
+	return network
+}
+
+func TestAdmitValidation(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	network.SetClusterID("prod")
+	network.SetJoinToken("secret")
+
+	tests := []struct {
+		name string
+		req  admission
+		code codes.Code
+	}{
+		{"valid", admission{NodeID: "node-1", ClusterID: "prod", AuthToken: "secret"}, codes.OK},
+		{"empty node ID", admission{ClusterID: "prod", AuthToken: "secret"}, codes.InvalidArgument},
+		{"newline in node ID", admission{NodeID: "node\n1", ClusterID: "prod", AuthToken: "secret"}, codes.InvalidArgument},
+		{"own node ID", admission{NodeID: "test-node", ClusterID: "prod", AuthToken: "secret"}, codes.AlreadyExists},
+		{"missing token", admission{NodeID: "node-1", ClusterID: "prod"}, codes.Unauthenticated},
+		{"wrong token", admission{NodeID: "node-1", ClusterID: "prod", AuthToken: "guess"}, codes.Unauthenticated},
+		{"other cluster", admission{NodeID: "node-1", ClusterID: "staging", AuthToken: "secret"}, codes.FailedPrecondition},
+		{"default cluster", admission{NodeID: "node-1", AuthToken: "secret"}, codes.FailedPrecondition},
+		{"newer protocol", admission{NodeID: "node-1", ClusterID: "prod", AuthToken: "secret", ProtocolVersion: ProtocolVersion + 1}, codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := network.admit(context.Background(), tt.req)
+			if got := status.Code(err); got != tt.code {
+				t.Errorf("Expected code %v, got %v (%v)", tt.code, got, err)
+			}
+		})
+	}
+}
+
+func TestAdmitRejectsMemberIDs(t *testing.T) {
+	nodeA, nodeB, _, _ := startGossipPair(t)
+	memberAddr := nodeB.memberlist.LocalNode().Addr.String()
+
+	from := func(addr string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 51234}})
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  admission
+		code codes.Code
+	}{
+		{"member ID from another address", from("10.0.0.9"), admission{NodeID: "node-b", Address: memberAddr}, codes.AlreadyExists},
+		{"member ID without a peer", context.Background(), admission{NodeID: "node-b", Address: memberAddr}, codes.AlreadyExists},
+		{"member ID from its address", from(memberAddr), admission{NodeID: "node-b"}, codes.OK},
+		{"new node ID", from("10.0.0.9"), admission{NodeID: "node-c"}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := nodeA.admit(tt.ctx, tt.req)
+			if got := status.Code(err); got != tt.code {
+				t.Errorf("Expected code %v, got %v (%v)", tt.code, got, err)
+			}
+		})
+	}
+
+	// An impostor can't override node-b's resources in the cluster view
+	impostor := admission{NodeID: "node-b", Resources: &pb.ResourceInfo{CpuCores: 64}}
+	if _, err := nodeA.admit(from("10.0.0.9"), impostor); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("Expected the impostor to be rejected, got %v", err)
+	}
+	for _, node := range nodeA.GetNodes() {
+		if node.ID == "node-b" && node.Resources.CPUCores == 64 {
+			t.Errorf("Expected node-b's resources to be kept, got %+v", node.Resources)
+		}
+	}
+}
+
+func TestRegisteredNodesAppearInClusterView(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	server := &NodeServer{network: network}
+
+	first, err := server.RegisterNode(context.Background(), &pb.RegisterNodeRequest{
+		NodeId:    "node-1",
+		Address:   "10.0.0.1",
+		Port:      8080,
+		Resources: &pb.ResourceInfo{CpuCores: 8, MemoryMb: 16384, MaxLayers: 20},
+	})
+	if err != nil {
+		t.Fatalf("RegisterNode failed: %v", err)
+	}
+	if len(first.ExistingNodes) != 0 {
+		t.Errorf("Expected no existing nodes for first registration, got %d", len(first.ExistingNodes))
+	}
+
+	second, err := server.RegisterNode(context.Background(), &pb.RegisterNodeRequest{NodeId: "node-2", Address: "10.0.0.2", Port: 8080})
+	if err != nil {
+		t.Fatalf("RegisterNode failed: %v", err)
+	}
+	if len(second.ExistingNodes) != 1 || second.ExistingNodes[0].NodeId != "node-1" {
+		t.Fatalf("Expected node-1 in existing nodes, got %+v", second.ExistingNodes)
+	}
+	if second.ExistingNodes[0].Resources.CpuCores != 8 {
+		t.Errorf("Expected reported resources to be kept, got %+v", second.ExistingNodes[0].Resources)
+	}
+
+	peers, err := server.GetPeers(context.Background(), &pb.GetPeersRequest{})
+	if err != nil {
+		t.Fatalf("GetPeers failed: %v", err)
+	}
+	if len(peers.Peers) != 2 {
+		t.Errorf("Expected 2 registered peers, got %d", len(peers.Peers))
+	}
+
+	discovery := NewDiscoveryServer(network)
+	if _, err := discovery.LeaveCluster(context.Background(), &pb.ClusterLeaveRequest{NodeId: "node-1"}); err != nil {
+		t.Fatalf("LeaveCluster failed: %v", err)
+	}
+	if len(network.GetNodes()) != 1 {
+		t.Errorf("Expected node-1 to be forgotten after leaving, got %+v", network.GetNodes())
+	}
+}
+
+func TestRegisteredNodesExpire(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	network.registered["stale"] = models.Node{ID: "stale", LastSeen: time.Now().Add(-2 * registeredNodeTTL)}
+
+	if nodes := network.GetNodes(); len(nodes) != 0 {
+		t.Errorf("Expected stale registration to be hidden, got %+v", nodes)
+	}
+
+	if _, err := network.admit(context.Background(), admission{NodeID: "fresh"}); err != nil {
+		t.Fatalf("admit failed: %v", err)
+	}
+	if _, ok := network.registered["stale"]; ok {
+		t.Error("Expected stale registration to be pruned on admit")
+	}
+}
+
+func TestEvictHidesNodesAtHost(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	network.registered["node-1"] = models.Node{ID: "node-1", Address: "10.0.0.1", LastSeen: time.Now()}
+	network.registered["node-2"] = models.Node{ID: "node-2", Address: "10.0.0.2", LastSeen: time.Now()}
+
+	network.Evict("10.0.0.1")
+
+	nodes := network.GetNodes()
+	if len(nodes) != 1 || nodes[0].ID != "node-2" {
+		t.Errorf("Expected only node-2 after evicting its neighbour, got %+v", nodes)
+	}
+	if !network.evictedHosts()["10.0.0.1"] {
+		t.Error("Expected evicted host to be remembered until it rejoins")
+	}
+
+	network.clearEviction("10.0.0.1")
+	if len(network.evictedHosts()) != 0 {
+		t.Error("Expected eviction to be cleared")
+	}
+}
+
+func TestEvictRemovesMembers(t *testing.T) {
+	nodeA, nodeB, _, _ := startGossipPair(t)
+
+	// Both nodes run on the same host; node-b no longer sees node-a, the
+	// leader of the state store, as a member
+	host := nodeA.memberlist.LocalNode().Addr.String()
+	nodeB.Evict(host)
+	if members := nodeB.Transport(ChannelState).Members(); len(members) != 1 || members[0] != "node-b" {
+		t.Errorf("Expected only node-b to be left, got %v", members)
+	}
+	if members := nodeA.GetMembers(); len(members) != 2 {
+		t.Errorf("Expected eviction to be local to node-b, got %v on node-a", members)
+	}
+
+	nodeB.clearEviction(host)
+	if members := nodeB.GetMembers(); len(members) != 2 {
+		t.Errorf("Expected node-a back after the eviction cleared, got %v", members)
+	}
+}
+
+func TestRegisterAddsNodeToMemberlist(t *testing.T) {
+	nodeA, nodeB := newTestNetwork(t, "node-a"), newTestNetwork(t, "node-b")
+	for _, network := range []*P2PNetwork{nodeA, nodeB} {
+		if err := network.Start(nil); err != nil {
+			t.Fatalf("Failed to start network: %v", err)
+		}
+		t.Cleanup(network.Stop)
+	}
+
+	server := NewDiscoveryServer(nodeA)
+	_, err := server.RegisterWithCluster(context.Background(), &pb.ClusterJoinRequest{
+		NodeId:     "node-b",
+		Address:    "127.0.0.1",
+		Port:       int32(nodeB.bindPort),
+		GossipPort: int32(nodeB.gossipPort),
+	})
+	if err != nil {
+		t.Fatalf("RegisterWithCluster failed: %v", err)
+	}
+
+	eventually(t, func() bool { return len(nodeA.GetMembers()) == 2 && len(nodeB.GetMembers()) == 2 })
+
+	// Re-registering doesn't join a member again
+	gossipAddr := net.JoinHostPort(nodeB.memberlist.LocalNode().Addr.String(), strconv.Itoa(nodeB.gossipPort))
+	if seeds := nodeA.unjoinedSeeds([]string{gossipAddr, "10.0.0.9:7946"}); len(seeds) != 1 || seeds[0] != "10.0.0.9:7946" {
+		t.Errorf("Expected only the unknown seed to be joined, got %v", seeds)
+	}
+}
+
+func TestJoinViaGRPC(t *testing.T) {
+	seed := newTestNetwork(t, "seed")
+	seed.SetJoinToken("secret")
+	seed.SetLocalResources(models.ResourceInfo{CPUCores: 4})
+	lastSeen := time.Now().Add(-time.Minute).Truncate(time.Second)
+	seed.registered["node-x"] = models.Node{ID: "node-x", Address: "10.0.0.9", Status: models.NodeStatusOnline, LastSeen: lastSeen}
+
+	server, err := NewGRPCServer(seed, findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	go server.Start()
+	t.Cleanup(server.Stop)
+	addr := fmt.Sprintf("127.0.0.1:%d", server.listener.Addr().(*net.TCPAddr).Port)
+
+	joiner := newTestNetwork(t, "joiner")
+	joiner.SetLocalResources(models.ResourceInfo{CPUCores: 2, MaxLayers: 6})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := joiner.JoinViaGRPC(ctx, []string{addr}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated without token, got %v", err)
+	}
+
+	joiner.SetJoinToken("secret")
+	if err := joiner.JoinViaGRPC(ctx, []string{"127.0.0.1:1", addr}); err != nil {
+		t.Fatalf("JoinViaGRPC failed: %v", err)
+	}
+
+	registered, ok := seed.registered["joiner"]
+	if !ok {
+		t.Fatal("Expected seed to admit joiner")
+	}
+	if registered.Resources.MaxLayers != 6 || registered.Port != joiner.bindPort {
+		t.Errorf("Expected joiner's port and resources to be recorded, got %+v", registered)
+	}
+	if learned, ok := joiner.registered["node-x"]; !ok {
+		t.Error("Expected joiner to learn existing nodes from the seed")
+	} else if !learned.LastSeen.Equal(lastSeen) {
+		t.Errorf("Expected the seed's last seen time %v to be kept, got %v", lastSeen, learned.LastSeen)
+	}
+}
@@ -0,0 +1,172 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/metrics"
+	pb "distributed-llm/proto"
+)
+
+// MetricsHistory holds periodic snapshots of this node's key metrics
+// (*metrics.History)
+type MetricsHistory interface {
+	Resolution() time.Duration
+	Latest() (metrics.Snapshot, bool)
+	Range(from, to time.Time, step time.Duration) []metrics.Snapshot
+}
+
+// SetMetricsHistory serves the node's recorded metrics through GetMetrics,
+// GetMetricsHistory and StreamMetrics
+func (g *GRPCServer) SetMetricsHistory(history MetricsHistory) {
+	g.nodeServer.history = history
+}
+
+func (s *NodeServer) GetMetrics(ctx context.Context, req *pb.GetMetricsRequest) (*pb.GetMetricsResponse, error) {
+	if s.history == nil && s.network.metricsCollector == nil {
+		return nil, fmt.Errorf("metrics collector not available")
+	}
+
+	update := s.latestMetrics()
+	return &pb.GetMetricsResponse{
+		Metrics:   update.Metrics,
+		Timestamp: update.Timestamp,
+	}, nil
+}
+
+// latestMetrics returns the newest snapshot, or empty metrics before the
+// first one is taken
+func (s *NodeServer) latestMetrics() *pb.MetricsUpdate {
+	if s.history != nil {
+		if snapshot, ok := s.history.Latest(); ok {
+			return snapshotToProto(s.network.nodeID, snapshot)
+		}
+	}
+	return &pb.MetricsUpdate{
+		NodeId:    s.network.nodeID,
+		Metrics:   &pb.NodeMetrics{},
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// GetMetricsHistory returns the snapshots taken between the requested
+// times, one per step
+func (s *NodeServer) GetMetricsHistory(ctx context.Context, req *pb.MetricsHistoryRequest) (*pb.MetricsHistoryResponse, error) {
+	if s.history == nil {
+		return nil, status.Error(codes.FailedPrecondition, "metrics history is not recorded on this node")
+	}
+	if req.StepSeconds < 0 || req.Start < 0 || req.End < 0 || (req.End > 0 && req.Start > req.End) {
+		return nil, status.Error(codes.InvalidArgument, "start must not be after end and step must not be negative")
+	}
+
+	// Steps count from the epoch, so they fall on the same times for
+	// every query
+	from, to := time.Unix(req.Start, 0), time.Now()
+	if req.End > 0 {
+		to = time.Unix(req.End, 0)
+	}
+	step := max(time.Duration(req.StepSeconds)*time.Second, s.history.Resolution())
+
+	resp := &pb.MetricsHistoryResponse{
+		NodeId:      s.network.nodeID,
+		StepSeconds: int32(step / time.Second),
+	}
+	for _, snapshot := range s.history.Range(from, to, step) {
+		resp.Samples = append(resp.Samples, snapshotToProto(s.network.nodeID, snapshot))
+	}
+	return resp, nil
+}
+
+// StreamMetrics sends the node's metrics every interval, preceded by the
+// snapshots taken over the requested backfill
+func (s *NodeServer) StreamMetrics(req *pb.StreamMetricsRequest, stream pb.NodeService_StreamMetricsServer) error {
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if interval < time.Second {
+		interval = time.Second // Minimum 1 second
+	}
+
+	// lastSent keeps a snapshot from being sent twice when the interval is
+	// shorter than the history's resolution
+	var lastSent time.Time
+	if s.history != nil && req.BackfillSeconds > 0 {
+		now := time.Now()
+		backfill := time.Duration(req.BackfillSeconds) * time.Second
+		for _, snapshot := range s.history.Range(now.Add(-backfill), now, interval) {
+			if err := stream.Send(snapshotToProto(s.network.nodeID, snapshot)); err != nil {
+				return err
+			}
+			lastSent = snapshot.Time
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+			var update *pb.MetricsUpdate
+			switch {
+			case s.history != nil:
+				snapshot, ok := s.history.Latest()
+				if !ok || !snapshot.Time.After(lastSent) {
+					continue
+				}
+				lastSent = snapshot.Time
+				update = snapshotToProto(s.network.nodeID, snapshot)
+			case s.network.metricsCollector != nil:
+				update = s.latestMetrics()
+			default:
+				continue
+			}
+
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// snapshotToProto converts a snapshot to the NodeMetrics of an update
+func snapshotToProto(nodeID string, s metrics.Snapshot) *pb.MetricsUpdate {
+	const mb = 1024 * 1024
+	return &pb.MetricsUpdate{
+		NodeId:    nodeID,
+		Timestamp: s.Time.Unix(),
+		Metrics: &pb.NodeMetrics{
+			ResourceMetrics: &pb.ResourceMetrics{
+				CpuUsagePercent: float32(s.CPUUsagePercent),
+				MemoryUsedMb:    int64(s.MemoryUsedBytes / mb),
+				MemoryTotalMb:   int64(s.MemoryTotalBytes / mb),
+				LayersAllocated: int32(s.LayersAllocated),
+				LayersTotal:     int32(s.LayersCapacity),
+			},
+			NetworkMetrics: &pb.NetworkMetrics{
+				ActiveConnections: int32(s.Connections),
+				LatencyMs:         float32(s.PeerRTT.Seconds() * 1000),
+				MessagesSent:      int32(s.MessagesSent),
+				MessagesReceived:  int32(s.MessagesReceived),
+			},
+			InferenceMetrics: &pb.InferenceMetrics{
+				RequestsTotal:   int32(s.RequestsTotal),
+				RequestsActive:  int32(s.RequestsActive),
+				AvgLatencyMs:    float32(s.AvgLatency.Seconds() * 1000),
+				TokensGenerated: int32(s.TokensGenerated),
+				TokensPerSecond: float32(s.TokensPerSecond),
+				ErrorsTotal:     int32(s.ErrorsTotal),
+			},
+			SystemMetrics: &pb.SystemMetrics{
+				UptimeSeconds:   int64(s.Uptime.Seconds()),
+				Goroutines:      int32(s.Goroutines),
+				MemoryAllocated: int64(s.HeapAllocBytes),
+				GcCycles:        int64(s.GCCycles),
+			},
+		},
+	}
+}
@@ -0,0 +1,133 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/metrics"
+	pb "distributed-llm/proto"
+)
+
+// newTestHistory records a snapshot every 5s over the minute before now,
+// counting requests from 0 to 11
+func newTestHistory(now time.Time) *metrics.History {
+	history := metrics.NewHistory(time.Hour, 5*time.Second)
+	for i := 0; i < 12; i++ {
+		history.Add(metrics.Snapshot{
+			Time:          now.Add(time.Duration(i-12) * 5 * time.Second),
+			RequestsTotal: uint64(i),
+			Connections:   2,
+		})
+	}
+	return history
+}
+
+// requestCounts returns the requests total of each update
+func requestCounts(updates []*pb.MetricsUpdate) []int32 {
+	counts := make([]int32, len(updates))
+	for i, update := range updates {
+		counts[i] = update.Metrics.InferenceMetrics.RequestsTotal
+	}
+	return counts
+}
+
+func TestGetMetricsHistory(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	now := time.Unix(1_800_000_000, 0)
+	nodeServer := &NodeServer{network: network, history: newTestHistory(now)}
+
+	tests := []struct {
+		name     string
+		req      *pb.MetricsHistoryRequest
+		want     []int32
+		wantStep int32
+		wantCode codes.Code
+	}{
+		{"whole history", &pb.MetricsHistoryRequest{End: now.Unix()}, []int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, 5, codes.OK},
+		{"range", &pb.MetricsHistoryRequest{Start: now.Unix() - 15, End: now.Unix()}, []int32{9, 10, 11}, 5, codes.OK},
+		{"newest per step", &pb.MetricsHistoryRequest{Start: now.Unix() - 60, End: now.Unix(), StepSeconds: 20}, []int32{3, 7, 11}, 20, codes.OK},
+		{"step below the resolution", &pb.MetricsHistoryRequest{Start: now.Unix() - 10, End: now.Unix(), StepSeconds: 1}, []int32{10, 11}, 5, codes.OK},
+		{"start after end", &pb.MetricsHistoryRequest{Start: now.Unix(), End: now.Unix() - 60}, nil, 0, codes.InvalidArgument},
+		{"negative step", &pb.MetricsHistoryRequest{StepSeconds: -5}, nil, 0, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := nodeServer.GetMetricsHistory(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Expected %v, got %v", tt.wantCode, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := requestCounts(resp.Samples); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected samples %v, got %v", tt.want, got)
+			}
+			if resp.StepSeconds != tt.wantStep || resp.NodeId != "node-a" {
+				t.Errorf("Expected node-a every %ds, got %s every %ds", tt.wantStep, resp.NodeId, resp.StepSeconds)
+			}
+		})
+	}
+
+	// GetMetrics serves the newest snapshot
+	latest, err := nodeServer.GetMetrics(context.Background(), &pb.GetMetricsRequest{})
+	if err != nil || latest.Metrics.InferenceMetrics.RequestsTotal != 11 || latest.Timestamp != now.Unix()-5 {
+		t.Errorf("Expected the newest snapshot, got %+v (%v)", latest, err)
+	}
+}
+
+func TestGetMetricsHistoryWithoutHistory(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	nodeServer := &NodeServer{network: network}
+
+	if _, err := nodeServer.GetMetricsHistory(context.Background(), &pb.MetricsHistoryRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestStreamMetricsBackfill(t *testing.T) {
+	_, server, port := newTracedNode(t, "node-a", nil)
+	history := newTestHistory(time.Now())
+	server.SetMetricsHistory(history)
+
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pb.NewNodeServiceClient(conn).StreamMetrics(ctx, &pb.StreamMetricsRequest{IntervalSeconds: 1, BackfillSeconds: 32})
+	if err != nil {
+		t.Fatalf("StreamMetrics failed: %v", err)
+	}
+
+	// The snapshots of the last 32s arrive at once, then new ones as they
+	// are taken
+	var updates []*pb.MetricsUpdate
+	for len(updates) < 7 {
+		update, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed after %v: %v", requestCounts(updates), err)
+		}
+		updates = append(updates, update)
+		if len(updates) == 6 {
+			history.Add(metrics.Snapshot{Time: time.Now(), RequestsTotal: 99})
+		}
+	}
+	if got := fmt.Sprint(requestCounts(updates)); got != "[6 7 8 9 10 11 99]" {
+		t.Errorf("Expected the backfill then the new snapshot, got %s", got)
+	}
+	if updates[0].NodeId != "node-a" || updates[0].Metrics.NetworkMetrics.ActiveConnections != 2 {
+		t.Errorf("Unexpected update %+v", updates[0])
+	}
+}
@@ -4,11 +4,20 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/memberlist"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 
+	"distributed-llm/internal/events"
+	"distributed-llm/internal/tensor"
 	"distributed-llm/pkg/models"
 	pb "distributed-llm/proto"
 )
@@ -19,6 +28,7 @@ type MetricsCollector interface {
 	RecordNetworkLatency(targetNode, operation string, duration time.Duration)
 	UpdateNodeStatus(status models.NodeStatus)
 	UpdateNetworkConnections(count int)
+	AddActiveRequests(modelID string, delta int)
 	RecordInferenceRequest(modelID, status string, duration time.Duration, tokensGenerated int)
 }
 
@@ -29,31 +39,91 @@ type P2PNetwork struct {
 	gossipPort       int
 	logger           *slog.Logger
 	eventDelegate    *EventDelegate
+	delegate         *gossipDelegate
+	latency          *LatencyTracker
 	metricsCollector MetricsCollector
+	startedAt        time.Time
+
+	mu             sync.RWMutex
+	clusterID      string
+	joinToken      string
+	version        string
+	labels         map[string]string
+	localResources *models.ResourceInfo
+	// activation encoding offered to other pipeline stages
+	activationCompression string
+	activationDType       tensor.DType
+	tracerProvider        trace.TracerProvider
+	// seeded is set when Start was given seed nodes, joined once one answered
+	seeded bool
+	joined bool
+	// registered holds nodes admitted over gRPC, which may not be reachable by gossip
+	registered map[string]models.Node
+	// evicted holds hosts reported gone by an orchestrator before gossip
+	// noticed. It has its own lock because memberlist calls the event
+	// delegate while holding its node lock.
+	evictMu sync.Mutex
+	evicted map[string]bool
+	// events receives membership changes; suspects holds the members that
+	// failed their last probe. Locked separately for the same reason.
+	eventMu  sync.Mutex
+	events   EventPublisher
+	suspects map[string]bool
+
+	// conns caches gRPC connections to peers, by address
+	connMu sync.Mutex
+	conns  map[string]*grpc.ClientConn
 }
 
 type EventDelegate struct {
 	network *P2PNetwork
 	logger  *slog.Logger
+	// members counts the live members, this node included. memberlist calls
+	// the delegate holding its node lock, so it can't be asked for them here.
+	members atomic.Int64
 }
 
 func (e *EventDelegate) NotifyJoin(node *memberlist.Node) {
 	e.logger.Info("Node joined", "name", node.Name, "addr", node.Addr)
+	e.network.checkCompatibility(node)
+	e.network.clearEviction(node.Addr.String())
+	e.network.publish(models.Event{
+		Type:       models.EventNodeJoined,
+		NodeID:     node.Name,
+		Attributes: map[string]string{"address": node.Addr.String()},
+	})
 
 	// Record metrics if collector is available
 	if e.network.metricsCollector != nil {
 		e.network.metricsCollector.RecordNetworkMessage("incoming", "join")
-		e.network.metricsCollector.UpdateNetworkConnections(len(e.network.GetMembers()))
 	}
+	e.updateConnections(1)
 }
 
 func (e *EventDelegate) NotifyLeave(node *memberlist.Node) {
 	e.logger.Info("Node left", "name", node.Name, "addr", node.Addr)
+	e.network.clearEviction(node.Addr.String())
+	e.network.latency.Forget(node.Name)
+	e.network.forgetSuspect(node.Name)
+	e.network.publish(models.Event{
+		Type:       models.EventNodeLeft,
+		NodeID:     node.Name,
+		Attributes: map[string]string{"address": node.Addr.String()},
+	})
 
 	// Record metrics if collector is available
 	if e.network.metricsCollector != nil {
 		e.network.metricsCollector.RecordNetworkMessage("incoming", "leave")
-		e.network.metricsCollector.UpdateNetworkConnections(len(e.network.GetMembers()))
+	}
+	e.updateConnections(-1)
+}
+
+// updateConnections counts a member joining or leaving and reports the
+// new count
+func (e *EventDelegate) updateConnections(delta int64) {
+	count := e.members.Add(delta)
+	if e.network.metricsCollector != nil {
+		e.network.metricsCollector.UpdateNetworkConnections(int(count))
 	}
 }
 
@@ -93,12 +163,18 @@ func NewP2PNetwork(nodeID string, bindPort, gossipPort int) (*P2PNetwork, error)
 		bindPort:   bindPort,
 		gossipPort: gossipPort,
 		logger:     logger,
+		startedAt:  time.Now(),
+		registered: make(map[string]models.Node),
+		suspects:   make(map[string]bool),
 	}
 
 	network.eventDelegate = &EventDelegate{
 		network: network,
 		logger:  logger,
 	}
+	network.delegate = newGossipDelegate(network)
+	network.latency = NewLatencyTracker(nodeID)
+	network.RegisterChannel(ChannelLatency, network.latency)
 
 	return network, nil
 }
@@ -106,6 +182,9 @@ func NewP2PNetwork(nodeID string, bindPort, gossipPort int) (*P2PNetwork, error)
 // SetMetricsCollector sets the metrics collector for the network
 func (n *P2PNetwork) SetMetricsCollector(collector MetricsCollector) {
 	n.metricsCollector = collector
+	if metrics, ok := collector.(LatencyMetrics); ok {
+		n.latency.SetMetrics(metrics)
+	}
 }
 
 func (n *P2PNetwork) Start(seedNodes []string) error {
@@ -117,6 +196,8 @@ func (n *P2PNetwork) Start(seedNodes []string) error {
 	config.BindPort = n.gossipPort
 	config.AdvertisePort = n.gossipPort
 	config.Events = n.eventDelegate
+	config.Delegate = n.delegate
+	config.Ping = n.latency
 
 	// Create memberlist
 	list, err := memberlist.Create(config)
@@ -132,6 +213,10 @@ func (n *P2PNetwork) Start(seedNodes []string) error {
 	// Join existing cluster if seed nodes provided
 	if len(seedNodes) > 0 {
 		_, err := list.Join(seedNodes)
+		n.mu.Lock()
+		n.seeded = true
+		n.joined = err == nil
+		n.mu.Unlock()
 		if err != nil {
 			n.logger.Warn("Failed to join cluster", "error", err)
 			// Record failed join metric
@@ -158,20 +243,57 @@ func (n *P2PNetwork) Start(seedNodes []string) error {
 	return nil
 }
 
+// Join gossips with more nodes after Start, e.g. peers found on the LAN
+func (n *P2PNetwork) Join(seeds []string) error {
+	if n.memberlist == nil {
+		return fmt.Errorf("network not started")
+	}
+	if _, err := n.memberlist.Join(seeds); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	n.joined = true
+	n.mu.Unlock()
+	for _, seed := range seeds {
+		if host, _, err := net.SplitHostPort(seed); err == nil {
+			n.clearEviction(host)
+		}
+	}
+	return nil
+}
+
+// GetNodes returns the gossip members plus any nodes admitted over gRPC that
+// memberlist cannot reach, with the resources they reported at registration
 func (n *P2PNetwork) GetNodes() []models.Node {
 	nodes := make([]models.Node, 0)
+	seen := make(map[string]bool)
+	evicted := n.evictedHosts()
 
-	if n.memberlist == nil {
-		return nodes
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.memberlist != nil {
+		for _, member := range n.memberlist.Members() {
+			if member.Name != n.nodeID && evicted[member.Addr.String()] {
+				continue
+			}
+			node := memberNode(member)
+			node.LastSeen = time.Now()
+			if registered, ok := n.registered[member.Name]; ok {
+				node.Resources = registered.Resources
+			}
+			if member.Name == n.nodeID && n.localResources != nil {
+				node.Resources = *n.localResources
+			}
+			nodes = append(nodes, node)
+			seen[member.Name] = true
+		}
 	}
 
-	for _, member := range n.memberlist.Members() {
-		node := models.Node{
-			ID:       member.Name,
-			Address:  member.Addr.String(),
-			Port:     int(member.Port),
-			Status:   models.NodeStatusOnline,
-			LastSeen: time.Now(),
+	for id, node := range n.registered {
+		if seen[id] || time.Since(node.LastSeen) > registeredNodeTTL {
+			continue
 		}
 		nodes = append(nodes, node)
 	}
@@ -179,6 +301,9 @@ func (n *P2PNetwork) GetNodes() []models.Node {
 	return nodes
 }
 
+// GetMembers returns the IDs of the gossip members, leaving out those at
+// evicted hosts, so that a node reported gone stops being a leader
+// candidate or routing target before memberlist times it out
 func (n *P2PNetwork) GetMembers() []string {
 	members := make([]string, 0)
 
@@ -186,35 +311,90 @@ func (n *P2PNetwork) GetMembers() []string {
 		return members
 	}
 
+	evicted := n.evictedHosts()
 	for _, member := range n.memberlist.Members() {
+		if member.Name != n.nodeID && evicted[member.Addr.String()] {
+			continue
+		}
 		members = append(members, member.Name)
 	}
 
 	return members
 }
 
+// Started returns true once the gossip layer is running
+func (n *P2PNetwork) Started() bool {
+	return n.memberlist != nil
+}
+
+// Uptime returns how long this node has been running
+func (n *P2PNetwork) Uptime() time.Duration {
+	return time.Since(n.startedAt)
+}
+
 func (n *P2PNetwork) Stop() {
 	if n.memberlist != nil {
 		n.memberlist.Shutdown()
 	}
+	n.closePeerConns()
 }
 
 // NodeServer implements the gRPC NodeService
 type NodeServer struct {
 	pb.UnimplementedNodeServiceServer
-	network *P2PNetwork
+	network   *P2PNetwork
+	readiness ReadinessSource
+	router    InferenceRouter
+	// slots limits the requests running at once when set
+	slots chan struct{}
+	// ledger records the usage of requests received by this node when set
+	ledger UsageLedger
+	// history serves the node's recorded metrics when set
+	history MetricsHistory
+	// alerts serves the cluster's active alerts when set
+	alerts AlertSource
+	// events streams the node's events and records failed requests when set
+	events *events.Bus
 }
 
 func (s *NodeServer) RegisterNode(ctx context.Context, req *pb.RegisterNodeRequest) (*pb.RegisterNodeResponse, error) {
 	s.network.logger.Info("Node registration request", "nodeID", req.GetNodeId())
 
+	existingNodes, err := s.network.admit(ctx, admission{
+		NodeID:          req.NodeId,
+		Address:         req.Address,
+		Port:            req.Port,
+		GossipPort:      req.GossipPort,
+		Resources:       req.Resources,
+		SeedNodes:       req.SeedNodes,
+		Version:         req.Version,
+		ProtocolVersion: req.ProtocolVersion,
+		MinProtocol:     req.MinProtocolVersion,
+		ClusterID:       req.ClusterId,
+		AuthToken:       req.AuthToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &pb.RegisterNodeResponse{
-		Success: true,
-		Message: "Node registered successfully",
+		Success:       true,
+		Message:       "Node registered successfully",
+		ExistingNodes: nodesToProto(existingNodes),
+		ClusterId:     s.network.ClusterID(),
 	}, nil
 }
 
 func (s *NodeServer) GetResources(ctx context.Context, req *pb.GetResourcesRequest) (*pb.GetResourcesResponse, error) {
+	if resources, ok := s.network.LocalResources(); ok {
+		pbResources := resources.ToProto()
+		pbResources.UsedLayers = resources.UsedLayers
+		return &pb.GetResourcesResponse{
+			Resources:       pbResources,
+			AvailableLayers: resources.MaxLayers - resources.UsedLayers,
+		}, nil
+	}
+
 	return &pb.GetResourcesResponse{
 		Resources: &pb.ResourceInfo{
 			CpuCores:  4,
@@ -225,36 +405,39 @@ func (s *NodeServer) GetResources(ctx context.Context, req *pb.GetResourcesReque
 	}, nil
 }
 
+// ProcessInference serves a request entering the cluster through this node,
+// routing it to a replica of its model unless it names its pipeline in
+// LayerAssignments, or this node's stage of a request another member
+// forwarded here. Requests entering through this node are recorded in its
+// usage ledger, and those failing are published as events.
 func (s *NodeServer) ProcessInference(ctx context.Context, req *pb.InferenceRequest) (*pb.InferenceResponse, error) {
-	startTime := time.Now()
-
-	// Record inference metrics
-	if s.network.metricsCollector != nil {
-		defer func() {
-			s.network.metricsCollector.RecordNetworkLatency("local", "inference_request", time.Since(startTime))
-		}()
+	if forwardedStage(ctx) {
+		return s.processInference(ctx, req)
 	}
 
-	// Simple mock response - in real implementation this would process the inference
-	response := &pb.InferenceResponse{
-		Success:       true,
-		GeneratedText: "Hello from node " + s.network.nodeID,
+	start := time.Now()
+	ctx, span := s.network.tracer().Start(ctx, spanGateway, trace.WithAttributes(
+		attribute.String("inference.model_id", req.ModelId),
+		attribute.String("request.id", RequestIDFromContext(ctx)),
+		attribute.Int("inference.prompt_bytes", len(req.Prompt)),
+		attribute.Int("inference.max_tokens", int(req.MaxTokens)),
+	))
+	var resp *pb.InferenceResponse
+	var err error
+	if s.router != nil && req.ModelId != "" && len(req.LayerAssignments) == 0 {
+		resp, err = s.routeInference(ctx, req)
+	} else {
+		resp, err = s.processInference(ctx, req)
 	}
-
-	// Record successful inference
-	if s.network.metricsCollector != nil {
-		s.network.metricsCollector.RecordInferenceRequest("default_model", "success", time.Since(startTime), 10)
+	if err == nil && !resp.Success {
+		span.SetStatus(otelcodes.Error, resp.ErrorMessage)
 	}
-
-	return response, nil
-}
-
-func (s *NodeServer) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
-	return &pb.HealthCheckResponse{
-		Healthy:       true,
-		Status:        "running",
-		UptimeSeconds: 3600,
-	}, nil
+	endSpan(span, err)
+	s.recordUsage(ctx, req, start, resp, err)
+	if err != nil || !resp.Success {
+		s.publishFailure(ctx, req, resp, err)
+	}
+	return resp, err
 }
 
 func (s *NodeServer) GetPeers(ctx context.Context, req *pb.GetPeersRequest) (*pb.GetPeersResponse, error) {
@@ -262,109 +445,10 @@ func (s *NodeServer) GetPeers(ctx context.Context, req *pb.GetPeersRequest) (*pb
 	peers := make([]*pb.NodeInfo, len(nodes))
 
 	for i, node := range nodes {
-		gpus := make([]*pb.GPUInfo, len(node.Resources.GPUs))
-		for j, gpu := range node.Resources.GPUs {
-			gpus[j] = &pb.GPUInfo{
-				Name:     gpu.Name,
-				MemoryMb: gpu.MemoryMB,
-				Uuid:     gpu.UUID,
-			}
-		}
-
-		peers[i] = &pb.NodeInfo{
-			NodeId:  node.ID,
-			Address: node.Address,
-			Port:    int32(node.Port),
-			Resources: &pb.ResourceInfo{
-				CpuCores:   node.Resources.CPUCores,
-				MemoryMb:   node.Resources.MemoryMB,
-				Gpus:       gpus,
-				MaxLayers:  node.Resources.MaxLayers,
-				UsedLayers: node.Resources.UsedLayers,
-			},
-			Status:   string(node.Status),
-			LastSeen: node.LastSeen.Unix(),
-		}
+		peers[i] = nodeToProto(node)
 	}
 
 	return &pb.GetPeersResponse{
 		Peers: peers,
 	}, nil
 }
-
-func (s *NodeServer) GetMetrics(ctx context.Context, req *pb.GetMetricsRequest) (*pb.GetMetricsResponse, error) {
-	if s.network.metricsCollector == nil {
-		return nil, fmt.Errorf("metrics collector not available")
-	}
-
-	// Get current metrics from the collector
-	// This is a simplified implementation - in reality we'd get actual metrics
-	return &pb.GetMetricsResponse{
-		Metrics: &pb.NodeMetrics{
-			ResourceMetrics: &pb.ResourceMetrics{
-				CpuUsagePercent: 50.0,
-				MemoryUsedMb:    4096,
-				MemoryTotalMb:   8192,
-				LayersAllocated: 5,
-				LayersTotal:     10,
-			},
-			NetworkMetrics: &pb.NetworkMetrics{
-				BytesSent:         1024000,
-				BytesReceived:     2048000,
-				ActiveConnections: 3,
-				LatencyMs:         15.5,
-				MessagesSent:      100,
-				MessagesReceived:  150,
-			},
-			InferenceMetrics: &pb.InferenceMetrics{
-				RequestsTotal:   500,
-				RequestsActive:  2,
-				AvgLatencyMs:    125.0,
-				TokensGenerated: 50000,
-				TokensPerSecond: 25.0,
-				ErrorsTotal:     5,
-			},
-			SystemMetrics: &pb.SystemMetrics{
-				UptimeSeconds:   3600,
-				Goroutines:      50,
-				MemoryAllocated: 104857600, // 100MB
-				GcCycles:        25,
-				LoadAverage:     1.5,
-			},
-		},
-		Timestamp: time.Now().Unix(),
-	}, nil
-}
-
-func (s *NodeServer) StreamMetrics(req *pb.StreamMetricsRequest, stream pb.NodeService_StreamMetricsServer) error {
-	interval := time.Duration(req.IntervalSeconds) * time.Second
-	if interval < time.Second {
-		interval = time.Second // Minimum 1 second
-	}
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-stream.Context().Done():
-			return stream.Context().Err()
-		case <-ticker.C:
-			// Get current metrics
-			metricsResp, err := s.GetMetrics(stream.Context(), &pb.GetMetricsRequest{NodeId: req.NodeId})
-			if err != nil {
-				continue
-			}
-
-			update := &pb.MetricsUpdate{
-				NodeId:    s.network.nodeID,
-				Metrics:   metricsResp.Metrics,
-				Timestamp: time.Now().Unix(),
-			}
-
-			if err := stream.Send(update); err != nil {
-				return err
-			}
-		}
-	}
-}
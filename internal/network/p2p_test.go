@@ -3,6 +3,7 @@ package network
 import (
 	"fmt"
 	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -138,6 +139,55 @@ func TestP2PNetworkMultipleNodes(t *testing.T) {
 	}
 }
 
+// connectionCollector records the last connection count reported
+type connectionCollector struct {
+	MockMetricsCollector
+	connections atomic.Int64
+}
+
+func (c *connectionCollector) UpdateNetworkConnections(count int) {
+	c.connections.Store(int64(count))
+}
+
+func TestP2PNetworkJoinWithMetricsCollector(t *testing.T) {
+	networks := make([]*P2PNetwork, 2)
+	collectors := make([]*connectionCollector, 2)
+	for i := range networks {
+		network, err := NewP2PNetwork(fmt.Sprintf("node-%d", i), findAvailablePort(t), findAvailablePort(t))
+		if err != nil {
+			t.Fatalf("Failed to create network %d: %v", i, err)
+		}
+		collectors[i] = &connectionCollector{}
+		network.SetMetricsCollector(collectors[i])
+		networks[i] = network
+	}
+
+	// Joining used to deadlock when the collector asked memberlist for its
+	// members from inside the join callback
+	started := make(chan error, 1)
+	go func() {
+		if err := networks[0].Start(nil); err != nil {
+			started <- err
+			return
+		}
+		started <- networks[1].Start([]string{fmt.Sprintf("127.0.0.1:%d", networks[0].gossipPort)})
+	}()
+	select {
+	case err := <-started:
+		if err != nil {
+			t.Fatalf("Failed to start networks: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Starting networks with metrics collectors hung")
+	}
+	defer networks[0].Stop()
+	defer networks[1].Stop()
+
+	eventually(t, func() bool {
+		return collectors[0].connections.Load() == 2 && collectors[1].connections.Load() == 2
+	})
+}
+
 func TestP2PNetworkStop(t *testing.T) {
 	grpcPort := findAvailablePort(t)
 	gossipPort := findAvailablePort(t)
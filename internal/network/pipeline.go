@@ -0,0 +1,199 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "distributed-llm/proto"
+)
+
+// mockHiddenSize is the width of the placeholder hidden states passed
+// between stages until a real backend produces them
+const mockHiddenSize = 64
+
+// processInference runs a request through its pipeline. The node receiving
+// it drives the pipeline from its own stage on: it runs its layers, ships
+// the hidden states to each later stage in turn and samples the output.
+// Requests without LayerAssignments are served by this node alone. The
+// response reports the compute time and traffic of each node involved.
+func (s *NodeServer) processInference(ctx context.Context, req *pb.InferenceRequest) (*pb.InferenceResponse, error) {
+	startTime := time.Now()
+
+	// Record inference metrics
+	if s.network.metricsCollector != nil {
+		defer func() {
+			s.network.metricsCollector.RecordNetworkLatency("local", "inference_request", time.Since(startTime))
+		}()
+	}
+
+	release, err := s.waitForSlot(ctx)
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	defer release()
+
+	stages := req.LayerAssignments
+	if len(stages) == 0 {
+		stages = []string{s.network.nodeID}
+	}
+	first := max(slices.Index(stages, s.network.nodeID), 0)
+	hidden := mockHiddenStates(req.Prompt)
+	var used stageUsage
+	self := used.node(s.network.nodeID)
+	for stage := first; stage < len(stages); stage++ {
+		if stages[stage] == s.network.nodeID {
+			computeStart := time.Now()
+			s.forward(ctx, req.ModelId, stage, hidden)
+			self.ComputeMicros += time.Since(computeStart).Microseconds()
+			continue
+		}
+		if hidden, err = s.transferActivations(ctx, req.ModelId, stages[stage], stage, hidden, &used); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "stage %d on %s: %v", stage, stages[stage], err)
+		}
+	}
+
+	computeStart := time.Now()
+	response := s.sample(ctx, req, hidden)
+	self.ComputeMicros += time.Since(computeStart).Microseconds()
+	response.PromptTokens = int32(len(strings.Fields(req.Prompt)))
+	response.TokensGenerated = int32(len(strings.Fields(response.GeneratedText)))
+	response.Stages = used
+
+	return response, nil
+}
+
+// SetMaxConcurrentInference limits the requests this node runs at once;
+// others wait for a slot. 0 means no limit. Call it before serving.
+func (g *GRPCServer) SetMaxConcurrentInference(limit int) {
+	g.nodeServer.slots = nil
+	if limit > 0 {
+		g.nodeServer.slots = make(chan struct{}, limit)
+	}
+}
+
+// waitForSlot blocks until the request may run and returns the function
+// that frees its slot
+func (s *NodeServer) waitForSlot(ctx context.Context) (func(), error) {
+	_, span := s.network.tracer().Start(ctx, spanQueueWait,
+		trace.WithAttributes(attribute.Int("inference.slots", cap(s.slots))))
+	if s.slots == nil {
+		span.End()
+		return func() {}, nil
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		span.End()
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		endSpan(span, ctx.Err())
+		return nil, ctx.Err()
+	}
+}
+
+// mockHiddenStates stands in for the embeddings of the prompt, one row per word
+func mockHiddenStates(prompt string) []float32 {
+	tokens := max(len(strings.Fields(prompt)), 1)
+	hidden := make([]float32, tokens*mockHiddenSize)
+	for i := range hidden {
+		hidden[i] = float32(i%mockHiddenSize) / mockHiddenSize
+	}
+	return hidden
+}
+
+// forward runs this node's layers of the model over the hidden states
+func (s *NodeServer) forward(ctx context.Context, modelID string, stage int, hidden []float32) {
+	_, span := s.network.tracer().Start(ctx, spanForward, trace.WithAttributes(
+		attribute.String("inference.model_id", modelID),
+		attribute.Int("inference.stage", stage),
+		attribute.String("inference.node_id", s.network.nodeID),
+		attribute.Int("inference.tokens", len(hidden)/mockHiddenSize),
+	))
+	defer span.End()
+
+	// Mock stage - a real backend would run the node's layers here; until
+	// then the hidden states pass through unchanged
+}
+
+// transferActivations ships the hidden states to the stage on nodeID and
+// returns its output, adding the traffic and the stage's compute time to
+// used. Stages on nodes without activation streams are skipped, as they
+// were before pipelines exchanged activations.
+func (s *NodeServer) transferActivations(ctx context.Context, modelID, nodeID string, stage int, hidden []float32, used *stageUsage) (output []float32, err error) {
+	ctx, span := s.network.tracer().Start(ctx, spanActivationTransfer, trace.WithAttributes(
+		attribute.String("inference.model_id", modelID),
+		attribute.Int("inference.stage", stage),
+		attribute.String("inference.node_id", nodeID),
+	))
+	defer func() { endSpan(span, err) }()
+
+	stream, err := s.network.OpenActivationStream(ctx, nodeID, RequestIDFromContext(ctx), modelID)
+	if errors.Is(err, ErrActivationStreamUnsupported) {
+		span.SetAttributes(attribute.Bool("inference.skipped", true))
+		return hidden, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+	defer func() {
+		self, remote := used.node(s.network.nodeID), used.node(nodeID)
+		self.BytesSent += stream.BytesSent
+		self.BytesReceived += stream.BytesReceived
+		remote.BytesReceived += stream.BytesSent
+		remote.BytesSent += stream.BytesReceived
+		remote.ComputeMicros += stream.ComputeTime.Microseconds()
+	}()
+	span.SetAttributes(
+		attribute.String("inference.compression", stream.Compression),
+		attribute.String("inference.dtype", stream.dtype.String()),
+	)
+
+	if err := stream.Send(int32(stage), []int64{int64(len(hidden) / mockHiddenSize), mockHiddenSize}, hidden); err != nil {
+		return nil, err
+	}
+	out, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if output, err = out.Float32s(); err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("inference.bytes", len(out.Data)))
+
+	// Wait for the stage to finish the stream
+	stream.CloseSend()
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		if err == nil {
+			err = fmt.Errorf("unexpected frame after the output")
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// sample turns the last stage's output into the generated text
+func (s *NodeServer) sample(ctx context.Context, req *pb.InferenceRequest, hidden []float32) *pb.InferenceResponse {
+	_, span := s.network.tracer().Start(ctx, spanSample, trace.WithAttributes(
+		attribute.String("inference.model_id", req.ModelId),
+		attribute.Int("inference.max_tokens", int(req.MaxTokens)),
+	))
+	defer span.End()
+
+	// Simple mock response - in real implementation this would sample
+	// tokens from the logits
+	return &pb.InferenceResponse{
+		Success:       true,
+		GeneratedText: "Hello from node " + s.network.nodeID,
+	}
+}
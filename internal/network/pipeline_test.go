@@ -0,0 +1,87 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+func TestMaxConcurrentInference(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	network, server, _ := newTracedNode(t, "node-a", provider)
+	server.SetMaxConcurrentInference(1)
+	nodeServer := server.nodeServer
+
+	// Hold the only slot
+	release, err := nodeServer.waitForSlot(context.Background())
+	if err != nil {
+		t.Fatalf("waitForSlot failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = nodeServer.ProcessInference(ctx, &pb.InferenceRequest{ModelId: "llama-7b"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Expected DeadlineExceeded while waiting for a slot, got %v", err)
+	}
+
+	var waited time.Duration
+	for _, span := range exporter.GetSpans() {
+		if span.Name == spanQueueWait && span.Status.Code != 0 {
+			waited = span.EndTime.Sub(span.StartTime)
+		}
+	}
+	if waited < 40*time.Millisecond {
+		t.Errorf("Expected a failed queue wait span of about 50ms, got %v", waited)
+	}
+
+	// Freeing the slot lets requests through again
+	release()
+	resp, err := nodeServer.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "llama-7b"})
+	if err != nil || resp.GeneratedText != "Hello from node "+network.nodeID {
+		t.Fatalf("Expected the request to run once the slot was freed, got %+v (%v)", resp, err)
+	}
+}
+
+func TestPipelineSkipsStagesWithoutActivationStreams(t *testing.T) {
+	_, _, portB := newTracedNode(t, "node-b", nil)
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	registerNode(network, models.Node{ID: "node-b", Address: "127.0.0.1", Port: portB})
+	registerNode(network, models.Node{ID: "node-c", Address: "127.0.0.1", Port: portB, Features: SupportedFeatures()})
+	registerNode(network, models.Node{ID: "node-d", Address: "127.0.0.1", Port: findAvailablePort(t), Features: SupportedFeatures()})
+	nodeServer := &NodeServer{network: network}
+
+	tests := []struct {
+		name    string
+		stages  []string
+		wantErr codes.Code
+	}{
+		{"skips an old stage", []string{"node-a", "node-b"}, codes.OK},
+		{"streams to a new stage", []string{"node-a", "node-c"}, codes.OK},
+		{"fails on an unreachable stage", []string{"node-a", "node-d"}, codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := nodeServer.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "llama-7b", LayerAssignments: tt.stages})
+			if status.Code(err) != tt.wantErr {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && resp.GeneratedText != "Hello from node node-a" {
+				t.Errorf("Expected node-a to drive the pipeline, got %q", resp.GeneratedText)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"distributed-llm/internal/quota"
+	pb "distributed-llm/proto"
+)
+
+// RetryAfterMetadataKey is the response header telling a caller rejected by
+// its quota how many seconds to wait, like HTTP's Retry-After. The status
+// also carries the wait as a RetryInfo detail.
+const RetryAfterMetadataKey = "retry-after"
+
+// QuotaLimiter admits requests against their tenant's quota (*quota.Limiter)
+type QuotaLimiter interface {
+	Acquire(tenant, modelID string) (func(tokens int), error)
+}
+
+// SetQuotaLimiter limits the inference requests entering the cluster through
+// this node by their tenant's quota. Call it before serving.
+func (g *GRPCServer) SetQuotaLimiter(limiter QuotaLimiter) {
+	g.interceptors.quotas = limiter
+}
+
+// unaryQuota admits inference requests entering the cluster against their
+// tenant's quota and charges them the tokens they used. Requests another
+// member forwarded to a stage of their pipeline were admitted by that member.
+func (i *serverInterceptors) unaryQuota(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	r, ok := req.(*pb.InferenceRequest)
+	if i.quotas == nil || !ok || info.FullMethod != pb.NodeService_ProcessInference_FullMethodName || forwardedStage(ctx) {
+		return handler(ctx, req)
+	}
+
+	release, err := i.quotas.Acquire(TenantFromContext(ctx), r.ModelId)
+	if err != nil {
+		return nil, quotaStatus(ctx, err)
+	}
+	resp, err := handler(ctx, req)
+	tokens := 0
+	if inference, ok := resp.(*pb.InferenceResponse); ok && inference != nil {
+		tokens = int(inference.PromptTokens + inference.TokensGenerated)
+	}
+	release(tokens)
+	return resp, err
+}
+
+// quotaStatus turns a rejection into PERMISSION_DENIED for a model the
+// tenant may not use, or RESOURCE_EXHAUSTED with a retry hint
+func quotaStatus(ctx context.Context, err error) error {
+	if errors.Is(err, quota.ErrModelNotAllowed) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	var limitErr *quota.LimitError
+	if !errors.As(err, &limitErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	seconds := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+	grpc.SetHeader(ctx, metadata.Pairs(RetryAfterMetadataKey, strconv.Itoa(max(seconds, 1))))
+	st, detailErr := status.New(codes.ResourceExhausted, err.Error()).WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(limitErr.RetryAfter)},
+		&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{
+			Subject:     "tenant:" + limitErr.Tenant,
+			Description: limitErr.Limit,
+		}}},
+	)
+	if detailErr != nil {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return st.Err()
+}
@@ -0,0 +1,80 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/quota"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+func TestQuotaInterceptor(t *testing.T) {
+	h := newInterceptorHarness(t)
+	limiter := quota.New("node-a")
+	limiter.SetQuotas(map[string]models.Quota{
+		"acme":   {RequestsPerSecond: 1},
+		"globex": {TokensPerMinute: 7, Models: []string{"llama-7b"}},
+	})
+	h.interceptors.tenantKeys = map[string]string{"acme-key": "acme", "globex-key": "globex"}
+	h.interceptors.quotas = limiter
+	h.interceptors.stages = func(ctx context.Context) bool {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return len(md.Get(forwardedByMetadataKey)) > 0
+	}
+
+	tenant := func(name string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadataKey, name+"-key")
+	}
+	forwarded := metadata.AppendToOutgoingContext(tenant("acme"), forwardedByMetadataKey, "node-b")
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		req        *pb.InferenceRequest
+		want       codes.Code
+		retryAfter string
+	}{
+		{"within quota", tenant("acme"), &pb.InferenceRequest{ModelId: "llama-7b"}, codes.OK, ""},
+		{"over requests per second", tenant("acme"), &pb.InferenceRequest{ModelId: "llama-7b"}, codes.ResourceExhausted, "1"},
+		{"clients setting their pipeline", tenant("acme"), &pb.InferenceRequest{ModelId: "llama-7b", LayerAssignments: []string{"node-a"}}, codes.ResourceExhausted, "1"},
+		{"stages forwarded by members", forwarded, &pb.InferenceRequest{ModelId: "llama-7b", LayerAssignments: []string{"node-a"}}, codes.OK, ""},
+		{"tokens charged on completion", tenant("globex"), &pb.InferenceRequest{ModelId: "llama-7b"}, codes.OK, ""},
+		{"over tokens per minute", tenant("globex"), &pb.InferenceRequest{ModelId: "llama-7b"}, codes.ResourceExhausted, "9"},
+		{"model not allowed", tenant("globex"), &pb.InferenceRequest{ModelId: "mistral-7b"}, codes.PermissionDenied, ""},
+		{"tenants without a quota", context.Background(), &pb.InferenceRequest{ModelId: "llama-7b"}, codes.OK, ""},
+		{"unknown API key", tenant("initech"), &pb.InferenceRequest{ModelId: "llama-7b"}, codes.Unauthenticated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header metadata.MD
+			_, err := h.client.ProcessInference(tt.ctx, tt.req, grpc.Header(&header))
+			st := status.Convert(err)
+			if st.Code() != tt.want {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			if got := header.Get(RetryAfterMetadataKey); tt.retryAfter != "" && (len(got) != 1 || got[0] != tt.retryAfter) {
+				t.Errorf("Expected retry-after %s, got %v", tt.retryAfter, got)
+			}
+			if tt.want != codes.ResourceExhausted {
+				return
+			}
+			var retry *errdetails.RetryInfo
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.RetryInfo); ok {
+					retry = info
+				}
+			}
+			if retry == nil || retry.RetryDelay.AsDuration() <= 0 || retry.RetryDelay.AsDuration() > time.Minute {
+				t.Errorf("Expected a RetryInfo detail, got %v", st.Details())
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"distributed-llm/internal/router"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// InferenceRouter picks the replica pipeline serving each request
+type InferenceRouter interface {
+	Pick(modelID string) (*router.Lease, error)
+	Stats() []router.ReplicaStats
+}
+
+// NodeHealthy reports whether a node is an online cluster member
+// (router.HealthSource)
+func (n *P2PNetwork) NodeHealthy(nodeID string) bool {
+	for _, node := range n.GetNodes() {
+		if node.ID == nodeID {
+			return node.Status == models.NodeStatusOnline
+		}
+	}
+	return false
+}
+
+// peerClient returns a NodeService client for a cluster member, reusing
+// connections across requests
+func (n *P2PNetwork) peerClient(nodeID string) (pb.NodeServiceClient, error) {
+	var address string
+	for _, node := range n.GetNodes() {
+		if node.ID == nodeID {
+			address = net.JoinHostPort(node.Address, strconv.Itoa(node.Port))
+			break
+		}
+	}
+	if address == "" {
+		return nil, fmt.Errorf("node %s is not a cluster member", nodeID)
+	}
+
+	n.connMu.Lock()
+	defer n.connMu.Unlock()
+	if conn, ok := n.conns[address]; ok {
+		return pb.NewNodeServiceClient(conn), nil
+	}
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(propagateRequestID, n.traceUnaryClient),
+		grpc.WithChainStreamInterceptor(propagateRequestIDStream, n.traceStreamClient))
+	if err != nil {
+		return nil, err
+	}
+	if n.conns == nil {
+		n.conns = make(map[string]*grpc.ClientConn)
+	}
+	n.conns[address] = conn
+	return pb.NewNodeServiceClient(conn), nil
+}
+
+// closePeerConns closes the connections opened by peerClient
+func (n *P2PNetwork) closePeerConns() {
+	n.connMu.Lock()
+	defer n.connMu.Unlock()
+	for address, conn := range n.conns {
+		conn.Close()
+		delete(n.conns, address)
+	}
+}
+
+// routeInference sends a request to a replica of its model, chosen by the
+// router, through the replica's first stage. Models without replicas are
+// served locally as before.
+func (s *NodeServer) routeInference(ctx context.Context, req *pb.InferenceRequest) (*pb.InferenceResponse, error) {
+	_, span := s.network.tracer().Start(ctx, spanSchedule,
+		trace.WithAttributes(attribute.String("inference.model_id", req.ModelId)))
+	lease, err := s.router.Pick(req.ModelId)
+	if errors.Is(err, router.ErrNoReplicas) {
+		span.SetAttributes(attribute.Bool("inference.local", true))
+		span.End()
+		return s.processInference(ctx, req)
+	}
+	if err != nil {
+		endSpan(span, err)
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	span.SetAttributes(
+		attribute.Int("inference.replica", lease.Plan.Replica),
+		attribute.StringSlice("inference.stages", lease.Plan.NodeIDs()),
+	)
+	span.End()
+
+	routed := &pb.InferenceRequest{
+		ModelId:          req.ModelId,
+		Prompt:           req.Prompt,
+		MaxTokens:        req.MaxTokens,
+		LayerAssignments: lease.Plan.NodeIDs(),
+	}
+	first := routed.LayerAssignments[0]
+
+	var resp *pb.InferenceResponse
+	if first == s.network.nodeID {
+		resp, err = s.processInference(ctx, routed)
+	} else {
+		var client pb.NodeServiceClient
+		client, err = s.network.peerClient(first)
+		if err == nil {
+			resp, err = client.ProcessInference(s.network.stageContext(ctx), routed)
+		}
+	}
+
+	switch {
+	case err != nil:
+		lease.Done(err)
+		return nil, status.Errorf(codes.Unavailable, "replica %d of %s on %s: %v", lease.Plan.Replica, req.ModelId, first, err)
+	case !resp.Success:
+		lease.Done(errors.New(resp.ErrorMessage))
+	default:
+		lease.Done(nil)
+	}
+	if first != s.network.nodeID {
+		// Charge the hop to the first stage to the gateway and that stage
+		toStage, fromStage := int64(proto.Size(routed)), int64(proto.Size(resp))
+		stages := stageUsage(resp.Stages)
+		gateway := stages.node(s.network.nodeID)
+		gateway.BytesSent += toStage
+		gateway.BytesReceived += fromStage
+		stage := stages.node(first)
+		stage.BytesReceived += toStage
+		stage.BytesSent += fromStage
+		resp.Stages = stages
+	}
+	return resp, nil
+}
+
+// replicaStatsToProto converts the router's view of each replica
+func replicaStatsToProto(stats []router.ReplicaStats) []*pb.ReplicaStats {
+	infos := make([]*pb.ReplicaStats, len(stats))
+	for i, s := range stats {
+		infos[i] = &pb.ReplicaStats{
+			ModelId:             s.ModelID,
+			Replica:             int32(s.Replica),
+			NodeIds:             s.Nodes,
+			Healthy:             s.Healthy,
+			OutstandingRequests: int32(s.Outstanding),
+			ServedRequests:      s.Served,
+			FailedRequests:      s.Failed,
+			AvgLatencyMs:        float64(s.Latency.Microseconds()) / 1000,
+		}
+	}
+	return infos
+}
@@ -0,0 +1,140 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"distributed-llm/internal/router"
+	"distributed-llm/internal/state"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// registerNode adds an online node to the network's view of the cluster
+func registerNode(network *P2PNetwork, node models.Node) {
+	node.Status = models.NodeStatusOnline
+	node.LastSeen = time.Now()
+	network.mu.Lock()
+	network.registered[node.ID] = node
+	network.mu.Unlock()
+}
+
+func TestProcessInferenceRoutesToReplicas(t *testing.T) {
+	// node-b serves a replica over gRPC
+	server, err := NewGRPCServer(newTestNetwork(t, "node-b"), findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	go server.Start()
+	t.Cleanup(server.Stop)
+
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	registerNode(network, models.Node{ID: "node-a", Address: "127.0.0.1"})
+	registerNode(network, models.Node{ID: "node-b", Address: "127.0.0.1", Port: server.listener.Addr().(*net.TCPAddr).Port})
+
+	rt := router.New("node-a", router.LeastOutstanding)
+	rt.SetHealthSource(network)
+	rt.Update([]models.PlacementPlan{
+		{ModelID: "llama-7b", Replica: 0, Stages: []models.PipelineStage{{NodeID: "node-a", LayerStart: 0, LayerEnd: 31}}},
+		{ModelID: "llama-7b", Replica: 1, Stages: []models.PipelineStage{{NodeID: "node-b", LayerStart: 0, LayerEnd: 31}}},
+	})
+	nodeServer := &NodeServer{network: network, router: rt}
+
+	served := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		resp, err := nodeServer.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "llama-7b", Prompt: "hi"})
+		if err != nil {
+			t.Fatalf("ProcessInference failed: %v", err)
+		}
+		served[resp.GeneratedText]++
+	}
+	if served["Hello from node node-a"] == 0 || served["Hello from node node-b"] == 0 {
+		t.Errorf("Expected both replicas to serve requests, got %v", served)
+	}
+
+	// Models without replicas are served locally
+	resp, err := nodeServer.ProcessInference(context.Background(), &pb.InferenceRequest{ModelId: "unplaced"})
+	if err != nil || resp.GeneratedText != "Hello from node node-a" {
+		t.Errorf("Expected local inference for an unplaced model, got %+v (%v)", resp, err)
+	}
+
+	info, err := (&DiscoveryServer{network: network, router: rt}).GetClusterInfo(context.Background(), &pb.ClusterInfoRequest{})
+	if err != nil {
+		t.Fatalf("GetClusterInfo failed: %v", err)
+	}
+	if len(info.Replicas) != 2 {
+		t.Fatalf("Expected stats for 2 replicas, got %+v", info.Replicas)
+	}
+	total := uint64(0)
+	for _, replica := range info.Replicas {
+		if !replica.Healthy || replica.OutstandingRequests != 0 {
+			t.Errorf("Expected an idle healthy replica, got %+v", replica)
+		}
+		total += replica.ServedRequests
+	}
+	if total != 4 {
+		t.Errorf("Expected 4 served requests across replicas, got %d", total)
+	}
+}
+
+func TestTUIServer_ScaleCommand(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	for _, id := range []string{"node-1", "node-2", "node-3"} {
+		registerNode(network, models.Node{ID: id, Address: "10.0.0." + id[len(id)-1:], Resources: models.ResourceInfo{MaxLayers: 32}})
+	}
+
+	store, err := state.NewStore(state.Config{NodeID: "test-node"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create state store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	store.PutModel(ctx, models.Model{ID: "llama-7b", LayerCount: 32})
+
+	tuiServer := NewTUIServer(network, NewDiscoveryServer(network))
+	tuiServer.SetStateStore(store)
+
+	tests := []struct {
+		name     string
+		args     []string
+		success  bool
+		replicas int
+	}{
+		{"scale up", []string{"llama-7b", "3"}, true, 3},
+		{"more replicas than nodes", []string{"llama-7b", "4"}, false, 3},
+		{"scale down", []string{"llama-7b", "1"}, true, 1},
+		{"invalid count", []string{"llama-7b", "0"}, false, 1},
+		{"unknown model", []string{"missing", "2"}, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tuiServer.ExecuteCommand(ctx, &pb.CommandRequest{Command: "scale", Args: tt.args})
+			if err != nil {
+				t.Fatalf("ExecuteCommand failed: %v", err)
+			}
+			if resp.Success != tt.success {
+				t.Errorf("Expected success=%v, got %v (error %q)", tt.success, resp.Success, resp.Error)
+			}
+
+			replicas := store.Snapshot().Replicas("llama-7b")
+			if len(replicas) != tt.replicas {
+				t.Fatalf("Expected %d replicas, got %+v", tt.replicas, replicas)
+			}
+			used := make(map[string]bool)
+			for i, plan := range replicas {
+				if plan.Replica != i || used[plan.Stages[0].NodeID] {
+					t.Errorf("Expected replicas 0..%d on distinct nodes, got %+v", tt.replicas-1, replicas)
+				}
+				used[plan.Stages[0].NodeID] = true
+			}
+		})
+	}
+
+	if model := store.Snapshot().Models["llama-7b"]; model.DesiredReplicas() != 1 {
+		t.Errorf("Expected the model to record 1 replica, got %d", model.Replicas)
+	}
+}
@@ -0,0 +1,267 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSeedRefreshInterval is how often seed providers are re-resolved
+const DefaultSeedRefreshInterval = 30 * time.Second
+
+// SeedProvider resolves the host:port addresses of agents to contact. Agents
+// resolve gossip addresses and the TUI gRPC addresses; each provider is
+// configured with the port it returns.
+type SeedProvider interface {
+	Seeds(ctx context.Context) ([]string, error)
+	String() string
+}
+
+// StaticSeeds is a fixed list of addresses, as given by --seed-nodes
+type StaticSeeds []string
+
+// Seeds returns the list
+func (s StaticSeeds) Seeds(ctx context.Context) ([]string, error) {
+	return append([]string(nil), s...), nil
+}
+
+func (s StaticSeeds) String() string {
+	return "static"
+}
+
+// Resolver is the part of net.Resolver used by the DNS providers
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// DNSSeeds resolves the A and AAAA records of a name, such as a headless
+// Kubernetes service or a Docker Compose service, and pairs each address with Port
+type DNSSeeds struct {
+	Name     string
+	Port     int
+	Resolver Resolver
+}
+
+// NewDNSSeeds creates a provider for the addresses of name
+func NewDNSSeeds(name string, port int) *DNSSeeds {
+	return &DNSSeeds{Name: name, Port: port, Resolver: net.DefaultResolver}
+}
+
+// Seeds looks up the name
+func (s *DNSSeeds) Seeds(ctx context.Context) ([]string, error) {
+	addrs, err := s.Resolver.LookupHost(ctx, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	seeds := make([]string, len(addrs))
+	for i, addr := range addrs {
+		seeds[i] = net.JoinHostPort(addr, strconv.Itoa(s.Port))
+	}
+	return seeds, nil
+}
+
+func (s *DNSSeeds) String() string {
+	return "dns:" + s.Name
+}
+
+// SRVSeeds resolves SRV records, which carry the port of each target
+type SRVSeeds struct {
+	Service  string
+	Proto    string
+	Name     string
+	Resolver Resolver
+}
+
+// NewSRVSeeds creates a provider for the _service._proto.name records
+func NewSRVSeeds(service, proto, name string) *SRVSeeds {
+	return &SRVSeeds{Service: service, Proto: proto, Name: name, Resolver: net.DefaultResolver}
+}
+
+// Seeds looks up the records
+func (s *SRVSeeds) Seeds(ctx context.Context) ([]string, error) {
+	_, records, err := s.Resolver.LookupSRV(ctx, s.Service, s.Proto, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	seeds := make([]string, len(records))
+	for i, record := range records {
+		seeds[i] = net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+	}
+	return seeds, nil
+}
+
+func (s *SRVSeeds) String() string {
+	return fmt.Sprintf("srv:_%s._%s.%s", s.Service, s.Proto, s.Name)
+}
+
+// ParseDNSSeeds parses a --seed-dns value: "_service._proto.name" for SRV
+// records, otherwise "name" or "name:port" for address records on defaultPort
+func ParseDNSSeeds(value string, defaultPort int) (SeedProvider, error) {
+	if strings.HasPrefix(value, "_") {
+		parts := strings.SplitN(value, ".", 3)
+		if len(parts) != 3 || !strings.HasPrefix(parts[1], "_") || parts[2] == "" {
+			return nil, fmt.Errorf("invalid SRV name %q, expected _service._proto.name", value)
+		}
+		return NewSRVSeeds(strings.TrimPrefix(parts[0], "_"), strings.TrimPrefix(parts[1], "_"), parts[2]), nil
+	}
+
+	host, port := value, defaultPort
+	if h, p, err := net.SplitHostPort(value); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return nil, fmt.Errorf("invalid port in %q", value)
+		}
+		host, port = h, n
+	}
+	if host == "" {
+		return nil, fmt.Errorf("invalid DNS seed %q", value)
+	}
+	return NewDNSSeeds(host, port), nil
+}
+
+// FileSeeds reads addresses from a file, one per line, with blank lines and
+// # comments ignored. The file is re-read whenever it changes, so tools such
+// as config management can rewrite it while agents run.
+type FileSeeds struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	seeds   []string
+}
+
+// NewFileSeeds creates a provider for the file at path
+func NewFileSeeds(path string) *FileSeeds {
+	return &FileSeeds{path: path}
+}
+
+// Seeds returns the addresses in the file, reading it again if it was modified
+func (s *FileSeeds) Seeds(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.ModTime().Equal(s.modTime) || s.seeds == nil {
+		seeds, err := readSeedFile(s.path)
+		if err != nil {
+			return nil, err
+		}
+		s.seeds = seeds
+		s.modTime = info.ModTime()
+	}
+	return append([]string(nil), s.seeds...), nil
+}
+
+func (s *FileSeeds) String() string {
+	return "file:" + s.path
+}
+
+func readSeedFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seeds := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			seeds = append(seeds, field)
+		}
+	}
+	return seeds, scanner.Err()
+}
+
+// SeedProviders combines providers. Seeds are merged without duplicates; a
+// failing provider does not hide the seeds of the others.
+type SeedProviders []SeedProvider
+
+// Seeds resolves every provider, returning the merged seeds and the errors
+// of the providers that failed
+func (p SeedProviders) Seeds(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var seeds []string
+	var errs []error
+	for _, provider := range p {
+		resolved, err := provider.Seeds(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider, err))
+		}
+		for _, seed := range resolved {
+			if !seen[seed] {
+				seen[seed] = true
+				seeds = append(seeds, seed)
+			}
+		}
+	}
+	return seeds, errors.Join(errs...)
+}
+
+func (p SeedProviders) String() string {
+	names := make([]string, len(p))
+	for i, provider := range p {
+		names[i] = provider.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// WatchSeeds resolves provider every interval until ctx is done, calling
+// handler with the current seeds and those not seen in the previous round.
+// Resolution errors are logged; the seeds that did resolve are still passed on.
+func WatchSeeds(ctx context.Context, provider SeedProvider, interval time.Duration, handler func(seeds, added []string)) {
+	if interval <= 0 {
+		interval = DefaultSeedRefreshInterval
+	}
+	logger := slog.With("component", "seeds", "provider", provider.String())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous := make(map[string]bool)
+	for {
+		resolveCtx, cancel := context.WithTimeout(ctx, interval)
+		seeds, err := provider.Seeds(resolveCtx)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			logger.Debug("Failed to resolve some seeds", "error", err)
+		}
+
+		sort.Strings(seeds)
+		current := make(map[string]bool, len(seeds))
+		var added []string
+		for _, seed := range seeds {
+			current[seed] = true
+			if !previous[seed] {
+				added = append(added, seed)
+			}
+		}
+		previous = current
+
+		if len(seeds) > 0 {
+			handler(seeds, added)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
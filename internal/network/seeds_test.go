@@ -0,0 +1,207 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers lookups from maps
+type fakeResolver struct {
+	hosts map[string][]string
+	srv   map[string][]*net.SRV
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	key := "_" + service + "._" + proto + "." + name
+	if records, ok := r.srv[key]; ok {
+		return key, records, nil
+	}
+	return "", nil, &net.DNSError{Err: "no such host", Name: key, IsNotFound: true}
+}
+
+func TestParseDNSSeeds(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"agents.local", "dns:agents.local", false},
+		{"agents.local:7950", "dns:agents.local", false},
+		{"_gossip._tcp.agents.svc.cluster.local", "srv:_gossip._tcp.agents.svc.cluster.local", false},
+		{"_gossip.agents", "", true},
+		{"agents.local:http", "", true},
+		{":7946", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			provider, err := ParseDNSSeeds(tt.value, 7946)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDNSSeeds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && provider.String() != tt.want {
+				t.Errorf("Expected provider %s, got %s", tt.want, provider)
+			}
+		})
+	}
+
+	provider, _ := ParseDNSSeeds("agents.local:7950", 7946)
+	if port := provider.(*DNSSeeds).Port; port != 7950 {
+		t.Errorf("Expected explicit port 7950, got %d", port)
+	}
+}
+
+func TestDNSSeeds(t *testing.T) {
+	resolver := &fakeResolver{
+		hosts: map[string][]string{"agents.local": {"10.0.0.1", "fd00::1"}},
+		srv: map[string][]*net.SRV{
+			"_gossip._tcp.agents.local": {{Target: "agent-0.agents.local.", Port: 7950}},
+		},
+	}
+
+	dns := NewDNSSeeds("agents.local", 7946)
+	dns.Resolver = resolver
+	seeds, err := dns.Seeds(context.Background())
+	if err != nil {
+		t.Fatalf("Seeds failed: %v", err)
+	}
+	if want := []string{"10.0.0.1:7946", "[fd00::1]:7946"}; !reflect.DeepEqual(seeds, want) {
+		t.Errorf("Expected %v, got %v", want, seeds)
+	}
+
+	srv := NewSRVSeeds("gossip", "tcp", "agents.local")
+	srv.Resolver = resolver
+	seeds, err = srv.Seeds(context.Background())
+	if err != nil {
+		t.Fatalf("Seeds failed: %v", err)
+	}
+	if want := []string{"agent-0.agents.local:7950"}; !reflect.DeepEqual(seeds, want) {
+		t.Errorf("Expected %v, got %v", want, seeds)
+	}
+}
+
+func TestFileSeeds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seeds")
+	if err := os.WriteFile(path, []byte("# agents\n10.0.0.1:7946\n\n10.0.0.2:7946, 10.0.0.3:7946 # rack b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewFileSeeds(path)
+	seeds, err := provider.Seeds(context.Background())
+	if err != nil {
+		t.Fatalf("Seeds failed: %v", err)
+	}
+	if want := []string{"10.0.0.1:7946", "10.0.0.2:7946", "10.0.0.3:7946"}; !reflect.DeepEqual(seeds, want) {
+		t.Errorf("Expected %v, got %v", want, seeds)
+	}
+
+	// Rewriting the file is picked up on the next call
+	if err := os.WriteFile(path, []byte("10.0.0.9:7946\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	seeds, err = provider.Seeds(context.Background())
+	if err != nil {
+		t.Fatalf("Seeds failed: %v", err)
+	}
+	if want := []string{"10.0.0.9:7946"}; !reflect.DeepEqual(seeds, want) {
+		t.Errorf("Expected rewritten seeds %v, got %v", want, seeds)
+	}
+
+	if _, err := NewFileSeeds(filepath.Join(t.TempDir(), "missing")).Seeds(context.Background()); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+// errSeeds is a provider that always fails
+type errSeeds struct{}
+
+func (errSeeds) Seeds(ctx context.Context) ([]string, error) { return nil, errors.New("unreachable") }
+func (errSeeds) String() string                              { return "broken" }
+
+func TestSeedProviders(t *testing.T) {
+	providers := SeedProviders{
+		StaticSeeds{"10.0.0.1:7946", "10.0.0.2:7946"},
+		errSeeds{},
+		StaticSeeds{"10.0.0.2:7946", "10.0.0.3:7946"},
+	}
+
+	seeds, err := providers.Seeds(context.Background())
+	if want := []string{"10.0.0.1:7946", "10.0.0.2:7946", "10.0.0.3:7946"}; !reflect.DeepEqual(seeds, want) {
+		t.Errorf("Expected merged seeds %v, got %v", want, seeds)
+	}
+	if err == nil || err.Error() != "broken: unreachable" {
+		t.Errorf("Expected the failing provider to be reported, got %v", err)
+	}
+	if got := providers.String(); got != "static,broken,static" {
+		t.Errorf("Unexpected String() %q", got)
+	}
+}
+
+// changingSeeds returns the next list on every call
+type changingSeeds struct {
+	mu     sync.Mutex
+	rounds [][]string
+}
+
+func (s *changingSeeds) Seeds(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seeds := s.rounds[0]
+	if len(s.rounds) > 1 {
+		s.rounds = s.rounds[1:]
+	}
+	return seeds, nil
+}
+
+func (s *changingSeeds) String() string { return "changing" }
+
+func TestWatchSeeds(t *testing.T) {
+	provider := &changingSeeds{rounds: [][]string{
+		{"b:1", "a:1"},
+		{"a:1", "b:1"},
+		{"a:1", "c:1"},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type round struct{ seeds, added []string }
+	rounds := make(chan round, 10)
+	go WatchSeeds(ctx, provider, 10*time.Millisecond, func(seeds, added []string) {
+		rounds <- round{seeds, added}
+	})
+
+	want := []round{
+		{[]string{"a:1", "b:1"}, []string{"a:1", "b:1"}},
+		{[]string{"a:1", "b:1"}, nil},
+		{[]string{"a:1", "c:1"}, []string{"c:1"}},
+	}
+	for i, w := range want {
+		select {
+		case got := <-rounds:
+			if !reflect.DeepEqual(got, w) {
+				t.Errorf("Round %d: expected %+v, got %+v", i, w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Round %d: handler not called", i)
+		}
+	}
+}
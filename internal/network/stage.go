@@ -0,0 +1,88 @@
+package network
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	pb "distributed-llm/proto"
+)
+
+const (
+	// forwardedByMetadataKey names the member forwarding a request to a
+	// stage of its pipeline
+	forwardedByMetadataKey = "x-forwarded-by"
+	// clusterTokenMetadataKey carries the join token on forwarded requests
+	// when the cluster has one
+	clusterTokenMetadataKey = "x-cluster-token"
+)
+
+type forwardedStageKey struct{}
+
+// forwardedStage reports whether the call being served was forwarded by
+// another member to a stage of its pipeline, rather than entering the
+// cluster through this node
+func forwardedStage(ctx context.Context) bool {
+	forwarded, _ := ctx.Value(forwardedStageKey{}).(bool)
+	return forwarded
+}
+
+// stageContext marks a request this node forwards to a stage of its
+// pipeline, so that the stage doesn't admit or charge it again
+func (n *P2PNetwork) stageContext(ctx context.Context) context.Context {
+	n.mu.RLock()
+	token := n.joinToken
+	n.mu.RUnlock()
+
+	ctx = metadata.AppendToOutgoingContext(ctx, forwardedByMetadataKey, n.nodeID)
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, clusterTokenMetadataKey, token)
+	}
+	return ctx
+}
+
+// forwardedByMember reports whether an incoming call was forwarded by a
+// cluster member: it names a member, comes from that member's address and,
+// when the cluster has a join token, presents it. LayerAssignments alone is
+// set by clients as well and proves nothing.
+func (n *P2PNetwork) forwardedByMember(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	ids := md.Get(forwardedByMetadataKey)
+	if len(ids) != 1 || ids[0] == "" || ids[0] == n.nodeID {
+		return false
+	}
+
+	n.mu.RLock()
+	token := n.joinToken
+	n.mu.RUnlock()
+	if token != "" {
+		tokens := md.Get(clusterTokenMetadataKey)
+		if len(tokens) != 1 || subtle.ConstantTimeCompare([]byte(token), []byte(tokens[0])) != 1 {
+			return false
+		}
+	}
+
+	remote := peerIP(ctx)
+	for _, node := range n.GetNodes() {
+		if node.ID == ids[0] {
+			return remote != nil && remote.Equal(net.ParseIP(node.Address))
+		}
+	}
+	return false
+}
+
+// unaryStage marks inference requests forwarded by another member, for the
+// quota, metrics and gateway steps that only apply where a request enters
+// the cluster
+func (i *serverInterceptors) unaryStage(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if i.stages != nil && info.FullMethod == pb.NodeService_ProcessInference_FullMethodName && i.stages(ctx) {
+		ctx = context.WithValue(ctx, forwardedStageKey{}, true)
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,56 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"distributed-llm/pkg/models"
+)
+
+func TestForwardedByMember(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	network.SetJoinToken("secret")
+	registerNode(network, models.Node{ID: "node-b", Address: "10.0.0.2", Port: 9000})
+
+	call := func(from string, pairs ...string) context.Context {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(from), Port: 51234}})
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"forwarded by a member", call("10.0.0.2", forwardedByMetadataKey, "node-b", clusterTokenMetadataKey, "secret"), true},
+		{"not forwarded", call("10.0.0.2"), false},
+		{"without the token", call("10.0.0.2", forwardedByMetadataKey, "node-b"), false},
+		{"with a wrong token", call("10.0.0.2", forwardedByMetadataKey, "node-b", clusterTokenMetadataKey, "guess"), false},
+		{"from another address", call("10.0.0.9", forwardedByMetadataKey, "node-b", clusterTokenMetadataKey, "secret"), false},
+		{"naming an unknown node", call("10.0.0.2", forwardedByMetadataKey, "node-x", clusterTokenMetadataKey, "secret"), false},
+		{"naming this node", call("10.0.0.2", forwardedByMetadataKey, "node-a", clusterTokenMetadataKey, "secret"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := network.forwardedByMember(tt.ctx); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStageContext(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	network.SetJoinToken("secret")
+
+	md, _ := metadata.FromOutgoingContext(network.stageContext(context.Background()))
+	if got := md.Get(forwardedByMetadataKey); len(got) != 1 || got[0] != "node-a" {
+		t.Errorf("Expected the request forwarded by node-a, got %v", got)
+	}
+	if got := md.Get(clusterTokenMetadataKey); len(got) != 1 || got[0] != "secret" {
+		t.Errorf("Expected the join token, got %v", got)
+	}
+}
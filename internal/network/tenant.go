@@ -0,0 +1,69 @@
+package network
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/usage"
+)
+
+// APIKeyMetadataKey is the metadata key carrying the API key that names the
+// tenant a request is charged to
+const APIKeyMetadataKey = "x-api-key"
+
+type tenantKey struct{}
+
+// TenantFromContext returns the tenant the call being served is charged to:
+// the tenant of the API key it presented, or usage.DefaultTenant
+func TenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+		return tenant
+	}
+	return usage.DefaultTenant
+}
+
+func withTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// SetTenantKeys authenticates tenants by API key, mapping each key to its
+// tenant. Calls without a key are charged to usage.DefaultTenant and calls
+// with an unknown key are rejected. Call it before serving.
+func (g *GRPCServer) SetTenantKeys(keys map[string]string) {
+	g.interceptors.tenantKeys = keys
+}
+
+// unaryTenant charges calls to the tenant of the API key they present.
+// Tenants are never taken from the caller's word, so that they can't
+// choose one without a quota or a new one on every call.
+func (i *serverInterceptors) unaryTenant(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(APIKeyMetadataKey)
+	if len(keys) == 0 {
+		return handler(ctx, req)
+	}
+	tenant, ok := i.tenantOf(keys)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unknown API key")
+	}
+	return handler(withTenant(ctx, tenant), req)
+}
+
+// tenantOf returns the tenant of the one API key presented
+func (i *serverInterceptors) tenantOf(keys []string) (string, bool) {
+	if len(keys) != 1 {
+		return "", false
+	}
+	tenant, found := "", false
+	for key, name := range i.tenantKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(keys[0])) == 1 {
+			tenant, found = name, true
+		}
+	}
+	return tenant, found
+}
@@ -0,0 +1,48 @@
+package network
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/usage"
+)
+
+func TestTenantInterceptor(t *testing.T) {
+	interceptors := &serverInterceptors{tenantKeys: map[string]string{"acme-key": "acme", "globex-key": "globex"}}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string
+		code codes.Code
+	}{
+		{"no API key", metadata.MD{}, usage.DefaultTenant, codes.OK},
+		{"API key", metadata.Pairs(APIKeyMetadataKey, "acme-key"), "acme", codes.OK},
+		{"tenant named without a key", metadata.Pairs("x-tenant-id", "acme"), usage.DefaultTenant, codes.OK},
+		{"tenant named with another's key", metadata.Pairs(APIKeyMetadataKey, "globex-key", "x-tenant-id", "acme"), "globex", codes.OK},
+		{"unknown API key", metadata.Pairs(APIKeyMetadataKey, "initech-key"), "", codes.Unauthenticated},
+		{"several API keys", metadata.Pairs(APIKeyMetadataKey, "acme-key", APIKeyMetadataKey, "globex-key"), "", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := func(ctx context.Context, req any) (any, error) {
+				got = TenantFromContext(ctx)
+				return nil, nil
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			_, err := interceptors.unaryTenant(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test"}, handler)
+			if status.Code(err) != tt.code {
+				t.Fatalf("Expected %v, got %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected tenant %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tracerName is the instrumentation scope of the agent's spans
+const tracerName = "distributed-llm/internal/network"
+
+// Spans recorded for the stages of an inference request
+const (
+	spanGateway            = "inference.gateway"
+	spanSchedule           = "inference.schedule"
+	spanQueueWait          = "inference.queue_wait"
+	spanForward            = "inference.forward"
+	spanActivationTransfer = "inference.activation_transfer"
+	spanSample             = "inference.sample"
+)
+
+// tracePropagator carries trace context and baggage in gRPC metadata
+var tracePropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// SetTracerProvider records the agent's spans with provider. Without one
+// nothing is recorded, but trace context is still passed on to peers.
+func (n *P2PNetwork) SetTracerProvider(provider trace.TracerProvider) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.tracerProvider = provider
+}
+
+func (n *P2PNetwork) tracer() trace.Tracer {
+	n.mu.RLock()
+	provider := n.tracerProvider
+	n.mu.RUnlock()
+	if provider == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+	return provider.Tracer(tracerName)
+}
+
+// endSpan marks the span failed when err is set and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+	}
+	span.End()
+}
+
+// metadataCarrier lets the propagator read and write gRPC metadata
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// rpcAttributes describes a gRPC method following the OpenTelemetry
+// semantic conventions
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", method),
+	}
+}
+
+// endRPCSpan records a call's status code and ends its span
+func endRPCSpan(span trace.Span, err error) {
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(status.Code(err))))
+	endSpan(span, err)
+}
+
+// startServerSpan continues the caller's trace, if any, for a call being served
+func (i *serverInterceptors) startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracePropagator.Extract(ctx, metadataCarrier(md))
+	attrs := append(rpcAttributes(method), attribute.String("request.id", RequestIDFromContext(ctx)))
+	return i.tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...))
+}
+
+func (i *serverInterceptors) unaryTracing(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := i.startServerSpan(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	endRPCSpan(span, err)
+	return resp, err
+}
+
+func (i *serverInterceptors) streamTracing(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := i.startServerSpan(ss.Context(), info.FullMethod)
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	endRPCSpan(span, err)
+	return err
+}
+
+// startClientSpan starts the span of a call to a peer and passes its trace
+// context on in the call's metadata
+func (n *P2PNetwork) startClientSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	ctx, span := n.tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(rpcAttributes(method)...))
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	tracePropagator.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+func (n *P2PNetwork) traceUnaryClient(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := n.startClientSpan(ctx, method)
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	endRPCSpan(span, err)
+	return err
+}
+
+func (n *P2PNetwork) traceStreamClient(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, span := n.startClientSpan(ctx, method)
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		endRPCSpan(span, err)
+		return nil, err
+	}
+
+	traced := &tracedClientStream{ClientStream: stream, span: span}
+	traced.stop = context.AfterFunc(ctx, func() { traced.finish(ctx.Err()) })
+	return traced, nil
+}
+
+// tracedClientStream ends its span when the stream finishes, which the
+// caller sees as an error from RecvMsg, or when its context is done
+type tracedClientStream struct {
+	grpc.ClientStream
+	span trace.Span
+	once sync.Once
+	stop func() bool
+}
+
+func (s *tracedClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.stop()
+		if errors.Is(err, io.EOF) {
+			s.finish(nil)
+		} else {
+			s.finish(err)
+		}
+	}
+	return err
+}
+
+func (s *tracedClientStream) finish(err error) {
+	s.once.Do(func() { endRPCSpan(s.span, err) })
+}
@@ -0,0 +1,187 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"distributed-llm/internal/router"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// newTracedNode starts an agent recording its spans with provider
+func newTracedNode(t *testing.T, nodeID string, provider trace.TracerProvider) (*P2PNetwork, *GRPCServer, int) {
+	t.Helper()
+	network := newTestNetwork(t, nodeID)
+	t.Cleanup(network.Stop)
+	network.SetTracerProvider(provider)
+	server, err := NewGRPCServer(network, findAvailablePort(t))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	go server.Start()
+	t.Cleanup(server.Stop)
+	return network, server, server.listener.Addr().(*net.TCPAddr).Port
+}
+
+// spanTree renders the spans below root, one per line and indented by
+// depth, with siblings in the order they started
+func spanTree(spans tracetest.SpanStubs, root trace.SpanID) string {
+	var b strings.Builder
+	var walk func(parent trace.SpanID, depth int)
+	walk = func(parent trace.SpanID, depth int) {
+		var children tracetest.SpanStubs
+		for _, span := range spans {
+			if span.Parent.SpanID() == parent {
+				children = append(children, span)
+			}
+		}
+		slices.SortFunc(children, func(a, b tracetest.SpanStub) int { return a.StartTime.Compare(b.StartTime) })
+		for _, span := range children {
+			fmt.Fprintf(&b, "%s%s %s\n", strings.Repeat("  ", depth), span.Name, span.SpanKind)
+			walk(span.SpanContext.SpanID(), depth+1)
+		}
+	}
+	walk(root, 0)
+	return b.String()
+}
+
+func TestInferenceSpanTree(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	// node-a is the gateway; the model's only replica runs on node-b then node-c
+	_, _, portC := newTracedNode(t, "node-c", provider)
+	networkB, _, portB := newTracedNode(t, "node-b", provider)
+	networkA, serverA, portA := newTracedNode(t, "node-a", provider)
+	for _, network := range []*P2PNetwork{networkA, networkB} {
+		registerNode(network, models.Node{ID: "node-b", Address: "127.0.0.1", Port: portB, Features: SupportedFeatures()})
+		registerNode(network, models.Node{ID: "node-c", Address: "127.0.0.1", Port: portC, Features: SupportedFeatures()})
+	}
+	registerNode(networkB, models.Node{ID: "node-a", Address: "127.0.0.1", Port: portA, Features: SupportedFeatures()})
+	rt := router.New("node-a", router.LeastOutstanding)
+	rt.Update([]models.PlacementPlan{{ModelID: "llama-7b", Stages: []models.PipelineStage{
+		{NodeID: "node-b", LayerStart: 0, LayerEnd: 15},
+		{NodeID: "node-c", LayerStart: 16, LayerEnd: 31},
+	}}})
+	serverA.SetRouter(rt)
+
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", portA), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	// The caller's trace context and request ID travel with the request
+	ctx, root := provider.Tracer("test").Start(context.Background(), "caller")
+	md := metadata.Pairs(RequestIDMetadataKey, "req-trace")
+	tracePropagator.Inject(ctx, metadataCarrier(md))
+	resp, err := pb.NewNodeServiceClient(conn).ProcessInference(metadata.NewOutgoingContext(ctx, md),
+		&pb.InferenceRequest{ModelId: "llama-7b", Prompt: "tell me a story"})
+	root.End()
+	if err != nil || !resp.Success {
+		t.Fatalf("ProcessInference failed: %+v (%v)", resp, err)
+	}
+	if resp.GeneratedText != "Hello from node node-b" {
+		t.Errorf("Expected node-b to drive the pipeline, got %q", resp.GeneratedText)
+	}
+
+	want := `proto.NodeService/ProcessInference server
+  inference.gateway internal
+    inference.schedule internal
+    proto.NodeService/ProcessInference client
+      proto.NodeService/ProcessInference server
+        inference.queue_wait internal
+        inference.forward internal
+        inference.activation_transfer internal
+          proto.NodeService/StreamActivations client
+            proto.NodeService/StreamActivations server
+              inference.forward internal
+        inference.sample internal
+`
+	// Servers may end their spans just after the caller has its answer
+	var got string
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		got = spanTree(exporter.GetSpans(), root.SpanContext().SpanID())
+		if got == want || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got != want {
+		t.Fatalf("Unexpected span tree:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, span := range exporter.GetSpans() {
+		if span.SpanContext.TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("Span %s is in trace %s, want %s", span.Name, span.SpanContext.TraceID(), root.SpanContext().TraceID())
+		}
+		for _, attr := range span.Attributes {
+			if attr.Key == "request.id" && attr.Value.AsString() != "req-trace" {
+				t.Errorf("Span %s has request ID %q, want req-trace", span.Name, attr.Value.AsString())
+			}
+		}
+		if span.Name == spanForward {
+			stage := -1
+			node := ""
+			for _, attr := range span.Attributes {
+				switch attr.Key {
+				case "inference.stage":
+					stage = int(attr.Value.AsInt64())
+				case "inference.node_id":
+					node = attr.Value.AsString()
+				}
+			}
+			if want := fmt.Sprintf("node-%c", 'b'+stage); node != want {
+				t.Errorf("Expected stage %d to run on %s, got %s", stage, want, node)
+			}
+		}
+	}
+}
+
+func TestUntracedAgentPassesTraceContextOn(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	// node-b records spans; node-a, in front of it, doesn't
+	_, _, portB := newTracedNode(t, "node-b", provider)
+	networkA := newTestNetwork(t, "node-a")
+	t.Cleanup(networkA.Stop)
+	registerNode(networkA, models.Node{ID: "node-b", Address: "127.0.0.1", Port: portB})
+	client, err := networkA.peerClient("node-b")
+	if err != nil {
+		t.Fatalf("peerClient failed: %v", err)
+	}
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "caller")
+	if _, err := client.GetVersion(ctx, &pb.GetVersionRequest{}); err != nil {
+		t.Fatalf("GetVersion failed: %v", err)
+	}
+	root.End()
+
+	var found bool
+	for deadline := time.Now().Add(2 * time.Second); !found && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		for _, span := range exporter.GetSpans() {
+			if span.Name == "proto.NodeService/GetVersion" && span.Parent.SpanID() == root.SpanContext().SpanID() {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected node-b's span to continue the caller's trace, got %v", exporter.GetSpans())
+	}
+}
@@ -0,0 +1,245 @@
+package network
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "distributed-llm/proto"
+)
+
+const (
+	// updateCoalesceDelay is how long StreamUpdates waits after a change
+	// for others, so that a burst of events is sent as one delta
+	updateCoalesceDelay = 100 * time.Millisecond
+	// defaultMetricsThreshold is the relative change in a cluster metric
+	// below which it is not sent
+	defaultMetricsThreshold = 0.05
+	// defaultUpdateInterval is how often resources and metrics are checked
+	defaultUpdateInterval = 5 * time.Second
+)
+
+// updateTypes are the sections a StreamUpdates subscriber can request
+var updateTypes = []string{"nodes", "models", "metrics"}
+
+// clusterView is the part of the cluster a StreamUpdates subscriber sees
+type clusterView struct {
+	nodes   map[string]*pb.NodeInfo
+	models  map[string]*pb.ModelInfo
+	metrics *pb.ClusterMetrics
+}
+
+// view collects the requested sections of the cluster
+func (t *TUIServer) view(ctx context.Context, req *pb.UpdateStreamRequest, types []string) clusterView {
+	var v clusterView
+	if slices.Contains(types, "nodes") {
+		v.nodes = make(map[string]*pb.NodeInfo)
+		for _, node := range t.network.GetNodes() {
+			v.nodes[node.ID] = nodeToProto(node)
+		}
+	}
+	if slices.Contains(types, "models") {
+		v.models = make(map[string]*pb.ModelInfo)
+		if resp, err := t.GetModelList(ctx, &pb.ModelListRequest{RequesterId: req.RequesterId}); err == nil {
+			for _, model := range resp.Models {
+				v.models[model.Id] = model
+			}
+		}
+	}
+	if slices.Contains(types, "metrics") {
+		if info, err := t.discoveryServer.GetClusterInfo(ctx, &pb.ClusterInfoRequest{RequesterId: req.RequesterId}); err == nil {
+			v.metrics = info.Metrics
+		}
+	}
+	return v
+}
+
+// snapshot returns the update holding the whole view
+func (v clusterView) snapshot() *pb.ClusterUpdate {
+	update := &pb.ClusterUpdate{UpdateType: "snapshot", Metrics: v.metrics}
+	for _, id := range sortedKeys(v.nodes) {
+		update.Nodes = append(update.Nodes, v.nodes[id])
+	}
+	for _, id := range sortedKeys(v.models) {
+		update.Models = append(update.Models, v.models[id])
+	}
+	return update
+}
+
+// diffViews returns the delta turning the view last sent into the current
+// one, nil if nothing changed, along with what the subscriber has seen once
+// it is sent. Metrics changed less than threshold are held back, so that
+// slow drift is sent once it adds up.
+func diffViews(sent, current clusterView, threshold float64) (*pb.ClusterUpdate, clusterView) {
+	update := &pb.ClusterUpdate{UpdateType: "delta"}
+	for _, id := range sortedKeys(current.nodes) {
+		if prev, ok := sent.nodes[id]; !ok || !sameNode(prev, current.nodes[id]) {
+			update.Nodes = append(update.Nodes, current.nodes[id])
+		}
+	}
+	for _, id := range sortedKeys(sent.nodes) {
+		if _, ok := current.nodes[id]; !ok {
+			update.RemovedNodeIds = append(update.RemovedNodeIds, id)
+		}
+	}
+	for _, id := range sortedKeys(current.models) {
+		if prev, ok := sent.models[id]; !ok || !proto.Equal(prev, current.models[id]) {
+			update.Models = append(update.Models, current.models[id])
+		}
+	}
+	for _, id := range sortedKeys(sent.models) {
+		if _, ok := current.models[id]; !ok {
+			update.RemovedModelIds = append(update.RemovedModelIds, id)
+		}
+	}
+
+	seen := current
+	if metricsChanged(sent.metrics, current.metrics, threshold) {
+		update.Metrics = current.metrics
+	} else {
+		seen.metrics = sent.metrics
+	}
+
+	if len(update.Nodes) == 0 && len(update.RemovedNodeIds) == 0 &&
+		len(update.Models) == 0 && len(update.RemovedModelIds) == 0 && update.Metrics == nil {
+		return nil, seen
+	}
+	return update, seen
+}
+
+// sameNode compares nodes ignoring when they were last seen, which
+// changes on every gossip round
+func sameNode(a, b *pb.NodeInfo) bool {
+	a, b = proto.Clone(a).(*pb.NodeInfo), proto.Clone(b).(*pb.NodeInfo)
+	a.LastSeen, b.LastSeen = 0, 0
+	return proto.Equal(a, b)
+}
+
+// metricsChanged reports whether any cluster metric changed by more than
+// threshold relative to its previous value
+func metricsChanged(prev, cur *pb.ClusterMetrics, threshold float64) bool {
+	if cur == nil {
+		return false
+	}
+	if prev == nil {
+		return true
+	}
+	pairs := [][2]float64{
+		{float64(prev.TotalNodes), float64(cur.TotalNodes)},
+		{float64(prev.HealthyNodes), float64(cur.HealthyNodes)},
+		{float64(prev.TotalMemoryMb), float64(cur.TotalMemoryMb)},
+		{float64(prev.AvailableMemoryMb), float64(cur.AvailableMemoryMb)},
+		{float64(prev.TotalGpus), float64(cur.TotalGpus)},
+		{float64(prev.TotalLayers), float64(cur.TotalLayers)},
+		{float64(prev.AllocatedLayers), float64(cur.AllocatedLayers)},
+		{float64(prev.ClusterUtilization), float64(cur.ClusterUtilization)},
+	}
+	for _, p := range pairs {
+		if diff := math.Abs(p[1] - p[0]); diff > 0 && diff > threshold*math.Max(math.Abs(p[0]), math.Abs(p[1])) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// StreamUpdates sends a snapshot of the requested sections of the cluster,
+// then a delta whenever they change. Changes published on the event bus are
+// sent right away, coalesced over a short window; resources and metrics are
+// checked every interval. Deltas are computed against what the subscriber
+// was last sent, so one that falls behind gets fewer, larger deltas rather
+// than a backlog.
+func (t *TUIServer) StreamUpdates(req *pb.UpdateStreamRequest, stream pb.TUIService_StreamUpdatesServer) error {
+	types := req.UpdateTypes
+	if len(types) == 0 {
+		types = updateTypes
+	}
+	for _, updateType := range types {
+		if !slices.Contains(updateTypes, updateType) {
+			return status.Errorf(codes.InvalidArgument, "unknown update type %q", updateType)
+		}
+	}
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if interval < time.Second {
+		interval = defaultUpdateInterval
+	}
+	threshold := req.MetricsThreshold
+	if threshold <= 0 {
+		threshold = defaultMetricsThreshold
+	}
+
+	// Events only mark the view dirty; the flag holds at most one pending
+	// change however many events arrive while an update is being sent
+	changed := make(chan struct{}, 1)
+	if t.events != nil {
+		if sub, err := t.events.Subscribe(t.events.Latest()); err == nil {
+			defer sub.Close()
+			go func() {
+				for range sub.Events() {
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				}
+			}()
+		}
+	}
+
+	ctx := stream.Context()
+	var sequence uint64
+	send := func(update *pb.ClusterUpdate) error {
+		sequence++
+		update.Sequence = sequence
+		update.Timestamp = time.Now().Unix()
+		return stream.Send(update)
+	}
+
+	sent := t.view(ctx, req, types)
+	if err := send(sent.snapshot()); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-changed:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(updateCoalesceDelay):
+			}
+			select {
+			case <-changed:
+			default:
+			}
+		}
+
+		update, seen := diffViews(sent, t.view(ctx, req, types), threshold)
+		if update == nil {
+			sent = seen
+			continue
+		}
+		if err := send(update); err != nil {
+			return err
+		}
+		sent = seen
+	}
+}
@@ -0,0 +1,157 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/events"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+func TestDiffViews(t *testing.T) {
+	node := func(id, status string, lastSeen int64) *pb.NodeInfo {
+		return &pb.NodeInfo{NodeId: id, Status: status, LastSeen: lastSeen}
+	}
+	metrics := func(healthy int32, utilization float32) *pb.ClusterMetrics {
+		return &pb.ClusterMetrics{TotalNodes: 10, HealthyNodes: healthy, ClusterUtilization: utilization}
+	}
+	sent := clusterView{
+		nodes:   map[string]*pb.NodeInfo{"node-a": node("node-a", "online", 1), "node-b": node("node-b", "online", 1)},
+		models:  map[string]*pb.ModelInfo{"llama-7b": {Id: "llama-7b", Replicas: 1}},
+		metrics: metrics(10, 0.50),
+	}
+
+	tests := []struct {
+		name        string
+		current     clusterView
+		wantNodes   []string
+		wantRemoved []string
+		wantModels  []string
+		wantMetrics bool
+	}{
+		{"unchanged", sent, nil, nil, nil, false},
+		{
+			"last seen only",
+			clusterView{nodes: map[string]*pb.NodeInfo{"node-a": node("node-a", "online", 2), "node-b": node("node-b", "online", 3)}, models: sent.models, metrics: sent.metrics},
+			nil, nil, nil, false,
+		},
+		{
+			"node changed, added and removed",
+			clusterView{nodes: map[string]*pb.NodeInfo{"node-a": node("node-a", "busy", 1), "node-c": node("node-c", "online", 1)}, models: sent.models, metrics: sent.metrics},
+			[]string{"node-a", "node-c"}, []string{"node-b"}, nil, false,
+		},
+		{
+			"model scaled",
+			clusterView{nodes: sent.nodes, models: map[string]*pb.ModelInfo{"llama-7b": {Id: "llama-7b", Replicas: 2}}, metrics: sent.metrics},
+			nil, nil, []string{"llama-7b"}, false,
+		},
+		{"metrics below threshold", clusterView{nodes: sent.nodes, models: sent.models, metrics: metrics(10, 0.51)}, nil, nil, nil, false},
+		{"metrics beyond threshold", clusterView{nodes: sent.nodes, models: sent.models, metrics: metrics(9, 0.50)}, nil, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, seen := diffViews(sent, tt.current, defaultMetricsThreshold)
+			if update == nil {
+				if tt.wantNodes != nil || tt.wantRemoved != nil || tt.wantModels != nil || tt.wantMetrics {
+					t.Fatal("Expected a delta, got none")
+				}
+				if seen.metrics != sent.metrics {
+					t.Error("Expected metrics below the threshold to be held back")
+				}
+				return
+			}
+			var nodes, modelIDs []string
+			for _, n := range update.Nodes {
+				nodes = append(nodes, n.NodeId)
+			}
+			for _, m := range update.Models {
+				modelIDs = append(modelIDs, m.Id)
+			}
+			if update.UpdateType != "delta" || !slices.Equal(nodes, tt.wantNodes) || !slices.Equal(update.RemovedNodeIds, tt.wantRemoved) ||
+				!slices.Equal(modelIDs, tt.wantModels) || (update.Metrics != nil) != tt.wantMetrics {
+				t.Errorf("Unexpected delta %v", update)
+			}
+		})
+	}
+}
+
+func TestMetricsDriftAddsUp(t *testing.T) {
+	sent := clusterView{metrics: &pb.ClusterMetrics{ClusterUtilization: 0.50}}
+	for _, utilization := range []float32{0.51, 0.52} {
+		update, seen := diffViews(sent, clusterView{metrics: &pb.ClusterMetrics{ClusterUtilization: utilization}}, defaultMetricsThreshold)
+		if update != nil {
+			t.Fatalf("Expected %v to be held back, got %v", utilization, update)
+		}
+		sent = seen
+	}
+	update, _ := diffViews(sent, clusterView{metrics: &pb.ClusterMetrics{ClusterUtilization: 0.53}}, defaultMetricsThreshold)
+	if update == nil || update.Metrics.ClusterUtilization != 0.53 {
+		t.Errorf("Expected the accumulated drift to be sent, got %v", update)
+	}
+}
+
+func TestStreamUpdates(t *testing.T) {
+	network, server, port := newTracedNode(t, "node-a", nil)
+	bus := events.NewBus(0)
+	server.SetEventBus(bus)
+	registerNode(network, models.Node{ID: "node-b", Address: "127.0.0.1", Port: 9000})
+
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// The interval is far longer than the test, so deltas come from events
+	stream, err := pb.NewTUIServiceClient(conn).StreamUpdates(ctx, &pb.UpdateStreamRequest{UpdateTypes: []string{"nodes"}, IntervalSeconds: 60})
+	if err != nil {
+		t.Fatalf("StreamUpdates failed: %v", err)
+	}
+	snapshot, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if snapshot.UpdateType != "snapshot" || snapshot.Sequence != 1 || !hasNode(snapshot.Nodes, "node-b") || snapshot.Models != nil {
+		t.Fatalf("Expected a snapshot of the nodes, got %v", snapshot)
+	}
+
+	// A burst of changes is coalesced into one delta
+	registerNode(network, models.Node{ID: "node-c", Address: "127.0.0.1", Port: 9001})
+	network.mu.Lock()
+	delete(network.registered, "node-b")
+	network.mu.Unlock()
+	bus.Publish(models.Event{Type: models.EventNodeJoined, NodeID: "node-c"})
+	bus.Publish(models.Event{Type: models.EventNodeLeft, NodeID: "node-b"})
+
+	delta, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if delta.UpdateType != "delta" || delta.Sequence != 2 || len(delta.Nodes) != 1 || delta.Nodes[0].NodeId != "node-c" ||
+		!slices.Equal(delta.RemovedNodeIds, []string{"node-b"}) {
+		t.Errorf("Expected node-c added and node-b removed, got %v", delta)
+	}
+}
+
+func TestStreamUpdatesUnknownType(t *testing.T) {
+	server := NewTUIServer(newTestNetwork(t, "node-a"), nil)
+	err := server.StreamUpdates(&pb.UpdateStreamRequest{UpdateTypes: []string{"gpus"}}, nil)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func hasNode(nodes []*pb.NodeInfo, id string) bool {
+	return slices.ContainsFunc(nodes, func(n *pb.NodeInfo) bool { return n.NodeId == id })
+}
@@ -0,0 +1,175 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/usage"
+	pb "distributed-llm/proto"
+)
+
+// UsageLedger records the usage of the requests entering the cluster
+// through this node (*usage.Ledger)
+type UsageLedger interface {
+	Append(r usage.Record) error
+	Query(f usage.Filter) ([]usage.Record, error)
+	Scan(f usage.Filter, fn func(*usage.Record)) error
+}
+
+// SetUsageLedger records the usage of requests received by this node and
+// serves it through GetUsage
+func (g *GRPCServer) SetUsageLedger(ledger UsageLedger) {
+	g.nodeServer.ledger = ledger
+}
+
+// stageUsage accumulates what each node spends on a request, in the order
+// the nodes joined in
+type stageUsage []*pb.StageUsage
+
+func (u *stageUsage) node(nodeID string) *pb.StageUsage {
+	for _, stage := range *u {
+		if stage.NodeId == nodeID {
+			return stage
+		}
+	}
+	stage := &pb.StageUsage{NodeId: nodeID}
+	*u = append(*u, stage)
+	return stage
+}
+
+// recordUsage adds a request received by this node to the ledger
+func (s *NodeServer) recordUsage(ctx context.Context, req *pb.InferenceRequest, start time.Time, resp *pb.InferenceResponse, err error) {
+	if s.ledger == nil {
+		return
+	}
+	record := usage.Record{
+		Time:      start,
+		RequestID: RequestIDFromContext(ctx),
+		Tenant:    TenantFromContext(ctx),
+		ModelID:   req.ModelId,
+		Duration:  time.Since(start),
+		Success:   err == nil && resp.Success,
+	}
+	if resp != nil {
+		record.PromptTokens = int(resp.PromptTokens)
+		record.CompletionTokens = int(resp.TokensGenerated)
+		record.Nodes = nodeUsageFromProto(resp.Stages)
+	}
+	if err := s.ledger.Append(record); err != nil {
+		s.network.logger.Warn("Failed to record usage", "requestID", record.RequestID, "error", err)
+	}
+}
+
+// GetUsage answers from the usage ledger of the requests received by this node
+func (s *NodeServer) GetUsage(ctx context.Context, req *pb.UsageRequest) (*pb.UsageResponse, error) {
+	if s.ledger == nil {
+		return nil, status.Error(codes.FailedPrecondition, "usage ledger is not enabled on this node")
+	}
+	filter := usage.Filter{
+		Tenant:  req.Tenant,
+		ModelID: req.ModelId,
+		Limit:   int(req.Limit),
+	}
+	if req.SinceUnixMs > 0 {
+		filter.Since = time.UnixMilli(req.SinceUnixMs)
+	}
+	if req.UntilUnixMs > 0 {
+		filter.Until = time.UnixMilli(req.UntilUnixMs)
+	}
+
+	// Summaries cover every matching record, however many are returned.
+	// Exports without a limit hold every matching record too, written as
+	// the ledger is scanned.
+	var summarizer usage.Summarizer
+	var export bytes.Buffer
+	var exporter *usage.Exporter
+	scan := summarizer.Add
+	if req.Format != "" && filter.Limit == 0 {
+		var err error
+		if exporter, err = usage.NewExporter(&export, req.Format); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		scan = func(r *usage.Record) {
+			summarizer.Add(r)
+			exporter.Add(r)
+		}
+	}
+	if err := s.ledger.Scan(filter, scan); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read usage ledger: %v", err)
+	}
+	resp := &pb.UsageResponse{}
+	for _, summary := range summarizer.Summaries() {
+		resp.Summaries = append(resp.Summaries, &pb.UsageSummary{
+			Tenant:           summary.Tenant,
+			ModelId:          summary.ModelID,
+			Requests:         summary.Requests,
+			Failed:           summary.Failed,
+			PromptTokens:     summary.PromptTokens,
+			CompletionTokens: summary.CompletionTokens,
+			ComputeMicros:    summary.ComputeTime.Microseconds(),
+			BytesMoved:       summary.BytesMoved,
+		})
+	}
+	if exporter != nil {
+		if err := exporter.Close(); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to export usage: %v", err)
+		}
+		resp.Export = export.Bytes()
+		return resp, nil
+	}
+
+	records, err := s.ledger.Query(filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read usage ledger: %v", err)
+	}
+	if req.Format != "" {
+		if err := usage.Export(&export, req.Format, records); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		resp.Export = export.Bytes()
+		return resp, nil
+	}
+	for i := range records {
+		resp.Records = append(resp.Records, usageRecordToProto(&records[i]))
+	}
+	return resp, nil
+}
+
+func usageRecordToProto(r *usage.Record) *pb.UsageRecord {
+	record := &pb.UsageRecord{
+		TimestampUnixMs:  r.Time.UnixMilli(),
+		RequestId:        r.RequestID,
+		Tenant:           r.Tenant,
+		ModelId:          r.ModelID,
+		PromptTokens:     int32(r.PromptTokens),
+		CompletionTokens: int32(r.CompletionTokens),
+		DurationMicros:   r.Duration.Microseconds(),
+		Success:          r.Success,
+	}
+	for _, node := range r.Nodes {
+		record.Nodes = append(record.Nodes, &pb.StageUsage{
+			NodeId:        node.NodeID,
+			ComputeMicros: node.ComputeTime.Microseconds(),
+			BytesReceived: node.BytesReceived,
+			BytesSent:     node.BytesSent,
+		})
+	}
+	return record
+}
+
+func nodeUsageFromProto(stages []*pb.StageUsage) []usage.NodeUsage {
+	nodes := make([]usage.NodeUsage, len(stages))
+	for i, stage := range stages {
+		nodes[i] = usage.NodeUsage{
+			NodeID:        stage.NodeId,
+			ComputeTime:   time.Duration(stage.ComputeMicros) * time.Microsecond,
+			BytesReceived: stage.BytesReceived,
+			BytesSent:     stage.BytesSent,
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,240 @@
+package network
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/router"
+	"distributed-llm/internal/usage"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+func TestUsageLedgerRecordsGatewayRequests(t *testing.T) {
+	// node-a is the gateway; the model's only replica runs on node-b then node-c
+	_, _, portC := newTracedNode(t, "node-c", nil)
+	networkB, _, portB := newTracedNode(t, "node-b", nil)
+	networkA, serverA, portA := newTracedNode(t, "node-a", nil)
+	for _, network := range []*P2PNetwork{networkA, networkB} {
+		registerNode(network, models.Node{ID: "node-b", Address: "127.0.0.1", Port: portB, Features: SupportedFeatures()})
+		registerNode(network, models.Node{ID: "node-c", Address: "127.0.0.1", Port: portC, Features: SupportedFeatures()})
+	}
+	registerNode(networkB, models.Node{ID: "node-a", Address: "127.0.0.1", Port: portA, Features: SupportedFeatures()})
+	rt := router.New("node-a", router.LeastOutstanding)
+	rt.Update([]models.PlacementPlan{{ModelID: "llama-7b", Stages: []models.PipelineStage{
+		{NodeID: "node-b", LayerStart: 0, LayerEnd: 15},
+		{NodeID: "node-c", LayerStart: 16, LayerEnd: 31},
+	}}})
+	serverA.SetRouter(rt)
+	ledger, err := usage.Open(usage.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to open ledger: %v", err)
+	}
+	defer ledger.Close()
+	serverA.SetUsageLedger(ledger)
+	serverA.SetTenantKeys(map[string]string{"acme-key": "acme", "globex-key": "globex"})
+
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", portA), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewNodeServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadataKey, "acme-key", RequestIDMetadataKey, "req-acme")
+	resp, err := client.ProcessInference(ctx, &pb.InferenceRequest{ModelId: "llama-7b", Prompt: "tell me a story"})
+	if err != nil || !resp.Success {
+		t.Fatalf("ProcessInference failed: %+v (%v)", resp, err)
+	}
+	if _, err := client.ProcessInference(context.Background(), &pb.InferenceRequest{Prompt: "hi"}); err != nil {
+		t.Fatalf("ProcessInference failed: %v", err)
+	}
+	// Clients naming their own pipeline still enter through the gateway
+	ctx = metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadataKey, "globex-key")
+	pipeline := &pb.InferenceRequest{ModelId: "llama-7b", Prompt: "hi", LayerAssignments: []string{"node-b", "node-c"}}
+	if _, err := client.ProcessInference(ctx, pipeline); err != nil {
+		t.Fatalf("ProcessInference failed: %v", err)
+	}
+
+	records, err := ledger.Query(usage.Filter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a record per gateway request, got %+v", records)
+	}
+	r := records[0]
+	if r.RequestID != "req-acme" || r.Tenant != "acme" || r.ModelID != "llama-7b" || !r.Success {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	if r.PromptTokens != 4 || r.CompletionTokens != 4 {
+		t.Errorf("Expected 4 prompt and 4 completion tokens, got %d and %d", r.PromptTokens, r.CompletionTokens)
+	}
+	var nodes []string
+	var sent, received int64
+	for _, node := range r.Nodes {
+		nodes = append(nodes, node.NodeID)
+		if node.BytesSent == 0 || node.BytesReceived == 0 {
+			t.Errorf("Expected %s to exchange bytes, got %+v", node.NodeID, node)
+		}
+		sent += node.BytesSent
+		received += node.BytesReceived
+	}
+	if strings.Join(nodes, ",") != "node-b,node-c,node-a" {
+		t.Errorf("Expected the pipeline then the gateway, got %v", nodes)
+	}
+	if sent != received {
+		t.Errorf("Expected every byte sent to be received, got %d sent and %d received", sent, received)
+	}
+	if r.BytesMoved() != sent {
+		t.Errorf("Expected %d bytes moved, got %d", sent, r.BytesMoved())
+	}
+
+	// Requests without an API key are charged to the default tenant and served
+	// by the gateway alone
+	if r := records[1]; r.Tenant != usage.DefaultTenant || len(r.Nodes) != 1 || r.Nodes[0].NodeID != "node-a" {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	if r := records[2]; r.Tenant != "globex" || r.ModelID != "llama-7b" {
+		t.Errorf("Expected the client's pipeline to be charged, got %+v", r)
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	ledger, err := usage.Open(usage.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to open ledger: %v", err)
+	}
+	defer ledger.Close()
+	nodeServer := &NodeServer{network: network, ledger: ledger}
+
+	for _, tenant := range []string{"acme", "globex", "acme"} {
+		if _, err := nodeServer.ProcessInference(withTenant(context.Background(), tenant), &pb.InferenceRequest{ModelId: "llama-7b", Prompt: "hi"}); err != nil {
+			t.Fatalf("ProcessInference failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		req         *pb.UsageRequest
+		wantRecords int
+		wantCode    codes.Code
+	}{
+		{"everything", &pb.UsageRequest{}, 3, codes.OK},
+		{"by tenant", &pb.UsageRequest{Tenant: "acme"}, 2, codes.OK},
+		{"newest", &pb.UsageRequest{Limit: 1}, 1, codes.OK},
+		{"other model", &pb.UsageRequest{ModelId: "mistral-7b"}, 0, codes.OK},
+		{"unknown format", &pb.UsageRequest{Format: "xml"}, 0, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := nodeServer.GetUsage(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Expected %v, got %v", tt.wantCode, err)
+			}
+			if err == nil && len(resp.Records) != tt.wantRecords {
+				t.Errorf("Expected %d records, got %d", tt.wantRecords, len(resp.Records))
+			}
+		})
+	}
+
+	resp, err := nodeServer.GetUsage(context.Background(), &pb.UsageRequest{Tenant: "acme", Format: usage.FormatCSV})
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if len(resp.Records) != 0 {
+		t.Errorf("Expected the records in the export only, got %d records", len(resp.Records))
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(resp.Export))).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Errorf("Expected a header and 2 rows, got %q (%v)", resp.Export, err)
+	}
+	if len(resp.Summaries) != 1 || resp.Summaries[0].Tenant != "acme" || resp.Summaries[0].Requests != 2 || resp.Summaries[0].CompletionTokens != 8 {
+		t.Errorf("Unexpected summaries %+v", resp.Summaries)
+	}
+}
+
+func TestGetUsageExportsEveryRecord(t *testing.T) {
+	ledger, err := usage.Open(usage.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to open ledger: %v", err)
+	}
+	defer ledger.Close()
+	nodeServer := &NodeServer{ledger: ledger}
+
+	const total = usage.MaxQueryRecords + 5
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < total; i++ {
+		record := usage.Record{Time: start.Add(time.Duration(i) * time.Millisecond), RequestID: fmt.Sprintf("req-%d", i), Tenant: "acme", ModelID: "llama-7b", Success: true}
+		if err := ledger.Append(record); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		req    *pb.UsageRequest
+		want   int
+		oldest string
+	}{
+		{"csv", &pb.UsageRequest{Format: usage.FormatCSV}, total, "req-0"},
+		{"json", &pb.UsageRequest{Format: usage.FormatJSON}, total, "req-0"},
+		{"newest", &pb.UsageRequest{Format: usage.FormatJSON, Limit: 2}, 2, fmt.Sprintf("req-%d", total-2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := nodeServer.GetUsage(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("GetUsage failed: %v", err)
+			}
+			var ids []string
+			if tt.req.Format == usage.FormatCSV {
+				rows, err := csv.NewReader(strings.NewReader(string(resp.Export))).ReadAll()
+				if err != nil {
+					t.Fatalf("Invalid CSV: %v", err)
+				}
+				for _, row := range rows[1:] {
+					ids = append(ids, row[1])
+				}
+			} else {
+				var records []usage.Record
+				if err := json.Unmarshal(resp.Export, &records); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				for _, r := range records {
+					ids = append(ids, r.RequestID)
+				}
+			}
+			if len(ids) != tt.want || ids[0] != tt.oldest {
+				t.Errorf("Expected %d records from %s, got %d from %v", tt.want, tt.oldest, len(ids), ids[:min(len(ids), 1)])
+			}
+			if len(resp.Summaries) != 1 || resp.Summaries[0].Requests != total {
+				t.Errorf("Expected summaries of %d requests, got %+v", total, resp.Summaries)
+			}
+		})
+	}
+}
+
+func TestGetUsageWithoutLedger(t *testing.T) {
+	network := newTestNetwork(t, "node-a")
+	t.Cleanup(network.Stop)
+	nodeServer := &NodeServer{network: network}
+
+	if _, err := nodeServer.GetUsage(context.Background(), &pb.UsageRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+}
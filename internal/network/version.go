@@ -0,0 +1,194 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/memberlist"
+
+	"distributed-llm/internal/compress"
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+const (
+	// ProtocolVersion is the newest wire protocol spoken by this build
+	ProtocolVersion = 1
+
+	// MinProtocolVersion is the oldest wire protocol this build still speaks.
+	// Raise ProtocolVersion first and MinProtocolVersion one release later so
+	// that every rolling upgrade has a release that speaks both.
+	MinProtocolVersion = 1
+)
+
+// Optional capabilities advertised to peers
+const (
+	FeatureReplicatedState  = "replicated-state"
+	FeatureGRPCJoin         = "grpc-join"
+	FeatureActivationStream = "activation-stream"
+)
+
+// SupportedFeatures returns the optional capabilities of this build,
+// including the compressors it accepts
+func SupportedFeatures() []string {
+	features := []string{FeatureReplicatedState, FeatureGRPCJoin, FeatureActivationStream}
+	return append(features, compress.Features()...)
+}
+
+// nodeMeta is the memberlist node metadata, kept short to fit in memberlist.MetaMaxSize
+type nodeMeta struct {
+	Version     string            `json:"v,omitempty"`
+	Protocol    int32             `json:"p"`
+	MinProtocol int32             `json:"mp"`
+	Features    []string          `json:"f,omitempty"`
+	GRPCPort    int               `json:"g,omitempty"`
+	Labels      map[string]string `json:"l,omitempty"`
+}
+
+// SetVersion sets the build version advertised to peers. It must be called
+// before Start to be included in the initial gossip metadata.
+func (n *P2PNetwork) SetVersion(version string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.version = version
+}
+
+// Version returns the build version advertised by this node
+func (n *P2PNetwork) Version() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.version
+}
+
+// SetLabels sets the topology and other labels advertised to peers. Like
+// SetVersion it must be called before Start.
+func (n *P2PNetwork) SetLabels(labels map[string]string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.labels = labels
+}
+
+// Labels returns the labels advertised by this node
+func (n *P2PNetwork) Labels() map[string]string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.labels
+}
+
+func (n *P2PNetwork) localMeta() nodeMeta {
+	return nodeMeta{
+		Version:     n.Version(),
+		Protocol:    ProtocolVersion,
+		MinProtocol: MinProtocolVersion,
+		Features:    SupportedFeatures(),
+		GRPCPort:    n.bindPort,
+		Labels:      n.Labels(),
+	}
+}
+
+// localNode describes this node's version for compatibility checks
+func (n *P2PNetwork) localNode() models.Node {
+	node := models.Node{ID: n.nodeID}
+	n.localMeta().applyTo(&node)
+	return node
+}
+
+func (m nodeMeta) applyTo(node *models.Node) {
+	node.Version = m.Version
+	node.ProtocolVersion = m.Protocol
+	node.MinProtocolVersion = m.MinProtocol
+	node.Features = m.Features
+	node.Labels = m.Labels
+	if m.GRPCPort > 0 {
+		node.Port = m.GRPCPort
+	}
+}
+
+// encodeMeta serializes metadata within limit bytes. If it doesn't fit the
+// feature list is dropped first, then every label but the topology ones,
+// then the labels altogether.
+func encodeMeta(meta nodeMeta, limit int) []byte {
+	data, err := json.Marshal(meta)
+	if err == nil && len(data) <= limit {
+		return data
+	}
+
+	meta.Features = nil
+	trims := []func(){
+		func() {},
+		func() { meta.Labels = topologyLabels(meta.Labels) },
+		func() { meta.Labels = nil },
+	}
+	for _, trim := range trims {
+		trim()
+		data, err = json.Marshal(meta)
+		if err == nil && len(data) <= limit {
+			return data
+		}
+	}
+	return nil
+}
+
+// topologyLabels returns the zone, rack and host labels
+func topologyLabels(labels map[string]string) map[string]string {
+	topology := make(map[string]string)
+	for _, key := range []string{models.LabelZone, models.LabelRack, models.LabelHost} {
+		if value, ok := labels[key]; ok {
+			topology[key] = value
+		}
+	}
+	return topology
+}
+
+// decodeMeta parses a member's metadata. Members running builds without
+// version negotiation advertise nothing.
+func decodeMeta(buf []byte) (nodeMeta, bool) {
+	var meta nodeMeta
+	if len(buf) == 0 || json.Unmarshal(buf, &meta) != nil {
+		return nodeMeta{}, false
+	}
+	return meta, true
+}
+
+// memberNode describes a memberlist member, including its advertised version
+func memberNode(member *memberlist.Node) models.Node {
+	node := models.Node{
+		ID:      member.Name,
+		Address: member.Addr.String(),
+		Port:    int(member.Port),
+		Status:  models.NodeStatusOnline,
+	}
+	if meta, ok := decodeMeta(member.Meta); ok {
+		meta.applyTo(&node)
+	}
+	return node
+}
+
+// checkCompatibility warns when a member joins that cannot share pipelines with us
+func (n *P2PNetwork) checkCompatibility(member *memberlist.Node) {
+	remote := memberNode(member)
+	local := n.localNode()
+	if local.CompatibleWith(&remote) {
+		return
+	}
+
+	n.logger.Warn("Incompatible node joined, it will not be placed in pipelines with this node",
+		"node", member.Name,
+		"version", remote.Version,
+		"protocolVersion", remote.ProtocolVersion,
+		"localProtocolVersion", ProtocolVersion)
+	if n.metricsCollector != nil {
+		n.metricsCollector.RecordNetworkMessage("incoming", "join_incompatible")
+	}
+}
+
+func (s *NodeServer) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.GetVersionResponse, error) {
+	meta := s.network.localMeta()
+	return &pb.GetVersionResponse{
+		NodeId:             s.network.nodeID,
+		Version:            meta.Version,
+		ProtocolVersion:    meta.Protocol,
+		MinProtocolVersion: meta.MinProtocol,
+		Features:           meta.Features,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	pb "distributed-llm/proto"
+)
+
+func TestEncodeMeta(t *testing.T) {
+	meta := nodeMeta{Version: "abc123", Protocol: 2, MinProtocol: 1, Features: SupportedFeatures()}
+
+	decoded, ok := decodeMeta(encodeMeta(meta, 512))
+	if !ok {
+		t.Fatal("Failed to decode metadata")
+	}
+	if decoded.Version != "abc123" || decoded.Protocol != 2 || decoded.MinProtocol != 1 || len(decoded.Features) != len(meta.Features) {
+		t.Errorf("Round trip mismatch: %+v", decoded)
+	}
+
+	// Features are dropped before the version when space runs out
+	meta.Features = []string{strings.Repeat("x", 100)}
+	decoded, ok = decodeMeta(encodeMeta(meta, 50))
+	if !ok || decoded.Version != "abc123" || len(decoded.Features) != 0 {
+		t.Errorf("Expected truncated metadata without features, got %+v", decoded)
+	}
+
+	// Topology labels outlast the others
+	meta.Labels = map[string]string{"zone": "eu-1a", "team": strings.Repeat("y", 100)}
+	decoded, ok = decodeMeta(encodeMeta(meta, 80))
+	if !ok || decoded.Labels["zone"] != "eu-1a" || len(decoded.Labels) != 1 {
+		t.Errorf("Expected only the zone label to be kept, got %+v", decoded)
+	}
+	decoded, ok = decodeMeta(encodeMeta(meta, 40))
+	if !ok || decoded.Version != "abc123" || len(decoded.Labels) != 0 {
+		t.Errorf("Expected labels to be dropped before the version, got %+v", decoded)
+	}
+
+	if encodeMeta(meta, 5) != nil {
+		t.Error("Expected nil when metadata cannot fit")
+	}
+	if _, ok := decodeMeta(nil); ok {
+		t.Error("Expected empty metadata from legacy nodes to be reported as missing")
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	network := newTestNetwork(t, "test-node")
+	network.SetVersion("abc123")
+	server := &NodeServer{network: network}
+
+	resp, err := server.GetVersion(context.Background(), &pb.GetVersionRequest{})
+	if err != nil {
+		t.Fatalf("GetVersion failed: %v", err)
+	}
+	if resp.NodeId != "test-node" || resp.Version != "abc123" {
+		t.Errorf("Unexpected version response: %+v", resp)
+	}
+	if resp.ProtocolVersion != ProtocolVersion || resp.MinProtocolVersion != MinProtocolVersion {
+		t.Errorf("Expected protocol range %d-%d, got %d-%d", MinProtocolVersion, ProtocolVersion, resp.MinProtocolVersion, resp.ProtocolVersion)
+	}
+}
+
+func TestVersionGossipedInMetadata(t *testing.T) {
+	nodeA, nodeB := newTestNetwork(t, "node-a"), newTestNetwork(t, "node-b")
+	nodeA.SetVersion("v1")
+	nodeB.SetVersion("v2")
+	nodeB.SetLabels(map[string]string{"zone": "eu-1b"})
+
+	if err := nodeA.Start(nil); err != nil {
+		t.Fatalf("Failed to start node-a: %v", err)
+	}
+	t.Cleanup(nodeA.Stop)
+	if err := nodeB.Start([]string{fmt.Sprintf("127.0.0.1:%d", nodeA.gossipPort)}); err != nil {
+		t.Fatalf("Failed to start node-b: %v", err)
+	}
+	t.Cleanup(nodeB.Stop)
+
+	eventually(t, func() bool { return len(nodeA.GetMembers()) == 2 })
+	versions := make(map[string]string)
+	zones := make(map[string]string)
+	for _, node := range nodeA.GetNodes() {
+		versions[node.ID] = node.Version
+		zones[node.ID] = node.Zone()
+		if node.ProtocolVersion != ProtocolVersion || !node.HasFeature(FeatureReplicatedState) {
+			t.Errorf("Expected %s to advertise protocol and features, got %+v", node.ID, node)
+		}
+	}
+	if versions["node-a"] != "v1" || versions["node-b"] != "v2" {
+		t.Errorf("Expected gossiped versions, got %v", versions)
+	}
+	if zones["node-a"] != "" || zones["node-b"] != "eu-1b" {
+		t.Errorf("Expected gossiped zones, got %v", zones)
+	}
+}
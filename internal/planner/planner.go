@@ -0,0 +1,489 @@
+// Package planner splits a model's layers into pipeline stages across cluster nodes.
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+var (
+	// ErrInsufficientCapacity means the candidate nodes can't hold every layer
+	ErrInsufficientCapacity = errors.New("insufficient layer capacity")
+	// ErrIncompatibleVersions means a pipeline would mix nodes without a common protocol
+	ErrIncompatibleVersions = errors.New("incompatible node versions")
+	// ErrTopologyConstraint means the nodes have capacity, but not where the
+	// placement constraints allow
+	ErrTopologyConstraint = errors.New("placement constraints not satisfied")
+)
+
+// unknownHopLatency stands in for pairs of nodes with no RTT estimate, so
+// that measured paths are preferred
+const unknownHopLatency = time.Second
+
+// maxExhaustiveStages is the largest pipeline whose stage orders are all tried;
+// longer pipelines are ordered greedily
+const maxExhaustiveStages = 8
+
+// LatencySource reports the round trip time between two nodes
+type LatencySource interface {
+	RTT(from, to string) (time.Duration, bool)
+}
+
+// Constraints restrict where pipelines are placed, using the nodes' topology labels
+type Constraints struct {
+	// SameZone keeps every stage of a pipeline within one zone. Nodes without
+	// a zone label form a zone of their own.
+	SameZone bool
+	// SpreadZones places each replica of a model in zones no other replica
+	// uses, when there are enough of them
+	SpreadZones bool
+	// SpreadHosts never places two replicas of a model on the same host
+	SpreadHosts bool
+}
+
+// Planner places models on nodes
+type Planner struct {
+	latency     LatencySource
+	constraints Constraints
+}
+
+// New creates a planner
+func New() *Planner {
+	return &Planner{}
+}
+
+// SetLatencySource makes the planner order pipeline stages, and choose
+// between otherwise equal sets of nodes, to minimize the total latency of
+// the hops between consecutive stages
+func (p *Planner) SetLatencySource(source LatencySource) {
+	p.latency = source
+}
+
+// SetConstraints sets the topology constraints applied to every plan
+func (p *Planner) SetConstraints(constraints Constraints) {
+	p.constraints = constraints
+}
+
+// candidateSet is a group of nodes that may share a pipeline
+type candidateSet struct {
+	nodes    []models.Node
+	capacity int32
+	// homogeneous sets run a single build version
+	homogeneous bool
+	label       string
+}
+
+// Plan assigns the model's layers to nodes. Nodes running the same build
+// version are preferred so that pipelines stay homogeneous during a rolling
+// upgrade; a mixed pipeline is only formed, from nodes sharing a protocol
+// version, when no single version has enough free layers.
+func (p *Planner) Plan(model models.Model, nodes []models.Node) (models.PlacementPlan, error) {
+	if model.LayerCount <= 0 {
+		return models.PlacementPlan{}, fmt.Errorf("model %s has no layers", model.ID)
+	}
+
+	eligible := eligibleNodes(nodes)
+	sets := candidateSets(eligible)
+	if p.constraints.SameZone {
+		sets = splitByZone(sets)
+	}
+	var best *candidateSet
+	for _, set := range sets {
+		if set.capacity < model.LayerCount {
+			continue
+		}
+		if best == nil || p.betterSet(set, best, model.LayerCount) {
+			best = set
+		}
+	}
+
+	if best == nil {
+		if p.constraints.SameZone && fitsAnySet(candidateSets(eligible), model.LayerCount) {
+			return models.PlacementPlan{}, fmt.Errorf("%w: no single zone has %d compatible free layers for %s",
+				ErrTopologyConstraint, model.LayerCount, model.ID)
+		}
+		if totalCapacity(eligible) >= model.LayerCount {
+			return models.PlacementPlan{}, fmt.Errorf("%w: no protocol-compatible set of nodes can hold %d layers of %s",
+				ErrIncompatibleVersions, model.LayerCount, model.ID)
+		}
+		return models.PlacementPlan{}, fmt.Errorf("%w: %s needs %d layers, %d free",
+			ErrInsufficientCapacity, model.ID, model.LayerCount, totalCapacity(eligible))
+	}
+
+	return models.PlacementPlan{
+		ModelID:   model.ID,
+		Stages:    p.assignLayers(best.nodes, model.LayerCount),
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// PlanReplicas places count more pipelines of the model alongside the
+// existing replicas, each on nodes no other replica uses, honouring
+// SpreadZones and SpreadHosts. New replicas take the lowest free replica
+// numbers. If not every replica fits, those placed so far are returned with
+// the error.
+func (p *Planner) PlanReplicas(model models.Model, nodes []models.Node, existing []models.PlacementPlan, count int) ([]models.PlacementPlan, error) {
+	byID := make(map[string]models.Node, len(nodes))
+	for _, node := range nodes {
+		byID[node.ID] = node
+	}
+	usedNodes := make(map[string]bool)
+	usedHosts := make(map[string]bool)
+	usedZones := make(map[string]bool)
+	usedReplicas := make(map[int]bool)
+	use := func(plan models.PlacementPlan) {
+		usedReplicas[plan.Replica] = true
+		for _, id := range plan.NodeIDs() {
+			usedNodes[id] = true
+			if node, ok := byID[id]; ok {
+				if node.Host() != "" {
+					usedHosts[node.Host()] = true
+				}
+				usedZones[node.Zone()] = true
+			}
+		}
+	}
+	for _, plan := range existing {
+		use(plan)
+	}
+
+	plans := make([]models.PlacementPlan, 0, count)
+	for i := 0; i < count; i++ {
+		available := make([]models.Node, 0, len(nodes))
+		for _, node := range nodes {
+			if usedNodes[node.ID] || (p.constraints.SpreadHosts && usedHosts[node.Host()]) {
+				continue
+			}
+			available = append(available, node)
+		}
+
+		var plan models.PlacementPlan
+		var err error
+		placed := false
+		if p.constraints.SpreadZones && len(usedZones) > 0 {
+			fresh := make([]models.Node, 0, len(available))
+			for _, node := range available {
+				if !usedZones[node.Zone()] {
+					fresh = append(fresh, node)
+				}
+			}
+			plan, err = p.Plan(model, fresh)
+			placed = err == nil
+		}
+		if !placed {
+			// Share a zone with another replica rather than not run at all
+			plan, err = p.Plan(model, available)
+		}
+		if err != nil {
+			return plans, fmt.Errorf("replica %d of %s: %w", len(existing)+i+1, model.ID, err)
+		}
+
+		for usedReplicas[plan.Replica] {
+			plan.Replica++
+		}
+		use(plan)
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Validate refuses plans whose nodes are unknown or cannot all talk to each
+// other, or that span zones when SameZone is set
+func (p *Planner) Validate(plan models.PlacementPlan, nodes []models.Node) error {
+	byID := make(map[string]*models.Node, len(nodes))
+	for i := range nodes {
+		byID[nodes[i].ID] = &nodes[i]
+	}
+
+	stageNodes := make([]*models.Node, 0, len(plan.Stages))
+	for _, stage := range plan.Stages {
+		node, ok := byID[stage.NodeID]
+		if !ok {
+			return fmt.Errorf("plan for %s uses unknown node %s", plan.ModelID, stage.NodeID)
+		}
+		stageNodes = append(stageNodes, node)
+	}
+
+	for i, a := range stageNodes {
+		for _, b := range stageNodes[i+1:] {
+			if !a.CompatibleWith(b) {
+				return fmt.Errorf("%w: %s (version %s, protocol %d) and %s (version %s, protocol %d)",
+					ErrIncompatibleVersions, a.ID, a.Version, a.ProtocolVersion, b.ID, b.Version, b.ProtocolVersion)
+			}
+			if p.constraints.SameZone && a.Zone() != b.Zone() {
+				return fmt.Errorf("%w: %s (zone %q) and %s (zone %q) share a pipeline",
+					ErrTopologyConstraint, a.ID, a.Zone(), b.ID, b.Zone())
+			}
+		}
+	}
+	return nil
+}
+
+// eligibleNodes returns online nodes with free layers
+func eligibleNodes(nodes []models.Node) []models.Node {
+	eligible := make([]models.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Status == models.NodeStatusOnline && freeLayers(node) > 0 {
+			eligible = append(eligible, node)
+		}
+	}
+	return eligible
+}
+
+// candidateSets groups nodes by build version, and by shared protocol version
+// for the mixed-version fallback
+func candidateSets(nodes []models.Node) []*candidateSet {
+	sets := make([]*candidateSet, 0)
+
+	byVersion := make(map[string][]models.Node)
+	for _, node := range nodes {
+		byVersion[node.Version] = append(byVersion[node.Version], node)
+	}
+	for version, group := range byVersion {
+		sets = append(sets, newCandidateSet(group, true, "version "+version))
+	}
+
+	protocols := make(map[int32]bool)
+	for _, node := range nodes {
+		for v := node.MinProtocolVersion; v <= node.ProtocolVersion; v++ {
+			protocols[v] = true
+		}
+	}
+	protocols[1] = true // Nodes without version metadata speak protocol 1
+	for protocol := range protocols {
+		group := make([]models.Node, 0)
+		for _, node := range nodes {
+			if node.SpeaksProtocol(protocol) {
+				group = append(group, node)
+			}
+		}
+		if len(group) > 0 {
+			sets = append(sets, newCandidateSet(group, false, fmt.Sprintf("protocol %d", protocol)))
+		}
+	}
+
+	return sets
+}
+
+// splitByZone divides each candidate set into one set per zone
+func splitByZone(sets []*candidateSet) []*candidateSet {
+	split := make([]*candidateSet, 0, len(sets))
+	for _, set := range sets {
+		byZone := make(map[string][]models.Node)
+		for _, node := range set.nodes {
+			byZone[node.Zone()] = append(byZone[node.Zone()], node)
+		}
+		for zone, group := range byZone {
+			split = append(split, newCandidateSet(group, set.homogeneous, fmt.Sprintf("%s in zone %q", set.label, zone)))
+		}
+	}
+	return split
+}
+
+// fitsAnySet returns true if some candidate set can hold the layers
+func fitsAnySet(sets []*candidateSet, layers int32) bool {
+	for _, set := range sets {
+		if set.capacity >= layers {
+			return true
+		}
+	}
+	return false
+}
+
+func newCandidateSet(nodes []models.Node, homogeneous bool, label string) *candidateSet {
+	return &candidateSet{
+		nodes:       nodes,
+		capacity:    totalCapacity(nodes),
+		homogeneous: homogeneous,
+		label:       label,
+	}
+}
+
+// betterSet prefers homogeneous sets, then pipelines with fewer stages, then
+// lower hop latency, then more spare capacity
+func (p *Planner) betterSet(a, b *candidateSet, layers int32) bool {
+	if a.homogeneous != b.homogeneous {
+		return a.homogeneous
+	}
+	stagesA, stagesB := p.assignLayers(a.nodes, layers), p.assignLayers(b.nodes, layers)
+	if len(stagesA) != len(stagesB) {
+		return len(stagesA) < len(stagesB)
+	}
+	if p.latency != nil {
+		latencyA, latencyB := p.PipelineLatency(stagesA), p.PipelineLatency(stagesB)
+		if latencyA != latencyB {
+			return latencyA < latencyB
+		}
+	}
+	if a.capacity != b.capacity {
+		return a.capacity > b.capacity
+	}
+	return a.label < b.label
+}
+
+// assignLayers uses the nodes with the most free layers, as few as can hold
+// the model, orders them by hop latency and gives each a contiguous range
+func (p *Planner) assignLayers(nodes []models.Node, layers int32) []models.PipelineStage {
+	ordered := append([]models.Node(nil), nodes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if freeLayers(ordered[i]) != freeLayers(ordered[j]) {
+			return freeLayers(ordered[i]) > freeLayers(ordered[j])
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	// Every node but the last is needed, so any order still gives each a layer
+	selected := make([]models.Node, 0, len(ordered))
+	capacity := int32(0)
+	for _, node := range ordered {
+		if capacity >= layers {
+			break
+		}
+		selected = append(selected, node)
+		capacity += freeLayers(node)
+	}
+	if p.latency != nil {
+		selected = p.orderByLatency(selected)
+	}
+
+	stages := make([]models.PipelineStage, 0)
+	next := int32(0)
+	for _, node := range selected {
+		if next >= layers {
+			break
+		}
+		count := min(freeLayers(node), layers-next)
+		stages = append(stages, models.PipelineStage{
+			NodeID:     node.ID,
+			LayerStart: next,
+			LayerEnd:   next + count - 1,
+		})
+		next += count
+	}
+	return stages
+}
+
+// PipelineLatency is the total RTT of the hops between consecutive stages
+func (p *Planner) PipelineLatency(stages []models.PipelineStage) time.Duration {
+	total := time.Duration(0)
+	for i := 1; i < len(stages); i++ {
+		total += p.hopLatency(stages[i-1].NodeID, stages[i].NodeID)
+	}
+	return total
+}
+
+func (p *Planner) hopLatency(from, to string) time.Duration {
+	if p.latency == nil {
+		return unknownHopLatency
+	}
+	if rtt, ok := p.latency.RTT(from, to); ok {
+		return rtt
+	}
+	return unknownHopLatency
+}
+
+// orderByLatency returns the order of nodes with the lowest total hop
+// latency, keeping the given order on ties. Short pipelines are searched
+// exhaustively, longer ones built greedily from each starting node.
+func (p *Planner) orderByLatency(nodes []models.Node) []models.Node {
+	if len(nodes) < 3 {
+		// Hops are symmetric, so two stages cost the same either way
+		return nodes
+	}
+
+	n := len(nodes)
+	cost := make([][]time.Duration, n)
+	for i := range nodes {
+		cost[i] = make([]time.Duration, n)
+		for j := range nodes {
+			if i != j {
+				cost[i][j] = p.hopLatency(nodes[i].ID, nodes[j].ID)
+			}
+		}
+	}
+
+	best := make([]int, n)
+	for i := range best {
+		best[i] = i
+	}
+	bestCost := pathCost(cost, best)
+
+	if n <= maxExhaustiveStages {
+		path := make([]int, 0, n)
+		used := make([]bool, n)
+		var search func(total time.Duration)
+		search = func(total time.Duration) {
+			if total >= bestCost {
+				return
+			}
+			if len(path) == n {
+				best, bestCost = append(best[:0], path...), total
+				return
+			}
+			for next := 0; next < n; next++ {
+				if used[next] {
+					continue
+				}
+				step := time.Duration(0)
+				if len(path) > 0 {
+					step = cost[path[len(path)-1]][next]
+				}
+				used[next] = true
+				path = append(path, next)
+				search(total + step)
+				path = path[:len(path)-1]
+				used[next] = false
+			}
+		}
+		search(0)
+	} else {
+		for start := 0; start < n; start++ {
+			path := []int{start}
+			used := make([]bool, n)
+			used[start] = true
+			for len(path) < n {
+				last, nearest := path[len(path)-1], -1
+				for next := 0; next < n; next++ {
+					if !used[next] && (nearest < 0 || cost[last][next] < cost[last][nearest]) {
+						nearest = next
+					}
+				}
+				used[nearest] = true
+				path = append(path, nearest)
+			}
+			if total := pathCost(cost, path); total < bestCost {
+				best, bestCost = path, total
+			}
+		}
+	}
+
+	ordered := make([]models.Node, n)
+	for i, index := range best {
+		ordered[i] = nodes[index]
+	}
+	return ordered
+}
+
+func pathCost(cost [][]time.Duration, path []int) time.Duration {
+	total := time.Duration(0)
+	for i := 1; i < len(path); i++ {
+		total += cost[path[i-1]][path[i]]
+	}
+	return total
+}
+
+func freeLayers(node models.Node) int32 {
+	return node.Resources.MaxLayers - node.Resources.UsedLayers
+}
+
+func totalCapacity(nodes []models.Node) int32 {
+	total := int32(0)
+	for _, node := range nodes {
+		total += freeLayers(node)
+	}
+	return total
+}
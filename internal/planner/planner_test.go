@@ -0,0 +1,326 @@
+package planner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+func node(id, version string, protocol, minProtocol, free int32) models.Node {
+	return models.Node{
+		ID:                 id,
+		Status:             models.NodeStatusOnline,
+		Version:            version,
+		ProtocolVersion:    protocol,
+		MinProtocolVersion: minProtocol,
+		Resources:          models.ResourceInfo{MaxLayers: free},
+	}
+}
+
+func TestPlanAssignsContiguousLayers(t *testing.T) {
+	nodes := []models.Node{
+		node("node-a", "v1", 1, 1, 10),
+		node("node-b", "v1", 1, 1, 20),
+		node("node-c", "v1", 1, 1, 5),
+	}
+
+	plan, err := New().Plan(models.Model{ID: "llama-7b", LayerCount: 32}, nodes)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	expected := []models.PipelineStage{
+		{NodeID: "node-b", LayerStart: 0, LayerEnd: 19},
+		{NodeID: "node-a", LayerStart: 20, LayerEnd: 29},
+		{NodeID: "node-c", LayerStart: 30, LayerEnd: 31},
+	}
+	if len(plan.Stages) != len(expected) {
+		t.Fatalf("Expected %d stages, got %+v", len(expected), plan.Stages)
+	}
+	for i, stage := range expected {
+		if plan.Stages[i] != stage {
+			t.Errorf("Stage %d = %+v, want %+v", i, plan.Stages[i], stage)
+		}
+	}
+}
+
+func TestPlanPrefersHomogeneousPipelines(t *testing.T) {
+	// Mid-upgrade: the old version has the most free layers, but the new
+	// version alone can hold the model
+	nodes := []models.Node{
+		node("old-1", "v1", 2, 1, 30),
+		node("new-1", "v2", 2, 1, 16),
+		node("new-2", "v2", 2, 1, 16),
+	}
+
+	plan, err := New().Plan(models.Model{ID: "llama-7b", LayerCount: 32}, nodes)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	for _, id := range plan.NodeIDs() {
+		if id == "old-1" {
+			t.Errorf("Expected a v2-only pipeline, got %v", plan.NodeIDs())
+		}
+	}
+}
+
+func TestPlanFallsBackToCompatibleMixedPipeline(t *testing.T) {
+	nodes := []models.Node{
+		node("old-1", "v1", 1, 1, 20),
+		node("new-1", "v2", 2, 1, 20),
+		node("next-1", "v3", 3, 3, 20),
+	}
+
+	plan, err := New().Plan(models.Model{ID: "llama-7b", LayerCount: 32}, nodes)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan.Stages) != 2 {
+		t.Fatalf("Expected 2 stages, got %+v", plan.Stages)
+	}
+	for _, id := range plan.NodeIDs() {
+		if id == "next-1" {
+			t.Errorf("Protocol 3 node cannot share a pipeline with protocol 1, got %v", plan.NodeIDs())
+		}
+	}
+	if err := New().Validate(plan, nodes); err != nil {
+		t.Errorf("Expected planned pipeline to validate, got %v", err)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []models.Node
+		err   error
+	}{
+		{
+			name:  "not enough layers",
+			nodes: []models.Node{node("node-a", "v1", 1, 1, 10)},
+			err:   ErrInsufficientCapacity,
+		},
+		{
+			name: "only incompatible nodes together have capacity",
+			nodes: []models.Node{
+				node("old-1", "v1", 1, 1, 20),
+				node("next-1", "v3", 3, 3, 20),
+			},
+			err: ErrIncompatibleVersions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().Plan(models.Model{ID: "llama-7b", LayerCount: 32}, tt.nodes)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	if _, err := New().Plan(models.Model{ID: "empty"}, nil); err == nil {
+		t.Error("Expected error for model without layers")
+	}
+}
+
+// rackLatency puts nodes a and c in one rack and b and d in another
+type rackLatency map[string]int
+
+func (r rackLatency) RTT(from, to string) (time.Duration, bool) {
+	if r[from] == r[to] {
+		return time.Millisecond, true
+	}
+	return 50 * time.Millisecond, true
+}
+
+func TestPlanOrdersStagesByLatency(t *testing.T) {
+	nodes := []models.Node{
+		node("node-a", "v1", 1, 1, 10),
+		node("node-b", "v1", 1, 1, 10),
+		node("node-c", "v1", 1, 1, 10),
+		node("node-d", "v1", 1, 1, 10),
+	}
+	planner := New()
+	planner.SetLatencySource(rackLatency{"node-a": 0, "node-b": 1, "node-c": 0, "node-d": 1})
+
+	plan, err := planner.Plan(models.Model{ID: "llama-7b", LayerCount: 40}, nodes)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	expected := []models.PipelineStage{
+		{NodeID: "node-a", LayerStart: 0, LayerEnd: 9},
+		{NodeID: "node-c", LayerStart: 10, LayerEnd: 19},
+		{NodeID: "node-b", LayerStart: 20, LayerEnd: 29},
+		{NodeID: "node-d", LayerStart: 30, LayerEnd: 39},
+	}
+	if len(plan.Stages) != len(expected) {
+		t.Fatalf("Expected %d stages, got %+v", len(expected), plan.Stages)
+	}
+	for i, stage := range expected {
+		if plan.Stages[i] != stage {
+			t.Errorf("Stage %d = %+v, want %+v", i, plan.Stages[i], stage)
+		}
+	}
+	if latency := planner.PipelineLatency(plan.Stages); latency != 52*time.Millisecond {
+		t.Errorf("Expected a single cross-rack hop, got %v", latency)
+	}
+}
+
+func TestPlanSkipsUnavailableNodes(t *testing.T) {
+	offline := node("offline", "v1", 1, 1, 40)
+	offline.Status = models.NodeStatusOffline
+	full := node("full", "v1", 1, 1, 40)
+	full.Resources.UsedLayers = 40
+
+	plan, err := New().Plan(models.Model{ID: "llama-7b", LayerCount: 8}, []models.Node{offline, full, node("node-a", "v1", 1, 1, 8)})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if ids := plan.NodeIDs(); len(ids) != 1 || ids[0] != "node-a" {
+		t.Errorf("Expected only node-a to be used, got %v", ids)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	nodes := []models.Node{
+		node("old-1", "v1", 1, 1, 20),
+		node("next-1", "v3", 3, 3, 20),
+		{ID: "legacy", Status: models.NodeStatusOnline},
+	}
+
+	tests := []struct {
+		name    string
+		stages  []string
+		wantErr error
+	}{
+		{"compatible with legacy node", []string{"old-1", "legacy"}, nil},
+		{"incompatible protocols", []string{"old-1", "next-1"}, ErrIncompatibleVersions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := models.PlacementPlan{ModelID: "llama-7b"}
+			for _, id := range tt.stages {
+				plan.Stages = append(plan.Stages, models.PipelineStage{NodeID: id})
+			}
+			if err := New().Validate(plan, nodes); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	unknown := models.PlacementPlan{ModelID: "llama-7b", Stages: []models.PipelineStage{{NodeID: "missing"}}}
+	if err := New().Validate(unknown, nodes); err == nil {
+		t.Error("Expected error for unknown node")
+	}
+}
+
+// located returns a node with topology labels
+func located(id, zone, host string, free int32) models.Node {
+	n := node(id, "v1", 1, 1, free)
+	n.Labels = map[string]string{models.LabelZone: zone, models.LabelHost: host}
+	return n
+}
+
+func TestPlanKeepsPipelineInOneZone(t *testing.T) {
+	nodes := []models.Node{
+		located("a-1", "zone-a", "host-1", 20),
+		located("b-1", "zone-b", "host-2", 16),
+		located("b-2", "zone-b", "host-3", 16),
+	}
+	planner := New()
+	planner.SetConstraints(Constraints{SameZone: true})
+
+	plan, err := planner.Plan(models.Model{ID: "llama-7b", LayerCount: 32}, nodes)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if ids := plan.NodeIDs(); len(ids) != 2 || ids[0] != "b-1" || ids[1] != "b-2" {
+		t.Errorf("Expected the pipeline to stay in zone-b, got %v", ids)
+	}
+
+	_, err = planner.Plan(models.Model{ID: "llama-13b", LayerCount: 40}, nodes)
+	if !errors.Is(err, ErrTopologyConstraint) {
+		t.Errorf("Expected %v when no zone is big enough, got %v", ErrTopologyConstraint, err)
+	}
+
+	spanning := models.PlacementPlan{ModelID: "llama-7b", Stages: []models.PipelineStage{
+		{NodeID: "a-1", LayerStart: 0, LayerEnd: 15},
+		{NodeID: "b-1", LayerStart: 16, LayerEnd: 31},
+	}}
+	if err := planner.Validate(spanning, nodes); !errors.Is(err, ErrTopologyConstraint) {
+		t.Errorf("Expected Validate to refuse a plan spanning zones, got %v", err)
+	}
+}
+
+func TestPlanReplicas(t *testing.T) {
+	nodes := []models.Node{
+		located("a-1", "zone-a", "host-1", 32),
+		located("a-2", "zone-a", "host-1", 32),
+		located("a-3", "zone-a", "host-2", 32),
+		located("b-1", "zone-b", "host-3", 32),
+	}
+	model := models.Model{ID: "llama-7b", LayerCount: 32}
+
+	tests := []struct {
+		name        string
+		constraints Constraints
+		replicas    int
+		want        []string
+		err         bool
+	}{
+		{"spread zones then hosts", Constraints{SpreadZones: true, SpreadHosts: true}, 3, []string{"a-1", "b-1", "a-3"}, false},
+		{"hosts exhausted", Constraints{SpreadHosts: true}, 4, []string{"a-1", "a-3", "b-1"}, true},
+		{"no constraints", Constraints{}, 4, []string{"a-1", "a-2", "a-3", "b-1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planner := New()
+			planner.SetConstraints(tt.constraints)
+
+			plans, err := planner.PlanReplicas(model, nodes, nil, tt.replicas)
+			if (err != nil) != tt.err {
+				t.Fatalf("PlanReplicas error = %v, want error %v", err, tt.err)
+			}
+			got := make([]string, 0, len(plans))
+			for _, plan := range plans {
+				got = append(got, plan.NodeIDs()...)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected replicas on %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected replicas on %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestPlanReplicasAlongsideExisting(t *testing.T) {
+	nodes := []models.Node{
+		located("a-1", "zone-a", "host-1", 32),
+		located("a-2", "zone-a", "host-2", 32),
+		located("b-1", "zone-b", "host-3", 32),
+	}
+	existing := []models.PlacementPlan{
+		{ModelID: "llama-7b", Replica: 0, Stages: []models.PipelineStage{{NodeID: "a-1", LayerStart: 0, LayerEnd: 31}}},
+		{ModelID: "llama-7b", Replica: 2, Stages: []models.PipelineStage{{NodeID: "a-2", LayerStart: 0, LayerEnd: 31}}},
+	}
+	planner := New()
+	planner.SetConstraints(Constraints{SpreadZones: true, SpreadHosts: true})
+
+	plans, err := planner.PlanReplicas(models.Model{ID: "llama-7b", LayerCount: 32}, nodes, existing, 1)
+	if err != nil {
+		t.Fatalf("PlanReplicas failed: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Replica != 1 || plans[0].NodeIDs()[0] != "b-1" {
+		t.Errorf("Expected replica 1 on b-1, got %+v", plans)
+	}
+}
@@ -0,0 +1,86 @@
+package quota
+
+import (
+	"time"
+
+	"distributed-llm/internal/gossip"
+)
+
+// reportTTL is how long another node's usage counts after it last reported
+// it, so that departed nodes stop holding quota
+const reportTTL = 10 * time.Second
+
+// usage is a node's consumption of one tenant's quota
+type usage struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	TokensPerMinute   float64 `json:"tokens_per_minute"`
+	InFlight          int     `json:"in_flight"`
+}
+
+// report is a node's usage of each active tenant
+type report struct {
+	gossip.Report
+	Tenants map[string]usage `json:"tenants,omitempty"`
+}
+
+// remoteLocked sums the tenant's usage on the other nodes
+func (l *Limiter) remoteLocked(tenantID string, now time.Time) usage {
+	var total usage
+	l.reports.Each(now, func(r *report) {
+		if r.NodeID == l.nodeID {
+			return
+		}
+		u := r.Tenants[tenantID]
+		total.RequestsPerSecond += u.RequestsPerSecond
+		total.TokensPerMinute += u.TokensPerMinute
+		total.InFlight += u.InFlight
+	})
+	return total
+}
+
+// LocalReport measures this node's usage since the last report and encodes
+// it for gossip. Call it periodically; rates are averaged over the period.
+func (l *Limiter) LocalReport() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	elapsed := now.Sub(l.lastReport).Seconds()
+	l.lastReport = now
+
+	l.forgetIdleLocked(now)
+	local := &report{Report: gossip.Report{Sent: now}, Tenants: make(map[string]usage)}
+	for id, t := range l.tenants {
+		if t.inFlight == 0 && t.admitted == 0 {
+			continue
+		}
+		u := usage{InFlight: t.inFlight}
+		if elapsed > 0 {
+			u.RequestsPerSecond = float64(t.admitted) / elapsed
+			u.TokensPerMinute = float64(t.used) / elapsed * 60
+		}
+		local.Tenants[id] = u
+		t.admitted, t.used = 0, 0
+	}
+	l.reports.SetLocal(local)
+	l.reports.Expire(now)
+	return l.reports.EncodeLocal(now)
+}
+
+// HandleMessage merges usage gossiped by another node (network.ChannelHandler)
+func (l *Limiter) HandleMessage(msg []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reports.Merge(msg)
+}
+
+// LocalState shares every node's usage during push/pull (network.ChannelHandler)
+func (l *Limiter) LocalState() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reports.Encode(l.now())
+}
+
+// MergeRemoteState merges the usage of a push/pull peer (network.ChannelHandler)
+func (l *Limiter) MergeRemoteState(buf []byte) {
+	l.HandleMessage(buf)
+}
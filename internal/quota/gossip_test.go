@@ -0,0 +1,68 @@
+package quota
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"distributed-llm/internal/gossip"
+	"distributed-llm/pkg/models"
+)
+
+func TestQuotaSharedAcrossNodes(t *testing.T) {
+	quotas := map[string]models.Quota{"acme": {RequestsPerSecond: 4, MaxConcurrent: 3}}
+	a, advanceA := newTestLimiter("node-a", quotas)
+	b, advanceB := newTestLimiter("node-b", quotas)
+
+	// node-b serves 3 requests a second and has 2 in flight
+	for i := 0; i < 3; i++ {
+		release, err := b.Acquire("acme", "llama-7b")
+		if err != nil {
+			t.Fatalf("Request %d rejected on node-b: %v", i, err)
+		}
+		if i == 0 {
+			release(10)
+		}
+	}
+	advanceB(time.Second)
+	a.HandleMessage(b.LocalReport())
+
+	// node-a is left a rate of 1 request a second and 1 slot
+	if _, err := a.Acquire("acme", "llama-7b"); err != nil {
+		t.Fatalf("Expected node-a's share to admit a request, got %v", err)
+	}
+	_, err := a.Acquire("acme", "llama-7b")
+	if limitErr := limitOf(t, err); limitErr.Limit != LimitConcurrent {
+		t.Errorf("Expected the cluster-wide concurrency limit, got %v", limitErr)
+	}
+
+	// Once node-b's report expires, node-a has the whole quota
+	advanceA(reportTTL + 2*time.Second)
+	if _, err := a.Acquire("acme", "llama-7b"); err != nil {
+		t.Errorf("Expected node-b's stale usage to be ignored, got %v", err)
+	}
+}
+
+func TestQuotaReportsKeepNewest(t *testing.T) {
+	a, _ := newTestLimiter("node-a", map[string]models.Quota{"acme": {RequestsPerSecond: 2}})
+	now := a.now()
+	older, _ := json.Marshal([]report{{Report: gossip.Report{NodeID: "node-b", Sent: now.Add(-time.Second)}, Tenants: map[string]usage{"acme": {RequestsPerSecond: 1}}}})
+	newer, _ := json.Marshal([]report{{Report: gossip.Report{NodeID: "node-b", Sent: now}, Tenants: map[string]usage{"acme": {RequestsPerSecond: 2}}}})
+
+	a.MergeRemoteState(newer)
+	a.HandleMessage(older)
+
+	// node-b uses the whole rate, leaving node-a a single token and no refill
+	if _, err := a.Acquire("acme", "llama-7b"); err != nil {
+		t.Fatalf("Expected the bucket's last token to admit a request, got %v", err)
+	}
+	_, err := a.Acquire("acme", "llama-7b")
+	if limitErr := limitOf(t, err); limitErr.Limit != LimitRequests || limitErr.RetryAfter != starvedRetryAfter {
+		t.Errorf("Expected node-a to be starved by the newer report, got %v", limitErr)
+	}
+
+	var reports []report
+	if err := json.Unmarshal(a.LocalState(), &reports); err != nil || len(reports) != 2 {
+		t.Errorf("Expected both nodes' reports in the local state, got %+v (%v)", reports, err)
+	}
+}
@@ -0,0 +1,232 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"distributed-llm/internal/gossip"
+	"distributed-llm/pkg/models"
+)
+
+// AnyTenant names the quota applied to tenants without one of their own.
+// Each such tenant is limited separately.
+const AnyTenant = "*"
+
+// Limits a request may exceed, as named in LimitError
+const (
+	LimitRequests   = "requests_per_second"
+	LimitTokens     = "tokens_per_minute"
+	LimitConcurrent = "max_concurrent"
+)
+
+// starvedRetryAfter is the retry hint when no wait can be computed: when
+// the other nodes use the whole quota or the concurrency limit is reached
+const starvedRetryAfter = time.Second
+
+// idleTenantTTL is how long an idle tenant's buckets are kept
+const idleTenantTTL = time.Minute
+
+// maxTenants bounds the tenants a node keeps buckets for; once reached,
+// new tenants share AnyTenant's until idle ones are forgotten
+const maxTenants = 10000
+
+// ErrModelNotAllowed is returned for requests for a model the tenant's
+// quota does not list
+var ErrModelNotAllowed = errors.New("model not allowed")
+
+// LimitError is returned for requests over their tenant's quota
+type LimitError struct {
+	Tenant string
+	Limit  string
+	// RetryAfter is when a request would next be admitted if the cluster's
+	// load stays the same
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("tenant %s is over its %s quota, retry after %s", e.Tenant, e.Limit, e.RetryAfter)
+}
+
+// Limiter enforces tenant quotas across the cluster. Each node admits
+// requests from token buckets refilled at the tenant's rate less the rate
+// the other nodes report consuming, so that together they stay within the
+// quota; concurrency counts the requests in flight on every node. Tokens
+// are charged when a request finishes, so a tenant may overrun its token
+// quota by the requests it has in flight.
+type Limiter struct {
+	nodeID string
+	now    func() time.Time
+
+	mu      sync.Mutex
+	quotas  map[string]models.Quota
+	tenants map[string]*tenant
+	// reports holds the latest usage report of every node, including ours
+	reports    *gossip.Table[report, *report]
+	lastReport time.Time
+}
+
+// tenant is this node's view of a tenant's consumption
+type tenant struct {
+	requests bucket
+	tokens   bucket
+	inFlight int
+	lastUsed time.Time
+	// admitted and used count the requests and tokens since the last report
+	admitted int
+	used     int64
+}
+
+// New creates a limiter for the node without any quotas
+func New(nodeID string) *Limiter {
+	now := time.Now()
+	return &Limiter{
+		nodeID:     nodeID,
+		now:        time.Now,
+		quotas:     make(map[string]models.Quota),
+		tenants:    make(map[string]*tenant),
+		reports:    gossip.NewTable(nodeID, reportTTL, &report{Report: gossip.Report{Sent: now}}),
+		lastReport: now,
+	}
+}
+
+// SetQuotas replaces the quotas by tenant; AnyTenant applies to tenants
+// without their own, and tenants without either are not limited
+func (l *Limiter) SetQuotas(quotas map[string]models.Quota) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.quotas = quotas
+}
+
+// Quota returns the quota applied to a tenant
+func (l *Limiter) Quota(tenantID string) (models.Quota, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.quotaLocked(tenantID)
+}
+
+func (l *Limiter) quotaLocked(tenantID string) (models.Quota, bool) {
+	if quota, ok := l.quotas[tenantID]; ok {
+		return quota, true
+	}
+	quota, ok := l.quotas[AnyTenant]
+	return quota, ok
+}
+
+// Acquire admits a request from a tenant for a model, or returns a
+// *LimitError or ErrModelNotAllowed. Once the request is done, call the
+// returned function with the tokens it consumed.
+func (l *Limiter) Acquire(tenantID, modelID string) (func(tokens int), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	quota, ok := l.quotaLocked(tenantID)
+	if !ok {
+		return func(int) {}, nil
+	}
+	if len(quota.Models) > 0 && !slices.Contains(quota.Models, modelID) {
+		return nil, fmt.Errorf("%w: tenant %s may not use model %q", ErrModelNotAllowed, tenantID, modelID)
+	}
+
+	now := l.now()
+	tenantID = l.bucketsLocked(tenantID, now)
+	t := l.tenants[tenantID]
+	t.lastUsed = now
+	remote := l.remoteLocked(tenantID, now)
+
+	if quota.MaxConcurrent > 0 && t.inFlight+remote.InFlight >= quota.MaxConcurrent {
+		return nil, &LimitError{Tenant: tenantID, Limit: LimitConcurrent, RetryAfter: starvedRetryAfter}
+	}
+	if quota.RequestsPerSecond > 0 {
+		t.requests.refill(now, quota.RequestsPerSecond, remote.RequestsPerSecond, max(quota.RequestsPerSecond, 1))
+		if t.requests.tokens < 1 {
+			return nil, &LimitError{Tenant: tenantID, Limit: LimitRequests, RetryAfter: t.requests.wait(1)}
+		}
+	}
+	if quota.TokensPerMinute > 0 {
+		perMinute := float64(quota.TokensPerMinute)
+		t.tokens.refill(now, perMinute/60, remote.TokensPerMinute/60, perMinute)
+		if t.tokens.tokens < 1 {
+			return nil, &LimitError{Tenant: tenantID, Limit: LimitTokens, RetryAfter: t.tokens.wait(1)}
+		}
+	}
+
+	if quota.RequestsPerSecond > 0 {
+		t.requests.tokens--
+	}
+	t.inFlight++
+	t.admitted++
+	var once sync.Once
+	return func(tokens int) {
+		once.Do(func() { l.release(t, tokens) })
+	}, nil
+}
+
+// bucketsLocked makes sure the tenant has buckets and returns the tenant
+// they are kept under: its own, or AnyTenant's when there are too many
+func (l *Limiter) bucketsLocked(tenantID string, now time.Time) string {
+	if _, ok := l.tenants[tenantID]; ok {
+		return tenantID
+	}
+	if len(l.tenants) >= maxTenants {
+		l.forgetIdleLocked(now)
+	}
+	if len(l.tenants) >= maxTenants {
+		tenantID = AnyTenant
+		if _, ok := l.tenants[tenantID]; ok {
+			return tenantID
+		}
+	}
+	l.tenants[tenantID] = &tenant{}
+	return tenantID
+}
+
+// forgetIdleLocked drops the buckets of tenants idle for idleTenantTTL
+// that have nothing left to report
+func (l *Limiter) forgetIdleLocked(now time.Time) {
+	for id, t := range l.tenants {
+		if t.inFlight == 0 && t.admitted == 0 && now.Sub(t.lastUsed) > idleTenantTTL {
+			delete(l.tenants, id)
+		}
+	}
+}
+
+func (l *Limiter) release(t *tenant, tokens int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t.inFlight--
+	t.used += int64(tokens)
+	t.tokens.tokens -= float64(tokens)
+	t.lastUsed = l.now()
+}
+
+// bucket is a token bucket whose rate is the tenant's limit less what the
+// other nodes consume
+type bucket struct {
+	tokens float64
+	// rate is the refill rate per second as of the last refill
+	rate float64
+	last time.Time
+}
+
+// refill adds the tokens earned since the last refill. The bucket holds
+// this node's share of the burst, and at least one token.
+func (b *bucket) refill(now time.Time, limit, remote, burst float64) {
+	b.rate = max(limit-remote, 0)
+	capacity := max(burst*b.rate/limit, 1)
+	if b.last.IsZero() {
+		b.tokens = capacity
+	} else {
+		b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, capacity)
+	}
+	b.last = now
+}
+
+// wait is how long until the bucket holds need tokens
+func (b *bucket) wait(need float64) time.Duration {
+	if b.rate <= 0 {
+		return starvedRetryAfter
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
@@ -0,0 +1,161 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+// newTestLimiter returns a limiter on a clock that only moves with advance
+func newTestLimiter(nodeID string, quotas map[string]models.Quota) (*Limiter, func(time.Duration)) {
+	l := New(nodeID)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return clock }
+	l.lastReport = clock
+	l.SetQuotas(quotas)
+	return l, func(d time.Duration) { clock = clock.Add(d) }
+}
+
+// limitOf returns the limit a request was rejected for
+func limitOf(t *testing.T, err error) *LimitError {
+	t.Helper()
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected a LimitError, got %v", err)
+	}
+	return limitErr
+}
+
+func TestRequestsPerSecond(t *testing.T) {
+	l, advance := newTestLimiter("node-a", map[string]models.Quota{"acme": {RequestsPerSecond: 2}})
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Acquire("acme", "llama-7b"); err != nil {
+			t.Fatalf("Request %d rejected: %v", i, err)
+		}
+	}
+	_, err := l.Acquire("acme", "llama-7b")
+	if limitErr := limitOf(t, err); limitErr.Limit != LimitRequests || limitErr.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Expected a retry after 500ms, got %v", limitErr)
+	}
+
+	advance(500 * time.Millisecond)
+	if _, err := l.Acquire("acme", "llama-7b"); err != nil {
+		t.Errorf("Expected a request once a token was earned, got %v", err)
+	}
+
+	// Other tenants have their own quota, or none
+	if _, err := l.Acquire("globex", "llama-7b"); err != nil {
+		t.Errorf("Expected a tenant without a quota to be admitted, got %v", err)
+	}
+}
+
+func TestTokensPerMinute(t *testing.T) {
+	l, advance := newTestLimiter("node-a", map[string]models.Quota{"acme": {TokensPerMinute: 60}})
+
+	release, err := l.Acquire("acme", "llama-7b")
+	if err != nil {
+		t.Fatalf("Request rejected: %v", err)
+	}
+	// The request used more than the minute's tokens
+	release(100)
+	release(100)
+
+	_, err = l.Acquire("acme", "llama-7b")
+	if limitErr := limitOf(t, err); limitErr.Limit != LimitTokens || limitErr.RetryAfter != 41*time.Second {
+		t.Errorf("Expected a retry after 41s, got %v", limitErr)
+	}
+	advance(41 * time.Second)
+	if _, err := l.Acquire("acme", "llama-7b"); err != nil {
+		t.Errorf("Expected a request once the debt was repaid, got %v", err)
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	l, _ := newTestLimiter("node-a", map[string]models.Quota{AnyTenant: {MaxConcurrent: 1}})
+
+	release, err := l.Acquire("acme", "llama-7b")
+	if err != nil {
+		t.Fatalf("Request rejected: %v", err)
+	}
+	_, err = l.Acquire("acme", "llama-7b")
+	if limitErr := limitOf(t, err); limitErr.Limit != LimitConcurrent {
+		t.Errorf("Expected the concurrency limit, got %v", limitErr)
+	}
+	if _, err := l.Acquire("globex", "llama-7b"); err != nil {
+		t.Errorf("Expected tenants under AnyTenant to be limited separately, got %v", err)
+	}
+
+	release(0)
+	if _, err := l.Acquire("acme", "llama-7b"); err != nil {
+		t.Errorf("Expected a request once the first finished, got %v", err)
+	}
+}
+
+func TestAllowedModels(t *testing.T) {
+	l, _ := newTestLimiter("node-a", map[string]models.Quota{"acme": {Models: []string{"llama-7b"}}})
+
+	tests := []struct {
+		model   string
+		allowed bool
+	}{
+		{"llama-7b", true},
+		{"mistral-7b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			_, err := l.Acquire("acme", tt.model)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected %s to be allowed, got %v", tt.model, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrModelNotAllowed) {
+				t.Errorf("Expected ErrModelNotAllowed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTenantBucketsBounded(t *testing.T) {
+	quotas := map[string]models.Quota{AnyTenant: {MaxConcurrent: 1}}
+
+	t.Run("new tenants share buckets", func(t *testing.T) {
+		l, _ := newTestLimiter("node-a", quotas)
+		for i := 0; i < maxTenants; i++ {
+			if _, err := l.Acquire(fmt.Sprintf("tenant-%d", i), "llama-7b"); err != nil {
+				t.Fatalf("Request %d rejected: %v", i, err)
+			}
+		}
+		if _, err := l.Acquire("tenant-new", "llama-7b"); err != nil {
+			t.Fatalf("Request rejected: %v", err)
+		}
+		_, err := l.Acquire("tenant-newer", "llama-7b")
+		if limitErr := limitOf(t, err); limitErr.Limit != LimitConcurrent {
+			t.Errorf("Expected new tenants to share the concurrency limit, got %v", limitErr)
+		}
+		if len(l.tenants) > maxTenants+1 {
+			t.Errorf("Expected at most %d tenants, got %d", maxTenants+1, len(l.tenants))
+		}
+	})
+
+	t.Run("idle tenants make room", func(t *testing.T) {
+		l, advance := newTestLimiter("node-a", quotas)
+		for i := 0; i < maxTenants; i++ {
+			release, err := l.Acquire(fmt.Sprintf("tenant-%d", i), "llama-7b")
+			if err != nil {
+				t.Fatalf("Request %d rejected: %v", i, err)
+			}
+			release(0)
+		}
+		l.LocalReport()
+		advance(2 * idleTenantTTL)
+		if _, err := l.Acquire("tenant-new", "llama-7b"); err != nil {
+			t.Fatalf("Request rejected: %v", err)
+		}
+		if _, ok := l.tenants["tenant-new"]; !ok || len(l.tenants) != 1 {
+			t.Errorf("Expected idle tenants to make room for tenant-new, got %d tenants", len(l.tenants))
+		}
+	})
+}
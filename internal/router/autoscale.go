@@ -0,0 +1,69 @@
+package router
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// AutoscaleConfig bounds the replicas of each model and sets the queue depth
+// each replica should carry
+type AutoscaleConfig struct {
+	MinReplicas int
+	MaxReplicas int
+	// TargetQueueDepth is the number of requests in flight per replica
+	TargetQueueDepth float64
+	// ScaleDownDelay is how long the queue must stay low before replicas
+	// are removed, so that bursts don't cause churn
+	ScaleDownDelay time.Duration
+}
+
+// Autoscaler sizes each model's replica set by its queue depth. Scaling up
+// is immediate; scaling down waits for ScaleDownDelay.
+type Autoscaler struct {
+	cfg AutoscaleConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	lowSince map[string]time.Time
+}
+
+// NewAutoscaler creates an autoscaler
+func NewAutoscaler(cfg AutoscaleConfig) *Autoscaler {
+	cfg.MinReplicas = max(cfg.MinReplicas, 1)
+	cfg.MaxReplicas = max(cfg.MaxReplicas, cfg.MinReplicas)
+	if cfg.TargetQueueDepth <= 0 {
+		cfg.TargetQueueDepth = 1
+	}
+	return &Autoscaler{
+		cfg:      cfg,
+		now:      time.Now,
+		lowSince: make(map[string]time.Time),
+	}
+}
+
+// Desired returns the number of replicas the model should run given its
+// current replica count and cluster-wide queue depth
+func (a *Autoscaler) Desired(modelID string, current, queueDepth int) int {
+	desired := int(math.Ceil(float64(queueDepth) / a.cfg.TargetQueueDepth))
+	desired = min(max(desired, a.cfg.MinReplicas), a.cfg.MaxReplicas)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if desired >= current {
+		delete(a.lowSince, modelID)
+		return desired
+	}
+
+	since, ok := a.lowSince[modelID]
+	if !ok {
+		a.lowSince[modelID] = a.now()
+		return current
+	}
+	if a.now().Sub(since) < a.cfg.ScaleDownDelay {
+		return current
+	}
+	delete(a.lowSince, modelID)
+	return desired
+}
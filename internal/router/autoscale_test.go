@@ -0,0 +1,42 @@
+package router
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoscaler(t *testing.T) {
+	a := NewAutoscaler(AutoscaleConfig{
+		MinReplicas:      1,
+		MaxReplicas:      4,
+		TargetQueueDepth: 8,
+		ScaleDownDelay:   time.Minute,
+	})
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		current int
+		queue   int
+		want    int
+	}{
+		{"idle stays at minimum", 0, 1, 0, 1},
+		{"scales up at once", 0, 1, 20, 3},
+		{"capped at maximum", 0, 3, 100, 4},
+		{"holds while the queue just dropped", 0, 4, 4, 4},
+		{"still holding", 30 * time.Second, 4, 4, 4},
+		{"scales down after the delay", 30 * time.Second, 4, 4, 1},
+		{"low queue starts the delay", 0, 2, 4, 2},
+		{"a burst resets it", 50 * time.Second, 2, 16, 2},
+		{"the delay starts over", 50 * time.Second, 2, 4, 2},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if got := a.Desired("llama-7b", step.current, step.queue); got != step.want {
+			t.Errorf("%s: Desired(current=%d, queue=%d) = %d, want %d", step.name, step.current, step.queue, got, step.want)
+		}
+	}
+}
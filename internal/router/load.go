@@ -0,0 +1,88 @@
+package router
+
+import (
+	"sync"
+	"time"
+
+	"distributed-llm/internal/gossip"
+)
+
+// loadReportTTL is how long another node's queue depths count after it last
+// reported them, so that departed nodes stop contributing
+const loadReportTTL = time.Minute
+
+// loadReport is a node's queue depth per model
+type loadReport struct {
+	gossip.Report
+	Depths map[string]int `json:"depths"`
+}
+
+// loadTable holds the latest report of every node, including our own
+type loadTable struct {
+	mu      sync.RWMutex
+	reports *gossip.Table[loadReport, *loadReport]
+}
+
+func newLoadTable(nodeID string) *loadTable {
+	return &loadTable{
+		reports: gossip.NewTable(nodeID, loadReportTTL, &loadReport{Depths: make(map[string]int)}),
+	}
+}
+
+func (t *loadTable) setLocal(modelID string, depth int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	local := t.reports.Local()
+	if depth == 0 {
+		delete(local.Depths, modelID)
+	} else {
+		local.Depths[modelID] = depth
+	}
+	local.Sent = time.Now()
+}
+
+func (t *loadTable) total(modelID string) int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	total := 0
+	t.reports.Each(time.Now(), func(report *loadReport) {
+		total += report.Depths[modelID]
+	})
+	return total
+}
+
+// encode serializes our own report, or every report when all is set
+func (t *loadTable) encode(all bool) []byte {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if all {
+		return t.reports.Encode(time.Now())
+	}
+	return t.reports.EncodeLocal(time.Now())
+}
+
+func (t *loadTable) merge(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reports.Merge(data)
+}
+
+// LocalReport encodes this node's queue depths for gossip
+func (r *Router) LocalReport() []byte {
+	return r.load.encode(false)
+}
+
+// HandleMessage merges queue depths gossiped by another node (network.ChannelHandler)
+func (r *Router) HandleMessage(msg []byte) {
+	r.load.merge(msg)
+}
+
+// LocalState shares every node's queue depths during push/pull (network.ChannelHandler)
+func (r *Router) LocalState() []byte {
+	return r.load.encode(true)
+}
+
+// MergeRemoteState merges the queue depths of a push/pull peer (network.ChannelHandler)
+func (r *Router) MergeRemoteState(buf []byte) {
+	r.load.merge(buf)
+}
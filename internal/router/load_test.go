@@ -0,0 +1,53 @@
+package router
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"distributed-llm/internal/gossip"
+	"distributed-llm/pkg/models"
+)
+
+func TestQueueDepthGossip(t *testing.T) {
+	a, b := New("node-a", LeastOutstanding), New("node-b", LeastOutstanding)
+	plans := []models.PlacementPlan{replicaPlan("llama-7b", 0, "node-a")}
+	a.Update(plans)
+	b.Update(plans)
+
+	for i := 0; i < 2; i++ {
+		a.Pick("llama-7b")
+	}
+	lease, _ := b.Pick("llama-7b")
+
+	b.HandleMessage(a.LocalReport())
+	a.MergeRemoteState(b.LocalState())
+	if depth := a.QueueDepth("llama-7b"); depth != 3 {
+		t.Errorf("Expected cluster queue depth 3 on node-a, got %d", depth)
+	}
+	if depth := b.QueueDepth("llama-7b"); depth != 3 {
+		t.Errorf("Expected cluster queue depth 3 on node-b, got %d", depth)
+	}
+
+	// Older reports are ignored
+	stale := b.LocalReport()
+	lease.Done(nil)
+	a.HandleMessage(b.LocalReport())
+	a.HandleMessage(stale)
+	if depth := a.QueueDepth("llama-7b"); depth != 2 {
+		t.Errorf("Expected the newer report to win, got %d", depth)
+	}
+}
+
+func TestQueueDepthIgnoresStaleNodes(t *testing.T) {
+	r := New("node-a", LeastOutstanding)
+	report, _ := json.Marshal([]loadReport{
+		{Report: gossip.Report{NodeID: "gone", Sent: time.Now().Add(-2 * loadReportTTL)}, Depths: map[string]int{"llama-7b": 5}},
+		{Report: gossip.Report{NodeID: "live", Sent: time.Now()}, Depths: map[string]int{"llama-7b": 1}},
+	})
+	r.MergeRemoteState(report)
+
+	if depth := r.QueueDepth("llama-7b"); depth != 1 {
+		t.Errorf("Expected only the live node's queue, got %d", depth)
+	}
+}
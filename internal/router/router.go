@@ -0,0 +1,313 @@
+// Package router load-balances inference requests across the replica
+// pipelines of a model.
+package router
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+var (
+	// ErrNoReplicas means the model has no placed replicas
+	ErrNoReplicas = errors.New("model has no replicas")
+	// ErrNoHealthyReplicas means every replica of the model is unhealthy
+	ErrNoHealthyReplicas = errors.New("no healthy replicas")
+)
+
+// Policy chooses between healthy replicas
+type Policy string
+
+const (
+	// LeastOutstanding sends each request to the replica with the fewest
+	// requests in flight
+	LeastOutstanding Policy = "least-outstanding"
+	// PowerOfTwoChoices compares two random replicas and picks the less
+	// loaded, which avoids every router herding onto the same replica
+	PowerOfTwoChoices Policy = "p2c"
+)
+
+// ParsePolicy validates a policy name
+func ParsePolicy(name string) (Policy, error) {
+	switch policy := Policy(name); policy {
+	case LeastOutstanding, PowerOfTwoChoices:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown routing policy %q (want %s or %s)", name, LeastOutstanding, PowerOfTwoChoices)
+	}
+}
+
+// Passive health checking: a replica failing this many requests in a row is
+// ejected for ejectDuration
+const (
+	maxConsecutiveFailures = 3
+	ejectDuration          = 10 * time.Second
+)
+
+// latencySmoothing weighs new samples in a replica's average latency
+const latencySmoothing = 0.2
+
+// HealthSource reports whether a node can serve its pipeline stages
+type HealthSource interface {
+	NodeHealthy(nodeID string) bool
+}
+
+// Metrics exports the router's queue depth
+type Metrics interface {
+	UpdateQueueDepth(modelID string, depth int)
+}
+
+// replica tracks the load and health of one pipeline. Its fields are
+// guarded by the router's mutex.
+type replica struct {
+	plan                models.PlacementPlan
+	outstanding         int
+	served              uint64
+	failed              uint64
+	consecutiveFailures int
+	ejectedUntil        time.Time
+	latency             time.Duration
+}
+
+// ReplicaStats describes a replica as seen by this router
+type ReplicaStats struct {
+	ModelID     string
+	Replica     int
+	Nodes       []string
+	Healthy     bool
+	Outstanding int
+	Served      uint64
+	Failed      uint64
+	Latency     time.Duration
+}
+
+// Router picks a replica pipeline for each request
+type Router struct {
+	policy  Policy
+	health  HealthSource
+	metrics Metrics
+
+	mu       sync.Mutex
+	replicas map[string][]*replica
+	rand     *rand.Rand
+	now      func() time.Time
+
+	load *loadTable
+}
+
+// New creates a router for the node nodeID
+func New(nodeID string, policy Policy) *Router {
+	return &Router{
+		policy:   policy,
+		replicas: make(map[string][]*replica),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:      time.Now,
+		load:     newLoadTable(nodeID),
+	}
+}
+
+// SetHealthSource makes the router skip replicas with unhealthy nodes
+func (r *Router) SetHealthSource(health HealthSource) {
+	r.health = health
+}
+
+// SetMetrics exports queue depths
+func (r *Router) SetMetrics(metrics Metrics) {
+	r.metrics = metrics
+}
+
+// Update replaces the set of replicas. Replicas whose pipeline is unchanged
+// keep their load and health.
+func (r *Router) Update(plans []models.PlacementPlan) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := make(map[string]*replica)
+	for _, replicas := range r.replicas {
+		for _, rep := range replicas {
+			current[rep.plan.Key()] = rep
+		}
+	}
+
+	updated := make(map[string][]*replica)
+	for _, plan := range plans {
+		rep, ok := current[plan.Key()]
+		if !ok || !slices.Equal(rep.plan.Stages, plan.Stages) {
+			rep = &replica{plan: plan}
+		}
+		updated[plan.ModelID] = append(updated[plan.ModelID], rep)
+	}
+	for _, replicas := range updated {
+		sort.Slice(replicas, func(i, j int) bool { return replicas[i].plan.Replica < replicas[j].plan.Replica })
+	}
+	r.replicas = updated
+}
+
+// Lease is a request routed to a replica. Done must be called once the
+// request finishes.
+type Lease struct {
+	// Plan is the pipeline serving the request
+	Plan models.PlacementPlan
+
+	router  *Router
+	replica *replica
+	start   time.Time
+	once    sync.Once
+}
+
+// Done releases the replica, recording whether the request failed
+func (l *Lease) Done(err error) {
+	l.once.Do(func() {
+		l.router.release(l, err)
+	})
+}
+
+// Pick chooses a healthy replica of the model according to the policy
+func (r *Router) Pick(modelID string) (*Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replicas := r.replicas[modelID]
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoReplicas, modelID)
+	}
+
+	healthy := make([]*replica, 0, len(replicas))
+	for _, rep := range replicas {
+		if r.healthyLocked(rep) {
+			healthy = append(healthy, rep)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, fmt.Errorf("%w: all %d replicas of %s are down", ErrNoHealthyReplicas, len(replicas), modelID)
+	}
+
+	chosen := r.chooseLocked(healthy)
+	chosen.outstanding++
+	r.queueChangedLocked(modelID)
+	return &Lease{Plan: chosen.plan, router: r, replica: chosen, start: r.now()}, nil
+}
+
+func (r *Router) chooseLocked(healthy []*replica) *replica {
+	if r.policy == PowerOfTwoChoices && len(healthy) > 2 {
+		i := r.rand.Intn(len(healthy))
+		j := r.rand.Intn(len(healthy) - 1)
+		if j >= i {
+			j++
+		}
+		return lessLoaded(healthy[i], healthy[j])
+	}
+
+	best := healthy[0]
+	for _, rep := range healthy[1:] {
+		best = lessLoaded(best, rep)
+	}
+	return best
+}
+
+// lessLoaded prefers fewer requests in flight, then the lower latency
+func lessLoaded(a, b *replica) *replica {
+	if a.outstanding != b.outstanding {
+		if a.outstanding < b.outstanding {
+			return a
+		}
+		return b
+	}
+	if b.latency < a.latency {
+		return b
+	}
+	return a
+}
+
+func (r *Router) healthyLocked(rep *replica) bool {
+	if r.now().Before(rep.ejectedUntil) {
+		return false
+	}
+	if r.health != nil {
+		for _, id := range rep.plan.NodeIDs() {
+			if !r.health.NodeHealthy(id) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *Router) release(l *Lease, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := l.replica
+	rep.outstanding--
+	if err != nil {
+		rep.failed++
+		rep.consecutiveFailures++
+		if rep.consecutiveFailures >= maxConsecutiveFailures {
+			rep.ejectedUntil = r.now().Add(ejectDuration)
+			rep.consecutiveFailures = 0
+		}
+	} else {
+		rep.served++
+		rep.consecutiveFailures = 0
+		elapsed := r.now().Sub(l.start)
+		if rep.latency == 0 {
+			rep.latency = elapsed
+		} else {
+			rep.latency += time.Duration(latencySmoothing * float64(elapsed-rep.latency))
+		}
+	}
+	r.queueChangedLocked(rep.plan.ModelID)
+}
+
+// queueChangedLocked publishes the model's local queue depth
+func (r *Router) queueChangedLocked(modelID string) {
+	depth := 0
+	for _, rep := range r.replicas[modelID] {
+		depth += rep.outstanding
+	}
+	r.load.setLocal(modelID, depth)
+	if r.metrics != nil {
+		r.metrics.UpdateQueueDepth(modelID, depth)
+	}
+}
+
+// QueueDepth returns the requests in flight for the model across the
+// cluster, as last reported by each node's router
+func (r *Router) QueueDepth(modelID string) int {
+	return r.load.total(modelID)
+}
+
+// Stats describes every replica, ordered by model and replica number
+func (r *Router) Stats() []ReplicaStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modelIDs := make([]string, 0, len(r.replicas))
+	for modelID := range r.replicas {
+		modelIDs = append(modelIDs, modelID)
+	}
+	sort.Strings(modelIDs)
+
+	stats := make([]ReplicaStats, 0)
+	for _, modelID := range modelIDs {
+		for _, rep := range r.replicas[modelID] {
+			stats = append(stats, ReplicaStats{
+				ModelID:     modelID,
+				Replica:     rep.plan.Replica,
+				Nodes:       rep.plan.NodeIDs(),
+				Healthy:     r.healthyLocked(rep),
+				Outstanding: rep.outstanding,
+				Served:      rep.served,
+				Failed:      rep.failed,
+				Latency:     rep.latency,
+			})
+		}
+	}
+	return stats
+}
@@ -0,0 +1,156 @@
+package router
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+// replicaPlan returns a single-stage plan for a replica on node
+func replicaPlan(modelID string, replica int, node string) models.PlacementPlan {
+	return models.PlacementPlan{
+		ModelID: modelID,
+		Replica: replica,
+		Stages:  []models.PipelineStage{{NodeID: node, LayerStart: 0, LayerEnd: 31}},
+	}
+}
+
+// downNodes marks nodes unhealthy
+type downNodes map[string]bool
+
+func (d downNodes) NodeHealthy(nodeID string) bool { return !d[nodeID] }
+
+func TestParsePolicy(t *testing.T) {
+	for _, name := range []string{"least-outstanding", "p2c"} {
+		if _, err := ParsePolicy(name); err != nil {
+			t.Errorf("ParsePolicy(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParsePolicy("round-robin"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestLeastOutstanding(t *testing.T) {
+	r := New("node-a", LeastOutstanding)
+	r.Update([]models.PlacementPlan{
+		replicaPlan("llama-7b", 0, "node-a"),
+		replicaPlan("llama-7b", 1, "node-b"),
+		replicaPlan("llama-7b", 2, "node-c"),
+	})
+
+	// Requests spread over every replica before any gets a second one
+	leases := make([]*Lease, 0)
+	seen := make(map[int]int)
+	for i := 0; i < 6; i++ {
+		lease, err := r.Pick("llama-7b")
+		if err != nil {
+			t.Fatalf("Pick failed: %v", err)
+		}
+		leases = append(leases, lease)
+		seen[lease.Plan.Replica]++
+	}
+	for replica := 0; replica < 3; replica++ {
+		if seen[replica] != 2 {
+			t.Errorf("Expected 2 requests on replica %d, got %v", replica, seen)
+		}
+	}
+	if depth := r.QueueDepth("llama-7b"); depth != 6 {
+		t.Errorf("Expected queue depth 6, got %d", depth)
+	}
+
+	for _, lease := range leases {
+		lease.Done(nil)
+		lease.Done(nil) // repeated calls are ignored
+	}
+	if depth := r.QueueDepth("llama-7b"); depth != 0 {
+		t.Errorf("Expected empty queue, got %d", depth)
+	}
+}
+
+func TestPowerOfTwoChoices(t *testing.T) {
+	r := New("node-a", PowerOfTwoChoices)
+	plans := make([]models.PlacementPlan, 0)
+	for i, node := range []string{"node-a", "node-b", "node-c", "node-d"} {
+		plans = append(plans, replicaPlan("llama-7b", i, node))
+	}
+	r.Update(plans)
+
+	// Load replica 0 heavily; it can only win when compared with itself
+	for i := 0; i < 10; i++ {
+		if _, err := r.Pick("llama-7b"); err != nil {
+			t.Fatalf("Pick failed: %v", err)
+		}
+	}
+	stats := r.Stats()
+	total := 0
+	for _, s := range stats {
+		total += s.Outstanding
+		if s.Outstanding > 5 {
+			t.Errorf("Expected load spread across replicas, got %+v", stats)
+		}
+	}
+	if total != 10 {
+		t.Errorf("Expected 10 requests in flight, got %d", total)
+	}
+}
+
+func TestRouterHealth(t *testing.T) {
+	r := New("node-a", LeastOutstanding)
+	down := downNodes{"node-b": true}
+	r.SetHealthSource(down)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	r.Update([]models.PlacementPlan{
+		replicaPlan("llama-7b", 0, "node-a"),
+		replicaPlan("llama-7b", 1, "node-b"),
+	})
+
+	// Unhealthy nodes take their replicas out of rotation
+	for i := 0; i < 3; i++ {
+		lease, err := r.Pick("llama-7b")
+		if err != nil {
+			t.Fatalf("Pick failed: %v", err)
+		}
+		if lease.Plan.Replica != 0 {
+			t.Errorf("Expected replica 0 while node-b is down, got %d", lease.Plan.Replica)
+		}
+		lease.Done(errors.New("backend crashed"))
+	}
+
+	// Failing replicas are ejected for a while
+	if _, err := r.Pick("llama-7b"); !errors.Is(err, ErrNoHealthyReplicas) {
+		t.Errorf("Expected %v after repeated failures, got %v", ErrNoHealthyReplicas, err)
+	}
+	now = now.Add(ejectDuration)
+	delete(down, "node-b")
+	if _, err := r.Pick("llama-7b"); err != nil {
+		t.Errorf("Expected replicas back after the ejection, got %v", err)
+	}
+
+	if _, err := r.Pick("unknown"); !errors.Is(err, ErrNoReplicas) {
+		t.Errorf("Expected %v for an unplaced model, got %v", ErrNoReplicas, err)
+	}
+}
+
+func TestRouterUpdateKeepsStats(t *testing.T) {
+	r := New("node-a", LeastOutstanding)
+	r.Update([]models.PlacementPlan{replicaPlan("llama-7b", 0, "node-a"), replicaPlan("llama-7b", 1, "node-b")})
+
+	lease, _ := r.Pick("llama-7b")
+	lease.Done(nil)
+
+	// Replica 1 moves to another node and loses its history; replica 0 keeps it
+	r.Update([]models.PlacementPlan{replicaPlan("llama-7b", 0, "node-a"), replicaPlan("llama-7b", 1, "node-c")})
+	stats := r.Stats()
+	if len(stats) != 2 || stats[0].Served != 1 || stats[1].Served != 0 || stats[1].Nodes[0] != "node-c" {
+		t.Errorf("Unexpected stats after update: %+v", stats)
+	}
+
+	r.Update(nil)
+	if stats := r.Stats(); len(stats) != 0 {
+		t.Errorf("Expected no replicas, got %+v", stats)
+	}
+}
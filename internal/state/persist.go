@@ -0,0 +1,156 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	walFile      = "wal.log"
+)
+
+// diskLog persists applied entries to a write-ahead log and periodically
+// compacts them into a snapshot. It is not safe for concurrent use; the
+// Store serializes access.
+type diskLog struct {
+	dir     string
+	wal     *os.File
+	entries int
+}
+
+func openDiskLog(dir string) (*diskLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create state directory: %w", err)
+	}
+
+	wal, err := os.OpenFile(filepath.Join(dir, walFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open write-ahead log: %w", err)
+	}
+
+	return &diskLog{dir: dir, wal: wal}, nil
+}
+
+// load restores the latest snapshot and replays any newer log entries on top of it
+func (l *diskLog) load() (*State, error) {
+	st := NewState()
+
+	data, err := os.ReadFile(filepath.Join(l.dir, snapshotFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	default:
+		if err := json.Unmarshal(data, st); err != nil {
+			return nil, fmt.Errorf("decode snapshot: %w", err)
+		}
+		st.normalize()
+	}
+
+	if _, err := l.wal.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("rewind write-ahead log: %w", err)
+	}
+	data, err = io.ReadAll(l.wal)
+	if err != nil {
+		return nil, fmt.Errorf("read write-ahead log: %w", err)
+	}
+
+	// good tracks the end of the last intact entry
+	good := 0
+	for good < len(data) {
+		end := bytes.IndexByte(data[good:], '\n')
+		if end < 0 {
+			break // A torn final write is expected after a crash
+		}
+		line := data[good : good+end]
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			break
+		}
+		good += end + 1
+
+		if entry.Index <= st.Index {
+			continue
+		}
+		if err := st.Apply(entry); err != nil {
+			return nil, fmt.Errorf("replay entry %d: %w", entry.Index, err)
+		}
+		l.entries++
+	}
+
+	if good < len(data) {
+		// Drop the damaged tail so new entries are not appended after it
+		if err := l.wal.Truncate(int64(good)); err != nil {
+			return nil, fmt.Errorf("truncate damaged write-ahead log: %w", err)
+		}
+	}
+
+	return st, nil
+}
+
+// append durably records an applied entry
+func (l *diskLog) append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if _, err := l.wal.Write(data); err != nil {
+		return fmt.Errorf("write entry %d: %w", e.Index, err)
+	}
+	if err := l.wal.Sync(); err != nil {
+		return fmt.Errorf("sync entry %d: %w", e.Index, err)
+	}
+	l.entries++
+	return nil
+}
+
+// snapshot atomically writes the state to disk and truncates the log
+func (l *diskLog) snapshot(st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(l.dir, snapshotFile+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(l.dir, snapshotFile)); err != nil {
+		return fmt.Errorf("install snapshot: %w", err)
+	}
+
+	if err := l.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate write-ahead log: %w", err)
+	}
+	l.entries = 0
+	return nil
+}
+
+func (l *diskLog) close() error {
+	return l.wal.Close()
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
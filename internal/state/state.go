@@ -0,0 +1,197 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"distributed-llm/pkg/models"
+)
+
+// Op identifies the mutation carried by a log entry
+type Op string
+
+const (
+	OpPutModel    Op = "put_model"
+	OpDeleteModel Op = "delete_model"
+	OpPutPlan     Op = "put_plan"
+	OpDeletePlan  Op = "delete_plan"
+	OpCordon      Op = "cordon"
+	OpUncordon    Op = "uncordon"
+	OpPutQuota    Op = "put_quota"
+	OpDeleteQuota Op = "delete_quota"
+)
+
+// Entry is a single replicated log entry
+type Entry struct {
+	Index uint64 `json:"index"`
+	Term  uint64 `json:"term"`
+	// Leader is the node that started the term
+	Leader string          `json:"leader,omitempty"`
+	ID     string          `json:"id"`
+	Op     Op              `json:"op"`
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value,omitempty"`
+}
+
+// State is the replicated cluster state machine
+type State struct {
+	Index uint64 `json:"index"`
+	Term  uint64 `json:"term"`
+	// Leader is the node that started the term
+	Leader   string                          `json:"leader,omitempty"`
+	Models   map[string]models.Model         `json:"models"`
+	Plans    map[string]models.PlacementPlan `json:"plans"`
+	Cordoned map[string]bool                 `json:"cordoned"`
+	Quotas   map[string]models.Quota         `json:"quotas"`
+}
+
+// NewState returns an empty state
+func NewState() *State {
+	return &State{
+		Models:   make(map[string]models.Model),
+		Plans:    make(map[string]models.PlacementPlan),
+		Cordoned: make(map[string]bool),
+		Quotas:   make(map[string]models.Quota),
+	}
+}
+
+// Apply applies an entry to the state. Entries must be applied in index order.
+func (s *State) Apply(e Entry) error {
+	if e.Index != s.Index+1 {
+		return fmt.Errorf("out of order entry: have index %d, got %d", s.Index, e.Index)
+	}
+
+	switch e.Op {
+	case OpPutModel:
+		var model models.Model
+		if err := json.Unmarshal(e.Value, &model); err != nil {
+			return fmt.Errorf("decode model %s: %w", e.Key, err)
+		}
+		s.Models[e.Key] = model
+	case OpDeleteModel:
+		delete(s.Models, e.Key)
+	case OpPutPlan:
+		var plan models.PlacementPlan
+		if err := json.Unmarshal(e.Value, &plan); err != nil {
+			return fmt.Errorf("decode plan %s: %w", e.Key, err)
+		}
+		s.Plans[e.Key] = plan
+	case OpDeletePlan:
+		delete(s.Plans, e.Key)
+	case OpCordon:
+		s.Cordoned[e.Key] = true
+	case OpUncordon:
+		delete(s.Cordoned, e.Key)
+	case OpPutQuota:
+		var quota models.Quota
+		if err := json.Unmarshal(e.Value, &quota); err != nil {
+			return fmt.Errorf("decode quota %s: %w", e.Key, err)
+		}
+		s.Quotas[e.Key] = quota
+	case OpDeleteQuota:
+		delete(s.Quotas, e.Key)
+	default:
+		return fmt.Errorf("unknown op %q", e.Op)
+	}
+
+	s.Index = e.Index
+	if laterTerm(e.Term, e.Leader, s.Term, s.Leader) {
+		s.Term, s.Leader = e.Term, e.Leader
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the state
+func (s *State) Clone() *State {
+	c := NewState()
+	c.Index = s.Index
+	c.Term = s.Term
+	c.Leader = s.Leader
+	for k, v := range s.Models {
+		c.Models[k] = v
+	}
+	for k, v := range s.Plans {
+		v.Stages = append([]models.PipelineStage(nil), v.Stages...)
+		c.Plans[k] = v
+	}
+	for k, v := range s.Cordoned {
+		c.Cordoned[k] = v
+	}
+	for k, v := range s.Quotas {
+		c.Quotas[k] = v
+	}
+	return c
+}
+
+// ModelsOn returns the registered models with a replica placed on the node
+func (s *State) ModelsOn(nodeID string) []models.Model {
+	seen := make(map[string]bool)
+	assigned := make([]models.Model, 0)
+	for _, plan := range s.Plans {
+		model, ok := s.Models[plan.ModelID]
+		if !ok || seen[model.ID] {
+			continue
+		}
+		for _, id := range plan.NodeIDs() {
+			if id == nodeID {
+				assigned = append(assigned, model)
+				seen[model.ID] = true
+				break
+			}
+		}
+	}
+	sort.Slice(assigned, func(i, j int) bool { return assigned[i].ID < assigned[j].ID })
+	return assigned
+}
+
+// Replicas returns the plans of every replica of a model, by replica number
+func (s *State) Replicas(modelID string) []models.PlacementPlan {
+	replicas := make([]models.PlacementPlan, 0)
+	for _, plan := range s.Plans {
+		if plan.ModelID == modelID {
+			replicas = append(replicas, plan)
+		}
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].Replica < replicas[j].Replica })
+	return replicas
+}
+
+// newer reports whether s is ahead of other. Higher terms win so that a
+// new leader's history replaces entries a stale leader wrote concurrently.
+func (s *State) newer(other *State) bool {
+	if s.Term != other.Term || s.Leader != other.Leader {
+		return laterTerm(s.Term, s.Leader, other.Term, other.Leader)
+	}
+	return s.Index > other.Index
+}
+
+// laterTerm reports whether a term started by one leader supersedes a term
+// started by another. Members that each believed they led may start the
+// same term; the tie goes to the lower node ID, the leader the others
+// choose, and terms recorded without their leader lose it.
+func laterTerm(term uint64, leader string, than uint64, thanLeader string) bool {
+	if term != than {
+		return term > than
+	}
+	if leader == "" || thanLeader == "" {
+		return leader != "" && thanLeader == ""
+	}
+	return leader < thanLeader
+}
+
+// normalize replaces nil maps left behind by decoding an older or partial snapshot
+func (s *State) normalize() {
+	if s.Models == nil {
+		s.Models = make(map[string]models.Model)
+	}
+	if s.Plans == nil {
+		s.Plans = make(map[string]models.PlacementPlan)
+	}
+	if s.Cordoned == nil {
+		s.Cordoned = make(map[string]bool)
+	}
+	if s.Quotas == nil {
+		s.Quotas = make(map[string]models.Quota)
+	}
+}
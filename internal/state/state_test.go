@@ -0,0 +1,193 @@
+package state
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"distributed-llm/pkg/models"
+)
+
+func mustEntry(t *testing.T, index uint64, op Op, key string, value any) Entry {
+	t.Helper()
+	entry := Entry{Index: index, Term: 1, Op: op, Key: key}
+	if value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("Failed to encode value: %v", err)
+		}
+		entry.Value = data
+	}
+	return entry
+}
+
+func TestStateApply(t *testing.T) {
+	st := NewState()
+
+	model := models.Model{ID: "llama-7b", Name: "Llama 2 7B", LayerCount: 32}
+	plan := models.PlacementPlan{
+		ModelID: "llama-7b",
+		Stages: []models.PipelineStage{
+			{NodeID: "node-1", LayerStart: 0, LayerEnd: 15},
+			{NodeID: "node-2", LayerStart: 16, LayerEnd: 31},
+		},
+	}
+	quota := models.Quota{RequestsPerSecond: 5, TokensPerMinute: 1000, MaxConcurrent: 2, Models: []string{"llama-7b"}}
+
+	entries := []Entry{
+		mustEntry(t, 1, OpPutModel, model.ID, model),
+		mustEntry(t, 2, OpPutPlan, plan.ModelID, plan),
+		mustEntry(t, 3, OpCordon, "node-3", nil),
+		mustEntry(t, 4, OpPutQuota, "team-a", quota),
+	}
+	for _, e := range entries {
+		if err := st.Apply(e); err != nil {
+			t.Fatalf("Apply(%d) failed: %v", e.Index, err)
+		}
+	}
+
+	if st.Index != 4 {
+		t.Errorf("Expected index 4, got %d", st.Index)
+	}
+	if got := st.Models["llama-7b"]; got.LayerCount != 32 {
+		t.Errorf("Expected model with 32 layers, got %+v", got)
+	}
+	if got := st.Plans["llama-7b"]; len(got.Stages) != 2 || got.Stages[1].NodeID != "node-2" {
+		t.Errorf("Unexpected plan: %+v", got)
+	}
+	if !st.Cordoned["node-3"] {
+		t.Error("Expected node-3 to be cordoned")
+	}
+	if got := st.Quotas["team-a"]; !reflect.DeepEqual(got, quota) {
+		t.Errorf("Expected quota %+v, got %+v", quota, got)
+	}
+
+	removals := []Entry{
+		mustEntry(t, 5, OpDeleteModel, model.ID, nil),
+		mustEntry(t, 6, OpDeletePlan, plan.ModelID, nil),
+		mustEntry(t, 7, OpUncordon, "node-3", nil),
+		mustEntry(t, 8, OpDeleteQuota, "team-a", nil),
+	}
+	for _, e := range removals {
+		if err := st.Apply(e); err != nil {
+			t.Fatalf("Apply(%d) failed: %v", e.Index, err)
+		}
+	}
+
+	if len(st.Models) != 0 || len(st.Plans) != 0 || len(st.Cordoned) != 0 || len(st.Quotas) != 0 {
+		t.Errorf("Expected empty state after removals, got %+v", st)
+	}
+}
+
+func TestStateApplyRejectsBadEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{"gap in index", Entry{Index: 2, Op: OpCordon, Key: "node-1"}},
+		{"replayed index", Entry{Index: 0, Op: OpCordon, Key: "node-1"}},
+		{"unknown op", Entry{Index: 1, Op: "explode", Key: "node-1"}},
+		{"malformed value", Entry{Index: 1, Op: OpPutModel, Key: "m", Value: json.RawMessage(`{`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := NewState()
+			if err := st.Apply(tt.entry); err == nil {
+				t.Error("Expected error but got none")
+			}
+			if st.Index != 0 {
+				t.Errorf("Expected index to stay 0, got %d", st.Index)
+			}
+		})
+	}
+}
+
+func TestStateClone(t *testing.T) {
+	st := NewState()
+	plan := models.PlacementPlan{
+		ModelID: "m",
+		Stages:  []models.PipelineStage{{NodeID: "node-1", LayerStart: 0, LayerEnd: 9}},
+	}
+	if err := st.Apply(mustEntry(t, 1, OpPutPlan, "m", plan)); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	clone := st.Clone()
+	clone.Plans["m"].Stages[0].NodeID = "changed"
+	clone.Cordoned["node-2"] = true
+
+	if st.Plans["m"].Stages[0].NodeID != "node-1" {
+		t.Error("Mutating clone changed original plan stages")
+	}
+	if st.Cordoned["node-2"] {
+		t.Error("Mutating clone changed original cordon flags")
+	}
+}
+
+func TestStateNewer(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  State
+		newer bool
+	}{
+		{"higher index same term", State{Term: 1, Index: 5}, State{Term: 1, Index: 4}, true},
+		{"lower index same term", State{Term: 1, Index: 3}, State{Term: 1, Index: 4}, false},
+		{"higher term wins over index", State{Term: 2, Index: 1}, State{Term: 1, Index: 10}, true},
+		{"equal", State{Term: 1, Index: 4}, State{Term: 1, Index: 4}, false},
+		{"same term, lower leader ID", State{Term: 2, Leader: "node-a", Index: 1}, State{Term: 2, Leader: "node-b", Index: 10}, true},
+		{"same term, higher leader ID", State{Term: 2, Leader: "node-b", Index: 10}, State{Term: 2, Leader: "node-a", Index: 1}, false},
+		{"same term, unknown leader", State{Term: 2, Index: 10}, State{Term: 2, Leader: "node-b", Index: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.newer(&tt.b); got != tt.newer {
+				t.Errorf("newer() = %v, want %v", got, tt.newer)
+			}
+		})
+	}
+}
+
+func TestStateModelsOn(t *testing.T) {
+	st := NewState()
+	st.Models["b-model"] = models.Model{ID: "b-model"}
+	st.Models["a-model"] = models.Model{ID: "a-model"}
+	st.Models["unplanned"] = models.Model{ID: "unplanned"}
+	st.Plans["b-model"] = models.PlacementPlan{ModelID: "b-model", Stages: []models.PipelineStage{{NodeID: "node-1"}, {NodeID: "node-2"}}}
+	st.Plans["a-model"] = models.PlacementPlan{ModelID: "a-model", Stages: []models.PipelineStage{{NodeID: "node-2"}}}
+	st.Plans["orphan"] = models.PlacementPlan{ModelID: "orphan", Stages: []models.PipelineStage{{NodeID: "node-2"}}}
+
+	assigned := st.ModelsOn("node-2")
+	if len(assigned) != 2 || assigned[0].ID != "a-model" || assigned[1].ID != "b-model" {
+		t.Errorf("ModelsOn(node-2) = %+v, want [a-model b-model]", assigned)
+	}
+	if got := st.ModelsOn("node-3"); len(got) != 0 {
+		t.Errorf("Expected no models on node-3, got %+v", got)
+	}
+
+	// A model is listed once even with several replicas on the node
+	st.Plans["a-model#1"] = models.PlacementPlan{ModelID: "a-model", Replica: 1, Stages: []models.PipelineStage{{NodeID: "node-2"}}}
+	if assigned := st.ModelsOn("node-2"); len(assigned) != 2 {
+		t.Errorf("ModelsOn(node-2) = %+v, want each model once", assigned)
+	}
+}
+
+func TestStateReplicas(t *testing.T) {
+	st := NewState()
+	for _, replica := range []int{2, 0, 1} {
+		plan := models.PlacementPlan{ModelID: "llama-7b", Replica: replica}
+		st.Plans[plan.Key()] = plan
+	}
+	st.Plans["other"] = models.PlacementPlan{ModelID: "other"}
+
+	replicas := st.Replicas("llama-7b")
+	if len(replicas) != 3 {
+		t.Fatalf("Expected 3 replicas, got %+v", replicas)
+	}
+	for i, plan := range replicas {
+		if plan.Replica != i {
+			t.Errorf("Replica %d = %+v, want replicas in order", i, plan)
+		}
+	}
+}
@@ -0,0 +1,742 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+// DefaultSnapshotThreshold is the number of logged entries after which the
+// store compacts its write-ahead log into a snapshot
+const DefaultSnapshotThreshold = 1024
+
+// syncRetryInterval limits how often a lagging follower asks for a snapshot
+const syncRetryInterval = 2 * time.Second
+
+// heartbeatInterval is how often the leader announces its index, so that
+// followers that missed its last entries catch up without waiting for the
+// next write
+const heartbeatInterval = 2 * time.Second
+
+// ErrClosed is returned when proposing to a closed store
+var ErrClosed = errors.New("state store closed")
+
+// ErrNotLeader is returned when a proposal reaches a member that does not
+// consider itself the leader
+var ErrNotLeader = errors.New("not the cluster state leader")
+
+// ErrProposalLost is returned when the leader a proposal was forwarded to
+// changed, or the state was replaced by a snapshot, before the proposal was
+// known to be applied. Every operation is idempotent, so it is safe to retry.
+var ErrProposalLost = errors.New("proposal lost to a leadership change or state sync")
+
+// Transport delivers state messages between cluster members
+type Transport interface {
+	// LocalID returns the ID of this node
+	LocalID() string
+	// Members returns the IDs of all live members, including this node
+	Members() []string
+	// Broadcast sends a message to every other member
+	Broadcast(msg []byte)
+	// SendTo reliably sends a message to a single member
+	SendTo(nodeID string, msg []byte) error
+}
+
+// Config configures a Store
+type Config struct {
+	NodeID string
+	// DataDir holds the snapshot and write-ahead log. Empty keeps state in memory only.
+	DataDir           string
+	SnapshotThreshold int
+}
+
+type msgType string
+
+const (
+	msgEntry       msgType = "entry"
+	msgPropose     msgType = "propose"
+	msgAck         msgType = "ack"
+	msgHeartbeat   msgType = "heartbeat"
+	msgSyncRequest msgType = "sync_request"
+	msgSnapshot    msgType = "snapshot"
+)
+
+type message struct {
+	Type  msgType `json:"type"`
+	From  string  `json:"from"`
+	Entry *Entry  `json:"entry,omitempty"`
+	State *State  `json:"state,omitempty"`
+	// Term, Leader and Index are the leader's state on heartbeats
+	Term   uint64 `json:"term,omitempty"`
+	Leader string `json:"leader,omitempty"`
+	Index  uint64 `json:"index,omitempty"`
+	// Error rejects a proposal on acks
+	Error string `json:"error,omitempty"`
+}
+
+// waiter is a proposal forwarded to the leader, waiting to be applied
+type waiter struct {
+	done   chan error
+	leader string
+	// index is the entry's index once the leader acknowledged it
+	index uint64
+}
+
+// EventPublisher records what happens in the cluster (*events.Bus)
+type EventPublisher interface {
+	Publish(event models.Event) models.Event
+}
+
+// Store is a replicated state machine holding cluster-wide placement plans,
+// the model registry, cordon flags and tenant quotas.
+//
+// The leader is the live member with the lowest node ID. It orders proposals
+// into log entries and broadcasts them; followers apply entries in index order
+// and fall back to a full snapshot when they detect a gap or a newer term,
+// or a heartbeat from the leader shows they missed its last entries.
+// Leadership is derived from membership rather than elected, which favors
+// availability over strict consistency during partitions.
+type Store struct {
+	mu        sync.Mutex
+	cfg       Config
+	transport Transport
+	state     *State
+	// term is the latest term seen and termLeader the node that started it
+	term       uint64
+	termLeader string
+	leading    bool
+	pending    map[uint64]Entry
+	waiters    map[string]*waiter
+	lastSync   time.Time
+	log        *diskLog
+	closed     bool
+	stop       chan struct{}
+	events     EventPublisher
+	logger     *slog.Logger
+}
+
+// NewStore creates a store, restoring any state previously persisted under cfg.DataDir
+func NewStore(cfg Config, transport Transport) (*Store, error) {
+	if cfg.NodeID == "" {
+		return nil, fmt.Errorf("node ID cannot be empty")
+	}
+	if cfg.SnapshotThreshold <= 0 {
+		cfg.SnapshotThreshold = DefaultSnapshotThreshold
+	}
+
+	s := &Store{
+		cfg:       cfg,
+		transport: transport,
+		state:     NewState(),
+		pending:   make(map[uint64]Entry),
+		waiters:   make(map[string]*waiter),
+		stop:      make(chan struct{}),
+		logger:    slog.With("component", "state"),
+	}
+
+	if cfg.DataDir != "" {
+		log, err := openDiskLog(cfg.DataDir)
+		if err != nil {
+			return nil, err
+		}
+		st, err := log.load()
+		if err != nil {
+			log.close()
+			return nil, err
+		}
+		s.log = log
+		s.state = st
+		s.term, s.termLeader = st.Term, st.Leader
+		s.logger.Info("Restored cluster state", "index", st.Index, "term", st.Term,
+			"models", len(st.Models), "plans", len(st.Plans))
+	}
+
+	return s, nil
+}
+
+// SetEventPublisher publishes the placement plans changed by entries and
+// installed snapshots
+func (s *Store) SetEventPublisher(publisher EventPublisher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = publisher
+}
+
+// Start sends heartbeats while this node leads and fails proposals whose
+// leader changed, until ctx is done or the store is closed
+func (s *Store) Start(ctx context.Context) error {
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.tick()
+			}
+		}
+	}()
+	return nil
+}
+
+// Close snapshots the state to disk and releases the log
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	s.failWaitersLocked(ErrClosed, func(*waiter) bool { return true })
+
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.snapshot(s.state)
+	if cerr := s.log.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Leader returns the ID of the current leader
+func (s *Store) Leader() string {
+	if s.transport == nil {
+		return s.cfg.NodeID
+	}
+	members := s.transport.Members()
+	if len(members) == 0 {
+		return s.cfg.NodeID
+	}
+	sort.Strings(members)
+	return members[0]
+}
+
+// IsLeader reports whether this node currently orders proposals
+func (s *Store) IsLeader() bool {
+	return s.Leader() == s.cfg.NodeID
+}
+
+// Snapshot returns a copy of the current state
+func (s *Store) Snapshot() *State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Clone()
+}
+
+// Dump returns the current state as indented JSON for debugging
+func (s *Store) Dump() ([]byte, error) {
+	return json.MarshalIndent(s.Snapshot(), "", "  ")
+}
+
+// Propose submits a mutation and waits until it has been applied locally.
+// Proposals made on a follower are forwarded to the leader, and fail with
+// ErrNotLeader if it doesn't consider itself the leader or ErrProposalLost
+// if leadership changes meanwhile.
+func (s *Store) Propose(ctx context.Context, op Op, key string, value any) error {
+	entry := Entry{ID: newEntryID(), Op: op, Key: key}
+	if value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("encode %s value: %w", op, err)
+		}
+		entry.Value = data
+	}
+
+	leader := s.Leader()
+	if leader == s.cfg.NodeID {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return ErrClosed
+		}
+		data, err := s.commitLocked(entry)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		s.broadcast(data)
+		return nil
+	}
+
+	done := make(chan error, 1)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.waiters[entry.ID] = &waiter{done: done, leader: leader}
+	s.mu.Unlock()
+
+	if err := s.send(leader, message{Type: msgPropose, Entry: &entry}); err != nil {
+		s.dropWaiter(entry.ID)
+		return fmt.Errorf("forward proposal to leader %s: %w", leader, err)
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		s.dropWaiter(entry.ID)
+		return ctx.Err()
+	}
+}
+
+// PutModel registers or replaces a model in the registry
+func (s *Store) PutModel(ctx context.Context, model models.Model) error {
+	return s.Propose(ctx, OpPutModel, model.ID, model)
+}
+
+// PutPlan records the placement plan for a replica of a model
+func (s *Store) PutPlan(ctx context.Context, plan models.PlacementPlan) error {
+	return s.Propose(ctx, OpPutPlan, plan.Key(), plan)
+}
+
+// DeletePlan removes the plan stored under key, see models.PlanKey
+func (s *Store) DeletePlan(ctx context.Context, key string) error {
+	return s.Propose(ctx, OpDeletePlan, key, nil)
+}
+
+// Cordon marks a node as unschedulable for new placements
+func (s *Store) Cordon(ctx context.Context, nodeID string) error {
+	return s.Propose(ctx, OpCordon, nodeID, nil)
+}
+
+// Uncordon makes a node schedulable again
+func (s *Store) Uncordon(ctx context.Context, nodeID string) error {
+	return s.Propose(ctx, OpUncordon, nodeID, nil)
+}
+
+// PutQuota sets the quota for a tenant
+func (s *Store) PutQuota(ctx context.Context, tenant string, quota models.Quota) error {
+	return s.Propose(ctx, OpPutQuota, tenant, quota)
+}
+
+// IsCordoned reports whether a node is cordoned
+func (s *Store) IsCordoned(nodeID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Cordoned[nodeID]
+}
+
+// Plan returns the placement plan for a replica of a model
+func (s *Store) Plan(modelID string, replica int) (models.PlacementPlan, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.state.Plans[models.PlanKey(modelID, replica)]
+	return plan, ok
+}
+
+// HandleMessage processes a message received from another member
+func (s *Store) HandleMessage(data []byte) {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		s.logger.Warn("Dropping malformed state message", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+
+	// Entries are broadcast and proposals acknowledged once the lock is
+	// released, as sending may block on every member
+	var entry []byte
+	var ack *message
+	switch msg.Type {
+	case msgEntry:
+		if msg.Entry != nil {
+			s.receiveLocked(msg.From, *msg.Entry)
+		}
+	case msgPropose:
+		if msg.Entry == nil {
+			break
+		}
+		ack = &message{Type: msgAck, Entry: &Entry{ID: msg.Entry.ID}}
+		if s.Leader() != s.cfg.NodeID {
+			s.logger.Warn("Rejecting proposal received while not leader", "from", msg.From, "op", msg.Entry.Op)
+			ack.Error = ErrNotLeader.Error()
+			break
+		}
+		data, err := s.commitLocked(*msg.Entry)
+		if err != nil {
+			s.logger.Error("Failed to commit forwarded proposal", "from", msg.From, "error", err)
+			ack.Error = err.Error()
+			break
+		}
+		entry = data
+		ack.Entry.Index = s.state.Index
+	case msgAck:
+		if msg.Entry != nil {
+			s.ackLocked(msg.From, msg.Entry.ID, msg.Entry.Index, msg.Error)
+		}
+	case msgHeartbeat:
+		s.observeTermLocked(msg.Term, msg.Leader)
+		leader := &State{Term: msg.Term, Leader: msg.Leader, Index: msg.Index}
+		if !laterTerm(s.term, s.termLeader, msg.Term, msg.Leader) && leader.newer(s.state) {
+			s.requestSyncLocked(msg.From)
+		}
+	case msgSyncRequest:
+		reply := message{Type: msgSnapshot, State: s.state.Clone()}
+		go func() {
+			if err := s.send(msg.From, reply); err != nil {
+				s.logger.Warn("Failed to send state snapshot", "to", msg.From, "error", err)
+			}
+		}()
+	case msgSnapshot:
+		if msg.State != nil {
+			s.installLocked(msg.State)
+		}
+	}
+	s.mu.Unlock()
+
+	s.broadcast(entry)
+	if ack != nil {
+		go func() {
+			if err := s.send(msg.From, *ack); err != nil {
+				s.logger.Warn("Failed to acknowledge proposal", "to", msg.From, "error", err)
+			}
+		}()
+	}
+}
+
+// tick announces the leader's index to followers, and fails proposals
+// forwarded to a member that no longer leads
+func (s *Store) tick() {
+	leader := s.Leader()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.failWaitersLocked(ErrProposalLost, func(w *waiter) bool { return w.leader != leader })
+	heartbeat := message{Type: msgHeartbeat, From: s.cfg.NodeID, Term: s.state.Term, Leader: s.state.Leader, Index: s.state.Index}
+	s.mu.Unlock()
+
+	if leader != s.cfg.NodeID || s.transport == nil {
+		return
+	}
+	data, err := json.Marshal(heartbeat)
+	if err != nil {
+		s.logger.Error("Failed to encode heartbeat", "error", err)
+		return
+	}
+	s.transport.Broadcast(data)
+}
+
+// ackLocked handles the leader's answer to a forwarded proposal. Accepted
+// proposals wait for their entry, or a snapshot including it, to be applied.
+func (s *Store) ackLocked(from, id string, index uint64, reason string) {
+	w, ok := s.waiters[id]
+	if !ok {
+		return
+	}
+	switch {
+	case reason == ErrNotLeader.Error():
+		w.done <- fmt.Errorf("%s: %w", from, ErrNotLeader)
+	case reason != "":
+		w.done <- fmt.Errorf("leader %s rejected proposal: %s", from, reason)
+	case index <= s.state.Index:
+		w.done <- nil
+	default:
+		w.index = index
+		return
+	}
+	delete(s.waiters, id)
+}
+
+// failWaitersLocked fails the proposals matching fail with err
+func (s *Store) failWaitersLocked(err error, fail func(*waiter) bool) {
+	for id, w := range s.waiters {
+		if fail(w) {
+			w.done <- err
+			delete(s.waiters, id)
+		}
+	}
+}
+
+// broadcast sends an encoded entry to every other member
+func (s *Store) broadcast(data []byte) {
+	if s.transport != nil && data != nil {
+		s.transport.Broadcast(data)
+	}
+}
+
+// LocalState returns the full state for anti-entropy push/pull exchanges
+func (s *Store) LocalState() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		s.logger.Error("Failed to encode local state", "error", err)
+		return nil
+	}
+	return data
+}
+
+// MergeRemoteState adopts a peer's state if it is ahead of ours
+func (s *Store) MergeRemoteState(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	remote := NewState()
+	if err := json.Unmarshal(data, remote); err != nil {
+		s.logger.Warn("Dropping malformed remote state", "error", err)
+		return
+	}
+	remote.normalize()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.installLocked(remote)
+	}
+}
+
+// commitLocked assigns the next index to an entry and applies it, returning
+// the message replicating it for the caller to broadcast once unlocked. A
+// new leader starts a term above every term it has seen; another member
+// that believes it leads may start the same one, which is why terms are
+// told apart by their leader too.
+func (s *Store) commitLocked(entry Entry) ([]byte, error) {
+	if !s.leading {
+		s.leading = true
+		s.term, s.termLeader = s.term+1, s.cfg.NodeID
+		s.logger.Info("Leading cluster state", "term", s.term, "index", s.state.Index)
+	}
+
+	entry.Index = s.state.Index + 1
+	entry.Term, entry.Leader = s.term, s.termLeader
+	if err := s.applyLocked(entry); err != nil {
+		return nil, err
+	}
+	return json.Marshal(message{Type: msgEntry, From: s.cfg.NodeID, Entry: &entry})
+}
+
+// receiveLocked handles an entry replicated by the leader
+func (s *Store) receiveLocked(from string, entry Entry) {
+	if laterTerm(s.term, s.termLeader, entry.Term, entry.Leader) {
+		return // Stale leader, or one that lost a tie
+	}
+	s.observeTermLocked(entry.Term, entry.Leader)
+
+	switch {
+	case entry.Index <= s.state.Index:
+		if laterTerm(entry.Term, entry.Leader, s.state.Term, s.state.Leader) {
+			// Our tail was written by an older leader; take the new leader's history
+			s.requestSyncLocked(from)
+		}
+	case entry.Index == s.state.Index+1:
+		if err := s.applyLocked(entry); err != nil {
+			s.logger.Error("Failed to apply replicated entry", "index", entry.Index, "error", err)
+			return
+		}
+		s.drainPendingLocked()
+	default:
+		s.pending[entry.Index] = entry
+		s.requestSyncLocked(from)
+	}
+}
+
+// observeTermLocked adopts a term that supersedes the latest one seen,
+// giving up leadership of an older term
+func (s *Store) observeTermLocked(term uint64, leader string) {
+	if laterTerm(term, leader, s.term, s.termLeader) {
+		s.term, s.termLeader = term, leader
+		s.leading = false
+	}
+}
+
+func (s *Store) drainPendingLocked() {
+	for {
+		next, ok := s.pending[s.state.Index+1]
+		if !ok {
+			break
+		}
+		delete(s.pending, next.Index)
+		if err := s.applyLocked(next); err != nil {
+			s.logger.Error("Failed to apply buffered entry", "index", next.Index, "error", err)
+			return
+		}
+	}
+	for index := range s.pending {
+		if index <= s.state.Index {
+			delete(s.pending, index)
+		}
+	}
+}
+
+func (s *Store) applyLocked(entry Entry) error {
+	previous, planned := s.state.Plans[entry.Key]
+	if err := s.state.Apply(entry); err != nil {
+		return err
+	}
+	switch entry.Op {
+	case OpPutPlan:
+		s.publishPlanLocked(entry.Key, s.state.Plans[entry.Key], false)
+	case OpDeletePlan:
+		if planned {
+			s.publishPlanLocked(entry.Key, previous, true)
+		}
+	}
+
+	if s.log != nil {
+		if err := s.log.append(entry); err != nil {
+			s.logger.Error("Failed to persist entry", "index", entry.Index, "error", err)
+		} else if s.log.entries >= s.cfg.SnapshotThreshold {
+			if err := s.log.snapshot(s.state); err != nil {
+				s.logger.Error("Failed to snapshot state", "error", err)
+			}
+		}
+	}
+
+	s.releaseWaitersLocked(entry.ID)
+	return nil
+}
+
+// releaseWaitersLocked releases the proposal with the given ID and those
+// acknowledged at an index that has been applied
+func (s *Store) releaseWaitersLocked(id string) {
+	for waiterID, w := range s.waiters {
+		if waiterID == id || (w.index > 0 && w.index <= s.state.Index) {
+			w.done <- nil
+			delete(s.waiters, waiterID)
+		}
+	}
+}
+
+// installLocked replaces the local state with a newer remote one
+func (s *Store) installLocked(remote *State) {
+	if !remote.newer(s.state) {
+		return
+	}
+
+	s.logger.Info("Installing cluster state snapshot",
+		"fromIndex", s.state.Index, "fromTerm", s.state.Term,
+		"toIndex", remote.Index, "toTerm", remote.Term)
+
+	previous := s.state
+	s.state = remote
+	s.publishPlanChangesLocked(previous.Plans, remote.Plans)
+	s.observeTermLocked(remote.Term, remote.Leader)
+
+	if s.log != nil {
+		if err := s.log.snapshot(s.state); err != nil {
+			s.logger.Error("Failed to persist installed snapshot", "error", err)
+		}
+	}
+	s.drainPendingLocked()
+
+	// The snapshot includes the proposals acknowledged up to its index; the
+	// others may or may not be in it
+	s.releaseWaitersLocked("")
+	s.failWaitersLocked(ErrProposalLost, func(*waiter) bool { return true })
+}
+
+// publishPlanChangesLocked publishes the plans that differ between two states
+func (s *Store) publishPlanChangesLocked(before, after map[string]models.PlacementPlan) {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		old, hadPlan := before[key]
+		plan, hasPlan := after[key]
+		switch {
+		case !hasPlan:
+			s.publishPlanLocked(key, old, true)
+		case !hadPlan || !reflect.DeepEqual(old, plan):
+			s.publishPlanLocked(key, plan, false)
+		}
+	}
+}
+
+// publishPlanLocked publishes a plan that was put or deleted
+func (s *Store) publishPlanLocked(key string, plan models.PlacementPlan, deleted bool) {
+	if s.events == nil {
+		return
+	}
+	event := models.Event{
+		Type:    models.EventPlanChanged,
+		ModelID: plan.ModelID,
+		Message: "Placement plan updated",
+		Attributes: map[string]string{
+			"plan":    key,
+			"replica": strconv.Itoa(plan.Replica),
+			"nodes":   strings.Join(plan.NodeIDs(), ","),
+		},
+	}
+	if deleted {
+		event.Message = "Placement plan deleted"
+		event.Attributes["deleted"] = "true"
+	}
+	s.events.Publish(event)
+}
+
+func (s *Store) requestSyncLocked(from string) {
+	if from == "" || time.Since(s.lastSync) < syncRetryInterval {
+		return
+	}
+	s.lastSync = time.Now()
+
+	go func() {
+		if err := s.send(from, message{Type: msgSyncRequest}); err != nil {
+			s.logger.Warn("Failed to request state sync", "from", from, "error", err)
+		}
+	}()
+}
+
+func (s *Store) send(nodeID string, msg message) error {
+	if s.transport == nil {
+		return fmt.Errorf("no transport configured")
+	}
+	msg.From = s.cfg.NodeID
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.transport.SendTo(nodeID, data)
+}
+
+func (s *Store) dropWaiter(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.waiters, id)
+}
+
+func newEntryID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,571 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/models"
+)
+
+// fakeCluster delivers messages synchronously between in-process stores
+type fakeCluster struct {
+	mu     sync.Mutex
+	stores map[string]*Store
+	// partitioned members neither send nor receive
+	partitioned map[string]bool
+	// dropEntries loses every replicated entry
+	dropEntries bool
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{
+		stores:      make(map[string]*Store),
+		partitioned: make(map[string]bool),
+	}
+}
+
+type fakeTransport struct {
+	cluster *fakeCluster
+	id      string
+	// members overrides the cluster's membership as seen by this node
+	members []string
+}
+
+func (t *fakeTransport) LocalID() string { return t.id }
+
+func (t *fakeTransport) Members() []string {
+	t.cluster.mu.Lock()
+	defer t.cluster.mu.Unlock()
+	if t.members != nil {
+		return slices.Clone(t.members)
+	}
+	members := make([]string, 0, len(t.cluster.stores))
+	for id := range t.cluster.stores {
+		members = append(members, id)
+	}
+	return members
+}
+
+func (t *fakeTransport) Broadcast(msg []byte) {
+	for _, id := range t.Members() {
+		if id != t.id {
+			t.SendTo(id, msg)
+		}
+	}
+}
+
+func (t *fakeTransport) SendTo(nodeID string, msg []byte) error {
+	t.cluster.mu.Lock()
+	target := t.cluster.stores[nodeID]
+	dropped := t.cluster.partitioned[nodeID] || t.cluster.partitioned[t.id] ||
+		(t.cluster.dropEntries && bytes.Contains(msg, []byte(`"type":"entry"`)))
+	t.cluster.mu.Unlock()
+
+	if target != nil && !dropped {
+		target.HandleMessage(msg)
+	}
+	return nil
+}
+
+func (c *fakeCluster) add(t *testing.T, id, dir string) *Store {
+	t.Helper()
+	store, err := NewStore(Config{NodeID: id, DataDir: dir}, &fakeTransport{cluster: c, id: id})
+	if err != nil {
+		t.Fatalf("NewStore(%s) failed: %v", id, err)
+	}
+	c.mu.Lock()
+	c.stores[id] = store
+	c.mu.Unlock()
+	return store
+}
+
+func (c *fakeCluster) remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.stores, id)
+}
+
+func (c *fakeCluster) setPartitioned(id string, partitioned bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.partitioned[id] = partitioned
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Condition not met before timeout")
+}
+
+func TestNewStoreValidation(t *testing.T) {
+	if _, err := NewStore(Config{}, nil); err == nil {
+		t.Error("Expected error for empty node ID")
+	}
+}
+
+func TestStoreStandalone(t *testing.T) {
+	store, err := NewStore(Config{NodeID: "node-1"}, nil)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	defer store.Close()
+
+	if !store.IsLeader() {
+		t.Error("Standalone store should lead itself")
+	}
+
+	if err := store.Cordon(context.Background(), "node-2"); err != nil {
+		t.Fatalf("Cordon failed: %v", err)
+	}
+	if !store.IsCordoned("node-2") {
+		t.Error("Expected node-2 to be cordoned")
+	}
+
+	snapshot := store.Snapshot()
+	if snapshot.Index != 1 || snapshot.Term != 1 {
+		t.Errorf("Expected index 1 term 1, got index %d term %d", snapshot.Index, snapshot.Term)
+	}
+}
+
+func TestStoreReplicatesFromLeader(t *testing.T) {
+	cluster := newFakeCluster()
+	leader := cluster.add(t, "node-a", "")
+	follower := cluster.add(t, "node-b", "")
+
+	if !leader.IsLeader() || follower.IsLeader() {
+		t.Fatal("Expected lowest node ID to lead")
+	}
+
+	model := models.Model{ID: "llama-7b", Name: "Llama 2 7B", LayerCount: 32}
+	if err := leader.PutModel(context.Background(), model); err != nil {
+		t.Fatalf("PutModel failed: %v", err)
+	}
+
+	if got := follower.Snapshot().Models["llama-7b"]; got.Name != model.Name {
+		t.Errorf("Expected follower to have replicated model, got %+v", got)
+	}
+}
+
+func TestStoreForwardsFollowerProposals(t *testing.T) {
+	cluster := newFakeCluster()
+	leader := cluster.add(t, "node-a", "")
+	follower := cluster.add(t, "node-b", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	plan := models.PlacementPlan{
+		ModelID: "llama-7b",
+		Stages:  []models.PipelineStage{{NodeID: "node-b", LayerStart: 0, LayerEnd: 31}},
+	}
+	if err := follower.PutPlan(ctx, plan); err != nil {
+		t.Fatalf("PutPlan from follower failed: %v", err)
+	}
+	plan.Replica = 1
+	if err := follower.PutPlan(ctx, plan); err != nil {
+		t.Fatalf("PutPlan from follower failed: %v", err)
+	}
+
+	for _, store := range []*Store{leader, follower} {
+		for replica := range 2 {
+			got, ok := store.Plan("llama-7b", replica)
+			if !ok || got.Replica != replica || len(got.Stages) != 1 {
+				t.Errorf("Expected replica %d's plan on %s, got %+v", replica, store.cfg.NodeID, got)
+			}
+		}
+	}
+}
+
+func TestStoreFollowerProposalTimesOutWithoutLeader(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.add(t, "node-a", "")
+	follower := cluster.add(t, "node-b", "")
+	cluster.setPartitioned("node-a", true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := follower.Cordon(ctx, "node-c"); err == nil {
+		t.Error("Expected proposal to fail while leader is unreachable")
+	}
+	if len(follower.waiters) != 0 {
+		t.Errorf("Expected abandoned waiter to be dropped, got %d", len(follower.waiters))
+	}
+}
+
+func TestStoreCatchesUpAfterMissedEntries(t *testing.T) {
+	cluster := newFakeCluster()
+	leader := cluster.add(t, "node-a", "")
+	follower := cluster.add(t, "node-b", "")
+	ctx := context.Background()
+
+	cluster.setPartitioned("node-b", true)
+	leader.Cordon(ctx, "node-1")
+	leader.Cordon(ctx, "node-2")
+	cluster.setPartitioned("node-b", false)
+
+	// The next entry reveals the gap and triggers a snapshot request
+	leader.Cordon(ctx, "node-3")
+
+	waitFor(t, func() bool { return follower.Snapshot().Index == 3 })
+	snapshot := follower.Snapshot()
+	for _, node := range []string{"node-1", "node-2", "node-3"} {
+		if !snapshot.Cordoned[node] {
+			t.Errorf("Expected %s to be cordoned on follower", node)
+		}
+	}
+}
+
+func TestStoreRivalLeadersOfTheSameTerm(t *testing.T) {
+	cluster := newFakeCluster()
+	leader := cluster.add(t, "node-a", "")
+	rival := cluster.add(t, "node-b", "")
+	follower := cluster.add(t, "node-c", "")
+	ctx := context.Background()
+
+	// node-b doesn't see node-a and leads the same term node-a is about to
+	// start, getting ahead of it
+	rival.transport.(*fakeTransport).members = []string{"node-b", "node-c"}
+	for _, node := range []string{"node-1", "node-2", "node-3"} {
+		rival.Cordon(ctx, node)
+	}
+	leader.Cordon(ctx, "node-4")
+	if got, want := leader.Snapshot().Term, rival.Snapshot().Term; got != want {
+		t.Fatalf("Expected both leaders in the same term, got %d and %d", got, want)
+	}
+
+	// The tie goes to node-a, whose history replaces node-b's further ahead
+	for _, store := range []*Store{rival, follower} {
+		waitFor(t, func() bool { return store.Snapshot().Leader == "node-a" })
+		snapshot := store.Snapshot()
+		if !snapshot.Cordoned["node-4"] || snapshot.Cordoned["node-1"] {
+			t.Errorf("Expected %s to take node-a's history, got %v", store.cfg.NodeID, snapshot.Cordoned)
+		}
+	}
+
+	// Leading again, node-b starts a term above every one it has seen
+	rival.Cordon(ctx, "node-5")
+	if got := rival.Snapshot(); got.Term != 2 || got.Leader != "node-b" {
+		t.Errorf("Expected node-b to lead term 2, got term %d led by %s", got.Term, got.Leader)
+	}
+}
+
+func TestStoreHeartbeatRepairsMissedFinalEntry(t *testing.T) {
+	cluster := newFakeCluster()
+	leader := cluster.add(t, "node-a", "")
+	follower := cluster.add(t, "node-b", "")
+
+	cluster.setPartitioned("node-b", true)
+	leader.Cordon(context.Background(), "node-1")
+	cluster.setPartitioned("node-b", false)
+
+	// No further write reveals the gap; the leader's heartbeat does
+	leader.tick()
+	waitFor(t, func() bool { return follower.IsCordoned("node-1") })
+}
+
+func TestStoreReleasesProposalsInstalledBySnapshot(t *testing.T) {
+	cluster := newFakeCluster()
+	leader := cluster.add(t, "node-a", "")
+	follower := cluster.add(t, "node-b", "")
+	cluster.dropEntries = true
+
+	done := make(chan error, 1)
+	go func() { done <- follower.Cordon(context.Background(), "node-c") }()
+	waitFor(t, func() bool {
+		follower.mu.Lock()
+		defer follower.mu.Unlock()
+		return len(follower.waiters) == 1 && follower.waiters[firstKey(follower.waiters)].index == 1
+	})
+
+	// The leader acknowledged the proposal but its entry was lost
+	leader.tick()
+	select {
+	case err := <-done:
+		if err != nil || !follower.IsCordoned("node-c") {
+			t.Errorf("Expected the proposal applied through the snapshot, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the proposal to be released")
+	}
+}
+
+func firstKey[V any](m map[string]V) string {
+	for k := range m {
+		return k
+	}
+	return ""
+}
+
+func TestStoreBroadcastsOutsideLock(t *testing.T) {
+	transport := &callbackTransport{id: "node-a"}
+	store, _ := NewStore(Config{NodeID: "node-a"}, transport)
+	transport.broadcast = func() { store.Snapshot() }
+
+	done := make(chan error, 1)
+	go func() { done <- store.Cordon(context.Background(), "node-b") }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Cordon failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the entry to be broadcast without holding the store lock")
+	}
+}
+
+func TestStoreRejectsProposalsWhenNotLeader(t *testing.T) {
+	cluster := newFakeCluster()
+	cluster.add(t, "node-a", "")
+	cluster.add(t, "node-b", "")
+	proposer, err := NewStore(Config{NodeID: "node-c"}, &fakeTransport{cluster: cluster, id: "node-c", members: []string{"node-b", "node-c"}})
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	cluster.mu.Lock()
+	cluster.stores["node-c"] = proposer
+	cluster.mu.Unlock()
+
+	// node-c takes node-b for the leader, which knows better
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := proposer.Cordon(ctx, "node-d"); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("Expected ErrNotLeader, got %v", err)
+	}
+}
+
+func TestStoreFailsLostProposals(t *testing.T) {
+	tests := []struct {
+		name string
+		lose func(cluster *fakeCluster, follower *Store)
+	}{
+		{"leadership changes", func(cluster *fakeCluster, follower *Store) {
+			cluster.remove("node-a")
+			follower.tick()
+		}},
+		{"snapshot installed", func(cluster *fakeCluster, follower *Store) {
+			remote := NewState()
+			remote.Term, remote.Index = 5, 5
+			data, _ := json.Marshal(remote)
+			follower.MergeRemoteState(data)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newFakeCluster()
+			cluster.add(t, "node-a", "")
+			follower := cluster.add(t, "node-b", "")
+			cluster.setPartitioned("node-a", true)
+
+			done := make(chan error, 1)
+			go func() { done <- follower.Cordon(context.Background(), "node-c") }()
+			waitFor(t, func() bool {
+				follower.mu.Lock()
+				defer follower.mu.Unlock()
+				return len(follower.waiters) == 1
+			})
+
+			tt.lose(cluster, follower)
+			select {
+			case err := <-done:
+				if !errors.Is(err, ErrProposalLost) {
+					t.Errorf("Expected ErrProposalLost, got %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Expected the proposal to fail")
+			}
+		})
+	}
+}
+
+// callbackTransport is a single-member transport calling back on broadcasts
+type callbackTransport struct {
+	id        string
+	broadcast func()
+}
+
+func (t *callbackTransport) LocalID() string             { return t.id }
+func (t *callbackTransport) Members() []string           { return []string{t.id} }
+func (t *callbackTransport) SendTo(string, []byte) error { return nil }
+func (t *callbackTransport) Broadcast([]byte)            { t.broadcast() }
+
+func TestStoreMergeRemoteState(t *testing.T) {
+	ahead, _ := NewStore(Config{NodeID: "node-a"}, nil)
+	behind, _ := NewStore(Config{NodeID: "node-b"}, nil)
+	ctx := context.Background()
+
+	ahead.Cordon(ctx, "node-1")
+	ahead.Cordon(ctx, "node-2")
+
+	behind.MergeRemoteState(ahead.LocalState())
+	if behind.Snapshot().Index != 2 {
+		t.Errorf("Expected behind store to adopt index 2, got %d", behind.Snapshot().Index)
+	}
+
+	// Merging an older state must not roll back
+	stale, _ := NewStore(Config{NodeID: "node-c"}, nil)
+	behind.MergeRemoteState(stale.LocalState())
+	if behind.Snapshot().Index != 2 {
+		t.Errorf("Expected index to stay 2 after stale merge, got %d", behind.Snapshot().Index)
+	}
+
+	behind.MergeRemoteState([]byte("not json"))
+	if behind.Snapshot().Index != 2 {
+		t.Error("Malformed remote state should be ignored")
+	}
+}
+
+func TestStoreRecoversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	store, err := NewStore(Config{NodeID: "node-a", DataDir: dir, SnapshotThreshold: 2}, nil)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	plan := models.PlacementPlan{
+		ModelID: "llama-7b",
+		Stages:  []models.PipelineStage{{NodeID: "node-a", LayerStart: 0, LayerEnd: 31}},
+	}
+	store.PutModel(ctx, models.Model{ID: "llama-7b", LayerCount: 32})
+	store.PutPlan(ctx, plan)
+	store.Cordon(ctx, "node-b")
+	store.PutQuota(ctx, "team-a", models.Quota{RequestsPerSecond: 10})
+
+	// Simulate a crash: release the log without the final snapshot
+	store.log.close()
+
+	restarted, err := NewStore(Config{NodeID: "node-a", DataDir: dir}, nil)
+	if err != nil {
+		t.Fatalf("NewStore after restart failed: %v", err)
+	}
+	defer restarted.Close()
+
+	snapshot := restarted.Snapshot()
+	if snapshot.Index != 4 {
+		t.Errorf("Expected index 4 after restart, got %d", snapshot.Index)
+	}
+	if got, ok := restarted.Plan("llama-7b", 0); !ok || got.Stages[0].NodeID != "node-a" {
+		t.Errorf("Expected plan to be restored, got %+v", got)
+	}
+	if !restarted.IsCordoned("node-b") {
+		t.Error("Expected cordon flag to be restored")
+	}
+	if snapshot.Quotas["team-a"].RequestsPerSecond != 10 {
+		t.Errorf("Expected quota to be restored, got %+v", snapshot.Quotas)
+	}
+
+	// New entries continue from the restored index and term
+	if err := restarted.Uncordon(ctx, "node-b"); err != nil {
+		t.Fatalf("Uncordon failed: %v", err)
+	}
+	if got := restarted.Snapshot(); got.Index != 5 || got.Term <= snapshot.Term {
+		t.Errorf("Expected index 5 in a new term, got index %d term %d", got.Index, got.Term)
+	}
+}
+
+func TestStoreClose(t *testing.T) {
+	store, _ := NewStore(Config{NodeID: "node-a", DataDir: t.TempDir()}, nil)
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Second Close should be a no-op, got %v", err)
+	}
+	if err := store.Cordon(context.Background(), "node-b"); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestStoreDump(t *testing.T) {
+	store, _ := NewStore(Config{NodeID: "node-a"}, nil)
+	store.Cordon(context.Background(), "node-b")
+
+	dump, err := store.Dump()
+	if err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	if len(dump) == 0 {
+		t.Error("Expected non-empty dump")
+	}
+}
+
+// eventRecorder records the events published to it
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []models.Event
+}
+
+func (r *eventRecorder) Publish(event models.Event) models.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return event
+}
+
+// summary describes the recorded events as plan:nodes or plan:deleted
+func (r *eventRecorder) summary() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, event := range r.events {
+		if event.Attributes["deleted"] == "true" {
+			out = append(out, event.Attributes["plan"]+":deleted")
+		} else {
+			out = append(out, event.Attributes["plan"]+":"+event.Attributes["nodes"])
+		}
+	}
+	return out
+}
+
+func TestStorePublishesPlanChanges(t *testing.T) {
+	ctx := context.Background()
+	plan := func(replica int, nodes ...string) models.PlacementPlan {
+		p := models.PlacementPlan{ModelID: "llama-7b", Replica: replica}
+		for _, node := range nodes {
+			p.Stages = append(p.Stages, models.PipelineStage{NodeID: node})
+		}
+		return p
+	}
+
+	leader, _ := NewStore(Config{NodeID: "node-a"}, nil)
+	recorder := &eventRecorder{}
+	leader.SetEventPublisher(recorder)
+
+	leader.PutPlan(ctx, plan(0, "node-1", "node-2"))
+	leader.PutPlan(ctx, plan(1, "node-3"))
+	leader.Cordon(ctx, "node-3")
+	leader.DeletePlan(ctx, "llama-7b#1")
+	leader.DeletePlan(ctx, "unknown")
+	want := []string{"llama-7b:node-1,node-2", "llama-7b#1:node-3", "llama-7b#1:deleted"}
+	if got := recorder.summary(); !slices.Equal(got, want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+
+	// Installing a snapshot publishes the plans that differ
+	behind, _ := NewStore(Config{NodeID: "node-b"}, nil)
+	behind.PutPlan(ctx, plan(0, "node-1"))
+	behind.PutPlan(ctx, plan(2, "node-4"))
+	recorder = &eventRecorder{}
+	behind.SetEventPublisher(recorder)
+	leader.PutPlan(ctx, plan(3, "node-5"))
+	behind.MergeRemoteState(leader.LocalState())
+	want = []string{"llama-7b:node-1,node-2", "llama-7b#2:deleted", "llama-7b#3:node-5"}
+	if got := recorder.summary(); !slices.Equal(got, want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+}
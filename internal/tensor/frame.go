@@ -0,0 +1,145 @@
+package tensor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// A frame is a fixed header followed by the tensor's elements:
+//
+//	magic    "DLTF"
+//	version  uint8
+//	dtype    uint8
+//	ndim     uint8
+//	reserved uint8
+//	scale    float32
+//	shape    ndim × int64
+//	length   uint64, bytes of data
+//	checksum uint32, CRC-32C of data
+//	data     length bytes
+//
+// Integers are little-endian. The data is never re-encoded: writing a
+// frame copies the elements once and parsing one hands them out in place.
+const (
+	frameMagic   = "DLTF"
+	frameVersion = 1
+
+	// MaxDims is the largest rank a frame carries
+	MaxDims = 8
+
+	fixedHeaderSize = 4 + 4 + 4 + 8 + 4
+)
+
+var (
+	// ErrBadFrame is returned for frames that are malformed or truncated
+	ErrBadFrame = errors.New("malformed tensor frame")
+	// ErrChecksum is returned when a frame's data does not match its checksum
+	ErrChecksum = errors.New("tensor frame checksum mismatch")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// HeaderSize returns the size of the header of a frame for t
+func HeaderSize(t *Tensor) int {
+	return fixedHeaderSize + 8*len(t.Shape)
+}
+
+// FrameSize returns the encoded size of t
+func FrameSize(t *Tensor) int {
+	return HeaderSize(t) + len(t.Data)
+}
+
+// appendHeader appends the frame header of t to dst
+func appendHeader(dst []byte, t *Tensor) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return dst, err
+	}
+	if len(t.Shape) > MaxDims {
+		return dst, fmt.Errorf("%w: rank %d exceeds %d", ErrShape, len(t.Shape), MaxDims)
+	}
+
+	dst = append(dst, frameMagic...)
+	dst = append(dst, frameVersion, byte(t.DType), byte(len(t.Shape)), 0)
+	dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(t.Scale))
+	for _, dim := range t.Shape {
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(dim))
+	}
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(len(t.Data)))
+	dst = binary.LittleEndian.AppendUint32(dst, crc32.Checksum(t.Data, castagnoli))
+	return dst, nil
+}
+
+// AppendFrame appends the frame of t to dst, which may be a reused buffer
+func AppendFrame(dst []byte, t *Tensor) ([]byte, error) {
+	dst, err := appendHeader(dst, t)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, t.Data...), nil
+}
+
+// WriteFrame writes the frame of t to w without copying its data
+func WriteFrame(w io.Writer, t *Tensor) error {
+	header, err := appendHeader(make([]byte, 0, HeaderSize(t)), t)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(t.Data)
+	return err
+}
+
+// ParseFrame decodes the frame at the start of buf and returns the rest.
+// The tensor's Data and the rest alias buf, so buf must not be reused while
+// they are in use.
+func ParseFrame(buf []byte) (*Tensor, []byte, error) {
+	if len(buf) < fixedHeaderSize {
+		return nil, nil, fmt.Errorf("%w: %d bytes is shorter than a header", ErrBadFrame, len(buf))
+	}
+	if string(buf[:4]) != frameMagic {
+		return nil, nil, fmt.Errorf("%w: bad magic %q", ErrBadFrame, buf[:4])
+	}
+	if buf[4] != frameVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrBadFrame, buf[4])
+	}
+	ndim := int(buf[6])
+	if ndim > MaxDims {
+		return nil, nil, fmt.Errorf("%w: rank %d exceeds %d", ErrBadFrame, ndim, MaxDims)
+	}
+	headerSize := fixedHeaderSize + 8*ndim
+	if len(buf) < headerSize {
+		return nil, nil, fmt.Errorf("%w: truncated header", ErrBadFrame)
+	}
+
+	t := &Tensor{
+		DType: DType(buf[5]),
+		Scale: math.Float32frombits(binary.LittleEndian.Uint32(buf[8:])),
+		Shape: make([]int64, ndim),
+	}
+	off := 12
+	for i := range t.Shape {
+		t.Shape[i] = int64(binary.LittleEndian.Uint64(buf[off:]))
+		off += 8
+	}
+	length := binary.LittleEndian.Uint64(buf[off:])
+	checksum := binary.LittleEndian.Uint32(buf[off+8:])
+
+	if length > uint64(len(buf)-headerSize) {
+		return nil, nil, fmt.Errorf("%w: %d data bytes announced, %d present", ErrBadFrame, length, len(buf)-headerSize)
+	}
+	end := headerSize + int(length)
+	t.Data = buf[headerSize:end:end]
+	if crc32.Checksum(t.Data, castagnoli) != checksum {
+		return nil, nil, ErrChecksum
+	}
+	if err := t.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return t, buf[end:], nil
+}
@@ -0,0 +1,113 @@
+package tensor
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	for _, dtype := range []DType{Float32, Float16, Int8} {
+		t.Run(dtype.String(), func(t *testing.T) {
+			tensor, err := Encode(dtype, []int64{2, 3, 4}, activations(24))
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+
+			frame, err := AppendFrame([]byte("prefix"), tensor)
+			if err != nil {
+				t.Fatalf("AppendFrame failed: %v", err)
+			}
+			frame = frame[len("prefix"):]
+			if len(frame) != FrameSize(tensor) {
+				t.Errorf("Expected a %d byte frame, got %d", FrameSize(tensor), len(frame))
+			}
+
+			var written bytes.Buffer
+			if err := WriteFrame(&written, tensor); err != nil {
+				t.Fatalf("WriteFrame failed: %v", err)
+			}
+			if !bytes.Equal(written.Bytes(), frame) {
+				t.Error("Expected WriteFrame and AppendFrame to agree")
+			}
+
+			// Two frames back to back parse one after the other
+			buf := append(frame, frame...)
+			parsed, rest, err := ParseFrame(buf)
+			if err != nil {
+				t.Fatalf("ParseFrame failed: %v", err)
+			}
+			if len(rest) != len(frame) {
+				t.Errorf("Expected the second frame to remain, got %d bytes", len(rest))
+			}
+			if parsed.DType != dtype || parsed.Scale != tensor.Scale || len(parsed.Shape) != 3 || parsed.Shape[2] != 4 {
+				t.Errorf("Unexpected header %+v", parsed)
+			}
+			if !bytes.Equal(parsed.Data, tensor.Data) {
+				t.Error("Expected the data to survive the round trip")
+			}
+			if &parsed.Data[0] != &buf[HeaderSize(tensor)] {
+				t.Error("Expected parsed data to alias the frame buffer")
+			}
+		})
+	}
+}
+
+func TestParseFrameErrors(t *testing.T) {
+	tensor, _ := Encode(Float16, []int64{8}, activations(8))
+	frame, _ := AppendFrame(nil, tensor)
+
+	corrupt := func(edit func(b []byte) []byte) []byte {
+		return edit(append([]byte(nil), frame...))
+	}
+
+	tests := []struct {
+		name  string
+		frame []byte
+		want  error
+	}{
+		{"empty", nil, ErrBadFrame},
+		{"bad magic", corrupt(func(b []byte) []byte { b[0] = 'X'; return b }), ErrBadFrame},
+		{"future version", corrupt(func(b []byte) []byte { b[4] = 2; return b }), ErrBadFrame},
+		{"rank too high", corrupt(func(b []byte) []byte { b[6] = MaxDims + 1; return b }), ErrBadFrame},
+		{"truncated data", frame[:len(frame)-1], ErrBadFrame},
+		{"flipped bit", corrupt(func(b []byte) []byte { b[len(b)-1] ^= 1; return b }), ErrChecksum},
+		{"wrong dtype", corrupt(func(b []byte) []byte { b[5] = byte(Float32); return b }), ErrShape},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseFrame(tt.frame); !errors.Is(err, tt.want) {
+				t.Errorf("ParseFrame() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkFrame(b *testing.B) {
+	// A 512-token prefill of a 4096-wide model
+	values := activations(512 * 4096)
+	for _, dtype := range []DType{Float32, Float16, Int8} {
+		tensor, err := Encode(dtype, []int64{512, 4096}, values)
+		if err != nil {
+			b.Fatal(err)
+		}
+		frame, _ := AppendFrame(nil, tensor)
+
+		b.Run("append/"+dtype.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(frame)))
+			buf := make([]byte, 0, len(frame))
+			for i := 0; i < b.N; i++ {
+				buf, _ = AppendFrame(buf[:0], tensor)
+			}
+		})
+		b.Run("parse/"+dtype.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(frame)))
+			for i := 0; i < b.N; i++ {
+				if _, _, err := ParseFrame(frame); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
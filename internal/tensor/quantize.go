@@ -0,0 +1,92 @@
+package tensor
+
+import "math"
+
+// Float32ToFloat16 converts a float to IEEE 754 half precision, rounding to
+// nearest even. Values beyond the half range become infinities.
+func Float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23&0xff) - 127 + 15
+	mant := bits & 0x7fffff
+
+	switch {
+	case bits&0x7fffffff > 0x7f800000: // NaN
+		return sign | 0x7e00
+	case exp >= 0x1f: // infinity or too large
+		return sign | 0x7c00
+	case exp <= 0: // subnormal half, or too small for one
+		if exp < -10 {
+			return sign
+		}
+		return sign | uint16(roundShift(mant|0x800000, uint32(14-exp)))
+	}
+	// A mantissa rounding up carries into the exponent, up to infinity
+	return sign | uint16(uint32(exp)<<10+roundShift(mant, 13))
+}
+
+// Float16ToFloat32 converts an IEEE 754 half precision value to a float
+func Float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f: // infinity or NaN
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Normalize the subnormal
+		e := int32(1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | uint32(e-15+127)<<23 | (mant&0x3ff)<<13)
+	}
+	return math.Float32frombits(sign | (exp-15+127)<<23 | mant<<13)
+}
+
+// roundShift shifts v right, rounding to nearest even
+func roundShift(v, shift uint32) uint32 {
+	half := uint32(1) << (shift - 1)
+	rem := v & (half<<1 - 1)
+	v >>= shift
+	if rem > half || (rem == half && v&1 == 1) {
+		v++
+	}
+	return v
+}
+
+// quantizeInt8 maps values symmetrically onto [-127, 127] with one scale
+// for the whole tensor, chosen from the largest finite magnitude. NaNs
+// become 0 and infinities saturate.
+func quantizeInt8(values []float32) (float32, []byte) {
+	var absMax float32
+	for _, v := range values {
+		if a := float32(math.Abs(float64(v))); a > absMax && !math.IsInf(float64(a), 0) {
+			absMax = a
+		}
+	}
+	data := make([]byte, len(values))
+	if absMax == 0 {
+		return 0, data
+	}
+
+	scale := absMax / 127
+	for i, v := range values {
+		q := math.Round(float64(v / scale))
+		switch {
+		case math.IsNaN(q):
+			q = 0
+		case q > 127:
+			q = 127
+		case q < -127:
+			q = -127
+		}
+		data[i] = byte(int8(q))
+	}
+	return scale, data
+}
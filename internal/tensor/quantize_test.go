@@ -0,0 +1,78 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat16Conversion(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float32
+		bits  uint16
+	}{
+		{"zero", 0, 0x0000},
+		{"negative zero", float32(math.Copysign(0, -1)), 0x8000},
+		{"one", 1, 0x3c00},
+		{"minus two", -2, 0xc000},
+		{"max half", 65504, 0x7bff},
+		{"overflow", 65520, 0x7c00},
+		{"infinity", float32(math.Inf(1)), 0x7c00},
+		{"smallest normal", float32(math.Ldexp(1, -14)), 0x0400},
+		{"smallest subnormal", float32(math.Ldexp(1, -24)), 0x0001},
+		{"underflow", float32(math.Ldexp(1, -26)), 0x0000},
+		{"rounds to even down", 1 + float32(math.Ldexp(1, -11)), 0x3c00},
+		{"rounds to even up", 1 + 3*float32(math.Ldexp(1, -11)), 0x3c02},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Float32ToFloat16(tt.value); got != tt.bits {
+				t.Errorf("Float32ToFloat16(%v) = %#04x, want %#04x", tt.value, got, tt.bits)
+			}
+		})
+	}
+
+	// Every finite half survives a round trip through float32
+	for h := uint32(0); h <= 0xffff; h++ {
+		if h&0x7c00 == 0x7c00 {
+			continue
+		}
+		if got := Float32ToFloat16(Float16ToFloat32(uint16(h))); got != uint16(h) {
+			t.Fatalf("Round trip of %#04x gave %#04x", h, got)
+		}
+	}
+
+	if !math.IsNaN(float64(Float16ToFloat32(Float32ToFloat16(float32(math.NaN()))))) {
+		t.Error("Expected NaN to survive conversion")
+	}
+}
+
+func TestQuantizeInt8(t *testing.T) {
+	nan, inf := float32(math.NaN()), float32(math.Inf(1))
+	scale, data := quantizeInt8([]float32{0, 1.27, -1.27, 0.5, nan, inf, -inf})
+
+	if math.Abs(float64(scale)-0.01) > 1e-6 {
+		t.Errorf("Expected scale 0.01 from the largest finite value, got %v", scale)
+	}
+	want := []int8{0, 127, -127, 50, 0, 127, -127}
+	for i, w := range want {
+		if int8(data[i]) != w {
+			t.Errorf("Element %d: got %d, want %d", i, int8(data[i]), w)
+		}
+	}
+
+	if scale, data := quantizeInt8([]float32{0, 0}); scale != 0 || data[0] != 0 {
+		t.Errorf("Expected zeros to quantize to zero scale, got %v %v", scale, data)
+	}
+}
+
+func BenchmarkFloat32ToFloat16(b *testing.B) {
+	values := activations(4096)
+	b.SetBytes(int64(4 * len(values)))
+	for i := 0; i < b.N; i++ {
+		for _, v := range values {
+			Float32ToFloat16(v)
+		}
+	}
+}
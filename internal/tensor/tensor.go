@@ -0,0 +1,195 @@
+// Package tensor defines how pipeline stages ship activations (hidden
+// states) to the next stage: an element type, a shape, an optional
+// quantization and the raw little-endian elements.
+package tensor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// DType is the element type of a tensor on the wire
+type DType uint8
+
+const (
+	// Float32 sends activations unchanged
+	Float32 DType = iota + 1
+	// Float16 halves the size of activations at a small loss of precision
+	Float16
+	// Int8 quarters the size of activations; elements are scaled by
+	// Tensor.Scale
+	Int8
+)
+
+var dtypeNames = map[DType]string{
+	Float32: "fp32",
+	Float16: "fp16",
+	Int8:    "int8",
+}
+
+func (d DType) String() string {
+	if name, ok := dtypeNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("dtype(%d)", d)
+}
+
+// Size returns the bytes per element, or 0 for unknown types
+func (d DType) Size() int {
+	switch d {
+	case Float32:
+		return 4
+	case Float16:
+		return 2
+	case Int8:
+		return 1
+	}
+	return 0
+}
+
+// ParseDType parses the quantization names used in configuration: "none"
+// or "fp32", "fp16" and "int8"
+func ParseDType(name string) (DType, error) {
+	if name == "" || name == "none" {
+		return Float32, nil
+	}
+	for dtype, n := range dtypeNames {
+		if n == name {
+			return dtype, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown activation quantization %q (want none, fp16 or int8)", name)
+}
+
+// ErrShape is returned when a tensor's data does not match its shape
+var ErrShape = errors.New("tensor data does not match its shape")
+
+// Tensor is a dense tensor of activations
+type Tensor struct {
+	DType DType
+	Shape []int64
+	// Scale maps Int8 elements back to floats: value = element * Scale
+	Scale float32
+	// Data holds the elements in little-endian order
+	Data []byte
+}
+
+// Elements returns the number of elements the shape describes
+func (t *Tensor) Elements() int64 {
+	n := int64(1)
+	for _, dim := range t.Shape {
+		n *= dim
+	}
+	return n
+}
+
+// Validate checks that the shape is non-negative and matches the data
+func (t *Tensor) Validate() error {
+	size := t.DType.Size()
+	if size == 0 {
+		return fmt.Errorf("unknown %s", t.DType)
+	}
+	n := int64(size)
+	for _, dim := range t.Shape {
+		if dim < 0 || (dim > 0 && n > math.MaxInt64/dim) {
+			return fmt.Errorf("%w: invalid dimensions %v", ErrShape, t.Shape)
+		}
+		n *= dim
+	}
+	if int64(len(t.Data)) != n {
+		return fmt.Errorf("%w: %v %s needs %d bytes, got %d", ErrShape, t.Shape, t.DType, n, len(t.Data))
+	}
+	return nil
+}
+
+// littleEndian is true on hosts whose memory layout matches the wire, where
+// float32 tensors are sent and read without converting their elements
+var littleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// Encode builds a tensor of the given type from float activations. Float32
+// tensors share values' memory on little-endian hosts; Float16 and Int8
+// tensors are quantized into new buffers.
+func Encode(dtype DType, shape []int64, values []float32) (*Tensor, error) {
+	t := &Tensor{DType: dtype, Shape: shape}
+	switch dtype {
+	case Float32:
+		t.Data = float32Bytes(values)
+	case Float16:
+		t.Data = make([]byte, 2*len(values))
+		for i, v := range values {
+			binary.LittleEndian.PutUint16(t.Data[2*i:], Float32ToFloat16(v))
+		}
+	case Int8:
+		t.Scale, t.Data = quantizeInt8(values)
+	default:
+		return nil, fmt.Errorf("cannot encode %s", dtype)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Float32s returns the activations as floats, dequantizing Float16 and Int8
+// tensors. The result of a Float32 tensor shares its memory on little-endian
+// hosts when the data is suitably aligned.
+func (t *Tensor) Float32s() ([]float32, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	n := len(t.Data) / t.DType.Size()
+	switch t.DType {
+	case Float32:
+		if values, ok := float32View(t.Data); ok {
+			return values, nil
+		}
+		values := make([]float32, n)
+		for i := range values {
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(t.Data[4*i:]))
+		}
+		return values, nil
+	case Float16:
+		values := make([]float32, n)
+		for i := range values {
+			values[i] = Float16ToFloat32(binary.LittleEndian.Uint16(t.Data[2*i:]))
+		}
+		return values, nil
+	default: // Int8
+		values := make([]float32, n)
+		for i, b := range t.Data {
+			values[i] = float32(int8(b)) * t.Scale
+		}
+		return values, nil
+	}
+}
+
+// float32Bytes returns the wire encoding of values, sharing their memory
+// when the host is little-endian
+func float32Bytes(values []float32) []byte {
+	if len(values) == 0 {
+		return []byte{}
+	}
+	if littleEndian {
+		return unsafe.Slice((*byte)(unsafe.Pointer(&values[0])), 4*len(values))
+	}
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// float32View reinterprets little-endian float data in place when the host
+// and the buffer's alignment allow it
+func float32View(data []byte) ([]float32, bool) {
+	if len(data) == 0 {
+		return []float32{}, true
+	}
+	if !littleEndian || uintptr(unsafe.Pointer(&data[0]))%unsafe.Alignof(float32(0)) != 0 {
+		return nil, false
+	}
+	return unsafe.Slice((*float32)(unsafe.Pointer(&data[0])), len(data)/4), true
+}
@@ -0,0 +1,156 @@
+package tensor
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// activations returns hidden states shaped like a transformer's: mostly
+// small normally distributed values with a few large outlier channels
+func activations(n int) []float32 {
+	rng := rand.New(rand.NewPCG(1, 2))
+	values := make([]float32, n)
+	for i := range values {
+		values[i] = float32(rng.NormFloat64())
+		if i%997 == 0 {
+			values[i] *= 40
+		}
+	}
+	return values
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	values := activations(4096)
+	absMax := float32(0)
+	for _, v := range values {
+		absMax = max(absMax, float32(math.Abs(float64(v))))
+	}
+
+	tests := []struct {
+		dtype   DType
+		size    int
+		maxDiff func(v float32) float64
+	}{
+		{Float32, 4 * 4096, func(float32) float64 { return 0 }},
+		// Half precision keeps 11 significant bits
+		{Float16, 2 * 4096, func(v float32) float64 { return math.Abs(float64(v)) / 1024 }},
+		// Int8 is off by at most half a step of the tensor's scale
+		{Int8, 4096, func(float32) float64 { return float64(absMax) / 127 / 2 * 1.0001 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dtype.String(), func(t *testing.T) {
+			tensor, err := Encode(tt.dtype, []int64{1, 4096}, values)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if len(tensor.Data) != tt.size {
+				t.Errorf("Expected %d bytes, got %d", tt.size, len(tensor.Data))
+			}
+
+			decoded, err := tensor.Float32s()
+			if err != nil {
+				t.Fatalf("Float32s failed: %v", err)
+			}
+			for i, v := range values {
+				if diff := math.Abs(float64(decoded[i] - v)); diff > tt.maxDiff(v) {
+					t.Fatalf("Element %d: got %v, want %v (diff %v)", i, decoded[i], v, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeFloat32SharesMemory(t *testing.T) {
+	if !littleEndian {
+		t.Skip("float32 tensors are copied on big-endian hosts")
+	}
+	values := []float32{1, 2, 3}
+	tensor, err := Encode(Float32, []int64{3}, values)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, _ := tensor.Float32s()
+	values[0] = 42
+	if decoded[0] != 42 {
+		t.Error("Expected float32 tensors to share memory with their values")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		tensor Tensor
+		valid  bool
+	}{
+		{"matching", Tensor{DType: Float16, Shape: []int64{2, 3}, Data: make([]byte, 12)}, true},
+		{"scalar", Tensor{DType: Float32, Data: make([]byte, 4)}, true},
+		{"empty", Tensor{DType: Int8, Shape: []int64{0, 4096}, Data: []byte{}}, true},
+		{"short data", Tensor{DType: Float32, Shape: []int64{4}, Data: make([]byte, 12)}, false},
+		{"negative dimension", Tensor{DType: Int8, Shape: []int64{-1, -4}, Data: make([]byte, 4)}, false},
+		{"overflowing shape", Tensor{DType: Int8, Shape: []int64{1 << 32, 1 << 32}, Data: []byte{}}, false},
+		{"unknown dtype", Tensor{DType: 9, Data: []byte{}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tensor.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestParseDType(t *testing.T) {
+	tests := []struct {
+		name string
+		want DType
+		ok   bool
+	}{
+		{"", Float32, true},
+		{"none", Float32, true},
+		{"fp16", Float16, true},
+		{"int8", Int8, true},
+		{"bf16", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDType(tt.name)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseDType(%q) = %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestEncodeRejectsMismatchedShape(t *testing.T) {
+	if _, err := Encode(Float16, []int64{2, 2}, []float32{1, 2, 3}); !errors.Is(err, ErrShape) {
+		t.Errorf("Expected ErrShape, got %v", err)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	// One decode step and one 512-token prefill of a 4096-wide model
+	sizes := []struct {
+		name  string
+		shape []int64
+	}{
+		{"decode-1x4096", []int64{1, 4096}},
+		{"prefill-512x4096", []int64{512, 4096}},
+	}
+
+	for _, size := range sizes {
+		values := activations(int(size.shape[0] * size.shape[1]))
+		for _, dtype := range []DType{Float32, Float16, Int8} {
+			b.Run(size.name+"/"+dtype.String(), func(b *testing.B) {
+				b.SetBytes(int64(4 * len(values)))
+				for i := 0; i < b.N; i++ {
+					if _, err := Encode(dtype, size.shape, values); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
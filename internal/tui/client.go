@@ -119,6 +119,90 @@ func (c *Client) GetPeers() ([]string, error) {
 	return peers, nil
 }
 
+// GetVersion gets the agent's build version and supported protocol range.
+// Agents that predate version negotiation return an Unimplemented error.
+func (c *Client) GetVersion() (*pb.GetVersionResponse, error) {
+	if c.nodeClient == nil {
+		return nil, fmt.Errorf("client not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.nodeClient.GetVersion(ctx, &pb.GetVersionRequest{NodeId: "tui-client"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version: %w", err)
+	}
+	return resp, nil
+}
+
+// GetAlerts gets the active alerts of every node in the cluster, as known
+// to the agent
+func (c *Client) GetAlerts() ([]models.Alert, error) {
+	if c.nodeClient == nil {
+		return nil, fmt.Errorf("client not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.nodeClient.GetAlerts(ctx, &pb.AlertsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+
+	alerts := make([]models.Alert, len(resp.Alerts))
+	for i, alert := range resp.Alerts {
+		alerts[i] = models.AlertFromProto(alert)
+	}
+	return alerts, nil
+}
+
+// StreamMetrics calls fn with the agent's metrics every interval, starting
+// with the snapshots it recorded over backfill. It returns when ctx is done
+// or the stream fails.
+func (c *Client) StreamMetrics(ctx context.Context, interval, backfill time.Duration, fn func(*pb.MetricsUpdate)) error {
+	if c.nodeClient == nil {
+		return fmt.Errorf("client not connected")
+	}
+
+	stream, err := c.nodeClient.StreamMetrics(ctx, &pb.StreamMetricsRequest{
+		NodeId:          "tui-client",
+		IntervalSeconds: int32(interval / time.Second),
+		BackfillSeconds: int32(backfill / time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stream metrics: %w", err)
+	}
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("metrics stream ended: %w", err)
+		}
+		fn(update)
+	}
+}
+
+// StreamEvents calls fn with each event the agent publishes after the given
+// sequence number. It returns when ctx is done or the stream fails.
+func (c *Client) StreamEvents(ctx context.Context, after uint64, fn func(models.Event)) error {
+	if c.nodeClient == nil {
+		return fmt.Errorf("client not connected")
+	}
+
+	stream, err := c.nodeClient.StreamEvents(ctx, &pb.EventsRequest{AfterSequence: after})
+	if err != nil {
+		return fmt.Errorf("failed to stream events: %w", err)
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("event stream ended: %w", err)
+		}
+		fn(models.EventFromProto(event))
+	}
+}
+
 // GetNodes gets all nodes in the cluster using TUI service
 func (c *Client) GetNodes() ([]models.Node, error) {
 	if c.tuiClient == nil {
@@ -208,7 +292,12 @@ func convertProtoToNode(nodeInfo *pb.NodeInfo) models.Node {
 			MaxLayers:  nodeInfo.Resources.MaxLayers,
 			UsedLayers: nodeInfo.Resources.UsedLayers,
 		},
-		LastSeen: time.Unix(nodeInfo.LastSeen, 0),
+		LastSeen:           time.Unix(nodeInfo.LastSeen, 0),
+		Version:            nodeInfo.Version,
+		ProtocolVersion:    nodeInfo.ProtocolVersion,
+		MinProtocolVersion: nodeInfo.MinProtocolVersion,
+		Features:           nodeInfo.Features,
+		Labels:             nodeInfo.Labels,
 	}
 }
 
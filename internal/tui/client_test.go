@@ -20,6 +20,8 @@ type MockNodeService struct {
 	pb.UnimplementedNodeServiceServer
 	resources     *pb.ResourceInfo
 	peers         []*pb.NodeInfo
+	alerts        []*pb.Alert
+	events        []*pb.ClusterEvent
 	shouldFail    bool
 	failWithError string
 }
@@ -51,6 +53,59 @@ func (m *MockNodeService) GetResources(ctx context.Context, req *pb.GetResources
 	}, nil
 }
 
+func (m *MockNodeService) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.GetVersionResponse, error) {
+	if m.shouldFail {
+		return nil, fmt.Errorf("%s", m.failWithError)
+	}
+
+	return &pb.GetVersionResponse{
+		NodeId:             "mock-node",
+		Version:            "abc123",
+		ProtocolVersion:    2,
+		MinProtocolVersion: 1,
+		Features:           []string{"replicated-state"},
+	}, nil
+}
+
+// StreamMetrics sends one update per second of backfill, then ends the stream
+func (m *MockNodeService) StreamMetrics(req *pb.StreamMetricsRequest, stream pb.NodeService_StreamMetricsServer) error {
+	if m.shouldFail {
+		return fmt.Errorf("%s", m.failWithError)
+	}
+	if req.IntervalSeconds != 5 {
+		return fmt.Errorf("unexpected interval %d", req.IntervalSeconds)
+	}
+	for i := int32(0); i < req.BackfillSeconds; i++ {
+		update := &pb.MetricsUpdate{NodeId: "mock-node", Timestamp: int64(i)}
+		if err := stream.Send(update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockNodeService) GetAlerts(ctx context.Context, req *pb.AlertsRequest) (*pb.AlertsResponse, error) {
+	if m.shouldFail {
+		return nil, fmt.Errorf("%s", m.failWithError)
+	}
+	return &pb.AlertsResponse{Alerts: m.alerts}, nil
+}
+
+func (m *MockNodeService) StreamEvents(req *pb.EventsRequest, stream pb.NodeService_StreamEventsServer) error {
+	if m.shouldFail {
+		return fmt.Errorf("%s", m.failWithError)
+	}
+	for _, event := range m.events {
+		if event.Sequence <= req.AfterSequence {
+			continue
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *MockNodeService) GetPeers(ctx context.Context, req *pb.GetPeersRequest) (*pb.GetPeersResponse, error) {
 	if m.shouldFail {
 		return nil, fmt.Errorf("%s", m.failWithError)
@@ -830,3 +885,88 @@ func TestClient_Integration(t *testing.T) {
 		t.Errorf("Failed to close client: %v", err)
 	}
 }
+
+func TestClientGetVersion(t *testing.T) {
+	client, cleanup := createClientWithMockServer(&MockNodeService{}, &MockTUIService{})
+	defer cleanup()
+
+	version, err := client.GetVersion()
+	if err != nil {
+		t.Fatalf("GetVersion failed: %v", err)
+	}
+	if version.Version != "abc123" || version.ProtocolVersion != 2 || version.MinProtocolVersion != 1 {
+		t.Errorf("Unexpected version: %+v", version)
+	}
+
+	failing, cleanupFailing := createClientWithMockServer(&MockNodeService{shouldFail: true, failWithError: "boom"}, &MockTUIService{})
+	defer cleanupFailing()
+	if _, err := failing.GetVersion(); err == nil {
+		t.Error("Expected error from failing server")
+	}
+
+	if _, err := NewClient("localhost:8080").GetVersion(); err == nil {
+		t.Error("Expected error when not connected")
+	}
+}
+
+func TestClientStreamMetrics(t *testing.T) {
+	client, cleanup := createClientWithMockServer(&MockNodeService{}, &MockTUIService{})
+	defer cleanup()
+
+	var timestamps []int64
+	err := client.StreamMetrics(context.Background(), 5*time.Second, 3*time.Second, func(update *pb.MetricsUpdate) {
+		timestamps = append(timestamps, update.Timestamp)
+	})
+	if err == nil {
+		t.Error("Expected an error once the stream ends")
+	}
+	if fmt.Sprint(timestamps) != "[0 1 2]" {
+		t.Errorf("Expected the backfilled updates, got %v", timestamps)
+	}
+
+	if err := NewClient("localhost:8080").StreamMetrics(context.Background(), time.Second, 0, nil); err == nil {
+		t.Error("Expected error when not connected")
+	}
+}
+
+func TestClientStreamEvents(t *testing.T) {
+	client, cleanup := createClientWithMockServer(&MockNodeService{events: []*pb.ClusterEvent{
+		{Sequence: 1, Type: "node_joined", NodeId: "node-2"},
+		{Sequence: 2, Type: "model_loaded", NodeId: "node-2", ModelId: "llama-7b", TimeUnixMs: 1_800_000_000_000},
+	}}, &MockTUIService{})
+	defer cleanup()
+
+	var events []models.Event
+	err := client.StreamEvents(context.Background(), 1, func(event models.Event) {
+		events = append(events, event)
+	})
+	if err == nil {
+		t.Error("Expected an error once the stream ends")
+	}
+	if len(events) != 1 || events[0].Type != models.EventModelLoaded || !events[0].Time.Equal(time.UnixMilli(1_800_000_000_000)) {
+		t.Errorf("Expected the events after sequence 1, got %+v", events)
+	}
+
+	if err := NewClient("localhost:8080").StreamEvents(context.Background(), 0, nil); err == nil {
+		t.Error("Expected error when not connected")
+	}
+}
+
+func TestClientGetAlerts(t *testing.T) {
+	client, cleanup := createClientWithMockServer(&MockNodeService{alerts: []*pb.Alert{
+		{Name: "HighCPU", NodeId: "node-1", State: "firing", Value: 95, ActiveSince: 1_800_000_000},
+	}}, &MockTUIService{})
+	defer cleanup()
+
+	alerts, err := client.GetAlerts()
+	if err != nil {
+		t.Fatalf("GetAlerts failed: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].State != models.AlertFiring || alerts[0].ActiveSince != time.Unix(1_800_000_000, 0) {
+		t.Errorf("Unexpected alerts %+v", alerts)
+	}
+
+	if _, err := NewClient("localhost:8080").GetAlerts(); err == nil {
+		t.Error("Expected error when not connected")
+	}
+}
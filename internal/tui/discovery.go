@@ -1,14 +1,22 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"net"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/internal/docker"
+	"distributed-llm/internal/k8s"
+	"distributed-llm/internal/network"
 	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
 )
 
 // AgentDiscovery handles discovery and connection to distributed agents
@@ -18,27 +26,99 @@ type AgentDiscovery struct {
 	nodes        map[string]*models.Node
 	logger       *slog.Logger
 	updateChan   chan []models.Node
+	metricsChan  chan MetricsSample
+	alertsChan   chan []models.Alert
+	alerts       map[string][]models.Alert
 	seedNodes    []string
+	providers    []network.SeedProvider
+	refresh      time.Duration
 	dockerMode   bool
+	dockerHost   string
 	k8sNamespace string
+	lanEnabled   bool
+	lanGroup     string
+	lan          *network.Discovery
+	ctx          context.Context
+	cancel       context.CancelFunc
+	eventsChan   chan models.Event
+	// seenEvents holds when cluster-wide events were last sent to the UI
+	seenEvents map[string]time.Time
 }
 
 type DiscoveryConfig struct {
-	SeedNodes    []string
-	DockerMode   bool
+	SeedNodes  []string
+	DockerMode bool
+	// DockerHost is the Engine API address for DockerMode, in DOCKER_HOST
+	// form; empty means the local socket
+	DockerHost   string
 	K8sNamespace string
 	UpdateChan   chan []models.Node
+	// MetricsChan receives each connected agent's metrics, starting with
+	// enough history to fill the sparklines
+	MetricsChan chan MetricsSample
+	// AlertsChan receives the cluster's active alerts as the agents report
+	// them
+	AlertsChan chan []models.Alert
+	// EventsChan receives the events published by the connected agents as
+	// they happen. Events every agent observes are sent once.
+	EventsChan chan models.Event
+	// SeedProviders are resolved for agent gRPC addresses alongside
+	// SeedNodes and the providers for the mode above
+	SeedProviders []network.SeedProvider
+	// RefreshInterval is how often seed providers are re-resolved
+	RefreshInterval time.Duration
+	// LANDiscovery listens for agents announcing themselves on the local
+	// network, in addition to the mode above. LANGroup overrides the
+	// multicast group.
+	LANDiscovery bool
+	LANGroup     string
+}
+
+// agentGRPCPort is the gRPC port agents listen on unless told otherwise
+const agentGRPCPort = 8080
+
+// gossipDiscoveryInterval is how often connected agents are asked for their peers
+const gossipDiscoveryInterval = 30 * time.Second
+
+// eventRetryInterval is how long to wait before resubscribing to an agent's events
+const eventRetryInterval = 5 * time.Second
+
+// eventDedupWindow is how long an event every agent observes is remembered,
+// so that the copies from the other agents are dropped
+const eventDedupWindow = 30 * time.Second
+
+// clusterEvents are observed and published by every agent
+var clusterEvents = map[models.EventType]bool{
+	models.EventNodeJoined:    true,
+	models.EventNodeLeft:      true,
+	models.EventNodeSuspect:   true,
+	models.EventNodeRecovered: true,
+	models.EventPlanChanged:   true,
 }
 
 func NewAgentDiscovery(config DiscoveryConfig) *AgentDiscovery {
+	refresh := config.RefreshInterval
+	if refresh <= 0 {
+		refresh = network.DefaultSeedRefreshInterval
+	}
 	return &AgentDiscovery{
 		clients:      make(map[string]*Client),
 		nodes:        make(map[string]*models.Node),
 		logger:       slog.Default(),
 		updateChan:   config.UpdateChan,
+		metricsChan:  config.MetricsChan,
+		alertsChan:   config.AlertsChan,
+		alerts:       make(map[string][]models.Alert),
+		eventsChan:   config.EventsChan,
+		seenEvents:   make(map[string]time.Time),
 		seedNodes:    config.SeedNodes,
+		providers:    config.SeedProviders,
+		refresh:      refresh,
 		dockerMode:   config.DockerMode,
+		dockerHost:   config.DockerHost,
 		k8sNamespace: config.K8sNamespace,
+		lanEnabled:   config.LANDiscovery,
+		lanGroup:     config.LANGroup,
 	}
 }
 
@@ -47,137 +127,174 @@ func (d *AgentDiscovery) Start() error {
 	d.logger.Info("Starting agent discovery",
 		"dockerMode", d.dockerMode,
 		"k8sNamespace", d.k8sNamespace,
-		"seedNodes", d.seedNodes)
+		"seedNodes", d.seedNodes,
+		"lanDiscovery", d.lanEnabled)
 
-	// Start discovery based on mode
-	if d.dockerMode {
-		return d.startDockerDiscovery()
-	} else if d.k8sNamespace != "" {
-		return d.startK8sDiscovery()
-	} else {
-		return d.startSeedNodeDiscovery()
+	if d.lanEnabled {
+		if err := d.startLANDiscovery(); err != nil {
+			d.logger.Warn("LAN discovery unavailable", "error", err)
+		}
 	}
-}
 
-// startDockerDiscovery discovers agents in Docker containers
-func (d *AgentDiscovery) startDockerDiscovery() error {
-	go func() {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	d.mu.Lock()
+	d.ctx, d.cancel = ctx, cancel
+	d.mu.Unlock()
 
-		for {
-			d.discoverDockerAgents()
-			select {
-			case <-ticker.C:
-				// Continue loop
-			}
+	// Static and DNS seeds are tried right away; Docker and Kubernetes
+	// discovery, which may take a while to sync, follow on their own
+	go d.watchSeeds(ctx, d.seedProviders())
+	go func() {
+		if providers := d.platformProviders(ctx); len(providers) > 0 {
+			d.watchSeeds(ctx, providers)
 		}
 	}()
+	go d.discoverThroughGossipEvery(ctx, gossipDiscoveryInterval)
 
 	return nil
 }
 
-// startK8sDiscovery discovers agents in Kubernetes cluster
-func (d *AgentDiscovery) startK8sDiscovery() error {
-	go func() {
-		ticker := time.NewTicker(10 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			d.discoverK8sAgents()
-			select {
-			case <-ticker.C:
-				// Continue loop
+// watchSeeds connects to the agents the providers resolve to until ctx is done
+func (d *AgentDiscovery) watchSeeds(ctx context.Context, providers network.SeedProviders) {
+	d.logger.Info("Resolving agents", "providers", providers.String())
+	network.WatchSeeds(ctx, providers, d.refresh, func(seeds, _ []string) {
+		// Seeds that failed to connect are retried every round
+		for _, seed := range seeds {
+			if ctx.Err() != nil {
+				return
+			}
+			if d.tryConnectToAgent(seed) {
+				d.logger.Debug("Connected to agent", "address", seed)
 			}
 		}
-	}()
+	})
+}
 
-	return nil
+// seedProviders builds the providers for the configured seeds
+func (d *AgentDiscovery) seedProviders() network.SeedProviders {
+	providers := network.SeedProviders{network.StaticSeeds(d.seedNodes)}
+	return append(providers, d.providers...)
 }
 
-// startSeedNodeDiscovery connects to specified seed nodes
-func (d *AgentDiscovery) startSeedNodeDiscovery() error {
-	for _, seedNode := range d.seedNodes {
-		go d.connectToAgent(seedNode)
+// platformProviders starts Docker and Kubernetes discovery when enabled,
+// returning their providers
+func (d *AgentDiscovery) platformProviders(ctx context.Context) network.SeedProviders {
+	var providers network.SeedProviders
+	if d.dockerMode {
+		if containers, err := d.startDockerDiscovery(ctx); err == nil {
+			providers = append(providers, containers)
+		} else {
+			d.logger.Warn("Docker discovery unavailable", "host", d.dockerHost, "error", err)
+		}
 	}
 
-	// Start periodic discovery of new nodes through gossip
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			d.discoverThroughGossip()
-			select {
-			case <-ticker.C:
-				// Continue loop
-			}
+	if d.k8sNamespace != "" {
+		if discovery, err := d.startK8sDiscovery(ctx); err == nil {
+			providers = append(providers, k8s.PodSeeds{Discovery: discovery, GRPC: true})
+		} else {
+			// Without API access fall back to the cluster DNS names of the agent service
+			d.logger.Debug("Kubernetes API unavailable, using cluster DNS", "error", err)
+			service := fmt.Sprintf("distributed-llm-agent.%s.svc.cluster.local", d.k8sNamespace)
+			providers = append(providers,
+				network.NewDNSSeeds(service, agentGRPCPort),
+				network.NewSRVSeeds("grpc", "tcp", service))
 		}
-	}()
+	}
 
-	return nil
+	return providers
 }
 
-// discoverDockerAgents finds agents running in Docker containers
-func (d *AgentDiscovery) discoverDockerAgents() {
-	// Common Docker service names and ports
-	commonNames := []string{
-		"distributed-llm-agent",
-		"agent",
-		"llm-agent",
+// startDockerDiscovery watches the agent containers of the Docker daemon,
+// connecting to them as they start and dropping them as they stop
+func (d *AgentDiscovery) startDockerDiscovery(ctx context.Context) (*docker.ContainerDiscovery, error) {
+	client, err := docker.NewClient(d.dockerHost)
+	if err != nil {
+		return nil, err
+	}
+	containers := docker.NewContainerDiscovery(client, docker.DiscoveryConfig{
+		GRPCPort:        agentGRPCPort,
+		PreferPublished: true,
+	})
+	if err := containers.Start(ctx); err != nil {
+		return nil, err
 	}
+	containers.SetAgentHandler(&dockerAgentHandler{discovery: d, containers: containers})
+	return containers, nil
+}
 
-	ports := []int{8080, 8081, 8082, 8083}
+// dockerAgentHandler follows agent containers starting and stopping
+type dockerAgentHandler struct {
+	discovery  *AgentDiscovery
+	containers *docker.ContainerDiscovery
+}
 
-	for _, name := range commonNames {
-		for _, port := range ports {
-			address := fmt.Sprintf("%s:%d", name, port)
-			if d.tryConnectToAgent(address) {
-				d.logger.Info("Found Docker agent", "address", address)
-			}
+func (h *dockerAgentHandler) AgentStarted(agent docker.Agent) {
+	address := h.containers.GRPCAddr(agent)
+	go func() {
+		if h.discovery.tryConnectToAgent(address) {
+			h.discovery.logger.Info("Found Docker agent", "container", agent.Name, "address", address)
 		}
-	}
+	}()
+}
 
-	// Also try localhost ports for docker-compose
-	for _, port := range ports {
-		address := fmt.Sprintf("localhost:%d", port)
-		if d.tryConnectToAgent(address) {
-			d.logger.Info("Found localhost agent", "address", address)
-		}
-	}
+func (h *dockerAgentHandler) AgentStopped(agent docker.Agent) {
+	h.discovery.removeAgent(h.containers.GRPCAddr(agent))
 }
 
-// discoverK8sAgents finds agents through Kubernetes service discovery
-func (d *AgentDiscovery) discoverK8sAgents() {
-	// Try common Kubernetes service patterns
-	services := []string{
-		fmt.Sprintf("distributed-llm-agent.%s.svc.cluster.local:8080", d.k8sNamespace),
-		fmt.Sprintf("agent.%s.svc.cluster.local:8080", d.k8sNamespace),
-		fmt.Sprintf("llm-agent.%s.svc.cluster.local:8080", d.k8sNamespace),
+// startK8sDiscovery watches the agent pods in the configured namespace
+func (d *AgentDiscovery) startK8sDiscovery(ctx context.Context) (*k8s.PeerDiscovery, error) {
+	client, err := k8s.NewKubernetesClient()
+	if err != nil {
+		return nil, err
+	}
+	discovery, err := k8s.NewPeerDiscovery(client.GetClientset(), k8s.DiscoveryConfig{
+		Namespace: d.k8sNamespace,
+		GRPCPort:  agentGRPCPort,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := discovery.Start(ctx); err != nil {
+		return nil, err
 	}
+	return discovery, nil
+}
 
-	for _, service := range services {
-		if d.tryConnectToAgent(service) {
-			d.logger.Info("Found Kubernetes agent", "service", service)
-		}
+// startLANDiscovery listens for agents announcing themselves on the local network
+func (d *AgentDiscovery) startLANDiscovery() error {
+	lan := network.NewDiscovery(network.DefaultDiscoveryInterval)
+	if d.lanGroup != "" {
+		lan.SetGroup(d.lanGroup)
+	}
+	lan.SetPeerHandler(func(peer network.Peer) {
+		go func() {
+			if d.tryConnectToAgent(peer.GRPCAddr()) {
+				d.logger.Info("Found agent on LAN", "nodeID", peer.NodeID, "address", peer.GRPCAddr())
+			}
+		}()
+	})
+	if err := lan.Start(); err != nil {
+		return err
 	}
 
-	// Try to discover through DNS SRV records
-	d.discoverThroughSRV()
+	d.mu.Lock()
+	d.lan = lan
+	d.mu.Unlock()
+	return nil
 }
 
-// discoverThroughSRV uses DNS SRV records for service discovery
-func (d *AgentDiscovery) discoverThroughSRV() {
-	_, srvs, err := net.LookupSRV("grpc", "tcp", fmt.Sprintf("distributed-llm.%s.svc.cluster.local", d.k8sNamespace))
-	if err != nil {
-		d.logger.Debug("No SRV records found", "error", err)
-		return
-	}
+// discoverThroughGossipEvery asks connected agents for their peers every
+// interval until ctx is done
+func (d *AgentDiscovery) discoverThroughGossipEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	for _, srv := range srvs {
-		address := fmt.Sprintf("%s:%d", strings.TrimSuffix(srv.Target, "."), srv.Port)
-		if d.tryConnectToAgent(address) {
-			d.logger.Info("Found agent via SRV", "address", address)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.discoverThroughGossip()
 		}
 	}
 }
@@ -237,35 +354,35 @@ func (d *AgentDiscovery) tryConnectToAgent(address string) bool {
 		Resources: *resources,
 		LastSeen:  time.Now(),
 	}
+	if version, err := client.GetVersion(); err == nil {
+		node.Version = version.Version
+		node.ProtocolVersion = version.ProtocolVersion
+		node.MinProtocolVersion = version.MinProtocolVersion
+		node.Features = version.Features
+	}
 
 	d.mu.Lock()
 	d.clients[address] = client
 	d.nodes[address] = node
+	ctx := d.ctx
 	d.mu.Unlock()
 
 	// Start resource monitoring for this agent
 	go d.monitorAgent(address, client)
+	if d.metricsChan != nil && ctx != nil {
+		go d.streamMetrics(ctx, address, node.ID, client)
+	}
+	if ctx != nil {
+		go d.streamEvents(ctx, address, client)
+	}
 
 	// Notify UI of updated nodes
 	d.notifyNodesUpdate()
+	d.refreshAlerts(address, client)
 
 	return true
 }
 
-// connectToAgent establishes connection to a specific agent
-func (d *AgentDiscovery) connectToAgent(address string) {
-	d.logger.Info("Connecting to agent", "address", address)
-
-	for {
-		if d.tryConnectToAgent(address) {
-			break
-		}
-
-		d.logger.Debug("Failed to connect to agent, retrying", "address", address)
-		time.Sleep(5 * time.Second)
-	}
-}
-
 // monitorAgent continuously monitors an agent's health and resources
 func (d *AgentDiscovery) monitorAgent(address string, client *Client) {
 	ticker := time.NewTicker(10 * time.Second)
@@ -290,22 +407,204 @@ func (d *AgentDiscovery) monitorAgent(address string, client *Client) {
 			d.mu.Unlock()
 
 			d.notifyNodesUpdate()
+			d.refreshAlerts(address, client)
 		}
 	}
 }
 
+// refreshAlerts asks an agent for the cluster's alerts and sends the UI
+// those reported by every connected agent
+func (d *AgentDiscovery) refreshAlerts(address string, client *Client) {
+	if d.alertsChan == nil {
+		return
+	}
+	alerts, err := client.GetAlerts()
+	if err != nil {
+		// Agents that predate alerting don't serve them
+		d.logger.Debug("Failed to get alerts", "address", address, "error", err)
+		alerts = nil
+	}
+
+	d.mu.Lock()
+	if _, connected := d.clients[address]; connected {
+		d.alerts[address] = alerts
+	}
+	d.mu.Unlock()
+	d.notifyAlertsUpdate()
+}
+
+// notifyAlertsUpdate sends the UI the alerts reported by the agents. Every
+// agent knows the whole cluster's alerts, so they are merged by node and
+// name, keeping the latest evaluation.
+func (d *AgentDiscovery) notifyAlertsUpdate() {
+	if d.alertsChan == nil {
+		return
+	}
+
+	d.mu.RLock()
+	merged := make(map[string]models.Alert)
+	for _, alerts := range d.alerts {
+		for _, alert := range alerts {
+			key := alert.NodeID + "/" + alert.Name
+			if existing, ok := merged[key]; !ok || alert.UpdatedAt.After(existing.UpdatedAt) {
+				merged[key] = alert
+			}
+		}
+	}
+	d.mu.RUnlock()
+
+	alerts := make([]models.Alert, 0, len(merged))
+	for _, alert := range merged {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].NodeID != alerts[j].NodeID {
+			return alerts[i].NodeID < alerts[j].NodeID
+		}
+		return alerts[i].Name < alerts[j].Name
+	})
+
+	select {
+	case d.alertsChan <- alerts:
+	default:
+		// Channel full, skip update
+	}
+}
+
+// streamMetrics forwards an agent's metrics to the UI until the agent is
+// removed, resubscribing with a backfill of whatever the UI missed when the
+// stream breaks
+func (d *AgentDiscovery) streamMetrics(ctx context.Context, address, nodeID string, client *Client) {
+	window := sparklineWidth * metricsInterval
+	var last time.Time
+	for {
+		backfill := window
+		if !last.IsZero() && time.Since(last)+metricsInterval < window {
+			backfill = time.Since(last) + metricsInterval
+		}
+		err := client.StreamMetrics(ctx, metricsInterval, backfill, func(update *pb.MetricsUpdate) {
+			sample := sampleFromProto(nodeID, update)
+			select {
+			case d.metricsChan <- sample:
+				last = sample.Time
+			case <-ctx.Done():
+			}
+		})
+		if status.Code(err) == codes.Unimplemented {
+			d.logger.Debug("Agent does not stream metrics", "address", address)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(metricsInterval):
+		}
+		if current, ok := d.GetClient(address); !ok || current != client {
+			return
+		}
+		d.logger.Debug("Resubscribing to agent metrics", "address", address, "error", err)
+	}
+}
+
+// streamEvents reacts to an agent's events until the agent is removed,
+// resuming after the last event received when the stream breaks. Alerts
+// are refreshed as they fire or resolve and joining nodes are connected
+// to at once.
+func (d *AgentDiscovery) streamEvents(ctx context.Context, address string, client *Client) {
+	// The events the agent retains from before we connected are history
+	connected := time.Now()
+	var after uint64
+	for {
+		err := client.StreamEvents(ctx, after, func(event models.Event) {
+			after = event.Sequence
+			if event.Time.Before(connected) {
+				return
+			}
+			d.handleEvent(address, client, event)
+		})
+		switch status.Code(err) {
+		case codes.Unimplemented, codes.FailedPrecondition:
+			d.logger.Debug("Agent does not stream events", "address", address)
+			return
+		case codes.OutOfRange:
+			// The agent restarted, so all its events are new
+			after = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventRetryInterval):
+		}
+		if current, ok := d.GetClient(address); !ok || current != client {
+			return
+		}
+		d.logger.Debug("Resubscribing to agent events", "address", address, "error", err)
+	}
+}
+
+// handleEvent reacts to an event from an agent and forwards it to the UI
+func (d *AgentDiscovery) handleEvent(address string, client *Client, event models.Event) {
+	switch event.Type {
+	case models.EventAlertFired, models.EventAlertResolved:
+		d.refreshAlerts(address, client)
+	case models.EventNodeJoined:
+		go d.discoverThroughGossip()
+	}
+
+	if d.eventsChan == nil || !d.firstSighting(event) {
+		return
+	}
+	select {
+	case d.eventsChan <- event:
+	default:
+		// Channel full, skip event
+	}
+}
+
+// firstSighting reports whether an event is new to the UI, which it isn't
+// when another agent already reported the same cluster-wide event
+func (d *AgentDiscovery) firstSighting(event models.Event) bool {
+	if !clusterEvents[event.Type] {
+		return true
+	}
+	attributes := make([]string, 0, len(event.Attributes))
+	for k, v := range event.Attributes {
+		attributes = append(attributes, k+"="+v)
+	}
+	sort.Strings(attributes)
+	key := strings.Join([]string{string(event.Type), event.NodeID, event.ModelID, strings.Join(attributes, ",")}, "|")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for k, seen := range d.seenEvents {
+		if now.Sub(seen) > eventDedupWindow {
+			delete(d.seenEvents, k)
+		}
+	}
+	if _, seen := d.seenEvents[key]; seen {
+		return false
+	}
+	d.seenEvents[key] = now
+	return true
+}
+
 // removeAgent removes a disconnected agent
 func (d *AgentDiscovery) removeAgent(address string) {
 	d.mu.Lock()
-	defer d.mu.Unlock()
+	client, exists := d.clients[address]
+	delete(d.clients, address)
+	delete(d.nodes, address)
+	delete(d.alerts, address)
+	d.mu.Unlock()
 
-	if client, exists := d.clients[address]; exists {
+	if exists {
 		client.Close()
-		delete(d.clients, address)
 	}
-
-	delete(d.nodes, address)
 	d.notifyNodesUpdate()
+	d.notifyAlertsUpdate()
 }
 
 // notifyNodesUpdate sends updated node list to the UI
@@ -360,10 +659,20 @@ func (d *AgentDiscovery) Stop() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+	if d.lan != nil {
+		d.lan.Stop()
+		d.lan = nil
+	}
+
 	for _, client := range d.clients {
 		client.Close()
 	}
 
 	d.clients = make(map[string]*Client)
 	d.nodes = make(map[string]*models.Node)
+	d.alerts = make(map[string][]models.Alert)
 }
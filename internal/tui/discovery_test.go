@@ -1,11 +1,18 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"sort"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc"
+
+	"distributed-llm/internal/network"
 	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
 )
 
 func TestNewAgentDiscovery(t *testing.T) {
@@ -353,3 +360,158 @@ func TestAgentDiscovery_ConcurrentOperations(t *testing.T) {
 
 	time.Sleep(100 * time.Millisecond) // Let concurrent operations complete
 }
+
+func freeUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		t.Fatalf("Failed to find free UDP port: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestAgentDiscovery_LAN(t *testing.T) {
+	// A real agent endpoint for the TUI to connect to
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterNodeServiceServer(server, &MockNodeService{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	groupPort := freeUDPPort(t)
+
+	discovery := NewAgentDiscovery(DiscoveryConfig{
+		UpdateChan:   make(chan []models.Node, 10),
+		LANDiscovery: true,
+		LANGroup:     fmt.Sprintf("239.255.76.76:%d", groupPort),
+	})
+	if err := discovery.startLANDiscovery(); err != nil {
+		t.Fatalf("Failed to start LAN discovery: %v", err)
+	}
+	defer discovery.Stop()
+
+	announcer := network.NewDiscovery(time.Second)
+	announcer.SetGroup(fmt.Sprintf("239.255.76.76:%d", freeUDPPort(t)))
+	announcer.SetAnnouncement(network.Announcement{
+		NodeID:     "lan-agent",
+		GRPCPort:   lis.Addr().(*net.TCPAddr).Port,
+		GossipPort: 7946,
+	})
+	if err := announcer.Start(); err != nil {
+		t.Fatalf("Failed to start announcer: %v", err)
+	}
+	defer announcer.Stop()
+
+	// Announce directly since multicast may not be routed in test environments
+	if err := announcer.Broadcast(fmt.Sprintf("127.0.0.1:%d", groupPort)); err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(discovery.GetNodes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, ok := discovery.GetClient(lis.Addr().String()); !ok {
+		t.Errorf("Expected TUI to connect to the announced agent, got nodes %+v", discovery.GetNodes())
+	}
+}
+
+// startMockAgent serves a mock agent on a local port and returns its address
+func startMockAgent(t *testing.T, service *MockNodeService) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterNodeServiceServer(server, service)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestAgentDiscovery_Alerts(t *testing.T) {
+	// Both agents know node-1's alert; the second saw a later evaluation
+	older := &pb.Alert{Name: "HighCPU", NodeId: "node-1", State: "firing", Value: 91, Updated: 100}
+	newer := &pb.Alert{Name: "HighCPU", NodeId: "node-1", State: "firing", Value: 97, Updated: 105}
+	pending := &pb.Alert{Name: "Backlog", NodeId: "node-2", State: "pending", Updated: 105}
+	first := startMockAgent(t, &MockNodeService{alerts: []*pb.Alert{older}})
+	second := startMockAgent(t, &MockNodeService{alerts: []*pb.Alert{newer, pending}})
+
+	alertsChan := make(chan []models.Alert, 10)
+	discovery := NewAgentDiscovery(DiscoveryConfig{AlertsChan: alertsChan})
+	defer discovery.Stop()
+	for _, address := range []string{first, second} {
+		if !discovery.tryConnectToAgent(address) {
+			t.Fatalf("Failed to connect to %s", address)
+		}
+	}
+
+	var alerts []models.Alert
+	for len(alertsChan) > 0 {
+		alerts = <-alertsChan
+	}
+	if len(alerts) != 2 || alerts[0].NodeID != "node-1" || alerts[0].Value != 97 || alerts[1].Name != "Backlog" {
+		t.Fatalf("Expected the newest of each alert, got %+v", alerts)
+	}
+
+	// Alerts known only to a removed agent clear
+	discovery.removeAgent(second)
+	alerts = <-alertsChan
+	for len(alertsChan) > 0 {
+		alerts = <-alertsChan
+	}
+	if len(alerts) != 1 || alerts[0].Value != 91 {
+		t.Errorf("Expected only the first agent's alerts, got %+v", alerts)
+	}
+}
+
+func TestAgentDiscovery_Events(t *testing.T) {
+	now := time.Now()
+	joined := &pb.ClusterEvent{Sequence: 2, Type: "node_joined", NodeId: "node-3", TimeUnixMs: now.Add(time.Minute).UnixMilli()}
+	first := startMockAgent(t, &MockNodeService{events: []*pb.ClusterEvent{
+		// Published before the TUI connected
+		{Sequence: 1, Type: "model_loaded", NodeId: "node-1", ModelId: "old", TimeUnixMs: now.Add(-time.Hour).UnixMilli()},
+		joined,
+		{Sequence: 3, Type: "model_loaded", NodeId: "node-1", ModelId: "llama-7b", TimeUnixMs: now.Add(time.Minute).UnixMilli()},
+	}})
+	// Every agent sees the join, with its own sequence numbers
+	second := startMockAgent(t, &MockNodeService{events: []*pb.ClusterEvent{
+		{Sequence: 7, Type: "node_joined", NodeId: "node-3", TimeUnixMs: joined.TimeUnixMs + 20},
+		{Sequence: 8, Type: "request_failed", NodeId: "node-2", ModelId: "llama-7b", TimeUnixMs: now.Add(time.Minute).UnixMilli()},
+	}})
+
+	eventsChan := make(chan models.Event, 10)
+	discovery := NewAgentDiscovery(DiscoveryConfig{EventsChan: eventsChan})
+	discovery.ctx, discovery.cancel = context.WithCancel(context.Background())
+	defer discovery.Stop()
+	for _, address := range []string{first, second} {
+		if !discovery.tryConnectToAgent(address) {
+			t.Fatalf("Failed to connect to %s", address)
+		}
+	}
+
+	var got []string
+	timeout := time.After(2 * time.Second)
+	for len(got) < 3 {
+		select {
+		case event := <-eventsChan:
+			got = append(got, fmt.Sprintf("%s:%s%s", event.Type, event.NodeID, event.ModelID))
+		case <-timeout:
+			t.Fatalf("Expected 3 events, got %v", got)
+		}
+	}
+	sort.Strings(got)
+	if want := "[model_loaded:node-1llama-7b node_joined:node-3 request_failed:node-2llama-7b]"; fmt.Sprint(got) != want {
+		t.Errorf("Expected %s, got %v", want, got)
+	}
+	select {
+	case event := <-eventsChan:
+		t.Errorf("Expected no more events, got %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
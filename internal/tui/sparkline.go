@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	pb "distributed-llm/proto"
+)
+
+// sparklineWidth is how many samples a node's sparklines show
+const sparklineWidth = 40
+
+// metricsInterval is how often agents stream metrics to the TUI. Agents
+// snapshot their metrics every 5 seconds, so a shorter interval adds nothing.
+const metricsInterval = 5 * time.Second
+
+// sparkBlocks are the bars of a sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// MetricsSample is one reading of the metrics a node's sparklines track
+type MetricsSample struct {
+	NodeID          string
+	Time            time.Time
+	CPUPercent      float64
+	TokensPerSecond float64
+	RequestsActive  int
+}
+
+// sampleFromProto reads the sparkline metrics of a streamed update
+func sampleFromProto(nodeID string, update *pb.MetricsUpdate) MetricsSample {
+	metrics := update.GetMetrics()
+	return MetricsSample{
+		NodeID:          nodeID,
+		Time:            time.Unix(update.GetTimestamp(), 0),
+		CPUPercent:      float64(metrics.GetResourceMetrics().GetCpuUsagePercent()),
+		TokensPerSecond: float64(metrics.GetInferenceMetrics().GetTokensPerSecond()),
+		RequestsActive:  int(metrics.GetInferenceMetrics().GetRequestsActive()),
+	}
+}
+
+// nodeSeries holds the latest sparklineWidth samples of a node
+type nodeSeries struct {
+	last     time.Time
+	cpu      []float64
+	tokens   []float64
+	requests []float64
+}
+
+// add appends a sample, ignoring any that is not newer than the last so a
+// resubscribe's backfill doesn't repeat samples
+func (s *nodeSeries) add(sample MetricsSample) {
+	if !sample.Time.After(s.last) {
+		return
+	}
+	s.last = sample.Time
+	s.cpu = appendWindow(s.cpu, sample.CPUPercent)
+	s.tokens = appendWindow(s.tokens, sample.TokensPerSecond)
+	s.requests = appendWindow(s.requests, float64(sample.RequestsActive))
+}
+
+func appendWindow(values []float64, v float64) []float64 {
+	values = append(values, v)
+	if len(values) > sparklineWidth {
+		values = values[len(values)-sparklineWidth:]
+	}
+	return values
+}
+
+// render draws the node's sparklines with the latest value of each
+func (s *nodeSeries) render() string {
+	if len(s.cpu) == 0 {
+		return ""
+	}
+	n := len(s.cpu) - 1
+	return fmt.Sprintf("CPU%%: %s %3.0f%%\n", sparkline(s.cpu, 100), s.cpu[n]) +
+		fmt.Sprintf("TOKS: %s %.1f/S\n", sparkline(s.tokens, 0), s.tokens[n]) +
+		fmt.Sprintf("REQS: %s %.0f ACTIVE", sparkline(s.requests, 0), s.requests[n])
+}
+
+// sparkline draws values as bars scaled to ceiling, or to the largest value
+// when ceiling is 0, padded on the left to sparklineWidth
+func sparkline(values []float64, ceiling float64) string {
+	if ceiling <= 0 {
+		for _, v := range values {
+			ceiling = max(ceiling, v)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", max(sparklineWidth-len(values), 0)))
+	for _, v := range values {
+		level := 0
+		if ceiling > 0 {
+			level = int(v / ceiling * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[min(max(level, 0), len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+func TestSparkline(t *testing.T) {
+	pad := strings.Repeat(" ", sparklineWidth-4)
+	tests := []struct {
+		name    string
+		values  []float64
+		ceiling float64
+		want    string
+	}{
+		{"scaled to the ceiling", []float64{0, 50, 100, 150}, 100, pad + "▁▄██"},
+		{"scaled to the largest value", []float64{0, 1, 2, 4}, 0, pad + "▁▂▄█"},
+		{"all zero", []float64{0, 0, 0, 0}, 0, pad + "▁▁▁▁"},
+		{"empty", nil, 0, strings.Repeat(" ", sparklineWidth)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sparkline(tt.values, tt.ceiling); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNodeSeries(t *testing.T) {
+	start := time.Unix(1_800_000_000, 0)
+	series := &nodeSeries{}
+	for i := 0; i < sparklineWidth+5; i++ {
+		series.add(MetricsSample{Time: start.Add(time.Duration(i) * metricsInterval), CPUPercent: float64(i)})
+	}
+	// A resubscribe's backfill repeats samples already drawn
+	series.add(MetricsSample{Time: start, CPUPercent: 99})
+
+	if len(series.cpu) != sparklineWidth || series.cpu[0] != 5 || series.cpu[sparklineWidth-1] != sparklineWidth+4 {
+		t.Errorf("Expected the latest %d samples, got %v", sparklineWidth, series.cpu)
+	}
+}
+
+func TestModelDrawsSparklines(t *testing.T) {
+	update := &pb.MetricsUpdate{
+		NodeId:    "agent-1",
+		Timestamp: 1_800_000_000,
+		Metrics: &pb.NodeMetrics{
+			ResourceMetrics:  &pb.ResourceMetrics{CpuUsagePercent: 42},
+			InferenceMetrics: &pb.InferenceMetrics{TokensPerSecond: 12.5, RequestsActive: 3},
+		},
+	}
+
+	metricsChan := make(chan MetricsSample, 1)
+	model := NewModel()
+	model.SetMetricsChan(metricsChan)
+	model.UpdateNodes([]models.Node{{ID: "node-1", Status: models.NodeStatusOnline}})
+	if view := stripANSI(model.renderNodesTab()); strings.Contains(view, "CPU%") {
+		t.Errorf("Expected no sparklines before metrics arrive, got:\n%s", view)
+	}
+
+	updated, cmd := model.Update(MetricsSampleMsg(sampleFromProto("node-1", update)))
+	if cmd == nil {
+		t.Error("Expected to keep waiting for metrics")
+	}
+	view := stripANSI(updated.(Model).renderNodesTab())
+	for _, want := range []string{"CPU%:", "42%", "TOKS:", "12.5/S", "REQS:", "3 ACTIVE"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the node, got:\n%s", want, view)
+		}
+	}
+}
@@ -111,12 +111,24 @@ type Model struct {
 	lastUpdate      time.Time
 	nodeUpdateChan  chan []models.Node
 	modelUpdateChan chan []models.Model
+	metricsChan     chan MetricsSample
+	series          map[string]*nodeSeries
+	alerts          []models.Alert
+	alertsChan      chan []models.Alert
+	events          []models.Event
+	eventsChan      chan models.Event
 	glitch          *GlitchEffect
 }
 
 type TickMsg time.Time
 type NodesUpdateMsg []models.Node
 type ModelsUpdateMsg []models.Model
+type MetricsSampleMsg MetricsSample
+type AlertsUpdateMsg []models.Alert
+type EventMsg models.Event
+
+// eventLogSize is how many of the latest events are shown
+const eventLogSize = 5
 
 func tickCmd() tea.Cmd {
 	return tea.Tick(time.Second*2, func(t time.Time) tea.Msg {
@@ -136,6 +148,24 @@ func waitForModelsUpdate(modelUpdateChan chan []models.Model) tea.Cmd {
 	}
 }
 
+func waitForMetricsSample(metricsChan chan MetricsSample) tea.Cmd {
+	return func() tea.Msg {
+		return MetricsSampleMsg(<-metricsChan)
+	}
+}
+
+func waitForAlertsUpdate(alertsChan chan []models.Alert) tea.Cmd {
+	return func() tea.Msg {
+		return AlertsUpdateMsg(<-alertsChan)
+	}
+}
+
+func waitForEvent(eventsChan chan models.Event) tea.Cmd {
+	return func() tea.Msg {
+		return EventMsg(<-eventsChan)
+	}
+}
+
 func (m Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
 	cmds = append(cmds, tickCmd())
@@ -148,6 +178,18 @@ func (m Model) Init() tea.Cmd {
 		cmds = append(cmds, waitForModelsUpdate(m.modelUpdateChan))
 	}
 
+	if m.metricsChan != nil {
+		cmds = append(cmds, waitForMetricsSample(m.metricsChan))
+	}
+
+	if m.alertsChan != nil {
+		cmds = append(cmds, waitForAlertsUpdate(m.alertsChan))
+	}
+
+	if m.eventsChan != nil {
+		cmds = append(cmds, waitForEvent(m.eventsChan))
+	}
+
 	return tea.Batch(cmds...)
 }
 
@@ -207,6 +249,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ModelsUpdateMsg:
 		m.modelList = []models.Model(msg)
 		return m, waitForModelsUpdate(m.modelUpdateChan)
+
+	case MetricsSampleMsg:
+		m.AddMetricsSample(MetricsSample(msg))
+		if m.metricsChan == nil {
+			return m, nil
+		}
+		return m, waitForMetricsSample(m.metricsChan)
+
+	case AlertsUpdateMsg:
+		m.alerts = []models.Alert(msg)
+		if m.alertsChan == nil {
+			return m, nil
+		}
+		return m, waitForAlertsUpdate(m.alertsChan)
+
+	case EventMsg:
+		m.AddEvent(models.Event(msg))
+		if m.eventsChan == nil {
+			return m, nil
+		}
+		return m, waitForEvent(m.eventsChan)
 	}
 
 	return m, nil
@@ -315,6 +378,34 @@ func (m Model) renderNodesTab() string {
 	content.WriteString(nodeCountStyle.Render(fmt.Sprintf("ACTIVE NODES: %d", len(m.nodes))))
 	content.WriteString("\n\n")
 
+	if warning := versionWarning(m.nodes); warning != "" {
+		content.WriteString(statusBusyStyle.Render(warning))
+		content.WriteString("\n\n")
+	}
+
+	if firing, pending := alertLines(m.alerts); len(firing) > 0 || pending != "" {
+		for _, line := range firing {
+			content.WriteString(statusOfflineStyle.Render(line))
+			content.WriteString("\n")
+		}
+		if pending != "" {
+			content.WriteString(statusBusyStyle.Render(pending))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+
+	if len(m.events) > 0 {
+		eventStyle := lipgloss.NewStyle().Foreground(darkGreen)
+		content.WriteString(eventStyle.Render("RECENT EVENTS:"))
+		content.WriteString("\n")
+		for i := len(m.events) - 1; i >= 0; i-- {
+			content.WriteString(eventStyle.Render(eventLine(m.events[i])))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+
 	for i, node := range m.nodes {
 		nodeContent := m.renderNode(node, i == m.selectedNode)
 		content.WriteString(nodeContent)
@@ -349,6 +440,12 @@ func (m Model) renderNode(node models.Node, selected bool) string {
 	content := headerStyle.Render(nodeHeader) + "\n"
 	content += statusStyle.Render(statusIcon) + "\n"
 	content += fmt.Sprintf("ADDR: %s:%d\n", node.Address, node.Port)
+	if node.Version != "" {
+		content += fmt.Sprintf("VER:  %s │ PROTO: %d\n", strings.ToUpper(node.Version), node.ProtocolVersion)
+	}
+	if node.Zone() != "" || node.Rack() != "" {
+		content += fmt.Sprintf("ZONE: %s │ RACK: %s\n", strings.ToUpper(node.Zone()), strings.ToUpper(node.Rack()))
+	}
 	content += fmt.Sprintf("CPU:  %d CORES │ RAM: %d MB\n", node.Resources.CPUCores, node.Resources.MemoryMB)
 
 	if len(node.Resources.GPUs) > 0 {
@@ -394,9 +491,73 @@ func (m Model) renderNode(node models.Node, selected bool) string {
 	layerBar := strings.Repeat("█", usedBars) + strings.Repeat("░", availBars)
 	content += fmt.Sprintf("LYRS: [%s] %d/%d AVAIL", layerBar, layersAvail, layersMax)
 
+	if series, ok := m.series[node.ID]; ok && len(series.cpu) > 0 {
+		content += "\n" + series.render()
+	}
+
 	return style.Render(content)
 }
 
+// versionWarning flags clusters running more than one build version, and
+// louder if some nodes share no protocol version and can't form pipelines
+func versionWarning(nodes []models.Node) string {
+	versions := models.DistinctVersions(nodes)
+	if len(versions) < 2 {
+		return ""
+	}
+
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			if !nodes[i].CompatibleWith(&nodes[j]) {
+				return fmt.Sprintf("!! INCOMPATIBLE VERSIONS: %s - PIPELINES SPLIT BY PROTOCOL !!",
+					strings.ToUpper(strings.Join(versions, ", ")))
+			}
+		}
+	}
+	return fmt.Sprintf("!! MIXED VERSIONS: %s - ROLLING UPGRADE IN PROGRESS !!",
+		strings.ToUpper(strings.Join(versions, ", ")))
+}
+
+// alertLines describes each firing alert and counts the pending ones
+func alertLines(alerts []models.Alert) ([]string, string) {
+	var firing []string
+	pending := 0
+	for _, alert := range alerts {
+		switch alert.State {
+		case models.AlertFiring:
+			line := fmt.Sprintf("!! ALERT %s ON %s: %s = %.4g", alert.Name, alert.NodeID, alert.Expr, alert.Value)
+			if alert.Severity != "" {
+				line += fmt.Sprintf(" [%s]", alert.Severity)
+			}
+			firing = append(firing, strings.ToUpper(line)+" !!")
+		case models.AlertPending:
+			pending++
+		}
+	}
+	if pending == 0 {
+		return firing, ""
+	}
+	return firing, fmt.Sprintf("%d ALERT(S) PENDING", pending)
+}
+
+// eventLine describes an event on one line
+func eventLine(event models.Event) string {
+	line := fmt.Sprintf("%s %s", event.Time.Format("15:04:05"), event.Type)
+	if event.NodeID != "" {
+		line += " " + event.NodeID
+	}
+	if event.ModelID != "" {
+		line += " " + event.ModelID
+	}
+	if name := event.Attributes["alert"]; name != "" {
+		line += " " + name
+	}
+	if event.Message != "" {
+		line += ": " + event.Message
+	}
+	return strings.ToUpper(line)
+}
+
 func (m Model) renderModelsTab() string {
 	var content strings.Builder
 
@@ -524,3 +685,39 @@ func (m *Model) UpdateNodes(nodes []models.Node) {
 func (m *Model) UpdateModels(models []models.Model) {
 	m.modelList = models
 }
+
+// SetMetricsChan draws sparklines of the node metrics sent on metricsChan
+func (m *Model) SetMetricsChan(metricsChan chan MetricsSample) {
+	m.metricsChan = metricsChan
+}
+
+// SetAlertsChan shows the cluster's alerts sent on alertsChan
+func (m *Model) SetAlertsChan(alertsChan chan []models.Alert) {
+	m.alertsChan = alertsChan
+}
+
+// SetEventsChan shows the latest events sent on eventsChan
+func (m *Model) SetEventsChan(eventsChan chan models.Event) {
+	m.eventsChan = eventsChan
+}
+
+// AddEvent adds an event to those shown, forgetting the oldest
+func (m *Model) AddEvent(event models.Event) {
+	m.events = append(m.events, event)
+	if len(m.events) > eventLogSize {
+		m.events = m.events[len(m.events)-eventLogSize:]
+	}
+}
+
+// AddMetricsSample extends the sparklines of the sample's node
+func (m *Model) AddMetricsSample(sample MetricsSample) {
+	if m.series == nil {
+		m.series = make(map[string]*nodeSeries)
+	}
+	series, ok := m.series[sample.NodeID]
+	if !ok {
+		series = &nodeSeries{}
+		m.series[sample.NodeID] = series
+	}
+	series.add(sample)
+}
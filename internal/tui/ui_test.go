@@ -389,3 +389,109 @@ func BenchmarkRenderNodes(b *testing.B) {
 		model.renderNodesTab()
 	}
 }
+
+func TestVersionWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodes    []models.Node
+		contains string
+	}{
+		{
+			name: "single version",
+			nodes: []models.Node{
+				{ID: "node-1", Version: "v1", ProtocolVersion: 1},
+				{ID: "node-2", Version: "v1", ProtocolVersion: 1},
+			},
+		},
+		{
+			name: "rolling upgrade",
+			nodes: []models.Node{
+				{ID: "node-1", Version: "v1", ProtocolVersion: 1},
+				{ID: "node-2", Version: "v2", ProtocolVersion: 2, MinProtocolVersion: 1},
+			},
+			contains: "MIXED VERSIONS: V1, V2",
+		},
+		{
+			name: "incompatible protocols",
+			nodes: []models.Node{
+				{ID: "node-1", Version: "v1", ProtocolVersion: 1},
+				{ID: "node-2", Version: "v3", ProtocolVersion: 3, MinProtocolVersion: 3},
+			},
+			contains: "INCOMPATIBLE VERSIONS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning := versionWarning(tt.nodes)
+			if tt.contains == "" && warning != "" {
+				t.Errorf("Expected no warning, got %q", warning)
+			}
+			if !strings.Contains(warning, tt.contains) {
+				t.Errorf("Expected warning containing %q, got %q", tt.contains, warning)
+			}
+		})
+	}
+
+	model := NewModel()
+	model.UpdateNodes(tests[1].nodes)
+	view := stripANSI(model.renderNodesTab())
+	if !strings.Contains(view, "MIXED VERSIONS") || !strings.Contains(view, "VER:  V2") {
+		t.Errorf("Nodes tab should flag the mixed-version cluster, got:\n%s", view)
+	}
+}
+
+func TestAlertLines(t *testing.T) {
+	alerts := []models.Alert{
+		{Name: "HighCPU", NodeID: "node-1", State: models.AlertFiring, Expr: "cpu_usage_percent > 90", Value: 95.25, Severity: "warning"},
+		{Name: "Backlog", NodeID: "node-2", State: models.AlertPending},
+		{Name: "Errors", NodeID: "node-2", State: models.AlertPending},
+	}
+
+	firing, pending := alertLines(alerts)
+	if len(firing) != 1 || firing[0] != "!! ALERT HIGHCPU ON NODE-1: CPU_USAGE_PERCENT > 90 = 95.25 [WARNING] !!" {
+		t.Errorf("Unexpected firing alerts %q", firing)
+	}
+	if pending != "2 ALERT(S) PENDING" {
+		t.Errorf("Unexpected pending summary %q", pending)
+	}
+
+	model := NewModel()
+	model.UpdateNodes([]models.Node{{ID: "node-1", Status: models.NodeStatusOnline}})
+	updated, _ := model.Update(AlertsUpdateMsg(alerts))
+	if view := stripANSI(updated.(Model).renderNodesTab()); !strings.Contains(view, "ALERT HIGHCPU ON NODE-1") || !strings.Contains(view, "2 ALERT(S) PENDING") {
+		t.Errorf("Nodes tab should show the alerts, got:\n%s", view)
+	}
+	if firing, pending := alertLines(nil); firing != nil || pending != "" {
+		t.Errorf("Expected nothing without alerts, got %q %q", firing, pending)
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	at := time.Date(2026, 1, 1, 12, 30, 45, 0, time.Local)
+	tests := []struct {
+		event models.Event
+		want  string
+	}{
+		{models.Event{Time: at, Type: models.EventNodeJoined, NodeID: "node-2"}, "12:30:45 NODE_JOINED NODE-2"},
+		{models.Event{Time: at, Type: models.EventRequestFailed, NodeID: "node-1", ModelID: "llama-7b", Message: "no replica"}, "12:30:45 REQUEST_FAILED NODE-1 LLAMA-7B: NO REPLICA"},
+		{models.Event{Time: at, Type: models.EventAlertFired, NodeID: "node-1", Attributes: map[string]string{"alert": "HighCPU"}}, "12:30:45 ALERT_FIRED NODE-1 HIGHCPU"},
+	}
+	for _, tt := range tests {
+		if got := eventLine(tt.event); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+
+	// Only the latest events are shown, newest first
+	model := NewModel()
+	model.UpdateNodes([]models.Node{{ID: "node-1", Status: models.NodeStatusOnline}})
+	var updated tea.Model = model
+	for i := 0; i < eventLogSize+2; i++ {
+		updated, _ = updated.Update(EventMsg(models.Event{Time: at, Type: models.EventModelLoaded, ModelID: fmt.Sprintf("model-%d", i)}))
+	}
+	view := stripANSI(updated.(Model).renderNodesTab())
+	if strings.Contains(view, "MODEL-1\n") || !strings.Contains(view, "RECENT EVENTS:\n12:30:45 MODEL_LOADED MODEL-6") {
+		t.Errorf("Nodes tab should show the latest events, got:\n%s", view)
+	}
+}
@@ -0,0 +1,132 @@
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// csvHeader names the columns of CSV exports
+var csvHeader = []string{
+	"time", "request_id", "tenant", "model_id", "success",
+	"prompt_tokens", "completion_tokens", "duration_ms", "compute_ms", "bytes_moved", "nodes",
+}
+
+// Export writes records in the named format
+func Export(w io.Writer, format string, records []Record) error {
+	e, err := NewExporter(w, format)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		e.Add(&records[i])
+	}
+	return e.Close()
+}
+
+// Exporter writes records one at a time in an export format, so that an
+// export of the whole ledger streams from Ledger.Scan. CSV has one row per
+// record with its totals across nodes, nodes listing the nodes that served
+// the request separated by semicolons; JSON is an array of the records,
+// with each node's usage.
+type Exporter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	records int
+	err     error
+}
+
+// NewExporter starts an export in the named format
+func NewExporter(w io.Writer, format string) (*Exporter, error) {
+	e := &Exporter{w: w}
+	switch format {
+	case FormatCSV:
+		e.csv = csv.NewWriter(w)
+		e.err = e.csv.Write(csvHeader)
+	case FormatJSON:
+		_, e.err = io.WriteString(w, "[")
+	default:
+		return nil, fmt.Errorf("unknown export format %q (want %s or %s)", format, FormatCSV, FormatJSON)
+	}
+	return e, nil
+}
+
+// Add writes a record. Errors are returned by Close.
+func (e *Exporter) Add(r *Record) {
+	if e.err != nil {
+		return
+	}
+	if e.csv != nil {
+		e.err = e.csv.Write(csvRow(r))
+	} else {
+		e.err = e.writeJSON(r)
+	}
+	e.records++
+}
+
+func (e *Exporter) writeJSON(r *Record) error {
+	data, err := json.MarshalIndent(r, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if e.records == 0 {
+		sep = "\n  "
+	}
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+// Close finishes the export
+func (e *Exporter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	end := "\n]\n"
+	if e.records == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+func csvRow(r *Record) []string {
+	nodes := make([]string, len(r.Nodes))
+	for j, node := range r.Nodes {
+		nodes[j] = node.NodeID
+	}
+	return []string{
+		r.Time.UTC().Format(time.RFC3339Nano),
+		r.RequestID,
+		r.Tenant,
+		r.ModelID,
+		strconv.FormatBool(r.Success),
+		strconv.Itoa(r.PromptTokens),
+		strconv.Itoa(r.CompletionTokens),
+		milliseconds(r.Duration),
+		milliseconds(r.ComputeTime()),
+		strconv.FormatInt(r.BytesMoved(), 10),
+		strings.Join(nodes, ";"),
+	}
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
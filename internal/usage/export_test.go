@@ -0,0 +1,78 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+)
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, FormatCSV, []Record{record(0, "acme", "llama-7b")}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected a header and 1 row, got %v", rows)
+	}
+	want := []string{"2026-01-01T00:00:00Z", "req-0", "acme", "llama-7b", "true", "10", "20", "1000.000", "300.000", "1100", "node-a;node-b"}
+	for i, column := range csvHeader {
+		if rows[0][i] != column {
+			t.Errorf("Header column %d: expected %s, got %s", i, column, rows[0][i])
+		}
+		if rows[1][i] != want[i] {
+			t.Errorf("Column %s: expected %s, got %s", column, want[i], rows[1][i])
+		}
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []Record
+	}{
+		{"records", []Record{record(0, "acme", "llama-7b"), record(1, "globex", "llama-7b")}},
+		{"no records", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Export(&buf, FormatJSON, tt.records); err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			var decoded []Record
+			if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+			}
+			if decoded == nil || len(decoded) != len(tt.records) {
+				t.Fatalf("Expected an array of %d records, got %s", len(tt.records), buf.String())
+			}
+			for i := range decoded {
+				if mustJSON(t, decoded[i]) != mustJSON(t, tt.records[i]) {
+					t.Errorf("Record %d changed: %+v", i, decoded[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExportRejectsUnknownFormat(t *testing.T) {
+	if err := Export(&bytes.Buffer{}, "xml", nil); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
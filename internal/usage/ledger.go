@@ -0,0 +1,299 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	currentFile   = "usage.jsonl"
+	rotatedPrefix = "usage-"
+	rotatedSuffix = ".jsonl"
+
+	// DefaultMaxFileBytes is the size at which the ledger file is rotated
+	DefaultMaxFileBytes = 64 << 20
+	// DefaultMaxFiles is the number of rotated files kept
+	DefaultMaxFiles = 10
+	// MaxQueryRecords bounds the records Query returns
+	MaxQueryRecords = 10000
+)
+
+// errClosed is returned when appending to a closed ledger
+var errClosed = errors.New("usage ledger is closed")
+
+// Config says where the ledger lives and how much of it is kept
+type Config struct {
+	Dir string
+	// MaxFileBytes rotates the file before it grows past this size
+	MaxFileBytes int64
+	// MaxFiles is the number of rotated files kept besides the current one;
+	// older ones are deleted
+	MaxFiles int
+}
+
+// Ledger is an append-only log of usage records, one JSON object per line.
+// Full files are renamed with the time they were rotated and the oldest are
+// deleted. Records are not synced to disk one by one, so a crash may lose
+// the last few. Queries read the files without holding up appends.
+type Ledger struct {
+	cfg Config
+	now func() time.Time
+
+	mu sync.Mutex
+	// file is nil after a failed rotation, until the next append reopens it
+	file   *os.File
+	size   int64
+	closed bool
+}
+
+// Open opens the ledger in cfg.Dir, creating it if needed
+func Open(cfg Config) (*Ledger, error) {
+	if cfg.MaxFileBytes <= 0 {
+		cfg.MaxFileBytes = DefaultMaxFileBytes
+	}
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = DefaultMaxFiles
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create usage directory: %w", err)
+	}
+
+	l := &Ledger{cfg: cfg, now: time.Now}
+	if err := l.openLocked(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Ledger) openLocked() error {
+	file, err := os.OpenFile(filepath.Join(l.cfg.Dir, currentFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open usage ledger: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat usage ledger: %w", err)
+	}
+	l.file, l.size = file, info.Size()
+
+	// End a line torn by a crash so the next record starts on its own line
+	if l.size > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, l.size-1); err != nil {
+			file.Close()
+			return fmt.Errorf("read usage ledger: %w", err)
+		}
+		if last[0] != '\n' {
+			n, err := file.Write([]byte{'\n'})
+			l.size += int64(n)
+			if err != nil {
+				file.Close()
+				return fmt.Errorf("repair usage ledger: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Append records a request's usage
+func (l *Ledger) Append(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return errClosed
+	}
+	if l.file == nil {
+		if err := l.openLocked(); err != nil {
+			return err
+		}
+	}
+	if l.size > 0 && l.size+int64(len(data)) > l.cfg.MaxFileBytes {
+		if err := l.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write usage record: %w", err)
+	}
+	return nil
+}
+
+// rotateLocked renames the current file after the time and starts a new
+// one, deleting the oldest rotated files beyond MaxFiles
+func (l *Ledger) rotateLocked() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("close usage ledger: %w", err)
+	}
+	l.file = nil
+	rotated := rotatedPrefix + l.now().UTC().Format("20060102T150405.000000000Z") + rotatedSuffix
+	if err := os.Rename(filepath.Join(l.cfg.Dir, currentFile), filepath.Join(l.cfg.Dir, rotated)); err != nil {
+		return fmt.Errorf("rotate usage ledger: %w", err)
+	}
+	if err := l.openLocked(); err != nil {
+		return err
+	}
+
+	files, err := l.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for len(files) > l.cfg.MaxFiles {
+		if err := os.Remove(filepath.Join(l.cfg.Dir, files[0])); err != nil {
+			return fmt.Errorf("delete old usage file: %w", err)
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// rotatedFiles lists the rotated files, oldest first
+func (l *Ledger) rotatedFiles() ([]string, error) {
+	entries, err := os.ReadDir(l.cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("list usage files: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, rotatedPrefix) && strings.HasSuffix(name, rotatedSuffix) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Query returns the newest records matching the filter, at most Limit or
+// MaxQueryRecords, oldest first
+func (l *Ledger) Query(f Filter) ([]Record, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > MaxQueryRecords {
+		limit = MaxQueryRecords
+	}
+	// Keep the newest records in a ring, next being the oldest once full
+	var ring []Record
+	next := 0
+	err := l.Scan(f, func(r *Record) {
+		if len(ring) < limit {
+			ring = append(ring, *r)
+			return
+		}
+		ring[next] = *r
+		next = (next + 1) % limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(ring[next:len(ring):len(ring)], ring[:next]...), nil
+}
+
+// Scan calls fn with every record matching the filter, oldest first,
+// ignoring its limit. Records appended meanwhile are not included. Lines
+// that don't decode, such as one torn by a crash, are skipped.
+func (l *Ledger) Scan(f Filter, fn func(*Record)) error {
+	files, err := l.scanFiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, sf := range files {
+			sf.file.Close()
+		}
+	}()
+
+	for _, sf := range files {
+		reader := bufio.NewReader(io.LimitReader(sf.file, sf.size))
+		for {
+			line, err := reader.ReadBytes('\n')
+			var r Record
+			if len(line) > 0 && json.Unmarshal(line, &r) == nil && f.Match(&r) {
+				fn(&r)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("read %s: %w", sf.file.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// scanFile is a ledger file opened for a scan, read up to size
+type scanFile struct {
+	file *os.File
+	size int64
+}
+
+// scanFiles opens the rotated files and the current one, oldest first.
+// Opening them under the lock keeps a rotation from moving the current file
+// between listing and opening them, and open files stay readable if they
+// are deleted meanwhile.
+func (l *Ledger) scanFiles() ([]scanFile, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	names, err := l.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	var files []scanFile
+	fail := func(err error) ([]scanFile, error) {
+		for _, sf := range files {
+			sf.file.Close()
+		}
+		return nil, err
+	}
+	for _, name := range append(names, currentFile) {
+		file, err := os.Open(filepath.Join(l.cfg.Dir, name))
+		if errors.Is(err, os.ErrNotExist) && name == currentFile {
+			continue
+		}
+		if err != nil {
+			return fail(fmt.Errorf("open %s: %w", name, err))
+		}
+		size := l.size
+		if name != currentFile || l.file == nil {
+			info, err := file.Stat()
+			if err != nil {
+				file.Close()
+				return fail(fmt.Errorf("stat %s: %w", name, err))
+			}
+			size = info.Size()
+		}
+		files = append(files, scanFile{file: file, size: size})
+	}
+	return files, nil
+}
+
+// Close closes the ledger file
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,215 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func record(i int, tenant, model string) Record {
+	return Record{
+		Time:             time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC),
+		RequestID:        fmt.Sprintf("req-%d", i),
+		Tenant:           tenant,
+		ModelID:          model,
+		PromptTokens:     10,
+		CompletionTokens: 20,
+		Duration:         time.Second,
+		Success:          true,
+		Nodes: []NodeUsage{
+			{NodeID: "node-a", ComputeTime: 100 * time.Millisecond, BytesReceived: 100, BytesSent: 1000},
+			{NodeID: "node-b", ComputeTime: 200 * time.Millisecond, BytesReceived: 1000, BytesSent: 100},
+		},
+	}
+}
+
+func TestLedgerAppendAndQuery(t *testing.T) {
+	dir := t.TempDir()
+	ledger, err := Open(Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for i, tenant := range []string{"acme", "globex", "acme", "acme"} {
+		model := "llama-7b"
+		if i == 3 {
+			model = "mistral-7b"
+		}
+		if err := ledger.Append(record(i, tenant, model)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	ledger.Close()
+
+	// Records survive a restart
+	ledger, err = Open(Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer ledger.Close()
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"everything", Filter{}, []string{"req-0", "req-1", "req-2", "req-3"}},
+		{"by tenant", Filter{Tenant: "acme"}, []string{"req-0", "req-2", "req-3"}},
+		{"by model", Filter{ModelID: "mistral-7b"}, []string{"req-3"}},
+		{"by time", Filter{Since: record(1, "", "").Time, Until: record(3, "", "").Time}, []string{"req-1", "req-2"}},
+		{"newest", Filter{Tenant: "acme", Limit: 2}, []string{"req-2", "req-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := ledger.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			var got []string
+			for _, r := range records {
+				got = append(got, r.RequestID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLedgerRotation(t *testing.T) {
+	dir := t.TempDir()
+	size := len(fmt.Sprintln(mustJSON(t, record(0, "acme", "llama-7b"))))
+	ledger, err := Open(Config{Dir: dir, MaxFileBytes: int64(2 * size), MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer ledger.Close()
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ledger.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	// Two records fit in a file: 10 records make 5 files, of which the
+	// current one and the 2 newest rotated ones are kept
+	for i := 0; i < 10; i++ {
+		if err := ledger.Append(record(i, "acme", "llama-7b")); err != nil {
+			t.Fatalf("Append %d failed: %v", i, err)
+		}
+	}
+
+	rotated, err := ledger.rotatedFiles()
+	if err != nil {
+		t.Fatalf("rotatedFiles failed: %v", err)
+	}
+	if len(rotated) != 2 {
+		t.Errorf("Expected 2 rotated files, got %v", rotated)
+	}
+	records, err := ledger.Query(Filter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(records) != 6 || records[0].RequestID != "req-4" || records[5].RequestID != "req-9" {
+		t.Errorf("Expected records 4 to 9 after rotation, got %d records", len(records))
+	}
+	records, err = ledger.Query(Filter{Limit: 3})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(records) != 3 || records[0].RequestID != "req-7" || records[2].RequestID != "req-9" {
+		t.Errorf("Expected the newest 3 records across files, got %+v", records)
+	}
+}
+
+func TestLedgerReopensAfterFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	size := len(fmt.Sprintln(mustJSON(t, record(0, "acme", "llama-7b"))))
+	ledger, err := Open(Config{Dir: dir, MaxFileBytes: int64(size)})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer ledger.Close()
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ledger.now = func() time.Time { return clock }
+	ledger.Append(record(0, "acme", "llama-7b"))
+
+	// A directory in the way of the rotated file fails the rotation
+	blocker := filepath.Join(dir, rotatedPrefix+clock.Format("20060102T150405.000000000Z")+rotatedSuffix)
+	if err := os.MkdirAll(filepath.Join(blocker, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Append(record(1, "acme", "llama-7b")); err == nil {
+		t.Fatal("Expected the rotation to fail")
+	}
+
+	os.RemoveAll(blocker)
+	if err := ledger.Append(record(2, "acme", "llama-7b")); err != nil {
+		t.Fatalf("Expected the next append to reopen the ledger, got %v", err)
+	}
+	records, err := ledger.Query(Filter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(records) != 2 || records[0].RequestID != "req-0" || records[1].RequestID != "req-2" {
+		t.Errorf("Expected records 0 and 2, got %+v", records)
+	}
+}
+
+func TestLedgerScanDoesNotBlockAppends(t *testing.T) {
+	ledger, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer ledger.Close()
+	ledger.Append(record(0, "acme", "llama-7b"))
+
+	var scanned []string
+	err = ledger.Scan(Filter{}, func(r *Record) {
+		scanned = append(scanned, r.RequestID)
+		if err := ledger.Append(record(1, "acme", "llama-7b")); err != nil {
+			t.Errorf("Append during a scan failed: %v", err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	// Records appended during the scan are left for the next one
+	if fmt.Sprint(scanned) != "[req-0]" {
+		t.Errorf("Expected only req-0 to be scanned, got %v", scanned)
+	}
+}
+
+func TestLedgerRepairsTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	ledger, err := Open(Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	ledger.Append(record(0, "acme", "llama-7b"))
+	ledger.Close()
+
+	// A crash tears the next record
+	f, err := os.OpenFile(filepath.Join(dir, currentFile), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2026-01-01T00:00:01Z","request_id":"req-torn`)
+	f.Close()
+
+	ledger, err = Open(Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer ledger.Close()
+	ledger.Append(record(2, "acme", "llama-7b"))
+
+	records, err := ledger.Query(Filter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(records) != 2 || records[0].RequestID != "req-0" || records[1].RequestID != "req-2" {
+		t.Errorf("Expected the records around the torn one, got %+v", records)
+	}
+}
@@ -0,0 +1,144 @@
+// Package usage keeps the per-request usage ledger agents use for
+// chargeback: who ran which model, the tokens in and out, and the compute
+// time and bytes each node spent on the request.
+package usage
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultTenant is charged for requests that don't name a tenant
+const DefaultTenant = "default"
+
+// Record is the usage of one inference request, written by the agent that
+// received it
+type Record struct {
+	Time             time.Time     `json:"time"`
+	RequestID        string        `json:"request_id"`
+	Tenant           string        `json:"tenant"`
+	ModelID          string        `json:"model_id"`
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	Duration         time.Duration `json:"duration_ns"`
+	Success          bool          `json:"success"`
+	Nodes            []NodeUsage   `json:"nodes,omitempty"`
+}
+
+// NodeUsage is what one node spent on a request
+type NodeUsage struct {
+	NodeID        string        `json:"node_id"`
+	ComputeTime   time.Duration `json:"compute_ns"`
+	BytesReceived int64         `json:"bytes_received"`
+	BytesSent     int64         `json:"bytes_sent"`
+}
+
+// ComputeTime is the compute time of every node on the request
+func (r *Record) ComputeTime() time.Duration {
+	var total time.Duration
+	for _, node := range r.Nodes {
+		total += node.ComputeTime
+	}
+	return total
+}
+
+// BytesMoved is the traffic between nodes for the request, counted once
+// at the sender
+func (r *Record) BytesMoved() int64 {
+	var total int64
+	for _, node := range r.Nodes {
+		total += node.BytesSent
+	}
+	return total
+}
+
+// Filter selects ledger records. Zero fields match everything.
+type Filter struct {
+	Tenant  string
+	ModelID string
+	// Since and Until bound the record time, Until excluded
+	Since time.Time
+	Until time.Time
+	// Limit keeps only the newest records, at most MaxQueryRecords
+	Limit int
+}
+
+// Match reports whether the record passes the filter, ignoring Limit
+func (f *Filter) Match(r *Record) bool {
+	switch {
+	case f.Tenant != "" && r.Tenant != f.Tenant:
+		return false
+	case f.ModelID != "" && r.ModelID != f.ModelID:
+		return false
+	case !f.Since.IsZero() && r.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !r.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Summary totals the usage of a tenant on a model
+type Summary struct {
+	Tenant           string
+	ModelID          string
+	Requests         int64
+	Failed           int64
+	PromptTokens     int64
+	CompletionTokens int64
+	ComputeTime      time.Duration
+	BytesMoved       int64
+}
+
+// Summarize totals records by tenant and model, sorted by both
+func Summarize(records []Record) []Summary {
+	var summarizer Summarizer
+	for i := range records {
+		summarizer.Add(&records[i])
+	}
+	return summarizer.Summaries()
+}
+
+// Summarizer totals records by tenant and model as they are added, for
+// summing more records than are worth holding at once
+type Summarizer struct {
+	totals map[summaryKey]*Summary
+}
+
+type summaryKey struct{ tenant, model string }
+
+// Add counts a record in its tenant and model's summary
+func (z *Summarizer) Add(r *Record) {
+	if z.totals == nil {
+		z.totals = make(map[summaryKey]*Summary)
+	}
+	k := summaryKey{r.Tenant, r.ModelID}
+	s, ok := z.totals[k]
+	if !ok {
+		s = &Summary{Tenant: r.Tenant, ModelID: r.ModelID}
+		z.totals[k] = s
+	}
+	s.Requests++
+	if !r.Success {
+		s.Failed++
+	}
+	s.PromptTokens += int64(r.PromptTokens)
+	s.CompletionTokens += int64(r.CompletionTokens)
+	s.ComputeTime += r.ComputeTime()
+	s.BytesMoved += r.BytesMoved()
+}
+
+// Summaries returns the totals so far, sorted by tenant and model
+func (z *Summarizer) Summaries() []Summary {
+	summaries := make([]Summary, 0, len(z.totals))
+	for _, s := range z.totals {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Tenant != summaries[j].Tenant {
+			return summaries[i].Tenant < summaries[j].Tenant
+		}
+		return summaries[i].ModelID < summaries[j].ModelID
+	})
+	return summaries
+}
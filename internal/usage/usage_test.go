@@ -0,0 +1,60 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	failed := record(3, "acme", "llama-7b")
+	failed.Success = false
+	records := []Record{record(0, "globex", "llama-7b"), record(1, "acme", "llama-7b"), record(2, "acme", "mistral-7b"), failed}
+
+	summaries := Summarize(records)
+	if len(summaries) != 3 {
+		t.Fatalf("Expected 3 summaries, got %+v", summaries)
+	}
+	got := summaries[0]
+	want := Summary{
+		Tenant:           "acme",
+		ModelID:          "llama-7b",
+		Requests:         2,
+		Failed:           1,
+		PromptTokens:     20,
+		CompletionTokens: 40,
+		ComputeTime:      600 * time.Millisecond,
+		BytesMoved:       2200,
+	}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if summaries[1].ModelID != "mistral-7b" || summaries[2].Tenant != "globex" {
+		t.Errorf("Expected summaries sorted by tenant and model, got %+v", summaries)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	r := record(5, "acme", "llama-7b")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"tenant", Filter{Tenant: "acme"}, true},
+		{"other tenant", Filter{Tenant: "globex"}, false},
+		{"other model", Filter{ModelID: "mistral-7b"}, false},
+		{"since is inclusive", Filter{Since: r.Time}, true},
+		{"until is exclusive", Filter{Until: r.Time}, false},
+		{"after", Filter{Since: r.Time.Add(time.Second)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(&r); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
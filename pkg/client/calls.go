@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// APIKeyMetadataKey is the metadata key carrying Config.APIKey, from which
+// the agents tell the tenant to charge and limit requests as
+const APIKeyMetadataKey = "x-api-key"
+
+// ErrInferenceFailed is returned when an agent answers an inference request
+// with an error of its own
+var ErrInferenceFailed = errors.New("inference failed")
+
+// CommandError is returned when an agent rejects an admin command
+type CommandError struct {
+	Command  string
+	Message  string
+	ExitCode int32
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("command %s failed: %s", e.Command, e.Message)
+}
+
+// Infer runs an inference on a replica of the requested model. Inference is
+// not retried once it may have reached an agent unless Idempotent is given.
+func (c *Client) Infer(ctx context.Context, req models.InferenceRequest, opts ...grpc.CallOption) (*models.InferenceResponse, error) {
+	resp, err := c.node.ProcessInference(ctx, &pb.InferenceRequest{
+		ModelId:   req.ModelID,
+		Prompt:    req.Prompt,
+		MaxTokens: req.MaxTokens,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.InferenceResponse{
+		RequestID:     req.RequestID,
+		GeneratedText: resp.GeneratedText,
+		Success:       resp.Success,
+		Error:         resp.ErrorMessage,
+	}
+	if !resp.Success {
+		return result, fmt.Errorf("%w: %s", ErrInferenceFailed, resp.ErrorMessage)
+	}
+	return result, nil
+}
+
+// Nodes returns the cluster's nodes as seen by one of the agents
+func (c *Client) Nodes(ctx context.Context, opts ...grpc.CallOption) ([]models.Node, error) {
+	resp, err := c.tui.GetNodeList(ctx, &pb.NodeListRequest{RequesterId: c.cfg.RequesterID}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]models.Node, len(resp.Nodes))
+	for i, info := range resp.Nodes {
+		nodes[i] = models.NodeFromProto(info)
+	}
+	return nodes, nil
+}
+
+// Models returns the models registered with the cluster
+func (c *Client) Models(ctx context.Context, opts ...grpc.CallOption) ([]models.Model, error) {
+	resp, err := c.tui.GetModelList(ctx, &pb.ModelListRequest{RequesterId: c.cfg.RequesterID}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]models.Model, len(resp.Models))
+	for i, info := range resp.Models {
+		list[i] = models.ModelFromProto(info)
+	}
+	return list, nil
+}
+
+// ClusterInfo returns the cluster's size, health and replica statistics
+func (c *Client) ClusterInfo(ctx context.Context, opts ...grpc.CallOption) (*pb.ClusterInfoResponse, error) {
+	return c.discovery.GetClusterInfo(ctx, &pb.ClusterInfoRequest{RequesterId: c.cfg.RequesterID}, opts...)
+}
+
+// Version returns the build and protocol version of one of the agents
+func (c *Client) Version(ctx context.Context, opts ...grpc.CallOption) (*pb.GetVersionResponse, error) {
+	return c.node.GetVersion(ctx, &pb.GetVersionRequest{}, opts...)
+}
+
+// Command runs an admin command on one of the agents and returns its output
+func (c *Client) Command(ctx context.Context, command string, args []string, opts ...grpc.CallOption) (string, error) {
+	resp, err := c.tui.ExecuteCommand(ctx, &pb.CommandRequest{
+		RequesterId: c.cfg.RequesterID,
+		Command:     command,
+		Args:        args,
+	}, opts...)
+	if err != nil {
+		return "", err
+	}
+	if !resp.Success {
+		return resp.Output, &CommandError{Command: command, Message: resp.Error, ExitCode: resp.ExitCode}
+	}
+	return resp.Output, nil
+}
+
+// Cordon stops new models from being placed on a node
+func (c *Client) Cordon(ctx context.Context, nodeID string, opts ...grpc.CallOption) error {
+	_, err := c.Command(ctx, "cordon", []string{nodeID}, opts...)
+	return err
+}
+
+// Uncordon lets models be placed on a node again
+func (c *Client) Uncordon(ctx context.Context, nodeID string, opts ...grpc.CallOption) error {
+	_, err := c.Command(ctx, "uncordon", []string{nodeID}, opts...)
+	return err
+}
+
+// Plan places a model across the cluster, or across nodeIDs when given,
+// and returns the agent's description of the placement
+func (c *Client) Plan(ctx context.Context, modelID string, nodeIDs []string, opts ...grpc.CallOption) (string, error) {
+	args := []string{modelID}
+	if len(nodeIDs) > 0 {
+		args = append(args, strings.Join(nodeIDs, ","))
+	}
+	return c.Command(ctx, "plan", args, opts...)
+}
+
+// Scale sets the number of replicas serving a model
+func (c *Client) Scale(ctx context.Context, modelID string, replicas int, opts ...grpc.CallOption) error {
+	_, err := c.Command(ctx, "scale", []string{modelID, strconv.Itoa(replicas)}, opts...)
+	return err
+}
+
+// DumpState returns the replicated cluster state as JSON
+func (c *Client) DumpState(ctx context.Context, opts ...grpc.CallOption) ([]byte, error) {
+	output, err := c.Command(ctx, "state", []string{"dump"}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// MetricsHistory returns the metrics the agent answering recorded between
+// from and to, one sample per step. A zero from starts at the oldest
+// recorded metrics, a zero to ends now and a zero step uses the agent's
+// recording resolution.
+func (c *Client) MetricsHistory(ctx context.Context, from, to time.Time, step time.Duration, opts ...grpc.CallOption) (*pb.MetricsHistoryResponse, error) {
+	req := &pb.MetricsHistoryRequest{
+		NodeId:      c.cfg.RequesterID,
+		StepSeconds: int32(step / time.Second),
+	}
+	if !from.IsZero() {
+		req.Start = from.Unix()
+	}
+	if !to.IsZero() {
+		req.End = to.Unix()
+	}
+	return c.node.GetMetricsHistory(ctx, req, opts...)
+}
+
+// Usage returns the usage recorded by the agent answering, which covers the
+// requests that entered the cluster through it. Set req.Format to "csv" or
+// "json" to have the records exported instead.
+func (c *Client) Usage(ctx context.Context, req *pb.UsageRequest, opts ...grpc.CallOption) (*pb.UsageResponse, error) {
+	return c.node.GetUsage(ctx, req, opts...)
+}
+
+// Alerts returns the active alerts of every node in the cluster, as known
+// to the agent answering. A state of "pending" or "firing" returns only
+// the alerts in that state.
+func (c *Client) Alerts(ctx context.Context, state string, opts ...grpc.CallOption) (*pb.AlertsResponse, error) {
+	return c.node.GetAlerts(ctx, &pb.AlertsRequest{State: state}, opts...)
+}
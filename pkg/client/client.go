@@ -0,0 +1,234 @@
+// Package client is a Go SDK for distributed-llm agents. It balances calls
+// across every agent it can find, retries them as far as each call is safe
+// to repeat, hedges reads and wraps the gRPC services in typed methods.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // client-side health checking
+	"google.golang.org/grpc/resolver"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// ErrNoAgents is returned when none of the configured targets resolve
+var ErrNoAgents = errors.New("no agents to connect to")
+
+const (
+	// DefaultTimeout is the deadline for calls whose context has none
+	DefaultTimeout = 10 * time.Second
+	// DefaultRefreshInterval is how often DNS names and peers are resolved again
+	DefaultRefreshInterval = 30 * time.Second
+
+	// minRefreshInterval spaces out the refreshes gRPC asks for, which come
+	// in bursts while agents are failing
+	minRefreshInterval = time.Second
+)
+
+// serviceConfig spreads calls over all healthy agents; agents that report
+// NOT_SERVING on the overall health service (e.g. while draining) are
+// skipped
+const serviceConfig = `{
+	"loadBalancingConfig": [{"round_robin": {}}],
+	"healthCheckConfig": {"serviceName": ""}
+}`
+
+// Config configures a Client
+type Config struct {
+	// Targets are agent addresses as host:port. Hostnames are looked up
+	// again on every refresh, so a headless service name reaches every
+	// agent behind it.
+	Targets []string
+	// DiscoverPeers adds the online cluster members the agents report
+	// through GetPeers, so one seed address is enough to reach them all
+	DiscoverPeers bool
+	// RefreshInterval is how often DNS names and peers are resolved again
+	RefreshInterval time.Duration
+
+	// Timeout is the deadline for calls whose context has none; WithTimeout
+	// overrides it per call and a negative value disables it
+	Timeout time.Duration
+	// Retry controls how failed calls are retried; zero means
+	// DefaultRetryPolicy
+	Retry RetryPolicy
+	// HedgeDelay, when positive, sends another copy of a read to the next
+	// agent if the first has not answered within the delay
+	HedgeDelay time.Duration
+
+	// RequesterID identifies the client in TUIService requests
+	RequesterID string
+	// APIKey authenticates the tenant the client's requests are charged to
+	// and limited as; empty uses the agents' default tenant
+	APIKey string
+	// DialOptions are applied after the client's own, e.g. for TLS
+	DialOptions []grpc.DialOption
+}
+
+// Client talks to a cluster of agents over one load-balanced connection
+type Client struct {
+	cfg      Config
+	resolver *agentResolver
+	conn     *grpc.ClientConn
+
+	node      pb.NodeServiceClient
+	discovery pb.DiscoveryServiceClient
+	tui       pb.TUIServiceClient
+
+	refreshNow chan struct{}
+	ctx        context.Context
+	stop       context.CancelFunc
+	wg         sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+// New resolves the configured agents and connects to them. Connections are
+// made lazily by gRPC; New only fails when no agent address can be found.
+func New(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultRefreshInterval
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry = DefaultRetryPolicy
+	}
+
+	r, err := newAgentResolver(cfg.Targets)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		cfg:        cfg,
+		resolver:   r,
+		refreshNow: make(chan struct{}, 1),
+	}
+	r.ResolveNowCallback = func(resolver.ResolveNowOptions) {
+		select {
+		case c.refreshNow <- struct{}{}:
+		default:
+		}
+	}
+
+	opts := append([]grpc.DialOption{
+		grpc.WithResolvers(r),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithUnaryInterceptor(c.unaryInterceptor),
+	}, cfg.DialOptions...)
+	conn, err := grpc.NewClient(Scheme+":///agents", opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	c.conn = conn
+	c.node = pb.NewNodeServiceClient(conn)
+	c.discovery = pb.NewDiscoveryServiceClient(conn)
+	c.tui = pb.NewTUIServiceClient(conn)
+
+	c.ctx, c.stop = context.WithCancel(context.Background())
+	c.wg.Add(1)
+	go c.refreshLoop()
+	return c, nil
+}
+
+// Close stops resolving agents and closes the connection
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.stop()
+		c.wg.Wait()
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// Addresses returns the agents the client currently balances across
+func (c *Client) Addresses() []string {
+	return c.resolver.Addresses()
+}
+
+// Conn returns the underlying load-balanced connection, e.g. to build
+// clients for services the SDK does not wrap. Calls made through it get the
+// same deadlines, retries and hedging.
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// Refresh resolves the agents again at once, including their peers when
+// discovery is on
+func (c *Client) Refresh(ctx context.Context) error {
+	var discoverErr error
+	if c.cfg.DiscoverPeers {
+		peers, err := c.Peers(ctx)
+		if err != nil {
+			// Keep the peers found last time and still re-resolve DNS
+			discoverErr = fmt.Errorf("failed to discover peers: %w", err)
+		} else {
+			c.resolver.setPeers(peers)
+		}
+	}
+	if err := c.resolver.refresh(ctx); err != nil {
+		return err
+	}
+	return discoverErr
+}
+
+// refreshLoop resolves the agents every RefreshInterval and whenever gRPC
+// asks, e.g. after a connection failed
+func (c *Client) refreshLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	var last time.Time
+	refresh := func() {
+		last = time.Now()
+		ctx, cancel := context.WithTimeout(c.ctx, DefaultTimeout)
+		defer cancel()
+		// Failures keep the last known agents
+		c.Refresh(ctx)
+	}
+
+	if c.cfg.DiscoverPeers {
+		refresh()
+	}
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		case <-c.refreshNow:
+			if time.Since(last) >= minRefreshInterval {
+				refresh()
+			}
+		}
+	}
+}
+
+// Peers returns the cluster members known to one of the agents
+func (c *Client) Peers(ctx context.Context, opts ...grpc.CallOption) ([]models.Node, error) {
+	resp, err := c.node.GetPeers(ctx, &pb.GetPeersRequest{NodeId: c.cfg.RequesterID}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]models.Node, len(resp.Peers))
+	for i, info := range resp.Peers {
+		peers[i] = models.NodeFromProto(info)
+	}
+	return peers, nil
+}
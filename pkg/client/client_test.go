@@ -0,0 +1,412 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"distributed-llm/pkg/models"
+	pb "distributed-llm/proto"
+)
+
+// fakeAgent serves the agent services from canned data and counts the
+// calls it receives
+type fakeAgent struct {
+	pb.UnimplementedNodeServiceServer
+	pb.UnimplementedDiscoveryServiceServer
+	pb.UnimplementedTUIServiceServer
+
+	id    string
+	addr  string
+	calls atomic.Int32
+
+	mu       sync.Mutex
+	failures int // calls left to fail with Unavailable
+	delay    time.Duration
+	peers    []*pb.NodeInfo
+	commands []*pb.CommandRequest
+	apiKeys  []string
+	// metricStreams are the StreamMetrics requests received
+	metricStreams []*pb.StreamMetricsRequest
+	// eventStreams are the StreamEvents requests received; latestEvent is
+	// the sequence number of the last event the agent published
+	eventStreams []*pb.EventsRequest
+	latestEvent  uint64
+}
+
+// startAgent serves a fake agent on a loopback port until the test ends
+func startAgent(t *testing.T, id string) *fakeAgent {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	agent := &fakeAgent{id: id, addr: listener.Addr().String()}
+
+	server := grpc.NewServer()
+	pb.RegisterNodeServiceServer(server, agent)
+	pb.RegisterDiscoveryServiceServer(server, agent)
+	pb.RegisterTUIServiceServer(server, agent)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return agent
+}
+
+// nodeInfo describes the agent as a cluster member
+func (a *fakeAgent) nodeInfo() *pb.NodeInfo {
+	host, port, _ := net.SplitHostPort(a.addr)
+	p, _ := strconv.Atoi(port)
+	return &pb.NodeInfo{NodeId: a.id, Address: host, Port: int32(p), Status: string(models.NodeStatusOnline)}
+}
+
+// serve counts a call and applies the configured delay and failures
+func (a *fakeAgent) serve(ctx context.Context) error {
+	a.calls.Add(1)
+	a.mu.Lock()
+	delay := a.delay
+	fail := a.failures > 0
+	if fail {
+		a.failures--
+	}
+	a.mu.Unlock()
+
+	if fail {
+		return status.Error(codes.Unavailable, "agent overloaded")
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *fakeAgent) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.GetVersionResponse, error) {
+	if err := a.serve(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.GetVersionResponse{NodeId: a.id, Version: "test"}, nil
+}
+
+func (a *fakeAgent) GetPeers(ctx context.Context, req *pb.GetPeersRequest) (*pb.GetPeersResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return &pb.GetPeersResponse{Peers: append([]*pb.NodeInfo{a.nodeInfo()}, a.peers...)}, nil
+}
+
+func (a *fakeAgent) ProcessInference(ctx context.Context, req *pb.InferenceRequest) (*pb.InferenceResponse, error) {
+	if err := a.serve(ctx); err != nil {
+		return nil, err
+	}
+	if req.ModelId == "" {
+		return &pb.InferenceResponse{Success: false, ErrorMessage: "model id required"}, nil
+	}
+	return &pb.InferenceResponse{Success: true, GeneratedText: req.Prompt + " from " + a.id}, nil
+}
+
+func (a *fakeAgent) GetUsage(ctx context.Context, req *pb.UsageRequest) (*pb.UsageResponse, error) {
+	if err := a.serve(ctx); err != nil {
+		return nil, err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	a.mu.Lock()
+	a.apiKeys = append(a.apiKeys, md.Get(APIKeyMetadataKey)...)
+	a.mu.Unlock()
+	return &pb.UsageResponse{Summaries: []*pb.UsageSummary{{Tenant: req.Tenant, ModelId: "llama-7b", Requests: 3}}}, nil
+}
+
+func (a *fakeAgent) GetAlerts(ctx context.Context, req *pb.AlertsRequest) (*pb.AlertsResponse, error) {
+	if err := a.serve(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.AlertsResponse{Alerts: []*pb.Alert{{Name: "HighCPU", NodeId: a.id, State: req.State}}}, nil
+}
+
+func (a *fakeAgent) GetMetricsHistory(ctx context.Context, req *pb.MetricsHistoryRequest) (*pb.MetricsHistoryResponse, error) {
+	if err := a.serve(ctx); err != nil {
+		return nil, err
+	}
+	// Echo the range as the samples' timestamps
+	return &pb.MetricsHistoryResponse{
+		NodeId:      a.id,
+		StepSeconds: max(req.StepSeconds, 5),
+		Samples:     []*pb.MetricsUpdate{{NodeId: a.id, Timestamp: req.Start}, {NodeId: a.id, Timestamp: req.End}},
+	}, nil
+}
+
+func (a *fakeAgent) GetModelList(ctx context.Context, req *pb.ModelListRequest) (*pb.ModelListResponse, error) {
+	if err := a.serve(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.ModelListResponse{Models: []*pb.ModelInfo{{Id: "llama-7b", LayerCount: 32, Replicas: 2}}}, nil
+}
+
+func (a *fakeAgent) ExecuteCommand(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	if err := a.serve(ctx); err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.commands = append(a.commands, req)
+	a.mu.Unlock()
+	if req.Command == "plan" {
+		return &pb.CommandResponse{Success: false, Error: "no capacity", ExitCode: 1}, nil
+	}
+	return &pb.CommandResponse{Success: true, Output: req.Command + " ok"}, nil
+}
+
+func (a *fakeAgent) setDelay(delay time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.delay = delay
+}
+
+func (a *fakeAgent) failNext(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.failures = n
+}
+
+func newTestClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+	cfg.Retry.InitialBackoff = time.Millisecond
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry.MaxAttempts = 3
+	}
+	c, err := New(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClientBalancesAcrossAgents(t *testing.T) {
+	a, b := startAgent(t, "agent-a"), startAgent(t, "agent-b")
+	c := newTestClient(t, Config{Targets: []string{a.addr, b.addr}})
+
+	served := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		resp, err := c.Version(context.Background())
+		if err != nil {
+			t.Fatalf("Version failed: %v", err)
+		}
+		served[resp.NodeId]++
+	}
+	if served["agent-a"] == 0 || served["agent-b"] == 0 {
+		t.Errorf("Expected calls on both agents, got %v", served)
+	}
+}
+
+func TestClientDiscoversPeers(t *testing.T) {
+	seed, peer := startAgent(t, "seed"), startAgent(t, "peer")
+	seed.peers = []*pb.NodeInfo{
+		peer.nodeInfo(),
+		{NodeId: "gone", Address: "127.0.0.1", Port: 1, Status: string(models.NodeStatusOffline)},
+	}
+
+	c := newTestClient(t, Config{Targets: []string{seed.addr}, DiscoverPeers: true})
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	addrs := c.Addresses()
+	if len(addrs) != 2 {
+		t.Fatalf("Expected the seed and its online peer, got %v", addrs)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for peer.calls.Load() == 0 && time.Now().Before(deadline) {
+		if _, err := c.Version(context.Background()); err != nil {
+			t.Fatalf("Version failed: %v", err)
+		}
+	}
+	if peer.calls.Load() == 0 {
+		t.Error("Expected calls to reach the discovered peer")
+	}
+}
+
+func TestClientRetriesByIdempotency(t *testing.T) {
+	agent := startAgent(t, "agent")
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+	ctx := context.Background()
+
+	// Reads are retried
+	agent.failNext(2)
+	if _, err := c.Models(ctx); err != nil {
+		t.Errorf("Expected the read to succeed on the third attempt, got %v", err)
+	}
+
+	// Inference is not, unless the caller says it is safe
+	agent.failNext(1)
+	if _, err := c.Infer(ctx, models.InferenceRequest{ModelID: "llama-7b", Prompt: "hi"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected inference to fail without retries, got %v", err)
+	}
+	agent.failNext(1)
+	resp, err := c.Infer(ctx, models.InferenceRequest{ModelID: "llama-7b", Prompt: "hi"}, Idempotent())
+	if err != nil || resp.GeneratedText != "hi from agent" {
+		t.Errorf("Expected an idempotent inference to be retried, got %+v (%v)", resp, err)
+	}
+
+	// Scaling is idempotent, planning is not
+	agent.failNext(1)
+	if err := c.Scale(ctx, "llama-7b", 2); err != nil {
+		t.Errorf("Expected scale to be retried, got %v", err)
+	}
+	agent.failNext(1)
+	if _, err := c.Plan(ctx, "llama-7b", nil); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected plan to fail without retries, got %v", err)
+	}
+
+	// Attempts are bounded
+	agent.failNext(5)
+	if _, err := c.Models(ctx); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected the read to give up after 3 attempts, got %v", err)
+	}
+}
+
+func TestClientDeadlines(t *testing.T) {
+	agent := startAgent(t, "agent")
+	agent.setDelay(200 * time.Millisecond)
+	c := newTestClient(t, Config{Targets: []string{agent.addr}, Timeout: 50 * time.Millisecond})
+
+	if _, err := c.Version(context.Background()); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected the default timeout to apply, got %v", err)
+	}
+	if _, err := c.Version(context.Background(), WithTimeout(time.Second)); err != nil {
+		t.Errorf("Expected a longer per-call timeout to succeed, got %v", err)
+	}
+}
+
+func TestClientHedgesReads(t *testing.T) {
+	slow, fast := startAgent(t, "slow"), startAgent(t, "fast")
+	slow.setDelay(2 * time.Second)
+	c := newTestClient(t, Config{Targets: []string{slow.addr, fast.addr}, HedgeDelay: 20 * time.Millisecond})
+
+	for i := 0; i < 4; i++ {
+		start := time.Now()
+		resp, err := c.Version(context.Background())
+		if err != nil {
+			t.Fatalf("Version failed: %v", err)
+		}
+		if resp.NodeId != "fast" || time.Since(start) > time.Second {
+			t.Errorf("Expected the hedged read to be answered by the fast agent, got %s after %v", resp.NodeId, time.Since(start))
+		}
+	}
+
+	// Writes are never hedged
+	slow.setDelay(0)
+	before := slow.calls.Load() + fast.calls.Load()
+	if err := c.Cordon(context.Background(), "node-1"); err != nil {
+		t.Fatalf("Cordon failed: %v", err)
+	}
+	if calls := slow.calls.Load() + fast.calls.Load() - before; calls != 1 {
+		t.Errorf("Expected one cordon call, got %d", calls)
+	}
+}
+
+func TestClientTypedCalls(t *testing.T) {
+	agent := startAgent(t, "agent")
+	c := newTestClient(t, Config{Targets: []string{agent.addr}, RequesterID: "sdk-test"})
+	ctx := context.Background()
+
+	list, err := c.Models(ctx)
+	if err != nil || len(list) != 1 || list[0].ID != "llama-7b" || list[0].DesiredReplicas() != 2 {
+		t.Errorf("Unexpected models %+v (%v)", list, err)
+	}
+
+	if _, err := c.Infer(ctx, models.InferenceRequest{Prompt: "hi"}); !errors.Is(err, ErrInferenceFailed) {
+		t.Errorf("Expected ErrInferenceFailed, got %v", err)
+	}
+
+	_, err = c.Plan(ctx, "llama-7b", []string{"node-1", "node-2"})
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Message != "no capacity" || cmdErr.ExitCode != 1 {
+		t.Errorf("Expected a CommandError, got %v", err)
+	}
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	last := agent.commands[len(agent.commands)-1]
+	if last.RequesterId != "sdk-test" || last.Command != "plan" || len(last.Args) != 2 || last.Args[1] != "node-1,node-2" {
+		t.Errorf("Unexpected command request %+v", last)
+	}
+}
+
+func TestClientUsage(t *testing.T) {
+	agent := startAgent(t, "agent")
+	c := newTestClient(t, Config{Targets: []string{agent.addr}, APIKey: "acme-key"})
+
+	resp, err := c.Usage(context.Background(), &pb.UsageRequest{Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	if len(resp.Summaries) != 1 || resp.Summaries[0].Tenant != "acme" || resp.Summaries[0].Requests != 3 {
+		t.Errorf("Unexpected summaries %+v", resp.Summaries)
+	}
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if len(agent.apiKeys) != 1 || agent.apiKeys[0] != "acme-key" {
+		t.Errorf("Expected the call to present acme's API key, got %v", agent.apiKeys)
+	}
+}
+
+func TestClientMetricsHistory(t *testing.T) {
+	agent := startAgent(t, "agent")
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+
+	to := time.Unix(1_800_000_000, 0)
+	tests := []struct {
+		name       string
+		from, to   time.Time
+		step       time.Duration
+		start, end int64
+		stepSecs   int32
+	}{
+		{"whole history", time.Time{}, time.Time{}, 0, 0, 0, 5},
+		{"range and step", to.Add(-time.Hour), to, time.Minute, to.Unix() - 3600, to.Unix(), 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := c.MetricsHistory(context.Background(), tt.from, tt.to, tt.step)
+			if err != nil {
+				t.Fatalf("MetricsHistory failed: %v", err)
+			}
+			if resp.StepSeconds != tt.stepSecs || resp.Samples[0].Timestamp != tt.start || resp.Samples[1].Timestamp != tt.end {
+				t.Errorf("Expected %d..%d every %ds, got %+v", tt.start, tt.end, tt.stepSecs, resp)
+			}
+		})
+	}
+}
+
+func TestClientAlerts(t *testing.T) {
+	agent := startAgent(t, "agent")
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+
+	resp, err := c.Alerts(context.Background(), "firing")
+	if err != nil {
+		t.Fatalf("Alerts failed: %v", err)
+	}
+	if len(resp.Alerts) != 1 || resp.Alerts[0].Name != "HighCPU" || resp.Alerts[0].State != "firing" {
+		t.Errorf("Unexpected alerts %+v", resp.Alerts)
+	}
+}
+
+func TestNewWithoutAgents(t *testing.T) {
+	if _, err := New(context.Background(), Config{}); !errors.Is(err, ErrNoAgents) {
+		t.Errorf("Expected ErrNoAgents, got %v", err)
+	}
+	if _, err := New(context.Background(), Config{Targets: []string{"no-port"}}); err == nil {
+		t.Error("Expected an error for an address without a port")
+	}
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+
+	"distributed-llm/pkg/models"
+)
+
+// Scheme is the gRPC resolver scheme the client dials its agents through
+const Scheme = "distributed-llm"
+
+// agentResolver feeds gRPC the set of agents to balance across: static
+// addresses, DNS names re-resolved on every refresh and, when discovery is
+// on, the cluster members the agents report through GetPeers
+type agentResolver struct {
+	*manual.Resolver
+
+	static []string
+	names  []string
+	lookup func(ctx context.Context, host string) ([]string, error)
+
+	mu      sync.Mutex
+	peers   []string
+	current []string
+}
+
+// newAgentResolver splits targets into literal addresses, which never
+// change, and hostnames, which are looked up again on every refresh
+func newAgentResolver(targets []string) (*agentResolver, error) {
+	r := &agentResolver{
+		Resolver: manual.NewBuilderWithScheme(Scheme),
+		lookup:   net.DefaultResolver.LookupHost,
+	}
+	for _, target := range targets {
+		host, port, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent address %q: %w", target, err)
+		}
+		if _, err := strconv.Atoi(port); err != nil {
+			return nil, fmt.Errorf("invalid port in agent address %q", target)
+		}
+		if net.ParseIP(host) != nil {
+			r.static = append(r.static, target)
+		} else {
+			r.names = append(r.names, target)
+		}
+	}
+	return r, nil
+}
+
+// resolve returns every known agent address, sorted and without duplicates.
+// Names that fail to resolve are skipped; the error is only returned when
+// nothing resolves at all.
+func (r *agentResolver) resolve(ctx context.Context) ([]string, error) {
+	addrs := slices.Clone(r.static)
+
+	var lastErr error
+	for _, name := range r.names {
+		host, port, _ := net.SplitHostPort(name)
+		hosts, err := r.lookup(ctx, host)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to resolve %s: %w", host, err)
+			continue
+		}
+		for _, h := range hosts {
+			addrs = append(addrs, net.JoinHostPort(h, port))
+		}
+	}
+
+	r.mu.Lock()
+	addrs = append(addrs, r.peers...)
+	r.mu.Unlock()
+
+	slices.Sort(addrs)
+	addrs = slices.Compact(addrs)
+	if len(addrs) == 0 {
+		if lastErr == nil {
+			lastErr = ErrNoAgents
+		}
+		return nil, lastErr
+	}
+	return addrs, nil
+}
+
+// setPeers replaces the addresses learned from GetPeers with the online
+// nodes among peers
+func (r *agentResolver) setPeers(peers []models.Node) {
+	addrs := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		if peer.Status == models.NodeStatusOnline && peer.Address != "" && peer.Port > 0 {
+			addrs = append(addrs, net.JoinHostPort(peer.Address, strconv.Itoa(peer.Port)))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.peers = addrs
+}
+
+// refresh resolves the agents again and hands gRPC the new set when it
+// changed
+func (r *agentResolver) refresh(ctx context.Context) error {
+	addrs, err := r.resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	changed := !slices.Equal(addrs, r.current)
+	r.current = addrs
+	r.mu.Unlock()
+
+	if changed {
+		r.UpdateState(resolverState(addrs))
+	}
+	return nil
+}
+
+// Addresses returns the agents the client currently balances across
+func (r *agentResolver) Addresses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.current)
+}
+
+func resolverState(addrs []string) resolver.State {
+	state := resolver.State{Addresses: make([]resolver.Address, len(addrs))}
+	for i, addr := range addrs {
+		state.Addresses[i] = resolver.Address{Addr: addr}
+	}
+	return state
+}
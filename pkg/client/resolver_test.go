@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"distributed-llm/pkg/models"
+)
+
+func TestAgentResolver(t *testing.T) {
+	r, err := newAgentResolver([]string{"10.0.0.1:8080", "agents.llm.svc:8080", "10.0.0.1:8080"})
+	if err != nil {
+		t.Fatalf("newAgentResolver failed: %v", err)
+	}
+	hosts := []string{"10.0.0.2", "10.0.0.3"}
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		if host != "agents.llm.svc" {
+			t.Errorf("Unexpected lookup of %s", host)
+		}
+		return hosts, nil
+	}
+
+	ctx := context.Background()
+	if err := r.refresh(ctx); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	want := []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080"}
+	if got := r.Addresses(); !slices.Equal(got, want) {
+		t.Errorf("Addresses() = %v, want %v", got, want)
+	}
+
+	// DNS is resolved again and online peers are added
+	hosts = []string{"10.0.0.3"}
+	r.setPeers([]models.Node{
+		{ID: "node-4", Address: "10.0.0.4", Port: 8080, Status: models.NodeStatusOnline},
+		{ID: "node-5", Address: "10.0.0.5", Port: 8080, Status: models.NodeStatusOffline},
+		{ID: "node-6", Address: "10.0.0.6", Status: models.NodeStatusOnline},
+	})
+	if err := r.refresh(ctx); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	want = []string{"10.0.0.1:8080", "10.0.0.3:8080", "10.0.0.4:8080"}
+	if got := r.Addresses(); !slices.Equal(got, want) {
+		t.Errorf("Addresses() = %v, want %v", got, want)
+	}
+}
+
+func TestAgentResolver_Errors(t *testing.T) {
+	if _, err := newAgentResolver([]string{"10.0.0.1"}); err == nil {
+		t.Error("Expected an error for an address without a port")
+	}
+	if _, err := newAgentResolver([]string{"10.0.0.1:http"}); err == nil {
+		t.Error("Expected an error for a named port")
+	}
+
+	r, err := newAgentResolver([]string{"missing.llm.svc:8080"})
+	if err != nil {
+		t.Fatalf("newAgentResolver failed: %v", err)
+	}
+	lookupErr := errors.New("no such host")
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return nil, lookupErr
+	}
+	if err := r.refresh(context.Background()); !errors.Is(err, lookupErr) {
+		t.Errorf("Expected the lookup error when nothing resolves, got %v", err)
+	}
+}
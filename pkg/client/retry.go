@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "distributed-llm/proto"
+)
+
+// RetryPolicy controls how failed calls are retried
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; 1 disables retries
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; it doubles on
+	// every further retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// PerAttemptTimeout bounds each attempt so that a hung agent leaves time
+	// to retry on another; 0 lets an attempt use the whole call deadline
+	PerAttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy retries up to twice with backoff starting at 100ms
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+// backoff returns a jittered wait before the given retry (1 for the first)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < retry && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	// Full jitter in [wait/2, wait) keeps clients from retrying in lockstep
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// retryDelay is the wait before retrying after err: the backoff, or longer
+// when the agent asked for it, as agents do for a tenant over its quota
+func retryDelay(err error, backoff time.Duration) time.Duration {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return max(backoff, info.RetryDelay.AsDuration())
+		}
+	}
+	return backoff
+}
+
+// callKind says how safe a call is to send more than once
+type callKind int
+
+const (
+	// unsafeCall may change state in ways a repeat would duplicate
+	unsafeCall callKind = iota
+	// idempotentCall may change state, but repeating it has no further effect
+	idempotentCall
+	// readCall never changes state, so it may also be hedged
+	readCall
+)
+
+var methodKinds = map[string]callKind{
+	pb.NodeService_RegisterNode_FullMethodName:             idempotentCall,
+	pb.NodeService_GetResources_FullMethodName:             readCall,
+	pb.NodeService_ProcessInference_FullMethodName:         unsafeCall,
+	pb.NodeService_HealthCheck_FullMethodName:              readCall,
+	pb.NodeService_GetPeers_FullMethodName:                 readCall,
+	pb.NodeService_GetMetrics_FullMethodName:               readCall,
+	pb.NodeService_GetMetricsHistory_FullMethodName:        readCall,
+	pb.NodeService_GetVersion_FullMethodName:               readCall,
+	pb.NodeService_GetLatencyMatrix_FullMethodName:         readCall,
+	pb.NodeService_ProbeLatency_FullMethodName:             readCall,
+	pb.NodeService_GetUsage_FullMethodName:                 readCall,
+	pb.NodeService_GetAlerts_FullMethodName:                readCall,
+	pb.DiscoveryService_DiscoverNodes_FullMethodName:       readCall,
+	pb.DiscoveryService_RegisterWithCluster_FullMethodName: idempotentCall,
+	pb.DiscoveryService_LeaveCluster_FullMethodName:        idempotentCall,
+	pb.DiscoveryService_GetClusterInfo_FullMethodName:      readCall,
+	pb.TUIService_GetNodeList_FullMethodName:               readCall,
+	pb.TUIService_GetModelList_FullMethodName:              readCall,
+	pb.TUIService_ExecuteCommand_FullMethodName:            unsafeCall,
+}
+
+// commandKinds classifies the admin commands run through ExecuteCommand.
+// Cordoning and scaling set a target state, so repeats are harmless;
+// "plan" places the model afresh every time and is left out.
+var commandKinds = map[string]callKind{
+	"status":   readCall,
+	"ping":     readCall,
+	"state":    readCall,
+	"cordon":   idempotentCall,
+	"uncordon": idempotentCall,
+	"scale":    idempotentCall,
+}
+
+// classify returns how safe a call to method with req is to repeat
+func classify(method string, req any) callKind {
+	if cmd, ok := req.(*pb.CommandRequest); ok && method == pb.TUIService_ExecuteCommand_FullMethodName {
+		return commandKinds[cmd.Command]
+	}
+	return methodKinds[method]
+}
+
+// retryable reports whether a failed attempt may be repeated on another
+// agent. DeadlineExceeded only counts when the attempt's own timeout fired,
+// not the caller's.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// callOptions are the per-call settings carried by the client's
+// grpc.CallOptions
+type callOptions struct {
+	timeout    time.Duration
+	idempotent bool
+	noHedge    bool
+}
+
+type timeoutOption struct {
+	grpc.EmptyCallOption
+	timeout time.Duration
+}
+
+type idempotentOption struct{ grpc.EmptyCallOption }
+
+type noHedgeOption struct{ grpc.EmptyCallOption }
+
+// WithTimeout sets the deadline of a single call, overriding
+// Config.Timeout. It never extends a deadline already on the context.
+func WithTimeout(timeout time.Duration) grpc.CallOption {
+	return timeoutOption{timeout: timeout}
+}
+
+// Idempotent marks a call as safe to retry even though its method is not,
+// e.g. an inference whose duplicate result would simply be discarded
+func Idempotent() grpc.CallOption {
+	return idempotentOption{}
+}
+
+// WithoutHedging sends a read to one agent at a time
+func WithoutHedging() grpc.CallOption {
+	return noHedgeOption{}
+}
+
+func (c *Client) callOptions(opts []grpc.CallOption) callOptions {
+	settings := callOptions{timeout: c.cfg.Timeout}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case timeoutOption:
+			settings.timeout = o.timeout
+		case idempotentOption:
+			settings.idempotent = true
+		case noHedgeOption:
+			settings.noHedge = true
+		}
+	}
+	return settings
+}
+
+// unaryInterceptor presents the API key and applies the call deadline, then
+// retries or hedges the call as far as its kind allows
+func (c *Client) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if c.cfg.APIKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, APIKeyMetadataKey, c.cfg.APIKey)
+	}
+	settings := c.callOptions(opts)
+	if settings.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.timeout)
+		defer cancel()
+	}
+
+	kind := classify(method, req)
+	if settings.idempotent && kind == unsafeCall {
+		kind = idempotentCall
+	}
+
+	policy := c.cfg.Retry
+	attempts := max(policy.MaxAttempts, 1)
+	if kind == unsafeCall {
+		// gRPC itself still retries requests that never reached an agent
+		attempts = 1
+	}
+
+	invoke := func(ctx context.Context, reply any) error {
+		if policy.PerAttemptTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, policy.PerAttemptTimeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	if kind == readCall && c.cfg.HedgeDelay > 0 && !settings.noHedge && attempts > 1 {
+		return hedge(ctx, reply, attempts, c.cfg.HedgeDelay, invoke)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := invoke(ctx, reply)
+		if err == nil || attempt >= attempts || !retryable(ctx, err) {
+			return err
+		}
+		select {
+		case <-time.After(retryDelay(err, policy.backoff(attempt))):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// hedge sends a read, and another copy every delay until one succeeds or
+// attempts copies are in flight. The first success wins and the rest are
+// cancelled; a copy failing with a retryable error starts the next at once.
+func hedge(ctx context.Context, reply any, attempts int, delay time.Duration, invoke func(context.Context, any) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		reply proto.Message
+		err   error
+	}
+	results := make(chan result, attempts)
+	launched, pending := 0, 0
+	launch := func() {
+		r := reply.(proto.Message).ProtoReflect().New().Interface()
+		launched++
+		pending++
+		go func() {
+			results <- result{reply: r, err: invoke(ctx, r)}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var lastErr error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if launched < attempts {
+				launch()
+				timer.Reset(delay)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				proto.Merge(reply.(proto.Message), res.reply)
+				return nil
+			}
+			lastErr = res.err
+			if !retryable(ctx, res.err) {
+				return res.err
+			}
+			if pending == 0 && launched < attempts {
+				launch()
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return lastErr
+}
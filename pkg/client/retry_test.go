@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "distributed-llm/proto"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		req    any
+		want   callKind
+	}{
+		{"read", pb.NodeService_GetVersion_FullMethodName, &pb.GetVersionRequest{}, readCall},
+		{"inference", pb.NodeService_ProcessInference_FullMethodName, &pb.InferenceRequest{}, unsafeCall},
+		{"join", pb.DiscoveryService_RegisterWithCluster_FullMethodName, &pb.ClusterJoinRequest{}, idempotentCall},
+		{"status command", pb.TUIService_ExecuteCommand_FullMethodName, &pb.CommandRequest{Command: "status"}, readCall},
+		{"cordon command", pb.TUIService_ExecuteCommand_FullMethodName, &pb.CommandRequest{Command: "cordon"}, idempotentCall},
+		{"plan command", pb.TUIService_ExecuteCommand_FullMethodName, &pb.CommandRequest{Command: "plan"}, unsafeCall},
+		{"unknown method", "/proto.NodeService/Unknown", nil, unsafeCall},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classify(tt.method, tt.req); got != tt.want {
+				t.Errorf("classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := policy.backoff(tt.retry); got < tt.want/2 || got > tt.want {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", tt.retry, got, tt.want/2, tt.want)
+			}
+		}
+	}
+
+	if got := (RetryPolicy{}).backoff(1); got != 0 {
+		t.Errorf("Expected no backoff without an initial backoff, got %v", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	overQuota, _ := status.New(codes.ResourceExhausted, "over quota").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(2 * time.Second)})
+
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{"plain error", status.Error(codes.Unavailable, "down"), 100 * time.Millisecond},
+		{"agent asks to wait", overQuota.Err(), 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.err, 100*time.Millisecond); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHedge(t *testing.T) {
+	t.Run("second copy wins", func(t *testing.T) {
+		var calls atomic.Int32
+		reply := &pb.GetVersionResponse{}
+		err := hedge(context.Background(), reply, 3, 10*time.Millisecond, func(ctx context.Context, r any) error {
+			n := calls.Add(1)
+			if n == 1 {
+				<-ctx.Done() // the first copy hangs until cancelled
+				return ctx.Err()
+			}
+			r.(*pb.GetVersionResponse).NodeId = "hedged"
+			return nil
+		})
+		if err != nil || reply.NodeId != "hedged" {
+			t.Errorf("Expected the hedged copy's reply, got %+v (%v)", reply, err)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("Expected 2 copies, got %d", calls.Load())
+		}
+	})
+
+	t.Run("retryable failures start the next copy", func(t *testing.T) {
+		var calls atomic.Int32
+		reply := &pb.GetVersionResponse{}
+		err := hedge(context.Background(), reply, 3, time.Hour, func(ctx context.Context, r any) error {
+			if calls.Add(1) < 3 {
+				return status.Error(codes.Unavailable, "down")
+			}
+			r.(*pb.GetVersionResponse).NodeId = "third"
+			return nil
+		})
+		if err != nil || reply.NodeId != "third" {
+			t.Errorf("Expected the third copy's reply, got %+v (%v)", reply, err)
+		}
+	})
+
+	t.Run("other errors stop at once", func(t *testing.T) {
+		var calls atomic.Int32
+		err := hedge(context.Background(), &pb.GetVersionResponse{}, 3, time.Hour, func(ctx context.Context, r any) error {
+			calls.Add(1)
+			return status.Error(codes.InvalidArgument, "bad request")
+		})
+		if status.Code(err) != codes.InvalidArgument || calls.Load() != 1 {
+			t.Errorf("Expected InvalidArgument after 1 copy, got %v after %d", err, calls.Load())
+		}
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		var calls atomic.Int32
+		err := hedge(context.Background(), &pb.GetVersionResponse{}, 2, time.Millisecond, func(ctx context.Context, r any) error {
+			calls.Add(1)
+			return status.Error(codes.Unavailable, "down")
+		})
+		if status.Code(err) != codes.Unavailable || calls.Load() != 2 {
+			t.Errorf("Expected Unavailable after 2 copies, got %v after %d", err, calls.Load())
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "distributed-llm/proto"
+)
+
+// StreamMetrics calls fn with the metrics an agent reports every interval
+// until ctx is done or fn returns an error. When the stream breaks it is
+// opened again, possibly on another agent, after a backoff.
+func (c *Client) StreamMetrics(ctx context.Context, interval time.Duration, fn func(*pb.MetricsUpdate) error, opts ...grpc.CallOption) error {
+	return c.StreamMetricsWithHistory(ctx, interval, 0, fn, opts...)
+}
+
+// StreamMetricsWithHistory is StreamMetrics preceded by the metrics the
+// agent recorded over the last backfill, one per interval, so charts can
+// be drawn at once. A reopened stream is backfilled from the last update
+// received, and updates already received are skipped.
+func (c *Client) StreamMetricsWithHistory(ctx context.Context, interval, backfill time.Duration, fn func(*pb.MetricsUpdate) error, opts ...grpc.CallOption) error {
+	var last *pb.MetricsUpdate
+	open := func(ctx context.Context) (grpc.ServerStreamingClient[pb.MetricsUpdate], error) {
+		req := &pb.StreamMetricsRequest{
+			NodeId:          c.cfg.RequesterID,
+			IntervalSeconds: int32(interval / time.Second),
+		}
+		if backfill > 0 {
+			gap := backfill
+			if last != nil {
+				gap = min(time.Since(time.Unix(last.Timestamp, 0)), backfill)
+			}
+			req.BackfillSeconds = int32(math.Ceil(gap.Seconds()))
+		}
+		return c.node.StreamMetrics(ctx, req, opts...)
+	}
+	if backfill <= 0 {
+		return follow(ctx, c.cfg.Retry, open, fn)
+	}
+	return follow(ctx, c.cfg.Retry, open, func(update *pb.MetricsUpdate) error {
+		if last != nil && update.NodeId == last.NodeId && update.Timestamp <= last.Timestamp {
+			return nil
+		}
+		last = update
+		return fn(update)
+	})
+}
+
+// StreamUpdates calls fn with the cluster updates of the given types
+// ("nodes", "models", "metrics"; all when empty) until ctx is done or fn
+// returns an error, reopening the stream like StreamMetrics. Each stream
+// starts with a "snapshot" followed by "delta"s, so a reopened stream
+// starts over with a snapshot that replaces what was applied so far.
+func (c *Client) StreamUpdates(ctx context.Context, types []string, interval time.Duration, fn func(*pb.ClusterUpdate) error, opts ...grpc.CallOption) error {
+	req := &pb.UpdateStreamRequest{
+		RequesterId:     c.cfg.RequesterID,
+		UpdateTypes:     types,
+		IntervalSeconds: int32(interval / time.Second),
+	}
+	return follow(ctx, c.cfg.Retry, func(ctx context.Context) (grpc.ServerStreamingClient[pb.ClusterUpdate], error) {
+		return c.tui.StreamUpdates(ctx, req, opts...)
+	}, fn)
+}
+
+// StreamEvents calls fn with the events of the given types (all when empty)
+// that an agent publishes after the given sequence number, until ctx is done
+// or fn returns an error. A reopened stream resumes after the last event
+// received. Sequence numbers are per agent: once events were received, an
+// agent that cannot resume the stream, because it restarted, no longer
+// retains the events or is another agent, replays every event it retains.
+func (c *Client) StreamEvents(ctx context.Context, after uint64, types []string, fn func(*pb.ClusterEvent) error, opts ...grpc.CallOption) error {
+	open := func(ctx context.Context) (grpc.ServerStreamingClient[pb.ClusterEvent], error) {
+		return c.node.StreamEvents(ctx, &pb.EventsRequest{AfterSequence: after, Types: types}, opts...)
+	}
+	received := false
+	for {
+		err := follow(ctx, c.cfg.Retry, open, func(event *pb.ClusterEvent) error {
+			after = event.Sequence
+			received = true
+			return fn(event)
+		})
+		if status.Code(err) != codes.OutOfRange || !received || after == 0 {
+			return err
+		}
+		after = 0
+	}
+}
+
+// follow receives from the streams returned by open until ctx is done or fn
+// fails. Streams that end or fail with a retryable error are reopened; the
+// backoff grows while reopening keeps failing and resets once a message
+// arrives.
+func follow[T any](ctx context.Context, policy RetryPolicy, open func(context.Context) (grpc.ServerStreamingClient[T], error), fn func(*T) error) error {
+	failures := 0
+	for {
+		received, err := receive(ctx, open, fn)
+		var handlerErr handlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != io.EOF && !retryable(ctx, err) {
+			return err
+		}
+
+		if received {
+			failures = 0
+		}
+		failures++
+		select {
+		case <-time.After(policy.backoff(failures)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handlerError marks an error returned by a stream's handler, which ends
+// the stream for good
+type handlerError struct{ err error }
+
+func (e handlerError) Error() string { return e.err.Error() }
+
+// receive opens one stream and passes its messages to fn until the stream
+// ends, reporting whether any message arrived
+func receive[T any](ctx context.Context, open func(context.Context) (grpc.ServerStreamingClient[T], error), fn func(*T) error) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := open(ctx)
+	if err != nil {
+		return false, err
+	}
+	for received := false; ; received = true {
+		msg, err := stream.Recv()
+		if err != nil {
+			return received, err
+		}
+		if err := fn(msg); err != nil {
+			return true, handlerError{err}
+		}
+	}
+}
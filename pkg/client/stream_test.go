@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "distributed-llm/proto"
+)
+
+func (a *fakeAgent) StreamUpdates(req *pb.UpdateStreamRequest, stream grpc.ServerStreamingServer[pb.ClusterUpdate]) error {
+	if err := a.serve(stream.Context()); err != nil {
+		return err
+	}
+	// Each stream sends two updates and then breaks
+	for i := 0; i < 2; i++ {
+		if err := stream.Send(&pb.ClusterUpdate{UpdateType: "nodes", Timestamp: time.Now().UnixNano()}); err != nil {
+			return err
+		}
+	}
+	return status.Error(codes.Unavailable, "agent restarting")
+}
+
+func (a *fakeAgent) StreamMetrics(req *pb.StreamMetricsRequest, stream grpc.ServerStreamingServer[pb.MetricsUpdate]) error {
+	if err := a.serve(stream.Context()); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.metricStreams = append(a.metricStreams, req)
+	opened := int64(len(a.metricStreams))
+	a.mu.Unlock()
+
+	// Each stream sends three updates, the first repeating the last one of
+	// the previous stream, and then breaks
+	base := time.Now().Unix() - 10 + 2*(opened-1)
+	for ts := base; ts < base+3; ts++ {
+		if err := stream.Send(&pb.MetricsUpdate{NodeId: a.id, Timestamp: ts}); err != nil {
+			return err
+		}
+	}
+	return status.Error(codes.Unavailable, "agent restarting")
+}
+
+func (a *fakeAgent) StreamEvents(req *pb.EventsRequest, stream grpc.ServerStreamingServer[pb.ClusterEvent]) error {
+	if err := a.serve(stream.Context()); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.eventStreams = append(a.eventStreams, req)
+	latest := a.latestEvent
+	a.mu.Unlock()
+
+	if req.AfterSequence > latest {
+		return status.Errorf(codes.OutOfRange, "cannot resume after sequence %d", req.AfterSequence)
+	}
+	// Each stream sends up to two events and then breaks
+	for seq := req.AfterSequence + 1; seq <= min(latest, req.AfterSequence+2); seq++ {
+		if err := stream.Send(&pb.ClusterEvent{Sequence: seq, Type: "node_joined"}); err != nil {
+			return err
+		}
+	}
+	return status.Error(codes.Unavailable, "agent restarting")
+}
+
+func TestStreamMetricsBackfill(t *testing.T) {
+	agent := startAgent(t, "agent")
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := errors.New("done")
+	var timestamps []int64
+	err := c.StreamMetricsWithHistory(ctx, 5*time.Second, time.Minute, func(update *pb.MetricsUpdate) error {
+		timestamps = append(timestamps, update.Timestamp)
+		if len(timestamps) == 5 {
+			return done
+		}
+		return nil
+	})
+	if !errors.Is(err, done) {
+		t.Fatalf("Expected the handler's error, got %v", err)
+	}
+	for i := 1; i < len(timestamps); i++ {
+		if timestamps[i] != timestamps[i-1]+1 {
+			t.Errorf("Expected each update once and in order, got %v", timestamps)
+		}
+	}
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	if len(agent.metricStreams) != 2 {
+		t.Fatalf("Expected the stream to be reopened once, got %d", len(agent.metricStreams))
+	}
+	first, second := agent.metricStreams[0], agent.metricStreams[1]
+	if first.BackfillSeconds != 60 || first.IntervalSeconds != 5 {
+		t.Errorf("Expected a minute of backfill every 5s, got %+v", first)
+	}
+	// The reopened stream only asks for the updates since the last one
+	if second.BackfillSeconds <= 0 || second.BackfillSeconds > 10 {
+		t.Errorf("Expected the backfill to start at the last update, got %d", second.BackfillSeconds)
+	}
+}
+
+func TestStreamUpdatesReconnects(t *testing.T) {
+	agent := startAgent(t, "agent")
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Opening the stream fails once before it succeeds
+	agent.failNext(1)
+	done := errors.New("done")
+	received := 0
+	err := c.StreamUpdates(ctx, []string{"nodes"}, time.Second, func(update *pb.ClusterUpdate) error {
+		received++
+		if received == 5 {
+			return done
+		}
+		return nil
+	})
+
+	if !errors.Is(err, done) {
+		t.Errorf("Expected the handler's error, got %v", err)
+	}
+	if calls := agent.calls.Load(); calls != 4 {
+		t.Errorf("Expected 1 failed and 3 broken streams, got %d opened", calls)
+	}
+}
+
+func TestStreamStopsWithContext(t *testing.T) {
+	agent := startAgent(t, "agent")
+	agent.failNext(1000)
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := c.StreamUpdates(ctx, nil, time.Second, func(*pb.ClusterUpdate) error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context's error, got %v", err)
+	}
+}
+
+func TestStreamEventsResumes(t *testing.T) {
+	agent := startAgent(t, "agent")
+	agent.latestEvent = 6
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := errors.New("done")
+	var sequences []uint64
+	err := c.StreamEvents(ctx, 0, nil, func(event *pb.ClusterEvent) error {
+		sequences = append(sequences, event.Sequence)
+		if event.Sequence == 4 {
+			// The agent restarts and only published one event since
+			agent.mu.Lock()
+			agent.latestEvent = 1
+			agent.mu.Unlock()
+		}
+		if len(sequences) == 5 {
+			return done
+		}
+		return nil
+	})
+	if !errors.Is(err, done) {
+		t.Fatalf("Expected the handler's error, got %v", err)
+	}
+	if got := fmt.Sprint(sequences); got != "[1 2 3 4 1]" {
+		t.Errorf("Expected each event once, then the restarted agent's, got %s", got)
+	}
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	var resumed []uint64
+	for _, req := range agent.eventStreams {
+		resumed = append(resumed, req.AfterSequence)
+	}
+	if got := fmt.Sprint(resumed); got != "[0 2 4 0]" {
+		t.Errorf("Expected each stream to resume after the last event, got %s", got)
+	}
+}
+
+func TestStreamEventsOutOfRange(t *testing.T) {
+	agent := startAgent(t, "agent")
+	agent.latestEvent = 2
+	c := newTestClient(t, Config{Targets: []string{agent.addr}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.StreamEvents(ctx, 10, nil, func(*pb.ClusterEvent) error { return nil })
+	if status.Code(err) != codes.OutOfRange {
+		t.Errorf("Expected OutOfRange for a sequence the agent never published, got %v", err)
+	}
+}
@@ -2,11 +2,15 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 )
 
 type Config struct {
 	NodeID              string         `json:"node_id"`
+	ClusterID           string         `json:"cluster_id"`
+	JoinToken           string         `json:"join_token"`
 	Port                int            `json:"port"`
 	GossipPort          int            `json:"gossip_port"`
 	KubernetesNamespace string         `json:"kubernetes_namespace"`
@@ -14,6 +18,101 @@ type Config struct {
 	ModelPath           string         `json:"model_path"`
 	DataPath            string         `json:"data_path"`
 	LogLevel            string         `json:"log_level"`
+
+	// Labels describe where the node runs (zone, rack, host) and are
+	// gossiped to peers for placement
+	Labels      map[string]string `json:"labels"`
+	Placement   Placement         `json:"placement"`
+	Routing     Routing           `json:"routing"`
+	Activations Activations       `json:"activations"`
+	Tracing     Tracing           `json:"tracing"`
+	Usage       Usage             `json:"usage"`
+	Alerts      Alerts            `json:"alerts"`
+	// Tenants maps tenant names to their API keys and quotas. "*" applies
+	// to tenants without their own quota; tenants without either are not
+	// limited. Requests without an API key are charged to "default".
+	Tenants map[string]Tenant `json:"tenants"`
+}
+
+// Placement holds the topology constraints for planning model pipelines
+type Placement struct {
+	SameZone    bool `json:"same_zone"`
+	SpreadZones bool `json:"spread_zones"`
+	SpreadHosts bool `json:"spread_hosts"`
+}
+
+// Routing configures how inference requests are spread across model replicas
+type Routing struct {
+	// Policy is least-outstanding or p2c
+	Policy    string    `json:"policy"`
+	Autoscale Autoscale `json:"autoscale"`
+}
+
+// Autoscale sizes each model's replica set by its queue depth
+type Autoscale struct {
+	Enabled               bool    `json:"enabled"`
+	MinReplicas           int     `json:"min_replicas"`
+	MaxReplicas           int     `json:"max_replicas"`
+	TargetQueueDepth      float64 `json:"target_queue_depth"`
+	ScaleDownDelaySeconds int     `json:"scale_down_delay_seconds"`
+}
+
+// Activations configures how hidden states are shipped between pipeline stages
+type Activations struct {
+	// Compression is none, lz4, zstd or gzip; stages that don't support it
+	// receive activations uncompressed
+	Compression string `json:"compression"`
+	// Quantization is none, fp16 or int8
+	Quantization string `json:"quantization"`
+}
+
+// Tracing configures OpenTelemetry trace export
+type Tracing struct {
+	// Endpoint is the OTLP/gRPC collector address (host:port); spans are
+	// not exported when it is empty
+	Endpoint string `json:"endpoint"`
+	Insecure bool   `json:"insecure"`
+	// SampleRatio is the fraction of new traces recorded
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// Usage configures the ledger of requests entering the cluster through the
+// node, kept under DataPath/usage
+type Usage struct {
+	// MaxFileMB is the size at which the ledger file is rotated
+	MaxFileMB int `json:"max_file_mb"`
+	// MaxFiles is the number of rotated files kept
+	MaxFiles int `json:"max_files"`
+}
+
+// Alerts configures the rules each node evaluates against its own metrics
+type Alerts struct {
+	Rules []AlertRule `json:"rules"`
+	// WebhookURL receives a POST of the alerts that start firing or resolve
+	WebhookURL string `json:"webhook_url"`
+}
+
+// AlertRule raises an alert while a metric crosses a threshold
+type AlertRule struct {
+	Name string `json:"name"`
+	// Expr compares a metric to a number, e.g. "cpu_usage_percent > 90"
+	Expr string `json:"expr"`
+	// ForSeconds is how long the condition must hold before the alert fires
+	ForSeconds int    `json:"for_seconds"`
+	Severity   string `json:"severity"`
+	Summary    string `json:"summary"`
+}
+
+// Tenant authenticates a tenant and limits what its requests may consume
+// across the cluster; zero leaves a limit off
+type Tenant struct {
+	// APIKeys authenticate the tenant's clients, sent in x-api-key metadata
+	APIKeys           []string `json:"api_keys"`
+	RequestsPerSecond float64  `json:"requests_per_second"`
+	TokensPerMinute   int64    `json:"tokens_per_minute"`
+	MaxConcurrent     int      `json:"max_concurrent"`
+	// Models lists the models the tenant may use; empty allows every model
+	Models []string `json:"models"`
 }
 
 type ResourceLimits struct {
@@ -43,6 +142,7 @@ func LoadConfig(filePath string) (*Config, error) {
 func Default() *Config {
 	return &Config{
 		NodeID:              "default-node",
+		ClusterID:           "distributed-llm-cluster",
 		Port:                8080,
 		GossipPort:          7946,
 		KubernetesNamespace: "default",
@@ -54,5 +154,56 @@ func Default() *Config {
 		ModelPath: "/models",
 		DataPath:  "/data",
 		LogLevel:  "info",
+		Placement: Placement{
+			SameZone:    true,
+			SpreadZones: true,
+			SpreadHosts: true,
+		},
+		Routing: Routing{
+			Policy: "least-outstanding",
+			Autoscale: Autoscale{
+				MinReplicas:           1,
+				MaxReplicas:           4,
+				TargetQueueDepth:      8,
+				ScaleDownDelaySeconds: 300,
+			},
+		},
+		Activations: Activations{
+			Compression:  "none",
+			Quantization: "none",
+		},
+		Tracing: Tracing{
+			SampleRatio: 1,
+		},
+		Usage: Usage{
+			MaxFileMB: 64,
+			MaxFiles:  10,
+		},
+		Alerts: Alerts{
+			Rules: []AlertRule{
+				{Name: "NodeHighCPUUsage", Expr: "cpu_usage_percent > 90", ForSeconds: 300, Severity: "warning", Summary: "High CPU usage on node"},
+				{Name: "NodeHighMemoryUsage", Expr: "memory_used_percent > 85", ForSeconds: 300, Severity: "warning", Summary: "High memory usage on node"},
+				{Name: "InferenceErrorRate", Expr: "error_ratio > 0.05", ForSeconds: 120, Severity: "warning", Summary: "High inference error rate"},
+				{Name: "InferenceBacklog", Expr: "requests_active > 10", ForSeconds: 300, Severity: "warning", Summary: "Inference requests queueing"},
+			},
+		},
+	}
+}
+
+// ParseLabels parses comma-separated key=value pairs
+func ParseLabels(value string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", pair)
+		}
+		labels[key] = strings.TrimSpace(val)
 	}
+	return labels, nil
 }
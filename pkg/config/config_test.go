@@ -1,6 +1,7 @@
 package config
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -16,6 +17,10 @@ func TestDefault(t *testing.T) {
 		t.Errorf("Expected NodeID to be 'default-node', got %s", cfg.NodeID)
 	}
 
+	if cfg.ClusterID != "distributed-llm-cluster" {
+		t.Errorf("Expected default ClusterID to be 'distributed-llm-cluster', got %s", cfg.ClusterID)
+	}
+
 	if cfg.Port != 8080 {
 		t.Errorf("Expected default Port to be 8080, got %d", cfg.Port)
 	}
@@ -52,6 +57,27 @@ func TestDefault(t *testing.T) {
 	if cfg.ResourceLimits.GPU != "1" {
 		t.Errorf("Expected default GPU limit to be '1', got %s", cfg.ResourceLimits.GPU)
 	}
+
+	if !cfg.Placement.SameZone || !cfg.Placement.SpreadZones || !cfg.Placement.SpreadHosts {
+		t.Errorf("Expected every placement constraint on by default, got %+v", cfg.Placement)
+	}
+
+	if cfg.Routing.Policy != "least-outstanding" || cfg.Routing.Autoscale.Enabled {
+		t.Errorf("Expected least-outstanding routing without autoscaling by default, got %+v", cfg.Routing)
+	}
+
+	if cfg.Activations.Compression != "none" || cfg.Activations.Quantization != "none" {
+		t.Errorf("Expected uncompressed fp32 activations by default, got %+v", cfg.Activations)
+	}
+	if cfg.Tracing.Endpoint != "" || cfg.Tracing.SampleRatio != 1 {
+		t.Errorf("Expected tracing off, sampling every trace once enabled, by default, got %+v", cfg.Tracing)
+	}
+	if cfg.Usage.MaxFileMB != 64 || cfg.Usage.MaxFiles != 10 {
+		t.Errorf("Expected 10 rotated usage files of 64MB by default, got %+v", cfg.Usage)
+	}
+	if len(cfg.Alerts.Rules) == 0 || cfg.Alerts.WebhookURL != "" {
+		t.Errorf("Expected alert rules without a webhook by default, got %+v", cfg.Alerts)
+	}
 }
 
 func TestLoadConfig(t *testing.T) {
@@ -84,3 +110,29 @@ func TestResourceLimits(t *testing.T) {
 		t.Errorf("Expected GPU '2', got %s", limits.GPU)
 	}
 }
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"", map[string]string{}, false},
+		{"zone=eu-1a, rack=r1", map[string]string{"zone": "eu-1a", "rack": "r1"}, false},
+		{"gpu=", map[string]string{"gpu": ""}, false},
+		{"zone", nil, true},
+		{"=value", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			labels, err := ParseLabels(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLabels(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(labels, tt.want) {
+				t.Errorf("ParseLabels(%q) = %v, want %v", tt.value, labels, tt.want)
+			}
+		})
+	}
+}
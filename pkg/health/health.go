@@ -0,0 +1,313 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind says which probe a check belongs to
+type Kind string
+
+const (
+	// Liveness checks fail when the process should be restarted
+	Liveness Kind = "livez"
+	// Readiness checks fail when the node should not receive traffic
+	Readiness Kind = "readyz"
+)
+
+// DefaultCheckTimeout bounds how long a single check may run
+const DefaultCheckTimeout = 2 * time.Second
+
+// ErrDraining is reported by the readiness probe once Drain has been called
+var ErrDraining = errors.New("node is draining")
+
+// ErrStarting is reported by readiness checks declared with Pending until
+// the subsystem registers the real check
+var ErrStarting = errors.New("not started yet")
+
+// Checker reports an error when the thing it checks is unhealthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name     string
+	Kind     Kind
+	Err      error
+	Duration time.Duration
+}
+
+// Passed returns true if the check succeeded
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+type registration struct {
+	name    string
+	kind    Kind
+	checker Checker
+}
+
+// Registry holds the liveness and readiness checks of a process. Subsystems
+// register their own checks; the registry serves them over HTTP.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []registration
+	timeout  time.Duration
+	draining bool
+	observer func(Result)
+}
+
+// NewRegistry creates a registry with a built-in readiness check that fails
+// once the node starts draining
+func NewRegistry() *Registry {
+	r := &Registry{timeout: DefaultCheckTimeout}
+	r.Register("draining", Readiness, CheckerFunc(func(ctx context.Context) error {
+		if r.Draining() {
+			return ErrDraining
+		}
+		return nil
+	}))
+	return r
+}
+
+// Register adds a check. Registering a name twice for the same kind replaces
+// the earlier check.
+func (r *Registry) Register(name string, kind Kind, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, reg := range r.checks {
+		if reg.name == name && reg.kind == kind {
+			r.checks[i].checker = checker
+			return
+		}
+	}
+	r.checks = append(r.checks, registration{name: name, kind: kind, checker: checker})
+}
+
+// Pending declares readiness checks that fail with ErrStarting until
+// registered for real, so that the node isn't ready while the subsystems
+// they belong to are still starting
+func (r *Registry) Pending(names ...string) {
+	for _, name := range names {
+		r.Register(name, Readiness, CheckerFunc(func(ctx context.Context) error {
+			return ErrStarting
+		}))
+	}
+}
+
+// Heartbeat registers a liveness check for an event loop. The loop must call
+// Beat at least once every maxAge.
+func (r *Registry) Heartbeat(name string, maxAge time.Duration) *Heartbeat {
+	heartbeat := NewHeartbeat(name, maxAge)
+	r.Register(name, Liveness, heartbeat)
+	return heartbeat
+}
+
+// SetTimeout changes how long each check may run
+func (r *Registry) SetTimeout(timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout = timeout
+}
+
+// SetObserver sets a function called with every check result, e.g. to record metrics
+func (r *Registry) SetObserver(observer func(Result)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observer = observer
+}
+
+// Drain makes the readiness probe fail so traffic moves elsewhere before shutdown
+func (r *Registry) Drain() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draining = true
+}
+
+// Draining returns true once Drain has been called
+func (r *Registry) Draining() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.draining
+}
+
+// Run executes the checks of the given kinds concurrently and returns the
+// results sorted by kind and name. With no kinds every check runs.
+func (r *Registry) Run(ctx context.Context, kinds ...Kind) []Result {
+	r.mu.RLock()
+	timeout := r.timeout
+	observer := r.observer
+	var selected []registration
+	for _, reg := range r.checks {
+		if len(kinds) == 0 || containsKind(kinds, reg.kind) {
+			selected = append(selected, reg)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(selected))
+	var wg sync.WaitGroup
+	for i, reg := range selected {
+		wg.Add(1)
+		go func(i int, reg registration) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, reg, timeout)
+		}(i, reg)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	if observer != nil {
+		for _, result := range results {
+			observer(result)
+		}
+	}
+	return results
+}
+
+// runCheck runs one check with a timeout, treating a panic as a failure
+func runCheck(ctx context.Context, reg registration, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := Result{Name: reg.name, Kind: reg.kind}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- reg.checker.Check(ctx)
+	}()
+
+	select {
+	case result.Err = <-done:
+	case <-ctx.Done():
+		result.Err = fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Healthy returns true if every result passed
+func Healthy(results []Result) bool {
+	for _, result := range results {
+		if !result.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler serves the checks of the given kinds. It answers 200 "OK" when all
+// pass and 503 with the failed checks otherwise. With verbose set, or the
+// ?verbose query parameter, every check result is listed.
+func (r *Registry) Handler(verbose bool, kinds ...Kind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		results := r.Run(req.Context(), kinds...)
+		healthy := Healthy(results)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		_, listAll := req.URL.Query()["verbose"]
+		if healthy && !verbose && !listAll {
+			w.Write([]byte("OK"))
+			return
+		}
+		w.Write([]byte(formatResults(results, healthy)))
+	})
+}
+
+// RegisterHandlers serves /livez, /readyz and /healthz/verbose on mux
+func (r *Registry) RegisterHandlers(mux *http.ServeMux) {
+	mux.Handle("/livez", r.Handler(false, Liveness))
+	mux.Handle("/readyz", r.Handler(false, Readiness))
+	mux.Handle("/healthz/verbose", r.Handler(true))
+}
+
+// formatResults lists each check as "[+]kind:name ok" or "[-]kind:name failed: reason"
+func formatResults(results []Result, healthy bool) string {
+	var b strings.Builder
+	for _, result := range results {
+		if result.Passed() {
+			fmt.Fprintf(&b, "[+]%s:%s ok\n", result.Kind, result.Name)
+		} else {
+			fmt.Fprintf(&b, "[-]%s:%s failed: %v\n", result.Kind, result.Name, result.Err)
+		}
+	}
+	if healthy {
+		b.WriteString("health check passed\n")
+	} else {
+		b.WriteString("health check failed\n")
+	}
+	return b.String()
+}
+
+// Heartbeat is a liveness check for a periodic loop
+type Heartbeat struct {
+	mu     sync.Mutex
+	name   string
+	maxAge time.Duration
+	last   time.Time
+}
+
+// NewHeartbeat creates a heartbeat that counts as fresh until maxAge has passed
+func NewHeartbeat(name string, maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{name: name, maxAge: maxAge, last: time.Now()}
+}
+
+// Beat records that the loop is still running
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = time.Now()
+}
+
+// Check fails if the loop has not beaten within maxAge
+func (h *Heartbeat) Check(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if age := time.Since(h.last); age > h.maxAge {
+		return fmt.Errorf("%s last ticked %s ago", h.name, age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func passing() Checker { return CheckerFunc(func(ctx context.Context) error { return nil }) }
+
+func failing(msg string) Checker {
+	return CheckerFunc(func(ctx context.Context) error { return errors.New(msg) })
+}
+
+func get(t *testing.T, handler http.Handler, target string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	body, err := io.ReadAll(rec.Result().Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return rec.Code, string(body)
+}
+
+func TestRegistryRun(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("loop", Liveness, passing())
+	registry.Register("grpc", Readiness, failing("not listening"))
+	registry.Register("grpc", Readiness, passing()) // replaces the failing check
+
+	results := registry.Run(context.Background(), Readiness)
+	if len(results) != 2 {
+		t.Fatalf("Expected draining and grpc readiness checks, got %+v", results)
+	}
+	if results[0].Name != "draining" || results[1].Name != "grpc" {
+		t.Errorf("Expected results sorted by name, got %+v", results)
+	}
+	if !Healthy(results) {
+		t.Errorf("Expected readiness to pass, got %+v", results)
+	}
+
+	if all := registry.Run(context.Background()); len(all) != 3 {
+		t.Errorf("Expected every check without a kind filter, got %d", len(all))
+	}
+
+	registry.Drain()
+	if Healthy(registry.Run(context.Background(), Readiness)) {
+		t.Error("Expected readiness to fail while draining")
+	}
+	if !Healthy(registry.Run(context.Background(), Liveness)) {
+		t.Error("Expected liveness to be unaffected by draining")
+	}
+}
+
+func TestRegistryTimeoutAndPanic(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetTimeout(20 * time.Millisecond)
+	registry.Register("slow", Liveness, CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}))
+	registry.Register("broken", Liveness, CheckerFunc(func(ctx context.Context) error {
+		panic("boom")
+	}))
+
+	var observed []Result
+	registry.SetObserver(func(r Result) { observed = append(observed, r) })
+
+	results := registry.Run(context.Background(), Liveness)
+	for _, result := range results {
+		if result.Passed() {
+			t.Errorf("Expected %s to fail", result.Name)
+		}
+	}
+	if !strings.Contains(results[0].Err.Error(), "panicked") {
+		t.Errorf("Expected panic to be reported, got %v", results[0].Err)
+	}
+	if !strings.Contains(results[1].Err.Error(), "timed out") {
+		t.Errorf("Expected timeout to be reported, got %v", results[1].Err)
+	}
+	if len(observed) != 2 {
+		t.Errorf("Expected observer to see 2 results, got %d", len(observed))
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	heartbeat := NewHeartbeat("loop", 30*time.Millisecond)
+	if err := heartbeat.Check(context.Background()); err != nil {
+		t.Errorf("Expected fresh heartbeat to pass, got %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := heartbeat.Check(context.Background()); err == nil {
+		t.Error("Expected stale heartbeat to fail")
+	}
+
+	heartbeat.Beat()
+	if err := heartbeat.Check(context.Background()); err != nil {
+		t.Errorf("Expected heartbeat to pass after Beat, got %v", err)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("loop", Liveness, passing())
+	registry.Register("models", Readiness, failing("no assigned model is loaded"))
+
+	mux := http.NewServeMux()
+	registry.RegisterHandlers(mux)
+
+	tests := []struct {
+		name     string
+		target   string
+		code     int
+		contains []string
+		excludes []string
+	}{
+		{"livez passes", "/livez", http.StatusOK, []string{"OK"}, []string{"models"}},
+		{"livez verbose", "/livez?verbose", http.StatusOK, []string{"[+]livez:loop ok", "health check passed"}, []string{"models"}},
+		{"readyz fails", "/readyz", http.StatusServiceUnavailable, []string{"[-]readyz:models failed: no assigned model is loaded", "[+]readyz:draining ok"}, []string{"livez:loop"}},
+		{"verbose lists all", "/healthz/verbose", http.StatusServiceUnavailable, []string{"[+]livez:loop ok", "[-]readyz:models failed", "health check failed"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := get(t, mux, tt.target)
+			if code != tt.code {
+				t.Errorf("Expected status %d, got %d: %s", tt.code, code, body)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("Expected body to contain %q, got:\n%s", s, body)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(body, s) {
+					t.Errorf("Expected body not to contain %q, got:\n%s", s, body)
+				}
+			}
+		})
+	}
+}
+
+func TestPending(t *testing.T) {
+	registry := NewRegistry()
+	registry.Pending("models", "grpc")
+
+	results := registry.Run(context.Background(), Readiness)
+	if Healthy(results) {
+		t.Fatal("Expected pending checks to fail readiness")
+	}
+	for _, result := range results {
+		if result.Name != "draining" && !errors.Is(result.Err, ErrStarting) {
+			t.Errorf("Expected %s to fail with ErrStarting, got %v", result.Name, result.Err)
+		}
+	}
+
+	// Registering the real checks replaces the pending ones
+	registry.Register("models", Readiness, passing())
+	registry.Register("grpc", Readiness, passing())
+	if results := registry.Run(context.Background(), Readiness); len(results) != 3 || !Healthy(results) {
+		t.Errorf("Expected the registered checks to pass, got %+v", results)
+	}
+}
@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+// deploymentsDir holds the Prometheus, alerting, autoscaling and Grafana
+// configs shipped with the agent
+const deploymentsDir = "../../deployments"
+
+// queryKeys are the config keys holding PromQL: alert rules and Grafana
+// targets use expr, KEDA triggers use query
+var queryKeys = map[string]bool{"expr": true, "query": true}
+
+// externalMetric reports whether a name is exported by something other
+// than the agent
+func externalMetric(name string) bool {
+	return name == "up" || strings.HasPrefix(name, "prometheus_")
+}
+
+// promqlWords are the PromQL aggregations, operators and modifiers that
+// can stand where a metric name could
+var promqlWords = map[string]bool{
+	"sum": true, "avg": true, "min": true, "max": true, "count": true, "group": true,
+	"stddev": true, "stdvar": true, "topk": true, "bottomk": true, "quantile": true,
+	"count_values": true, "and": true, "or": true, "unless": true, "bool": true,
+	"offset": true, "by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true, "inf": true, "nan": true,
+}
+
+var fqNamePattern = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+// exportedNames lists the series names a collector exports
+func exportedNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, c := range newCollectorMetrics("test-node").collectors() {
+		suffixes := []string{""}
+		switch c.(type) {
+		case prometheus.Histogram, *prometheus.HistogramVec:
+			suffixes = []string{"_bucket", "_sum", "_count"}
+		}
+
+		descs := make(chan *prometheus.Desc)
+		go func() {
+			c.Describe(descs)
+			close(descs)
+		}()
+		for desc := range descs {
+			if match := fqNamePattern.FindStringSubmatch(desc.String()); match != nil {
+				for _, suffix := range suffixes {
+					names[match[1]+suffix] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+// metricNames returns the metric names a PromQL expression selects
+func metricNames(expr string) []string {
+	var names []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return names
+			}
+			i += end + 2
+		case c == '{' || c == '[':
+			closing := byte('}')
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(expr[i:], closing)
+			if end < 0 {
+				return names
+			}
+			i += end + 1
+		case isIdentStart(c):
+			start := i
+			for i < len(expr) && (isIdentStart(expr[i]) || expr[i] >= '0' && expr[i] <= '9') {
+				i++
+			}
+			word := expr[start:i]
+			rest := strings.TrimLeft(expr[i:], " \t\n")
+			switch {
+			case word == "by" || word == "without" || word == "on" || word == "ignoring" ||
+				word == "group_left" || word == "group_right":
+				// skip the label list
+				if strings.HasPrefix(rest, "(") {
+					i = len(expr) - len(rest) + strings.IndexByte(rest, ')') + 1
+				}
+			case promqlWords[strings.ToLower(word)], strings.HasPrefix(rest, "("):
+				// an aggregation, operator or function call
+			default:
+				names = append(names, word)
+			}
+		case c >= '0' && c <= '9' || c == '.':
+			for i < len(expr) && (isIdentStart(expr[i]) || expr[i] >= '0' && expr[i] <= '9' || expr[i] == '.') {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return names
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':'
+}
+
+// collectQueries appends the PromQL found under queryKeys in a decoded
+// YAML or JSON document
+func collectQueries(doc any, queries []string) []string {
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && queryKeys[key] {
+				queries = append(queries, s)
+				continue
+			}
+			queries = collectQueries(value, queries)
+		}
+	case []any:
+		for _, value := range v {
+			queries = collectQueries(value, queries)
+		}
+	}
+	return queries
+}
+
+// deploymentQueries returns the PromQL in each config under deployments/
+func deploymentQueries(t *testing.T) map[string][]string {
+	t.Helper()
+	queries := make(map[string][]string)
+	err := filepath.WalkDir(deploymentsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		switch filepath.Ext(path) {
+		case ".json":
+			var doc any
+			if err := json.NewDecoder(f).Decode(&doc); err != nil {
+				return err
+			}
+			queries[path] = collectQueries(doc, nil)
+		case ".yaml", ".yml":
+			decoder := yaml.NewDecoder(f)
+			for {
+				var doc any
+				if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					return err
+				}
+				queries[path] = collectQueries(doc, queries[path])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", deploymentsDir, err)
+	}
+	return queries
+}
+
+func TestMetricNames(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`avg(distributed_llm_node_cpu_usage_percent{job="agent"})`, []string{"distributed_llm_node_cpu_usage_percent"}},
+		{`a_bytes / b_bytes * 100 > 85`, []string{"a_bytes", "b_bytes"}},
+		{`histogram_quantile(0.95, sum by (le, model_id) (rate(x_bucket[5m]))) > 1.0`, []string{"x_bucket"}},
+		{`capacity - ignoring(model_id) allocated{model_id="total"} == 0`, []string{"capacity", "allocated"}},
+		{`up{job="a b"} == 0`, []string{"up"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := metricNames(tt.expr); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDeploymentMetricsAreExported(t *testing.T) {
+	exported := exportedNames()
+	queries := deploymentQueries(t)
+	if len(queries) == 0 {
+		t.Fatal("Expected configs under deployments/")
+	}
+
+	checked := 0
+	for path, exprs := range queries {
+		for _, expr := range exprs {
+			for _, name := range metricNames(expr) {
+				checked++
+				if !exported[name] && !externalMetric(name) {
+					t.Errorf("%s: %s is not exported by the agent (in %q)", path, name, expr)
+				}
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("Expected the configs to reference metrics")
+	}
+
+	if t.Failed() {
+		var names []string
+		for name := range exported {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t.Logf("Exported metrics: %s", strings.Join(names, ", "))
+	}
+}
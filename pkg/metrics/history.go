@@ -0,0 +1,214 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Snapshot is the value of a node's key metrics at one time. Counters are
+// totals since the agent started; rates and averages cover the interval
+// since the previous snapshot.
+type Snapshot struct {
+	Time   time.Time
+	Uptime time.Duration
+
+	CPUUsagePercent  float64
+	MemoryUsedBytes  uint64
+	MemoryTotalBytes uint64
+	LayersAllocated  int
+	LayersCapacity   int
+
+	Connections      int
+	MessagesSent     uint64
+	MessagesReceived uint64
+	// PeerRTT is the average smoothed round trip time to the peers
+	PeerRTT time.Duration
+
+	RequestsTotal   uint64
+	RequestsActive  int
+	ErrorsTotal     uint64
+	TokensGenerated uint64
+	TokensPerSecond float64
+	// AvgLatency is the mean latency of the requests finished in the interval
+	AvgLatency time.Duration
+
+	Goroutines     int
+	HeapAllocBytes uint64
+	GCCycles       uint32
+}
+
+// History is a ring buffer of the snapshots taken over a bounded period
+type History struct {
+	resolution time.Duration
+
+	mu      sync.RWMutex
+	samples []Snapshot
+	// next is where the next snapshot goes; count is how many are kept
+	next  int
+	count int
+}
+
+// NewHistory keeps the snapshots taken every resolution over retention
+func NewHistory(retention, resolution time.Duration) *History {
+	size := max(int(retention/resolution), 1)
+	return &History{
+		resolution: resolution,
+		samples:    make([]Snapshot, size),
+	}
+}
+
+// Resolution returns how often snapshots are taken
+func (h *History) Resolution() time.Duration {
+	return h.resolution
+}
+
+// Add records a snapshot, dropping the oldest once the history is full
+func (h *History) Add(s Snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	h.count = min(h.count+1, len(h.samples))
+}
+
+// Latest returns the newest snapshot
+func (h *History) Latest() (Snapshot, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.count == 0 {
+		return Snapshot{}, false
+	}
+	return h.samples[(h.next-1+len(h.samples))%len(h.samples)], true
+}
+
+// Range returns the snapshots taken between from and to, oldest first. A
+// step above the resolution keeps the newest snapshot of each step,
+// counted from from.
+func (h *History) Range(from, to time.Time, step time.Duration) []Snapshot {
+	step = max(step, h.resolution)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var samples []Snapshot
+	lastBucket := int64(-1)
+	for i := 0; i < h.count; i++ {
+		s := h.samples[(h.next-h.count+i+len(h.samples))%len(h.samples)]
+		if s.Time.Before(from) || s.Time.After(to) {
+			continue
+		}
+		bucket := int64(s.Time.Sub(from) / step)
+		if bucket == lastBucket {
+			samples[len(samples)-1] = s
+			continue
+		}
+		samples = append(samples, s)
+		lastBucket = bucket
+	}
+	return samples
+}
+
+// Snapshots are taken every systemMetricsInterval and kept for
+// historyRetention
+const historyRetention = time.Hour
+
+// History returns the snapshots of the node's key metrics
+func (mc *MetricsCollector) History() *History {
+	return mc.history
+}
+
+// snapshot reads the key metrics from the collector's registry, so the
+// history agrees with what /metrics serves
+func (mc *MetricsCollector) snapshot(now time.Time, memStats *runtime.MemStats) Snapshot {
+	families := make(map[string]*dto.MetricFamily)
+	gathered, err := mc.registry.Gather()
+	if err != nil {
+		mc.logger.Warn("Failed to gather metrics for the history", "error", err)
+	}
+	for _, family := range gathered {
+		families[family.GetName()] = family
+	}
+	value := func(name string, labels ...string) float64 {
+		total, _ := sumSeries(families[name], labels...)
+		return total
+	}
+
+	s := Snapshot{
+		Time:             now,
+		Uptime:           now.Sub(mc.startTime),
+		CPUUsagePercent:  value("distributed_llm_node_cpu_usage_percent"),
+		MemoryUsedBytes:  uint64(value("distributed_llm_node_memory_used_bytes")),
+		MemoryTotalBytes: uint64(value("distributed_llm_node_memory_total_bytes")),
+		LayersAllocated:  int(value("distributed_llm_layers_allocated", "model_id", "total")),
+		LayersCapacity:   int(value("distributed_llm_layers_capacity")),
+		Connections:      int(value("distributed_llm_network_connections")),
+		MessagesSent:     uint64(value("distributed_llm_network_messages_total", "direction", "outgoing")),
+		MessagesReceived: uint64(value("distributed_llm_network_messages_total", "direction", "incoming")),
+		RequestsTotal:    uint64(value("distributed_llm_inference_requests_total")),
+		RequestsActive:   int(value("distributed_llm_inference_requests_active")),
+		ErrorsTotal:      uint64(value("distributed_llm_inference_requests_total", "status", "error")),
+		TokensGenerated:  uint64(value("distributed_llm_inference_tokens_generated_total")),
+		TokensPerSecond:  value("distributed_llm_inference_tokens_per_second"),
+		Goroutines:       runtime.NumGoroutine(),
+		HeapAllocBytes:   memStats.HeapAlloc,
+		GCCycles:         memStats.NumGC,
+	}
+	if rtt, peers := sumSeries(families["distributed_llm_network_rtt_seconds"]); peers > 0 {
+		s.PeerRTT = time.Duration(rtt / float64(peers) * float64(time.Second))
+	}
+
+	// Average the latency of the requests finished since the last snapshot
+	latency, requests := histogramTotals(families["distributed_llm_inference_latency_seconds"])
+	if requests > mc.lastLatencyCount {
+		average := (latency - mc.lastLatencySum) / float64(requests-mc.lastLatencyCount)
+		s.AvgLatency = time.Duration(average * float64(time.Second))
+	}
+	mc.lastLatencySum, mc.lastLatencyCount = latency, requests
+	return s
+}
+
+// sumSeries adds up the counter and gauge series of a family that have
+// the given label name/value pairs, returning the sum and series count
+func sumSeries(family *dto.MetricFamily, labels ...string) (float64, int) {
+	total, count := 0.0, 0
+series:
+	for _, metric := range family.GetMetric() {
+		for i := 0; i+1 < len(labels); i += 2 {
+			if labelValue(metric, labels[i]) != labels[i+1] {
+				continue series
+			}
+		}
+		switch {
+		case metric.Counter != nil:
+			total += metric.GetCounter().GetValue()
+		case metric.Gauge != nil:
+			total += metric.GetGauge().GetValue()
+		default:
+			continue
+		}
+		count++
+	}
+	return total, count
+}
+
+// histogramTotals adds up the observations of every series of a histogram
+func histogramTotals(family *dto.MetricFamily) (float64, uint64) {
+	var sum float64
+	var count uint64
+	for _, metric := range family.GetMetric() {
+		sum += metric.GetHistogram().GetSampleSum()
+		count += metric.GetHistogram().GetSampleCount()
+	}
+	return sum, count
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, pair := range metric.GetLabel() {
+		if pair.GetName() == name {
+			return pair.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryRange(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHistory(time.Minute, 5*time.Second)
+	if _, ok := h.Latest(); ok {
+		t.Error("Expected an empty history to have no latest snapshot")
+	}
+
+	// 15 snapshots overflow the 12 kept for a minute
+	for i := 0; i < 15; i++ {
+		h.Add(Snapshot{Time: start.Add(time.Duration(i) * 5 * time.Second), Goroutines: i})
+	}
+	if latest, ok := h.Latest(); !ok || latest.Goroutines != 14 {
+		t.Errorf("Expected the newest snapshot, got %+v", latest)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Duration
+		step     time.Duration
+		want     []int
+	}{
+		{"everything kept", 0, time.Hour, 0, []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}},
+		{"bounds are inclusive", 20 * time.Second, 30 * time.Second, 0, []int{4, 5, 6}},
+		{"step below the resolution", 20 * time.Second, 30 * time.Second, time.Second, []int{4, 5, 6}},
+		{"newest per step", 20 * time.Second, 70 * time.Second, 20 * time.Second, []int{7, 11, 14}},
+		{"outside the history", 0, 10 * time.Second, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, s := range h.Range(start.Add(tt.from), start.Add(tt.to), tt.step) {
+				got = append(got, s.Goroutines)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectorRecordsHistory(t *testing.T) {
+	collector := NewMetricsCollector("node-a", 0)
+
+	collector.UpdateLayerAllocation("total", 6)
+	collector.RecordNetworkMessage("outgoing", "gossip")
+	collector.RecordNetworkMessage("incoming", "gossip")
+	collector.RecordNetworkMessage("incoming", "join")
+	collector.UpdatePeerLatency("node-b", 10*time.Millisecond, 0)
+	collector.UpdatePeerLatency("node-c", 30*time.Millisecond, 0)
+	collector.AddActiveRequests("llama-7b", 2)
+	collector.RecordInferenceRequest("llama-7b", "success", time.Second, 40)
+	collector.RecordInferenceRequest("llama-7b", "error", 3*time.Second, 0)
+	collector.updateSystemMetrics()
+
+	s, ok := collector.History().Latest()
+	if !ok {
+		t.Fatal("Expected a snapshot per collection")
+	}
+	if s.LayersAllocated != 6 || s.MessagesSent != 1 || s.MessagesReceived != 2 {
+		t.Errorf("Expected layers and messages in the snapshot, got %+v", s)
+	}
+	if s.RequestsTotal != 2 || s.ErrorsTotal != 1 || s.RequestsActive != 2 || s.TokensGenerated != 40 {
+		t.Errorf("Expected the inference totals in the snapshot, got %+v", s)
+	}
+	if s.AvgLatency != 2*time.Second || s.PeerRTT != 20*time.Millisecond {
+		t.Errorf("Expected average latencies, got %v and %v", s.AvgLatency, s.PeerRTT)
+	}
+
+	// Latency is averaged over the requests since the previous snapshot
+	collector.updateSystemMetrics()
+	if s, _ := collector.History().Latest(); s.AvgLatency != 0 || s.RequestsTotal != 2 {
+		t.Errorf("Expected no latency without new requests, got %+v", s)
+	}
+}
@@ -6,229 +6,393 @@ import (
 	"log/slog"
 	"net/http"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 
+	"distributed-llm/pkg/health"
 	"distributed-llm/pkg/models"
 )
 
-var (
+// collectorMetrics are the metric vectors of one collector. node_id is a
+// constant label, so collectors for several nodes can run in one process.
+type collectorMetrics struct {
+	nodeResourcesGauge          *prometheus.GaugeVec
+	nodeStatusGauge             prometheus.Gauge
+	nodeUptimeGauge             prometheus.Gauge
+	nodeCPUUsagePercent         prometheus.Gauge
+	nodeMemoryUsedBytes         prometheus.Gauge
+	nodeMemoryTotalBytes        prometheus.Gauge
+	layersAllocatedGauge        *prometheus.GaugeVec
+	layersCapacityGauge         prometheus.Gauge
+	networkMessagesTotal        *prometheus.CounterVec
+	networkLatencyHistogram     *prometheus.HistogramVec
+	networkRTTGauge             *prometheus.GaugeVec
+	networkBandwidthGauge       *prometheus.GaugeVec
+	networkConnectionsGauge     prometheus.Gauge
+	inferenceRequestsTotal      *prometheus.CounterVec
+	inferenceLatencyHistogram   *prometheus.HistogramVec
+	inferenceTokensGenerated    *prometheus.CounterVec
+	inferenceTokensPerSecond    prometheus.Gauge
+	inferenceActiveGauge        *prometheus.GaugeVec
+	inferenceQueueDepthGauge    *prometheus.GaugeVec
+	modelsLoadedGauge           prometheus.Gauge
+	modelSizeBytes              *prometheus.GaugeVec
+	systemMemoryUsageBytes      *prometheus.GaugeVec
+	systemGoroutinesGauge       prometheus.Gauge
+	healthCheckTotal            *prometheus.CounterVec
+	healthCheckLatencyHistogram prometheus.Histogram
+}
+
+func newCollectorMetrics(nodeID string) *collectorMetrics {
+	nodeLabel := prometheus.Labels{"node_id": nodeID}
+	m := &collectorMetrics{}
 	// Node metrics
-	nodeResourcesGauge = prometheus.NewGaugeVec(
+	m.nodeResourcesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "distributed_llm_node_resources",
+			Help:        "Node resource information",
+			ConstLabels: nodeLabel,
+		},
+		[]string{"resource_type"},
+	)
+
+	m.nodeStatusGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "distributed_llm_node_status",
+			Help:        "Node status (0=offline, 1=online, 2=busy, 3=unknown)",
+			ConstLabels: nodeLabel,
+		},
+	)
+
+	m.nodeUptimeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "distributed_llm_node_uptime_seconds",
+			Help:        "Node uptime in seconds",
+			ConstLabels: nodeLabel,
+		},
+	)
+
+	m.nodeCPUUsagePercent = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_node_resources",
-			Help: "Node resource information",
+			Name:        "distributed_llm_node_cpu_usage_percent",
+			Help:        "Host CPU usage percentage over the last collection interval",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "resource_type"},
 	)
 
-	nodeStatusGauge = prometheus.NewGaugeVec(
+	m.nodeMemoryUsedBytes = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_node_status",
-			Help: "Node status (0=offline, 1=online, 2=busy, 3=unknown)",
+			Name:        "distributed_llm_node_memory_used_bytes",
+			Help:        "Host memory in use in bytes, excluding reclaimable caches",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id"},
 	)
 
-	nodeUptimeGauge = prometheus.NewGaugeVec(
+	m.nodeMemoryTotalBytes = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_node_uptime_seconds",
-			Help: "Node uptime in seconds",
+			Name:        "distributed_llm_node_memory_total_bytes",
+			Help:        "Host memory in bytes",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id"},
 	)
 
 	// Layer management metrics
-	layersAllocatedGauge = prometheus.NewGaugeVec(
+	m.layersAllocatedGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_layers_allocated",
-			Help: "Number of layers allocated on each node",
+			Name:        "distributed_llm_layers_allocated",
+			Help:        "Number of layers allocated on each node",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "model_id"},
+		[]string{"model_id"},
 	)
 
-	layersCapacityGauge = prometheus.NewGaugeVec(
+	m.layersCapacityGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_layers_capacity",
-			Help: "Maximum layer capacity of each node",
+			Name:        "distributed_llm_layers_capacity",
+			Help:        "Maximum layer capacity of each node",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id"},
 	)
 
 	// Network metrics
-	networkMessagesTotal = prometheus.NewCounterVec(
+	m.networkMessagesTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "distributed_llm_network_messages_total",
-			Help: "Total number of network messages sent/received",
+			Name:        "distributed_llm_network_messages_total",
+			Help:        "Total number of network messages sent/received",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "direction", "message_type"},
+		[]string{"direction", "message_type"},
 	)
 
-	networkLatencyHistogram = prometheus.NewHistogramVec(
+	m.networkLatencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "distributed_llm_network_latency_seconds",
-			Help:    "Network request latency in seconds",
-			Buckets: prometheus.DefBuckets,
+			Name:        "distributed_llm_network_latency_seconds",
+			Help:        "Network request latency in seconds",
+			ConstLabels: nodeLabel,
+			Buckets:     prometheus.DefBuckets,
+		},
+		[]string{"target_node", "operation"},
+	)
+
+	m.networkRTTGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "distributed_llm_network_rtt_seconds",
+			Help:        "Smoothed round trip time to each peer in seconds",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "target_node", "operation"},
+		[]string{"target_node"},
 	)
 
-	networkConnectionsGauge = prometheus.NewGaugeVec(
+	m.networkBandwidthGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_network_connections",
-			Help: "Number of active network connections",
+			Name:        "distributed_llm_network_bandwidth_bytes_per_second",
+			Help:        "Estimated bandwidth to each peer in bytes per second",
+			ConstLabels: nodeLabel,
+		},
+		[]string{"target_node"},
+	)
+
+	m.networkConnectionsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "distributed_llm_network_connections",
+			Help:        "Number of active network connections",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id"},
 	)
 
 	// Inference metrics
-	inferenceRequestsTotal = prometheus.NewCounterVec(
+	m.inferenceRequestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "distributed_llm_inference_requests_total",
-			Help: "Total number of inference requests",
+			Name:        "distributed_llm_inference_requests_total",
+			Help:        "Total number of inference requests",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "model_id", "status"},
+		[]string{"model_id", "status"},
 	)
 
-	inferenceLatencyHistogram = prometheus.NewHistogramVec(
+	m.inferenceLatencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "distributed_llm_inference_latency_seconds",
-			Help:    "Inference request latency in seconds",
-			Buckets: []float64{0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 30.0, 60.0},
+			Name:        "distributed_llm_inference_latency_seconds",
+			Help:        "Inference request latency in seconds",
+			ConstLabels: nodeLabel,
+			Buckets:     []float64{0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 30.0, 60.0},
 		},
-		[]string{"node_id", "model_id"},
+		[]string{"model_id"},
 	)
 
-	inferenceTokensGenerated = prometheus.NewCounterVec(
+	m.inferenceTokensGenerated = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "distributed_llm_inference_tokens_generated_total",
-			Help: "Total number of tokens generated",
+			Name:        "distributed_llm_inference_tokens_generated_total",
+			Help:        "Total number of tokens generated",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "model_id"},
+		[]string{"model_id"},
 	)
 
-	// Model metrics
-	modelsLoadedGauge = prometheus.NewGaugeVec(
+	m.inferenceTokensPerSecond = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_models_loaded",
-			Help: "Number of models loaded",
+			Name:        "distributed_llm_inference_tokens_per_second",
+			Help:        "Tokens generated per second over the last collection interval",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id"},
 	)
 
-	modelSizeBytes = prometheus.NewGaugeVec(
+	m.inferenceActiveGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_model_size_bytes",
-			Help: "Size of loaded models in bytes",
+			Name:        "distributed_llm_inference_requests_active",
+			Help:        "Inference requests this node is serving, as gateway or pipeline stage",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "model_id"},
+		[]string{"model_id"},
 	)
 
-	// System metrics
-	systemMemoryUsageBytes = prometheus.NewGaugeVec(
+	m.inferenceQueueDepthGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_system_memory_usage_bytes",
-			Help: "System memory usage in bytes",
+			Name:        "distributed_llm_inference_queue_depth",
+			Help:        "Inference requests in flight through this node's router",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "memory_type"},
+		[]string{"model_id"},
 	)
 
-	systemCPUUsagePercent = prometheus.NewGaugeVec(
+	// Model metrics
+	m.modelsLoadedGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_system_cpu_usage_percent",
-			Help: "System CPU usage percentage",
+			Name:        "distributed_llm_models_loaded",
+			Help:        "Number of models loaded",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id"},
 	)
 
-	systemGoroutinesGauge = prometheus.NewGaugeVec(
+	m.modelSizeBytes = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "distributed_llm_system_goroutines",
-			Help: "Number of goroutines",
+			Name:        "distributed_llm_model_size_bytes",
+			Help:        "Size of loaded models in bytes",
+			ConstLabels: nodeLabel,
+		},
+		[]string{"model_id"},
+	)
+
+	// System metrics
+	m.systemMemoryUsageBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "distributed_llm_system_memory_usage_bytes",
+			Help:        "System memory usage in bytes",
+			ConstLabels: nodeLabel,
+		},
+		[]string{"memory_type"},
+	)
+
+	m.systemGoroutinesGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "distributed_llm_system_goroutines",
+			Help:        "Number of goroutines",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id"},
 	)
 
 	// Health check metrics
-	healthCheckTotal = prometheus.NewCounterVec(
+	m.healthCheckTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "distributed_llm_health_checks_total",
-			Help: "Total number of health checks",
+			Name:        "distributed_llm_health_checks_total",
+			Help:        "Total number of health checks",
+			ConstLabels: nodeLabel,
 		},
-		[]string{"node_id", "status"},
+		[]string{"status"},
 	)
 
-	healthCheckLatencyHistogram = prometheus.NewHistogramVec(
+	m.healthCheckLatencyHistogram = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
-			Name:    "distributed_llm_health_check_latency_seconds",
-			Help:    "Health check latency in seconds",
-			Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1.0},
+			Name:        "distributed_llm_health_check_latency_seconds",
+			Help:        "Health check latency in seconds",
+			ConstLabels: nodeLabel,
+			Buckets:     []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1.0},
 		},
-		[]string{"node_id"},
 	)
-)
+
+	return m
+}
+
+// collectors lists the vectors for registration
+func (m *collectorMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.nodeResourcesGauge,
+		m.nodeStatusGauge,
+		m.nodeUptimeGauge,
+		m.nodeCPUUsagePercent,
+		m.nodeMemoryUsedBytes,
+		m.nodeMemoryTotalBytes,
+		m.layersAllocatedGauge,
+		m.layersCapacityGauge,
+		m.networkMessagesTotal,
+		m.networkLatencyHistogram,
+		m.networkRTTGauge,
+		m.networkBandwidthGauge,
+		m.networkConnectionsGauge,
+		m.inferenceRequestsTotal,
+		m.inferenceLatencyHistogram,
+		m.inferenceTokensGenerated,
+		m.inferenceTokensPerSecond,
+		m.inferenceActiveGauge,
+		m.inferenceQueueDepthGauge,
+		m.modelsLoadedGauge,
+		m.modelSizeBytes,
+		m.systemMemoryUsageBytes,
+		m.systemGoroutinesGauge,
+		m.healthCheckTotal,
+		m.healthCheckLatencyHistogram,
+	}
+}
 
 // MetricsCollector manages metrics collection and export
 type MetricsCollector struct {
 	nodeID     string
 	registry   *prometheus.Registry
+	metrics    *collectorMetrics
+	health     *health.Registry
+	heartbeat  *health.Heartbeat
 	server     *http.Server
 	startTime  time.Time
 	logger     *slog.Logger
 	cancelFunc context.CancelFunc
+
+	// history keeps a snapshot of the key metrics per collection
+	history *History
+
+	// cpu and the last token and latency totals are only used by
+	// updateSystemMetrics
+	cpu              cpuSampler
+	tokens           atomic.Int64
+	lastTokens       int64
+	lastSample       time.Time
+	lastLatencySum   float64
+	lastLatencyCount uint64
 }
 
-// NewMetricsCollector creates a new metrics collector
+// systemMetricsInterval is how often system-level metrics are refreshed
+// and the history recorded
+const systemMetricsInterval = 5 * time.Second
+
+// NewMetricsCollector creates a metrics collector with a registry of its
+// own, labelling every metric with the node's ID. Collectors for several
+// nodes can run in one process as long as they serve different ports.
 func NewMetricsCollector(nodeID string, port int) *MetricsCollector {
 	registry := prometheus.NewRegistry()
 
 	// Register all metrics
-	registry.MustRegister(
-		nodeResourcesGauge,
-		nodeStatusGauge,
-		nodeUptimeGauge,
-		layersAllocatedGauge,
-		layersCapacityGauge,
-		networkMessagesTotal,
-		networkLatencyHistogram,
-		networkConnectionsGauge,
-		inferenceRequestsTotal,
-		inferenceLatencyHistogram,
-		inferenceTokensGenerated,
-		modelsLoadedGauge,
-		modelSizeBytes,
-		systemMemoryUsageBytes,
-		systemCPUUsagePercent,
-		systemGoroutinesGauge,
-		healthCheckTotal,
-		healthCheckLatencyHistogram,
-	)
+	metrics := newCollectorMetrics(nodeID)
+	registry.MustRegister(metrics.collectors()...)
 
 	// Add Go runtime metrics
 	registry.MustRegister(prometheus.NewGoCollector())
 	registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
 
+	mc := &MetricsCollector{
+		nodeID:     nodeID,
+		registry:   registry,
+		metrics:    metrics,
+		history:    NewHistory(historyRetention, systemMetricsInterval),
+		health:     health.NewRegistry(),
+		startTime:  time.Now(),
+		lastSample: time.Now(),
+		logger:     slog.With("component", "metrics", "nodeID", nodeID),
+	}
+	mc.heartbeat = mc.health.Heartbeat("metrics-collection", 3*systemMetricsInterval)
+	mc.health.SetObserver(func(result health.Result) {
+		status := "ok"
+		if !result.Passed() {
+			status = "failed"
+		}
+		mc.RecordHealthCheck(status, result.Duration)
+	})
+
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	mux.Handle("/health", mc.health.Handler(false, health.Liveness))
+	mc.health.RegisterHandlers(mux)
 
-	server := &http.Server{
+	mc.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: mux,
 	}
 
-	return &MetricsCollector{
-		nodeID:    nodeID,
-		registry:  registry,
-		server:    server,
-		startTime: time.Now(),
-		logger:    slog.With("component", "metrics"),
-	}
+	return mc
+}
+
+// Gather returns the current value of every metric of the collector, as
+// served on /metrics
+func (mc *MetricsCollector) Gather() ([]*dto.MetricFamily, error) {
+	return mc.registry.Gather()
+}
+
+// HealthRegistry returns the registry behind /livez, /readyz and
+// /healthz/verbose so other subsystems can add their checks
+func (mc *MetricsCollector) HealthRegistry() *health.Registry {
+	return mc.health
 }
 
 // Start begins the metrics server and periodic collection
@@ -264,7 +428,7 @@ func (mc *MetricsCollector) Stop() error {
 
 // collectSystemMetrics periodically collects system-level metrics
 func (mc *MetricsCollector) collectSystemMetrics(ctx context.Context) {
-	ticker := time.NewTicker(15 * time.Second)
+	ticker := time.NewTicker(systemMetricsInterval)
 	defer ticker.Stop()
 
 	for {
@@ -273,6 +437,7 @@ func (mc *MetricsCollector) collectSystemMetrics(ctx context.Context) {
 			return
 		case <-ticker.C:
 			mc.updateSystemMetrics()
+			mc.heartbeat.Beat()
 		}
 	}
 }
@@ -281,32 +446,53 @@ func (mc *MetricsCollector) collectSystemMetrics(ctx context.Context) {
 func (mc *MetricsCollector) updateSystemMetrics() {
 	// Update uptime
 	uptime := time.Since(mc.startTime).Seconds()
-	nodeUptimeGauge.WithLabelValues(mc.nodeID).Set(uptime)
+	mc.metrics.nodeUptimeGauge.Set(uptime)
 
 	// Update goroutine count
 	goroutines := runtime.NumGoroutine()
-	systemGoroutinesGauge.WithLabelValues(mc.nodeID).Set(float64(goroutines))
+	mc.metrics.systemGoroutinesGauge.Set(float64(goroutines))
 
 	// Update memory stats
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
 
-	systemMemoryUsageBytes.WithLabelValues(mc.nodeID, "heap_alloc").Set(float64(memStats.HeapAlloc))
-	systemMemoryUsageBytes.WithLabelValues(mc.nodeID, "heap_sys").Set(float64(memStats.HeapSys))
-	systemMemoryUsageBytes.WithLabelValues(mc.nodeID, "stack_sys").Set(float64(memStats.StackSys))
+	mc.metrics.systemMemoryUsageBytes.WithLabelValues("heap_alloc").Set(float64(memStats.HeapAlloc))
+	mc.metrics.systemMemoryUsageBytes.WithLabelValues("heap_sys").Set(float64(memStats.HeapSys))
+	mc.metrics.systemMemoryUsageBytes.WithLabelValues("stack_sys").Set(float64(memStats.StackSys))
+
+	// Update host CPU and memory
+	if times, err := readProcFile(procStat, readCPUTimes); err == nil {
+		if usage, ok := mc.cpu.sample(times); ok {
+			mc.metrics.nodeCPUUsagePercent.Set(usage)
+		}
+	}
+	if memory, err := readProcFile(procMeminfo, readMemoryInfo); err == nil {
+		mc.metrics.nodeMemoryUsedBytes.Set(float64(memory.used))
+		mc.metrics.nodeMemoryTotalBytes.Set(float64(memory.total))
+	}
+
+	// Update token throughput
+	now := time.Now()
+	tokens := mc.tokens.Load()
+	if elapsed := now.Sub(mc.lastSample).Seconds(); elapsed > 0 {
+		mc.metrics.inferenceTokensPerSecond.Set(float64(tokens-mc.lastTokens) / elapsed)
+	}
+	mc.lastTokens, mc.lastSample = tokens, now
+
+	mc.history.Add(mc.snapshot(now, &memStats))
 }
 
 // UpdateNodeResources updates node resource metrics
 func (mc *MetricsCollector) UpdateNodeResources(resources models.ResourceInfo) {
-	nodeResourcesGauge.WithLabelValues(mc.nodeID, "cpu_cores").Set(float64(resources.CPUCores))
-	nodeResourcesGauge.WithLabelValues(mc.nodeID, "memory_mb").Set(float64(resources.MemoryMB))
-	layersCapacityGauge.WithLabelValues(mc.nodeID).Set(float64(resources.MaxLayers))
-	layersAllocatedGauge.WithLabelValues(mc.nodeID, "total").Set(float64(resources.UsedLayers))
+	mc.metrics.nodeResourcesGauge.WithLabelValues("cpu_cores").Set(float64(resources.CPUCores))
+	mc.metrics.nodeResourcesGauge.WithLabelValues("memory_mb").Set(float64(resources.MemoryMB))
+	mc.metrics.layersCapacityGauge.Set(float64(resources.MaxLayers))
+	mc.metrics.layersAllocatedGauge.WithLabelValues("total").Set(float64(resources.UsedLayers))
 
 	// Update GPU metrics
 	for i, gpu := range resources.GPUs {
 		gpuLabel := fmt.Sprintf("gpu_%d", i)
-		nodeResourcesGauge.WithLabelValues(mc.nodeID, gpuLabel+"_memory_mb").Set(float64(gpu.MemoryMB))
+		mc.metrics.nodeResourcesGauge.WithLabelValues(gpuLabel + "_memory_mb").Set(float64(gpu.MemoryMB))
 	}
 }
 
@@ -323,50 +509,76 @@ func (mc *MetricsCollector) UpdateNodeStatus(status models.NodeStatus) {
 	default:
 		statusValue = 3 // Unknown/error status
 	}
-	nodeStatusGauge.WithLabelValues(mc.nodeID).Set(statusValue)
+	mc.metrics.nodeStatusGauge.Set(statusValue)
 }
 
 // RecordNetworkMessage records a network message
 func (mc *MetricsCollector) RecordNetworkMessage(direction, messageType string) {
-	networkMessagesTotal.WithLabelValues(mc.nodeID, direction, messageType).Inc()
+	mc.metrics.networkMessagesTotal.WithLabelValues(direction, messageType).Inc()
 }
 
 // RecordNetworkLatency records network request latency
 func (mc *MetricsCollector) RecordNetworkLatency(targetNode, operation string, duration time.Duration) {
-	networkLatencyHistogram.WithLabelValues(mc.nodeID, targetNode, operation).Observe(duration.Seconds())
+	mc.metrics.networkLatencyHistogram.WithLabelValues(targetNode, operation).Observe(duration.Seconds())
+}
+
+// UpdatePeerLatency updates the RTT and bandwidth measured to a peer
+func (mc *MetricsCollector) UpdatePeerLatency(peer string, rtt time.Duration, bandwidth float64) {
+	mc.metrics.networkRTTGauge.WithLabelValues(peer).Set(rtt.Seconds())
+	if bandwidth > 0 {
+		mc.metrics.networkBandwidthGauge.WithLabelValues(peer).Set(bandwidth)
+	}
+}
+
+// RemovePeerLatency drops the series of a peer that left
+func (mc *MetricsCollector) RemovePeerLatency(peer string) {
+	mc.metrics.networkRTTGauge.DeleteLabelValues(peer)
+	mc.metrics.networkBandwidthGauge.DeleteLabelValues(peer)
 }
 
 // UpdateNetworkConnections updates the number of active network connections
 func (mc *MetricsCollector) UpdateNetworkConnections(count int) {
-	networkConnectionsGauge.WithLabelValues(mc.nodeID).Set(float64(count))
+	mc.metrics.networkConnectionsGauge.Set(float64(count))
+}
+
+// UpdateQueueDepth updates the requests in flight for a model
+func (mc *MetricsCollector) UpdateQueueDepth(modelID string, depth int) {
+	mc.metrics.inferenceQueueDepthGauge.WithLabelValues(modelID).Set(float64(depth))
+}
+
+// AddActiveRequests adjusts the inference requests this node is serving
+// for a model by delta
+func (mc *MetricsCollector) AddActiveRequests(modelID string, delta int) {
+	mc.metrics.inferenceActiveGauge.WithLabelValues(modelID).Add(float64(delta))
 }
 
 // RecordInferenceRequest records an inference request
 func (mc *MetricsCollector) RecordInferenceRequest(modelID, status string, duration time.Duration, tokensGenerated int) {
-	inferenceRequestsTotal.WithLabelValues(mc.nodeID, modelID, status).Inc()
-	inferenceLatencyHistogram.WithLabelValues(mc.nodeID, modelID).Observe(duration.Seconds())
+	mc.metrics.inferenceRequestsTotal.WithLabelValues(modelID, status).Inc()
+	mc.metrics.inferenceLatencyHistogram.WithLabelValues(modelID).Observe(duration.Seconds())
 	if tokensGenerated > 0 {
-		inferenceTokensGenerated.WithLabelValues(mc.nodeID, modelID).Add(float64(tokensGenerated))
+		mc.metrics.inferenceTokensGenerated.WithLabelValues(modelID).Add(float64(tokensGenerated))
+		mc.tokens.Add(int64(tokensGenerated))
 	}
 }
 
 // UpdateModelsLoaded updates the number of loaded models
 func (mc *MetricsCollector) UpdateModelsLoaded(count int) {
-	modelsLoadedGauge.WithLabelValues(mc.nodeID).Set(float64(count))
+	mc.metrics.modelsLoadedGauge.Set(float64(count))
 }
 
 // UpdateModelSize updates the size of a loaded model
 func (mc *MetricsCollector) UpdateModelSize(modelID string, sizeBytes int64) {
-	modelSizeBytes.WithLabelValues(mc.nodeID, modelID).Set(float64(sizeBytes))
+	mc.metrics.modelSizeBytes.WithLabelValues(modelID).Set(float64(sizeBytes))
 }
 
 // RecordHealthCheck records a health check
 func (mc *MetricsCollector) RecordHealthCheck(status string, duration time.Duration) {
-	healthCheckTotal.WithLabelValues(mc.nodeID, status).Inc()
-	healthCheckLatencyHistogram.WithLabelValues(mc.nodeID).Observe(duration.Seconds())
+	mc.metrics.healthCheckTotal.WithLabelValues(status).Inc()
+	mc.metrics.healthCheckLatencyHistogram.Observe(duration.Seconds())
 }
 
 // UpdateLayerAllocation updates layer allocation for a specific model
 func (mc *MetricsCollector) UpdateLayerAllocation(modelID string, allocatedLayers int) {
-	layersAllocatedGauge.WithLabelValues(mc.nodeID, modelID).Set(float64(allocatedLayers))
+	mc.metrics.layersAllocatedGauge.WithLabelValues(modelID).Set(float64(allocatedLayers))
 }
@@ -2,11 +2,13 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"testing"
 	"time"
 
+	"distributed-llm/pkg/health"
 	"distributed-llm/pkg/models"
 )
 
@@ -39,34 +41,54 @@ func TestMetricsCollectorStartStop(t *testing.T) {
 	// Give the server a moment to start
 	time.Sleep(100 * time.Millisecond)
 
+	// Bodies are read and closed as soon as each response arrives, and idle
+	// connections closed before stopping, so that shutdown has none to wait for
+	client := &http.Client{}
+	get := func(path string) (int, string, error) {
+		resp, err := client.Get("http://localhost:9091" + path)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
 	// Test that metrics endpoint is accessible
-	resp, err := http.Get("http://localhost:9091/metrics")
-	if err != nil {
+	if code, _, err := get("/metrics"); err != nil {
 		t.Errorf("Failed to get metrics: %v", err)
-	} else {
-		resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
-		}
+	} else if code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", code)
 	}
 
 	// Test health endpoint
-	resp, err = http.Get("http://localhost:9091/health")
-	if err != nil {
+	if code, body, err := get("/health"); err != nil {
 		t.Errorf("Failed to get health: %v", err)
-	} else {
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
-		}
+	} else if code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", code)
+	} else if body != "OK" {
+		t.Errorf("Expected 'OK', got '%s'", body)
+	}
 
-		body, err := io.ReadAll(resp.Body)
+	// Readiness reflects registered checks, liveness does not
+	collector.HealthRegistry().Register("grpc", health.Readiness, health.CheckerFunc(func(ctx context.Context) error {
+		return errors.New("not serving")
+	}))
+	for path, want := range map[string]int{
+		"/livez":           http.StatusOK,
+		"/readyz":          http.StatusServiceUnavailable,
+		"/healthz/verbose": http.StatusServiceUnavailable,
+	} {
+		code, _, err := get(path)
 		if err != nil {
-			t.Errorf("Failed to read response body: %v", err)
-		} else if string(body) != "OK" {
-			t.Errorf("Expected 'OK', got '%s'", string(body))
+			t.Errorf("Failed to get %s: %v", path, err)
+			continue
+		}
+		if code != want {
+			t.Errorf("Expected %s status %d, got %d", path, want, code)
 		}
 	}
+	client.CloseIdleConnections()
 
 	err = collector.Stop()
 	if err != nil {
@@ -110,6 +132,7 @@ func TestRecordInferenceRequest(t *testing.T) {
 	duration := 1500 * time.Millisecond
 	collector.RecordInferenceRequest("llama-7b", "success", duration, 150)
 	collector.RecordInferenceRequest("llama-7b", "error", duration, 0)
+	collector.UpdateQueueDepth("llama-7b", 3)
 }
 
 func TestNetworkMetrics(t *testing.T) {
@@ -122,6 +145,9 @@ func TestNetworkMetrics(t *testing.T) {
 	collector.RecordNetworkLatency("node-2", "health_check", duration)
 
 	collector.UpdateNetworkConnections(5)
+
+	collector.UpdatePeerLatency("node-2", 2*time.Millisecond, 1e9)
+	collector.RemovePeerLatency("node-2")
 }
 
 func TestModelMetrics(t *testing.T) {
@@ -161,6 +187,102 @@ func TestSystemMetricsCollection(t *testing.T) {
 	collector.Stop()
 }
 
+// gathered returns the value of the series of a metric with the given
+// labels, and whether the collector has it
+func gathered(t *testing.T, collector *MetricsCollector, name string, labels map[string]string) (float64, bool) {
+	t.Helper()
+	families, err := collector.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	series:
+		for _, metric := range family.GetMetric() {
+			got := make(map[string]string)
+			for _, pair := range metric.GetLabel() {
+				got[pair.GetName()] = pair.GetValue()
+			}
+			for k, v := range labels {
+				if got[k] != v {
+					continue series
+				}
+			}
+			switch {
+			case metric.Counter != nil:
+				return metric.GetCounter().GetValue(), true
+			case metric.Gauge != nil:
+				return metric.GetGauge().GetValue(), true
+			case metric.Histogram != nil:
+				return float64(metric.GetHistogram().GetSampleCount()), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func TestCollectorsAreIndependent(t *testing.T) {
+	a := NewMetricsCollector("node-a", 0)
+	b := NewMetricsCollector("node-b", 0)
+
+	a.RecordInferenceRequest("llama-7b", "success", time.Second, 10)
+	a.RecordInferenceRequest("llama-7b", "success", time.Second, 5)
+	b.RecordInferenceRequest("llama-7b", "error", time.Second, 0)
+	a.UpdateNodeStatus(models.NodeStatusBusy)
+
+	tests := []struct {
+		name      string
+		collector *MetricsCollector
+		metric    string
+		labels    map[string]string
+		want      float64
+		present   bool
+	}{
+		{"own requests", a, "distributed_llm_inference_requests_total", map[string]string{"node_id": "node-a", "model_id": "llama-7b", "status": "success"}, 2, true},
+		{"own tokens", a, "distributed_llm_inference_tokens_generated_total", map[string]string{"node_id": "node-a"}, 15, true},
+		{"unlabelled gauge", a, "distributed_llm_node_status", map[string]string{"node_id": "node-a"}, 2, true},
+		{"other node's requests", b, "distributed_llm_inference_requests_total", map[string]string{"node_id": "node-b", "status": "error"}, 1, true},
+		{"not shared", b, "distributed_llm_inference_requests_total", map[string]string{"status": "success"}, 0, false},
+		{"no foreign node label", a, "distributed_llm_inference_requests_total", map[string]string{"node_id": "node-b"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := gathered(t, tt.collector, tt.metric, tt.labels)
+			if ok != tt.present || got != tt.want {
+				t.Errorf("Expected %s%v = %v (present %v), got %v (present %v)", tt.metric, tt.labels, tt.want, tt.present, got, ok)
+			}
+		})
+	}
+}
+
+func TestInferenceLoadMetrics(t *testing.T) {
+	collector := NewMetricsCollector("node-a", 0)
+
+	collector.AddActiveRequests("llama-7b", 1)
+	collector.AddActiveRequests("llama-7b", 1)
+	collector.AddActiveRequests("llama-7b", -1)
+	collector.RecordInferenceRequest("llama-7b", "success", time.Second, 30)
+	collector.lastSample = time.Now().Add(-10 * time.Second)
+	collector.updateSystemMetrics()
+
+	active, _ := gathered(t, collector, "distributed_llm_inference_requests_active", map[string]string{"model_id": "llama-7b"})
+	if active != 1 {
+		t.Errorf("Expected 1 active request, got %v", active)
+	}
+	// 30 tokens over the 10s since the last sample
+	rate, _ := gathered(t, collector, "distributed_llm_inference_tokens_per_second", nil)
+	if rate < 2.9 || rate > 3 {
+		t.Errorf("Expected about 3 tokens per second, got %v", rate)
+	}
+	collector.updateSystemMetrics()
+	if rate, _ := gathered(t, collector, "distributed_llm_inference_tokens_per_second", nil); rate != 0 {
+		t.Errorf("Expected no tokens since the last sample, got %v", rate)
+	}
+}
+
 // Benchmark tests for performance
 func BenchmarkUpdateNodeResources(b *testing.B) {
 	collector := NewMetricsCollector("test-node", 9099)
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Host statistics are read from procfs, so node CPU and memory are only
+// exported on Linux
+const (
+	procStat    = "/proc/stat"
+	procMeminfo = "/proc/meminfo"
+)
+
+// cpuTimes are the host's cumulative CPU times, in clock ticks
+type cpuTimes struct {
+	idle  uint64
+	total uint64
+}
+
+// cpuSampler turns successive /proc/stat readings into a usage percentage
+type cpuSampler struct {
+	last cpuTimes
+	ok   bool
+}
+
+// sample returns the host's CPU usage since the previous sample. The first
+// sample only records the counters.
+func (s *cpuSampler) sample(times cpuTimes) (float64, bool) {
+	last, ok := s.last, s.ok
+	s.last, s.ok = times, true
+	if !ok || times.total <= last.total {
+		return 0, false
+	}
+	busy := float64((times.total - last.total) - (times.idle - last.idle))
+	return 100 * busy / float64(times.total-last.total), true
+}
+
+// readCPUTimes reads the aggregate "cpu" line of /proc/stat
+func readCPUTimes(r io.Reader) (cpuTimes, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		var times cpuTimes
+		for i, field := range fields[1:] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return cpuTimes{}, fmt.Errorf("invalid cpu time %q: %w", field, err)
+			}
+			// guest time is already counted in user time
+			if i >= 8 {
+				break
+			}
+			times.total += value
+			// idle and iowait
+			if i == 3 || i == 4 {
+				times.idle += value
+			}
+		}
+		return times, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return cpuTimes{}, err
+	}
+	return cpuTimes{}, fmt.Errorf("no cpu line")
+}
+
+// memoryInfo is the host's memory in bytes
+type memoryInfo struct {
+	used  uint64
+	total uint64
+}
+
+// readMemoryInfo reads /proc/meminfo. Memory the kernel can reclaim, such
+// as the page cache, counts as free.
+func readMemoryInfo(r io.Reader) (memoryInfo, error) {
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[key] = kb * 1024
+	}
+	if err := scanner.Err(); err != nil {
+		return memoryInfo{}, err
+	}
+
+	total, ok := values["MemTotal"]
+	if !ok {
+		return memoryInfo{}, fmt.Errorf("no MemTotal")
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	return memoryInfo{used: total - min(available, total), total: total}, nil
+}
+
+// readProcFile parses a procfs file
+func readProcFile[T any](path string, parse func(io.Reader) (T, error)) (T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer f.Close()
+	return parse(f)
+}
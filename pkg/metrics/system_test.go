@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCPUSampler(t *testing.T) {
+	first := "cpu  100 0 100 700 100 0 0 0 50 0\ncpu0 50 0 50 350 50 0 0 0 25 0\n"
+	second := "cpu  250 0 150 1400 200 0 0 0 80 0\n"
+
+	var sampler cpuSampler
+	times, err := readCPUTimes(strings.NewReader(first))
+	if err != nil {
+		t.Fatalf("Failed to parse /proc/stat: %v", err)
+	}
+	if _, ok := sampler.sample(times); ok {
+		t.Error("Expected the first sample to only record the counters")
+	}
+
+	times, err = readCPUTimes(strings.NewReader(second))
+	if err != nil {
+		t.Fatalf("Failed to parse /proc/stat: %v", err)
+	}
+	// 200 busy ticks of 1000, guest time not counted twice
+	if usage, ok := sampler.sample(times); !ok || usage != 20 {
+		t.Errorf("Expected 20%% usage, got %v (%v)", usage, ok)
+	}
+
+	if _, err := readCPUTimes(strings.NewReader("intr 1 2 3\n")); err == nil {
+		t.Error("Expected an error without a cpu line")
+	}
+}
+
+func TestReadMemoryInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    memoryInfo
+		wantErr bool
+	}{
+		{
+			name:  "available memory",
+			input: "MemTotal:       16000 kB\nMemFree:         2000 kB\nMemAvailable:    6000 kB\nCached:          3000 kB\n",
+			want:  memoryInfo{used: 10000 * 1024, total: 16000 * 1024},
+		},
+		{
+			name:  "older kernels without MemAvailable",
+			input: "MemTotal: 16000 kB\nMemFree: 2000 kB\nBuffers: 1000 kB\nCached: 3000 kB\n",
+			want:  memoryInfo{used: 10000 * 1024, total: 16000 * 1024},
+		},
+		{
+			name:    "no total",
+			input:   "MemFree: 2000 kB\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readMemoryInfo(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
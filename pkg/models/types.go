@@ -2,6 +2,8 @@ package models
 
 import (
 	pb "distributed-llm/proto"
+	"fmt"
+	"sort"
 	"time"
 )
 
@@ -12,8 +14,28 @@ type Node struct {
 	Resources ResourceInfo `json:"resources"`
 	Status    NodeStatus   `json:"status"`
 	LastSeen  time.Time    `json:"last_seen"`
+
+	// Build version and protocol range advertised by the node's agent
+	Version            string   `json:"version,omitempty"`
+	ProtocolVersion    int32    `json:"protocol_version,omitempty"`
+	MinProtocolVersion int32    `json:"min_protocol_version,omitempty"`
+	Features           []string `json:"features,omitempty"`
+
+	// Topology and other labels, see LabelZone, LabelRack and LabelHost
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
+// Well-known topology labels
+const (
+	// LabelZone is the failure domain a pipeline is kept within
+	LabelZone = "zone"
+	// LabelRack groups nodes sharing a switch or power supply
+	LabelRack = "rack"
+	// LabelHost is the machine a node runs on; nodes without it are
+	// distinguished by address
+	LabelHost = "host"
+)
+
 type ResourceInfo struct {
 	CPUCores   int64     `json:"cpu_cores"`
 	MemoryMB   int64     `json:"memory_mb"`
@@ -43,6 +65,9 @@ type Model struct {
 	LayerCount int32  `json:"layer_count"`
 	FilePath   string `json:"file_path"`
 	Size       int64  `json:"size"`
+
+	// Replicas is the number of pipelines serving the model; 0 means 1
+	Replicas int32 `json:"replicas,omitempty"`
 }
 
 type InferenceRequest struct {
@@ -69,6 +94,82 @@ func (n *Node) IsHealthy() bool {
 	return n.Status == NodeStatusOnline && time.Since(n.LastSeen) < 5*time.Minute
 }
 
+// protocolRange returns the protocol versions the node speaks. Nodes that
+// don't advertise a protocol predate negotiation and speak protocol 1.
+func (n *Node) protocolRange() (int32, int32) {
+	maxVersion := n.ProtocolVersion
+	if maxVersion <= 0 {
+		maxVersion = 1
+	}
+	minVersion := n.MinProtocolVersion
+	if minVersion <= 0 || minVersion > maxVersion {
+		minVersion = maxVersion
+	}
+	return minVersion, maxVersion
+}
+
+// CompatibleWith returns true if the two nodes share a protocol version and
+// can therefore exchange activations in the same pipeline
+func (n *Node) CompatibleWith(other *Node) bool {
+	minA, maxA := n.protocolRange()
+	minB, maxB := other.protocolRange()
+	return max(minA, minB) <= min(maxA, maxB)
+}
+
+// SpeaksProtocol returns true if the node supports the given protocol version
+func (n *Node) SpeaksProtocol(version int32) bool {
+	minVersion, maxVersion := n.protocolRange()
+	return minVersion <= version && version <= maxVersion
+}
+
+// HasFeature returns true if the node advertises the named feature
+func (n *Node) HasFeature(feature string) bool {
+	for _, f := range n.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Zone returns the node's zone, or "" if it has none
+func (n *Node) Zone() string {
+	return n.Labels[LabelZone]
+}
+
+// Rack returns the node's rack, or "" if it has none
+func (n *Node) Rack() string {
+	return n.Labels[LabelRack]
+}
+
+// Host returns the machine the node runs on, falling back to its address
+func (n *Node) Host() string {
+	if host := n.Labels[LabelHost]; host != "" {
+		return host
+	}
+	return n.Address
+}
+
+// DistinctVersions returns the sorted set of build versions advertised by the
+// nodes. More than one entry means a rolling upgrade is in progress.
+func DistinctVersions(nodes []Node) []string {
+	seen := make(map[string]bool)
+	versions := make([]string, 0)
+	for _, node := range nodes {
+		if node.Version != "" && !seen[node.Version] {
+			seen[node.Version] = true
+			versions = append(versions, node.Version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// DesiredReplicas returns the number of pipelines that should serve the model
+func (m *Model) DesiredReplicas() int {
+	return max(int(m.Replicas), 1)
+}
+
 // SizeInGB returns the model size in gigabytes
 func (m *Model) SizeInGB() float64 {
 	return float64(m.Size) / (1024 * 1024 * 1024)
@@ -124,3 +225,224 @@ func ResourceInfoFromProto(pbRes *pb.ResourceInfo) *ResourceInfo {
 		MaxLayers: pbRes.MaxLayers,
 	}
 }
+
+// NodeFromProto converts a protobuf node description to a node
+func NodeFromProto(info *pb.NodeInfo) Node {
+	node := Node{
+		ID:                 info.NodeId,
+		Address:            info.Address,
+		Port:               int(info.Port),
+		Status:             NodeStatus(info.Status),
+		LastSeen:           time.Unix(info.LastSeen, 0),
+		Version:            info.Version,
+		ProtocolVersion:    info.ProtocolVersion,
+		MinProtocolVersion: info.MinProtocolVersion,
+		Features:           info.Features,
+		Labels:             info.Labels,
+	}
+	if info.Resources != nil {
+		node.Resources = *ResourceInfoFromProto(info.Resources)
+		node.Resources.UsedLayers = info.Resources.UsedLayers
+	}
+	return node
+}
+
+// ModelFromProto converts a protobuf model description to a model
+func ModelFromProto(info *pb.ModelInfo) Model {
+	return Model{
+		ID:         info.Id,
+		Name:       info.Name,
+		Version:    info.Version,
+		LayerCount: info.LayerCount,
+		FilePath:   info.FilePath,
+		Size:       info.SizeBytes,
+		Replicas:   info.Replicas,
+	}
+}
+
+// PipelineStage assigns a contiguous, inclusive range of a model's layers to a node
+type PipelineStage struct {
+	NodeID     string `json:"node_id"`
+	LayerStart int32  `json:"layer_start"`
+	LayerEnd   int32  `json:"layer_end"`
+}
+
+// PlacementPlan describes how a model's layers are split across nodes
+type PlacementPlan struct {
+	ModelID   string          `json:"model_id"`
+	Replica   int             `json:"replica,omitempty"`
+	Stages    []PipelineStage `json:"stages"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// PlanKey identifies a replica's plan in the cluster state. The first
+// replica is keyed by the model ID alone, as before replicas existed.
+func PlanKey(modelID string, replica int) string {
+	if replica == 0 {
+		return modelID
+	}
+	return fmt.Sprintf("%s#%d", modelID, replica)
+}
+
+// ModelState is the lifecycle state of a model on a node
+type ModelState string
+
+const (
+	ModelStateLoading ModelState = "loading"
+	ModelStateReady   ModelState = "ready"
+	ModelStateFailed  ModelState = "failed"
+)
+
+// ModelReadiness reports whether a node can serve inference for a model
+type ModelReadiness struct {
+	ModelID string     `json:"model_id"`
+	State   ModelState `json:"state"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// Quota limits what a tenant may consume from the cluster; zero leaves a
+// limit off
+type Quota struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	TokensPerMinute   int64   `json:"tokens_per_minute"`
+	MaxConcurrent     int     `json:"max_concurrent"`
+	// Models lists the models the tenant may use; empty allows every model
+	Models []string `json:"models,omitempty"`
+}
+
+// AlertState is where an alert is in its lifecycle
+type AlertState string
+
+const (
+	// AlertPending alerts' conditions hold but not yet for the rule's duration
+	AlertPending AlertState = "pending"
+	AlertFiring  AlertState = "firing"
+	// AlertResolved is reported once when a firing alert's condition stops holding
+	AlertResolved AlertState = "resolved"
+)
+
+// Alert is a rule whose condition holds on a node
+type Alert struct {
+	Name     string     `json:"name"`
+	NodeID   string     `json:"node_id"`
+	Severity string     `json:"severity,omitempty"`
+	State    AlertState `json:"state"`
+	Expr     string     `json:"expr"`
+	// Value is the rule's metric when last evaluated
+	Value       float64   `json:"value"`
+	Summary     string    `json:"summary,omitempty"`
+	ActiveSince time.Time `json:"active_since"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToProto converts the alert to its protobuf form
+func (a *Alert) ToProto() *pb.Alert {
+	return &pb.Alert{
+		Name:        a.Name,
+		NodeId:      a.NodeID,
+		Severity:    a.Severity,
+		State:       string(a.State),
+		Expr:        a.Expr,
+		Value:       a.Value,
+		Summary:     a.Summary,
+		ActiveSince: a.ActiveSince.Unix(),
+		Updated:     a.UpdatedAt.Unix(),
+	}
+}
+
+// AlertFromProto converts a protobuf alert to an alert
+func AlertFromProto(info *pb.Alert) Alert {
+	return Alert{
+		Name:        info.Name,
+		NodeID:      info.NodeId,
+		Severity:    info.Severity,
+		State:       AlertState(info.State),
+		Expr:        info.Expr,
+		Value:       info.Value,
+		Summary:     info.Summary,
+		ActiveSince: time.Unix(info.ActiveSince, 0),
+		UpdatedAt:   time.Unix(info.Updated, 0),
+	}
+}
+
+// EventType identifies what happened in a cluster event
+type EventType string
+
+const (
+	EventNodeJoined EventType = "node_joined"
+	EventNodeLeft   EventType = "node_left"
+	// EventNodeSuspect is a member that stopped answering probes
+	EventNodeSuspect EventType = "node_suspect"
+	// EventNodeRecovered is a suspect member answering probes again
+	EventNodeRecovered EventType = "node_recovered"
+	EventModelLoaded   EventType = "model_loaded"
+	EventModelUnloaded EventType = "model_unloaded"
+	EventPlanChanged   EventType = "plan_changed"
+	EventRequestFailed EventType = "request_failed"
+	EventAlertFired    EventType = "alert_fired"
+	EventAlertResolved EventType = "alert_resolved"
+)
+
+// EventTypes lists every event type
+var EventTypes = []EventType{
+	EventNodeJoined, EventNodeLeft, EventNodeSuspect, EventNodeRecovered,
+	EventModelLoaded, EventModelUnloaded, EventPlanChanged,
+	EventRequestFailed, EventAlertFired, EventAlertResolved,
+}
+
+// Event is something an agent observed happening in the cluster. Sequence
+// numbers are assigned by the agent and restart with it.
+type Event struct {
+	Sequence   uint64            `json:"sequence"`
+	Time       time.Time         `json:"time"`
+	Type       EventType         `json:"type"`
+	NodeID     string            `json:"node_id,omitempty"`
+	ModelID    string            `json:"model_id,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// ToProto converts the event to its protobuf form
+func (e *Event) ToProto() *pb.ClusterEvent {
+	return &pb.ClusterEvent{
+		Sequence:   e.Sequence,
+		TimeUnixMs: e.Time.UnixMilli(),
+		Type:       string(e.Type),
+		NodeId:     e.NodeID,
+		ModelId:    e.ModelID,
+		Message:    e.Message,
+		Attributes: e.Attributes,
+	}
+}
+
+// EventFromProto converts a protobuf event to an event
+func EventFromProto(info *pb.ClusterEvent) Event {
+	return Event{
+		Sequence:   info.Sequence,
+		Time:       time.UnixMilli(info.TimeUnixMs),
+		Type:       EventType(info.Type),
+		NodeID:     info.NodeId,
+		ModelID:    info.ModelId,
+		Message:    info.Message,
+		Attributes: info.Attributes,
+	}
+}
+
+// LayerCount returns the number of layers covered by the stage
+func (s *PipelineStage) LayerCount() int32 {
+	return s.LayerEnd - s.LayerStart + 1
+}
+
+// Key returns the plan's key in the cluster state
+func (p *PlacementPlan) Key() string {
+	return PlanKey(p.ModelID, p.Replica)
+}
+
+// NodeIDs returns the nodes participating in the plan in pipeline order
+func (p *PlacementPlan) NodeIDs() []string {
+	ids := make([]string, len(p.Stages))
+	for i, stage := range p.Stages {
+		ids[i] = stage.NodeID
+	}
+	return ids
+}
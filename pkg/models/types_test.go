@@ -1,6 +1,7 @@
 package models
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -112,6 +113,39 @@ func TestNode_IsHealthy(t *testing.T) {
 	}
 }
 
+func TestNode_Topology(t *testing.T) {
+	tests := []struct {
+		name             string
+		node             Node
+		zone, rack, host string
+	}{
+		{
+			name: "Labelled node",
+			node: Node{Address: "10.0.0.1", Labels: map[string]string{LabelZone: "eu-1a", LabelRack: "r1", LabelHost: "worker-1"}},
+			zone: "eu-1a", rack: "r1", host: "worker-1",
+		},
+		{
+			name: "Unlabelled node",
+			node: Node{Address: "10.0.0.2"},
+			host: "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.node.Zone(); got != tt.zone {
+				t.Errorf("Node.Zone() = %q, want %q", got, tt.zone)
+			}
+			if got := tt.node.Rack(); got != tt.rack {
+				t.Errorf("Node.Rack() = %q, want %q", got, tt.rack)
+			}
+			if got := tt.node.Host(); got != tt.host {
+				t.Errorf("Node.Host() = %q, want %q", got, tt.host)
+			}
+		})
+	}
+}
+
 func TestModel_SizeInGB(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -248,6 +282,44 @@ func TestResourceInfoFromProto(t *testing.T) {
 	}
 }
 
+func TestNodeFromProto(t *testing.T) {
+	node := NodeFromProto(&pb.NodeInfo{
+		NodeId:    "node-1",
+		Address:   "10.0.0.1",
+		Port:      8080,
+		Status:    "online",
+		Resources: &pb.ResourceInfo{CpuCores: 8, MaxLayers: 32, UsedLayers: 16},
+		LastSeen:  1700000000,
+		Labels:    map[string]string{LabelZone: "us-east-1a"},
+	})
+
+	if node.ID != "node-1" || node.Address != "10.0.0.1" || node.Port != 8080 {
+		t.Errorf("Unexpected identity %+v", node)
+	}
+	if node.Status != NodeStatusOnline || node.LastSeen.Unix() != 1700000000 {
+		t.Errorf("Expected an online node last seen at 1700000000, got %s at %v", node.Status, node.LastSeen)
+	}
+	if node.Resources.CPUCores != 8 || node.Resources.UsedLayers != 16 {
+		t.Errorf("Expected resources to carry used layers, got %+v", node.Resources)
+	}
+	if node.Zone() != "us-east-1a" {
+		t.Errorf("Expected zone us-east-1a, got %q", node.Zone())
+	}
+
+	// Nodes without resources convert to zero resources
+	if node := NodeFromProto(&pb.NodeInfo{NodeId: "node-2"}); node.Resources.CPUCores != 0 {
+		t.Errorf("Expected empty resources, got %+v", node.Resources)
+	}
+}
+
+func TestModelFromProto(t *testing.T) {
+	model := ModelFromProto(&pb.ModelInfo{Id: "llama-7b", Name: "Llama", LayerCount: 32, SizeBytes: 1 << 30, Replicas: 2})
+
+	if model.ID != "llama-7b" || model.LayerCount != 32 || model.Size != 1<<30 || model.DesiredReplicas() != 2 {
+		t.Errorf("Unexpected model %+v", model)
+	}
+}
+
 func TestInferenceTypes(t *testing.T) {
 	// Test InferenceRequest
 	req := InferenceRequest{
@@ -333,3 +405,130 @@ func TestClusterState(t *testing.T) {
 		t.Errorf("Expected model size 7GB, got %f", state.Models[0].SizeInGB())
 	}
 }
+
+func TestPlacementPlan_NodeIDs(t *testing.T) {
+	plan := PlacementPlan{
+		ModelID: "llama-7b",
+		Stages: []PipelineStage{
+			{NodeID: "node-1", LayerStart: 0, LayerEnd: 9},
+			{NodeID: "node-2", LayerStart: 10, LayerEnd: 31},
+		},
+	}
+
+	ids := plan.NodeIDs()
+	if len(ids) != 2 || ids[0] != "node-1" || ids[1] != "node-2" {
+		t.Errorf("NodeIDs() = %v, want [node-1 node-2]", ids)
+	}
+
+	if got := plan.Stages[0].LayerCount(); got != 10 {
+		t.Errorf("LayerCount() = %d, want 10", got)
+	}
+	if got := plan.Stages[1].LayerCount(); got != 22 {
+		t.Errorf("LayerCount() = %d, want 22", got)
+	}
+}
+
+func TestPlacementPlan_Key(t *testing.T) {
+	tests := []struct {
+		plan PlacementPlan
+		want string
+	}{
+		{PlacementPlan{ModelID: "llama-7b"}, "llama-7b"},
+		{PlacementPlan{ModelID: "llama-7b", Replica: 2}, "llama-7b#2"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.plan.Key(); got != tt.want {
+			t.Errorf("Key() = %q, want %q", got, tt.want)
+		}
+	}
+
+	if got := (&Model{}).DesiredReplicas(); got != 1 {
+		t.Errorf("DesiredReplicas() = %d, want 1 when unset", got)
+	}
+}
+
+func TestNode_CompatibleWith(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        Node
+		b        Node
+		expected bool
+	}{
+		{"both unversioned", Node{}, Node{}, true},
+		{"same protocol", Node{ProtocolVersion: 2}, Node{ProtocolVersion: 2}, true},
+		{"different protocol", Node{ProtocolVersion: 1}, Node{ProtocolVersion: 2}, false},
+		{"overlapping ranges", Node{ProtocolVersion: 1}, Node{ProtocolVersion: 2, MinProtocolVersion: 1}, true},
+		{"disjoint ranges", Node{ProtocolVersion: 3, MinProtocolVersion: 3}, Node{ProtocolVersion: 2, MinProtocolVersion: 1}, false},
+		{"unversioned speaks protocol 1", Node{}, Node{ProtocolVersion: 2, MinProtocolVersion: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.CompatibleWith(&tt.b); got != tt.expected {
+				t.Errorf("CompatibleWith() = %v, want %v", got, tt.expected)
+			}
+			if got := tt.b.CompatibleWith(&tt.a); got != tt.expected {
+				t.Errorf("CompatibleWith() is not symmetric")
+			}
+		})
+	}
+}
+
+func TestNode_HasFeature(t *testing.T) {
+	node := Node{Features: []string{"replicated-state", "grpc-join"}}
+
+	if !node.HasFeature("grpc-join") {
+		t.Error("Expected node to have grpc-join feature")
+	}
+	if node.HasFeature("activation-stream") {
+		t.Error("Expected node not to have activation-stream feature")
+	}
+}
+
+func TestDistinctVersions(t *testing.T) {
+	nodes := []Node{
+		{ID: "node-1", Version: "b2"},
+		{ID: "node-2", Version: "a1"},
+		{ID: "node-3", Version: "b2"},
+		{ID: "node-4"},
+	}
+
+	versions := DistinctVersions(nodes)
+	if len(versions) != 2 || versions[0] != "a1" || versions[1] != "b2" {
+		t.Errorf("DistinctVersions() = %v, want [a1 b2]", versions)
+	}
+}
+
+func TestAlertProtoRoundTrip(t *testing.T) {
+	alert := Alert{
+		Name:        "HighCPU",
+		NodeID:      "node-1",
+		Severity:    "warning",
+		State:       AlertFiring,
+		Expr:        "cpu_usage_percent > 90",
+		Value:       95.5,
+		Summary:     "High CPU usage on node",
+		ActiveSince: time.Unix(1_800_000_000, 0),
+		UpdatedAt:   time.Unix(1_800_000_300, 0),
+	}
+	if got := AlertFromProto(alert.ToProto()); got != alert {
+		t.Errorf("Expected %+v, got %+v", alert, got)
+	}
+}
+
+func TestEventProtoRoundTrip(t *testing.T) {
+	event := Event{
+		Sequence:   42,
+		Time:       time.UnixMilli(1_800_000_000_123),
+		Type:       EventModelLoaded,
+		NodeID:     "node-1",
+		ModelID:    "llama-7b",
+		Message:    "Model loaded",
+		Attributes: map[string]string{"replica": "0"},
+	}
+	got := EventFromProto(event.ToProto())
+	if !reflect.DeepEqual(got, event) {
+		t.Errorf("Expected %+v, got %+v", event, got)
+	}
+}
@@ -0,0 +1,67 @@
+// Package tracing sets up OpenTelemetry trace export for agents. Spans are
+// batched and sent to an OTLP/gRPC collector; standard OTEL_EXPORTER_OTLP_*
+// environment variables such as headers and certificates apply as well.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ServiceName is the service agents report their spans under
+const ServiceName = "distributed-llm-agent"
+
+// Config says where spans are exported and how many traces are kept
+type Config struct {
+	// Endpoint is the collector's OTLP/gRPC address (host:port)
+	Endpoint string
+	// Insecure sends spans without TLS
+	Insecure bool
+	// SampleRatio is the fraction of new traces recorded; traces started
+	// by a caller follow the caller's decision
+	SampleRatio float64
+	// NodeID and Version identify the agent in its spans
+	NodeID  string
+	Version string
+}
+
+// NewProvider returns a tracer provider exporting to the configured
+// collector. Shut it down to flush the spans still buffered.
+func NewProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("no OTLP endpoint configured")
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio %v is outside [0, 1]", cfg.SampleRatio)
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	// The exporter connects lazily, so an unreachable collector doesn't
+	// hold up startup
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return newProvider(cfg, sdktrace.WithBatcher(exporter)), nil
+}
+
+// newProvider builds a provider around the exporting span processor
+func newProvider(cfg Config, processor sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		processor,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", ServiceName),
+			attribute.String("service.instance.id", cfg.NodeID),
+			attribute.String("service.version", cfg.Version),
+		)),
+	)
+}
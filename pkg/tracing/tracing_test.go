@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewProviderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"no endpoint", Config{SampleRatio: 1}, true},
+		{"ratio above 1", Config{Endpoint: "localhost:4317", SampleRatio: 1.5}, true},
+		{"negative ratio", Config{Endpoint: "localhost:4317", SampleRatio: -0.1}, true},
+		{"valid", Config{Endpoint: "localhost:4317", Insecure: true, SampleRatio: 0.5}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if provider != nil {
+				provider.Shutdown(context.Background())
+			}
+		})
+	}
+}
+
+func TestProviderSampling(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := newProvider(Config{SampleRatio: 0, NodeID: "node-a", Version: "v1.2.3"}, sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+	tracer := provider.Tracer("test")
+
+	// New traces are dropped at ratio 0
+	_, span := tracer.Start(context.Background(), "root")
+	span.End()
+	if got := len(exporter.GetSpans()); got != 0 {
+		t.Fatalf("Expected no spans at ratio 0, got %d", got)
+	}
+
+	// Traces the caller sampled are kept
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, span = tracer.Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "child")
+	span.End()
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Parent.TraceID() != parent.TraceID() {
+		t.Fatalf("Expected the caller's sampled trace to be kept, got %v", spans)
+	}
+
+	want := map[attribute.Key]string{
+		"service.name":        ServiceName,
+		"service.instance.id": "node-a",
+		"service.version":     "v1.2.3",
+	}
+	for _, attr := range spans[0].Resource.Attributes() {
+		if value, ok := want[attr.Key]; ok {
+			if attr.Value.AsString() != value {
+				t.Errorf("Expected %s=%s, got %s", attr.Key, value, attr.Value.AsString())
+			}
+			delete(want, attr.Key)
+		}
+	}
+	if len(want) != 0 {
+		t.Errorf("Missing resource attributes %v", want)
+	}
+}
@@ -23,13 +23,20 @@ const (
 
 // Messages for node registration
 type RegisterNodeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
-	Port          int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
-	Resources     *ResourceInfo          `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NodeId             string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Address            string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Port               int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	Resources          *ResourceInfo          `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
+	GossipPort         int32                  `protobuf:"varint,5,opt,name=gossip_port,json=gossipPort,proto3" json:"gossip_port,omitempty"`
+	SeedNodes          []string               `protobuf:"bytes,6,rep,name=seed_nodes,json=seedNodes,proto3" json:"seed_nodes,omitempty"`
+	Version            string                 `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`
+	ProtocolVersion    int32                  `protobuf:"varint,8,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	ClusterId          string                 `protobuf:"bytes,9,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	AuthToken          string                 `protobuf:"bytes,10,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	MinProtocolVersion int32                  `protobuf:"varint,11,opt,name=min_protocol_version,json=minProtocolVersion,proto3" json:"min_protocol_version,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *RegisterNodeRequest) Reset() {
@@ -90,10 +97,61 @@ func (x *RegisterNodeRequest) GetResources() *ResourceInfo {
 	return nil
 }
 
+func (x *RegisterNodeRequest) GetGossipPort() int32 {
+	if x != nil {
+		return x.GossipPort
+	}
+	return 0
+}
+
+func (x *RegisterNodeRequest) GetSeedNodes() []string {
+	if x != nil {
+		return x.SeedNodes
+	}
+	return nil
+}
+
+func (x *RegisterNodeRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *RegisterNodeRequest) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetAuthToken() string {
+	if x != nil {
+		return x.AuthToken
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetMinProtocolVersion() int32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
 type RegisterNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	ExistingNodes []*NodeInfo            `protobuf:"bytes,3,rep,name=existing_nodes,json=existingNodes,proto3" json:"existing_nodes,omitempty"`
+	ClusterId     string                 `protobuf:"bytes,4,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -142,6 +200,20 @@ func (x *RegisterNodeResponse) GetMessage() string {
 	return ""
 }
 
+func (x *RegisterNodeResponse) GetExistingNodes() []*NodeInfo {
+	if x != nil {
+		return x.ExistingNodes
+	}
+	return nil
+}
+
+func (x *RegisterNodeResponse) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
 // Resource information
 type ResourceInfo struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -452,8 +524,11 @@ type InferenceResponse struct {
 	ErrorMessage    string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
 	TokensGenerated int32                  `protobuf:"varint,4,opt,name=tokens_generated,json=tokensGenerated,proto3" json:"tokens_generated,omitempty"`
 	InferenceTimeMs float32                `protobuf:"fixed32,5,opt,name=inference_time_ms,json=inferenceTimeMs,proto3" json:"inference_time_ms,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	PromptTokens    int32                  `protobuf:"varint,6,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	// What each node of the pipeline spent on the request
+	Stages        []*StageUsage `protobuf:"bytes,7,rep,name=stages,proto3" json:"stages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InferenceResponse) Reset() {
@@ -521,28 +596,45 @@ func (x *InferenceResponse) GetInferenceTimeMs() float32 {
 	return 0
 }
 
-// Health checking
-type HealthCheckRequest struct {
+func (x *InferenceResponse) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *InferenceResponse) GetStages() []*StageUsage {
+	if x != nil {
+		return x.Stages
+	}
+	return nil
+}
+
+// StageUsage is the compute time and traffic of one node on a request
+type StageUsage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	ComputeMicros int64                  `protobuf:"varint,2,opt,name=compute_micros,json=computeMicros,proto3" json:"compute_micros,omitempty"`
+	BytesReceived int64                  `protobuf:"varint,3,opt,name=bytes_received,json=bytesReceived,proto3" json:"bytes_received,omitempty"`
+	BytesSent     int64                  `protobuf:"varint,4,opt,name=bytes_sent,json=bytesSent,proto3" json:"bytes_sent,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckRequest) Reset() {
-	*x = HealthCheckRequest{}
+func (x *StageUsage) Reset() {
+	*x = StageUsage{}
 	mi := &file_proto_node_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckRequest) String() string {
+func (x *StageUsage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckRequest) ProtoMessage() {}
+func (*StageUsage) ProtoMessage() {}
 
-func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+func (x *StageUsage) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -554,41 +646,70 @@ func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
-func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use StageUsage.ProtoReflect.Descriptor instead.
+func (*StageUsage) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *HealthCheckRequest) GetNodeId() string {
+func (x *StageUsage) GetNodeId() string {
 	if x != nil {
 		return x.NodeId
 	}
 	return ""
 }
 
-type HealthCheckResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
-	UptimeSeconds int64                  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+func (x *StageUsage) GetComputeMicros() int64 {
+	if x != nil {
+		return x.ComputeMicros
+	}
+	return 0
+}
+
+func (x *StageUsage) GetBytesReceived() int64 {
+	if x != nil {
+		return x.BytesReceived
+	}
+	return 0
+}
+
+func (x *StageUsage) GetBytesSent() int64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
+// ActivationFrame carries the hidden states of one request between
+// pipeline stages. The tensor is encoded in the framing of internal/tensor
+// (dtype, shape, quantization scale, CRC-32C checksum, raw elements).
+type ActivationFrame struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	RequestId string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ModelId   string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	// First layer the receiving stage applies to the activations
+	Layer    int32  `protobuf:"varint,3,opt,name=layer,proto3" json:"layer,omitempty"`
+	Sequence uint32 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Tensor   []byte `protobuf:"bytes,5,opt,name=tensor,proto3" json:"tensor,omitempty"`
+	// Time the stage spent computing the frame, set on its replies
+	ComputeMicros int64 `protobuf:"varint,6,opt,name=compute_micros,json=computeMicros,proto3" json:"compute_micros,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckResponse) Reset() {
-	*x = HealthCheckResponse{}
+func (x *ActivationFrame) Reset() {
+	*x = ActivationFrame{}
 	mi := &file_proto_node_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckResponse) String() string {
+func (x *ActivationFrame) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckResponse) ProtoMessage() {}
+func (*ActivationFrame) ProtoMessage() {}
 
-func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+func (x *ActivationFrame) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -600,54 +721,85 @@ func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
-func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ActivationFrame.ProtoReflect.Descriptor instead.
+func (*ActivationFrame) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *HealthCheckResponse) GetHealthy() bool {
+func (x *ActivationFrame) GetRequestId() string {
 	if x != nil {
-		return x.Healthy
+		return x.RequestId
 	}
-	return false
+	return ""
 }
 
-func (x *HealthCheckResponse) GetStatus() string {
+func (x *ActivationFrame) GetModelId() string {
 	if x != nil {
-		return x.Status
+		return x.ModelId
 	}
 	return ""
 }
 
-func (x *HealthCheckResponse) GetUptimeSeconds() int64 {
+func (x *ActivationFrame) GetLayer() int32 {
 	if x != nil {
-		return x.UptimeSeconds
+		return x.Layer
 	}
 	return 0
 }
 
-// Peer discovery messages
-type GetPeersRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+func (x *ActivationFrame) GetSequence() uint32 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ActivationFrame) GetTensor() []byte {
+	if x != nil {
+		return x.Tensor
+	}
+	return nil
+}
+
+func (x *ActivationFrame) GetComputeMicros() int64 {
+	if x != nil {
+		return x.ComputeMicros
+	}
+	return 0
+}
+
+// Usage ledger queries. Zero fields match everything; times are Unix
+// milliseconds and until is excluded.
+type UsageRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Tenant      string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	ModelId     string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	SinceUnixMs int64                  `protobuf:"varint,3,opt,name=since_unix_ms,json=sinceUnixMs,proto3" json:"since_unix_ms,omitempty"`
+	UntilUnixMs int64                  `protobuf:"varint,4,opt,name=until_unix_ms,json=untilUnixMs,proto3" json:"until_unix_ms,omitempty"`
+	// Newest records returned, at most 10000 and 0 for as many; exports
+	// without a limit hold every matching record. Summaries cover every
+	// matching record.
+	Limit int32 `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	// "csv" or "json" returns the records in export instead of records
+	Format        string `protobuf:"bytes,6,opt,name=format,proto3" json:"format,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetPeersRequest) Reset() {
-	*x = GetPeersRequest{}
+func (x *UsageRequest) Reset() {
+	*x = UsageRequest{}
 	mi := &file_proto_node_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetPeersRequest) String() string {
+func (x *UsageRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetPeersRequest) ProtoMessage() {}
+func (*UsageRequest) ProtoMessage() {}
 
-func (x *GetPeersRequest) ProtoReflect() protoreflect.Message {
+func (x *UsageRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -659,89 +811,83 @@ func (x *GetPeersRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetPeersRequest.ProtoReflect.Descriptor instead.
-func (*GetPeersRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UsageRequest.ProtoReflect.Descriptor instead.
+func (*UsageRequest) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *GetPeersRequest) GetNodeId() string {
+func (x *UsageRequest) GetTenant() string {
 	if x != nil {
-		return x.NodeId
+		return x.Tenant
 	}
 	return ""
 }
 
-type GetPeersResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Peers         []*NodeInfo            `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *GetPeersResponse) Reset() {
-	*x = GetPeersResponse{}
-	mi := &file_proto_node_proto_msgTypes[11]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *UsageRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
 }
 
-func (x *GetPeersResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *UsageRequest) GetSinceUnixMs() int64 {
+	if x != nil {
+		return x.SinceUnixMs
+	}
+	return 0
 }
 
-func (*GetPeersResponse) ProtoMessage() {}
-
-func (x *GetPeersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[11]
+func (x *UsageRequest) GetUntilUnixMs() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.UntilUnixMs
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use GetPeersResponse.ProtoReflect.Descriptor instead.
-func (*GetPeersResponse) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{11}
+func (x *UsageRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
 }
 
-func (x *GetPeersResponse) GetPeers() []*NodeInfo {
+func (x *UsageRequest) GetFormat() string {
 	if x != nil {
-		return x.Peers
+		return x.Format
 	}
-	return nil
+	return ""
 }
 
-type NodeInfo struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
-	Port          int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
-	Resources     *ResourceInfo          `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
-	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
-	LastSeen      int64                  `protobuf:"varint,6,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type UsageRecord struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	TimestampUnixMs  int64                  `protobuf:"varint,1,opt,name=timestamp_unix_ms,json=timestampUnixMs,proto3" json:"timestamp_unix_ms,omitempty"`
+	RequestId        string                 `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Tenant           string                 `protobuf:"bytes,3,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	ModelId          string                 `protobuf:"bytes,4,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	PromptTokens     int32                  `protobuf:"varint,5,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int32                  `protobuf:"varint,6,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	DurationMicros   int64                  `protobuf:"varint,7,opt,name=duration_micros,json=durationMicros,proto3" json:"duration_micros,omitempty"`
+	Success          bool                   `protobuf:"varint,8,opt,name=success,proto3" json:"success,omitempty"`
+	Nodes            []*StageUsage          `protobuf:"bytes,9,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *NodeInfo) Reset() {
-	*x = NodeInfo{}
-	mi := &file_proto_node_proto_msgTypes[12]
+func (x *UsageRecord) Reset() {
+	*x = UsageRecord{}
+	mi := &file_proto_node_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NodeInfo) String() string {
+func (x *UsageRecord) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodeInfo) ProtoMessage() {}
+func (*UsageRecord) ProtoMessage() {}
 
-func (x *NodeInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[12]
+func (x *UsageRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -752,77 +898,104 @@ func (x *NodeInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodeInfo.ProtoReflect.Descriptor instead.
-func (*NodeInfo) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use UsageRecord.ProtoReflect.Descriptor instead.
+func (*UsageRecord) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *NodeInfo) GetNodeId() string {
+func (x *UsageRecord) GetTimestampUnixMs() int64 {
 	if x != nil {
-		return x.NodeId
+		return x.TimestampUnixMs
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
 	}
 	return ""
 }
 
-func (x *NodeInfo) GetAddress() string {
+func (x *UsageRecord) GetTenant() string {
 	if x != nil {
-		return x.Address
+		return x.Tenant
 	}
 	return ""
 }
 
-func (x *NodeInfo) GetPort() int32 {
+func (x *UsageRecord) GetModelId() string {
 	if x != nil {
-		return x.Port
+		return x.ModelId
 	}
-	return 0
+	return ""
 }
 
-func (x *NodeInfo) GetResources() *ResourceInfo {
+func (x *UsageRecord) GetPromptTokens() int32 {
 	if x != nil {
-		return x.Resources
+		return x.PromptTokens
 	}
-	return nil
+	return 0
 }
 
-func (x *NodeInfo) GetStatus() string {
+func (x *UsageRecord) GetCompletionTokens() int32 {
 	if x != nil {
-		return x.Status
+		return x.CompletionTokens
 	}
-	return ""
+	return 0
 }
 
-func (x *NodeInfo) GetLastSeen() int64 {
+func (x *UsageRecord) GetDurationMicros() int64 {
 	if x != nil {
-		return x.LastSeen
+		return x.DurationMicros
 	}
 	return 0
 }
 
-// Discovery service messages
-type DiscoveryRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RequesterId   string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
-	KnownNodes    []string               `protobuf:"bytes,2,rep,name=known_nodes,json=knownNodes,proto3" json:"known_nodes,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *UsageRecord) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
 }
 
-func (x *DiscoveryRequest) Reset() {
-	*x = DiscoveryRequest{}
-	mi := &file_proto_node_proto_msgTypes[13]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *UsageRecord) GetNodes() []*StageUsage {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
 }
 
-func (x *DiscoveryRequest) String() string {
+// UsageSummary totals the matching records of a tenant on a model
+type UsageSummary struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Tenant           string                 `protobuf:"bytes,1,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	ModelId          string                 `protobuf:"bytes,2,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Requests         int64                  `protobuf:"varint,3,opt,name=requests,proto3" json:"requests,omitempty"`
+	Failed           int64                  `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	PromptTokens     int64                  `protobuf:"varint,5,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int64                  `protobuf:"varint,6,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	ComputeMicros    int64                  `protobuf:"varint,7,opt,name=compute_micros,json=computeMicros,proto3" json:"compute_micros,omitempty"`
+	BytesMoved       int64                  `protobuf:"varint,8,opt,name=bytes_moved,json=bytesMoved,proto3" json:"bytes_moved,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UsageSummary) Reset() {
+	*x = UsageSummary{}
+	mi := &file_proto_node_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageSummary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DiscoveryRequest) ProtoMessage() {}
+func (*UsageSummary) ProtoMessage() {}
 
-func (x *DiscoveryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[13]
+func (x *UsageSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -833,49 +1006,91 @@ func (x *DiscoveryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DiscoveryRequest.ProtoReflect.Descriptor instead.
-func (*DiscoveryRequest) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use UsageSummary.ProtoReflect.Descriptor instead.
+func (*UsageSummary) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *DiscoveryRequest) GetRequesterId() string {
+func (x *UsageSummary) GetTenant() string {
 	if x != nil {
-		return x.RequesterId
+		return x.Tenant
 	}
 	return ""
 }
 
-func (x *DiscoveryRequest) GetKnownNodes() []string {
+func (x *UsageSummary) GetModelId() string {
 	if x != nil {
-		return x.KnownNodes
+		return x.ModelId
 	}
-	return nil
+	return ""
 }
 
-type DiscoveryResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	DiscoveredNodes []*NodeInfo            `protobuf:"bytes,1,rep,name=discovered_nodes,json=discoveredNodes,proto3" json:"discovered_nodes,omitempty"`
-	Success         bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
-	Message         string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *UsageSummary) GetRequests() int64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
 }
 
-func (x *DiscoveryResponse) Reset() {
-	*x = DiscoveryResponse{}
-	mi := &file_proto_node_proto_msgTypes[14]
+func (x *UsageSummary) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *UsageSummary) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *UsageSummary) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *UsageSummary) GetComputeMicros() int64 {
+	if x != nil {
+		return x.ComputeMicros
+	}
+	return 0
+}
+
+func (x *UsageSummary) GetBytesMoved() int64 {
+	if x != nil {
+		return x.BytesMoved
+	}
+	return 0
+}
+
+type UsageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*UsageRecord         `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	Summaries     []*UsageSummary        `protobuf:"bytes,2,rep,name=summaries,proto3" json:"summaries,omitempty"`
+	Export        []byte                 `protobuf:"bytes,3,opt,name=export,proto3" json:"export,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageResponse) Reset() {
+	*x = UsageResponse{}
+	mi := &file_proto_node_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DiscoveryResponse) String() string {
+func (x *UsageResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DiscoveryResponse) ProtoMessage() {}
+func (*UsageResponse) ProtoMessage() {}
 
-func (x *DiscoveryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[14]
+func (x *UsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -886,57 +1101,102 @@ func (x *DiscoveryResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DiscoveryResponse.ProtoReflect.Descriptor instead.
-func (*DiscoveryResponse) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use UsageResponse.ProtoReflect.Descriptor instead.
+func (*UsageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *DiscoveryResponse) GetDiscoveredNodes() []*NodeInfo {
+func (x *UsageResponse) GetRecords() []*UsageRecord {
 	if x != nil {
-		return x.DiscoveredNodes
+		return x.Records
 	}
 	return nil
 }
 
-func (x *DiscoveryResponse) GetSuccess() bool {
+func (x *UsageResponse) GetSummaries() []*UsageSummary {
 	if x != nil {
-		return x.Success
+		return x.Summaries
 	}
-	return false
+	return nil
 }
 
-func (x *DiscoveryResponse) GetMessage() string {
+func (x *UsageResponse) GetExport() []byte {
 	if x != nil {
-		return x.Message
+		return x.Export
 	}
-	return ""
+	return nil
 }
 
-type ClusterJoinRequest struct {
+// Health checking
+type HealthCheckRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
-	Port          int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
-	Resources     *ResourceInfo          `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
-	SeedNodes     []string               `protobuf:"bytes,5,rep,name=seed_nodes,json=seedNodes,proto3" json:"seed_nodes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ClusterJoinRequest) Reset() {
-	*x = ClusterJoinRequest{}
+func (x *HealthCheckRequest) Reset() {
+	*x = HealthCheckRequest{}
+	mi := &file_proto_node_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckRequest) ProtoMessage() {}
+
+func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *HealthCheckRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type HealthCheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	UptimeSeconds int64                  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	BackendReady  bool                   `protobuf:"varint,4,opt,name=backend_ready,json=backendReady,proto3" json:"backend_ready,omitempty"`
+	Models        []*ModelReadiness      `protobuf:"bytes,5,rep,name=models,proto3" json:"models,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckResponse) Reset() {
+	*x = HealthCheckResponse{}
 	mi := &file_proto_node_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClusterJoinRequest) String() string {
+func (x *HealthCheckResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClusterJoinRequest) ProtoMessage() {}
+func (*HealthCheckResponse) ProtoMessage() {}
 
-func (x *ClusterJoinRequest) ProtoReflect() protoreflect.Message {
+func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -948,70 +1208,69 @@ func (x *ClusterJoinRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClusterJoinRequest.ProtoReflect.Descriptor instead.
-func (*ClusterJoinRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *ClusterJoinRequest) GetNodeId() string {
+func (x *HealthCheckResponse) GetHealthy() bool {
 	if x != nil {
-		return x.NodeId
+		return x.Healthy
 	}
-	return ""
+	return false
 }
 
-func (x *ClusterJoinRequest) GetAddress() string {
+func (x *HealthCheckResponse) GetStatus() string {
 	if x != nil {
-		return x.Address
+		return x.Status
 	}
 	return ""
 }
 
-func (x *ClusterJoinRequest) GetPort() int32 {
+func (x *HealthCheckResponse) GetUptimeSeconds() int64 {
 	if x != nil {
-		return x.Port
+		return x.UptimeSeconds
 	}
 	return 0
 }
 
-func (x *ClusterJoinRequest) GetResources() *ResourceInfo {
+func (x *HealthCheckResponse) GetBackendReady() bool {
 	if x != nil {
-		return x.Resources
+		return x.BackendReady
 	}
-	return nil
+	return false
 }
 
-func (x *ClusterJoinRequest) GetSeedNodes() []string {
+func (x *HealthCheckResponse) GetModels() []*ModelReadiness {
 	if x != nil {
-		return x.SeedNodes
+		return x.Models
 	}
 	return nil
 }
 
-type ClusterJoinResponse struct {
+type ModelReadiness struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	ExistingNodes []*NodeInfo            `protobuf:"bytes,3,rep,name=existing_nodes,json=existingNodes,proto3" json:"existing_nodes,omitempty"`
-	ClusterId     string                 `protobuf:"bytes,4,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ClusterJoinResponse) Reset() {
-	*x = ClusterJoinResponse{}
+func (x *ModelReadiness) Reset() {
+	*x = ModelReadiness{}
 	mi := &file_proto_node_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClusterJoinResponse) String() string {
+func (x *ModelReadiness) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClusterJoinResponse) ProtoMessage() {}
+func (*ModelReadiness) ProtoMessage() {}
 
-func (x *ClusterJoinResponse) ProtoReflect() protoreflect.Message {
+func (x *ModelReadiness) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1023,61 +1282,54 @@ func (x *ClusterJoinResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClusterJoinResponse.ProtoReflect.Descriptor instead.
-func (*ClusterJoinResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ModelReadiness.ProtoReflect.Descriptor instead.
+func (*ModelReadiness) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *ClusterJoinResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
-}
-
-func (x *ClusterJoinResponse) GetMessage() string {
+func (x *ModelReadiness) GetModelId() string {
 	if x != nil {
-		return x.Message
+		return x.ModelId
 	}
 	return ""
 }
 
-func (x *ClusterJoinResponse) GetExistingNodes() []*NodeInfo {
+func (x *ModelReadiness) GetState() string {
 	if x != nil {
-		return x.ExistingNodes
+		return x.State
 	}
-	return nil
+	return ""
 }
 
-func (x *ClusterJoinResponse) GetClusterId() string {
+func (x *ModelReadiness) GetError() string {
 	if x != nil {
-		return x.ClusterId
+		return x.Error
 	}
 	return ""
 }
 
-type ClusterLeaveRequest struct {
+// Version negotiation messages
+type GetVersionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ClusterLeaveRequest) Reset() {
-	*x = ClusterLeaveRequest{}
+func (x *GetVersionRequest) Reset() {
+	*x = GetVersionRequest{}
 	mi := &file_proto_node_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClusterLeaveRequest) String() string {
+func (x *GetVersionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClusterLeaveRequest) ProtoMessage() {}
+func (*GetVersionRequest) ProtoMessage() {}
 
-func (x *ClusterLeaveRequest) ProtoReflect() protoreflect.Message {
+func (x *GetVersionRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1089,47 +1341,43 @@ func (x *ClusterLeaveRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClusterLeaveRequest.ProtoReflect.Descriptor instead.
-func (*ClusterLeaveRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetVersionRequest) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *ClusterLeaveRequest) GetNodeId() string {
+func (x *GetVersionRequest) GetNodeId() string {
 	if x != nil {
 		return x.NodeId
 	}
 	return ""
 }
 
-func (x *ClusterLeaveRequest) GetReason() string {
-	if x != nil {
-		return x.Reason
-	}
-	return ""
-}
-
-type ClusterLeaveResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type GetVersionResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NodeId             string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Version            string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	ProtocolVersion    int32                  `protobuf:"varint,3,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	MinProtocolVersion int32                  `protobuf:"varint,4,opt,name=min_protocol_version,json=minProtocolVersion,proto3" json:"min_protocol_version,omitempty"`
+	Features           []string               `protobuf:"bytes,5,rep,name=features,proto3" json:"features,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
-func (x *ClusterLeaveResponse) Reset() {
-	*x = ClusterLeaveResponse{}
+func (x *GetVersionResponse) Reset() {
+	*x = GetVersionResponse{}
 	mi := &file_proto_node_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClusterLeaveResponse) String() string {
+func (x *GetVersionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClusterLeaveResponse) ProtoMessage() {}
+func (*GetVersionResponse) ProtoMessage() {}
 
-func (x *ClusterLeaveResponse) ProtoReflect() protoreflect.Message {
+func (x *GetVersionResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1141,46 +1389,68 @@ func (x *ClusterLeaveResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClusterLeaveResponse.ProtoReflect.Descriptor instead.
-func (*ClusterLeaveResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetVersionResponse) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *ClusterLeaveResponse) GetSuccess() bool {
+func (x *GetVersionResponse) GetNodeId() string {
 	if x != nil {
-		return x.Success
+		return x.NodeId
 	}
-	return false
+	return ""
 }
 
-func (x *ClusterLeaveResponse) GetMessage() string {
+func (x *GetVersionResponse) GetVersion() string {
 	if x != nil {
-		return x.Message
+		return x.Version
 	}
 	return ""
 }
 
-type ClusterInfoRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RequesterId   string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *GetVersionResponse) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
 }
 
-func (x *ClusterInfoRequest) Reset() {
-	*x = ClusterInfoRequest{}
-	mi := &file_proto_node_proto_msgTypes[19]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+func (x *GetVersionResponse) GetMinProtocolVersion() int32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
+func (x *GetVersionResponse) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+// Latency topology messages
+type LatencyMatrixRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequesterId   string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatencyMatrixRequest) Reset() {
+	*x = LatencyMatrixRequest{}
+	mi := &file_proto_node_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClusterInfoRequest) String() string {
+func (x *LatencyMatrixRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClusterInfoRequest) ProtoMessage() {}
+func (*LatencyMatrixRequest) ProtoMessage() {}
 
-func (x *ClusterInfoRequest) ProtoReflect() protoreflect.Message {
+func (x *LatencyMatrixRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1192,42 +1462,39 @@ func (x *ClusterInfoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClusterInfoRequest.ProtoReflect.Descriptor instead.
-func (*ClusterInfoRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use LatencyMatrixRequest.ProtoReflect.Descriptor instead.
+func (*LatencyMatrixRequest) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *ClusterInfoRequest) GetRequesterId() string {
+func (x *LatencyMatrixRequest) GetRequesterId() string {
 	if x != nil {
 		return x.RequesterId
 	}
 	return ""
 }
 
-type ClusterInfoResponse struct {
+type LatencyMatrixResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ClusterId     string                 `protobuf:"bytes,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
-	Nodes         []*NodeInfo            `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
-	Models        []*ModelInfo           `protobuf:"bytes,3,rep,name=models,proto3" json:"models,omitempty"`
-	Metrics       *ClusterMetrics        `protobuf:"bytes,4,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Entries       []*LatencyEntry        `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ClusterInfoResponse) Reset() {
-	*x = ClusterInfoResponse{}
+func (x *LatencyMatrixResponse) Reset() {
+	*x = LatencyMatrixResponse{}
 	mi := &file_proto_node_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClusterInfoResponse) String() string {
+func (x *LatencyMatrixResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClusterInfoResponse) ProtoMessage() {}
+func (*LatencyMatrixResponse) ProtoMessage() {}
 
-func (x *ClusterInfoResponse) ProtoReflect() protoreflect.Message {
+func (x *LatencyMatrixResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_node_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1239,68 +1506,1589 @@ func (x *ClusterInfoResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClusterInfoResponse.ProtoReflect.Descriptor instead.
-func (*ClusterInfoResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use LatencyMatrixResponse.ProtoReflect.Descriptor instead.
+func (*LatencyMatrixResponse) Descriptor() ([]byte, []int) {
 	return file_proto_node_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *ClusterInfoResponse) GetClusterId() string {
+func (x *LatencyMatrixResponse) GetEntries() []*LatencyEntry {
 	if x != nil {
-		return x.ClusterId
+		return x.Entries
+	}
+	return nil
+}
+
+// LatencyEntry is the path from one node to another. Estimated entries come
+// from network coordinates rather than measurements.
+type LatencyEntry struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	FromNode                string                 `protobuf:"bytes,1,opt,name=from_node,json=fromNode,proto3" json:"from_node,omitempty"`
+	ToNode                  string                 `protobuf:"bytes,2,opt,name=to_node,json=toNode,proto3" json:"to_node,omitempty"`
+	RttMs                   float64                `protobuf:"fixed64,3,opt,name=rtt_ms,json=rttMs,proto3" json:"rtt_ms,omitempty"`
+	BandwidthBytesPerSecond float64                `protobuf:"fixed64,4,opt,name=bandwidth_bytes_per_second,json=bandwidthBytesPerSecond,proto3" json:"bandwidth_bytes_per_second,omitempty"`
+	Estimated               bool                   `protobuf:"varint,5,opt,name=estimated,proto3" json:"estimated,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *LatencyEntry) Reset() {
+	*x = LatencyEntry{}
+	mi := &file_proto_node_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatencyEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencyEntry) ProtoMessage() {}
+
+func (x *LatencyEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencyEntry.ProtoReflect.Descriptor instead.
+func (*LatencyEntry) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *LatencyEntry) GetFromNode() string {
+	if x != nil {
+		return x.FromNode
 	}
 	return ""
 }
 
-func (x *ClusterInfoResponse) GetNodes() []*NodeInfo {
+func (x *LatencyEntry) GetToNode() string {
 	if x != nil {
-		return x.Nodes
+		return x.ToNode
 	}
-	return nil
+	return ""
 }
 
-func (x *ClusterInfoResponse) GetModels() []*ModelInfo {
+func (x *LatencyEntry) GetRttMs() float64 {
 	if x != nil {
-		return x.Models
+		return x.RttMs
 	}
-	return nil
+	return 0
 }
 
-func (x *ClusterInfoResponse) GetMetrics() *ClusterMetrics {
+func (x *LatencyEntry) GetBandwidthBytesPerSecond() float64 {
 	if x != nil {
-		return x.Metrics
+		return x.BandwidthBytesPerSecond
+	}
+	return 0
+}
+
+func (x *LatencyEntry) GetEstimated() bool {
+	if x != nil {
+		return x.Estimated
+	}
+	return false
+}
+
+// ProbeRequest carries a payload whose transfer time estimates bandwidth
+type ProbeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeRequest) Reset() {
+	*x = ProbeRequest{}
+	mi := &file_proto_node_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeRequest) ProtoMessage() {}
+
+func (x *ProbeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeRequest.ProtoReflect.Descriptor instead.
+func (*ProbeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ProbeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ProbeRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
 	}
 	return nil
 }
 
-// Model information
-type ModelInfo struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Version         string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
-	LayerCount      int32                  `protobuf:"varint,4,opt,name=layer_count,json=layerCount,proto3" json:"layer_count,omitempty"`
-	FilePath        string                 `protobuf:"bytes,5,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
-	SizeBytes       int64                  `protobuf:"varint,6,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
-	NodeAssignments []string               `protobuf:"bytes,7,rep,name=node_assignments,json=nodeAssignments,proto3" json:"node_assignments,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type ProbeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	ReceivedBytes int64                  `protobuf:"varint,2,opt,name=received_bytes,json=receivedBytes,proto3" json:"received_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ModelInfo) Reset() {
-	*x = ModelInfo{}
-	mi := &file_proto_node_proto_msgTypes[21]
+func (x *ProbeResponse) Reset() {
+	*x = ProbeResponse{}
+	mi := &file_proto_node_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeResponse) ProtoMessage() {}
+
+func (x *ProbeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeResponse.ProtoReflect.Descriptor instead.
+func (*ProbeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ProbeResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ProbeResponse) GetReceivedBytes() int64 {
+	if x != nil {
+		return x.ReceivedBytes
+	}
+	return 0
+}
+
+// Peer discovery messages
+type GetPeersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPeersRequest) Reset() {
+	*x = GetPeersRequest{}
+	mi := &file_proto_node_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPeersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPeersRequest) ProtoMessage() {}
+
+func (x *GetPeersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPeersRequest.ProtoReflect.Descriptor instead.
+func (*GetPeersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetPeersRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type GetPeersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peers         []*NodeInfo            `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPeersResponse) Reset() {
+	*x = GetPeersResponse{}
+	mi := &file_proto_node_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPeersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPeersResponse) ProtoMessage() {}
+
+func (x *GetPeersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPeersResponse.ProtoReflect.Descriptor instead.
+func (*GetPeersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetPeersResponse) GetPeers() []*NodeInfo {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+type NodeInfo struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NodeId             string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Address            string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Port               int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	Resources          *ResourceInfo          `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
+	Status             string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	LastSeen           int64                  `protobuf:"varint,6,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	Version            string                 `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`
+	ProtocolVersion    int32                  `protobuf:"varint,8,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	MinProtocolVersion int32                  `protobuf:"varint,9,opt,name=min_protocol_version,json=minProtocolVersion,proto3" json:"min_protocol_version,omitempty"`
+	Features           []string               `protobuf:"bytes,10,rep,name=features,proto3" json:"features,omitempty"`
+	Labels             map[string]string      `protobuf:"bytes,11,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *NodeInfo) Reset() {
+	*x = NodeInfo{}
+	mi := &file_proto_node_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeInfo) ProtoMessage() {}
+
+func (x *NodeInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeInfo.ProtoReflect.Descriptor instead.
+func (*NodeInfo) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *NodeInfo) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetResources() *ResourceInfo {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *NodeInfo) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetLastSeen() int64 {
+	if x != nil {
+		return x.LastSeen
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetMinProtocolVersion() int32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *NodeInfo) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// Discovery service messages
+type DiscoveryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequesterId   string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
+	KnownNodes    []string               `protobuf:"bytes,2,rep,name=known_nodes,json=knownNodes,proto3" json:"known_nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiscoveryRequest) Reset() {
+	*x = DiscoveryRequest{}
+	mi := &file_proto_node_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoveryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoveryRequest) ProtoMessage() {}
+
+func (x *DiscoveryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoveryRequest.ProtoReflect.Descriptor instead.
+func (*DiscoveryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *DiscoveryRequest) GetRequesterId() string {
+	if x != nil {
+		return x.RequesterId
+	}
+	return ""
+}
+
+func (x *DiscoveryRequest) GetKnownNodes() []string {
+	if x != nil {
+		return x.KnownNodes
+	}
+	return nil
+}
+
+type DiscoveryResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DiscoveredNodes []*NodeInfo            `protobuf:"bytes,1,rep,name=discovered_nodes,json=discoveredNodes,proto3" json:"discovered_nodes,omitempty"`
+	Success         bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Message         string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DiscoveryResponse) Reset() {
+	*x = DiscoveryResponse{}
+	mi := &file_proto_node_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoveryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoveryResponse) ProtoMessage() {}
+
+func (x *DiscoveryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoveryResponse.ProtoReflect.Descriptor instead.
+func (*DiscoveryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DiscoveryResponse) GetDiscoveredNodes() []*NodeInfo {
+	if x != nil {
+		return x.DiscoveredNodes
+	}
+	return nil
+}
+
+func (x *DiscoveryResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DiscoveryResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ClusterJoinRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	NodeId             string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Address            string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Port               int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	Resources          *ResourceInfo          `protobuf:"bytes,4,opt,name=resources,proto3" json:"resources,omitempty"`
+	SeedNodes          []string               `protobuf:"bytes,5,rep,name=seed_nodes,json=seedNodes,proto3" json:"seed_nodes,omitempty"`
+	GossipPort         int32                  `protobuf:"varint,6,opt,name=gossip_port,json=gossipPort,proto3" json:"gossip_port,omitempty"`
+	Version            string                 `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`
+	ProtocolVersion    int32                  `protobuf:"varint,8,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	ClusterId          string                 `protobuf:"bytes,9,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	AuthToken          string                 `protobuf:"bytes,10,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	MinProtocolVersion int32                  `protobuf:"varint,11,opt,name=min_protocol_version,json=minProtocolVersion,proto3" json:"min_protocol_version,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ClusterJoinRequest) Reset() {
+	*x = ClusterJoinRequest{}
+	mi := &file_proto_node_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterJoinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterJoinRequest) ProtoMessage() {}
+
+func (x *ClusterJoinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterJoinRequest.ProtoReflect.Descriptor instead.
+func (*ClusterJoinRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ClusterJoinRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ClusterJoinRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ClusterJoinRequest) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *ClusterJoinRequest) GetResources() *ResourceInfo {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *ClusterJoinRequest) GetSeedNodes() []string {
+	if x != nil {
+		return x.SeedNodes
+	}
+	return nil
+}
+
+func (x *ClusterJoinRequest) GetGossipPort() int32 {
+	if x != nil {
+		return x.GossipPort
+	}
+	return 0
+}
+
+func (x *ClusterJoinRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ClusterJoinRequest) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *ClusterJoinRequest) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *ClusterJoinRequest) GetAuthToken() string {
+	if x != nil {
+		return x.AuthToken
+	}
+	return ""
+}
+
+func (x *ClusterJoinRequest) GetMinProtocolVersion() int32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
+type ClusterJoinResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	ExistingNodes []*NodeInfo            `protobuf:"bytes,3,rep,name=existing_nodes,json=existingNodes,proto3" json:"existing_nodes,omitempty"`
+	ClusterId     string                 `protobuf:"bytes,4,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterJoinResponse) Reset() {
+	*x = ClusterJoinResponse{}
+	mi := &file_proto_node_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterJoinResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterJoinResponse) ProtoMessage() {}
+
+func (x *ClusterJoinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterJoinResponse.ProtoReflect.Descriptor instead.
+func (*ClusterJoinResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ClusterJoinResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ClusterJoinResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ClusterJoinResponse) GetExistingNodes() []*NodeInfo {
+	if x != nil {
+		return x.ExistingNodes
+	}
+	return nil
+}
+
+func (x *ClusterJoinResponse) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+type ClusterLeaveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterLeaveRequest) Reset() {
+	*x = ClusterLeaveRequest{}
+	mi := &file_proto_node_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterLeaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterLeaveRequest) ProtoMessage() {}
+
+func (x *ClusterLeaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterLeaveRequest.ProtoReflect.Descriptor instead.
+func (*ClusterLeaveRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ClusterLeaveRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ClusterLeaveRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ClusterLeaveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterLeaveResponse) Reset() {
+	*x = ClusterLeaveResponse{}
+	mi := &file_proto_node_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterLeaveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterLeaveResponse) ProtoMessage() {}
+
+func (x *ClusterLeaveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterLeaveResponse.ProtoReflect.Descriptor instead.
+func (*ClusterLeaveResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ClusterLeaveResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ClusterLeaveResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ClusterInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequesterId   string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterInfoRequest) Reset() {
+	*x = ClusterInfoRequest{}
+	mi := &file_proto_node_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterInfoRequest) ProtoMessage() {}
+
+func (x *ClusterInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterInfoRequest.ProtoReflect.Descriptor instead.
+func (*ClusterInfoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ClusterInfoRequest) GetRequesterId() string {
+	if x != nil {
+		return x.RequesterId
+	}
+	return ""
+}
+
+type ClusterInfoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClusterId     string                 `protobuf:"bytes,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	Nodes         []*NodeInfo            `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Models        []*ModelInfo           `protobuf:"bytes,3,rep,name=models,proto3" json:"models,omitempty"`
+	Metrics       *ClusterMetrics        `protobuf:"bytes,4,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Replicas      []*ReplicaStats        `protobuf:"bytes,5,rep,name=replicas,proto3" json:"replicas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterInfoResponse) Reset() {
+	*x = ClusterInfoResponse{}
+	mi := &file_proto_node_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterInfoResponse) ProtoMessage() {}
+
+func (x *ClusterInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterInfoResponse.ProtoReflect.Descriptor instead.
+func (*ClusterInfoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ClusterInfoResponse) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *ClusterInfoResponse) GetNodes() []*NodeInfo {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *ClusterInfoResponse) GetModels() []*ModelInfo {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+func (x *ClusterInfoResponse) GetMetrics() *ClusterMetrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *ClusterInfoResponse) GetReplicas() []*ReplicaStats {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+// Load and health of a model replica, as seen by the answering node's router
+type ReplicaStats struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ModelId             string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Replica             int32                  `protobuf:"varint,2,opt,name=replica,proto3" json:"replica,omitempty"`
+	NodeIds             []string               `protobuf:"bytes,3,rep,name=node_ids,json=nodeIds,proto3" json:"node_ids,omitempty"`
+	Healthy             bool                   `protobuf:"varint,4,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	OutstandingRequests int32                  `protobuf:"varint,5,opt,name=outstanding_requests,json=outstandingRequests,proto3" json:"outstanding_requests,omitempty"`
+	ServedRequests      uint64                 `protobuf:"varint,6,opt,name=served_requests,json=servedRequests,proto3" json:"served_requests,omitempty"`
+	FailedRequests      uint64                 `protobuf:"varint,7,opt,name=failed_requests,json=failedRequests,proto3" json:"failed_requests,omitempty"`
+	AvgLatencyMs        float64                `protobuf:"fixed64,8,opt,name=avg_latency_ms,json=avgLatencyMs,proto3" json:"avg_latency_ms,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ReplicaStats) Reset() {
+	*x = ReplicaStats{}
+	mi := &file_proto_node_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaStats) ProtoMessage() {}
+
+func (x *ReplicaStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaStats.ProtoReflect.Descriptor instead.
+func (*ReplicaStats) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ReplicaStats) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *ReplicaStats) GetReplica() int32 {
+	if x != nil {
+		return x.Replica
+	}
+	return 0
+}
+
+func (x *ReplicaStats) GetNodeIds() []string {
+	if x != nil {
+		return x.NodeIds
+	}
+	return nil
+}
+
+func (x *ReplicaStats) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *ReplicaStats) GetOutstandingRequests() int32 {
+	if x != nil {
+		return x.OutstandingRequests
+	}
+	return 0
+}
+
+func (x *ReplicaStats) GetServedRequests() uint64 {
+	if x != nil {
+		return x.ServedRequests
+	}
+	return 0
+}
+
+func (x *ReplicaStats) GetFailedRequests() uint64 {
+	if x != nil {
+		return x.FailedRequests
+	}
+	return 0
+}
+
+func (x *ReplicaStats) GetAvgLatencyMs() float64 {
+	if x != nil {
+		return x.AvgLatencyMs
+	}
+	return 0
+}
+
+// Model information
+type ModelInfo struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Version         string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	LayerCount      int32                  `protobuf:"varint,4,opt,name=layer_count,json=layerCount,proto3" json:"layer_count,omitempty"`
+	FilePath        string                 `protobuf:"bytes,5,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	SizeBytes       int64                  `protobuf:"varint,6,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	NodeAssignments []string               `protobuf:"bytes,7,rep,name=node_assignments,json=nodeAssignments,proto3" json:"node_assignments,omitempty"`
+	Replicas        int32                  `protobuf:"varint,8,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ModelInfo) Reset() {
+	*x = ModelInfo{}
+	mi := &file_proto_node_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelInfo) ProtoMessage() {}
+
+func (x *ModelInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelInfo.ProtoReflect.Descriptor instead.
+func (*ModelInfo) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ModelInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetLayerCount() int32 {
+	if x != nil {
+		return x.LayerCount
+	}
+	return 0
+}
+
+func (x *ModelInfo) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *ModelInfo) GetNodeAssignments() []string {
+	if x != nil {
+		return x.NodeAssignments
+	}
+	return nil
+}
+
+func (x *ModelInfo) GetReplicas() int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return 0
+}
+
+// Metrics messages
+type GetMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	MetricTypes   []string               `protobuf:"bytes,2,rep,name=metric_types,json=metricTypes,proto3" json:"metric_types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetricsRequest) Reset() {
+	*x = GetMetricsRequest{}
+	mi := &file_proto_node_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricsRequest) ProtoMessage() {}
+
+func (x *GetMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricsRequest.ProtoReflect.Descriptor instead.
+func (*GetMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *GetMetricsRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *GetMetricsRequest) GetMetricTypes() []string {
+	if x != nil {
+		return x.MetricTypes
+	}
+	return nil
+}
+
+type GetMetricsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metrics       *NodeMetrics           `protobuf:"bytes,1,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetricsResponse) Reset() {
+	*x = GetMetricsResponse{}
+	mi := &file_proto_node_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricsResponse) ProtoMessage() {}
+
+func (x *GetMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricsResponse.ProtoReflect.Descriptor instead.
+func (*GetMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *GetMetricsResponse) GetMetrics() *NodeMetrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type StreamMetricsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	NodeId          string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	IntervalSeconds int32                  `protobuf:"varint,2,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	MetricTypes     []string               `protobuf:"bytes,3,rep,name=metric_types,json=metricTypes,proto3" json:"metric_types,omitempty"`
+	// Metrics recorded over this many seconds before subscribing are sent
+	// first, one per interval
+	BackfillSeconds int32 `protobuf:"varint,4,opt,name=backfill_seconds,json=backfillSeconds,proto3" json:"backfill_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamMetricsRequest) Reset() {
+	*x = StreamMetricsRequest{}
+	mi := &file_proto_node_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamMetricsRequest) ProtoMessage() {}
+
+func (x *StreamMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamMetricsRequest.ProtoReflect.Descriptor instead.
+func (*StreamMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *StreamMetricsRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *StreamMetricsRequest) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+func (x *StreamMetricsRequest) GetMetricTypes() []string {
+	if x != nil {
+		return x.MetricTypes
+	}
+	return nil
+}
+
+func (x *StreamMetricsRequest) GetBackfillSeconds() int32 {
+	if x != nil {
+		return x.BackfillSeconds
+	}
+	return 0
+}
+
+type MetricsUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Metrics       *NodeMetrics           `protobuf:"bytes,2,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricsUpdate) Reset() {
+	*x = MetricsUpdate{}
+	mi := &file_proto_node_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsUpdate) ProtoMessage() {}
+
+func (x *MetricsUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsUpdate.ProtoReflect.Descriptor instead.
+func (*MetricsUpdate) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *MetricsUpdate) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *MetricsUpdate) GetMetrics() *NodeMetrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *MetricsUpdate) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// Metrics history queries. Times are Unix seconds; end defaults to now and
+// start to the oldest recorded metrics.
+type MetricsHistoryRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	NodeId string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Start  int64                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	End    int64                  `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	// One sample per step, at least the recording resolution
+	StepSeconds   int32 `protobuf:"varint,4,opt,name=step_seconds,json=stepSeconds,proto3" json:"step_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricsHistoryRequest) Reset() {
+	*x = MetricsHistoryRequest{}
+	mi := &file_proto_node_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsHistoryRequest) ProtoMessage() {}
+
+func (x *MetricsHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsHistoryRequest.ProtoReflect.Descriptor instead.
+func (*MetricsHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *MetricsHistoryRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *MetricsHistoryRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *MetricsHistoryRequest) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *MetricsHistoryRequest) GetStepSeconds() int32 {
+	if x != nil {
+		return x.StepSeconds
+	}
+	return 0
+}
+
+type MetricsHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	StepSeconds   int32                  `protobuf:"varint,2,opt,name=step_seconds,json=stepSeconds,proto3" json:"step_seconds,omitempty"`
+	Samples       []*MetricsUpdate       `protobuf:"bytes,3,rep,name=samples,proto3" json:"samples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricsHistoryResponse) Reset() {
+	*x = MetricsHistoryResponse{}
+	mi := &file_proto_node_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsHistoryResponse) ProtoMessage() {}
+
+func (x *MetricsHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsHistoryResponse.ProtoReflect.Descriptor instead.
+func (*MetricsHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *MetricsHistoryResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *MetricsHistoryResponse) GetStepSeconds() int32 {
+	if x != nil {
+		return x.StepSeconds
+	}
+	return 0
+}
+
+func (x *MetricsHistoryResponse) GetSamples() []*MetricsUpdate {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+// Alerts raised by the rule evaluators of every node in the cluster
+type AlertsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only alerts in this state ("pending" or "firing"); empty for both
+	State         string `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AlertsRequest) Reset() {
+	*x = AlertsRequest{}
+	mi := &file_proto_node_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlertsRequest) ProtoMessage() {}
+
+func (x *AlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlertsRequest.ProtoReflect.Descriptor instead.
+func (*AlertsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *AlertsRequest) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type Alert struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	NodeId   string                 `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Severity string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	State    string                 `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	Expr     string                 `protobuf:"bytes,5,opt,name=expr,proto3" json:"expr,omitempty"`
+	// Value of the rule's metric when last evaluated
+	Value   float64 `protobuf:"fixed64,6,opt,name=value,proto3" json:"value,omitempty"`
+	Summary string  `protobuf:"bytes,7,opt,name=summary,proto3" json:"summary,omitempty"`
+	// Unix seconds since the condition has held, and of the last evaluation
+	ActiveSince   int64 `protobuf:"varint,8,opt,name=active_since,json=activeSince,proto3" json:"active_since,omitempty"`
+	Updated       int64 `protobuf:"varint,9,opt,name=updated,proto3" json:"updated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alert) Reset() {
+	*x = Alert{}
+	mi := &file_proto_node_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ModelInfo) String() string {
+func (x *Alert) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ModelInfo) ProtoMessage() {}
+func (*Alert) ProtoMessage() {}
 
-func (x *ModelInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[21]
+func (x *Alert) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1311,84 +3099,96 @@ func (x *ModelInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ModelInfo.ProtoReflect.Descriptor instead.
-func (*ModelInfo) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{21}
+// Deprecated: Use Alert.ProtoReflect.Descriptor instead.
+func (*Alert) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{44}
 }
 
-func (x *ModelInfo) GetId() string {
+func (x *Alert) GetName() string {
 	if x != nil {
-		return x.Id
+		return x.Name
 	}
 	return ""
 }
 
-func (x *ModelInfo) GetName() string {
+func (x *Alert) GetNodeId() string {
 	if x != nil {
-		return x.Name
+		return x.NodeId
 	}
 	return ""
 }
 
-func (x *ModelInfo) GetVersion() string {
+func (x *Alert) GetSeverity() string {
 	if x != nil {
-		return x.Version
+		return x.Severity
 	}
 	return ""
 }
 
-func (x *ModelInfo) GetLayerCount() int32 {
+func (x *Alert) GetState() string {
 	if x != nil {
-		return x.LayerCount
+		return x.State
+	}
+	return ""
+}
+
+func (x *Alert) GetExpr() string {
+	if x != nil {
+		return x.Expr
+	}
+	return ""
+}
+
+func (x *Alert) GetValue() float64 {
+	if x != nil {
+		return x.Value
 	}
 	return 0
 }
 
-func (x *ModelInfo) GetFilePath() string {
+func (x *Alert) GetSummary() string {
 	if x != nil {
-		return x.FilePath
+		return x.Summary
 	}
 	return ""
 }
 
-func (x *ModelInfo) GetSizeBytes() int64 {
+func (x *Alert) GetActiveSince() int64 {
 	if x != nil {
-		return x.SizeBytes
+		return x.ActiveSince
 	}
 	return 0
 }
 
-func (x *ModelInfo) GetNodeAssignments() []string {
+func (x *Alert) GetUpdated() int64 {
 	if x != nil {
-		return x.NodeAssignments
+		return x.Updated
 	}
-	return nil
+	return 0
 }
 
-// Metrics messages
-type GetMetricsRequest struct {
+type AlertsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	MetricTypes   []string               `protobuf:"bytes,2,rep,name=metric_types,json=metricTypes,proto3" json:"metric_types,omitempty"`
+	Alerts        []*Alert               `protobuf:"bytes,1,rep,name=alerts,proto3" json:"alerts,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMetricsRequest) Reset() {
-	*x = GetMetricsRequest{}
-	mi := &file_proto_node_proto_msgTypes[22]
+func (x *AlertsResponse) Reset() {
+	*x = AlertsResponse{}
+	mi := &file_proto_node_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMetricsRequest) String() string {
+func (x *AlertsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMetricsRequest) ProtoMessage() {}
+func (*AlertsResponse) ProtoMessage() {}
 
-func (x *GetMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[22]
+func (x *AlertsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1399,48 +3199,44 @@ func (x *GetMetricsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMetricsRequest.ProtoReflect.Descriptor instead.
-func (*GetMetricsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{22}
-}
-
-func (x *GetMetricsRequest) GetNodeId() string {
-	if x != nil {
-		return x.NodeId
-	}
-	return ""
+// Deprecated: Use AlertsResponse.ProtoReflect.Descriptor instead.
+func (*AlertsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{45}
 }
 
-func (x *GetMetricsRequest) GetMetricTypes() []string {
+func (x *AlertsResponse) GetAlerts() []*Alert {
 	if x != nil {
-		return x.MetricTypes
+		return x.Alerts
 	}
 	return nil
 }
 
-type GetMetricsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Metrics       *NodeMetrics           `protobuf:"bytes,1,opt,name=metrics,proto3" json:"metrics,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+// Cluster events observed by an agent, in the order it observed them
+type EventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resume after this sequence number; 0 replays every retained event
+	AfterSequence uint64 `protobuf:"varint,1,opt,name=after_sequence,json=afterSequence,proto3" json:"after_sequence,omitempty"`
+	// Only events of these types; empty for all
+	Types         []string `protobuf:"bytes,2,rep,name=types,proto3" json:"types,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetMetricsResponse) Reset() {
-	*x = GetMetricsResponse{}
-	mi := &file_proto_node_proto_msgTypes[23]
+func (x *EventsRequest) Reset() {
+	*x = EventsRequest{}
+	mi := &file_proto_node_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMetricsResponse) String() string {
+func (x *EventsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMetricsResponse) ProtoMessage() {}
+func (*EventsRequest) ProtoMessage() {}
 
-func (x *GetMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[23]
+func (x *EventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1451,49 +3247,54 @@ func (x *GetMetricsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetMetricsResponse.ProtoReflect.Descriptor instead.
-func (*GetMetricsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{23}
+// Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{46}
 }
 
-func (x *GetMetricsResponse) GetMetrics() *NodeMetrics {
+func (x *EventsRequest) GetAfterSequence() uint64 {
 	if x != nil {
-		return x.Metrics
+		return x.AfterSequence
 	}
-	return nil
+	return 0
 }
 
-func (x *GetMetricsResponse) GetTimestamp() int64 {
+func (x *EventsRequest) GetTypes() []string {
 	if x != nil {
-		return x.Timestamp
+		return x.Types
 	}
-	return 0
+	return nil
 }
 
-type StreamMetricsRequest struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	NodeId          string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	IntervalSeconds int32                  `protobuf:"varint,2,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
-	MetricTypes     []string               `protobuf:"bytes,3,rep,name=metric_types,json=metricTypes,proto3" json:"metric_types,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type ClusterEvent struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Sequence uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Unix milliseconds
+	TimeUnixMs    int64             `protobuf:"varint,2,opt,name=time_unix_ms,json=timeUnixMs,proto3" json:"time_unix_ms,omitempty"`
+	Type          string            `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	NodeId        string            `protobuf:"bytes,4,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	ModelId       string            `protobuf:"bytes,5,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Message       string            `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
+	Attributes    map[string]string `protobuf:"bytes,7,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamMetricsRequest) Reset() {
-	*x = StreamMetricsRequest{}
-	mi := &file_proto_node_proto_msgTypes[24]
+func (x *ClusterEvent) Reset() {
+	*x = ClusterEvent{}
+	mi := &file_proto_node_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamMetricsRequest) String() string {
+func (x *ClusterEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamMetricsRequest) ProtoMessage() {}
+func (*ClusterEvent) ProtoMessage() {}
 
-func (x *StreamMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[24]
+func (x *ClusterEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_node_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1504,90 +3305,58 @@ func (x *StreamMetricsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamMetricsRequest.ProtoReflect.Descriptor instead.
-func (*StreamMetricsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{24}
+// Deprecated: Use ClusterEvent.ProtoReflect.Descriptor instead.
+func (*ClusterEvent) Descriptor() ([]byte, []int) {
+	return file_proto_node_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *StreamMetricsRequest) GetNodeId() string {
+func (x *ClusterEvent) GetSequence() uint64 {
 	if x != nil {
-		return x.NodeId
+		return x.Sequence
 	}
-	return ""
+	return 0
 }
 
-func (x *StreamMetricsRequest) GetIntervalSeconds() int32 {
+func (x *ClusterEvent) GetTimeUnixMs() int64 {
 	if x != nil {
-		return x.IntervalSeconds
+		return x.TimeUnixMs
 	}
 	return 0
 }
 
-func (x *StreamMetricsRequest) GetMetricTypes() []string {
+func (x *ClusterEvent) GetType() string {
 	if x != nil {
-		return x.MetricTypes
+		return x.Type
 	}
-	return nil
-}
-
-type MetricsUpdate struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Metrics       *NodeMetrics           `protobuf:"bytes,2,opt,name=metrics,proto3" json:"metrics,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *MetricsUpdate) Reset() {
-	*x = MetricsUpdate{}
-	mi := &file_proto_node_proto_msgTypes[25]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *MetricsUpdate) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return ""
 }
 
-func (*MetricsUpdate) ProtoMessage() {}
-
-func (x *MetricsUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[25]
+func (x *ClusterEvent) GetNodeId() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.NodeId
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use MetricsUpdate.ProtoReflect.Descriptor instead.
-func (*MetricsUpdate) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{25}
+	return ""
 }
 
-func (x *MetricsUpdate) GetNodeId() string {
+func (x *ClusterEvent) GetModelId() string {
 	if x != nil {
-		return x.NodeId
+		return x.ModelId
 	}
 	return ""
 }
 
-func (x *MetricsUpdate) GetMetrics() *NodeMetrics {
+func (x *ClusterEvent) GetMessage() string {
 	if x != nil {
-		return x.Metrics
+		return x.Message
 	}
-	return nil
+	return ""
 }
 
-func (x *MetricsUpdate) GetTimestamp() int64 {
+func (x *ClusterEvent) GetAttributes() map[string]string {
 	if x != nil {
-		return x.Timestamp
+		return x.Attributes
 	}
-	return 0
+	return nil
 }
 
 type NodeMetrics struct {
@@ -1602,7 +3371,7 @@ type NodeMetrics struct {
 
 func (x *NodeMetrics) Reset() {
 	*x = NodeMetrics{}
-	mi := &file_proto_node_proto_msgTypes[26]
+	mi := &file_proto_node_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1614,7 +3383,7 @@ func (x *NodeMetrics) String() string {
 func (*NodeMetrics) ProtoMessage() {}
 
 func (x *NodeMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[26]
+	mi := &file_proto_node_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1627,7 +3396,7 @@ func (x *NodeMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeMetrics.ProtoReflect.Descriptor instead.
 func (*NodeMetrics) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{26}
+	return file_proto_node_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *NodeMetrics) GetResourceMetrics() *ResourceMetrics {
@@ -1672,7 +3441,7 @@ type ResourceMetrics struct {
 
 func (x *ResourceMetrics) Reset() {
 	*x = ResourceMetrics{}
-	mi := &file_proto_node_proto_msgTypes[27]
+	mi := &file_proto_node_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1684,7 +3453,7 @@ func (x *ResourceMetrics) String() string {
 func (*ResourceMetrics) ProtoMessage() {}
 
 func (x *ResourceMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[27]
+	mi := &file_proto_node_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1697,7 +3466,7 @@ func (x *ResourceMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResourceMetrics.ProtoReflect.Descriptor instead.
 func (*ResourceMetrics) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{27}
+	return file_proto_node_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *ResourceMetrics) GetCpuUsagePercent() float32 {
@@ -1755,7 +3524,7 @@ type GPUMetrics struct {
 
 func (x *GPUMetrics) Reset() {
 	*x = GPUMetrics{}
-	mi := &file_proto_node_proto_msgTypes[28]
+	mi := &file_proto_node_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1767,7 +3536,7 @@ func (x *GPUMetrics) String() string {
 func (*GPUMetrics) ProtoMessage() {}
 
 func (x *GPUMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[28]
+	mi := &file_proto_node_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1780,7 +3549,7 @@ func (x *GPUMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GPUMetrics.ProtoReflect.Descriptor instead.
 func (*GPUMetrics) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{28}
+	return file_proto_node_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *GPUMetrics) GetGpuId() string {
@@ -1832,7 +3601,7 @@ type NetworkMetrics struct {
 
 func (x *NetworkMetrics) Reset() {
 	*x = NetworkMetrics{}
-	mi := &file_proto_node_proto_msgTypes[29]
+	mi := &file_proto_node_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1844,7 +3613,7 @@ func (x *NetworkMetrics) String() string {
 func (*NetworkMetrics) ProtoMessage() {}
 
 func (x *NetworkMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[29]
+	mi := &file_proto_node_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1857,7 +3626,7 @@ func (x *NetworkMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NetworkMetrics.ProtoReflect.Descriptor instead.
 func (*NetworkMetrics) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{29}
+	return file_proto_node_proto_rawDescGZIP(), []int{51}
 }
 
 func (x *NetworkMetrics) GetBytesSent() int64 {
@@ -1916,7 +3685,7 @@ type InferenceMetrics struct {
 
 func (x *InferenceMetrics) Reset() {
 	*x = InferenceMetrics{}
-	mi := &file_proto_node_proto_msgTypes[30]
+	mi := &file_proto_node_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1928,7 +3697,7 @@ func (x *InferenceMetrics) String() string {
 func (*InferenceMetrics) ProtoMessage() {}
 
 func (x *InferenceMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[30]
+	mi := &file_proto_node_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1941,7 +3710,7 @@ func (x *InferenceMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InferenceMetrics.ProtoReflect.Descriptor instead.
 func (*InferenceMetrics) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{30}
+	return file_proto_node_proto_rawDescGZIP(), []int{52}
 }
 
 func (x *InferenceMetrics) GetRequestsTotal() int32 {
@@ -1999,7 +3768,7 @@ type SystemMetrics struct {
 
 func (x *SystemMetrics) Reset() {
 	*x = SystemMetrics{}
-	mi := &file_proto_node_proto_msgTypes[31]
+	mi := &file_proto_node_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2011,7 +3780,7 @@ func (x *SystemMetrics) String() string {
 func (*SystemMetrics) ProtoMessage() {}
 
 func (x *SystemMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[31]
+	mi := &file_proto_node_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2024,7 +3793,7 @@ func (x *SystemMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SystemMetrics.ProtoReflect.Descriptor instead.
 func (*SystemMetrics) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{31}
+	return file_proto_node_proto_rawDescGZIP(), []int{53}
 }
 
 func (x *SystemMetrics) GetUptimeSeconds() int64 {
@@ -2078,7 +3847,7 @@ type ClusterMetrics struct {
 
 func (x *ClusterMetrics) Reset() {
 	*x = ClusterMetrics{}
-	mi := &file_proto_node_proto_msgTypes[32]
+	mi := &file_proto_node_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2090,7 +3859,7 @@ func (x *ClusterMetrics) String() string {
 func (*ClusterMetrics) ProtoMessage() {}
 
 func (x *ClusterMetrics) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[32]
+	mi := &file_proto_node_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2103,7 +3872,7 @@ func (x *ClusterMetrics) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClusterMetrics.ProtoReflect.Descriptor instead.
 func (*ClusterMetrics) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{32}
+	return file_proto_node_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *ClusterMetrics) GetTotalNodes() int32 {
@@ -2173,7 +3942,7 @@ type NodeListRequest struct {
 
 func (x *NodeListRequest) Reset() {
 	*x = NodeListRequest{}
-	mi := &file_proto_node_proto_msgTypes[33]
+	mi := &file_proto_node_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2185,7 +3954,7 @@ func (x *NodeListRequest) String() string {
 func (*NodeListRequest) ProtoMessage() {}
 
 func (x *NodeListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[33]
+	mi := &file_proto_node_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2198,7 +3967,7 @@ func (x *NodeListRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeListRequest.ProtoReflect.Descriptor instead.
 func (*NodeListRequest) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{33}
+	return file_proto_node_proto_rawDescGZIP(), []int{55}
 }
 
 func (x *NodeListRequest) GetRequesterId() string {
@@ -2225,7 +3994,7 @@ type NodeListResponse struct {
 
 func (x *NodeListResponse) Reset() {
 	*x = NodeListResponse{}
-	mi := &file_proto_node_proto_msgTypes[34]
+	mi := &file_proto_node_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2237,7 +4006,7 @@ func (x *NodeListResponse) String() string {
 func (*NodeListResponse) ProtoMessage() {}
 
 func (x *NodeListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[34]
+	mi := &file_proto_node_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2250,7 +4019,7 @@ func (x *NodeListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NodeListResponse.ProtoReflect.Descriptor instead.
 func (*NodeListResponse) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{34}
+	return file_proto_node_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *NodeListResponse) GetNodes() []*NodeInfo {
@@ -2276,7 +4045,7 @@ type ModelListRequest struct {
 
 func (x *ModelListRequest) Reset() {
 	*x = ModelListRequest{}
-	mi := &file_proto_node_proto_msgTypes[35]
+	mi := &file_proto_node_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2288,7 +4057,7 @@ func (x *ModelListRequest) String() string {
 func (*ModelListRequest) ProtoMessage() {}
 
 func (x *ModelListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[35]
+	mi := &file_proto_node_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2301,7 +4070,7 @@ func (x *ModelListRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ModelListRequest.ProtoReflect.Descriptor instead.
 func (*ModelListRequest) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{35}
+	return file_proto_node_proto_rawDescGZIP(), []int{57}
 }
 
 func (x *ModelListRequest) GetRequesterId() string {
@@ -2320,7 +4089,7 @@ type ModelListResponse struct {
 
 func (x *ModelListResponse) Reset() {
 	*x = ModelListResponse{}
-	mi := &file_proto_node_proto_msgTypes[36]
+	mi := &file_proto_node_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2332,7 +4101,7 @@ func (x *ModelListResponse) String() string {
 func (*ModelListResponse) ProtoMessage() {}
 
 func (x *ModelListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[36]
+	mi := &file_proto_node_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2345,7 +4114,7 @@ func (x *ModelListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ModelListResponse.ProtoReflect.Descriptor instead.
 func (*ModelListResponse) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{36}
+	return file_proto_node_proto_rawDescGZIP(), []int{58}
 }
 
 func (x *ModelListResponse) GetModels() []*ModelInfo {
@@ -2356,17 +4125,22 @@ func (x *ModelListResponse) GetModels() []*ModelInfo {
 }
 
 type UpdateStreamRequest struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	RequesterId     string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
-	UpdateTypes     []string               `protobuf:"bytes,2,rep,name=update_types,json=updateTypes,proto3" json:"update_types,omitempty"` // "nodes", "models", "metrics"
-	IntervalSeconds int32                  `protobuf:"varint,3,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	RequesterId string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
+	UpdateTypes []string               `protobuf:"bytes,2,rep,name=update_types,json=updateTypes,proto3" json:"update_types,omitempty"` // "nodes", "models", "metrics"; empty for all
+	// How often resources and metrics are checked for changes; membership,
+	// plan and model changes are sent as they happen
+	IntervalSeconds int32 `protobuf:"varint,3,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	// Relative change in a cluster metric below which it is not sent;
+	// 0 for the default of 0.05
+	MetricsThreshold float64 `protobuf:"fixed64,4,opt,name=metrics_threshold,json=metricsThreshold,proto3" json:"metrics_threshold,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *UpdateStreamRequest) Reset() {
 	*x = UpdateStreamRequest{}
-	mi := &file_proto_node_proto_msgTypes[37]
+	mi := &file_proto_node_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2378,7 +4152,7 @@ func (x *UpdateStreamRequest) String() string {
 func (*UpdateStreamRequest) ProtoMessage() {}
 
 func (x *UpdateStreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[37]
+	mi := &file_proto_node_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2391,7 +4165,7 @@ func (x *UpdateStreamRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateStreamRequest.ProtoReflect.Descriptor instead.
 func (*UpdateStreamRequest) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{37}
+	return file_proto_node_proto_rawDescGZIP(), []int{59}
 }
 
 func (x *UpdateStreamRequest) GetRequesterId() string {
@@ -2415,20 +4189,35 @@ func (x *UpdateStreamRequest) GetIntervalSeconds() int32 {
 	return 0
 }
 
+func (x *UpdateStreamRequest) GetMetricsThreshold() float64 {
+	if x != nil {
+		return x.MetricsThreshold
+	}
+	return 0
+}
+
+// The first update of a stream is a "snapshot" of the requested types. The
+// following are "delta"s holding the nodes and models added or changed
+// since the previous update, those removed, and the cluster metrics when
+// one changed beyond the threshold.
 type ClusterUpdate struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UpdateType    string                 `protobuf:"bytes,1,opt,name=update_type,json=updateType,proto3" json:"update_type,omitempty"`
-	Nodes         []*NodeInfo            `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
-	Models        []*ModelInfo           `protobuf:"bytes,3,rep,name=models,proto3" json:"models,omitempty"`
-	Metrics       *ClusterMetrics        `protobuf:"bytes,4,opt,name=metrics,proto3" json:"metrics,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	UpdateType string                 `protobuf:"bytes,1,opt,name=update_type,json=updateType,proto3" json:"update_type,omitempty"`
+	Nodes      []*NodeInfo            `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Models     []*ModelInfo           `protobuf:"bytes,3,rep,name=models,proto3" json:"models,omitempty"`
+	Metrics    *ClusterMetrics        `protobuf:"bytes,4,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Timestamp  int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Numbers the stream's updates from 1, without gaps
+	Sequence        uint64   `protobuf:"varint,6,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	RemovedNodeIds  []string `protobuf:"bytes,7,rep,name=removed_node_ids,json=removedNodeIds,proto3" json:"removed_node_ids,omitempty"`
+	RemovedModelIds []string `protobuf:"bytes,8,rep,name=removed_model_ids,json=removedModelIds,proto3" json:"removed_model_ids,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ClusterUpdate) Reset() {
 	*x = ClusterUpdate{}
-	mi := &file_proto_node_proto_msgTypes[38]
+	mi := &file_proto_node_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2440,7 +4229,7 @@ func (x *ClusterUpdate) String() string {
 func (*ClusterUpdate) ProtoMessage() {}
 
 func (x *ClusterUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[38]
+	mi := &file_proto_node_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2453,7 +4242,7 @@ func (x *ClusterUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClusterUpdate.ProtoReflect.Descriptor instead.
 func (*ClusterUpdate) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{38}
+	return file_proto_node_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *ClusterUpdate) GetUpdateType() string {
@@ -2491,6 +4280,27 @@ func (x *ClusterUpdate) GetTimestamp() int64 {
 	return 0
 }
 
+func (x *ClusterUpdate) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ClusterUpdate) GetRemovedNodeIds() []string {
+	if x != nil {
+		return x.RemovedNodeIds
+	}
+	return nil
+}
+
+func (x *ClusterUpdate) GetRemovedModelIds() []string {
+	if x != nil {
+		return x.RemovedModelIds
+	}
+	return nil
+}
+
 type CommandRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RequesterId   string                 `protobuf:"bytes,1,opt,name=requester_id,json=requesterId,proto3" json:"requester_id,omitempty"`
@@ -2503,7 +4313,7 @@ type CommandRequest struct {
 
 func (x *CommandRequest) Reset() {
 	*x = CommandRequest{}
-	mi := &file_proto_node_proto_msgTypes[39]
+	mi := &file_proto_node_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2515,7 +4325,7 @@ func (x *CommandRequest) String() string {
 func (*CommandRequest) ProtoMessage() {}
 
 func (x *CommandRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[39]
+	mi := &file_proto_node_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2528,7 +4338,7 @@ func (x *CommandRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CommandRequest.ProtoReflect.Descriptor instead.
 func (*CommandRequest) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{39}
+	return file_proto_node_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *CommandRequest) GetRequesterId() string {
@@ -2571,7 +4381,7 @@ type CommandResponse struct {
 
 func (x *CommandResponse) Reset() {
 	*x = CommandResponse{}
-	mi := &file_proto_node_proto_msgTypes[40]
+	mi := &file_proto_node_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2583,7 +4393,7 @@ func (x *CommandResponse) String() string {
 func (*CommandResponse) ProtoMessage() {}
 
 func (x *CommandResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_node_proto_msgTypes[40]
+	mi := &file_proto_node_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2596,7 +4406,7 @@ func (x *CommandResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
 func (*CommandResponse) Descriptor() ([]byte, []int) {
-	return file_proto_node_proto_rawDescGZIP(), []int{40}
+	return file_proto_node_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *CommandResponse) GetSuccess() bool {
@@ -2631,15 +4441,30 @@ var File_proto_node_proto protoreflect.FileDescriptor
 
 const file_proto_node_proto_rawDesc = "" +
 	"\n" +
-	"\x10proto/node.proto\x12\x05proto\"\x8f\x01\n" +
+	"\x10proto/node.proto\x12\x05proto\"\x84\x03\n" +
 	"\x13RegisterNodeRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
 	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
 	"\x04port\x18\x03 \x01(\x05R\x04port\x121\n" +
-	"\tresources\x18\x04 \x01(\v2\x13.proto.ResourceInfoR\tresources\"J\n" +
+	"\tresources\x18\x04 \x01(\v2\x13.proto.ResourceInfoR\tresources\x12\x1f\n" +
+	"\vgossip_port\x18\x05 \x01(\x05R\n" +
+	"gossipPort\x12\x1d\n" +
+	"\n" +
+	"seed_nodes\x18\x06 \x03(\tR\tseedNodes\x12\x18\n" +
+	"\aversion\x18\a \x01(\tR\aversion\x12)\n" +
+	"\x10protocol_version\x18\b \x01(\x05R\x0fprotocolVersion\x12\x1d\n" +
+	"\n" +
+	"cluster_id\x18\t \x01(\tR\tclusterId\x12\x1d\n" +
+	"\n" +
+	"auth_token\x18\n" +
+	" \x01(\tR\tauthToken\x120\n" +
+	"\x14min_protocol_version\x18\v \x01(\x05R\x12minProtocolVersion\"\xa1\x01\n" +
 	"\x14RegisterNodeResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\xac\x01\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x126\n" +
+	"\x0eexisting_nodes\x18\x03 \x03(\v2\x0f.proto.NodeInfoR\rexistingNodes\x12\x1d\n" +
+	"\n" +
+	"cluster_id\x18\x04 \x01(\tR\tclusterId\"\xac\x01\n" +
 	"\fResourceInfo\x12\x1b\n" +
 	"\tcpu_cores\x18\x01 \x01(\x03R\bcpuCores\x12\x1b\n" +
 	"\tmemory_mb\x18\x02 \x01(\x03R\bmemoryMb\x12\"\n" +
@@ -2662,30 +4487,118 @@ const file_proto_node_proto_rawDesc = "" +
 	"\x06prompt\x18\x02 \x01(\tR\x06prompt\x12\x1d\n" +
 	"\n" +
 	"max_tokens\x18\x03 \x01(\x05R\tmaxTokens\x12+\n" +
-	"\x11layer_assignments\x18\x04 \x03(\tR\x10layerAssignments\"\xd0\x01\n" +
+	"\x11layer_assignments\x18\x04 \x03(\tR\x10layerAssignments\"\xa0\x02\n" +
 	"\x11InferenceResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12%\n" +
 	"\x0egenerated_text\x18\x02 \x01(\tR\rgeneratedText\x12#\n" +
 	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x12)\n" +
 	"\x10tokens_generated\x18\x04 \x01(\x05R\x0ftokensGenerated\x12*\n" +
-	"\x11inference_time_ms\x18\x05 \x01(\x02R\x0finferenceTimeMs\"-\n" +
+	"\x11inference_time_ms\x18\x05 \x01(\x02R\x0finferenceTimeMs\x12#\n" +
+	"\rprompt_tokens\x18\x06 \x01(\x05R\fpromptTokens\x12)\n" +
+	"\x06stages\x18\a \x03(\v2\x11.proto.StageUsageR\x06stages\"\x92\x01\n" +
+	"\n" +
+	"StageUsage\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12%\n" +
+	"\x0ecompute_micros\x18\x02 \x01(\x03R\rcomputeMicros\x12%\n" +
+	"\x0ebytes_received\x18\x03 \x01(\x03R\rbytesReceived\x12\x1d\n" +
+	"\n" +
+	"bytes_sent\x18\x04 \x01(\x03R\tbytesSent\"\xbc\x01\n" +
+	"\x0fActivationFrame\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05layer\x18\x03 \x01(\x05R\x05layer\x12\x1a\n" +
+	"\bsequence\x18\x04 \x01(\rR\bsequence\x12\x16\n" +
+	"\x06tensor\x18\x05 \x01(\fR\x06tensor\x12%\n" +
+	"\x0ecompute_micros\x18\x06 \x01(\x03R\rcomputeMicros\"\xb7\x01\n" +
+	"\fUsageRequest\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\x12\"\n" +
+	"\rsince_unix_ms\x18\x03 \x01(\x03R\vsinceUnixMs\x12\"\n" +
+	"\runtil_unix_ms\x18\x04 \x01(\x03R\vuntilUnixMs\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06format\x18\x06 \x01(\tR\x06format\"\xc9\x02\n" +
+	"\vUsageRecord\x12*\n" +
+	"\x11timestamp_unix_ms\x18\x01 \x01(\x03R\x0ftimestampUnixMs\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12\x16\n" +
+	"\x06tenant\x18\x03 \x01(\tR\x06tenant\x12\x19\n" +
+	"\bmodel_id\x18\x04 \x01(\tR\amodelId\x12#\n" +
+	"\rprompt_tokens\x18\x05 \x01(\x05R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x06 \x01(\x05R\x10completionTokens\x12'\n" +
+	"\x0fduration_micros\x18\a \x01(\x03R\x0edurationMicros\x12\x18\n" +
+	"\asuccess\x18\b \x01(\bR\asuccess\x12'\n" +
+	"\x05nodes\x18\t \x03(\v2\x11.proto.StageUsageR\x05nodes\"\x8f\x02\n" +
+	"\fUsageSummary\x12\x16\n" +
+	"\x06tenant\x18\x01 \x01(\tR\x06tenant\x12\x19\n" +
+	"\bmodel_id\x18\x02 \x01(\tR\amodelId\x12\x1a\n" +
+	"\brequests\x18\x03 \x01(\x03R\brequests\x12\x16\n" +
+	"\x06failed\x18\x04 \x01(\x03R\x06failed\x12#\n" +
+	"\rprompt_tokens\x18\x05 \x01(\x03R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x06 \x01(\x03R\x10completionTokens\x12%\n" +
+	"\x0ecompute_micros\x18\a \x01(\x03R\rcomputeMicros\x12\x1f\n" +
+	"\vbytes_moved\x18\b \x01(\x03R\n" +
+	"bytesMoved\"\x88\x01\n" +
+	"\rUsageResponse\x12,\n" +
+	"\arecords\x18\x01 \x03(\v2\x12.proto.UsageRecordR\arecords\x121\n" +
+	"\tsummaries\x18\x02 \x03(\v2\x13.proto.UsageSummaryR\tsummaries\x12\x16\n" +
+	"\x06export\x18\x03 \x01(\fR\x06export\"-\n" +
 	"\x12HealthCheckRequest\x12\x17\n" +
-	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"n\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"\xc2\x01\n" +
 	"\x13HealthCheckResponse\x12\x18\n" +
 	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x12%\n" +
-	"\x0euptime_seconds\x18\x03 \x01(\x03R\ruptimeSeconds\"*\n" +
+	"\x0euptime_seconds\x18\x03 \x01(\x03R\ruptimeSeconds\x12#\n" +
+	"\rbackend_ready\x18\x04 \x01(\bR\fbackendReady\x12-\n" +
+	"\x06models\x18\x05 \x03(\v2\x15.proto.ModelReadinessR\x06models\"W\n" +
+	"\x0eModelReadiness\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\",\n" +
+	"\x11GetVersionRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"\xc0\x01\n" +
+	"\x12GetVersionResponse\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12)\n" +
+	"\x10protocol_version\x18\x03 \x01(\x05R\x0fprotocolVersion\x120\n" +
+	"\x14min_protocol_version\x18\x04 \x01(\x05R\x12minProtocolVersion\x12\x1a\n" +
+	"\bfeatures\x18\x05 \x03(\tR\bfeatures\"9\n" +
+	"\x14LatencyMatrixRequest\x12!\n" +
+	"\frequester_id\x18\x01 \x01(\tR\vrequesterId\"F\n" +
+	"\x15LatencyMatrixResponse\x12-\n" +
+	"\aentries\x18\x01 \x03(\v2\x13.proto.LatencyEntryR\aentries\"\xb6\x01\n" +
+	"\fLatencyEntry\x12\x1b\n" +
+	"\tfrom_node\x18\x01 \x01(\tR\bfromNode\x12\x17\n" +
+	"\ato_node\x18\x02 \x01(\tR\x06toNode\x12\x15\n" +
+	"\x06rtt_ms\x18\x03 \x01(\x01R\x05rttMs\x12;\n" +
+	"\x1abandwidth_bytes_per_second\x18\x04 \x01(\x01R\x17bandwidthBytesPerSecond\x12\x1c\n" +
+	"\testimated\x18\x05 \x01(\bR\testimated\"A\n" +
+	"\fProbeRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\apayload\x18\x02 \x01(\fR\apayload\"O\n" +
+	"\rProbeResponse\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12%\n" +
+	"\x0ereceived_bytes\x18\x02 \x01(\x03R\rreceivedBytes\"*\n" +
 	"\x0fGetPeersRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"9\n" +
 	"\x10GetPeersResponse\x12%\n" +
-	"\x05peers\x18\x01 \x03(\v2\x0f.proto.NodeInfoR\x05peers\"\xb9\x01\n" +
+	"\x05peers\x18\x01 \x03(\v2\x0f.proto.NodeInfoR\x05peers\"\xbc\x03\n" +
 	"\bNodeInfo\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
 	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
 	"\x04port\x18\x03 \x01(\x05R\x04port\x121\n" +
 	"\tresources\x18\x04 \x01(\v2\x13.proto.ResourceInfoR\tresources\x12\x16\n" +
 	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1b\n" +
-	"\tlast_seen\x18\x06 \x01(\x03R\blastSeen\"V\n" +
+	"\tlast_seen\x18\x06 \x01(\x03R\blastSeen\x12\x18\n" +
+	"\aversion\x18\a \x01(\tR\aversion\x12)\n" +
+	"\x10protocol_version\x18\b \x01(\x05R\x0fprotocolVersion\x120\n" +
+	"\x14min_protocol_version\x18\t \x01(\x05R\x12minProtocolVersion\x12\x1a\n" +
+	"\bfeatures\x18\n" +
+	" \x03(\tR\bfeatures\x123\n" +
+	"\x06labels\x18\v \x03(\v2\x1b.proto.NodeInfo.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"V\n" +
 	"\x10DiscoveryRequest\x12!\n" +
 	"\frequester_id\x18\x01 \x01(\tR\vrequesterId\x12\x1f\n" +
 	"\vknown_nodes\x18\x02 \x03(\tR\n" +
@@ -2693,14 +4606,24 @@ const file_proto_node_proto_rawDesc = "" +
 	"\x11DiscoveryResponse\x12:\n" +
 	"\x10discovered_nodes\x18\x01 \x03(\v2\x0f.proto.NodeInfoR\x0fdiscoveredNodes\x12\x18\n" +
 	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"\xad\x01\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x83\x03\n" +
 	"\x12ClusterJoinRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
 	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
 	"\x04port\x18\x03 \x01(\x05R\x04port\x121\n" +
 	"\tresources\x18\x04 \x01(\v2\x13.proto.ResourceInfoR\tresources\x12\x1d\n" +
 	"\n" +
-	"seed_nodes\x18\x05 \x03(\tR\tseedNodes\"\xa0\x01\n" +
+	"seed_nodes\x18\x05 \x03(\tR\tseedNodes\x12\x1f\n" +
+	"\vgossip_port\x18\x06 \x01(\x05R\n" +
+	"gossipPort\x12\x18\n" +
+	"\aversion\x18\a \x01(\tR\aversion\x12)\n" +
+	"\x10protocol_version\x18\b \x01(\x05R\x0fprotocolVersion\x12\x1d\n" +
+	"\n" +
+	"cluster_id\x18\t \x01(\tR\tclusterId\x12\x1d\n" +
+	"\n" +
+	"auth_token\x18\n" +
+	" \x01(\tR\tauthToken\x120\n" +
+	"\x14min_protocol_version\x18\v \x01(\x05R\x12minProtocolVersion\"\xa0\x01\n" +
 	"\x13ClusterJoinResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x126\n" +
@@ -2714,13 +4637,23 @@ const file_proto_node_proto_rawDesc = "" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\"7\n" +
 	"\x12ClusterInfoRequest\x12!\n" +
-	"\frequester_id\x18\x01 \x01(\tR\vrequesterId\"\xb6\x01\n" +
+	"\frequester_id\x18\x01 \x01(\tR\vrequesterId\"\xe7\x01\n" +
 	"\x13ClusterInfoResponse\x12\x1d\n" +
 	"\n" +
 	"cluster_id\x18\x01 \x01(\tR\tclusterId\x12%\n" +
 	"\x05nodes\x18\x02 \x03(\v2\x0f.proto.NodeInfoR\x05nodes\x12(\n" +
 	"\x06models\x18\x03 \x03(\v2\x10.proto.ModelInfoR\x06models\x12/\n" +
-	"\ametrics\x18\x04 \x01(\v2\x15.proto.ClusterMetricsR\ametrics\"\xd1\x01\n" +
+	"\ametrics\x18\x04 \x01(\v2\x15.proto.ClusterMetricsR\ametrics\x12/\n" +
+	"\breplicas\x18\x05 \x03(\v2\x13.proto.ReplicaStatsR\breplicas\"\xa3\x02\n" +
+	"\fReplicaStats\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x18\n" +
+	"\areplica\x18\x02 \x01(\x05R\areplica\x12\x19\n" +
+	"\bnode_ids\x18\x03 \x03(\tR\anodeIds\x12\x18\n" +
+	"\ahealthy\x18\x04 \x01(\bR\ahealthy\x121\n" +
+	"\x14outstanding_requests\x18\x05 \x01(\x05R\x13outstandingRequests\x12'\n" +
+	"\x0fserved_requests\x18\x06 \x01(\x04R\x0eservedRequests\x12'\n" +
+	"\x0ffailed_requests\x18\a \x01(\x04R\x0efailedRequests\x12$\n" +
+	"\x0eavg_latency_ms\x18\b \x01(\x01R\favgLatencyMs\"\xed\x01\n" +
 	"\tModelInfo\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
@@ -2730,21 +4663,63 @@ const file_proto_node_proto_rawDesc = "" +
 	"\tfile_path\x18\x05 \x01(\tR\bfilePath\x12\x1d\n" +
 	"\n" +
 	"size_bytes\x18\x06 \x01(\x03R\tsizeBytes\x12)\n" +
-	"\x10node_assignments\x18\a \x03(\tR\x0fnodeAssignments\"O\n" +
+	"\x10node_assignments\x18\a \x03(\tR\x0fnodeAssignments\x12\x1a\n" +
+	"\breplicas\x18\b \x01(\x05R\breplicas\"O\n" +
 	"\x11GetMetricsRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12!\n" +
 	"\fmetric_types\x18\x02 \x03(\tR\vmetricTypes\"`\n" +
 	"\x12GetMetricsResponse\x12,\n" +
 	"\ametrics\x18\x01 \x01(\v2\x12.proto.NodeMetricsR\ametrics\x12\x1c\n" +
-	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"}\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"\xa8\x01\n" +
 	"\x14StreamMetricsRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12)\n" +
 	"\x10interval_seconds\x18\x02 \x01(\x05R\x0fintervalSeconds\x12!\n" +
-	"\fmetric_types\x18\x03 \x03(\tR\vmetricTypes\"t\n" +
+	"\fmetric_types\x18\x03 \x03(\tR\vmetricTypes\x12)\n" +
+	"\x10backfill_seconds\x18\x04 \x01(\x05R\x0fbackfillSeconds\"t\n" +
 	"\rMetricsUpdate\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12,\n" +
 	"\ametrics\x18\x02 \x01(\v2\x12.proto.NodeMetricsR\ametrics\x12\x1c\n" +
-	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"\x93\x02\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"{\n" +
+	"\x15MetricsHistoryRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\x03R\x03end\x12!\n" +
+	"\fstep_seconds\x18\x04 \x01(\x05R\vstepSeconds\"\x84\x01\n" +
+	"\x16MetricsHistoryResponse\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12!\n" +
+	"\fstep_seconds\x18\x02 \x01(\x05R\vstepSeconds\x12.\n" +
+	"\asamples\x18\x03 \x03(\v2\x14.proto.MetricsUpdateR\asamples\"%\n" +
+	"\rAlertsRequest\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\"\xe7\x01\n" +
+	"\x05Alert\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x17\n" +
+	"\anode_id\x18\x02 \x01(\tR\x06nodeId\x12\x1a\n" +
+	"\bseverity\x18\x03 \x01(\tR\bseverity\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12\x12\n" +
+	"\x04expr\x18\x05 \x01(\tR\x04expr\x12\x14\n" +
+	"\x05value\x18\x06 \x01(\x01R\x05value\x12\x18\n" +
+	"\asummary\x18\a \x01(\tR\asummary\x12!\n" +
+	"\factive_since\x18\b \x01(\x03R\vactiveSince\x12\x18\n" +
+	"\aupdated\x18\t \x01(\x03R\aupdated\"6\n" +
+	"\x0eAlertsResponse\x12$\n" +
+	"\x06alerts\x18\x01 \x03(\v2\f.proto.AlertR\x06alerts\"L\n" +
+	"\rEventsRequest\x12%\n" +
+	"\x0eafter_sequence\x18\x01 \x01(\x04R\rafterSequence\x12\x14\n" +
+	"\x05types\x18\x02 \x03(\tR\x05types\"\xb2\x02\n" +
+	"\fClusterEvent\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12 \n" +
+	"\ftime_unix_ms\x18\x02 \x01(\x03R\n" +
+	"timeUnixMs\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x17\n" +
+	"\anode_id\x18\x04 \x01(\tR\x06nodeId\x12\x19\n" +
+	"\bmodel_id\x18\x05 \x01(\tR\amodelId\x12\x18\n" +
+	"\amessage\x18\x06 \x01(\tR\amessage\x12C\n" +
+	"\n" +
+	"attributes\x18\a \x03(\v2#.proto.ClusterEvent.AttributesEntryR\n" +
+	"attributes\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x93\x02\n" +
 	"\vNodeMetrics\x12A\n" +
 	"\x10resource_metrics\x18\x01 \x01(\v2\x16.proto.ResourceMetricsR\x0fresourceMetrics\x12>\n" +
 	"\x0fnetwork_metrics\x18\x02 \x01(\v2\x15.proto.NetworkMetricsR\x0enetworkMetrics\x12D\n" +
@@ -2809,18 +4784,22 @@ const file_proto_node_proto_rawDesc = "" +
 	"\x10ModelListRequest\x12!\n" +
 	"\frequester_id\x18\x01 \x01(\tR\vrequesterId\"=\n" +
 	"\x11ModelListResponse\x12(\n" +
-	"\x06models\x18\x01 \x03(\v2\x10.proto.ModelInfoR\x06models\"\x86\x01\n" +
+	"\x06models\x18\x01 \x03(\v2\x10.proto.ModelInfoR\x06models\"\xb3\x01\n" +
 	"\x13UpdateStreamRequest\x12!\n" +
 	"\frequester_id\x18\x01 \x01(\tR\vrequesterId\x12!\n" +
 	"\fupdate_types\x18\x02 \x03(\tR\vupdateTypes\x12)\n" +
-	"\x10interval_seconds\x18\x03 \x01(\x05R\x0fintervalSeconds\"\xd0\x01\n" +
+	"\x10interval_seconds\x18\x03 \x01(\x05R\x0fintervalSeconds\x12+\n" +
+	"\x11metrics_threshold\x18\x04 \x01(\x01R\x10metricsThreshold\"\xc2\x02\n" +
 	"\rClusterUpdate\x12\x1f\n" +
 	"\vupdate_type\x18\x01 \x01(\tR\n" +
 	"updateType\x12%\n" +
 	"\x05nodes\x18\x02 \x03(\v2\x0f.proto.NodeInfoR\x05nodes\x12(\n" +
 	"\x06models\x18\x03 \x03(\v2\x10.proto.ModelInfoR\x06models\x12/\n" +
 	"\ametrics\x18\x04 \x01(\v2\x15.proto.ClusterMetricsR\ametrics\x12\x1c\n" +
-	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"\xdb\x01\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12\x1a\n" +
+	"\bsequence\x18\x06 \x01(\x04R\bsequence\x12(\n" +
+	"\x10removed_node_ids\x18\a \x03(\tR\x0eremovedNodeIds\x12*\n" +
+	"\x11removed_model_ids\x18\b \x03(\tR\x0fremovedModelIds\"\xdb\x01\n" +
 	"\x0eCommandRequest\x12!\n" +
 	"\frequester_id\x18\x01 \x01(\tR\vrequesterId\x12\x18\n" +
 	"\acommand\x18\x02 \x01(\tR\acommand\x12\x12\n" +
@@ -2833,7 +4812,7 @@ const file_proto_node_proto_rawDesc = "" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x16\n" +
 	"\x06output\x18\x02 \x01(\tR\x06output\x12\x14\n" +
 	"\x05error\x18\x03 \x01(\tR\x05error\x12\x1b\n" +
-	"\texit_code\x18\x04 \x01(\x05R\bexitCode2\xf2\x03\n" +
+	"\texit_code\x18\x04 \x01(\x05R\bexitCode2\x88\b\n" +
 	"\vNodeService\x12G\n" +
 	"\fRegisterNode\x12\x1a.proto.RegisterNodeRequest\x1a\x1b.proto.RegisterNodeResponse\x12G\n" +
 	"\fGetResources\x12\x1a.proto.GetResourcesRequest\x1a\x1b.proto.GetResourcesResponse\x12E\n" +
@@ -2842,7 +4821,16 @@ const file_proto_node_proto_rawDesc = "" +
 	"\bGetPeers\x12\x16.proto.GetPeersRequest\x1a\x17.proto.GetPeersResponse\x12A\n" +
 	"\n" +
 	"GetMetrics\x12\x18.proto.GetMetricsRequest\x1a\x19.proto.GetMetricsResponse\x12D\n" +
-	"\rStreamMetrics\x12\x1b.proto.StreamMetricsRequest\x1a\x14.proto.MetricsUpdate0\x012\xb6\x02\n" +
+	"\rStreamMetrics\x12\x1b.proto.StreamMetricsRequest\x1a\x14.proto.MetricsUpdate0\x01\x12P\n" +
+	"\x11GetMetricsHistory\x12\x1c.proto.MetricsHistoryRequest\x1a\x1d.proto.MetricsHistoryResponse\x12A\n" +
+	"\n" +
+	"GetVersion\x12\x18.proto.GetVersionRequest\x1a\x19.proto.GetVersionResponse\x12M\n" +
+	"\x10GetLatencyMatrix\x12\x1b.proto.LatencyMatrixRequest\x1a\x1c.proto.LatencyMatrixResponse\x129\n" +
+	"\fProbeLatency\x12\x13.proto.ProbeRequest\x1a\x14.proto.ProbeResponse\x12G\n" +
+	"\x11StreamActivations\x12\x16.proto.ActivationFrame\x1a\x16.proto.ActivationFrame(\x010\x01\x125\n" +
+	"\bGetUsage\x12\x13.proto.UsageRequest\x1a\x14.proto.UsageResponse\x128\n" +
+	"\tGetAlerts\x12\x14.proto.AlertsRequest\x1a\x15.proto.AlertsResponse\x12;\n" +
+	"\fStreamEvents\x12\x14.proto.EventsRequest\x1a\x13.proto.ClusterEvent0\x012\xb6\x02\n" +
 	"\x10DiscoveryService\x12B\n" +
 	"\rDiscoverNodes\x12\x17.proto.DiscoveryRequest\x1a\x18.proto.DiscoveryResponse\x12L\n" +
 	"\x13RegisterWithCluster\x12\x19.proto.ClusterJoinRequest\x1a\x1a.proto.ClusterJoinResponse\x12G\n" +
@@ -2867,112 +4855,164 @@ func file_proto_node_proto_rawDescGZIP() []byte {
 	return file_proto_node_proto_rawDescData
 }
 
-var file_proto_node_proto_msgTypes = make([]protoimpl.MessageInfo, 42)
+var file_proto_node_proto_msgTypes = make([]protoimpl.MessageInfo, 66)
 var file_proto_node_proto_goTypes = []any{
-	(*RegisterNodeRequest)(nil),  // 0: proto.RegisterNodeRequest
-	(*RegisterNodeResponse)(nil), // 1: proto.RegisterNodeResponse
-	(*ResourceInfo)(nil),         // 2: proto.ResourceInfo
-	(*GPUInfo)(nil),              // 3: proto.GPUInfo
-	(*GetResourcesRequest)(nil),  // 4: proto.GetResourcesRequest
-	(*GetResourcesResponse)(nil), // 5: proto.GetResourcesResponse
-	(*InferenceRequest)(nil),     // 6: proto.InferenceRequest
-	(*InferenceResponse)(nil),    // 7: proto.InferenceResponse
-	(*HealthCheckRequest)(nil),   // 8: proto.HealthCheckRequest
-	(*HealthCheckResponse)(nil),  // 9: proto.HealthCheckResponse
-	(*GetPeersRequest)(nil),      // 10: proto.GetPeersRequest
-	(*GetPeersResponse)(nil),     // 11: proto.GetPeersResponse
-	(*NodeInfo)(nil),             // 12: proto.NodeInfo
-	(*DiscoveryRequest)(nil),     // 13: proto.DiscoveryRequest
-	(*DiscoveryResponse)(nil),    // 14: proto.DiscoveryResponse
-	(*ClusterJoinRequest)(nil),   // 15: proto.ClusterJoinRequest
-	(*ClusterJoinResponse)(nil),  // 16: proto.ClusterJoinResponse
-	(*ClusterLeaveRequest)(nil),  // 17: proto.ClusterLeaveRequest
-	(*ClusterLeaveResponse)(nil), // 18: proto.ClusterLeaveResponse
-	(*ClusterInfoRequest)(nil),   // 19: proto.ClusterInfoRequest
-	(*ClusterInfoResponse)(nil),  // 20: proto.ClusterInfoResponse
-	(*ModelInfo)(nil),            // 21: proto.ModelInfo
-	(*GetMetricsRequest)(nil),    // 22: proto.GetMetricsRequest
-	(*GetMetricsResponse)(nil),   // 23: proto.GetMetricsResponse
-	(*StreamMetricsRequest)(nil), // 24: proto.StreamMetricsRequest
-	(*MetricsUpdate)(nil),        // 25: proto.MetricsUpdate
-	(*NodeMetrics)(nil),          // 26: proto.NodeMetrics
-	(*ResourceMetrics)(nil),      // 27: proto.ResourceMetrics
-	(*GPUMetrics)(nil),           // 28: proto.GPUMetrics
-	(*NetworkMetrics)(nil),       // 29: proto.NetworkMetrics
-	(*InferenceMetrics)(nil),     // 30: proto.InferenceMetrics
-	(*SystemMetrics)(nil),        // 31: proto.SystemMetrics
-	(*ClusterMetrics)(nil),       // 32: proto.ClusterMetrics
-	(*NodeListRequest)(nil),      // 33: proto.NodeListRequest
-	(*NodeListResponse)(nil),     // 34: proto.NodeListResponse
-	(*ModelListRequest)(nil),     // 35: proto.ModelListRequest
-	(*ModelListResponse)(nil),    // 36: proto.ModelListResponse
-	(*UpdateStreamRequest)(nil),  // 37: proto.UpdateStreamRequest
-	(*ClusterUpdate)(nil),        // 38: proto.ClusterUpdate
-	(*CommandRequest)(nil),       // 39: proto.CommandRequest
-	(*CommandResponse)(nil),      // 40: proto.CommandResponse
-	nil,                          // 41: proto.CommandRequest.OptionsEntry
+	(*RegisterNodeRequest)(nil),    // 0: proto.RegisterNodeRequest
+	(*RegisterNodeResponse)(nil),   // 1: proto.RegisterNodeResponse
+	(*ResourceInfo)(nil),           // 2: proto.ResourceInfo
+	(*GPUInfo)(nil),                // 3: proto.GPUInfo
+	(*GetResourcesRequest)(nil),    // 4: proto.GetResourcesRequest
+	(*GetResourcesResponse)(nil),   // 5: proto.GetResourcesResponse
+	(*InferenceRequest)(nil),       // 6: proto.InferenceRequest
+	(*InferenceResponse)(nil),      // 7: proto.InferenceResponse
+	(*StageUsage)(nil),             // 8: proto.StageUsage
+	(*ActivationFrame)(nil),        // 9: proto.ActivationFrame
+	(*UsageRequest)(nil),           // 10: proto.UsageRequest
+	(*UsageRecord)(nil),            // 11: proto.UsageRecord
+	(*UsageSummary)(nil),           // 12: proto.UsageSummary
+	(*UsageResponse)(nil),          // 13: proto.UsageResponse
+	(*HealthCheckRequest)(nil),     // 14: proto.HealthCheckRequest
+	(*HealthCheckResponse)(nil),    // 15: proto.HealthCheckResponse
+	(*ModelReadiness)(nil),         // 16: proto.ModelReadiness
+	(*GetVersionRequest)(nil),      // 17: proto.GetVersionRequest
+	(*GetVersionResponse)(nil),     // 18: proto.GetVersionResponse
+	(*LatencyMatrixRequest)(nil),   // 19: proto.LatencyMatrixRequest
+	(*LatencyMatrixResponse)(nil),  // 20: proto.LatencyMatrixResponse
+	(*LatencyEntry)(nil),           // 21: proto.LatencyEntry
+	(*ProbeRequest)(nil),           // 22: proto.ProbeRequest
+	(*ProbeResponse)(nil),          // 23: proto.ProbeResponse
+	(*GetPeersRequest)(nil),        // 24: proto.GetPeersRequest
+	(*GetPeersResponse)(nil),       // 25: proto.GetPeersResponse
+	(*NodeInfo)(nil),               // 26: proto.NodeInfo
+	(*DiscoveryRequest)(nil),       // 27: proto.DiscoveryRequest
+	(*DiscoveryResponse)(nil),      // 28: proto.DiscoveryResponse
+	(*ClusterJoinRequest)(nil),     // 29: proto.ClusterJoinRequest
+	(*ClusterJoinResponse)(nil),    // 30: proto.ClusterJoinResponse
+	(*ClusterLeaveRequest)(nil),    // 31: proto.ClusterLeaveRequest
+	(*ClusterLeaveResponse)(nil),   // 32: proto.ClusterLeaveResponse
+	(*ClusterInfoRequest)(nil),     // 33: proto.ClusterInfoRequest
+	(*ClusterInfoResponse)(nil),    // 34: proto.ClusterInfoResponse
+	(*ReplicaStats)(nil),           // 35: proto.ReplicaStats
+	(*ModelInfo)(nil),              // 36: proto.ModelInfo
+	(*GetMetricsRequest)(nil),      // 37: proto.GetMetricsRequest
+	(*GetMetricsResponse)(nil),     // 38: proto.GetMetricsResponse
+	(*StreamMetricsRequest)(nil),   // 39: proto.StreamMetricsRequest
+	(*MetricsUpdate)(nil),          // 40: proto.MetricsUpdate
+	(*MetricsHistoryRequest)(nil),  // 41: proto.MetricsHistoryRequest
+	(*MetricsHistoryResponse)(nil), // 42: proto.MetricsHistoryResponse
+	(*AlertsRequest)(nil),          // 43: proto.AlertsRequest
+	(*Alert)(nil),                  // 44: proto.Alert
+	(*AlertsResponse)(nil),         // 45: proto.AlertsResponse
+	(*EventsRequest)(nil),          // 46: proto.EventsRequest
+	(*ClusterEvent)(nil),           // 47: proto.ClusterEvent
+	(*NodeMetrics)(nil),            // 48: proto.NodeMetrics
+	(*ResourceMetrics)(nil),        // 49: proto.ResourceMetrics
+	(*GPUMetrics)(nil),             // 50: proto.GPUMetrics
+	(*NetworkMetrics)(nil),         // 51: proto.NetworkMetrics
+	(*InferenceMetrics)(nil),       // 52: proto.InferenceMetrics
+	(*SystemMetrics)(nil),          // 53: proto.SystemMetrics
+	(*ClusterMetrics)(nil),         // 54: proto.ClusterMetrics
+	(*NodeListRequest)(nil),        // 55: proto.NodeListRequest
+	(*NodeListResponse)(nil),       // 56: proto.NodeListResponse
+	(*ModelListRequest)(nil),       // 57: proto.ModelListRequest
+	(*ModelListResponse)(nil),      // 58: proto.ModelListResponse
+	(*UpdateStreamRequest)(nil),    // 59: proto.UpdateStreamRequest
+	(*ClusterUpdate)(nil),          // 60: proto.ClusterUpdate
+	(*CommandRequest)(nil),         // 61: proto.CommandRequest
+	(*CommandResponse)(nil),        // 62: proto.CommandResponse
+	nil,                            // 63: proto.NodeInfo.LabelsEntry
+	nil,                            // 64: proto.ClusterEvent.AttributesEntry
+	nil,                            // 65: proto.CommandRequest.OptionsEntry
 }
 var file_proto_node_proto_depIdxs = []int32{
 	2,  // 0: proto.RegisterNodeRequest.resources:type_name -> proto.ResourceInfo
-	3,  // 1: proto.ResourceInfo.gpus:type_name -> proto.GPUInfo
-	2,  // 2: proto.GetResourcesResponse.resources:type_name -> proto.ResourceInfo
-	12, // 3: proto.GetPeersResponse.peers:type_name -> proto.NodeInfo
-	2,  // 4: proto.NodeInfo.resources:type_name -> proto.ResourceInfo
-	12, // 5: proto.DiscoveryResponse.discovered_nodes:type_name -> proto.NodeInfo
-	2,  // 6: proto.ClusterJoinRequest.resources:type_name -> proto.ResourceInfo
-	12, // 7: proto.ClusterJoinResponse.existing_nodes:type_name -> proto.NodeInfo
-	12, // 8: proto.ClusterInfoResponse.nodes:type_name -> proto.NodeInfo
-	21, // 9: proto.ClusterInfoResponse.models:type_name -> proto.ModelInfo
-	32, // 10: proto.ClusterInfoResponse.metrics:type_name -> proto.ClusterMetrics
-	26, // 11: proto.GetMetricsResponse.metrics:type_name -> proto.NodeMetrics
-	26, // 12: proto.MetricsUpdate.metrics:type_name -> proto.NodeMetrics
-	27, // 13: proto.NodeMetrics.resource_metrics:type_name -> proto.ResourceMetrics
-	29, // 14: proto.NodeMetrics.network_metrics:type_name -> proto.NetworkMetrics
-	30, // 15: proto.NodeMetrics.inference_metrics:type_name -> proto.InferenceMetrics
-	31, // 16: proto.NodeMetrics.system_metrics:type_name -> proto.SystemMetrics
-	28, // 17: proto.ResourceMetrics.gpu_metrics:type_name -> proto.GPUMetrics
-	12, // 18: proto.NodeListResponse.nodes:type_name -> proto.NodeInfo
-	32, // 19: proto.NodeListResponse.cluster_metrics:type_name -> proto.ClusterMetrics
-	21, // 20: proto.ModelListResponse.models:type_name -> proto.ModelInfo
-	12, // 21: proto.ClusterUpdate.nodes:type_name -> proto.NodeInfo
-	21, // 22: proto.ClusterUpdate.models:type_name -> proto.ModelInfo
-	32, // 23: proto.ClusterUpdate.metrics:type_name -> proto.ClusterMetrics
-	41, // 24: proto.CommandRequest.options:type_name -> proto.CommandRequest.OptionsEntry
-	0,  // 25: proto.NodeService.RegisterNode:input_type -> proto.RegisterNodeRequest
-	4,  // 26: proto.NodeService.GetResources:input_type -> proto.GetResourcesRequest
-	6,  // 27: proto.NodeService.ProcessInference:input_type -> proto.InferenceRequest
-	8,  // 28: proto.NodeService.HealthCheck:input_type -> proto.HealthCheckRequest
-	10, // 29: proto.NodeService.GetPeers:input_type -> proto.GetPeersRequest
-	22, // 30: proto.NodeService.GetMetrics:input_type -> proto.GetMetricsRequest
-	24, // 31: proto.NodeService.StreamMetrics:input_type -> proto.StreamMetricsRequest
-	13, // 32: proto.DiscoveryService.DiscoverNodes:input_type -> proto.DiscoveryRequest
-	15, // 33: proto.DiscoveryService.RegisterWithCluster:input_type -> proto.ClusterJoinRequest
-	17, // 34: proto.DiscoveryService.LeaveCluster:input_type -> proto.ClusterLeaveRequest
-	19, // 35: proto.DiscoveryService.GetClusterInfo:input_type -> proto.ClusterInfoRequest
-	33, // 36: proto.TUIService.GetNodeList:input_type -> proto.NodeListRequest
-	35, // 37: proto.TUIService.GetModelList:input_type -> proto.ModelListRequest
-	37, // 38: proto.TUIService.StreamUpdates:input_type -> proto.UpdateStreamRequest
-	39, // 39: proto.TUIService.ExecuteCommand:input_type -> proto.CommandRequest
-	1,  // 40: proto.NodeService.RegisterNode:output_type -> proto.RegisterNodeResponse
-	5,  // 41: proto.NodeService.GetResources:output_type -> proto.GetResourcesResponse
-	7,  // 42: proto.NodeService.ProcessInference:output_type -> proto.InferenceResponse
-	9,  // 43: proto.NodeService.HealthCheck:output_type -> proto.HealthCheckResponse
-	11, // 44: proto.NodeService.GetPeers:output_type -> proto.GetPeersResponse
-	23, // 45: proto.NodeService.GetMetrics:output_type -> proto.GetMetricsResponse
-	25, // 46: proto.NodeService.StreamMetrics:output_type -> proto.MetricsUpdate
-	14, // 47: proto.DiscoveryService.DiscoverNodes:output_type -> proto.DiscoveryResponse
-	16, // 48: proto.DiscoveryService.RegisterWithCluster:output_type -> proto.ClusterJoinResponse
-	18, // 49: proto.DiscoveryService.LeaveCluster:output_type -> proto.ClusterLeaveResponse
-	20, // 50: proto.DiscoveryService.GetClusterInfo:output_type -> proto.ClusterInfoResponse
-	34, // 51: proto.TUIService.GetNodeList:output_type -> proto.NodeListResponse
-	36, // 52: proto.TUIService.GetModelList:output_type -> proto.ModelListResponse
-	38, // 53: proto.TUIService.StreamUpdates:output_type -> proto.ClusterUpdate
-	40, // 54: proto.TUIService.ExecuteCommand:output_type -> proto.CommandResponse
-	40, // [40:55] is the sub-list for method output_type
-	25, // [25:40] is the sub-list for method input_type
-	25, // [25:25] is the sub-list for extension type_name
-	25, // [25:25] is the sub-list for extension extendee
-	0,  // [0:25] is the sub-list for field type_name
+	26, // 1: proto.RegisterNodeResponse.existing_nodes:type_name -> proto.NodeInfo
+	3,  // 2: proto.ResourceInfo.gpus:type_name -> proto.GPUInfo
+	2,  // 3: proto.GetResourcesResponse.resources:type_name -> proto.ResourceInfo
+	8,  // 4: proto.InferenceResponse.stages:type_name -> proto.StageUsage
+	8,  // 5: proto.UsageRecord.nodes:type_name -> proto.StageUsage
+	11, // 6: proto.UsageResponse.records:type_name -> proto.UsageRecord
+	12, // 7: proto.UsageResponse.summaries:type_name -> proto.UsageSummary
+	16, // 8: proto.HealthCheckResponse.models:type_name -> proto.ModelReadiness
+	21, // 9: proto.LatencyMatrixResponse.entries:type_name -> proto.LatencyEntry
+	26, // 10: proto.GetPeersResponse.peers:type_name -> proto.NodeInfo
+	2,  // 11: proto.NodeInfo.resources:type_name -> proto.ResourceInfo
+	63, // 12: proto.NodeInfo.labels:type_name -> proto.NodeInfo.LabelsEntry
+	26, // 13: proto.DiscoveryResponse.discovered_nodes:type_name -> proto.NodeInfo
+	2,  // 14: proto.ClusterJoinRequest.resources:type_name -> proto.ResourceInfo
+	26, // 15: proto.ClusterJoinResponse.existing_nodes:type_name -> proto.NodeInfo
+	26, // 16: proto.ClusterInfoResponse.nodes:type_name -> proto.NodeInfo
+	36, // 17: proto.ClusterInfoResponse.models:type_name -> proto.ModelInfo
+	54, // 18: proto.ClusterInfoResponse.metrics:type_name -> proto.ClusterMetrics
+	35, // 19: proto.ClusterInfoResponse.replicas:type_name -> proto.ReplicaStats
+	48, // 20: proto.GetMetricsResponse.metrics:type_name -> proto.NodeMetrics
+	48, // 21: proto.MetricsUpdate.metrics:type_name -> proto.NodeMetrics
+	40, // 22: proto.MetricsHistoryResponse.samples:type_name -> proto.MetricsUpdate
+	44, // 23: proto.AlertsResponse.alerts:type_name -> proto.Alert
+	64, // 24: proto.ClusterEvent.attributes:type_name -> proto.ClusterEvent.AttributesEntry
+	49, // 25: proto.NodeMetrics.resource_metrics:type_name -> proto.ResourceMetrics
+	51, // 26: proto.NodeMetrics.network_metrics:type_name -> proto.NetworkMetrics
+	52, // 27: proto.NodeMetrics.inference_metrics:type_name -> proto.InferenceMetrics
+	53, // 28: proto.NodeMetrics.system_metrics:type_name -> proto.SystemMetrics
+	50, // 29: proto.ResourceMetrics.gpu_metrics:type_name -> proto.GPUMetrics
+	26, // 30: proto.NodeListResponse.nodes:type_name -> proto.NodeInfo
+	54, // 31: proto.NodeListResponse.cluster_metrics:type_name -> proto.ClusterMetrics
+	36, // 32: proto.ModelListResponse.models:type_name -> proto.ModelInfo
+	26, // 33: proto.ClusterUpdate.nodes:type_name -> proto.NodeInfo
+	36, // 34: proto.ClusterUpdate.models:type_name -> proto.ModelInfo
+	54, // 35: proto.ClusterUpdate.metrics:type_name -> proto.ClusterMetrics
+	65, // 36: proto.CommandRequest.options:type_name -> proto.CommandRequest.OptionsEntry
+	0,  // 37: proto.NodeService.RegisterNode:input_type -> proto.RegisterNodeRequest
+	4,  // 38: proto.NodeService.GetResources:input_type -> proto.GetResourcesRequest
+	6,  // 39: proto.NodeService.ProcessInference:input_type -> proto.InferenceRequest
+	14, // 40: proto.NodeService.HealthCheck:input_type -> proto.HealthCheckRequest
+	24, // 41: proto.NodeService.GetPeers:input_type -> proto.GetPeersRequest
+	37, // 42: proto.NodeService.GetMetrics:input_type -> proto.GetMetricsRequest
+	39, // 43: proto.NodeService.StreamMetrics:input_type -> proto.StreamMetricsRequest
+	41, // 44: proto.NodeService.GetMetricsHistory:input_type -> proto.MetricsHistoryRequest
+	17, // 45: proto.NodeService.GetVersion:input_type -> proto.GetVersionRequest
+	19, // 46: proto.NodeService.GetLatencyMatrix:input_type -> proto.LatencyMatrixRequest
+	22, // 47: proto.NodeService.ProbeLatency:input_type -> proto.ProbeRequest
+	9,  // 48: proto.NodeService.StreamActivations:input_type -> proto.ActivationFrame
+	10, // 49: proto.NodeService.GetUsage:input_type -> proto.UsageRequest
+	43, // 50: proto.NodeService.GetAlerts:input_type -> proto.AlertsRequest
+	46, // 51: proto.NodeService.StreamEvents:input_type -> proto.EventsRequest
+	27, // 52: proto.DiscoveryService.DiscoverNodes:input_type -> proto.DiscoveryRequest
+	29, // 53: proto.DiscoveryService.RegisterWithCluster:input_type -> proto.ClusterJoinRequest
+	31, // 54: proto.DiscoveryService.LeaveCluster:input_type -> proto.ClusterLeaveRequest
+	33, // 55: proto.DiscoveryService.GetClusterInfo:input_type -> proto.ClusterInfoRequest
+	55, // 56: proto.TUIService.GetNodeList:input_type -> proto.NodeListRequest
+	57, // 57: proto.TUIService.GetModelList:input_type -> proto.ModelListRequest
+	59, // 58: proto.TUIService.StreamUpdates:input_type -> proto.UpdateStreamRequest
+	61, // 59: proto.TUIService.ExecuteCommand:input_type -> proto.CommandRequest
+	1,  // 60: proto.NodeService.RegisterNode:output_type -> proto.RegisterNodeResponse
+	5,  // 61: proto.NodeService.GetResources:output_type -> proto.GetResourcesResponse
+	7,  // 62: proto.NodeService.ProcessInference:output_type -> proto.InferenceResponse
+	15, // 63: proto.NodeService.HealthCheck:output_type -> proto.HealthCheckResponse
+	25, // 64: proto.NodeService.GetPeers:output_type -> proto.GetPeersResponse
+	38, // 65: proto.NodeService.GetMetrics:output_type -> proto.GetMetricsResponse
+	40, // 66: proto.NodeService.StreamMetrics:output_type -> proto.MetricsUpdate
+	42, // 67: proto.NodeService.GetMetricsHistory:output_type -> proto.MetricsHistoryResponse
+	18, // 68: proto.NodeService.GetVersion:output_type -> proto.GetVersionResponse
+	20, // 69: proto.NodeService.GetLatencyMatrix:output_type -> proto.LatencyMatrixResponse
+	23, // 70: proto.NodeService.ProbeLatency:output_type -> proto.ProbeResponse
+	9,  // 71: proto.NodeService.StreamActivations:output_type -> proto.ActivationFrame
+	13, // 72: proto.NodeService.GetUsage:output_type -> proto.UsageResponse
+	45, // 73: proto.NodeService.GetAlerts:output_type -> proto.AlertsResponse
+	47, // 74: proto.NodeService.StreamEvents:output_type -> proto.ClusterEvent
+	28, // 75: proto.DiscoveryService.DiscoverNodes:output_type -> proto.DiscoveryResponse
+	30, // 76: proto.DiscoveryService.RegisterWithCluster:output_type -> proto.ClusterJoinResponse
+	32, // 77: proto.DiscoveryService.LeaveCluster:output_type -> proto.ClusterLeaveResponse
+	34, // 78: proto.DiscoveryService.GetClusterInfo:output_type -> proto.ClusterInfoResponse
+	56, // 79: proto.TUIService.GetNodeList:output_type -> proto.NodeListResponse
+	58, // 80: proto.TUIService.GetModelList:output_type -> proto.ModelListResponse
+	60, // 81: proto.TUIService.StreamUpdates:output_type -> proto.ClusterUpdate
+	62, // 82: proto.TUIService.ExecuteCommand:output_type -> proto.CommandResponse
+	60, // [60:83] is the sub-list for method output_type
+	37, // [37:60] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
 }
 
 func init() { file_proto_node_proto_init() }
@@ -2986,7 +5026,7 @@ func file_proto_node_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_node_proto_rawDesc), len(file_proto_node_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   42,
+			NumMessages:   66,
 			NumExtensions: 0,
 			NumServices:   3,
 		},
@@ -19,13 +19,21 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	NodeService_RegisterNode_FullMethodName     = "/proto.NodeService/RegisterNode"
-	NodeService_GetResources_FullMethodName     = "/proto.NodeService/GetResources"
-	NodeService_ProcessInference_FullMethodName = "/proto.NodeService/ProcessInference"
-	NodeService_HealthCheck_FullMethodName      = "/proto.NodeService/HealthCheck"
-	NodeService_GetPeers_FullMethodName         = "/proto.NodeService/GetPeers"
-	NodeService_GetMetrics_FullMethodName       = "/proto.NodeService/GetMetrics"
-	NodeService_StreamMetrics_FullMethodName    = "/proto.NodeService/StreamMetrics"
+	NodeService_RegisterNode_FullMethodName      = "/proto.NodeService/RegisterNode"
+	NodeService_GetResources_FullMethodName      = "/proto.NodeService/GetResources"
+	NodeService_ProcessInference_FullMethodName  = "/proto.NodeService/ProcessInference"
+	NodeService_HealthCheck_FullMethodName       = "/proto.NodeService/HealthCheck"
+	NodeService_GetPeers_FullMethodName          = "/proto.NodeService/GetPeers"
+	NodeService_GetMetrics_FullMethodName        = "/proto.NodeService/GetMetrics"
+	NodeService_StreamMetrics_FullMethodName     = "/proto.NodeService/StreamMetrics"
+	NodeService_GetMetricsHistory_FullMethodName = "/proto.NodeService/GetMetricsHistory"
+	NodeService_GetVersion_FullMethodName        = "/proto.NodeService/GetVersion"
+	NodeService_GetLatencyMatrix_FullMethodName  = "/proto.NodeService/GetLatencyMatrix"
+	NodeService_ProbeLatency_FullMethodName      = "/proto.NodeService/ProbeLatency"
+	NodeService_StreamActivations_FullMethodName = "/proto.NodeService/StreamActivations"
+	NodeService_GetUsage_FullMethodName          = "/proto.NodeService/GetUsage"
+	NodeService_GetAlerts_FullMethodName         = "/proto.NodeService/GetAlerts"
+	NodeService_StreamEvents_FullMethodName      = "/proto.NodeService/StreamEvents"
 )
 
 // NodeServiceClient is the client API for NodeService service.
@@ -41,6 +49,14 @@ type NodeServiceClient interface {
 	GetPeers(ctx context.Context, in *GetPeersRequest, opts ...grpc.CallOption) (*GetPeersResponse, error)
 	GetMetrics(ctx context.Context, in *GetMetricsRequest, opts ...grpc.CallOption) (*GetMetricsResponse, error)
 	StreamMetrics(ctx context.Context, in *StreamMetricsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MetricsUpdate], error)
+	GetMetricsHistory(ctx context.Context, in *MetricsHistoryRequest, opts ...grpc.CallOption) (*MetricsHistoryResponse, error)
+	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
+	GetLatencyMatrix(ctx context.Context, in *LatencyMatrixRequest, opts ...grpc.CallOption) (*LatencyMatrixResponse, error)
+	ProbeLatency(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeResponse, error)
+	StreamActivations(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ActivationFrame, ActivationFrame], error)
+	GetUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error)
+	GetAlerts(ctx context.Context, in *AlertsRequest, opts ...grpc.CallOption) (*AlertsResponse, error)
+	StreamEvents(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClusterEvent], error)
 }
 
 type nodeServiceClient struct {
@@ -130,6 +146,98 @@ func (c *nodeServiceClient) StreamMetrics(ctx context.Context, in *StreamMetrics
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type NodeService_StreamMetricsClient = grpc.ServerStreamingClient[MetricsUpdate]
 
+func (c *nodeServiceClient) GetMetricsHistory(ctx context.Context, in *MetricsHistoryRequest, opts ...grpc.CallOption) (*MetricsHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MetricsHistoryResponse)
+	err := c.cc.Invoke(ctx, NodeService_GetMetricsHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeServiceClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetVersionResponse)
+	err := c.cc.Invoke(ctx, NodeService_GetVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeServiceClient) GetLatencyMatrix(ctx context.Context, in *LatencyMatrixRequest, opts ...grpc.CallOption) (*LatencyMatrixResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LatencyMatrixResponse)
+	err := c.cc.Invoke(ctx, NodeService_GetLatencyMatrix_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeServiceClient) ProbeLatency(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProbeResponse)
+	err := c.cc.Invoke(ctx, NodeService_ProbeLatency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeServiceClient) StreamActivations(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ActivationFrame, ActivationFrame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NodeService_ServiceDesc.Streams[1], NodeService_StreamActivations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ActivationFrame, ActivationFrame]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeService_StreamActivationsClient = grpc.BidiStreamingClient[ActivationFrame, ActivationFrame]
+
+func (c *nodeServiceClient) GetUsage(ctx context.Context, in *UsageRequest, opts ...grpc.CallOption) (*UsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UsageResponse)
+	err := c.cc.Invoke(ctx, NodeService_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeServiceClient) GetAlerts(ctx context.Context, in *AlertsRequest, opts ...grpc.CallOption) (*AlertsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AlertsResponse)
+	err := c.cc.Invoke(ctx, NodeService_GetAlerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeServiceClient) StreamEvents(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClusterEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NodeService_ServiceDesc.Streams[2], NodeService_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, ClusterEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeService_StreamEventsClient = grpc.ServerStreamingClient[ClusterEvent]
+
 // NodeServiceServer is the server API for NodeService service.
 // All implementations must embed UnimplementedNodeServiceServer
 // for forward compatibility.
@@ -143,6 +251,14 @@ type NodeServiceServer interface {
 	GetPeers(context.Context, *GetPeersRequest) (*GetPeersResponse, error)
 	GetMetrics(context.Context, *GetMetricsRequest) (*GetMetricsResponse, error)
 	StreamMetrics(*StreamMetricsRequest, grpc.ServerStreamingServer[MetricsUpdate]) error
+	GetMetricsHistory(context.Context, *MetricsHistoryRequest) (*MetricsHistoryResponse, error)
+	GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error)
+	GetLatencyMatrix(context.Context, *LatencyMatrixRequest) (*LatencyMatrixResponse, error)
+	ProbeLatency(context.Context, *ProbeRequest) (*ProbeResponse, error)
+	StreamActivations(grpc.BidiStreamingServer[ActivationFrame, ActivationFrame]) error
+	GetUsage(context.Context, *UsageRequest) (*UsageResponse, error)
+	GetAlerts(context.Context, *AlertsRequest) (*AlertsResponse, error)
+	StreamEvents(*EventsRequest, grpc.ServerStreamingServer[ClusterEvent]) error
 	mustEmbedUnimplementedNodeServiceServer()
 }
 
@@ -174,6 +290,30 @@ func (UnimplementedNodeServiceServer) GetMetrics(context.Context, *GetMetricsReq
 func (UnimplementedNodeServiceServer) StreamMetrics(*StreamMetricsRequest, grpc.ServerStreamingServer[MetricsUpdate]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamMetrics not implemented")
 }
+func (UnimplementedNodeServiceServer) GetMetricsHistory(context.Context, *MetricsHistoryRequest) (*MetricsHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetricsHistory not implemented")
+}
+func (UnimplementedNodeServiceServer) GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
+}
+func (UnimplementedNodeServiceServer) GetLatencyMatrix(context.Context, *LatencyMatrixRequest) (*LatencyMatrixResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatencyMatrix not implemented")
+}
+func (UnimplementedNodeServiceServer) ProbeLatency(context.Context, *ProbeRequest) (*ProbeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProbeLatency not implemented")
+}
+func (UnimplementedNodeServiceServer) StreamActivations(grpc.BidiStreamingServer[ActivationFrame, ActivationFrame]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamActivations not implemented")
+}
+func (UnimplementedNodeServiceServer) GetUsage(context.Context, *UsageRequest) (*UsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedNodeServiceServer) GetAlerts(context.Context, *AlertsRequest) (*AlertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAlerts not implemented")
+}
+func (UnimplementedNodeServiceServer) StreamEvents(*EventsRequest, grpc.ServerStreamingServer[ClusterEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
 func (UnimplementedNodeServiceServer) mustEmbedUnimplementedNodeServiceServer() {}
 func (UnimplementedNodeServiceServer) testEmbeddedByValue()                     {}
 
@@ -314,6 +454,132 @@ func _NodeService_StreamMetrics_Handler(srv interface{}, stream grpc.ServerStrea
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type NodeService_StreamMetricsServer = grpc.ServerStreamingServer[MetricsUpdate]
 
+func _NodeService_GetMetricsHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricsHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).GetMetricsHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_GetMetricsHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).GetMetricsHistory(ctx, req.(*MetricsHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeService_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_GetVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).GetVersion(ctx, req.(*GetVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeService_GetLatencyMatrix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LatencyMatrixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).GetLatencyMatrix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_GetLatencyMatrix_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).GetLatencyMatrix(ctx, req.(*LatencyMatrixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeService_ProbeLatency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).ProbeLatency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_ProbeLatency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).ProbeLatency(ctx, req.(*ProbeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeService_StreamActivations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NodeServiceServer).StreamActivations(&grpc.GenericServerStream[ActivationFrame, ActivationFrame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeService_StreamActivationsServer = grpc.BidiStreamingServer[ActivationFrame, ActivationFrame]
+
+func _NodeService_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).GetUsage(ctx, req.(*UsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeService_GetAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServiceServer).GetAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeService_GetAlerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServiceServer).GetAlerts(ctx, req.(*AlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeService_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServiceServer).StreamEvents(m, &grpc.GenericServerStream[EventsRequest, ClusterEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeService_StreamEventsServer = grpc.ServerStreamingServer[ClusterEvent]
+
 // NodeService_ServiceDesc is the grpc.ServiceDesc for NodeService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -345,6 +611,30 @@ var NodeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMetrics",
 			Handler:    _NodeService_GetMetrics_Handler,
 		},
+		{
+			MethodName: "GetMetricsHistory",
+			Handler:    _NodeService_GetMetricsHistory_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _NodeService_GetVersion_Handler,
+		},
+		{
+			MethodName: "GetLatencyMatrix",
+			Handler:    _NodeService_GetLatencyMatrix_Handler,
+		},
+		{
+			MethodName: "ProbeLatency",
+			Handler:    _NodeService_ProbeLatency_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _NodeService_GetUsage_Handler,
+		},
+		{
+			MethodName: "GetAlerts",
+			Handler:    _NodeService_GetAlerts_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -352,6 +642,17 @@ var NodeService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _NodeService_StreamMetrics_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamActivations",
+			Handler:       _NodeService_StreamActivations_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _NodeService_StreamEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/node.proto",
 }
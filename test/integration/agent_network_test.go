@@ -76,8 +76,8 @@ func TestAgentNetworkIntegration(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// Subscribe to resource updates on node2
-	ch2 := make(chan models.ResourceInfo, 10)
-	broadcaster2.Subscribe(ch2)
+	ch2, unsubscribe := broadcaster2.Subscribe(ctx, agent.SubscribeOptions{Buffer: 10})
+	defer unsubscribe()
 
 	// Update resources on node1
 	resources := models.ResourceInfo{